@@ -0,0 +1,224 @@
+// Package dbimportertest runs a PostgreSQL or MySQL database in a Docker container for tests, so
+// that a project can check that its CSV fixtures import into its schema:
+//
+//	func TestFixtures(t *testing.T) {
+//		db := dbimportertest.Import(t, dbimportertest.Postgres, "./testdata/fixtures",
+//			dbimportertest.WithSchemaFiles("./migrations/schema.sql"))
+//		var n int
+//		if err := db.QueryRow("SELECT count(*) FROM users").Scan(&n); err != nil {
+//			t.Fatal(err)
+//		}
+//	}
+//
+// The containers are started with testcontainers-go, which needs a Docker daemon.
+package dbimportertest
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/k-wa-wa/db-auto-importer/dbimport"
+	"github.com/testcontainers/testcontainers-go/modules/mysql"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+)
+
+// Engine is a database that can be started in a container.
+type Engine string
+
+const (
+	Postgres Engine = "postgres"
+	MySQL    Engine = "mysql"
+)
+
+// The database and credentials of the containers.
+const (
+	databaseName = "database"
+	username     = "username"
+	password     = "password"
+)
+
+// defaultImages are the images started unless WithImage is given.
+var defaultImages = map[Engine]string{
+	Postgres: "postgres:16-alpine",
+	MySQL:    "mysql:8",
+}
+
+// options are the settings of Start; see Option.
+type options struct {
+	image       string
+	schemaFiles []string
+	config      func(cfg *dbimport.Config)
+}
+
+// Option configures the database started by Start, New or Import.
+type Option func(*options)
+
+// WithImage starts the given Docker image, e.g. "postgres:15", instead of the default one.
+func WithImage(image string) Option {
+	return func(o *options) { o.image = image }
+}
+
+// WithSchemaFiles runs the given SQL files, in order, when the database starts, e.g. to create
+// the tables the fixtures are imported into.
+func WithSchemaFiles(paths ...string) Option {
+	return func(o *options) { o.schemaFiles = append(o.schemaFiles, paths...) }
+}
+
+// WithConfig changes the settings of the imports of Container.Import, e.g. to set a seed or a
+// config file. The connection settings are filled in before fn is called.
+func WithConfig(fn func(cfg *dbimport.Config)) Option {
+	return func(o *options) { o.config = fn }
+}
+
+// Container is a database running in a container.
+type Container struct {
+	// Engine is the database type, usable as dbimport.Config.DBType.
+	Engine Engine
+	// ConnStr is the connection string of the database.
+	ConnStr string
+	// Schema is the schema the fixtures are imported into: "public" for PostgreSQL and the
+	// database for MySQL.
+	Schema string
+
+	db        *sql.DB
+	config    func(cfg *dbimport.Config)
+	terminate func(ctx context.Context) error
+}
+
+// Start starts a database of engine, runs the schema files and connects to it. Call Terminate
+// when it is no longer needed. Tests should rather call New, which does so when they end.
+func Start(ctx context.Context, engine Engine, opts ...Option) (*Container, error) {
+	o := options{image: defaultImages[engine]}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	c := &Container{Engine: engine, config: o.config}
+
+	switch engine {
+	case Postgres:
+		container, err := postgres.Run(ctx, o.image,
+			postgres.WithDatabase(databaseName),
+			postgres.WithUsername(username),
+			postgres.WithPassword(password),
+			postgres.BasicWaitStrategies(),
+			postgres.WithOrderedInitScripts(o.schemaFiles...),
+		)
+		if container != nil {
+			c.terminate = func(ctx context.Context) error { return container.Terminate(ctx) }
+		}
+		if err != nil {
+			return nil, c.fail(ctx, fmt.Errorf("failed to start PostgreSQL container: %w", err))
+		}
+		if c.ConnStr, err = container.ConnectionString(ctx, "sslmode=disable"); err != nil {
+			return nil, c.fail(ctx, err)
+		}
+		c.Schema = "public"
+	case MySQL:
+		container, err := mysql.Run(ctx, o.image,
+			mysql.WithDatabase(databaseName),
+			mysql.WithUsername(username),
+			mysql.WithPassword(password),
+			mysql.WithScripts(o.schemaFiles...),
+		)
+		if container != nil {
+			c.terminate = func(ctx context.Context) error { return container.Terminate(ctx) }
+		}
+		if err != nil {
+			return nil, c.fail(ctx, fmt.Errorf("failed to start MySQL container: %w", err))
+		}
+		if c.ConnStr, err = container.ConnectionString(ctx); err != nil {
+			return nil, c.fail(ctx, err)
+		}
+		c.Schema = databaseName
+	default:
+		return nil, fmt.Errorf("unsupported engine %q (expected %q or %q)", engine, Postgres, MySQL)
+	}
+
+	db, err := sql.Open(string(engine), c.ConnStr)
+	if err != nil {
+		return nil, c.fail(ctx, err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, c.fail(ctx, fmt.Errorf("failed to connect to the %s container: %w", engine, err))
+	}
+	c.db = db
+	return c, nil
+}
+
+// fail terminates the container of a Start that failed with err.
+func (c *Container) fail(ctx context.Context, err error) error {
+	if c.terminate != nil {
+		c.terminate(ctx)
+	}
+	return err
+}
+
+// New starts a database of engine like Start and terminates it when the test ends. It stops the
+// test if the database cannot be started.
+func New(t testing.TB, engine Engine, opts ...Option) *Container {
+	t.Helper()
+	c, err := Start(context.Background(), engine, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := c.Terminate(context.Background()); err != nil {
+			t.Error(err)
+		}
+	})
+	return c
+}
+
+// Import starts a database of engine like New, imports the CSV files of csvDir into it and
+// returns the connection to it. It stops the test if the database cannot be started or the import
+// fails, including if any record is rejected.
+func Import(t testing.TB, engine Engine, csvDir string, opts ...Option) *sql.DB {
+	t.Helper()
+	c := New(t, engine, opts...)
+	if _, err := c.Import(context.Background(), csvDir); err != nil {
+		t.Fatal(err)
+	}
+	return c.DB()
+}
+
+// DB returns the connection to the database. It is closed by Terminate.
+func (c *Container) DB() *sql.DB {
+	return c.db
+}
+
+// Config returns the settings Import uses: the connection to the database, CSV files with a
+// header row, and the changes of WithConfig.
+func (c *Container) Config() dbimport.Config {
+	cfg := dbimport.Config{
+		DBType:       string(c.Engine),
+		DBConnStr:    c.ConnStr,
+		DBSchemaName: c.Schema,
+		HasHeader:    true,
+	}
+	if c.config != nil {
+		c.config(&cfg)
+	}
+	return cfg
+}
+
+// Import imports the CSV files of csvDir into the database with the settings of Config, like
+// dbimport.Importer.ImportDir: rejected records are listed in the result, not returned as an error.
+func (c *Container) Import(ctx context.Context, csvDir string) (*dbimport.ImportResult, error) {
+	imp, err := dbimport.Open(ctx, c.Config())
+	if err != nil {
+		return nil, err
+	}
+	defer imp.Close()
+	return imp.ImportDir(ctx, csvDir)
+}
+
+// Terminate closes the connection and removes the container.
+func (c *Container) Terminate(ctx context.Context) error {
+	if c.db != nil {
+		c.db.Close()
+	}
+	return c.terminate(ctx)
+}
@@ -0,0 +1,38 @@
+package dbimportertest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/k-wa-wa/db-auto-importer/dbimport"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Start(t *testing.T) {
+	t.Run("対応していないデータベースはコンテナを起動せずにエラーになること", func(t *testing.T) {
+		_, err := Start(context.Background(), Engine("sqlite"))
+		assert.ErrorContains(t, err, "unsupported engine")
+	})
+}
+
+func Test_Container_Config(t *testing.T) {
+	c := &Container{Engine: MySQL, ConnStr: "username:password@tcp(localhost:3306)/database", Schema: "database"}
+
+	t.Run("コンテナへの接続設定が入ること", func(t *testing.T) {
+		cfg := c.Config()
+		assert.Equal(t, "mysql", cfg.DBType)
+		assert.Equal(t, c.ConnStr, cfg.DBConnStr)
+		assert.Equal(t, "database", cfg.DBSchemaName)
+		assert.True(t, cfg.HasHeader)
+	})
+
+	t.Run("WithConfigで設定を変更できること", func(t *testing.T) {
+		var o options
+		WithConfig(func(cfg *dbimport.Config) { cfg.HasHeader = false; cfg.ConfigFile = "fixtures.yaml" })(&o)
+		c.config = o.config
+		cfg := c.Config()
+		assert.False(t, cfg.HasHeader)
+		assert.Equal(t, "fixtures.yaml", cfg.ConfigFile)
+		assert.Equal(t, c.ConnStr, cfg.DBConnStr)
+	})
+}
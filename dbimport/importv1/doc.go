@@ -0,0 +1,5 @@
+// Package importv1 contains the gRPC client and server code of the ImportService, generated
+// from proto/dbimport/v1/import.proto. Run the service with the command's serve subcommand.
+package importv1
+
+//go:generate protoc -I ../../proto --go_out=. --go_opt=module=github.com/k-wa-wa/db-auto-importer/dbimport/importv1 --go-grpc_out=. --go-grpc_opt=module=github.com/k-wa-wa/db-auto-importer/dbimport/importv1 dbimport/v1/import.proto
@@ -0,0 +1,853 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.7
+// 	protoc        (unknown)
+// source: dbimport/v1/import.proto
+
+package importv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type JobStatus_State int32
+
+const (
+	JobStatus_STATE_UNSPECIFIED JobStatus_State = 0
+	// Waiting for another import to finish.
+	JobStatus_STATE_QUEUED    JobStatus_State = 1
+	JobStatus_STATE_RUNNING   JobStatus_State = 2
+	JobStatus_STATE_SUCCEEDED JobStatus_State = 3
+	// The import stopped with an error, or some rows were rejected.
+	JobStatus_STATE_FAILED JobStatus_State = 4
+)
+
+// Enum value maps for JobStatus_State.
+var (
+	JobStatus_State_name = map[int32]string{
+		0: "STATE_UNSPECIFIED",
+		1: "STATE_QUEUED",
+		2: "STATE_RUNNING",
+		3: "STATE_SUCCEEDED",
+		4: "STATE_FAILED",
+	}
+	JobStatus_State_value = map[string]int32{
+		"STATE_UNSPECIFIED": 0,
+		"STATE_QUEUED":      1,
+		"STATE_RUNNING":     2,
+		"STATE_SUCCEEDED":   3,
+		"STATE_FAILED":      4,
+	}
+)
+
+func (x JobStatus_State) Enum() *JobStatus_State {
+	p := new(JobStatus_State)
+	*p = x
+	return p
+}
+
+func (x JobStatus_State) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (JobStatus_State) Descriptor() protoreflect.EnumDescriptor {
+	return file_dbimport_v1_import_proto_enumTypes[0].Descriptor()
+}
+
+func (JobStatus_State) Type() protoreflect.EnumType {
+	return &file_dbimport_v1_import_proto_enumTypes[0]
+}
+
+func (x JobStatus_State) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use JobStatus_State.Descriptor instead.
+func (JobStatus_State) EnumDescriptor() ([]byte, []int) {
+	return file_dbimport_v1_import_proto_rawDescGZIP(), []int{9, 0}
+}
+
+type ImportTableRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*ImportTableRequest_Header
+	//	*ImportTableRequest_Chunk
+	Payload       isImportTableRequest_Payload `protobuf_oneof:"payload"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImportTableRequest) Reset() {
+	*x = ImportTableRequest{}
+	mi := &file_dbimport_v1_import_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportTableRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportTableRequest) ProtoMessage() {}
+
+func (x *ImportTableRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_dbimport_v1_import_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportTableRequest.ProtoReflect.Descriptor instead.
+func (*ImportTableRequest) Descriptor() ([]byte, []int) {
+	return file_dbimport_v1_import_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ImportTableRequest) GetPayload() isImportTableRequest_Payload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *ImportTableRequest) GetHeader() *ImportTableHeader {
+	if x != nil {
+		if x, ok := x.Payload.(*ImportTableRequest_Header); ok {
+			return x.Header
+		}
+	}
+	return nil
+}
+
+func (x *ImportTableRequest) GetChunk() []byte {
+	if x != nil {
+		if x, ok := x.Payload.(*ImportTableRequest_Chunk); ok {
+			return x.Chunk
+		}
+	}
+	return nil
+}
+
+type isImportTableRequest_Payload interface {
+	isImportTableRequest_Payload()
+}
+
+type ImportTableRequest_Header struct {
+	Header *ImportTableHeader `protobuf:"bytes,1,opt,name=header,proto3,oneof"`
+}
+
+type ImportTableRequest_Chunk struct {
+	Chunk []byte `protobuf:"bytes,2,opt,name=chunk,proto3,oneof"`
+}
+
+func (*ImportTableRequest_Header) isImportTableRequest_Payload() {}
+
+func (*ImportTableRequest_Chunk) isImportTableRequest_Payload() {}
+
+type ImportTableHeader struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Table is the name of the table to import into.
+	Table string `protobuf:"bytes,1,opt,name=table,proto3" json:"table,omitempty"`
+	// HasHeader tells whether the CSV data starts with a header row. Defaults to the server's --header.
+	HasHeader *bool `protobuf:"varint,2,opt,name=has_header,json=hasHeader,proto3,oneof" json:"has_header,omitempty"`
+	// JobId identifies the import for GetJobStatus. The server assigns one if empty.
+	JobId         string `protobuf:"bytes,3,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImportTableHeader) Reset() {
+	*x = ImportTableHeader{}
+	mi := &file_dbimport_v1_import_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportTableHeader) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportTableHeader) ProtoMessage() {}
+
+func (x *ImportTableHeader) ProtoReflect() protoreflect.Message {
+	mi := &file_dbimport_v1_import_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportTableHeader.ProtoReflect.Descriptor instead.
+func (*ImportTableHeader) Descriptor() ([]byte, []int) {
+	return file_dbimport_v1_import_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ImportTableHeader) GetTable() string {
+	if x != nil {
+		return x.Table
+	}
+	return ""
+}
+
+func (x *ImportTableHeader) GetHasHeader() bool {
+	if x != nil && x.HasHeader != nil {
+		return *x.HasHeader
+	}
+	return false
+}
+
+func (x *ImportTableHeader) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+type ImportTableResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        *JobStatus             `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImportTableResponse) Reset() {
+	*x = ImportTableResponse{}
+	mi := &file_dbimport_v1_import_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportTableResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportTableResponse) ProtoMessage() {}
+
+func (x *ImportTableResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_dbimport_v1_import_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportTableResponse.ProtoReflect.Descriptor instead.
+func (*ImportTableResponse) Descriptor() ([]byte, []int) {
+	return file_dbimport_v1_import_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ImportTableResponse) GetStatus() *JobStatus {
+	if x != nil {
+		return x.Status
+	}
+	return nil
+}
+
+type GetSchemaRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSchemaRequest) Reset() {
+	*x = GetSchemaRequest{}
+	mi := &file_dbimport_v1_import_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSchemaRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSchemaRequest) ProtoMessage() {}
+
+func (x *GetSchemaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_dbimport_v1_import_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSchemaRequest.ProtoReflect.Descriptor instead.
+func (*GetSchemaRequest) Descriptor() ([]byte, []int) {
+	return file_dbimport_v1_import_proto_rawDescGZIP(), []int{3}
+}
+
+type GetSchemaResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tables        []*Table               `protobuf:"bytes,1,rep,name=tables,proto3" json:"tables,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSchemaResponse) Reset() {
+	*x = GetSchemaResponse{}
+	mi := &file_dbimport_v1_import_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSchemaResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSchemaResponse) ProtoMessage() {}
+
+func (x *GetSchemaResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_dbimport_v1_import_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSchemaResponse.ProtoReflect.Descriptor instead.
+func (*GetSchemaResponse) Descriptor() ([]byte, []int) {
+	return file_dbimport_v1_import_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetSchemaResponse) GetTables() []*Table {
+	if x != nil {
+		return x.Tables
+	}
+	return nil
+}
+
+type Table struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Columns       []*Column              `protobuf:"bytes,2,rep,name=columns,proto3" json:"columns,omitempty"`
+	PrimaryKey    []string               `protobuf:"bytes,3,rep,name=primary_key,json=primaryKey,proto3" json:"primary_key,omitempty"`
+	ForeignKeys   []*ForeignKey          `protobuf:"bytes,4,rep,name=foreign_keys,json=foreignKeys,proto3" json:"foreign_keys,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Table) Reset() {
+	*x = Table{}
+	mi := &file_dbimport_v1_import_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Table) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Table) ProtoMessage() {}
+
+func (x *Table) ProtoReflect() protoreflect.Message {
+	mi := &file_dbimport_v1_import_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Table.ProtoReflect.Descriptor instead.
+func (*Table) Descriptor() ([]byte, []int) {
+	return file_dbimport_v1_import_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Table) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Table) GetColumns() []*Column {
+	if x != nil {
+		return x.Columns
+	}
+	return nil
+}
+
+func (x *Table) GetPrimaryKey() []string {
+	if x != nil {
+		return x.PrimaryKey
+	}
+	return nil
+}
+
+func (x *Table) GetForeignKeys() []*ForeignKey {
+	if x != nil {
+		return x.ForeignKeys
+	}
+	return nil
+}
+
+type Column struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// DataType is one of STRING, INTEGER, FLOAT, BOOLEAN, DATE, TIMESTAMP or UNKNOWN.
+	DataType      string `protobuf:"bytes,2,opt,name=data_type,json=dataType,proto3" json:"data_type,omitempty"`
+	Nullable      bool   `protobuf:"varint,3,opt,name=nullable,proto3" json:"nullable,omitempty"`
+	AutoIncrement bool   `protobuf:"varint,4,opt,name=auto_increment,json=autoIncrement,proto3" json:"auto_increment,omitempty"`
+	Generated     bool   `protobuf:"varint,5,opt,name=generated,proto3" json:"generated,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Column) Reset() {
+	*x = Column{}
+	mi := &file_dbimport_v1_import_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Column) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Column) ProtoMessage() {}
+
+func (x *Column) ProtoReflect() protoreflect.Message {
+	mi := &file_dbimport_v1_import_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Column.ProtoReflect.Descriptor instead.
+func (*Column) Descriptor() ([]byte, []int) {
+	return file_dbimport_v1_import_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Column) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Column) GetDataType() string {
+	if x != nil {
+		return x.DataType
+	}
+	return ""
+}
+
+func (x *Column) GetNullable() bool {
+	if x != nil {
+		return x.Nullable
+	}
+	return false
+}
+
+func (x *Column) GetAutoIncrement() bool {
+	if x != nil {
+		return x.AutoIncrement
+	}
+	return false
+}
+
+func (x *Column) GetGenerated() bool {
+	if x != nil {
+		return x.Generated
+	}
+	return false
+}
+
+type ForeignKey struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Columns       []string               `protobuf:"bytes,2,rep,name=columns,proto3" json:"columns,omitempty"`
+	ParentTable   string                 `protobuf:"bytes,3,opt,name=parent_table,json=parentTable,proto3" json:"parent_table,omitempty"`
+	ParentColumns []string               `protobuf:"bytes,4,rep,name=parent_columns,json=parentColumns,proto3" json:"parent_columns,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ForeignKey) Reset() {
+	*x = ForeignKey{}
+	mi := &file_dbimport_v1_import_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ForeignKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ForeignKey) ProtoMessage() {}
+
+func (x *ForeignKey) ProtoReflect() protoreflect.Message {
+	mi := &file_dbimport_v1_import_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ForeignKey.ProtoReflect.Descriptor instead.
+func (*ForeignKey) Descriptor() ([]byte, []int) {
+	return file_dbimport_v1_import_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ForeignKey) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ForeignKey) GetColumns() []string {
+	if x != nil {
+		return x.Columns
+	}
+	return nil
+}
+
+func (x *ForeignKey) GetParentTable() string {
+	if x != nil {
+		return x.ParentTable
+	}
+	return ""
+}
+
+func (x *ForeignKey) GetParentColumns() []string {
+	if x != nil {
+		return x.ParentColumns
+	}
+	return nil
+}
+
+type GetJobStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	JobId         string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetJobStatusRequest) Reset() {
+	*x = GetJobStatusRequest{}
+	mi := &file_dbimport_v1_import_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetJobStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetJobStatusRequest) ProtoMessage() {}
+
+func (x *GetJobStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_dbimport_v1_import_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetJobStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetJobStatusRequest) Descriptor() ([]byte, []int) {
+	return file_dbimport_v1_import_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetJobStatusRequest) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+type JobStatus struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	JobId        string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	Table        string                 `protobuf:"bytes,2,opt,name=table,proto3" json:"table,omitempty"`
+	State        JobStatus_State        `protobuf:"varint,3,opt,name=state,proto3,enum=dbimport.v1.JobStatus_State" json:"state,omitempty"`
+	RowsRead     int64                  `protobuf:"varint,4,opt,name=rows_read,json=rowsRead,proto3" json:"rows_read,omitempty"`
+	RowsInserted int64                  `protobuf:"varint,5,opt,name=rows_inserted,json=rowsInserted,proto3" json:"rows_inserted,omitempty"`
+	RowsSkipped  int64                  `protobuf:"varint,6,opt,name=rows_skipped,json=rowsSkipped,proto3" json:"rows_skipped,omitempty"`
+	RowsRejected int64                  `protobuf:"varint,7,opt,name=rows_rejected,json=rowsRejected,proto3" json:"rows_rejected,omitempty"`
+	// ParentsCreated counts the parent records created automatically in all tables.
+	ParentsCreated int64 `protobuf:"varint,8,opt,name=parents_created,json=parentsCreated,proto3" json:"parents_created,omitempty"`
+	// Error describes why the import failed.
+	Error         string `protobuf:"bytes,9,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JobStatus) Reset() {
+	*x = JobStatus{}
+	mi := &file_dbimport_v1_import_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobStatus) ProtoMessage() {}
+
+func (x *JobStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_dbimport_v1_import_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobStatus.ProtoReflect.Descriptor instead.
+func (*JobStatus) Descriptor() ([]byte, []int) {
+	return file_dbimport_v1_import_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *JobStatus) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *JobStatus) GetTable() string {
+	if x != nil {
+		return x.Table
+	}
+	return ""
+}
+
+func (x *JobStatus) GetState() JobStatus_State {
+	if x != nil {
+		return x.State
+	}
+	return JobStatus_STATE_UNSPECIFIED
+}
+
+func (x *JobStatus) GetRowsRead() int64 {
+	if x != nil {
+		return x.RowsRead
+	}
+	return 0
+}
+
+func (x *JobStatus) GetRowsInserted() int64 {
+	if x != nil {
+		return x.RowsInserted
+	}
+	return 0
+}
+
+func (x *JobStatus) GetRowsSkipped() int64 {
+	if x != nil {
+		return x.RowsSkipped
+	}
+	return 0
+}
+
+func (x *JobStatus) GetRowsRejected() int64 {
+	if x != nil {
+		return x.RowsRejected
+	}
+	return 0
+}
+
+func (x *JobStatus) GetParentsCreated() int64 {
+	if x != nil {
+		return x.ParentsCreated
+	}
+	return 0
+}
+
+func (x *JobStatus) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+var File_dbimport_v1_import_proto protoreflect.FileDescriptor
+
+const file_dbimport_v1_import_proto_rawDesc = "" +
+	"\n" +
+	"\x18dbimport/v1/import.proto\x12\vdbimport.v1\"q\n" +
+	"\x12ImportTableRequest\x128\n" +
+	"\x06header\x18\x01 \x01(\v2\x1e.dbimport.v1.ImportTableHeaderH\x00R\x06header\x12\x16\n" +
+	"\x05chunk\x18\x02 \x01(\fH\x00R\x05chunkB\t\n" +
+	"\apayload\"s\n" +
+	"\x11ImportTableHeader\x12\x14\n" +
+	"\x05table\x18\x01 \x01(\tR\x05table\x12\"\n" +
+	"\n" +
+	"has_header\x18\x02 \x01(\bH\x00R\thasHeader\x88\x01\x01\x12\x15\n" +
+	"\x06job_id\x18\x03 \x01(\tR\x05jobIdB\r\n" +
+	"\v_has_header\"E\n" +
+	"\x13ImportTableResponse\x12.\n" +
+	"\x06status\x18\x01 \x01(\v2\x16.dbimport.v1.JobStatusR\x06status\"\x12\n" +
+	"\x10GetSchemaRequest\"?\n" +
+	"\x11GetSchemaResponse\x12*\n" +
+	"\x06tables\x18\x01 \x03(\v2\x12.dbimport.v1.TableR\x06tables\"\xa7\x01\n" +
+	"\x05Table\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12-\n" +
+	"\acolumns\x18\x02 \x03(\v2\x13.dbimport.v1.ColumnR\acolumns\x12\x1f\n" +
+	"\vprimary_key\x18\x03 \x03(\tR\n" +
+	"primaryKey\x12:\n" +
+	"\fforeign_keys\x18\x04 \x03(\v2\x17.dbimport.v1.ForeignKeyR\vforeignKeys\"\x9a\x01\n" +
+	"\x06Column\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1b\n" +
+	"\tdata_type\x18\x02 \x01(\tR\bdataType\x12\x1a\n" +
+	"\bnullable\x18\x03 \x01(\bR\bnullable\x12%\n" +
+	"\x0eauto_increment\x18\x04 \x01(\bR\rautoIncrement\x12\x1c\n" +
+	"\tgenerated\x18\x05 \x01(\bR\tgenerated\"\x84\x01\n" +
+	"\n" +
+	"ForeignKey\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\acolumns\x18\x02 \x03(\tR\acolumns\x12!\n" +
+	"\fparent_table\x18\x03 \x01(\tR\vparentTable\x12%\n" +
+	"\x0eparent_columns\x18\x04 \x03(\tR\rparentColumns\",\n" +
+	"\x13GetJobStatusRequest\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\"\xa1\x03\n" +
+	"\tJobStatus\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\x12\x14\n" +
+	"\x05table\x18\x02 \x01(\tR\x05table\x122\n" +
+	"\x05state\x18\x03 \x01(\x0e2\x1c.dbimport.v1.JobStatus.StateR\x05state\x12\x1b\n" +
+	"\trows_read\x18\x04 \x01(\x03R\browsRead\x12#\n" +
+	"\rrows_inserted\x18\x05 \x01(\x03R\frowsInserted\x12!\n" +
+	"\frows_skipped\x18\x06 \x01(\x03R\vrowsSkipped\x12#\n" +
+	"\rrows_rejected\x18\a \x01(\x03R\frowsRejected\x12'\n" +
+	"\x0fparents_created\x18\b \x01(\x03R\x0eparentsCreated\x12\x14\n" +
+	"\x05error\x18\t \x01(\tR\x05error\"j\n" +
+	"\x05State\x12\x15\n" +
+	"\x11STATE_UNSPECIFIED\x10\x00\x12\x10\n" +
+	"\fSTATE_QUEUED\x10\x01\x12\x11\n" +
+	"\rSTATE_RUNNING\x10\x02\x12\x13\n" +
+	"\x0fSTATE_SUCCEEDED\x10\x03\x12\x10\n" +
+	"\fSTATE_FAILED\x10\x042\xf9\x01\n" +
+	"\rImportService\x12R\n" +
+	"\vImportTable\x12\x1f.dbimport.v1.ImportTableRequest\x1a .dbimport.v1.ImportTableResponse(\x01\x12J\n" +
+	"\tGetSchema\x12\x1d.dbimport.v1.GetSchemaRequest\x1a\x1e.dbimport.v1.GetSchemaResponse\x12H\n" +
+	"\fGetJobStatus\x12 .dbimport.v1.GetJobStatusRequest\x1a\x16.dbimport.v1.JobStatusB@Z>github.com/k-wa-wa/db-auto-importer/dbimport/importv1;importv1b\x06proto3"
+
+var (
+	file_dbimport_v1_import_proto_rawDescOnce sync.Once
+	file_dbimport_v1_import_proto_rawDescData []byte
+)
+
+func file_dbimport_v1_import_proto_rawDescGZIP() []byte {
+	file_dbimport_v1_import_proto_rawDescOnce.Do(func() {
+		file_dbimport_v1_import_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_dbimport_v1_import_proto_rawDesc), len(file_dbimport_v1_import_proto_rawDesc)))
+	})
+	return file_dbimport_v1_import_proto_rawDescData
+}
+
+var file_dbimport_v1_import_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_dbimport_v1_import_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_dbimport_v1_import_proto_goTypes = []any{
+	(JobStatus_State)(0),        // 0: dbimport.v1.JobStatus.State
+	(*ImportTableRequest)(nil),  // 1: dbimport.v1.ImportTableRequest
+	(*ImportTableHeader)(nil),   // 2: dbimport.v1.ImportTableHeader
+	(*ImportTableResponse)(nil), // 3: dbimport.v1.ImportTableResponse
+	(*GetSchemaRequest)(nil),    // 4: dbimport.v1.GetSchemaRequest
+	(*GetSchemaResponse)(nil),   // 5: dbimport.v1.GetSchemaResponse
+	(*Table)(nil),               // 6: dbimport.v1.Table
+	(*Column)(nil),              // 7: dbimport.v1.Column
+	(*ForeignKey)(nil),          // 8: dbimport.v1.ForeignKey
+	(*GetJobStatusRequest)(nil), // 9: dbimport.v1.GetJobStatusRequest
+	(*JobStatus)(nil),           // 10: dbimport.v1.JobStatus
+}
+var file_dbimport_v1_import_proto_depIdxs = []int32{
+	2,  // 0: dbimport.v1.ImportTableRequest.header:type_name -> dbimport.v1.ImportTableHeader
+	10, // 1: dbimport.v1.ImportTableResponse.status:type_name -> dbimport.v1.JobStatus
+	6,  // 2: dbimport.v1.GetSchemaResponse.tables:type_name -> dbimport.v1.Table
+	7,  // 3: dbimport.v1.Table.columns:type_name -> dbimport.v1.Column
+	8,  // 4: dbimport.v1.Table.foreign_keys:type_name -> dbimport.v1.ForeignKey
+	0,  // 5: dbimport.v1.JobStatus.state:type_name -> dbimport.v1.JobStatus.State
+	1,  // 6: dbimport.v1.ImportService.ImportTable:input_type -> dbimport.v1.ImportTableRequest
+	4,  // 7: dbimport.v1.ImportService.GetSchema:input_type -> dbimport.v1.GetSchemaRequest
+	9,  // 8: dbimport.v1.ImportService.GetJobStatus:input_type -> dbimport.v1.GetJobStatusRequest
+	3,  // 9: dbimport.v1.ImportService.ImportTable:output_type -> dbimport.v1.ImportTableResponse
+	5,  // 10: dbimport.v1.ImportService.GetSchema:output_type -> dbimport.v1.GetSchemaResponse
+	10, // 11: dbimport.v1.ImportService.GetJobStatus:output_type -> dbimport.v1.JobStatus
+	9,  // [9:12] is the sub-list for method output_type
+	6,  // [6:9] is the sub-list for method input_type
+	6,  // [6:6] is the sub-list for extension type_name
+	6,  // [6:6] is the sub-list for extension extendee
+	0,  // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_dbimport_v1_import_proto_init() }
+func file_dbimport_v1_import_proto_init() {
+	if File_dbimport_v1_import_proto != nil {
+		return
+	}
+	file_dbimport_v1_import_proto_msgTypes[0].OneofWrappers = []any{
+		(*ImportTableRequest_Header)(nil),
+		(*ImportTableRequest_Chunk)(nil),
+	}
+	file_dbimport_v1_import_proto_msgTypes[1].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_dbimport_v1_import_proto_rawDesc), len(file_dbimport_v1_import_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_dbimport_v1_import_proto_goTypes,
+		DependencyIndexes: file_dbimport_v1_import_proto_depIdxs,
+		EnumInfos:         file_dbimport_v1_import_proto_enumTypes,
+		MessageInfos:      file_dbimport_v1_import_proto_msgTypes,
+	}.Build()
+	File_dbimport_v1_import_proto = out.File
+	file_dbimport_v1_import_proto_goTypes = nil
+	file_dbimport_v1_import_proto_depIdxs = nil
+}
@@ -0,0 +1,209 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: dbimport/v1/import.proto
+
+package importv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	ImportService_ImportTable_FullMethodName  = "/dbimport.v1.ImportService/ImportTable"
+	ImportService_GetSchema_FullMethodName    = "/dbimport.v1.ImportService/GetSchema"
+	ImportService_GetJobStatus_FullMethodName = "/dbimport.v1.ImportService/GetJobStatus"
+)
+
+// ImportServiceClient is the client API for ImportService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// ImportService imports CSV data into the database the server is connected to.
+// Imports run one at a time; a request waits while another import is running.
+type ImportServiceClient interface {
+	// ImportTable imports the CSV data streamed by the client into one table, creating missing
+	// parent records as the command does. The first message carries the header; the following
+	// messages carry consecutive chunks of the CSV data. The response is sent once the import
+	// has finished.
+	ImportTable(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[ImportTableRequest, ImportTableResponse], error)
+	// GetSchema returns the tables of the database schema.
+	GetSchema(ctx context.Context, in *GetSchemaRequest, opts ...grpc.CallOption) (*GetSchemaResponse, error)
+	// GetJobStatus returns the status of a running or recently finished import.
+	GetJobStatus(ctx context.Context, in *GetJobStatusRequest, opts ...grpc.CallOption) (*JobStatus, error)
+}
+
+type importServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewImportServiceClient(cc grpc.ClientConnInterface) ImportServiceClient {
+	return &importServiceClient{cc}
+}
+
+func (c *importServiceClient) ImportTable(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[ImportTableRequest, ImportTableResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ImportService_ServiceDesc.Streams[0], ImportService_ImportTable_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ImportTableRequest, ImportTableResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ImportService_ImportTableClient = grpc.ClientStreamingClient[ImportTableRequest, ImportTableResponse]
+
+func (c *importServiceClient) GetSchema(ctx context.Context, in *GetSchemaRequest, opts ...grpc.CallOption) (*GetSchemaResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetSchemaResponse)
+	err := c.cc.Invoke(ctx, ImportService_GetSchema_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *importServiceClient) GetJobStatus(ctx context.Context, in *GetJobStatusRequest, opts ...grpc.CallOption) (*JobStatus, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(JobStatus)
+	err := c.cc.Invoke(ctx, ImportService_GetJobStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ImportServiceServer is the server API for ImportService service.
+// All implementations must embed UnimplementedImportServiceServer
+// for forward compatibility.
+//
+// ImportService imports CSV data into the database the server is connected to.
+// Imports run one at a time; a request waits while another import is running.
+type ImportServiceServer interface {
+	// ImportTable imports the CSV data streamed by the client into one table, creating missing
+	// parent records as the command does. The first message carries the header; the following
+	// messages carry consecutive chunks of the CSV data. The response is sent once the import
+	// has finished.
+	ImportTable(grpc.ClientStreamingServer[ImportTableRequest, ImportTableResponse]) error
+	// GetSchema returns the tables of the database schema.
+	GetSchema(context.Context, *GetSchemaRequest) (*GetSchemaResponse, error)
+	// GetJobStatus returns the status of a running or recently finished import.
+	GetJobStatus(context.Context, *GetJobStatusRequest) (*JobStatus, error)
+	mustEmbedUnimplementedImportServiceServer()
+}
+
+// UnimplementedImportServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedImportServiceServer struct{}
+
+func (UnimplementedImportServiceServer) ImportTable(grpc.ClientStreamingServer[ImportTableRequest, ImportTableResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method ImportTable not implemented")
+}
+func (UnimplementedImportServiceServer) GetSchema(context.Context, *GetSchemaRequest) (*GetSchemaResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSchema not implemented")
+}
+func (UnimplementedImportServiceServer) GetJobStatus(context.Context, *GetJobStatusRequest) (*JobStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetJobStatus not implemented")
+}
+func (UnimplementedImportServiceServer) mustEmbedUnimplementedImportServiceServer() {}
+func (UnimplementedImportServiceServer) testEmbeddedByValue()                       {}
+
+// UnsafeImportServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ImportServiceServer will
+// result in compilation errors.
+type UnsafeImportServiceServer interface {
+	mustEmbedUnimplementedImportServiceServer()
+}
+
+func RegisterImportServiceServer(s grpc.ServiceRegistrar, srv ImportServiceServer) {
+	// If the following call pancis, it indicates UnimplementedImportServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ImportService_ServiceDesc, srv)
+}
+
+func _ImportService_ImportTable_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ImportServiceServer).ImportTable(&grpc.GenericServerStream[ImportTableRequest, ImportTableResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ImportService_ImportTableServer = grpc.ClientStreamingServer[ImportTableRequest, ImportTableResponse]
+
+func _ImportService_GetSchema_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSchemaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImportServiceServer).GetSchema(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ImportService_GetSchema_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImportServiceServer).GetSchema(ctx, req.(*GetSchemaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ImportService_GetJobStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetJobStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImportServiceServer).GetJobStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ImportService_GetJobStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImportServiceServer).GetJobStatus(ctx, req.(*GetJobStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ImportService_ServiceDesc is the grpc.ServiceDesc for ImportService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ImportService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "dbimport.v1.ImportService",
+	HandlerType: (*ImportServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetSchema",
+			Handler:    _ImportService_GetSchema_Handler,
+		},
+		{
+			MethodName: "GetJobStatus",
+			Handler:    _ImportService_GetJobStatus_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ImportTable",
+			Handler:       _ImportService_ImportTable_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "dbimport/v1/import.proto",
+}
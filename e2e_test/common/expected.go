@@ -2,13 +2,16 @@ package common
 
 import (
 	"database/sql"
-	"db-auto-importer/internal/database"
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/stretchr/testify/require"
 )
 
+// GeneratorSeed is passed as --seed so that generated values of auto-created parents are predictable.
+const GeneratorSeed int64 = 42
+
 var ExpectedDBInfo = map[string]database.DBInfo{
 	"organizations": {
 		TableName:         "organizations",
@@ -26,11 +29,11 @@ var ExpectedDBInfo = map[string]database.DBInfo{
 		UniqueKeyColumns:  nil,
 		ForeignKeys: []database.ForeignKeyInfo{
 			{
-				ConstraintName:    "fk_organization_id",
-				TableName:         "users",
-				ColumnName:        "organization_id",
-				ForeignTableName:  "organizations",
-				ForeignColumnName: "id",
+				ConstraintName:     "fk_organization_id",
+				TableName:          "users",
+				ColumnNames:        []string{"organization_id"},
+				ForeignTableName:   "organizations",
+				ForeignColumnNames: []string{"id"},
 			},
 		},
 		Columns: []database.ColumnInfo{
@@ -46,11 +49,11 @@ var ExpectedDBInfo = map[string]database.DBInfo{
 		UniqueKeyColumns:  nil,
 		ForeignKeys: []database.ForeignKeyInfo{
 			{
-				ConstraintName:    "fk_user_id",
-				TableName:         "posts",
-				ColumnName:        "user_id",
-				ForeignTableName:  "users",
-				ForeignColumnName: "id",
+				ConstraintName:     "fk_user_id",
+				TableName:          "posts",
+				ColumnNames:        []string{"user_id"},
+				ForeignTableName:   "users",
+				ForeignColumnNames: []string{"id"},
 			},
 		},
 		Columns: []database.ColumnInfo{
@@ -88,18 +91,18 @@ var ExpectedDBInfo = map[string]database.DBInfo{
 		UniqueKeyColumns:  nil,
 		ForeignKeys: []database.ForeignKeyInfo{
 			{
-				ConstraintName:    "fk_product_id",
-				TableName:         "product_tags",
-				ColumnName:        "product_id",
-				ForeignTableName:  "products",
-				ForeignColumnName: "id",
+				ConstraintName:     "fk_product_id",
+				TableName:          "product_tags",
+				ColumnNames:        []string{"product_id"},
+				ForeignTableName:   "products",
+				ForeignColumnNames: []string{"id"},
 			},
 			{
-				ConstraintName:    "fk_tag_id",
-				TableName:         "product_tags",
-				ColumnName:        "tag_id",
-				ForeignTableName:  "tags",
-				ForeignColumnName: "id",
+				ConstraintName:     "fk_tag_id",
+				TableName:          "product_tags",
+				ColumnNames:        []string{"tag_id"},
+				ForeignTableName:   "tags",
+				ForeignColumnNames: []string{"id"},
 			},
 		},
 		Columns: []database.ColumnInfo{
@@ -143,7 +146,7 @@ func AssertAllDataCreated(t *testing.T, db *sql.DB) {
 		expectedUsers := []User{
 			{ID: 1, Name: "Alice", OrganizationID: sql.NullInt16{Valid: true, Int16: 1}},
 			{ID: 2, Name: "Bob", OrganizationID: sql.NullInt16{Valid: true, Int16: 2}},
-			{ID: 3, Name: ""}, // 自動で作成される
+			{ID: 3, Name: "Grace King"}, // 自動で作成される。NameはGeneratorSeedから生成される
 		}
 
 		var actualUsers []User
@@ -167,7 +170,7 @@ func AssertAllDataCreated(t *testing.T, db *sql.DB) {
 	t.Run("organizationsが正しく作成されていること", func(t *testing.T) {
 		expectedOrganizations := []Organization{
 			{ID: 1, Name: "Organization A"},
-			{ID: 2, Name: ""},
+			{ID: 2, Name: "Wayne Co., Ltd."}, // 自動で作成される。NameはGeneratorSeedから生成される
 		}
 
 		var actualOrganizations []Organization
@@ -192,7 +195,7 @@ func AssertAllDataCreated(t *testing.T, db *sql.DB) {
 		expectedProducts := []Product{
 			{ID: 1, Name: "Laptop", Price: sql.NullFloat64{Valid: true, Float64: 1200.00}},
 			{ID: 2, Name: "Mouse", Price: sql.NullFloat64{Valid: true, Float64: 25.50}},
-			{ID: 3, Name: "---", Price: sql.NullFloat64{}}, // 自動で作成される。Nameはランダム生成されるため比較対象外とする
+			{ID: 3, Name: "river-345380", Price: sql.NullFloat64{}}, // 自動で作成される。NameはGeneratorSeedから生成される
 		}
 
 		var actualProducts []Product
@@ -208,7 +211,6 @@ func AssertAllDataCreated(t *testing.T, db *sql.DB) {
 		}
 		require.NoError(t, rows.Err())
 
-		actualProducts[2].Name = expectedProducts[2].Name // 自動生成のためdiffが出ないようにする
 		if diff := cmp.Diff(expectedProducts, actualProducts); diff != "" {
 			t.Errorf("diff: -want, +got:\n%s", diff)
 		}
@@ -218,7 +220,7 @@ func AssertAllDataCreated(t *testing.T, db *sql.DB) {
 		expectedTags := []Tag{
 			{ID: 1, Name: "electronics"},
 			{ID: 2, Name: "computer"},
-			{ID: 3, Name: "---"}, // 自動で作成される。Nameはランダム生成されるため比較対象外とする
+			{ID: 3, Name: "umber-944785"}, // 自動で作成される。NameはGeneratorSeedから生成される
 		}
 
 		var actualTags []Tag
@@ -234,15 +236,7 @@ func AssertAllDataCreated(t *testing.T, db *sql.DB) {
 		}
 		require.NoError(t, rows.Err())
 
-		actualTags[2].Name = expectedTags[2].Name // 自動生成のためdiffが出ないようにする
-		cmpOption := cmp.FilterValues(
-			func(x, y interface{}) bool {
-				tagX, okX := x.(Tag)
-				return okX && tagX.Name == "---"
-			},
-			cmp.Ignore(),
-		)
-		if diff := cmp.Diff(expectedTags, actualTags, cmpOption); diff != "" {
+		if diff := cmp.Diff(expectedTags, actualTags); diff != "" {
 			t.Errorf("diff: -want, +got:\n%s", diff)
 		}
 	})
@@ -3,17 +3,17 @@ package e2e_test_postgres
 import (
 	"context"
 	"database/sql"
-	"db-auto-importer/internal/database"
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
 	"log"
 	"os"
 	"testing"
 
-	"db-auto-importer/e2e_test/common"
-	"db-auto-importer/internal/app" // Import the new app package
+	"github.com/k-wa-wa/db-auto-importer/dbimport/dbimportertest"
+	"github.com/k-wa-wa/db-auto-importer/e2e_test/common"
+	"github.com/k-wa-wa/db-auto-importer/internal/app" // Import the new app package
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/stretchr/testify/require"
-	"github.com/testcontainers/testcontainers-go/modules/postgres"
 )
 
 var dbConnStr string
@@ -21,38 +21,24 @@ var dbConnStr string
 func TestMain(m *testing.M) {
 	ctx := context.Background()
 
-	postgresContainer, err := postgres.Run(
-		ctx,
-		"postgres:16-alpine",
-		postgres.WithDatabase("database"),
-		postgres.WithUsername("username"),
-		postgres.WithPassword("password"),
-		postgres.BasicWaitStrategies(),
-		postgres.WithSQLDriver("pgx"),
-		postgres.WithOrderedInitScripts(
-			"../initdb.d/01-create-table.sql",
-		),
-	)
+	container, err := dbimportertest.Start(ctx, dbimportertest.Postgres, dbimportertest.WithSchemaFiles("../initdb.d/01-create-table.sql"))
 	if err != nil {
 		log.Fatal(err)
 	}
+	dbConnStr = container.ConnStr
 
-	dbURL, err := postgresContainer.ConnectionString(ctx)
-	if err != nil {
-		log.Fatal(err)
-	}
-	dbConnStr = dbURL + "sslmode=disable"
-
-	os.Exit(m.Run())
+	code := m.Run()
+	container.Terminate(ctx)
+	os.Exit(code)
 }
 
 func Test_schema情報を正しく読み取れること(t *testing.T) {
 	t.Run("テーブル定義を正しく読み取れていること", func(t *testing.T) {
-		dbClient, err := database.NewDBClient("postgres", dbConnStr)
+		dbClient, err := database.NewDBClient(context.Background(), "postgres", dbConnStr, database.ConnOptions{})
 		require.NoError(t, err)
 		defer dbClient.Close()
 
-		schemaInfo, err := dbClient.GetSchemaInfo("public")
+		schemaInfo, err := dbClient.GetSchemaInfo(context.Background(), "public")
 
 		if diff := cmp.Diff(common.ExpectedDBInfo, schemaInfo); diff != "" {
 			t.Errorf("diff: -want, +got:\n%s", diff)
@@ -62,7 +48,15 @@ func Test_schema情報を正しく読み取れること(t *testing.T) {
 
 func Test_csvを正しくimportできること(t *testing.T) {
 	t.Run("importが成功すること", func(t *testing.T) {
-		err := app.RunApp("postgres", dbConnStr, "../input_data/01", true, "public")
+		seed := common.GeneratorSeed
+		err := app.Run(context.Background(), app.Config{
+			DBType:       "postgres",
+			DBConnStr:    dbConnStr,
+			CSVDir:       "../input_data/01",
+			HasHeader:    true,
+			DBSchemaName: "public",
+			Seed:         &seed,
+		})
 		require.NoError(t, err)
 	})
 
@@ -1,39 +1,728 @@
 package app
 
 import (
-	"db-auto-importer/internal/database"
-	"db-auto-importer/internal/importer"
+	"context"
+	"errors"
 	"fmt"
+	"github.com/k-wa-wa/db-auto-importer/internal/config"
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/k-wa-wa/db-auto-importer/internal/graph"
+	"github.com/k-wa-wa/db-auto-importer/internal/importer"
+	"github.com/k-wa-wa/db-auto-importer/internal/metrics"
+	"github.com/k-wa-wa/db-auto-importer/internal/notify"
+	"github.com/k-wa-wa/db-auto-importer/internal/secrets"
+	"github.com/k-wa-wa/db-auto-importer/internal/version"
+	"io"
 	"log"
+	"maps"
+	"os"
+	"runtime/debug"
+	"slices"
+	"sort"
+	"strings"
+	"time"
 )
 
+// Config holds the settings for a single import run.
+type Config struct {
+	DBType    string
+	DBConnStr string
+	// ReadDBConnStr, if set, is a reader endpoint of the same database, e.g. a read replica, the
+	// schema is read from and parent records are looked up on, while rows are written through
+	// DBConnStr. It may be a secret reference like DBConnStr.
+	ReadDBConnStr      string
+	CSVDir             string
+	HasHeader          bool
+	DBSchemaName       string
+	UnmappedFilePolicy importer.UnmappedFilePolicy
+	// SourceDBConnStr, if set, is a database whose tables are imported instead of the CSV files
+	// of CSVDir. It may be a secret reference like DBConnStr.
+	SourceDBConnStr string
+	// SourceDBType is the type of the SourceDBConnStr database.
+	SourceDBType string
+	// SourceSchemaName is the schema of the SourceDBConnStr database the tables are read from.
+	SourceSchemaName string
+	// Tables, if set, limits the import to the CSV files of these tables.
+	Tables []string
+	// MaskExports makes Export and Snapshot anonymize the values they write with the masking rules
+	// of the config file.
+	MaskExports bool
+	// FakeValueKinds assigns fake value kinds to columns or types,
+	// e.g. "users.email=email,type:STRING=word" (see database.FakeGenerator).
+	FakeValueKinds string
+	// Seed, if set, makes all generated values reproducible run-to-run.
+	Seed *int64
+	// NoAutoParents rejects records whose referenced parent does not exist instead of creating it.
+	NoAutoParents bool
+	// Generate fabricates data for every table instead of importing CSV files.
+	Generate bool
+	// GenerateRows is the number of rows generated per table unless overridden in the config file.
+	GenerateRows int
+	// ConfigFile is the optional path of a YAML configuration file.
+	ConfigFile string
+	// Progress, if set, receives per-file import progress. A terminal gets a redrawn status line.
+	Progress io.Writer
+	// TUI shows an interactive terminal UI of the import, in which tables can be paused and
+	// aborted, instead of the log and Progress. It needs a terminal on stdin and stderr.
+	TUI bool
+	// SummaryFile, if set, is where the per-table import summary is written as JSON.
+	SummaryFile string
+	// ReportFile, if set, is where an HTML report of the import is written.
+	ReportFile string
+	// Watch keeps running after the import and re-imports CSV files as they appear or change.
+	Watch bool
+	// Manifest, if set, is a manifest the CSV files are verified against before they are imported.
+	// The import does not start if they differ.
+	Manifest string
+	// StateFile, if set, records the progress of each CSV file so an interrupted import can resume.
+	StateFile string
+	// WatermarkFile, if set, makes the import incremental: only the CSV rows newer than the
+	// watermark stored in the file for their table are imported; see importer.Watermarks.
+	WatermarkFile string
+	// WatermarkColumn is the watermark column of the tables that have it, unless the config file
+	// names another for a table.
+	WatermarkColumn string
+	// SlowThreshold, if positive, logs a warning for each insert or parent record check taking longer.
+	SlowThreshold time.Duration
+	// SQLLog selects whether the SQL statements sent to the database are logged.
+	SQLLog database.SQLLogMode
+	// AWSIAMAuth connects to Amazon RDS or Aurora with IAM authentication tokens instead of the
+	// password of the connection string; see database.ConnOptions.AWSIAMAuth. It applies to the
+	// connections of DBConnStr and ReadDBConnStr, not to a source or baseline database.
+	AWSIAMAuth bool
+	// TLS configures the TLS connections to the database: a CA bundle, a client certificate for
+	// mutual TLS and the server name to verify.
+	TLS database.TLSOptions
+	// StatementTimeout, if positive, bounds each insert and each parent record check or creation.
+	StatementTimeout time.Duration
+	// BatchSize, if larger than 1, is the number of CSV rows inserted together on the databases that
+	// support it, and 0 leaves it to the database; see importer.Importer.BatchSize.
+	BatchSize int
+	// RefreshMaterializedViews refreshes the materialized views over the written tables after the run.
+	RefreshMaterializedViews bool
+	// SchemaCache, if set, is a file the schema is read from instead of the database when it
+	// exists, and written to after reading the schema from the database otherwise.
+	SchemaCache string
+	// RecordRuns adds a row describing each import to the _import_runs table of the database.
+	RecordRuns bool
+	// MaxMemory, if positive, is a soft limit of the memory of the process, in bytes. Half of it
+	// limits the buffers kept until the end of an import; see importer.Importer.MaxMemory.
+	MaxMemory int64
+	// BatchColumn, if set, is a column every written row is given the id of the run in.
+	BatchColumn string
+	// MetricsAddr, if set, is the address Prometheus metrics are served on (at /metrics) while
+	// the import, daemon or server runs.
+	MetricsAddr string
+	// Metrics, if set, collects the statistics of the imports. It is set while MetricsAddr is served.
+	Metrics *metrics.Registry
+	// NotifyURL, if set, is a webhook a summary of each import is posted to when it finishes.
+	// It may be a secret reference like the connection string.
+	NotifyURL string
+	// NotifyFormat is the payload posted to NotifyURL; see notify.Webhook.
+	NotifyFormat notify.Format
+	// notifier posts the notifications while NotifyURL is used; see withNotification.
+	notifier *runNotifier
+	// EventLog, if set, receives the import events as NDJSON.
+	EventLog *importer.EventLog
+	// Schema, if set, is used instead of the schema read from the database, e.g. in tests.
+	Schema map[string]database.DBInfo
+	// PatchSchema, if set, may modify the schema before it is used, e.g. to add a table the
+	// database does not report or to correct a column type.
+	PatchSchema func(schema map[string]database.DBInfo) error
+}
+
 func RunApp(dbType, dbConnStr, csvDir string, hasHeader bool, dbSchemaName string) error {
+	return Run(context.Background(), Config{
+		DBType:       dbType,
+		DBConnStr:    dbConnStr,
+		CSVDir:       csvDir,
+		HasHeader:    hasHeader,
+		DBSchemaName: dbSchemaName,
+	})
+}
+
+// session holds the connection and schema shared by the commands.
+type session struct {
+	fileCfg    *config.Config
+	dbClient   database.DBClient
+	schemaInfo map[string]database.DBInfo
+}
+
+// connOptions returns the options of the connections of cfg, including those to a source or
+// baseline database.
+func (cfg Config) connOptions() (database.ConnOptions, error) {
+	if err := cfg.TLS.Validate(); err != nil {
+		return database.ConnOptions{}, fmt.Errorf("invalid TLS options: %w", err)
+	}
+	return database.ConnOptions{TLS: cfg.TLS, SQLLog: cfg.SQLLog}, nil
+}
+
+// dbConnOptions returns the options of the connections to the database of cfg, through DBConnStr
+// or ReadDBConnStr, which also authenticate with IAM if cfg.AWSIAMAuth is set.
+func (cfg Config) dbConnOptions() (database.ConnOptions, error) {
+	options, err := cfg.connOptions()
+	options.AWSIAMAuth = cfg.AWSIAMAuth
+	return options, err
+}
+
+// openSession loads the config file, connects to the database and reads the schema.
+// The caller must Close the returned session.
+func openSession(ctx context.Context, cfg Config) (*session, error) {
+	fileCfg := &config.Config{}
+	if cfg.ConfigFile != "" {
+		var err error
+		fileCfg, err = config.Load(cfg.ConfigFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// The connection string may be, or contain, a reference to a secrets manager
+	connStr, err := secrets.ResolveConnStr(cfg.DBConnStr)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving database connection string: %w", err)
+	}
+
 	// Initialize DBClient based on dbType
-	dbClient, err := database.NewDBClient(dbType, dbConnStr)
+	connOptions, err := cfg.dbConnOptions()
+	if err != nil {
+		return nil, err
+	}
+	dbClient, err := database.NewDBClient(ctx, cfg.DBType, connStr, connOptions)
 	if err != nil {
-		return fmt.Errorf("error creating database client: %w", err)
+		return nil, fmt.Errorf("error creating database client: %w", err)
+	}
+	if cfg.ReadDBConnStr != "" {
+		readConnStr, err := secrets.ResolveConnStr(cfg.ReadDBConnStr)
+		if err != nil {
+			dbClient.Close()
+			return nil, fmt.Errorf("error resolving read database connection string: %w", err)
+		}
+		reader, err := database.NewDBClient(ctx, cfg.DBType, readConnStr, connOptions)
+		if err != nil {
+			dbClient.Close()
+			return nil, fmt.Errorf("error creating read database client: %w", err)
+		}
+		dbClient = database.NewReadSplitDB(dbClient, reader)
+		log.Println("Reading the schema and looking up parent records through the read endpoint.")
+	}
+
+	// Database Schema Detection
+	schemaInfo := cfg.Schema
+	if schemaInfo == nil {
+		schemaInfo, err = loadSchema(ctx, dbClient, cfg)
+		if err != nil {
+			dbClient.Close()
+			return nil, err
+		}
+	}
+	if err := patchSchema(schemaInfo, fileCfg, cfg.PatchSchema); err != nil {
+		dbClient.Close()
+		return nil, err
+	}
+	for _, problem := range database.CheckColumnHints(schemaInfo) {
+		log.Printf("Warning: Ignoring column comment hint of %s\n", problem)
 	}
-	defer dbClient.Close() // Ensure the database connection is closed
 
-	// 1. Database Schema Detection
-	schemaInfo, err := dbClient.GetSchemaInfo(dbSchemaName)
+	return &session{fileCfg: fileCfg, dbClient: dbClient, schemaInfo: schemaInfo}, nil
+}
+
+// loadSchema reads the schema from cfg.SchemaCache if the file exists and was written for the
+// same database type and schema, and from the database otherwise, saving it to cfg.SchemaCache.
+func loadSchema(ctx context.Context, dbClient database.DBClient, cfg Config) (map[string]database.DBInfo, error) {
+	if cfg.SchemaCache != "" {
+		snapshot, err := readSchemaCache(cfg.SchemaCache)
+		switch {
+		case err != nil && !errors.Is(err, os.ErrNotExist):
+			return nil, err
+		case err != nil:
+		case snapshot.DBType != cfg.DBType || snapshot.Schema != cfg.DBSchemaName:
+			log.Printf("Warning: schema cache %s was written for %s schema %s. Reading the schema from the database instead.\n", cfg.SchemaCache, snapshot.DBType, snapshot.Schema)
+		default:
+			log.Printf("Database schema information read from %s.\n", cfg.SchemaCache)
+			return snapshot.Tables, nil
+		}
+	}
+
+	schemaInfo, err := dbClient.GetSchemaInfo(ctx, cfg.DBSchemaName)
 	if err != nil {
-		return fmt.Errorf("error getting database schema info: %w", err)
+		return nil, fmt.Errorf("error getting database schema info: %w", err)
 	}
 	log.Println("Database schema information retrieved successfully.")
 
-	// 2. CSV Parsing and Data Import
-	importer, err := importer.NewImporter(schemaInfo, dbClient)
+	if cfg.SchemaCache != "" {
+		if err := writeSchemaCache(cfg.SchemaCache, cfg, schemaInfo); err != nil {
+			return nil, err
+		}
+		log.Printf("Database schema information saved to %s.\n", cfg.SchemaCache)
+	}
+	return schemaInfo, nil
+}
+
+func readSchemaCache(path string) (*database.SchemaSnapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	snapshot, err := database.ReadSchemaSnapshot(f)
 	if err != nil {
-		return fmt.Errorf("error creating importer: %w", err)
+		return nil, fmt.Errorf("error reading schema cache %s: %w", path, err)
 	}
-	// The importer now manages its own DBClient, so its Close method will call dbClient.Close
-	// defer importer.Close() // No longer needed here, importer handles it
+	return snapshot, nil
+}
 
-	// Pass the hasHeader flag to the importer
-	if err := importer.ImportCSVFiles(csvDir, hasHeader); err != nil {
-		return fmt.Errorf("error importing CSV files: %w", err)
+func writeSchemaCache(path string, cfg Config, schemaInfo map[string]database.DBInfo) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("error writing schema cache: %w", err)
+	}
+	if err := database.WriteSchemaSnapshot(f, cfg.DBType, cfg.DBSchemaName, schemaInfo); err != nil {
+		f.Close()
+		return fmt.Errorf("error writing schema cache %s: %w", path, err)
 	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("error writing schema cache %s: %w", path, err)
+	}
+	return nil
+}
+
+// patchSchema applies the column types and ignored foreign keys of the config file and then the
+// caller's patch to schema. It also checks the excluded columns of the config file against schema.
+func patchSchema(schema map[string]database.DBInfo, fileCfg *config.Config, patch func(map[string]database.DBInfo) error) error {
+	if err := fileCfg.ApplyColumnTypes(schema); err != nil {
+		return fmt.Errorf("error applying column types: %w", err)
+	}
+	if err := fileCfg.CheckExcludedColumns(schema); err != nil {
+		return fmt.Errorf("error excluding columns: %w", err)
+	}
+	if err := fileCfg.RemoveIgnoredForeignKeys(schema); err != nil {
+		return fmt.Errorf("error ignoring foreign keys: %w", err)
+	}
+	if patch != nil {
+		if err := patch(schema); err != nil {
+			return fmt.Errorf("error patching database schema: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *session) Close() error {
+	return s.dbClient.Close()
+}
+
+// setEncryptor sets the encryptor of the encryption section of the config file, if any, on imp.
+// It is only set up for imports, since fetching the keys is pointless for commands that do not insert.
+func (s *session) setEncryptor(cfg Config, imp *importer.Importer) error {
+	if len(s.fileCfg.Encryption) == 0 {
+		return nil
+	}
+	rules, err := s.fileCfg.EncryptionRules()
+	if err != nil {
+		return err
+	}
+	encryptor, err := importer.NewEncryptor(rules)
+	if err != nil {
+		return err
+	}
+	if encryptor.UsesPGP() && cfg.DBType != "postgres" && cfg.DBType != "yugabyte" {
+		return fmt.Errorf("pgp encryption needs PostgreSQL or YugabyteDB with the pgcrypto extension, not %s; use aes-gcm instead", cfg.DBType)
+	}
+	for _, warning := range encryptor.KeyWarnings(s.schemaInfo) {
+		log.Printf("Warning: %s\n", warning)
+	}
+	imp.Encryptor = encryptor
+	return nil
+}
+
+// newImporter creates an importer with the generators, masking and policies configured in cfg.
+func (s *session) newImporter(cfg Config) (*importer.Importer, error) {
+	generator := database.NewFakeGenerator()
+	if err := generator.ParseAssignments(cfg.FakeValueKinds); err != nil {
+		return nil, fmt.Errorf("error parsing fake value kinds: %w", err)
+	}
+	rules, err := s.fileCfg.GenerationRules()
+	if err != nil {
+		return nil, err
+	}
+	ruleGenerator := database.NewRuleGenerator(rules, generator)
+	if err := ruleGenerator.CheckSchema(s.schemaInfo); err != nil {
+		return nil, err
+	}
+	maskingRules, err := s.fileCfg.MaskingRules()
+	if err != nil {
+		return nil, err
+	}
+	// Masking draws from its own generator so that masked columns do not shift the generated values of others.
+	maskFaker := database.NewFakeGenerator()
+	if cfg.Seed != nil {
+		generator.Seed(*cfg.Seed)
+		ruleGenerator.Seed(*cfg.Seed)
+		maskFaker.Seed(*cfg.Seed)
+	}
+	if configurer, ok := s.dbClient.(database.ParentConfigurer); ok {
+		configurer.SetValueGenerator(ruleGenerator)
+		configurer.SetParentTemplates(s.fileCfg.ParentTemplates(s.schemaInfo))
+	}
+
+	opts := []importer.Option{
+		importer.WithValueGenerator(ruleGenerator),
+		importer.WithTables(s.fileCfg.TableOptions()),
+		importer.WithStatementTimeout(cfg.StatementTimeout),
+		importer.WithBatchSize(cfg.BatchSize),
+		importer.WithSlowThreshold(cfg.SlowThreshold),
+		importer.WithOrderRules(s.fileCfg.OrderRules()),
+		importer.WithSelectedTables(cfg.Tables),
+	}
+	if len(s.fileCfg.AuditColumns) > 0 {
+		opts = append(opts, importer.WithAuditColumns(s.fileCfg.AuditColumns))
+	}
+	if len(rules) > 0 {
+		opts = append(opts, importer.WithCellGenerator(ruleGenerator))
+	}
+	if len(maskingRules) > 0 {
+		opts = append(opts, importer.WithMasker(importer.NewMasker(maskingRules, maskFaker)))
+	}
+	if cfg.MaskExports {
+		opts = append(opts, importer.WithMaskedExports())
+	}
+	if cfg.NoAutoParents {
+		opts = append(opts, importer.WithNoAutoParents())
+	}
+	if cfg.RefreshMaterializedViews {
+		opts = append(opts, importer.WithMaterializedViewRefresh())
+	}
+	if cfg.RecordRuns {
+		opts = append(opts, importer.WithRunHistory())
+	}
+	if cfg.BatchColumn != "" {
+		opts = append(opts, importer.WithBatchColumn(cfg.BatchColumn))
+	}
+	if cfg.MaxMemory > 0 {
+		opts = append(opts, importer.WithMaxMemory(cfg.MaxMemory))
+	}
+	if cfg.Progress != nil {
+		f, ok := cfg.Progress.(*os.File)
+		opts = append(opts, importer.WithProgress(importer.NewProgress(cfg.Progress, ok && importer.IsTerminal(f))))
+	}
+	var observers []importer.Observer
+	if cfg.Metrics != nil {
+		observers = append(observers, cfg.Metrics)
+	}
+	if cfg.EventLog != nil {
+		observers = append(observers, cfg.EventLog)
+	}
+	if cfg.notifier != nil {
+		observers = append(observers, cfg.notifier)
+	}
+	if len(observers) > 0 {
+		opts = append(opts, importer.WithObserver(importer.MultiObserver(observers...)))
+	}
+	if cfg.StateFile != "" {
+		checkpoint, err := importer.LoadCheckpoint(cfg.StateFile)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, importer.WithCheckpoint(checkpoint))
+	}
+	if cfg.WatermarkFile != "" {
+		watermarks, err := importer.LoadWatermarks(cfg.WatermarkFile)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, importer.WithWatermarks(watermarks, cfg.WatermarkColumn))
+	}
+	if cfg.UnmappedFilePolicy != "" {
+		opts = append(opts, importer.WithUnmappedFilePolicy(cfg.UnmappedFilePolicy))
+	}
+
+	imp, err := importer.NewImporter(s.schemaInfo, s.dbClient, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("error creating importer: %w", err)
+	}
+	return imp, nil
+}
+
+// Run executes an import (or, with cfg.Generate, data generation) with the given configuration.
+func Run(ctx context.Context, cfg Config) error {
+	return withMetrics(ctx, cfg, func(cfg Config) error {
+		return withNotification(ctx, cfg, func(cfg Config) error { return run(ctx, cfg) })
+	})
+}
 
+func run(ctx context.Context, cfg Config) error {
+	if cfg.MaxMemory > 0 {
+		// The garbage collector works harder as the heap nears the limit, instead of letting it grow
+		debug.SetMemoryLimit(cfg.MaxMemory)
+	}
+	if cfg.Manifest != "" {
+		// A partial or corrupted drop of the CSV files must not be imported
+		report := func(problem string) { log.Printf("Manifest mismatch: %s\n", problem) }
+		if err := verifyManifest(cfg, cfg.Manifest, report); err != nil {
+			return err
+		}
+	}
+	s, err := openSession(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer s.Close() // Ensure the database connection is closed
+
+	importer, err := s.newImporter(cfg)
+	if err != nil {
+		return err
+	}
+	if err := s.setEncryptor(cfg, importer); err != nil {
+		return err
+	}
+
+	if cfg.Generate {
+		if err := importer.GenerateData(ctx, s.fileCfg.Rows, cfg.GenerateRows); err != nil {
+			return fmt.Errorf("error generating data: %w", err)
+		}
+		return failedRowsError(importer.FailedRows())
+	}
+
+	importCtx, stopTUI := ctx, func() {}
+	if cfg.TUI {
+		if importCtx, stopTUI, err = startTUI(ctx, importer, cfg); err != nil {
+			return err
+		}
+	}
+	writeReport := startReport(importer, cfg.ReportFile)
+	result, importErr := importData(importCtx, importer, cfg)
+	stopTUI()
+	// The summary is reported even if the import failed, to show how far it got
+	if err := reportSummary(importer.Summary(), cfg.SummaryFile); err != nil {
+		return err
+	}
+	if err := writeReport(result, importErr); err != nil {
+		return err
+	}
+	if importErr != nil {
+		return fmt.Errorf("error importing CSV files: %w", importErr)
+	}
+	if cfg.Watch {
+		// Rejected rows are reported above; the files that fix them may still arrive
+		return watch(ctx, importer, cfg)
+	}
+
+	return failedRowsError(result.Failed)
+}
+
+// reportSummary logs the per-table summary and, if path is not empty, writes it there as JSON.
+func reportSummary(summaries []importer.TableSummary, path string) error {
+	log.Println("Import summary:")
+	for _, summary := range summaries {
+		log.Printf("  %s\n", summary)
+	}
+	if path == "" {
+		return nil
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create summary file %s: %w", path, err)
+	}
+	if err := importer.WriteSummaryJSON(file, version.String(), summaries); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write summary file %s: %w", path, err)
+	}
+	return file.Close()
+}
+
+// Validate checks the CSV files against the database schema without importing them.
+// Each issue is written to w.
+func Validate(ctx context.Context, cfg Config, w io.Writer) error {
+	s, err := openSession(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	imp, err := s.newImporter(cfg)
+	if err != nil {
+		return err
+	}
+	issues, err := imp.ValidateCSVFiles(ctx, cfg.CSVDir, cfg.HasHeader)
+	if err != nil {
+		return fmt.Errorf("error validating CSV files: %w", err)
+	}
+	for _, issue := range issues {
+		fmt.Fprintln(w, issue)
+	}
+	if len(issues) > 0 {
+		return fmt.Errorf("%d issue(s) found: %w", len(issues), importer.ErrValidationFailed)
+	}
+	log.Println("CSV files are valid.")
+	return nil
+}
+
+// ListTables writes the name of each table to w, one per line, sorted.
+func ListTables(ctx context.Context, cfg Config, w io.Writer) error {
+	s, err := openSession(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	for _, tableName := range slices.Sorted(maps.Keys(s.schemaInfo)) {
+		fmt.Fprintln(w, tableName)
+	}
+	return nil
+}
+
+// DescribeSchema writes the detected tables, columns and keys to w.
+func DescribeSchema(ctx context.Context, cfg Config, w io.Writer) error {
+	s, err := openSession(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	tableNames := make([]string, 0, len(s.schemaInfo))
+	for tableName := range s.schemaInfo {
+		tableNames = append(tableNames, tableName)
+	}
+	sort.Strings(tableNames)
+
+	for _, tableName := range tableNames {
+		dbInfo := s.schemaInfo[tableName]
+		fmt.Fprintln(w, tableName)
+		for _, colInfo := range dbInfo.Columns {
+			var attrs []string
+			if !colInfo.IsNullable {
+				attrs = append(attrs, "NOT NULL")
+			}
+			if colInfo.ColumnDefault.Valid {
+				attrs = append(attrs, "DEFAULT "+colInfo.ColumnDefault.String)
+			}
+			if colInfo.IsAutoIncrement {
+				attrs = append(attrs, "AUTO INCREMENT")
+			}
+			if colInfo.IsGenerated {
+				attrs = append(attrs, "GENERATED")
+			}
+			fmt.Fprintf(w, "  %s %s %s\n", colInfo.ColumnName, colInfo.DataType, strings.Join(attrs, " "))
+		}
+		if len(dbInfo.PrimaryKeyColumns) > 0 {
+			fmt.Fprintf(w, "  PRIMARY KEY (%s)\n", strings.Join(dbInfo.PrimaryKeyColumns, ", "))
+		}
+		for _, ukCols := range dbInfo.UniqueKeyColumns {
+			fmt.Fprintf(w, "  UNIQUE (%s)\n", strings.Join(ukCols, ", "))
+		}
+		if len(dbInfo.InheritsFrom) > 0 {
+			fmt.Fprintf(w, "  INHERITS (%s)\n", strings.Join(dbInfo.InheritsFrom, ", "))
+		}
+		for _, fk := range dbInfo.ForeignKeys {
+			fmt.Fprintf(w, "  FOREIGN KEY %s (%s) REFERENCES %s (%s)\n", fk.ConstraintName, strings.Join(fk.ColumnNames, ", "), fk.ForeignTableName, strings.Join(fk.ForeignColumnNames, ", "))
+		}
+	}
+	return nil
+}
+
+// PrintImportOrder writes the tables in the order import would process them, each with the CSV
+// file of cfg.CSVDir it would read, followed by the deferred foreign keys and the files matching
+// no table. Nothing is imported.
+func PrintImportOrder(ctx context.Context, cfg Config, w io.Writer) error {
+	s, err := openSession(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	imp, err := s.newImporter(cfg)
+	if err != nil {
+		return err
+	}
+	plan, err := imp.PlanImport(ctx, importer.DirSource(cfg.CSVDir))
+	if err != nil {
+		return err
+	}
+	for idx, table := range plan.Tables {
+		fmt.Fprintf(w, "%d. %s\n", idx+1, table)
+	}
+	if len(plan.ReferencedTables) > 0 {
+		fmt.Fprintf(w, "referenced: %s\n", strings.Join(plan.ReferencedTables, ", "))
+	}
+	for _, fk := range plan.DeferredForeignKeys {
+		fmt.Fprintf(w, "deferred: %s.%s -> %s\n", fk.TableName, fk.ConstraintName, fk.ForeignTableName)
+	}
+	for _, filePath := range plan.UnmappedFiles {
+		fmt.Fprintf(w, "unmapped: %s\n", filePath)
+	}
+	return nil
+}
+
+// PrintWaves writes the tables grouped into waves, one numbered line per wave; see graph.Graph.Waves.
+func PrintWaves(ctx context.Context, cfg Config, w io.Writer) error {
+	s, err := openSession(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	waves, _, err := graph.ImportWaves(s.schemaInfo, s.fileCfg.OrderRules())
+	if err != nil {
+		return fmt.Errorf("failed to determine import order: %w", err)
+	}
+	for idx, wave := range waves {
+		fmt.Fprintf(w, "%d: %s\n", idx+1, strings.Join(wave, ", "))
+	}
+	return nil
+}
+
+// ExportGraph writes the dependency graph of the tables to w in the given format.
+func ExportGraph(ctx context.Context, cfg Config, format graph.Format, w io.Writer) error {
+	s, err := openSession(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	return graph.Export(w, s.schemaInfo, format)
+}
+
+// PrintGraph writes the tables in import order to w, each with the tables it depends on. Tables
+// referenced only by foreign keys deferred to break a cycle are marked as such.
+func PrintGraph(ctx context.Context, cfg Config, w io.Writer) error {
+	s, err := openSession(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	order, deferredFKs, err := graph.ImportOrder(s.schemaInfo, s.fileCfg.OrderRules())
+	if err != nil {
+		return fmt.Errorf("failed to determine import order: %w", err)
+	}
+	deferred := make(map[string]bool)
+	for _, fk := range deferredFKs {
+		deferred[fk.TableName+"."+fk.ConstraintName] = true
+	}
+	for _, tableName := range order {
+		parents := make(map[string]bool)
+		for _, fk := range s.schemaInfo[tableName].ForeignKeys {
+			switch {
+			case deferred[tableName+"."+fk.ConstraintName]:
+				parents[fk.ForeignTableName+" (deferred)"] = true
+			case fk.ForeignTableName != tableName:
+				parents[fk.ForeignTableName] = true
+			}
+		}
+		for _, parent := range s.schemaInfo[tableName].InheritsFrom {
+			parents[parent] = true
+		}
+		if len(parents) == 0 {
+			fmt.Fprintln(w, tableName)
+			continue
+		}
+		parentNames := make([]string, 0, len(parents))
+		for parent := range parents {
+			parentNames = append(parentNames, parent)
+		}
+		sort.Strings(parentNames)
+		fmt.Fprintf(w, "%s -> %s\n", tableName, strings.Join(parentNames, ", "))
+	}
 	return nil
 }
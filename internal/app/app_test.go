@@ -0,0 +1,64 @@
+package app
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/config"
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_patchSchema(t *testing.T) {
+	newSchema := func() map[string]database.DBInfo {
+		return map[string]database.DBInfo{
+			"users": {TableName: "users", Columns: []database.ColumnInfo{
+				{ColumnName: "id", DataType: database.IntegerType},
+				{ColumnName: "active", DataType: database.StringType},
+			}},
+		}
+	}
+
+	t.Run("設定ファイルの型で上書きされた後に関数が適用されること", func(t *testing.T) {
+		schema := newSchema()
+		fileCfg := &config.Config{Tables: map[string]config.TableConfig{
+			"users": {Types: map[string]string{"active": "boolean"}},
+		}}
+		err := patchSchema(schema, fileCfg, func(schema map[string]database.DBInfo) error {
+			assert.Equal(t, database.BooleanType, schema["users"].Columns[1].DataType)
+			schema["audit_logs"] = database.DBInfo{TableName: "audit_logs"}
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Contains(t, schema, "audit_logs")
+	})
+	t.Run("存在しないカラムの型を指定した場合はエラーになること", func(t *testing.T) {
+		fileCfg := &config.Config{Tables: map[string]config.TableConfig{
+			"users": {Types: map[string]string{"missing": "INTEGER"}},
+		}}
+		err := patchSchema(newSchema(), fileCfg, nil)
+		assert.EqualError(t, err, "error applying column types: tables.users.types.missing: column not found in table users")
+	})
+	t.Run("無視する外部キーが取り除かれること", func(t *testing.T) {
+		schema := newSchema()
+		schema["posts"] = database.DBInfo{TableName: "posts", ForeignKeys: []database.ForeignKeyInfo{
+			{ConstraintName: "fk_author", TableName: "posts", ForeignTableName: "users"},
+			{ConstraintName: "fk_editor", TableName: "posts", ForeignTableName: "users"},
+		}}
+		fileCfg := &config.Config{IgnoreForeignKeys: []string{"posts.fk_editor"}}
+		require.NoError(t, patchSchema(schema, fileCfg, nil))
+		require.Len(t, schema["posts"].ForeignKeys, 1)
+		assert.Equal(t, "fk_author", schema["posts"].ForeignKeys[0].ConstraintName)
+
+		fileCfg = &config.Config{IgnoreForeignKeys: []string{"fk_missing"}}
+		err := patchSchema(newSchema(), fileCfg, nil)
+		assert.EqualError(t, err, "error ignoring foreign keys: ignore_foreign_keys: foreign key fk_missing not found in the database schema")
+	})
+	t.Run("関数のエラーが返されること", func(t *testing.T) {
+		boom := errors.New("boom")
+		err := patchSchema(newSchema(), &config.Config{}, func(map[string]database.DBInfo) error { return boom })
+		assert.ErrorIs(t, err, boom)
+	})
+}
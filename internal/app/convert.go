@@ -0,0 +1,28 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/importer"
+)
+
+// Convert rewrites the CSV, JSONL and Parquet files in inDir (or those of cfg.Tables) to format to
+// in outDir, typing the values by the columns of the schema; see importer.Importer.ConvertFiles.
+// cfg.HasHeader tells whether the CSV inputs have a header row.
+func Convert(ctx context.Context, cfg Config, inDir, outDir string, to importer.FileFormat) error {
+	s, err := openSession(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	imp, err := s.newImporter(cfg)
+	if err != nil {
+		return err
+	}
+	if err := imp.ConvertFiles(ctx, inDir, outDir, to, cfg.HasHeader); err != nil {
+		return fmt.Errorf("error converting files: %w", err)
+	}
+	return nil
+}
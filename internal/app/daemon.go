@@ -0,0 +1,62 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/k-wa-wa/db-auto-importer/internal/schedule"
+	"log"
+	"time"
+)
+
+// ErrLocked is returned when another run holds the lock file.
+var ErrLocked = errors.New("another import is running")
+
+// Daemon runs an import each time the schedule fires until ctx is cancelled.
+// A failed run is logged and does not stop the daemon. If lockPath is not empty, a run
+// only starts if it can lock the lock file, so that several daemons (or a manual run
+// using the same lock file) never import concurrently.
+func Daemon(ctx context.Context, cfg Config, sched *schedule.Schedule, lockPath string) error {
+	// The metrics accumulate over all runs and stay available between them
+	return withMetrics(ctx, cfg, func(cfg Config) error { return daemon(ctx, cfg, sched, lockPath) })
+}
+
+func daemon(ctx context.Context, cfg Config, sched *schedule.Schedule, lockPath string) error {
+	for {
+		next := sched.Next(time.Now())
+		if next.IsZero() {
+			return fmt.Errorf("schedule %q never fires", sched)
+		}
+		log.Printf("Next import scheduled at %s.\n", next.Format(time.RFC3339))
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			log.Println("Daemon stopped.")
+			return nil
+		}
+
+		// The next run is computed from the time this one finishes, so runs never overlap
+		if err := runLocked(ctx, cfg, lockPath); err != nil {
+			if ctx.Err() != nil {
+				log.Println("Daemon stopped.")
+				return nil
+			}
+			log.Printf("Error: scheduled import failed: %v\n", err)
+		}
+	}
+}
+
+// runLocked runs one import while holding the lock file at lockPath.
+func runLocked(ctx context.Context, cfg Config, lockPath string) error {
+	if lockPath != "" {
+		release, err := acquireLock(lockPath)
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+	return Run(ctx, cfg)
+}
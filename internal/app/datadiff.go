@@ -0,0 +1,36 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// DiffData compares the CSV files with the rows of their tables without importing them, and
+// writes each row an import would insert or update, and each row only in the table, to w,
+// followed by a summary line per table.
+func DiffData(ctx context.Context, cfg Config, w io.Writer) error {
+	s, err := openSession(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	imp, err := s.newImporter(cfg)
+	if err != nil {
+		return err
+	}
+	diffs, err := imp.DiffCSVFiles(ctx, cfg.CSVDir, cfg.HasHeader)
+	if err != nil {
+		return fmt.Errorf("error comparing CSV files: %w", err)
+	}
+	for _, diff := range diffs {
+		for _, change := range diff.Changes {
+			fmt.Fprintf(w, "%s %s\n", diff.Table, change)
+		}
+	}
+	for _, diff := range diffs {
+		fmt.Fprintln(w, diff)
+	}
+	return nil
+}
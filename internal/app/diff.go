@@ -0,0 +1,115 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/k-wa-wa/db-auto-importer/internal/secrets"
+)
+
+// SchemaBaseline is what DiffSchema compares the live schema with: either a snapshot file, as
+// written by --schema-cache, or the schema of another database.
+type SchemaBaseline struct {
+	SnapshotFile string
+	DBType       string
+	DBConnStr    string
+	DBSchemaName string
+}
+
+// DiffSchema reads the schema of cfg's database and writes each difference from baseline to w.
+// Added objects exist in the live schema but not in the baseline. It returns an error wrapping
+// database.ErrSchemaDrift if there is any difference, so that a script can stop before importing.
+// The schema is compared as the database reports it, before any config file or cache applies.
+func DiffSchema(ctx context.Context, cfg Config, baseline SchemaBaseline, w io.Writer) error {
+	connOptions, err := cfg.dbConnOptions()
+	if err != nil {
+		return err
+	}
+	schemaInfo, err := readLiveSchema(ctx, cfg.DBType, cfg.schemaConnStr(), cfg.DBSchemaName, connOptions)
+	if err != nil {
+		return err
+	}
+
+	var baselineInfo map[string]database.DBInfo
+	if baseline.SnapshotFile != "" {
+		snapshot, err := readSchemaCache(baseline.SnapshotFile)
+		if err != nil {
+			return fmt.Errorf("error reading schema snapshot: %w", err)
+		}
+		if snapshot.DBType != cfg.DBType {
+			log.Printf("Warning: schema snapshot %s was written for %s, not %s. Column types and defaults may differ only in notation.\n", baseline.SnapshotFile, snapshot.DBType, cfg.DBType)
+		}
+		baselineInfo = snapshot.Tables
+	} else {
+		// The baseline is another database, which is not authenticated with IAM
+		baselineOptions := connOptions
+		baselineOptions.AWSIAMAuth = false
+		baselineInfo, err = readLiveSchema(ctx, baseline.DBType, baseline.DBConnStr, baseline.DBSchemaName, baselineOptions)
+		if err != nil {
+			return fmt.Errorf("error reading baseline schema: %w", err)
+		}
+	}
+
+	changes := database.DiffSchemas(baselineInfo, schemaInfo)
+	for _, change := range changes {
+		fmt.Fprintln(w, change)
+	}
+	if len(changes) > 0 {
+		return fmt.Errorf("%d difference(s) found: %w", len(changes), database.ErrSchemaDrift)
+	}
+	log.Println("Schema matches the baseline.")
+	return nil
+}
+
+// DumpSchema reads the schema of cfg's database and writes it to w as JSON, or as YAML if yaml is
+// set. The dump can be read back as a schema snapshot, e.g. by --schema-cache or schema diff.
+// Like DiffSchema, it writes the schema as the database reports it.
+func DumpSchema(ctx context.Context, cfg Config, yaml bool, w io.Writer) error {
+	connOptions, err := cfg.dbConnOptions()
+	if err != nil {
+		return err
+	}
+	schemaInfo, err := readLiveSchema(ctx, cfg.DBType, cfg.schemaConnStr(), cfg.DBSchemaName, connOptions)
+	if err != nil {
+		return err
+	}
+	write := database.WriteSchemaSnapshot
+	if yaml {
+		write = database.WriteSchemaSnapshotYAML
+	}
+	if err := write(w, cfg.DBType, cfg.DBSchemaName, schemaInfo); err != nil {
+		return fmt.Errorf("error writing schema dump: %w", err)
+	}
+	return nil
+}
+
+// schemaConnStr returns the connection string the schema is read through: the read endpoint if
+// there is one.
+func (cfg Config) schemaConnStr() string {
+	if cfg.ReadDBConnStr != "" {
+		return cfg.ReadDBConnStr
+	}
+	return cfg.DBConnStr
+}
+
+// readLiveSchema connects to a database with options just to read its schema.
+func readLiveSchema(ctx context.Context, dbType, dbConnStr, schemaName string, options database.ConnOptions) (map[string]database.DBInfo, error) {
+	connStr, err := secrets.ResolveConnStr(dbConnStr)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving database connection string: %w", err)
+	}
+	dbClient, err := database.NewDBClient(ctx, dbType, connStr, options)
+	if err != nil {
+		return nil, fmt.Errorf("error creating database client: %w", err)
+	}
+	defer dbClient.Close()
+
+	schemaInfo, err := dbClient.GetSchemaInfo(ctx, schemaName)
+	if err != nil {
+		return nil, fmt.Errorf("error getting database schema info: %w", err)
+	}
+	return schemaInfo, nil
+}
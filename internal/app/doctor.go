@@ -0,0 +1,119 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/k-wa-wa/db-auto-importer/internal/importer"
+)
+
+// ErrCheckFailed is returned by Doctor when a check failed.
+var ErrCheckFailed = errors.New("pre-flight check failed")
+
+// doctorReport writes the result of each check of Doctor.
+type doctorReport struct {
+	w      io.Writer
+	failed int
+}
+
+func (r *doctorReport) ok(format string, args ...interface{}) {
+	fmt.Fprintf(r.w, "OK    %s\n", fmt.Sprintf(format, args...))
+}
+
+// fail reports a failed check with a hint on how to fix it.
+func (r *doctorReport) fail(hint, format string, args ...interface{}) {
+	r.failed++
+	fmt.Fprintf(r.w, "FAIL  %s\n      hint: %s\n", fmt.Sprintf(format, args...), hint)
+}
+
+// Doctor checks, before a long import starts, that the database can be reached, that the schema
+// is visible, that the CSV files of cfg.CSVDir match its tables and that the connection has the
+// privileges the import needs on every table it would write: SELECT and INSERT, and UPDATE on
+// the tables whose foreign keys are deferred to break a cycle. The result of each check is
+// written to w. Nothing is imported and no row is changed.
+func Doctor(ctx context.Context, cfg Config, w io.Writer) error {
+	report := &doctorReport{w: w}
+	s, err := openSession(ctx, cfg)
+	if errors.Is(err, database.ErrConnectionFailed) {
+		report.fail("check --db, that the database is reachable from this host and the user name and password", "connection: %v", err)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	report.ok("connection: %s", cfg.DBType)
+
+	if len(s.schemaInfo) == 0 {
+		report.fail("check --schema and that the user may read the catalog of the schema (e.g. USAGE on the schema in PostgreSQL)", "schema: no tables found")
+		return fmt.Errorf("%d check(s) failed: %w", report.failed, ErrCheckFailed)
+	}
+	report.ok("schema: %d table(s)", len(s.schemaInfo))
+
+	imp, err := s.newImporter(cfg)
+	if err != nil {
+		return err
+	}
+	tables := slices.Sorted(maps.Keys(s.schemaInfo))
+	deferred := map[string]bool{}
+	plan, err := imp.PlanImport(ctx, importer.DirSource(cfg.CSVDir))
+	if err != nil {
+		// The privileges are still worth checking, on every table
+		report.fail("see the error; \"graph order\" shows the import order", "import plan: %v", err)
+	} else {
+		tables = tables[:0]
+		for _, table := range plan.Tables {
+			tables = append(tables, table.Table)
+		}
+		for _, fk := range plan.DeferredForeignKeys {
+			deferred[fk.TableName] = true
+		}
+		if len(plan.UnmappedFiles) > 0 && cfg.UnmappedFilePolicy == importer.UnmappedFileFail {
+			report.fail("rename the files after their tables or use --unmapped-files=warn", "CSV files: no table for %s", strings.Join(plan.UnmappedFiles, ", "))
+		} else {
+			report.ok("CSV files: %d table(s) to import, %d file(s) with no table", len(plan.Tables), len(plan.UnmappedFiles))
+		}
+	}
+
+	checker, ok := s.dbClient.(database.AccessChecker)
+	if !ok {
+		report.ok("table privileges: not checked, the database cannot check them")
+		tables = nil
+	}
+	for _, table := range tables {
+		privileges := []string{database.PrivilegeSelect, database.PrivilegeInsert}
+		if deferred[table] {
+			privileges = append(privileges, database.PrivilegeUpdate)
+		}
+		err := checker.CheckTableAccess(ctx, s.schemaInfo[table], privileges)
+		var accessErr *database.TableAccessError
+		switch {
+		case errors.As(err, &accessErr):
+			report.fail(grantHint(cfg, accessErr), "table %s: %s failed: %v", table, accessErr.Privilege, accessErr.Err)
+		case err != nil:
+			return fmt.Errorf("error checking table %s: %w", table, err)
+		default:
+			report.ok("table %s: %s", table, strings.Join(privileges, ", "))
+		}
+	}
+
+	if report.failed > 0 {
+		return fmt.Errorf("%d check(s) failed: %w", report.failed, ErrCheckFailed)
+	}
+	return nil
+}
+
+// grantHint suggests the statement granting a missing privilege.
+func grantHint(cfg Config, err *database.TableAccessError) string {
+	table := err.TableName
+	if cfg.DBSchemaName != "" {
+		table = cfg.DBSchemaName + "." + table
+	}
+	return fmt.Sprintf("GRANT %s ON %s TO <user>, or check that the table exists", err.Privilege, table)
+}
@@ -0,0 +1,58 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/k-wa-wa/db-auto-importer/internal/graph"
+	"github.com/k-wa-wa/db-auto-importer/internal/importer"
+)
+
+// Process exit codes reported by the db-auto-importer binary.
+const (
+	ExitOK                = 0   // Import completed without errors.
+	ExitError             = 1   // Any failure not covered by a more specific code.
+	ExitUsage             = 2   // Invalid command-line arguments.
+	ExitConnectionFailure = 3   // The database could not be reached.
+	ExitSchemaMismatch    = 4   // CSV files or foreign keys do not match the database schema, or schema diff found differences.
+	ExitPartialFailure    = 5   // The import finished but some records were rejected.
+	ExitCycleDetected     = 6   // Foreign key dependencies contain a cycle.
+	ExitValidationFailed  = 7   // validate found problems in the CSV files, or they do not match their manifest.
+	ExitCheckFailed       = 8   // doctor found a problem that would make the import fail.
+	ExitInterrupted       = 130 // The run was cancelled with Ctrl+C.
+)
+
+// failedRowsError returns an error that ExitCode maps to ExitPartialFailure if n records could not
+// be inserted, and nil otherwise. Imports report the records in their result instead of an error.
+func failedRowsError(n int) error {
+	if n == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d record(s) could not be inserted: %w", n, database.ErrRowInsert)
+}
+
+// ExitCode maps an error returned by Run to the process exit code.
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, context.Canceled):
+		return ExitInterrupted
+	case errors.Is(err, database.ErrConnectionFailed):
+		return ExitConnectionFailure
+	case errors.Is(err, graph.ErrCycleDetected):
+		return ExitCycleDetected
+	case errors.Is(err, importer.ErrUnmappedFiles), errors.Is(err, database.ErrMissingParentTable), errors.Is(err, database.ErrSchemaDrift):
+		return ExitSchemaMismatch
+	case errors.Is(err, database.ErrRowInsert):
+		return ExitPartialFailure
+	case errors.Is(err, importer.ErrValidationFailed), errors.Is(err, importer.ErrManifestMismatch):
+		return ExitValidationFailed
+	case errors.Is(err, ErrCheckFailed):
+		return ExitCheckFailed
+	default:
+		return ExitError
+	}
+}
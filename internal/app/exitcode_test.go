@@ -0,0 +1,38 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/k-wa-wa/db-auto-importer/internal/graph"
+	"github.com/k-wa-wa/db-auto-importer/internal/importer"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"エラーがない場合は0を返すこと", nil, ExitOK},
+		{"接続エラーの場合", fmt.Errorf("error creating database client: %w", &database.ConnectionError{DBType: "PostgreSQL", Err: errors.New("refused")}), ExitConnectionFailure},
+		{"循環参照の場合", fmt.Errorf("failed to determine import order: %w", &graph.CycleError{Tables: []string{"a", "b"}}), ExitCycleDetected},
+		{"対応するテーブルがないCSVの場合", fmt.Errorf("%w: x.csv", importer.ErrUnmappedFiles), ExitSchemaMismatch},
+		{"親テーブルが存在しない場合", &database.MissingParentTableError{TableName: "a", ConstraintName: "fk"}, ExitSchemaMismatch},
+		{"一部のレコードが失敗した場合", failedRowsError(1), ExitPartialFailure},
+		{"検証で問題が見つかった場合", fmt.Errorf("2 issue(s) found: %w", importer.ErrValidationFailed), ExitValidationFailed},
+		{"マニフェストと一致しない場合", fmt.Errorf("1 difference(s) from manifest m.json: %w", importer.ErrManifestMismatch), ExitValidationFailed},
+		{"事前チェックが失敗した場合", fmt.Errorf("1 check(s) failed: %w", ErrCheckFailed), ExitCheckFailed},
+		{"中断された場合", fmt.Errorf("error importing CSV files: %w", context.Canceled), ExitInterrupted},
+		{"その他のエラーの場合", errors.New("boom"), ExitError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ExitCode(tt.err))
+		})
+	}
+}
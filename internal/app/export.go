@@ -0,0 +1,65 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Export writes the rows of the tables (or of cfg.Tables) to CSV files in dir, named and in the
+// order import reads them, so that the data can be imported into another database. where selects
+// the rows of some tables by an SQL condition; see importer.Importer.ExportCSVFiles.
+func Export(ctx context.Context, cfg Config, dir string, where map[string]string) error {
+	s, err := openSession(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	imp, err := s.newImporter(cfg)
+	if err != nil {
+		return err
+	}
+	if err := imp.ExportCSVFiles(ctx, dir, where); err != nil {
+		return fmt.Errorf("error exporting tables: %w", err)
+	}
+	return nil
+}
+
+// snapshotLayout names the directory of a snapshot after the time it was taken, in UTC.
+const snapshotLayout = "20060102T150405Z"
+
+// Snapshot exports the tables (or cfg.Tables) like Export to a new directory in baseDir named
+// after the current time, e.g. fixtures/20240601T120000Z, and writes its path to w. The directory
+// also holds schema.json, the schema the rows were read with, so that a test can check with
+// schema diff that the fixture still fits the database before importing it.
+func Snapshot(ctx context.Context, cfg Config, baseDir string, where map[string]string, w io.Writer) error {
+	s, err := openSession(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	imp, err := s.newImporter(cfg)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(baseDir, 0o755); err != nil {
+		return fmt.Errorf("error creating snapshot directory: %w", err)
+	}
+	dir := filepath.Join(baseDir, time.Now().UTC().Format(snapshotLayout))
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		return fmt.Errorf("error creating snapshot directory: %w", err)
+	}
+	if err := imp.ExportCSVFiles(ctx, dir, where); err != nil {
+		return fmt.Errorf("error exporting tables: %w", err)
+	}
+	if err := writeSchemaCache(filepath.Join(dir, "schema.json"), cfg, s.schemaInfo); err != nil {
+		return err
+	}
+	fmt.Fprintln(w, dir)
+	return nil
+}
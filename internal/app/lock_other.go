@@ -0,0 +1,24 @@
+//go:build !unix
+
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// acquireLock creates the file at path exclusively and writes the PID to it. Without flock, a
+// file left behind by a crashed run has to be removed by hand.
+func acquireLock(path string) (release func(), err error) {
+	lock, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if errors.Is(err, os.ErrExist) {
+		return nil, fmt.Errorf("%w: lock file %s exists (remove it if no import is running)", ErrLocked, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create lock file %s: %w", path, err)
+	}
+	fmt.Fprintf(lock, "%d\n", os.Getpid())
+	lock.Close()
+	return func() { os.Remove(path) }, nil
+}
@@ -0,0 +1,36 @@
+//go:build unix
+
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_acquireLock(t *testing.T) {
+	t.Run("ロック中は取得できず解放後は取得できること", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "import.lock")
+		release, err := acquireLock(path)
+		require.NoError(t, err)
+
+		_, err = acquireLock(path)
+		assert.ErrorIs(t, err, ErrLocked)
+
+		release()
+		release, err = acquireLock(path)
+		require.NoError(t, err)
+		release()
+	})
+
+	t.Run("異常終了で残ったファイルはロックを妨げないこと", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "import.lock")
+		require.NoError(t, os.WriteFile(path, []byte("12345\n"), 0o644))
+		release, err := acquireLock(path)
+		require.NoError(t, err)
+		release()
+	})
+}
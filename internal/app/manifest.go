@@ -0,0 +1,50 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/importer"
+)
+
+// GenerateManifest writes a manifest of the CSV files of cfg.CSVDir to path, to be checked with
+// VerifyManifest or Config.Manifest after the directory has been copied to where it is imported.
+func GenerateManifest(cfg Config, path string) error {
+	m, err := importer.GenerateManifest(cfg.CSVDir, cfg.HasHeader)
+	if err != nil {
+		return fmt.Errorf("error generating manifest: %w", err)
+	}
+	if err := m.Save(path); err != nil {
+		return err
+	}
+	log.Printf("Manifest of %d CSV file(s) written to %s.\n", len(m.Files), path)
+	return nil
+}
+
+// VerifyManifest checks the CSV files of cfg.CSVDir against the manifest at path. Each difference
+// is written to w.
+func VerifyManifest(cfg Config, path string, w io.Writer) error {
+	return verifyManifest(cfg, path, func(problem string) { fmt.Fprintln(w, problem) })
+}
+
+// verifyManifest checks the CSV files of cfg.CSVDir against the manifest at path, passing each
+// difference to report.
+func verifyManifest(cfg Config, path string, report func(problem string)) error {
+	m, err := importer.LoadManifest(path)
+	if err != nil {
+		return err
+	}
+	problems, err := m.Verify(cfg.CSVDir, cfg.HasHeader)
+	if err != nil {
+		return fmt.Errorf("error verifying CSV files: %w", err)
+	}
+	for _, problem := range problems {
+		report(problem)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%d difference(s) from manifest %s: %w", len(problems), path, importer.ErrManifestMismatch)
+	}
+	log.Printf("CSV files match manifest %s.\n", path)
+	return nil
+}
@@ -0,0 +1,43 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/k-wa-wa/db-auto-importer/internal/metrics"
+	"log"
+	"net"
+	"net/http"
+	"time"
+)
+
+// withMetrics serves Prometheus metrics on cfg.MetricsAddr while run runs, passing run a copy of
+// cfg whose Metrics collects them. Without an address, or when cfg.Metrics is already set by an
+// enclosing long-running mode, run is called with cfg as is.
+func withMetrics(ctx context.Context, cfg Config, run func(cfg Config) error) error {
+	if cfg.MetricsAddr == "" || cfg.Metrics != nil {
+		return run(cfg)
+	}
+	listener, err := net.Listen("tcp", cfg.MetricsAddr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s for metrics: %w", cfg.MetricsAddr, err)
+	}
+	registry := metrics.NewRegistry()
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", registry)
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Warning: metrics server stopped: %v\n", err)
+		}
+	}()
+	log.Printf("Serving metrics on http://%s/metrics.\n", listener.Addr())
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	cfg.Metrics = registry
+	return run(cfg)
+}
@@ -0,0 +1,41 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/k-wa-wa/db-auto-importer/internal/importer"
+	"github.com/k-wa-wa/db-auto-importer/internal/secrets"
+)
+
+// importData imports the tables of cfg.SourceDBConnStr if it is set, and the CSV files of
+// cfg.CSVDir otherwise.
+func importData(ctx context.Context, imp *importer.Importer, cfg Config) (*importer.ImportResult, error) {
+	if cfg.SourceDBConnStr == "" {
+		// Pass the hasHeader flag to the importer
+		return imp.ImportCSVFiles(ctx, cfg.CSVDir, cfg.HasHeader)
+	}
+
+	connStr, err := secrets.ResolveConnStr(cfg.SourceDBConnStr)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving source database connection string: %w", err)
+	}
+	connOptions, err := cfg.connOptions()
+	if err != nil {
+		return nil, err
+	}
+	sourceClient, err := database.NewDBClient(ctx, cfg.SourceDBType, connStr, connOptions)
+	if err != nil {
+		return nil, fmt.Errorf("error creating source database client: %w", err)
+	}
+	defer sourceClient.Close()
+
+	sourceSchema, err := sourceClient.GetSchemaInfo(ctx, cfg.SourceSchemaName)
+	if err != nil {
+		return nil, fmt.Errorf("error getting source database schema info: %w", err)
+	}
+	log.Printf("Importing %d table(s) from the %s source database.\n", len(sourceSchema), cfg.SourceDBType)
+	return imp.ImportDatabase(ctx, sourceClient, sourceSchema)
+}
@@ -0,0 +1,84 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/k-wa-wa/db-auto-importer/internal/importer"
+	"github.com/k-wa-wa/db-auto-importer/internal/notify"
+	"github.com/k-wa-wa/db-auto-importer/internal/secrets"
+	"github.com/k-wa-wa/db-auto-importer/internal/version"
+)
+
+// notificationTimeout bounds the post of a notification, so that an unreachable webhook
+// delays the end of a run only briefly.
+const notificationTimeout = 10 * time.Second
+
+// withNotification posts a summary of the imports of run to cfg.NotifyURL: one for each import,
+// which in watch mode is each re-import, and one for the run if it ended before or without
+// an import, e.g. because the database could not be reached. A failed post is logged as a
+// warning and does not change the outcome of the run.
+func withNotification(ctx context.Context, cfg Config, run func(cfg Config) error) error {
+	if cfg.NotifyURL == "" {
+		return run(cfg)
+	}
+	// The URL of a webhook is a secret, so it may be a reference to a secrets manager
+	webhookURL, err := secrets.ResolveConnStr(cfg.NotifyURL)
+	if err != nil {
+		return fmt.Errorf("error resolving notification URL: %w", err)
+	}
+	n := &runNotifier{webhook: &notify.Webhook{URL: webhookURL, Format: cfg.NotifyFormat}, started: time.Now()}
+	cfg.notifier = n
+	err = run(cfg)
+	if !n.sent {
+		n.post(context.WithoutCancel(ctx), nil, err)
+	}
+	return err
+}
+
+// runNotifier is the importer.Observer that posts the notification of each import.
+type runNotifier struct {
+	webhook *notify.Webhook
+	started time.Time
+	sent    bool
+}
+
+var _ importer.Observer = (*runNotifier)(nil)
+
+func (n *runNotifier) FileStarted(table, filePath string)                    {}
+func (n *runNotifier) RowsProcessed(table string, done, total int)           {}
+func (n *runNotifier) RowFailed(err *database.RowInsertError)                {}
+func (n *runNotifier) FileFinished(summary importer.TableSummary, err error) {}
+
+func (n *runNotifier) ImportFinished(summaries []importer.TableSummary, err error) {
+	n.post(context.Background(), summaries, err)
+}
+
+// post sends the notification of an import that ended with err.
+func (n *runNotifier) post(ctx context.Context, summaries []importer.TableSummary, err error) {
+	result := importer.ImportResult{Tables: summaries}
+	run := notify.Run{
+		Status:          "success",
+		Version:         version.String(),
+		Tables:          len(summaries),
+		RowsInserted:    result.RowsInserted(),
+		RowsUpdated:     result.RowsUpdated(),
+		RowsRejected:    result.RowsRejected(),
+		ParentsCreated:  result.ParentsCreated(),
+		DurationSeconds: time.Since(n.started).Seconds(),
+	}
+	if err != nil {
+		run.Status = "failure"
+		run.Error = err.Error()
+	}
+	ctx, cancel := context.WithTimeout(ctx, notificationTimeout)
+	defer cancel()
+	if err := n.webhook.Post(ctx, run); err != nil {
+		log.Printf("Warning: %v\n", err)
+	}
+	n.sent = true
+	n.started = time.Now() // A re-import in watch mode is timed from here
+}
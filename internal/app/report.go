@@ -0,0 +1,41 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/importer"
+	"github.com/k-wa-wa/db-auto-importer/internal/report"
+	"github.com/k-wa-wa/db-auto-importer/internal/version"
+)
+
+// startReport starts collecting the parent records imp creates for the HTML report written to
+// path. The returned function stops collecting and writes the report of the import that ended
+// with result and err. Without a path, nothing is collected or written.
+func startReport(imp *importer.Importer, path string) func(result *importer.ImportResult, err error) error {
+	if path == "" {
+		return func(*importer.ImportResult, error) error { return nil }
+	}
+	started := time.Now()
+	events := make(chan importer.Event)
+	collected := make(chan map[string][]importer.ParentCreated, 1)
+	go func() { collected <- report.CollectParents(events) }()
+	imp.Events = events
+
+	return func(result *importer.ImportResult, err error) error {
+		imp.Events = nil
+		close(events)
+		run := report.Run{Version: version.String(), Started: started, Result: result, Err: err, Parents: <-collected}
+
+		file, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("failed to create report file %s: %w", path, err)
+		}
+		if err := report.WriteHTML(file, run); err != nil {
+			file.Close()
+			return fmt.Errorf("failed to write report file %s: %w", path, err)
+		}
+		return file.Close()
+	}
+}
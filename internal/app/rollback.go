@@ -0,0 +1,47 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+)
+
+// ErrRollbackNotConfirmed is returned by Rollback when confirm declines the plan.
+var ErrRollbackNotConfirmed = errors.New("rollback not confirmed")
+
+// Rollback deletes the rows that the run runID tagged in cfg.BatchColumn, each table before the
+// tables it references, as a way back from a bad import. The plan is written to w and carried out
+// only if confirm returns true.
+func Rollback(ctx context.Context, cfg Config, runID string, w io.Writer, confirm func() bool) error {
+	s, err := openSession(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	imp, err := s.newImporter(cfg)
+	if err != nil {
+		return err
+	}
+	plan, err := imp.PlanRollback()
+	if err != nil {
+		return fmt.Errorf("error planning rollback: %w", err)
+	}
+	fmt.Fprintf(w, "Rows with %s = '%s' will be deleted from these tables, in this order:\n", cfg.BatchColumn, runID)
+	writeDeletionPlan(w, plan)
+	if !confirm() {
+		return ErrRollbackNotConfirmed
+	}
+	deleted, err := imp.RollbackRun(ctx, plan, runID)
+	if err != nil {
+		return fmt.Errorf("error rolling back run %s after deleting %d row(s): %w", runID, deleted, err)
+	}
+	if deleted == 0 {
+		log.Printf("Warning: No rows of run %s found.\n", runID)
+		return nil
+	}
+	log.Printf("Rolled back run %s: deleted %d row(s).\n", runID, deleted)
+	return nil
+}
@@ -0,0 +1,49 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"github.com/k-wa-wa/db-auto-importer/dbimport/importv1"
+	"github.com/k-wa-wa/db-auto-importer/internal/rpcserver"
+	"log"
+	"net"
+
+	"google.golang.org/grpc"
+)
+
+// Serve runs the gRPC ImportService on addr until ctx is cancelled. cfg.HasHeader is the
+// default for requests that do not say whether their CSV data has a header row.
+func Serve(ctx context.Context, cfg Config, addr string) error {
+	return withMetrics(ctx, cfg, func(cfg Config) error { return serve(ctx, cfg, addr) })
+}
+
+func serve(ctx context.Context, cfg Config, addr string) error {
+	session, err := Open(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	server := grpc.NewServer()
+	importv1.RegisterImportServiceServer(server, rpcserver.New(session.Importer, cfg.HasHeader))
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Serve(listener)
+	}()
+	log.Printf("Serving the import service on %s. Press Ctrl+C to stop.\n", listener.Addr())
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("import service stopped: %w", err)
+	case <-ctx.Done():
+		// Let running imports finish; they are cancelled with their requests' contexts otherwise
+		server.GracefulStop()
+		log.Println("Import service stopped.")
+		return nil
+	}
+}
@@ -0,0 +1,47 @@
+package app
+
+import (
+	"context"
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/k-wa-wa/db-auto-importer/internal/importer"
+)
+
+// Session is an open database connection with the schema read and an importer configured
+// from a Config. It backs the public dbimport package.
+type Session struct {
+	s        *session
+	Importer *importer.Importer
+}
+
+// Open connects to the database described by cfg and configures an importer for it.
+// The caller must Close the session.
+func Open(ctx context.Context, cfg Config) (*Session, error) {
+	s, err := openSession(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	imp, err := s.newImporter(cfg)
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+	if err := s.setEncryptor(cfg, imp); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return &Session{s: s, Importer: imp}, nil
+}
+
+// Schema returns the tables read from the database.
+func (s *Session) Schema() map[string]database.DBInfo {
+	return s.s.schemaInfo
+}
+
+// Rows returns the per-table row counts of the config file's rows section.
+func (s *Session) Rows() map[string]int {
+	return s.s.fileCfg.Rows
+}
+
+func (s *Session) Close() error {
+	return s.s.Close()
+}
@@ -0,0 +1,26 @@
+package app
+
+import (
+	"context"
+	"fmt"
+)
+
+// WriteTemplates writes a CSV file with the header and a hint row, but no rows, for each of the
+// tables (or of cfg.Tables) to dir, as a valid starting point for writing the data to import.
+// Existing files are only replaced if overwrite is set.
+func WriteTemplates(ctx context.Context, cfg Config, dir string, overwrite bool) error {
+	s, err := openSession(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	imp, err := s.newImporter(cfg)
+	if err != nil {
+		return err
+	}
+	if err := imp.WriteCSVTemplates(dir, overwrite); err != nil {
+		return fmt.Errorf("error writing CSV templates: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/importer"
+)
+
+// ErrTruncateNotConfirmed is returned by Truncate when confirm declines the plan.
+var ErrTruncateNotConfirmed = errors.New("truncate not confirmed")
+
+// Truncate empties cfg.Tables or, if none are given, the tables with a CSV file in cfg.CSVDir,
+// each before the tables it references, e.g. to reset an environment before a fresh import. The
+// plan is written to w and carried out only if confirm returns true.
+func Truncate(ctx context.Context, cfg Config, w io.Writer, confirm func() bool) error {
+	s, err := openSession(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	imp, err := s.newImporter(cfg)
+	if err != nil {
+		return err
+	}
+	plan, err := imp.PlanTruncate(cfg.CSVDir)
+	if err != nil {
+		return fmt.Errorf("error planning truncate: %w", err)
+	}
+	fmt.Fprintln(w, "All rows of these tables will be deleted, in this order:")
+	writeDeletionPlan(w, plan)
+	if !confirm() {
+		return ErrTruncateNotConfirmed
+	}
+	if err := imp.TruncateTables(ctx, plan); err != nil {
+		return fmt.Errorf("error emptying tables: %w", err)
+	}
+	return nil
+}
+
+// writeDeletionPlan lists the tables of plan in order, with the foreign keys cleared first.
+func writeDeletionPlan(w io.Writer, plan *importer.TruncatePlan) {
+	for _, tableName := range plan.Tables {
+		line := "  " + tableName
+		if referencing := plan.Referencing[tableName]; len(referencing) > 0 {
+			line += fmt.Sprintf(" (fails if rows of %s still reference it)", strings.Join(referencing, ", "))
+		}
+		fmt.Fprintln(w, line)
+	}
+	for _, fk := range plan.Cleared {
+		fmt.Fprintf(w, "First, %s of table %s will be set to NULL to break a cycle of foreign keys.\n", strings.Join(fk.ColumnNames, ", "), fk.TableName)
+	}
+}
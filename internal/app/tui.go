@@ -0,0 +1,37 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/importer"
+	"github.com/k-wa-wa/db-auto-importer/internal/tui"
+)
+
+// startTUI shows the interactive terminal UI of the import of imp on the terminal of the
+// process until the returned function is called. The returned context is the context of the
+// import, cancelled when the user quits.
+func startTUI(ctx context.Context, imp *importer.Importer, cfg Config) (context.Context, func(), error) {
+	if !importer.IsTerminal(os.Stdin) || !importer.IsTerminal(os.Stderr) {
+		return nil, nil, errors.New("the terminal UI needs an interactive terminal on stdin and stderr")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	ui := tui.New(os.Stdin, os.Stderr, cancel)
+	if cfg.SourceDBConnStr == "" {
+		// The tables of a source database only appear as their imports start
+		if plan, err := imp.PlanImport(ctx, importer.DirSource(cfg.CSVDir)); err == nil {
+			ui.SetTables(plan.Tables)
+		}
+	}
+	imp.Observer = importer.MultiObserver(imp.Observer, ui)
+	imp.Control = ui.Control()
+	if err := ui.Start(); err != nil {
+		cancel()
+		return nil, nil, err
+	}
+	return ctx, func() {
+		ui.Stop()
+		cancel()
+	}, nil
+}
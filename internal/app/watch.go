@@ -0,0 +1,75 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"github.com/k-wa-wa/db-auto-importer/internal/importer"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchSettleTime is how long a CSV file must stay unchanged before it is imported, so that
+// files still being copied into the directory are not read half-written. Replaced in tests.
+var watchSettleTime = 2 * time.Second
+
+// watch re-imports CSV files as they appear or change in csvDir until ctx is cancelled.
+// Failed imports are logged and do not stop watching.
+func watch(ctx context.Context, imp *importer.Importer, cfg Config) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to start watching %s: %w", cfg.CSVDir, err)
+	}
+	defer watcher.Close()
+	if err := watcher.Add(cfg.CSVDir); err != nil {
+		return fmt.Errorf("failed to watch %s: %w", cfg.CSVDir, err)
+	}
+
+	log.Printf("Watching %s for new or changed CSV files. Press Ctrl+C to stop.\n", cfg.CSVDir)
+	pending := make(map[string]bool)
+	settle := time.NewTimer(watchSettleTime)
+	settle.Stop()
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if !event.Has(fsnotify.Create) && !event.Has(fsnotify.Write) {
+				continue
+			}
+			if !importer.IsInputFile(event.Name) {
+				continue
+			}
+			pending[event.Name] = true
+			settle.Reset(watchSettleTime)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			log.Printf("Warning: error watching %s: %v\n", cfg.CSVDir, err)
+		case <-settle.C:
+			files := make([]string, 0, len(pending))
+			for filePath := range pending {
+				files = append(files, filePath)
+			}
+			sort.Strings(files)
+			pending = make(map[string]bool)
+
+			log.Printf("Importing changed CSV files: %s\n", strings.Join(files, ", "))
+			_, importErr := imp.ImportFiles(ctx, files, cfg.HasHeader)
+			if err := reportSummary(imp.Summary(), cfg.SummaryFile); err != nil {
+				log.Printf("Warning: %v\n", err)
+			}
+			if importErr != nil {
+				log.Printf("Error importing changed CSV files: %v\n", importErr)
+			}
+		case <-ctx.Done():
+			log.Println("Stopped watching.")
+			return nil
+		}
+	}
+}
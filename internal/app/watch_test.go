@@ -0,0 +1,65 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/k-wa-wa/db-auto-importer/internal/importer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// watchClient is the DBClient of watch tests. The methods it does not implement panic through
+// the nil DBClient; the imports stop in the BeforeTable hook before using them.
+type watchClient struct {
+	database.DBClient
+}
+
+func Test_watch(t *testing.T) {
+	settleTime := watchSettleTime
+	watchSettleTime = 50 * time.Millisecond
+	t.Cleanup(func() { watchSettleTime = settleTime })
+
+	t.Run("ファイルを変更すると1回だけ再インポートされること", func(t *testing.T) {
+		dir := t.TempDir()
+		imported := make(chan string, 10)
+		imp, err := importer.NewImporter(map[string]database.DBInfo{"users": {TableName: "users"}}, watchClient{})
+		require.NoError(t, err)
+		imp.Hooks.BeforeTable = func(ctx context.Context, table, filePath string) error {
+			imported <- filePath
+			return errors.New("stop before inserting")
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() { done <- watch(ctx, imp, Config{CSVDir: dir, HasHeader: true}) }()
+		// Let the watcher start before the file changes
+		time.Sleep(200 * time.Millisecond)
+
+		filePath := filepath.Join(dir, "users.csv")
+		require.NoError(t, os.WriteFile(filePath, []byte("id,name\n"), 0o644))
+		f, err := os.OpenFile(filePath, os.O_APPEND|os.O_WRONLY, 0)
+		require.NoError(t, err)
+		_, err = f.WriteString("1,Alice\n")
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		select {
+		case got := <-imported:
+			assert.Equal(t, filePath, got)
+		case <-time.After(5 * time.Second):
+			t.Fatal("the changed file was not imported")
+		}
+		time.Sleep(5 * watchSettleTime)
+		assert.Empty(t, imported, "変更がまとめて1回だけインポートされること")
+
+		cancel()
+		assert.NoError(t, <-done)
+	})
+}
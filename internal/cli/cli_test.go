@@ -0,0 +1,222 @@
+package cli
+
+import (
+	"bytes"
+	"flag"
+	"github.com/k-wa-wa/db-auto-importer/internal/app"
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Run(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"helpコマンドは0を返すこと", []string{"help"}, app.ExitOK},
+		{"--versionは0を返すこと", []string{"--version"}, app.ExitOK},
+		{"-hはコマンドのフラグを表示して0を返すこと", []string{"import", "-h"}, app.ExitOK},
+		{"未知のコマンドは2を返すこと", []string{"unknown"}, app.ExitUsage},
+		{"未知のフラグは2を返すこと", []string{"schema", "--no-such-flag"}, app.ExitUsage},
+		{"コマンド省略時もフラグが検証されること", []string{"--no-such-flag"}, app.ExitUsage},
+		{"余分な引数は2を返すこと", []string{"graph", "extra"}, app.ExitUsage},
+		{"doctorは引数を取らないこと", []string{"doctor", "extra"}, app.ExitUsage},
+		{"exportの--whereは条件が必須であること", []string{"export", "--where", "users"}, app.ExitUsage},
+		{"snapshotは引数を取らないこと", []string{"snapshot", "extra"}, app.ExitUsage},
+		{"diffは引数を取らないこと", []string{"diff", "extra"}, app.ExitUsage},
+		{"templateは引数を取らないこと", []string{"template", "extra"}, app.ExitUsage},
+		{"truncateは引数を取らないこと", []string{"truncate", "extra"}, app.ExitUsage},
+		{"rollbackは--run-idが必須であること", []string{"rollback"}, app.ExitUsage},
+		{"rollbackは--batch-columnが必須であること", []string{"rollback", "--run-id", "run-1"}, app.ExitUsage},
+		{"manifestはサブコマンドが必須であること", []string{"manifest"}, app.ExitUsage},
+		{"manifestは未知のサブコマンドで2を返すこと", []string{"manifest", "check"}, app.ExitUsage},
+		{"importの--manifestは--watchと併用できないこと", []string{"import", "--manifest", "m.json", "--watch"}, app.ExitUsage},
+		{"convertの未知の形式は2を返すこと", []string{"convert", "--to", "xlsx"}, app.ExitUsage},
+		{"daemonは--scheduleが必須であること", []string{"daemon"}, app.ExitUsage},
+		{"daemonは不正な--scheduleで2を返すこと", []string{"daemon", "--schedule", "61 * * * *"}, app.ExitUsage},
+		{"completionはシェルを指定すること", []string{"completion"}, app.ExitUsage},
+		{"completionは未対応のシェルで2を返すこと", []string{"completion", "tcsh"}, app.ExitUsage},
+		{"completionはbashのスクリプトを出力すること", []string{"completion", "bash"}, app.ExitOK},
+		{"不正な--unmapped-filesは2を返すこと", []string{"import", "--unmapped-files", "bogus"}, app.ExitUsage},
+		{"不正な--log-formatは2を返すこと", []string{"import", "--log-format", "xml"}, app.ExitUsage},
+		{"不正な--max-memoryは2を返すこと", []string{"import", "--max-memory", "-1GB"}, app.ExitUsage},
+		{"不正な--notify-formatは2を返すこと", []string{"import", "--notify-format", "teams"}, app.ExitUsage},
+		{"--watchと--source-dbは併用できないこと", []string{"import", "--watch", "--source-db", "postgres://"}, app.ExitUsage},
+		{"不正な--debug-sqlは2を返すこと", []string{"schema", "--debug-sql", "all"}, app.ExitUsage},
+		{"schema diffは比較対象が必須であること", []string{"schema", "diff"}, app.ExitUsage},
+		{"schema diffは比較対象を1つだけ指定すること", []string{"schema", "diff", "--snapshot", "a.json", "--other-db", "postgres://"}, app.ExitUsage},
+		{"schema dumpの未知の形式は2を返すこと", []string{"schema", "dump", "--dump-format", "xml"}, app.ExitUsage},
+		{"schemaの未知のサブコマンドは2を返すこと", []string{"schema", "drift"}, app.ExitUsage},
+		{"graph exportの未知の形式は2を返すこと", []string{"graph", "export", "--format", "svg"}, app.ExitUsage},
+		{"graphの未知のサブコマンドは2を返すこと", []string{"graph", "draw"}, app.ExitUsage},
+		{"graph orderの余分な引数は2を返すこと", []string{"graph", "order", "extra"}, app.ExitUsage},
+		{"サブコマンドの後の--configも読み込まれること", []string{"schema", "dump", "--config", "missing.yaml"}, app.ExitUsage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			assert.Equal(t, tt.want, Run(tt.args, &stdout, &stderr))
+		})
+	}
+}
+
+func Test_parseSize(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int64
+	}{
+		{"1024", 1024},
+		{"512MB", 512e6},
+		{"2GiB", 2 << 30},
+		{"1.5g", 3 << 29},
+		{"64 kib", 64 << 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value+"が解釈されること", func(t *testing.T) {
+			got, err := parseSize(tt.value)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("数値でない場合はエラーになること", func(t *testing.T) {
+		_, err := parseSize("lots")
+		assert.Error(t, err)
+	})
+}
+
+func Test_splitTables(t *testing.T) {
+	t.Run("カンマ区切りのテーブル名が空白と空要素を除いて分割されること", func(t *testing.T) {
+		assert.Equal(t, []string{"orders", "order_items"}, splitTables(" orders, ,order_items,"))
+	})
+
+	t.Run("空文字列は全テーブルを表すnilになること", func(t *testing.T) {
+		assert.Nil(t, splitTables(""))
+	})
+}
+
+func Test_confirm(t *testing.T) {
+	tests := []struct {
+		name   string
+		answer string
+		want   bool
+	}{
+		{"yで続行すること", "y\n", true},
+		{"大文字のYESでも続行すること", "YES\n", true},
+		{"空の回答では続行しないこと", "\n", false},
+		{"入力がない場合は続行しないこと", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			assert.Equal(t, tt.want, confirm(strings.NewReader(tt.answer), &out, "Delete?"))
+			assert.Equal(t, "Delete? [y/N]: ", out.String())
+		})
+	}
+}
+
+func Test_applyConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("db_type: mysql\nschema: app\nheader: false\nseed: 7\ntls_ca: ca.pem\ntls_server_name: db.internal\n"), 0o644))
+
+	t.Run("コマンドラインで指定されていないフラグに設定ファイルの値が入ること", func(t *testing.T) {
+		fs := flag.NewFlagSet("import", flag.ContinueOnError)
+		conn := addConnectionFlags(fs)
+		csv := addCSVFlags(fs)
+		gen := addGeneratorFlags(fs)
+		require.NoError(t, fs.Parse([]string{"--config", path, "--schema", "cli"}))
+		require.NoError(t, applyConfigFile(fs))
+
+		var cfg app.Config
+		conn.apply(&cfg)
+		require.NoError(t, csv.apply(&cfg))
+		gen.apply(&cfg)
+		assert.Equal(t, "mysql", cfg.DBType)
+		assert.Equal(t, "cli", cfg.DBSchemaName)
+		assert.False(t, cfg.HasHeader)
+		require.NotNil(t, cfg.Seed)
+		assert.Equal(t, int64(7), *cfg.Seed)
+		assert.Equal(t, database.TLSOptions{CAFile: "ca.pem", ServerName: "db.internal"}, cfg.TLS)
+	})
+
+	t.Run("コマンドにないフラグの設定は無視されること", func(t *testing.T) {
+		fs := flag.NewFlagSet("schema", flag.ContinueOnError)
+		addConnectionFlags(fs)
+		require.NoError(t, fs.Parse([]string{"--config", path}))
+		assert.NoError(t, applyConfigFile(fs))
+	})
+
+	t.Run("存在しない設定ファイルはエラーになること", func(t *testing.T) {
+		fs := flag.NewFlagSet("schema", flag.ContinueOnError)
+		addConnectionFlags(fs)
+		require.NoError(t, fs.Parse([]string{"--config", filepath.Join(t.TempDir(), "missing.yaml")}))
+		assert.Error(t, applyConfigFile(fs))
+	})
+
+	t.Run("サブコマンドの後に指定された設定ファイルも読み込まれること", func(t *testing.T) {
+		fs := flag.NewFlagSet("schema", flag.ContinueOnError)
+		conn := addConnectionFlags(fs)
+		require.NoError(t, fs.Parse([]string{"--schema", "cli", "diff", "--config", path}))
+		require.NoError(t, applyConfigFile(fs))
+		require.Equal(t, "diff", fs.Arg(0))
+		require.NoError(t, parseSubcommandFlags(fs))
+
+		var cfg app.Config
+		conn.apply(&cfg)
+		assert.Equal(t, path, cfg.ConfigFile)
+		assert.Equal(t, "mysql", cfg.DBType)
+		assert.Equal(t, "cli", cfg.DBSchemaName)
+	})
+}
+
+func Test_applyEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("db_type: mysql\ndb: file-conn\nschema: file-schema\n"), 0o644))
+	t.Setenv("DBAI_CONFIG", path)
+	t.Setenv("DBAI_DB_URL", "env-conn")
+	t.Setenv("DBAI_READ_DB_URL", "env-read-conn")
+	t.Setenv("DBAI_SCHEMA", "env-schema")
+
+	t.Run("コマンドライン引数、環境変数、設定ファイルの順に優先されること", func(t *testing.T) {
+		fs := flag.NewFlagSet("schema", flag.ContinueOnError)
+		conn := addConnectionFlags(fs)
+		require.NoError(t, fs.Parse([]string{"--schema", "cli-schema"}))
+		require.NoError(t, applyEnv(fs))
+		require.NoError(t, applyConfigFile(fs))
+
+		var cfg app.Config
+		conn.apply(&cfg)
+		assert.Equal(t, path, cfg.ConfigFile)
+		assert.Equal(t, "mysql", cfg.DBType)
+		assert.Equal(t, "env-conn", cfg.DBConnStr)
+		assert.Equal(t, "env-read-conn", cfg.ReadDBConnStr)
+		assert.Equal(t, "cli-schema", cfg.DBSchemaName)
+	})
+
+	t.Run("環境変数でIAM認証を有効にできること", func(t *testing.T) {
+		t.Setenv("DBAI_AWS_IAM_AUTH", "true")
+		fs := flag.NewFlagSet("schema", flag.ContinueOnError)
+		conn := addConnectionFlags(fs)
+		require.NoError(t, fs.Parse(nil))
+		require.NoError(t, applyEnv(fs))
+
+		var cfg app.Config
+		conn.apply(&cfg)
+		assert.True(t, cfg.AWSIAMAuth)
+	})
+
+	t.Run("コマンドにないフラグの環境変数は無視されること", func(t *testing.T) {
+		t.Setenv("DBAI_CSV_DIR", "./data")
+		fs := flag.NewFlagSet("schema", flag.ContinueOnError)
+		addConnectionFlags(fs)
+		require.NoError(t, fs.Parse(nil))
+		assert.NoError(t, applyEnv(fs))
+	})
+}
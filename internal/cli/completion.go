@@ -0,0 +1,195 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/k-wa-wa/db-auto-importer/internal/graph"
+	"github.com/k-wa-wa/db-auto-importer/internal/importer"
+	"github.com/k-wa-wa/db-auto-importer/internal/notify"
+	"io"
+	"strings"
+)
+
+// completionShells lists the shells the completion command can write a script for.
+var completionShells = []string{"bash", "zsh", "fish"}
+
+// completionFlag describes a flag of a command for the completion scripts.
+type completionFlag struct {
+	name    string
+	usage   string
+	isBool  bool     // Takes no value
+	isFile  bool     // Takes a file or directory path
+	isTable bool     // Takes comma-separated table names
+	values  []string // Fixed set of values, if any
+}
+
+// fileFlags are the flags that take a file or directory path.
+var fileFlags = map[string]bool{"config": true, "csv": true, "summary": true, "report": true, "state": true, "lock": true, "schema-cache": true, "snapshot": true, "out": true, "dir": true, "in": true, "manifest": true, "watermark-file": true, "tls-ca": true, "tls-cert": true, "tls-key": true}
+
+// tableFlags are the flags that take table names, completed from `schema --names`.
+// The connection comes from the DBAI_* environment variables or the default config file.
+var tableFlags = map[string]bool{"tables": true}
+
+// flagValues lists the accepted values of flags with a fixed set of values.
+func flagValues(name string) []string {
+	switch name {
+	case "db-type", "source-db-type":
+		return database.DBTypes()
+	case "unmapped-files":
+		return []string{"warn", "fail", "ignore"}
+	case "debug-sql":
+		return []string{"off", string(database.SQLLogStatements), string(database.SQLLogValues)}
+	case "log-format":
+		return []string{"text", "ndjson"}
+	case "notify-format":
+		return []string{string(notify.FormatJSON), string(notify.FormatSlack)}
+	case "dump-format":
+		return []string{"json", "yaml"}
+	case "to":
+		return []string{string(importer.FormatCSV), string(importer.FormatJSONL), string(importer.FormatParquet)}
+	case "format":
+		return []string{string(graph.FormatDOT), string(graph.FormatMermaid)}
+	default:
+		return nil
+	}
+}
+
+// commandFlags returns the flags of a command by registering them on a throwaway flag set.
+func commandFlags(c command) []completionFlag {
+	fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	c.setup(fs, io.Discard)
+	var flags []completionFlag
+	fs.VisitAll(func(f *flag.Flag) {
+		boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool })
+		flags = append(flags, completionFlag{
+			name:    f.Name,
+			usage:   f.Usage,
+			isBool:  ok && boolFlag.IsBoolFlag(),
+			isFile:  fileFlags[f.Name],
+			isTable: tableFlags[f.Name],
+			values:  flagValues(f.Name),
+		})
+	})
+	return flags
+}
+
+func setupCompletion(fs *flag.FlagSet, stdout io.Writer) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		if fs.NArg() != 1 {
+			return &usageError{fmt.Errorf("expected one shell: %s", strings.Join(completionShells, ", "))}
+		}
+		switch shell := fs.Arg(0); shell {
+		case "bash":
+			writeBashCompletion(stdout)
+		case "zsh":
+			fmt.Fprintln(stdout, "autoload -U +X bashcompinit && bashcompinit")
+			writeBashCompletion(stdout)
+		case "fish":
+			writeFishCompletion(stdout)
+		default:
+			return &usageError{fmt.Errorf("unsupported shell %q (expected %s)", shell, strings.Join(completionShells, ", "))}
+		}
+		return nil
+	}
+}
+
+func writeBashCompletion(w io.Writer) {
+	var names []string
+	for _, c := range commands() {
+		names = append(names, c.name)
+	}
+	names = append(names, "help")
+
+	fmt.Fprintf(w, `# bash completion for db-auto-importer
+_db_auto_importer() {
+    local cur prev cmd opts
+    cur="${COMP_WORDS[COMP_CWORD]}"
+    prev="${COMP_WORDS[COMP_CWORD-1]}"
+    cmd=%s
+    if [[ ${COMP_CWORD} -gt 1 && ${COMP_WORDS[1]} != -* ]]; then
+        cmd="${COMP_WORDS[1]}"
+    fi
+    if [[ ${COMP_CWORD} -eq 1 && ${cur} != -* ]]; then
+        COMPREPLY=($(compgen -W "%s" -- "${cur}"))
+        return
+    fi
+    case "${cmd}" in
+`, defaultCommand, strings.Join(names, " "))
+	for _, c := range commands() {
+		if c.name == "completion" {
+			fmt.Fprintf(w, "    completion)\n        COMPREPLY=($(compgen -W \"%s\" -- \"${cur}\"))\n        return\n        ;;\n", strings.Join(completionShells, " "))
+			continue
+		}
+		var opts []string
+		var valueCases []string
+		for _, f := range commandFlags(c) {
+			opts = append(opts, "--"+f.name)
+			pattern := fmt.Sprintf("--%s|-%s", f.name, f.name)
+			switch {
+			case len(f.values) > 0:
+				valueCases = append(valueCases, fmt.Sprintf("            %s) COMPREPLY=($(compgen -W \"%s\" -- \"${cur}\")); return ;;", pattern, strings.Join(f.values, " ")))
+			case f.isFile:
+				valueCases = append(valueCases, fmt.Sprintf("            %s) COMPREPLY=($(compgen -f -- \"${cur}\")); return ;;", pattern))
+			case f.isTable:
+				// Only the name after the last comma is completed; the ones before it are kept
+				valueCases = append(valueCases, fmt.Sprintf("            %s) local prefix=\"\"; [[ ${cur} == *,* ]] && prefix=\"${cur%%,*},\"; COMPREPLY=($(compgen -P \"${prefix}\" -W \"$(db-auto-importer schema --names 2>/dev/null)\" -- \"${cur##*,}\")); return ;;", pattern))
+			case !f.isBool:
+				valueCases = append(valueCases, fmt.Sprintf("            %s) return ;;", pattern))
+			}
+		}
+		fmt.Fprintf(w, "    %s)\n        case \"${prev}\" in\n%s\n        esac\n        opts=\"%s\"\n        ;;\n", c.name, strings.Join(valueCases, "\n"), strings.Join(opts, " "))
+	}
+	fmt.Fprint(w, `    *)
+        return
+        ;;
+    esac
+    COMPREPLY=($(compgen -W "${opts}" -- "${cur}"))
+}
+complete -o default -F _db_auto_importer db-auto-importer
+`)
+}
+
+func writeFishCompletion(w io.Writer) {
+	fmt.Fprintln(w, "# fish completion for db-auto-importer")
+	fmt.Fprintln(w, "complete -c db-auto-importer -f")
+	var names []string
+	for _, c := range commands() {
+		names = append(names, c.name)
+		fmt.Fprintf(w, "complete -c db-auto-importer -n __fish_use_subcommand -a %s -d %s\n", c.name, fishQuote(c.summary))
+	}
+	fmt.Fprintln(w, "complete -c db-auto-importer -n __fish_use_subcommand -a help -d 'Show the commands'")
+	fmt.Fprintln(w, "complete -c db-auto-importer -n __fish_use_subcommand -l version -d 'Show the version and build information'")
+
+	for _, c := range commands() {
+		condition := fmt.Sprintf("'__fish_seen_subcommand_from %s'", c.name)
+		if c.name == "completion" {
+			fmt.Fprintf(w, "complete -c db-auto-importer -n %s -a '%s'\n", condition, strings.Join(completionShells, " "))
+			continue
+		}
+		if c.name == defaultCommand {
+			// Flags of the default command are also offered before any command is given
+			condition = fmt.Sprintf("'not __fish_seen_subcommand_from %s; or __fish_seen_subcommand_from %s'", strings.Join(names, " "), c.name)
+		}
+		for _, f := range commandFlags(c) {
+			line := fmt.Sprintf("complete -c db-auto-importer -n %s -l %s -d %s", condition, f.name, fishQuote(f.usage))
+			switch {
+			case len(f.values) > 0:
+				line += fmt.Sprintf(" -x -a '%s'", strings.Join(f.values, " "))
+			case f.isFile:
+				line += " -r -F"
+			case f.isTable:
+				line += " -x -a '(db-auto-importer schema --names 2>/dev/null)'"
+			case !f.isBool:
+				line += " -x"
+			}
+			fmt.Fprintln(w, line)
+		}
+	}
+}
+
+// fishQuote quotes s as a single-quoted fish string.
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
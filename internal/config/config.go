@@ -0,0 +1,489 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/k-wa-wa/db-auto-importer/internal/graph"
+	"github.com/k-wa-wa/db-auto-importer/internal/importer"
+	"github.com/k-wa-wa/db-auto-importer/internal/secrets"
+	"io"
+	"maps"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the content of the YAML file passed with --config.
+type Config struct {
+	// Settings of the command-line flags with the same name; flags given on the command line take precedence.
+	DBType                   string `yaml:"db_type"`
+	DB                       string `yaml:"db"`
+	ReadDB                   string `yaml:"read_db"`
+	Schema                   string `yaml:"schema"`
+	CSVDir                   string `yaml:"csv"`
+	Header                   *bool  `yaml:"header"`
+	UnmappedFiles            string `yaml:"unmapped_files"`
+	Fake                     string `yaml:"fake"`
+	Seed                     *int64 `yaml:"seed"`
+	NoAutoParents            *bool  `yaml:"no_auto_parents"`
+	DefaultRows              *int   `yaml:"default_rows"`
+	Summary                  string `yaml:"summary"`
+	Report                   string `yaml:"report"`
+	State                    string `yaml:"state"`
+	Resume                   *bool  `yaml:"resume"`
+	WatermarkFile            string `yaml:"watermark_file"`
+	WatermarkColumn          string `yaml:"watermark_column"`
+	Manifest                 string `yaml:"manifest"`
+	Schedule                 string `yaml:"schedule"`
+	Lock                     string `yaml:"lock"`
+	StatementTimeout         string `yaml:"statement_timeout"`
+	SchemaCache              string `yaml:"schema_cache"`
+	RefreshMaterializedViews *bool  `yaml:"refresh_materialized_views"`
+	MetricsAddr              string `yaml:"metrics_addr"`
+	RecordRuns               *bool  `yaml:"record_runs"`
+	BatchColumn              string `yaml:"batch_column"`
+	BatchSize                *int   `yaml:"batch_size"`
+	MaxMemory                string `yaml:"max_memory"`
+	DebugSQL                 string `yaml:"debug_sql"`
+	AWSIAMAuth               *bool  `yaml:"aws_iam_auth"`
+	TLSCA                    string `yaml:"tls_ca"`
+	TLSCert                  string `yaml:"tls_cert"`
+	TLSKey                   string `yaml:"tls_key"`
+	TLSServerName            string `yaml:"tls_server_name"`
+	LogFormat                string `yaml:"log_format"`
+	SlowThreshold            string `yaml:"slow_threshold"`
+	NotifyURL                string `yaml:"notify_url"`
+	NotifyFormat             string `yaml:"notify_format"`
+	SourceDB                 string `yaml:"source_db"`
+	SourceDBType             string `yaml:"source_db_type"`
+	SourceSchema             string `yaml:"source_schema"`
+
+	// Tables holds per-table import options, keyed by table name.
+	Tables map[string]TableConfig `yaml:"tables"`
+	// Generation declares how values are invented for columns, keyed by "table.column" or "column".
+	Generation map[string]GenerationRule `yaml:"generation"`
+	// Rows sets the number of rows fabricated per table in generate mode.
+	Rows map[string]int `yaml:"rows"`
+	// Masking anonymizes imported values, keyed by "table.column" or "column".
+	Masking map[string]MaskingRule `yaml:"masking"`
+	// Encryption encrypts imported values, keyed by "table.column" or "column".
+	Encryption map[string]EncryptionRule `yaml:"encryption"`
+	// Templates provides fixed column values for auto-created parent records, keyed by table and column.
+	Templates map[string]map[string]string `yaml:"templates"`
+	// IgnoreForeignKeys names foreign keys the importer treats as absent, as "constraint" or "table.constraint".
+	IgnoreForeignKeys []string `yaml:"ignore_foreign_keys"`
+	// ImportOrder adjusts the import order where the foreign keys do not declare a dependency.
+	ImportOrder ImportOrderConfig `yaml:"import_order"`
+	// AuditColumns provides values for columns that CSV files never contain, keyed by column name.
+	AuditColumns map[string]string `yaml:"audit_columns"`
+}
+
+// TableConfig is the YAML form of importer.TableOptions.
+type TableConfig struct {
+	// File is the CSV file name to read for the table instead of "<table>.csv".
+	File string `yaml:"file"`
+	// Columns maps CSV header names to column names where they differ.
+	Columns map[string]string `yaml:"columns"`
+	// Types overrides the data type read from the database for columns, e.g. "INTEGER".
+	Types map[string]string `yaml:"types"`
+	// Watermark is the column incremental imports of the table compare with its watermark.
+	Watermark string `yaml:"watermark"`
+	// BatchSize overrides the batch_size setting for the table.
+	BatchSize int `yaml:"batch_size"`
+	// Mode is "upsert" (the default), "insert" or "skip".
+	Mode string `yaml:"mode"`
+	// Parallelism is the number of batches of the table inserted at the same time.
+	Parallelism int `yaml:"parallelism"`
+	// Exclude lists columns left out of the inserts, to be filled by defaults or triggers.
+	Exclude []string `yaml:"exclude"`
+}
+
+// ImportOrderConfig is the YAML form of graph.OrderRules.
+type ImportOrderConfig struct {
+	// First lists tables to import before all others, e.g. lookup tables.
+	First []string `yaml:"first"`
+	// After maps a table to tables that must be imported before it.
+	After map[string][]string `yaml:"after"`
+}
+
+// GenerationRule is the YAML form of database.GenerationRule.
+type GenerationRule struct {
+	Pattern  string        `yaml:"pattern"`
+	Min      *float64      `yaml:"min"`
+	Max      *float64      `yaml:"max"`
+	Choices  []string      `yaml:"choices"`
+	Sequence *SequenceRule `yaml:"sequence"`
+}
+
+// SequenceRule is the YAML form of database.SequenceRule.
+type SequenceRule struct {
+	Start  int64  `yaml:"start"`
+	Step   int64  `yaml:"step"`
+	Format string `yaml:"format"`
+}
+
+// MaskingRule is the YAML form of importer.MaskingRule. A plain string is shorthand for the method,
+// e.g. "users.email: hash".
+type MaskingRule struct {
+	Method      string `yaml:"method"`
+	Kind        string `yaml:"kind"`
+	Replacement string `yaml:"replacement"`
+	Salt        string `yaml:"salt"`
+}
+
+// UnmarshalYAML accepts either a mapping or a bare method name.
+func (r *MaskingRule) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		r.Method = node.Value
+		return nil
+	}
+	type plain MaskingRule
+	return node.Decode((*plain)(r))
+}
+
+// EncryptionRule is the YAML form of importer.EncryptionRule. The key material is never written in
+// the file: KeyEnv names an environment variable holding it, or Key is a secret reference such as
+// "aws-sm://prod/keys#ssn".
+type EncryptionRule struct {
+	Method string `yaml:"method"`
+	KeyEnv string `yaml:"key_env"`
+	Key    string `yaml:"key"`
+}
+
+// Load reads and validates a configuration file.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	var cfg Config
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true) // Report misspelled keys instead of silently ignoring them
+	if err := decoder.Decode(&cfg); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	if _, err := cfg.GenerationRules(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	if _, err := cfg.MaskingRules(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	if err := cfg.checkEncryption(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	if err := cfg.checkTables(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// FlagValues returns the settings given in the file, keyed by the name of the corresponding command-line flag.
+func (c *Config) FlagValues() map[string]string {
+	values := make(map[string]string)
+	setString := func(name, value string) {
+		if value != "" {
+			values[name] = value
+		}
+	}
+	setString("db-type", c.DBType)
+	setString("db", c.DB)
+	setString("read-db", c.ReadDB)
+	setString("schema", c.Schema)
+	setString("csv", c.CSVDir)
+	setString("unmapped-files", c.UnmappedFiles)
+	setString("fake", c.Fake)
+	setString("summary", c.Summary)
+	setString("report", c.Report)
+	setString("state", c.State)
+	setString("watermark-file", c.WatermarkFile)
+	setString("watermark-column", c.WatermarkColumn)
+	setString("manifest", c.Manifest)
+	setString("schedule", c.Schedule)
+	setString("lock", c.Lock)
+	setString("statement-timeout", c.StatementTimeout)
+	setString("schema-cache", c.SchemaCache)
+	setString("metrics-addr", c.MetricsAddr)
+	setString("batch-column", c.BatchColumn)
+	setString("max-memory", c.MaxMemory)
+	setString("debug-sql", c.DebugSQL)
+	setString("tls-ca", c.TLSCA)
+	setString("tls-cert", c.TLSCert)
+	setString("tls-key", c.TLSKey)
+	setString("tls-server-name", c.TLSServerName)
+	setString("log-format", c.LogFormat)
+	setString("slow-threshold", c.SlowThreshold)
+	setString("notify-url", c.NotifyURL)
+	setString("notify-format", c.NotifyFormat)
+	setString("source-db", c.SourceDB)
+	setString("source-db-type", c.SourceDBType)
+	setString("source-schema", c.SourceSchema)
+	if c.Header != nil {
+		values["header"] = strconv.FormatBool(*c.Header)
+	}
+	if c.Seed != nil {
+		values["seed"] = strconv.FormatInt(*c.Seed, 10)
+	}
+	if c.NoAutoParents != nil {
+		values["no-auto-parents"] = strconv.FormatBool(*c.NoAutoParents)
+	}
+	if c.RefreshMaterializedViews != nil {
+		values["refresh-materialized-views"] = strconv.FormatBool(*c.RefreshMaterializedViews)
+	}
+	if c.AWSIAMAuth != nil {
+		values["aws-iam-auth"] = strconv.FormatBool(*c.AWSIAMAuth)
+	}
+	if c.RecordRuns != nil {
+		values["record-runs"] = strconv.FormatBool(*c.RecordRuns)
+	}
+	if c.Resume != nil {
+		values["resume"] = strconv.FormatBool(*c.Resume)
+	}
+	if c.DefaultRows != nil {
+		values["rows"] = strconv.Itoa(*c.DefaultRows)
+	}
+	if c.BatchSize != nil {
+		values["batch-size"] = strconv.Itoa(*c.BatchSize)
+	}
+	return values
+}
+
+// TableOptions converts the tables section into importer.TableOptions.
+func (c *Config) TableOptions() map[string]importer.TableOptions {
+	options := make(map[string]importer.TableOptions, len(c.Tables))
+	for tableName, t := range c.Tables {
+		opts := importer.TableOptions{
+			File:            t.File,
+			ColumnMap:       t.Columns,
+			WatermarkColumn: t.Watermark,
+			BatchSize:       t.BatchSize,
+			Parallelism:     t.Parallelism,
+			ExcludeColumns:  t.Exclude,
+		}
+		if t.Mode != "" {
+			opts.Mode, _ = database.ParseInsertMode(t.Mode) // Validated by Load
+		}
+		options[tableName] = opts
+	}
+	return options
+}
+
+// checkTables validates the import settings of the tables section.
+func (c *Config) checkTables() error {
+	for tableName, t := range c.Tables {
+		if t.Mode != "" {
+			if _, err := database.ParseInsertMode(t.Mode); err != nil {
+				return fmt.Errorf("tables.%s.mode: %w", tableName, err)
+			}
+		}
+		if t.BatchSize < 0 {
+			return fmt.Errorf("tables.%s.batch_size: must not be negative", tableName)
+		}
+		if t.Parallelism < 0 {
+			return fmt.Errorf("tables.%s.parallelism: must not be negative", tableName)
+		}
+	}
+	return nil
+}
+
+// OrderRules converts the import_order section into graph.OrderRules.
+func (c *Config) OrderRules() graph.OrderRules {
+	return graph.OrderRules{First: c.ImportOrder.First, After: c.ImportOrder.After}
+}
+
+// ParentTemplates returns the templates section with the audit columns added to each table of
+// schema that has them. Values given in the templates section take precedence.
+func (c *Config) ParentTemplates(schema map[string]database.DBInfo) map[string]map[string]string {
+	templates := make(map[string]map[string]string, len(c.Templates))
+	for tableName, row := range c.Templates {
+		templates[tableName] = maps.Clone(row)
+	}
+	for tableName, dbInfo := range schema {
+		for _, colInfo := range dbInfo.Columns {
+			for auditCol, value := range c.AuditColumns {
+				if !strings.EqualFold(auditCol, colInfo.ColumnName) {
+					continue
+				}
+				if templates[tableName] == nil {
+					templates[tableName] = make(map[string]string)
+				}
+				if _, ok := templates[tableName][colInfo.ColumnName]; !ok {
+					templates[tableName][colInfo.ColumnName] = value
+				}
+			}
+		}
+	}
+	return templates
+}
+
+// ApplyColumnTypes overrides the column types in schema with those of the tables section.
+func (c *Config) ApplyColumnTypes(schema map[string]database.DBInfo) error {
+	for tableName, t := range c.Tables {
+		if len(t.Types) == 0 {
+			continue
+		}
+		dbInfo, ok := schema[tableName]
+		if !ok {
+			return fmt.Errorf("tables.%s.types: table not found in the database schema", tableName)
+		}
+		for colName, typeName := range t.Types {
+			dataType, err := database.ParseColumnDataTypeName(typeName)
+			if err != nil {
+				return fmt.Errorf("tables.%s.types.%s: %w", tableName, colName, err)
+			}
+			found := false
+			for idx := range dbInfo.Columns {
+				if dbInfo.Columns[idx].ColumnName == colName {
+					dbInfo.Columns[idx].DataType = dataType
+					found = true
+				}
+			}
+			if !found {
+				return fmt.Errorf("tables.%s.types.%s: column not found in table %s", tableName, colName, tableName)
+			}
+		}
+	}
+	return nil
+}
+
+// CheckExcludedColumns verifies that the excluded columns of the tables section exist in schema.
+func (c *Config) CheckExcludedColumns(schema map[string]database.DBInfo) error {
+	for tableName, t := range c.Tables {
+		if len(t.Exclude) == 0 {
+			continue
+		}
+		dbInfo, ok := schema[tableName]
+		if !ok {
+			return fmt.Errorf("tables.%s.exclude: table not found in the database schema", tableName)
+		}
+		for _, colName := range t.Exclude {
+			found := false
+			for _, colInfo := range dbInfo.Columns {
+				if strings.EqualFold(colInfo.ColumnName, colName) {
+					found = true
+				}
+			}
+			if !found {
+				return fmt.Errorf("tables.%s.exclude: column %s not found in table %s", tableName, colName, tableName)
+			}
+		}
+	}
+	return nil
+}
+
+// RemoveIgnoredForeignKeys removes the foreign keys of the ignore_foreign_keys section from schema,
+// so that they neither order the import nor make the importer check or create parent records.
+// A constraint name without a table matches the constraint in every table.
+func (c *Config) RemoveIgnoredForeignKeys(schema map[string]database.DBInfo) error {
+	for _, entry := range c.IgnoreForeignKeys {
+		tableName, constraintName := "", entry
+		if idx := strings.LastIndex(entry, "."); idx >= 0 {
+			tableName, constraintName = entry[:idx], entry[idx+1:]
+		}
+		found := false
+		for name, dbInfo := range schema {
+			if tableName != "" && name != tableName {
+				continue
+			}
+			kept := dbInfo.ForeignKeys[:0:0]
+			for _, fk := range dbInfo.ForeignKeys {
+				if fk.ConstraintName == constraintName {
+					found = true
+					continue
+				}
+				kept = append(kept, fk)
+			}
+			dbInfo.ForeignKeys = kept
+			schema[name] = dbInfo
+		}
+		if !found {
+			return fmt.Errorf("ignore_foreign_keys: foreign key %s not found in the database schema", entry)
+		}
+	}
+	return nil
+}
+
+// GenerationRules converts the generation section into validated database.GenerationRules.
+func (c *Config) GenerationRules() (map[string]*database.GenerationRule, error) {
+	rules := make(map[string]*database.GenerationRule, len(c.Generation))
+	for key, r := range c.Generation {
+		rule := &database.GenerationRule{
+			Pattern: r.Pattern,
+			Min:     r.Min,
+			Max:     r.Max,
+			Choices: r.Choices,
+		}
+		if r.Sequence != nil {
+			rule.Sequence = &database.SequenceRule{Start: r.Sequence.Start, Step: r.Sequence.Step, Format: r.Sequence.Format}
+		}
+		if err := rule.Validate(); err != nil {
+			return nil, fmt.Errorf("generation rule for '%s': %w", key, err)
+		}
+		rules[key] = rule
+	}
+	return rules, nil
+}
+
+// MaskingRules converts the masking section into validated importer.MaskingRules.
+func (c *Config) MaskingRules() (map[string]importer.MaskingRule, error) {
+	rules := make(map[string]importer.MaskingRule, len(c.Masking))
+	for key, r := range c.Masking {
+		rule := importer.MaskingRule{
+			Method:      importer.MaskingMethod(r.Method),
+			Kind:        database.FakeKind(r.Kind),
+			Replacement: r.Replacement,
+			Salt:        r.Salt,
+		}
+		if err := rule.Validate(); err != nil {
+			return nil, fmt.Errorf("masking rule for '%s': %w", key, err)
+		}
+		rules[key] = rule
+	}
+	return rules, nil
+}
+
+// checkEncryption validates the encryption section without resolving the keys.
+func (c *Config) checkEncryption() error {
+	for key, r := range c.Encryption {
+		switch importer.EncryptionMethod(r.Method) {
+		case importer.EncryptAESGCM, importer.EncryptPGP:
+		default:
+			return fmt.Errorf("encryption rule for '%s': unknown encryption method '%s' (expected aes-gcm or pgp)", key, r.Method)
+		}
+		switch {
+		case (r.KeyEnv == "") == (r.Key == ""):
+			return fmt.Errorf("encryption rule for '%s': exactly one of key_env and key must be set", key)
+		case r.Key != "" && !secrets.IsReference(r.Key):
+			return fmt.Errorf("encryption rule for '%s': key must be a secret reference (aws-sm://, gcp-sm:// or vault://); use key_env for a key in an environment variable", key)
+		}
+	}
+	return nil
+}
+
+// EncryptionRules converts the encryption section into importer.EncryptionRules, reading the keys
+// from the environment variables and fetching those held in secret managers.
+func (c *Config) EncryptionRules() (map[string]importer.EncryptionRule, error) {
+	if err := c.checkEncryption(); err != nil {
+		return nil, err
+	}
+	rules := make(map[string]importer.EncryptionRule, len(c.Encryption))
+	for key, r := range c.Encryption {
+		rule := importer.EncryptionRule{Method: importer.EncryptionMethod(r.Method)}
+		if r.KeyEnv != "" {
+			rule.Key = os.Getenv(r.KeyEnv)
+			if rule.Key == "" {
+				return nil, fmt.Errorf("encryption rule for '%s': environment variable %s is not set", key, r.KeyEnv)
+			}
+		} else {
+			var err error
+			if rule.Key, err = secrets.Resolve(r.Key); err != nil {
+				return nil, fmt.Errorf("encryption rule for '%s': %w", key, err)
+			}
+		}
+		rules[key] = rule
+	}
+	return rules, nil
+}
@@ -0,0 +1,58 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Privileges that CheckTableAccess can verify.
+const (
+	PrivilegeSelect = "SELECT"
+	PrivilegeInsert = "INSERT"
+	PrivilegeUpdate = "UPDATE"
+)
+
+// checkTableAccess runs, for each privilege, a statement on table (the dialect's name for
+// dbInfo in SQL) that needs the privilege but matches no rows. The statements that write run in a
+// transaction that is rolled back, so that not even statement-level triggers leave a trace.
+func checkTableAccess(ctx context.Context, db *sql.DB, table string, dbInfo DBInfo, privileges []string) error {
+	var cols []string
+	for _, colInfo := range dbInfo.InsertColumns() {
+		cols = append(cols, colInfo.ColumnName)
+	}
+	for _, privilege := range privileges {
+		var query string
+		switch privilege {
+		case PrivilegeSelect:
+			query = fmt.Sprintf("SELECT 1 FROM %s WHERE 1 = 0", table)
+		case PrivilegeInsert:
+			if len(cols) == 0 {
+				continue
+			}
+			query = fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s WHERE 1 = 0", table, strings.Join(cols, ", "), strings.Join(cols, ", "), table)
+		case PrivilegeUpdate:
+			if len(cols) == 0 {
+				continue
+			}
+			query = fmt.Sprintf("UPDATE %s SET %s = %s WHERE 1 = 0", table, cols[0], cols[0])
+		default:
+			return fmt.Errorf("unknown privilege %q", privilege)
+		}
+
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		rows, err := tx.QueryContext(ctx, query)
+		if err == nil {
+			err = rows.Close()
+		}
+		tx.Rollback()
+		if err != nil {
+			return &TableAccessError{TableName: dbInfo.TableName, Privilege: privilege, Err: err}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// BatchExecutor is implemented by the DBClients that can insert rows in batches.
+type BatchExecutor interface {
+	// ExecBatch executes stmt once with each row of args, committing them together, and returns
+	// the result of each row. A row that fails does not fail the others: it is retried on its own,
+	// outside the batch, and its error is reported in its result. The error is returned when the
+	// batch itself cannot be executed, e.g. when ctx is cancelled.
+	ExecBatch(ctx context.Context, stmt *sql.Stmt, args [][]interface{}, rowContext RowContextFunc) ([]BatchResult, error)
+}
+
+// BatchSizer is implemented by the BatchExecutors that batch the rows of some tables by default.
+type BatchSizer interface {
+	// DefaultBatchSize returns the number of rows of the table of dbInfo to insert together when
+	// no batch size is configured. 1 or less inserts the rows one by one.
+	DefaultBatchSize(dbInfo DBInfo) int
+}
+
+var (
+	_ BatchExecutor  = (*PostgresDB)(nil)
+	_ BatchExecutor  = (*ReadSplitDB)(nil)
+	_ BatchExecutor  = (*YugabyteDB)(nil)
+	_ BatchSizer     = (*ReadSplitDB)(nil)
+	_ InsertExecutor = (*PostgresDB)(nil)
+	_ InsertExecutor = (*ReadSplitDB)(nil)
+)
+
+// RowContextFunc returns the context of the execution of a single row, e.g. bounded by a statement
+// timeout, and the function to call when it is done.
+type RowContextFunc func(ctx context.Context) (context.Context, func())
+
+// BatchResult is the result of a row of a batch.
+type BatchResult struct {
+	// RowsAffected is the number of rows the statement changed, or -1 if the driver cannot tell.
+	// An upsert that updated an existing row counts it as UpsertUpdated, like MySQL does.
+	RowsAffected int64
+	Err          error
+}
+
+// UpsertUpdated is the RowsAffected of an insert statement that updated an existing row instead of
+// inserting one. MySQL reports it for INSERT ... ON DUPLICATE KEY UPDATE; PostgresDB returns it
+// through InsertExecutor. The other databases count an updated row like an inserted one.
+const UpsertUpdated = 2
+
+// InsertExecutor is implemented by the DBClients whose insert statements tell an inserted row from
+// an updated one other than through RowsAffected.
+type InsertExecutor interface {
+	// ExecInsert executes stmt, a statement of PrepareInsertStatement or PrepareModeInsertStatement,
+	// with the values of a single row and returns its result like ExecBatch.
+	ExecInsert(ctx context.Context, stmt *sql.Stmt, args []interface{}) BatchResult
+}
+
+// rowExecFunc executes an insert statement with the values of a single row: execRow, or
+// execReturningRow for the statements of postgresInsertStatement returning whether they inserted.
+type rowExecFunc func(ctx context.Context, stmt *sql.Stmt, args []interface{}) BatchResult
+
+// execBatch runs args in transactions of db without savepoints. PostgreSQL aborts a transaction on
+// the first error, so on a failure the transaction is rolled back, the rows before the failed one
+// are run again in a new transaction, the failed row is run on its own and the rest continue in
+// another transaction. Each row that fails costs a rollback and a retry, while batches without
+// failures commit once.
+func execBatch(ctx context.Context, db *sql.DB, stmt *sql.Stmt, args [][]interface{}, rowContext RowContextFunc, exec rowExecFunc) ([]BatchResult, error) {
+	results := make([]BatchResult, len(args))
+	if err := execIsolated(ctx, db, stmt, args, rowContext, exec, results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// execIsolated runs args for execBatch, filling results.
+func execIsolated(ctx context.Context, db *sql.DB, stmt *sql.Stmt, args [][]interface{}, rowContext RowContextFunc, exec rowExecFunc, results []BatchResult) error {
+	for len(args) > 0 {
+		failed, err := execInTransaction(ctx, db, stmt, args, rowContext, exec, results)
+		if err != nil || failed < 0 {
+			return err
+		}
+		// The rows before the failed one were rolled back with it
+		if err := execIsolated(ctx, db, stmt, args[:failed], rowContext, exec, results[:failed]); err != nil {
+			return err
+		}
+		// Retry the failed row outside of any transaction, where its error affects no other row
+		rowCtx, done := rowContext(ctx)
+		results[failed] = exec(rowCtx, stmt, args[failed])
+		done()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		args, results = args[failed+1:], results[failed+1:]
+	}
+	return nil
+}
+
+// execInTransaction runs args in a single transaction, filling results. It returns the index of
+// the first row that failed, whose transaction was rolled back, or -1 if the transaction committed.
+func execInTransaction(ctx context.Context, db *sql.DB, stmt *sql.Stmt, args [][]interface{}, rowContext RowContextFunc, exec rowExecFunc, results []BatchResult) (int, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin batch transaction: %w", err)
+	}
+	txStmt := tx.StmtContext(ctx, stmt)
+	defer txStmt.Close()
+	for idx, rowArgs := range args {
+		rowCtx, done := rowContext(ctx)
+		results[idx] = exec(rowCtx, txStmt, rowArgs)
+		done()
+		if results[idx].Err != nil {
+			tx.Rollback()
+			if ctx.Err() != nil {
+				return 0, ctx.Err()
+			}
+			return idx, nil
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit batch of %d rows: %w", len(args), err)
+	}
+	return -1, nil
+}
+
+// execRows executes stmt with each row of args on its own, for clients without transactional batches.
+func execRows(ctx context.Context, stmt *sql.Stmt, args [][]interface{}, rowContext RowContextFunc) ([]BatchResult, error) {
+	results := make([]BatchResult, len(args))
+	for idx, rowArgs := range args {
+		rowCtx, done := rowContext(ctx)
+		results[idx] = execRow(rowCtx, stmt, rowArgs)
+		done()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+	return results, nil
+}
+
+// execRow executes stmt with the values of a single row.
+func execRow(ctx context.Context, stmt *sql.Stmt, args []interface{}) BatchResult {
+	result, err := stmt.ExecContext(ctx, args...)
+	if err != nil {
+		return BatchResult{Err: err}
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		affected = -1
+	}
+	return BatchResult{RowsAffected: affected}
+}
+
+// execReturningRow executes stmt, an insert statement returning whether it inserted its row rather
+// than updated an existing one, with the values of a single row. A statement that changed nothing,
+// e.g. one doing nothing on a conflict, returns no row.
+func execReturningRow(ctx context.Context, stmt *sql.Stmt, args []interface{}) BatchResult {
+	var inserted bool
+	err := stmt.QueryRowContext(ctx, args...).Scan(&inserted)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return BatchResult{RowsAffected: 0}
+	case err != nil:
+		return BatchResult{Err: err}
+	case inserted:
+		return BatchResult{RowsAffected: 1}
+	default:
+		return BatchResult{RowsAffected: UpsertUpdated}
+	}
+}
@@ -0,0 +1,156 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// txStore is the table of txDriver: the committed values and the statistics of the transactions.
+type txStore struct {
+	mu        sync.Mutex
+	committed []string
+	commits   int
+	rollbacks int
+}
+
+// txDriver inserts the string values of statements into its store, in transactions like
+// PostgreSQL's: the value "bad" fails, and so does every statement after it in its transaction.
+type txDriver struct{ store *txStore }
+
+func (d txDriver) Open(name string) (driver.Conn, error) { return &txConn{store: d.store}, nil }
+
+type txConn struct {
+	store   *txStore
+	inTx    bool
+	aborted bool
+	pending []string
+}
+
+func (c *txConn) Prepare(query string) (driver.Stmt, error) { return &txStmt{conn: c}, nil }
+func (c *txConn) Close() error                              { return nil }
+func (c *txConn) Begin() (driver.Tx, error) {
+	c.inTx, c.aborted, c.pending = true, false, nil
+	return c, nil
+}
+
+func (c *txConn) Commit() error {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+	c.inTx = false
+	if c.aborted {
+		return errors.New("current transaction is aborted")
+	}
+	c.store.committed = append(c.store.committed, c.pending...)
+	c.store.commits++
+	return nil
+}
+
+func (c *txConn) Rollback() error {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+	c.inTx = false
+	c.store.rollbacks++
+	return nil
+}
+
+type txStmt struct{ conn *txConn }
+
+func (s *txStmt) Close() error  { return nil }
+func (s *txStmt) NumInput() int { return 1 }
+func (s *txStmt) Exec(args []driver.Value) (driver.Result, error) {
+	c := s.conn
+	if c.aborted {
+		return nil, errors.New("current transaction is aborted")
+	}
+	value := args[0].(string)
+	if value == "bad" {
+		c.aborted = c.inTx
+		return nil, errors.New("invalid value")
+	}
+	if c.inTx {
+		c.pending = append(c.pending, value)
+	} else {
+		c.store.mu.Lock()
+		c.store.committed = append(c.store.committed, value)
+		c.store.mu.Unlock()
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *txStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+var batchStore = &txStore{}
+
+func init() {
+	sql.Register("batch-test", txDriver{store: batchStore})
+}
+
+func Test_execBatch(t *testing.T) {
+	db, err := sql.Open("batch-test", "")
+	require.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	stmt, err := db.Prepare("INSERT INTO t (v) VALUES ($1)")
+	require.NoError(t, err)
+	defer stmt.Close()
+
+	rowContexts := 0
+	rowContext := func(ctx context.Context) (context.Context, func()) {
+		rowContexts++
+		return ctx, func() {}
+	}
+	run := func(t *testing.T, values ...string) []BatchResult {
+		t.Helper()
+		*batchStore = txStore{}
+		rowContexts = 0
+		args := make([][]interface{}, len(values))
+		for idx, value := range values {
+			args[idx] = []interface{}{value}
+		}
+		results, err := execBatch(context.Background(), db, stmt, args, rowContext, execRow)
+		require.NoError(t, err)
+		require.Len(t, results, len(values))
+		return results
+	}
+
+	t.Run("失敗する行がない場合は1回のコミットで挿入されること", func(t *testing.T) {
+		results := run(t, "a", "b", "c")
+		for _, result := range results {
+			assert.NoError(t, result.Err)
+			assert.Equal(t, int64(1), result.RowsAffected)
+		}
+		assert.Equal(t, []string{"a", "b", "c"}, batchStore.committed)
+		assert.Equal(t, 1, batchStore.commits)
+		assert.Equal(t, 0, batchStore.rollbacks)
+		assert.Equal(t, 3, rowContexts)
+	})
+
+	t.Run("失敗した行だけがエラーとなり他の行は挿入されること", func(t *testing.T) {
+		results := run(t, "a", "bad", "b", "c", "bad", "d")
+		for idx, result := range results {
+			if idx == 1 || idx == 4 {
+				assert.EqualError(t, result.Err, "invalid value", idx)
+			} else {
+				assert.NoError(t, result.Err, idx)
+			}
+		}
+		assert.Equal(t, []string{"a", "b", "c", "d"}, batchStore.committed, "行の順序が保たれること")
+		assert.Equal(t, 2, batchStore.rollbacks)
+	})
+
+	t.Run("全ての行が失敗してもエラーにならないこと", func(t *testing.T) {
+		results := run(t, "bad", "bad")
+		assert.Error(t, results[0].Err)
+		assert.Error(t, results[1].Err)
+		assert.Empty(t, batchStore.committed)
+	})
+}
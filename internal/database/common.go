@@ -1,14 +1,16 @@
 package database
 
 import (
-	"crypto/rand"
+	"context"
 	"database/sql"
-	"encoding/hex"
 	"fmt"
 	"log"
-	"math/big"
+	"maps"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -45,30 +47,125 @@ func (cdt ColumnDataType) String() string {
 	}
 }
 
+// ParseColumnDataTypeName converts the name returned by ColumnDataType.String back to the type.
+func ParseColumnDataTypeName(name string) (ColumnDataType, error) {
+	for _, cdt := range []ColumnDataType{StringType, IntegerType, FloatType, BooleanType, DateType, TimestampType} {
+		if strings.EqualFold(cdt.String(), name) {
+			return cdt, nil
+		}
+	}
+	return UnknownType, fmt.Errorf("unknown column data type '%s'", name)
+}
+
 // DBInfo holds information about a database table and its columns.
 type DBInfo struct {
-	TableName         string
-	Columns           []ColumnInfo
-	PrimaryKeyColumns []string
-	UniqueKeyColumns  [][]string
-	ForeignKeys       []ForeignKeyInfo
+	TableName         string           `json:"name"`
+	Columns           []ColumnInfo     `json:"columns"`
+	PrimaryKeyColumns []string         `json:"primary_key,omitempty"`
+	UniqueKeyColumns  [][]string       `json:"unique_keys,omitempty"`
+	ForeignKeys       []ForeignKeyInfo `json:"foreign_keys,omitempty"`
+	// InheritsFrom lists the tables this table inherits from (PostgreSQL table inheritance).
+	InheritsFrom []string `json:"inherits_from,omitempty"`
+	// Partitioned is set for a PostgreSQL partitioned table, which holds no rows of its own but
+	// routes them to its partitions.
+	Partitioned bool `json:"partitioned,omitempty"`
+	// HasInheritingTables is set for a PostgreSQL table other tables inherit from with INHERITS.
+	// Its own rows are read and written apart from theirs with ONLY.
+	HasInheritingTables bool `json:"has_inheriting_tables,omitempty"`
+}
+
+// InsertColumns returns the columns that can be written by an INSERT, i.e. all columns except generated ones.
+func (d DBInfo) InsertColumns() []ColumnInfo {
+	cols := make([]ColumnInfo, 0, len(d.Columns))
+	for _, colInfo := range d.Columns {
+		if !colInfo.IsGenerated {
+			cols = append(cols, colInfo)
+		}
+	}
+	return cols
 }
 
 // ColumnInfo holds information about a database column.
 type ColumnInfo struct {
-	ColumnName    string
-	DataType      ColumnDataType
-	IsNullable    bool
-	ColumnDefault sql.NullString
+	ColumnName      string
+	DataType        ColumnDataType
+	IsNullable      bool
+	ColumnDefault   sql.NullString
+	IsAutoIncrement bool   // Value is assigned by a sequence, serial, identity or AUTO_INCREMENT
+	IsGenerated     bool   // Value is always computed by the database (GENERATED ALWAYS, computed column) and cannot be inserted
+	Comment         string // Column comment from the catalog, which may carry hints (see Hint)
 }
 
 // ForeignKeyInfo holds information about a foreign key constraint.
+// ColumnNames[i] references ForeignColumnNames[i]; composite keys have more than one column.
 type ForeignKeyInfo struct {
-	ConstraintName    string
-	TableName         string
-	ColumnName        string
-	ForeignTableName  string
-	ForeignColumnName string
+	ConstraintName     string   `json:"name"`
+	TableName          string   `json:"table"`
+	ColumnNames        []string `json:"columns"`
+	ForeignTableName   string   `json:"parent_table"`
+	ForeignColumnNames []string `json:"parent_columns"`
+}
+
+// appendForeignKeyColumn adds one column pair of a foreign key to fks. Rows of the same constraint
+// must be consecutive and in key column order; they are grouped into a single ForeignKeyInfo.
+func appendForeignKeyColumn(fks []ForeignKeyInfo, tableName, constraintName, columnName, foreignTableName, foreignColumnName string) []ForeignKeyInfo {
+	if n := len(fks); n > 0 && fks[n-1].ConstraintName == constraintName {
+		fks[n-1].ColumnNames = append(fks[n-1].ColumnNames, columnName)
+		fks[n-1].ForeignColumnNames = append(fks[n-1].ForeignColumnNames, foreignColumnName)
+		return fks
+	}
+	return append(fks, ForeignKeyInfo{
+		ConstraintName:     constraintName,
+		TableName:          tableName,
+		ColumnNames:        []string{columnName},
+		ForeignTableName:   foreignTableName,
+		ForeignColumnNames: []string{foreignColumnName},
+	})
+}
+
+// qualifiedTableName returns the name a table is keyed by in the schema map. Tables of the imported
+// schema keep their bare name; tables in other schemas, which are only introspected because a foreign
+// key references them, are qualified as "schema.table" so that generated SQL reaches the right table.
+func qualifiedTableName(mainSchema, tableSchema, tableName string) string {
+	if tableSchema == "" || tableSchema == mainSchema {
+		return tableName
+	}
+	return tableSchema + "." + tableName
+}
+
+// describeTableFunc introspects a single table of the given schema.
+type describeTableFunc func(ctx context.Context, tableSchema, tableName string) (DBInfo, error)
+
+// addForeignSchemaTables adds the parent tables that live in other schemas to schemaInfo. Their own
+// foreign keys may point to yet another schema, so this repeats until every referenced table is known.
+func addForeignSchemaTables(ctx context.Context, schemaInfo map[string]DBInfo, describe describeTableFunc) error {
+	for {
+		var pending []string
+		for _, dbInfo := range schemaInfo {
+			for _, fk := range dbInfo.ForeignKeys {
+				if _, ok := schemaInfo[fk.ForeignTableName]; ok || !strings.Contains(fk.ForeignTableName, ".") {
+					continue
+				}
+				if !slices.Contains(pending, fk.ForeignTableName) {
+					pending = append(pending, fk.ForeignTableName)
+				}
+			}
+		}
+		if len(pending) == 0 {
+			return nil
+		}
+		sort.Strings(pending)
+
+		for _, name := range pending {
+			tableSchema, tableName, _ := strings.Cut(name, ".")
+			log.Printf("Retrieving table '%s' referenced by a foreign key.\n", name)
+			dbInfo, err := describe(ctx, tableSchema, tableName)
+			if err != nil {
+				return fmt.Errorf("failed to get info for table %s: %w", name, err)
+			}
+			schemaInfo[name] = dbInfo
+		}
+	}
 }
 
 // ParseDataType converts a database-specific data type string to a standardized ColumnDataType.
@@ -103,7 +200,7 @@ func ConvertToDBType(csvValue string, dataType ColumnDataType, isNullable bool,
 	}
 	if csvValue == "" && !isNullable {
 		// If not nullable and no default, provide a sensible default based on type.
-		// This part is now handled by generateRandomValue if it's a unique key.
+		// This part is now handled by the ValueGenerator if it's a unique key.
 		// If not a unique key, we still need a default.
 		switch dataType {
 		case StringType:
@@ -117,7 +214,7 @@ func ConvertToDBType(csvValue string, dataType ColumnDataType, isNullable bool,
 		case DateType, TimestampType:
 			return time.Time{}, nil // Zero value for time
 		default:
-			return nil, fmt.Errorf("non-nullable column with no default and empty CSV value for type %s", dataType.String())
+			return nil, &ConversionError{Value: csvValue, DataType: dataType, Err: fmt.Errorf("non-nullable column with no default and empty CSV value")}
 		}
 	}
 
@@ -127,13 +224,13 @@ func ConvertToDBType(csvValue string, dataType ColumnDataType, isNullable bool,
 	case IntegerType:
 		val, err := strconv.ParseInt(csvValue, 10, 64)
 		if err != nil {
-			return nil, fmt.Errorf("failed to convert '%s' to integer: %w", csvValue, err)
+			return nil, &ConversionError{Value: csvValue, DataType: dataType, Err: err}
 		}
 		return val, nil
 	case FloatType:
 		val, err := strconv.ParseFloat(csvValue, 64)
 		if err != nil {
-			return nil, fmt.Errorf("failed to convert '%s' to float: %w", csvValue, err)
+			return nil, &ConversionError{Value: csvValue, DataType: dataType, Err: err}
 		}
 		return val, nil
 	case BooleanType:
@@ -147,14 +244,14 @@ func ConvertToDBType(csvValue string, dataType ColumnDataType, isNullable bool,
 			if lowerVal == "false" || lowerVal == "f" || lowerVal == "0" || lowerVal == "no" || lowerVal == "n" {
 				return false, nil
 			}
-			return nil, fmt.Errorf("failed to convert '%s' to boolean: %w", csvValue, err)
+			return nil, &ConversionError{Value: csvValue, DataType: dataType, Err: err}
 		}
 		return val, nil
 	case DateType:
 		// Assuming YYYY-MM-DD format
 		val, err := time.Parse("2006-01-02", csvValue)
 		if err != nil {
-			return nil, fmt.Errorf("failed to convert '%s' to date (expected YYYY-MM-DD): %w", csvValue, err)
+			return nil, &ConversionError{Value: csvValue, DataType: dataType, Err: fmt.Errorf("expected YYYY-MM-DD: %w", err)}
 		}
 		return val, nil
 	case TimestampType:
@@ -164,23 +261,390 @@ func ConvertToDBType(csvValue string, dataType ColumnDataType, isNullable bool,
 			// Try other common formats if RFC3339 fails
 			val, err = time.Parse("2006-01-02 15:04:05", csvValue)
 			if err != nil {
-				return nil, fmt.Errorf("failed to convert '%s' to timestamp: %w", csvValue, err)
+				return nil, &ConversionError{Value: csvValue, DataType: dataType, Err: err}
 			}
 		}
 		return val, nil
 	default:
 		// For unsupported types, return an error as we now have a strict enum
-		return nil, fmt.Errorf("unsupported data type '%s' for value '%s'", dataType.String(), csvValue)
+		return nil, &ConversionError{Value: csvValue, DataType: dataType, Err: fmt.Errorf("unsupported data type")}
+	}
+}
+
+// FormatValue converts a value produced by ConvertToDBType or a ValueGenerator back into
+// its CSV string form, so that it can be passed where CSV values are expected.
+func FormatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.Format(time.RFC3339) // Or another suitable format
+	case string:
+		return v
+	case []byte:
+		// Drivers return text-like column types (e.g. UUID, DECIMAL) as raw bytes
+		return string(v)
+	default:
+		// Fallback for other types, might need more specific handling
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// updateStatement builds an UPDATE of columnNames in the row identified by keyColumns, numbering
+// the placeholders with placeholder: the columns first, then the key.
+func updateStatement(table string, keyColumns, columnNames []string, placeholder func(n int) string) string {
+	assignments := make([]string, len(columnNames))
+	for idx, colName := range columnNames {
+		assignments[idx] = fmt.Sprintf("%s = %s", colName, placeholder(idx+1))
+	}
+	conditions := make([]string, len(keyColumns))
+	for idx, colName := range keyColumns {
+		conditions[idx] = fmt.Sprintf("%s = %s", colName, placeholder(len(columnNames)+idx+1))
+	}
+	return fmt.Sprintf("UPDATE %s SET %s WHERE %s", table, strings.Join(assignments, ", "), strings.Join(conditions, " AND "))
+}
+
+// execer runs the statements of clearColumns and deleteRows: a *sql.DB, or the *sql.Tx of a
+// RowDeletion.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// txRowDeletion is the RowDeletion of the clients built on clearColumns and deleteRows. table
+// returns the dialect's name of a table in SQL and placeholder numbers the placeholders, as in the
+// ClearColumns and DeleteRows of the client.
+type txRowDeletion struct {
+	*sql.Tx
+	table       func(dbInfo DBInfo) string
+	placeholder func(n int) string
+}
+
+// beginRowDeletion starts the transaction of a txRowDeletion on db.
+func beginRowDeletion(ctx context.Context, db *sql.DB, table func(dbInfo DBInfo) string, placeholder func(n int) string) (RowDeletion, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	return &txRowDeletion{Tx: tx, table: table, placeholder: placeholder}, nil
+}
+
+func (d *txRowDeletion) ClearColumns(ctx context.Context, dbInfo DBInfo, columnNames []string, filter RowFilter) error {
+	return clearColumns(ctx, d.Tx, d.table(dbInfo), dbInfo, columnNames, filter, d.placeholder, 0)
+}
+
+func (d *txRowDeletion) DeleteRows(ctx context.Context, dbInfo DBInfo, filter RowFilter) (int64, error) {
+	return deleteRows(ctx, d.Tx, d.table(dbInfo), dbInfo, filter, d.placeholder, 0)
+}
+
+// clearColumns sets columnNames to NULL in the rows of table (the dialect's name for it in SQL)
+// matching filter, numbering the placeholders with placeholder. If limit is positive, each
+// statement clears at most limit rows, with MySQL's LIMIT clause, and is repeated until none are
+// left, to keep the transactions small.
+func clearColumns(ctx context.Context, db execer, table string, dbInfo DBInfo, columnNames []string, filter RowFilter, placeholder func(n int) string, limit int) error {
+	assignments := make([]string, len(columnNames))
+	notNull := make([]string, len(columnNames))
+	for idx, colName := range columnNames {
+		assignments[idx] = colName + " = NULL"
+		notNull[idx] = colName + " IS NOT NULL"
+	}
+	return keyBatches(filter, func(keys [][]string) error {
+		where, args := filterCondition(dbInfo, filter, keys, placeholder)
+		query := fmt.Sprintf("UPDATE %s SET %s%s", table, strings.Join(assignments, ", "), where)
+		if limit > 0 {
+			// Rows already cleared must not match again
+			if where == "" {
+				query += " WHERE "
+			} else {
+				query += " AND "
+			}
+			query += fmt.Sprintf("(%s) LIMIT %d", strings.Join(notNull, " OR "), limit)
+		}
+		for {
+			result, err := db.ExecContext(ctx, query, args...)
+			if err != nil {
+				return fmt.Errorf("failed to clear columns %s of table %s: %w", strings.Join(columnNames, ", "), dbInfo.TableName, err)
+			}
+			if limit <= 0 {
+				return nil
+			}
+			affected, err := result.RowsAffected()
+			if err != nil {
+				return fmt.Errorf("failed to clear columns %s of table %s: %w", strings.Join(columnNames, ", "), dbInfo.TableName, err)
+			}
+			if affected < int64(limit) {
+				return nil
+			}
+		}
+	})
+}
+
+// deleteRows deletes the rows of table (the dialect's name for it in SQL) matching filter and
+// returns their number, numbering the placeholders with placeholder. If limit is positive, each
+// statement deletes at most limit rows, as in clearColumns.
+func deleteRows(ctx context.Context, db execer, table string, dbInfo DBInfo, filter RowFilter, placeholder func(n int) string, limit int) (int64, error) {
+	var deleted int64
+	err := keyBatches(filter, func(keys [][]string) error {
+		where, args := filterCondition(dbInfo, filter, keys, placeholder)
+		query := "DELETE FROM " + table + where
+		if limit > 0 {
+			query += fmt.Sprintf(" LIMIT %d", limit)
+		}
+		for {
+			result, err := db.ExecContext(ctx, query, args...)
+			if err != nil {
+				return fmt.Errorf("failed to delete rows of table %s: %w", dbInfo.TableName, err)
+			}
+			affected, err := result.RowsAffected()
+			if err != nil {
+				return fmt.Errorf("failed to delete rows of table %s: %w", dbInfo.TableName, err)
+			}
+			deleted += affected
+			if limit <= 0 || affected < int64(limit) {
+				return nil
+			}
+		}
+	})
+	return deleted, err
+}
+
+// updateArgs returns the arguments of a statement built by updateStatement.
+func updateArgs(keyValues, values []string) []interface{} {
+	args := make([]interface{}, 0, len(values)+len(keyValues))
+	for _, v := range values {
+		args = append(args, v)
+	}
+	for _, v := range keyValues {
+		args = append(args, v)
+	}
+	return args
+}
+
+// parentRecordSettings holds the settings shared by all DBClient implementations
+// for automatically creating parent records. It is embedded in each client.
+type parentRecordSettings struct {
+	generator ValueGenerator
+	// templates holds fixed column values per parent table, keyed by lower-cased table and column names.
+	templates map[string]map[string]string
+	// batchColumn, if set, is given batchValue in every parent table with the column.
+	batchColumn, batchValue string
+
+	mu sync.Mutex
+	// assignedKeys maps a referenced key (see assignedKeyID) to the key the database
+	// assigned when the parent record was created for it.
+	assignedKeys map[string][]string
+	// createdParents counts the auto-created parent records per table.
+	createdParents map[string]int
+	// onParentCreated, if set, is called for each auto-created parent record.
+	onParentCreated func(tableName string, columnNames, key []string)
+}
+
+// SetValueGenerator replaces the generator used to invent values for auto-created parent records.
+func (s *parentRecordSettings) SetValueGenerator(gen ValueGenerator) {
+	s.generator = gen
+}
+
+// SetParentTemplates sets fixed values (in CSV string form) used for the columns of auto-created
+// parent records, keyed by table name and then column name. Template values take precedence over
+// column defaults and generated values.
+func (s *parentRecordSettings) SetParentTemplates(templates map[string]map[string]string) {
+	s.templates = make(map[string]map[string]string, len(templates))
+	for tableName, row := range templates {
+		lowerRow := make(map[string]string, len(row))
+		for colName, value := range row {
+			lowerRow[strings.ToLower(colName)] = value
+		}
+		s.templates[strings.ToLower(tableName)] = lowerRow
+	}
+}
+
+// SetBatchColumn sets columnName (matched case-insensitively) to value in every auto-created
+// parent record whose table has the column, over any template value. An empty columnName removes it.
+func (s *parentRecordSettings) SetBatchColumn(columnName, value string) {
+	s.batchColumn, s.batchValue = strings.ToLower(columnName), value
+}
+
+// SetParentCreatedFunc sets a function called with the referenced columns and key of each
+// auto-created parent record. nil removes it.
+func (s *parentRecordSettings) SetParentCreatedFunc(fn func(tableName string, columnNames, key []string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onParentCreated = fn
+}
+
+func (s *parentRecordSettings) template(tableName string) map[string]string {
+	template := s.templates[strings.ToLower(tableName)]
+	if s.batchColumn == "" {
+		return template
+	}
+	template = maps.Clone(template)
+	if template == nil {
+		template = make(map[string]string, 1)
+	}
+	template[s.batchColumn] = s.batchValue
+	return template
+}
+
+func (s *parentRecordSettings) valueGenerator() ValueGenerator {
+	if s.generator == nil {
+		s.generator = NewFakeGenerator()
+	}
+	return s.generator
+}
+
+// assignedKeyID identifies a referenced key in assignedKeys.
+func assignedKeyID(tableName string, columnNames, values []string) string {
+	return tableName + "." + strings.Join(columnNames, ",") + "=" + strings.Join(values, "\x1f")
+}
+
+func (s *parentRecordSettings) assignedKey(tableName string, columnNames, values []string) ([]string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, ok := s.assignedKeys[assignedKeyID(tableName, columnNames, values)]
+	return key, ok
+}
+
+func (s *parentRecordSettings) recordAssignedKey(tableName string, columnNames, values, key []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.assignedKeys == nil {
+		s.assignedKeys = make(map[string][]string)
+	}
+	s.assignedKeys[assignedKeyID(tableName, columnNames, values)] = key
+}
+
+func (s *parentRecordSettings) recordCreatedParent(tableName string, columnNames, key []string) {
+	s.mu.Lock()
+	if s.createdParents == nil {
+		s.createdParents = make(map[string]int)
+	}
+	s.createdParents[tableName]++
+	onParentCreated := s.onParentCreated
+	s.mu.Unlock()
+	if onParentCreated != nil {
+		onParentCreated(tableName, columnNames, key)
+	}
+}
+
+// CreatedParents returns the number of parent records created so far, keyed by table name.
+func (s *parentRecordSettings) CreatedParents() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := make(map[string]int, len(s.createdParents))
+	for tableName, n := range s.createdParents {
+		counts[tableName] = n
 	}
+	return counts
+}
+
+// isDatabaseAssigned reports whether the database, not the INSERT, supplies the column's value.
+func isDatabaseAssigned(colInfo ColumnInfo) bool {
+	return colInfo.IsAutoIncrement || colInfo.IsGenerated
+}
+
+// maxParentInsertAttempts bounds how often an auto-created parent record is retried with freshly
+// generated values after colliding with an existing row.
+const maxParentInsertAttempts = 5
+
+// parentInsertFunc performs the database-specific INSERT of a parent record. If returning is not
+// empty, the value the database assigned to that column must be returned as key. inserted is false
+// when the row was rejected by a unique constraint.
+type parentInsertFunc func(cols []string, values []interface{}, returning string) (key interface{}, inserted bool, err error)
+
+// ensureParentRecord makes sure a parent record referenced by foreignKeyValues exists and returns the
+// key that children must use to reference it. This is foreignKeyValues itself unless a referenced
+// column is assigned by the database (serial, identity, AUTO_INCREMENT, generated), in which case the
+// record is created without it and the assigned value is substituted. Assigned keys are remembered,
+// so every child referencing the same values is linked to the same parent record.
+//
+// When an insert collides with an existing row, the record is rebuilt with new generated values,
+// up to maxParentInsertAttempts times.
+func ensureParentRecord(
+	ctx context.Context,
+	client DBClient,
+	settings *parentRecordSettings,
+	parentDBInfo DBInfo,
+	foreignColumnNames, foreignKeyValues []string,
+	dbSchema map[string]DBInfo,
+	insert parentInsertFunc,
+) ([]string, error) {
+	if key, ok := settings.assignedKey(parentDBInfo.TableName, foreignColumnNames, foreignKeyValues); ok {
+		return key, nil
+	}
+
+	// Check if the parent record already exists
+	exists, err := client.ParentRecordExists(ctx, parentDBInfo, foreignColumnNames, foreignKeyValues)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check parent record existence: %w", err)
+	}
+	if exists {
+		return foreignKeyValues, nil // Parent record already exists
+	}
+
+	// Parent record does not exist, create it
+	log.Printf("Creating missing parent record %s\n", formatParentKey(parentDBInfo.TableName, foreignColumnNames, foreignKeyValues))
+
+	// Only a single database-assigned column can be read back after the INSERT
+	returning, returningIdx := "", -1
+	for _, colInfo := range parentDBInfo.Columns {
+		for idx, colName := range foreignColumnNames {
+			if colInfo.ColumnName == colName && isDatabaseAssigned(colInfo) && returningIdx == -1 {
+				returning, returningIdx = colName, idx
+			}
+		}
+	}
+
+	for attempt := 1; attempt <= maxParentInsertAttempts; attempt++ {
+		parentCols, _, parentValues, err := ensureParentRecordExistsCommon(ctx, client, settings.valueGenerator(), settings.template(parentDBInfo.TableName), parentDBInfo, foreignColumnNames, foreignKeyValues, dbSchema)
+		if err != nil {
+			return nil, err
+		}
+		key, inserted, err := insert(parentCols, parentValues, returning)
+		if err != nil {
+			return nil, fmt.Errorf("failed to insert parent record into %s: %w", parentDBInfo.TableName, err)
+		}
+		if inserted {
+			if returningIdx == -1 {
+				settings.recordCreatedParent(parentDBInfo.TableName, foreignColumnNames, foreignKeyValues)
+				return foreignKeyValues, nil
+			}
+			assigned := append([]string(nil), foreignKeyValues...)
+			assigned[returningIdx] = FormatValue(key)
+			log.Printf("Parent record in table '%s' was assigned %s=%s for referenced value '%s'\n", parentDBInfo.TableName, returning, assigned[returningIdx], foreignKeyValues[returningIdx])
+			settings.recordAssignedKey(parentDBInfo.TableName, foreignColumnNames, foreignKeyValues, assigned)
+			settings.recordCreatedParent(parentDBInfo.TableName, foreignColumnNames, assigned)
+			return assigned, nil
+		}
+
+		// The conflict may have been on the referenced key itself, e.g. a row inserted in the meantime.
+		exists, err := client.ParentRecordExists(ctx, parentDBInfo, foreignColumnNames, foreignKeyValues)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check parent record existence: %w", err)
+		}
+		if exists {
+			return foreignKeyValues, nil
+		}
+		log.Printf("Warning: Generated values for parent record in table '%s' collided with an existing row (attempt %d/%d). Retrying with new values.\n", parentDBInfo.TableName, attempt, maxParentInsertAttempts)
+	}
+	return nil, &UniqueCollisionError{TableName: parentDBInfo.TableName, ColumnNames: foreignColumnNames, Values: foreignKeyValues, Attempts: maxParentInsertAttempts}
 }
 
 // ensureParentRecordExistsCommon contains the common logic for ensuring parent records.
 // It handles value generation and recursive calls, but delegates database-specific
 // operations (like checking existence and actual insertion) to the DBClient.
+// template holds the configured fixed values of the parent table, keyed by lower-cased column name.
 func ensureParentRecordExistsCommon(
+	ctx context.Context,
 	client DBClient,
+	generator ValueGenerator,
+	template map[string]string,
 	parentDBInfo DBInfo,
-	foreignColumnName, foreignKeyValue string,
+	foreignColumnNames, foreignKeyValues []string,
 	dbSchema map[string]DBInfo,
 ) ([]string, []string, []interface{}, error) {
 	// Prepare values for the new parent record
@@ -194,27 +658,48 @@ func ensureParentRecordExistsCommon(
 		uniqueColsMap[pkCol] = true
 	}
 	for _, ukCols := range parentDBInfo.UniqueKeyColumns {
-		if len(ukCols) == 1 { // Only consider single-column unique constraints for random generation
-			uniqueColsMap[ukCols[0]] = true
+		for _, ukCol := range ukCols {
+			uniqueColsMap[ukCol] = true
 		}
 	}
+	// Columns whose value was invented by the generator
+	generatedCols := make(map[string]bool)
+
+	// Columns left out of the INSERT so that the database assigns their values
+	omittedCols := make(map[string]bool)
+
+	// Values of the referenced key columns that triggered this call
+	foreignKeyValueMap := make(map[string]string, len(foreignColumnNames))
+	for idx, colName := range foreignColumnNames {
+		foreignKeyValueMap[colName] = foreignKeyValues[idx]
+	}
 
 	// First, populate parentValues with default/provided/random values
 	for colIdx, colInfo := range parentDBInfo.Columns {
-		parentCols = append(parentCols, colInfo.ColumnName)
-		// Placeholder will be database-specific, so we'll return these and let the caller format
-		parentPlaceholders = append(parentPlaceholders, "") // Placeholder for now
-
 		var val interface{}
 		var err error
 
-		if colInfo.ColumnName == foreignColumnName {
-			// Use the foreignKeyValue for the foreign key column that triggered this call
+		if isDatabaseAssigned(colInfo) {
+			// Let the sequence/identity assign the key instead of inventing one that it doesn't know about.
+			// This includes the referenced column; its assigned value is returned by the INSERT.
+			omittedCols[colInfo.ColumnName] = true
+			continue
+		}
+
+		if foreignKeyValue, ok := foreignKeyValueMap[colInfo.ColumnName]; ok {
+			// Use the foreign key value for the referenced column that triggered this call
 			val, err = ConvertToDBType(foreignKeyValue, colInfo.DataType, colInfo.IsNullable, colInfo.ColumnDefault)
 			if err != nil {
 				log.Printf("Warning: Failed to convert foreign key value '%s' for column %s (%s) in parent table %s: %v. Using nil.\n", foreignKeyValue, colInfo.ColumnName, colInfo.DataType, parentDBInfo.TableName, err)
 				val = nil // Use nil if conversion fails
 			}
+		} else if tmplValue, ok := template[strings.ToLower(colInfo.ColumnName)]; ok {
+			// Use the value from the parent table's template row
+			val, err = ConvertToDBType(tmplValue, colInfo.DataType, colInfo.IsNullable, colInfo.ColumnDefault)
+			if err != nil {
+				log.Printf("Warning: Failed to convert template value '%s' for column %s (%s) in parent table %s: %v. Using nil.\n", tmplValue, colInfo.ColumnName, colInfo.DataType, parentDBInfo.TableName, err)
+				val = nil
+			}
 		} else if colInfo.ColumnDefault.Valid {
 			// Use the explicit column default if available
 			val, err = ConvertToDBType(colInfo.ColumnDefault.String, colInfo.DataType, colInfo.IsNullable, colInfo.ColumnDefault)
@@ -222,15 +707,18 @@ func ensureParentRecordExistsCommon(
 				log.Printf("Warning: Failed to convert default value '%s' for column %s (%s) in parent table %s: %v. Using nil.\n", colInfo.ColumnDefault.String, colInfo.ColumnName, colInfo.DataType, parentDBInfo.TableName, err)
 				val = nil
 			}
-		} else if uniqueColsMap[colInfo.ColumnName] && !colInfo.IsNullable {
-			// If it's a unique column (PK or UK) and not nullable, generate a random value
-			val, err = generateRandomValue(colInfo.DataType)
+		} else if !colInfo.IsNullable {
+			// A NOT NULL column without a value gets a generated one, unique if it's part of a PK or UK
+			unique := uniqueColsMap[colInfo.ColumnName]
+			val, err = generator.GenerateValue(parentDBInfo.TableName, colInfo, unique)
 			if err != nil {
-				log.Printf("Warning: Failed to generate random value for unique column %s (%s) in parent table %s: %v. Using nil.\n", colInfo.ColumnName, colInfo.DataType, parentDBInfo.TableName, err)
+				log.Printf("Warning: Failed to generate random value for column %s (%s) in parent table %s: %v. Using nil.\n", colInfo.ColumnName, colInfo.DataType, parentDBInfo.TableName, err)
 				val = nil // Fallback to nil if random generation fails
+			} else if unique {
+				generatedCols[colInfo.ColumnName] = true
 			}
 		} else {
-			// For other columns, use default behavior (empty string for ConvertToDBType)
+			// Nullable columns are left NULL (ConvertToDBType of an empty string)
 			val, err = ConvertToDBType("", colInfo.DataType, colInfo.IsNullable, colInfo.ColumnDefault)
 			if err != nil {
 				log.Printf("Warning: Failed to get default value for column %s (%s) in parent table %s: %v. Using nil.\n", colInfo.ColumnName, colInfo.DataType, parentDBInfo.TableName, err)
@@ -240,106 +728,97 @@ func ensureParentRecordExistsCommon(
 		parentValues[colIdx] = val
 	}
 
-	// Recursively ensure parent records for this parentDBInfo's foreign keys
-	for _, fk := range parentDBInfo.ForeignKeys {
-		// Find the value for this foreign key from the prepared parentValues
-		fkColIdx := -1
-		for idx, colInfo := range parentDBInfo.Columns {
-			if colInfo.ColumnName == fk.ColumnName {
-				fkColIdx = idx
-				break
-			}
+	// A multi-column unique constraint is only satisfied if at least one of its columns
+	// is NULL or freshly generated. Otherwise (e.g. all other members have defaults),
+	// replace the default of one member with a generated value.
+	for _, ukCols := range parentDBInfo.UniqueKeyColumns {
+		if len(ukCols) < 2 {
+			continue
 		}
-
-		if fkColIdx != -1 {
-			fkValueInterface := parentValues[fkColIdx] // This is an interface{}
-			if fkValueInterface != nil {
-				// Convert the interface{} value back to a string suitable for the recursive call
-				var fkValueStr string
-				switch v := fkValueInterface.(type) {
-				case int64:
-					fkValueStr = strconv.FormatInt(v, 10)
-				case float64:
-					fkValueStr = strconv.FormatFloat(v, 'f', -1, 64)
-				case bool:
-					fkValueStr = strconv.FormatBool(v)
-				case time.Time:
-					fkValueStr = v.Format(time.RFC3339) // Or another suitable format
-				case string:
-					fkValueStr = v
-				default:
-					// Fallback for other types, might need more specific handling
-					fkValueStr = fmt.Sprintf("%v", v)
-				}
-
-				parentOfParentDBInfo, ok := dbSchema[fk.ForeignTableName]
-				if !ok {
-					return nil, nil, nil, fmt.Errorf("foreign table %s not found in schema info for foreign key %s during recursive ensureParent", fk.ForeignTableName, fk.ConstraintName)
+		satisfied := false
+		candidateIdx := -1
+		for _, ukCol := range ukCols {
+			for colIdx, colInfo := range parentDBInfo.Columns {
+				if colInfo.ColumnName != ukCol {
+					continue
 				}
-				err := client.EnsureParentRecordExists(parentOfParentDBInfo, fk.ForeignColumnName, fkValueStr, dbSchema)
-				if err != nil {
-					return nil, nil, nil, fmt.Errorf("failed to recursively ensure parent record for %s.%s (value: %s): %w", fk.ForeignTableName, fk.ForeignColumnName, fkValueStr, err)
+				if parentValues[colIdx] == nil || generatedCols[ukCol] || omittedCols[ukCol] {
+					satisfied = true
+				} else if _, referenced := foreignKeyValueMap[ukCol]; !referenced && candidateIdx == -1 {
+					candidateIdx = colIdx
 				}
 			}
-		} else {
-			log.Printf("Warning: Foreign key column '%s' not found in parentDBInfo.Columns for table '%s'. Cannot recursively ensure its parent.\n", fk.ColumnName, parentDBInfo.TableName)
 		}
+		if satisfied || candidateIdx == -1 {
+			continue
+		}
+		colInfo := parentDBInfo.Columns[candidateIdx]
+		val, err := generator.GenerateValue(parentDBInfo.TableName, colInfo, true)
+		if err != nil {
+			log.Printf("Warning: Failed to generate value for column %s of unique constraint (%s) in parent table %s: %v.\n", colInfo.ColumnName, strings.Join(ukCols, ", "), parentDBInfo.TableName, err)
+			continue
+		}
+		parentValues[candidateIdx] = val
+		generatedCols[colInfo.ColumnName] = true
 	}
-	return parentCols, parentPlaceholders, parentValues, nil
-}
 
-// generateRandomValue generates a random value suitable for database insertion based on data type.
-// This is used for unique columns (PK/UK) that don't have a default value and are not the FK being inserted.
-func generateRandomValue(dataType ColumnDataType) (interface{}, error) {
-	switch dataType {
-	case StringType:
-		b := make([]byte, 16) // 16 bytes for a 32-char hex string
-		_, err := rand.Read(b)
-		if err != nil {
-			return nil, fmt.Errorf("failed to generate random bytes for string: %w", err)
+	// Recursively ensure parent records for this parentDBInfo's foreign keys
+	colIdxByName := make(map[string]int, len(parentDBInfo.Columns))
+	for idx, colInfo := range parentDBInfo.Columns {
+		colIdxByName[colInfo.ColumnName] = idx
+	}
+	for _, fk := range parentDBInfo.ForeignKeys {
+		// Find the values for this foreign key from the prepared parentValues.
+		// A key with any NULL (or database-assigned) column references nothing.
+		fkColIdxs := make([]int, 0, len(fk.ColumnNames))
+		fkValueStrs := make([]string, 0, len(fk.ColumnNames))
+		for _, colName := range fk.ColumnNames {
+			idx, ok := colIdxByName[colName]
+			if !ok {
+				log.Printf("Warning: Foreign key column '%s' not found in parentDBInfo.Columns for table '%s'. Cannot recursively ensure its parent.\n", colName, parentDBInfo.TableName)
+				break
+			}
+			if parentValues[idx] == nil || omittedCols[colName] {
+				break
+			}
+			fkColIdxs = append(fkColIdxs, idx)
+			// Convert the interface{} value back to a string suitable for the recursive call
+			fkValueStrs = append(fkValueStrs, FormatValue(parentValues[idx]))
 		}
-		return hex.EncodeToString(b), nil
-	case IntegerType:
-		// Generate a random int64
-		max := big.NewInt(int64(^uint64(0) >> 1)) // Max int64
-		n, err := rand.Int(rand.Reader, max)
-		if err != nil {
-			return nil, fmt.Errorf("failed to generate random integer: %w", err)
+		if len(fkValueStrs) != len(fk.ColumnNames) {
+			continue
 		}
-		return n.Int64(), nil
-	case FloatType:
-		// Generate a random float64 between 0 and 1, then scale it
-		// This is a simple approach; for true randomness or specific ranges, more complex logic might be needed.
-		max := big.NewInt(1e9) // For a reasonable range of floats
-		n, err := rand.Int(rand.Reader, max)
-		if err != nil {
-			return nil, fmt.Errorf("failed to generate random float: %w", err)
+
+		parentOfParentDBInfo, ok := dbSchema[fk.ForeignTableName]
+		if !ok {
+			return nil, nil, nil, &MissingParentTableError{TableName: fk.ForeignTableName, ConstraintName: fk.ConstraintName}
 		}
-		return float64(n.Int64()) / float64(max.Int64()), nil
-	case BooleanType:
-		// Random boolean
-		b := make([]byte, 1)
-		_, err := rand.Read(b)
+		key, err := client.EnsureParentRecordExists(ctx, parentOfParentDBInfo, fk.ForeignColumnNames, fkValueStrs, dbSchema)
 		if err != nil {
-			return nil, fmt.Errorf("failed to generate random boolean: %w", err)
-		}
-		return b[0]%2 == 0, nil
-	case DateType, TimestampType:
-		// Generate a random time within a reasonable range (e.g., last 10 years)
-		now := time.Now()
-		tenYearsAgo := now.AddDate(-10, 0, 0)
-		diff := now.Sub(tenYearsAgo)
-		randomSeconds := big.NewInt(0)
-		if diff.Seconds() > 0 {
-			maxSeconds := big.NewInt(int64(diff.Seconds()))
-			var err error
-			randomSeconds, err = rand.Int(rand.Reader, maxSeconds)
+			return nil, nil, nil, fmt.Errorf("failed to recursively ensure parent record %s: %w", formatParentKey(fk.ForeignTableName, fk.ForeignColumnNames, fkValueStrs), err)
+		}
+		for i, idx := range fkColIdxs {
+			if key[i] == fkValueStrs[i] {
+				continue
+			}
+			// Reference the key the database assigned to the grandparent
+			colInfo := parentDBInfo.Columns[idx]
+			parentValues[idx], err = ConvertToDBType(key[i], colInfo.DataType, colInfo.IsNullable, colInfo.ColumnDefault)
 			if err != nil {
-				return nil, fmt.Errorf("failed to generate random time: %w", err)
+				return nil, nil, nil, fmt.Errorf("failed to convert assigned key '%s' for column %s in parent table %s: %w", key[i], colInfo.ColumnName, parentDBInfo.TableName, err)
 			}
 		}
-		return tenYearsAgo.Add(time.Duration(randomSeconds.Int64()) * time.Second), nil
-	default:
-		return nil, fmt.Errorf("unsupported data type for random value generation: %s", dataType.String())
 	}
+
+	insertValues := make([]interface{}, 0, len(parentDBInfo.Columns))
+	for colIdx, colInfo := range parentDBInfo.Columns {
+		if omittedCols[colInfo.ColumnName] {
+			continue
+		}
+		parentCols = append(parentCols, colInfo.ColumnName)
+		// Placeholder will be database-specific, so we'll return these and let the caller format
+		parentPlaceholders = append(parentPlaceholders, "") // Placeholder for now
+		insertValues = append(insertValues, parentValues[colIdx])
+	}
+	return parentCols, parentPlaceholders, insertValues, nil
 }
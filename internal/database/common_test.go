@@ -0,0 +1,403 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_appendForeignKeyColumn(t *testing.T) {
+	t.Run("同じ制約の列が1つの外部キーにまとめられること", func(t *testing.T) {
+		var fks []ForeignKeyInfo
+		fks = appendForeignKeyColumn(fks, "order_items", "fk_order", "order_id", "orders", "id")
+		fks = appendForeignKeyColumn(fks, "order_items", "fk_variant", "product_id", "variants", "product_id")
+		fks = appendForeignKeyColumn(fks, "order_items", "fk_variant", "variant_no", "variants", "no")
+
+		assert.Equal(t, []ForeignKeyInfo{
+			{
+				ConstraintName:     "fk_order",
+				TableName:          "order_items",
+				ColumnNames:        []string{"order_id"},
+				ForeignTableName:   "orders",
+				ForeignColumnNames: []string{"id"},
+			},
+			{
+				ConstraintName:     "fk_variant",
+				TableName:          "order_items",
+				ColumnNames:        []string{"product_id", "variant_no"},
+				ForeignTableName:   "variants",
+				ForeignColumnNames: []string{"product_id", "no"},
+			},
+		}, fks)
+	})
+}
+
+func Test_addForeignSchemaTables(t *testing.T) {
+	t.Run("他スキーマの親テーブルが連鎖的に追加されること", func(t *testing.T) {
+		schemaInfo := map[string]DBInfo{
+			"orders": {TableName: "orders", ForeignKeys: []ForeignKeyInfo{
+				{TableName: "orders", ColumnNames: []string{"account_id"}, ForeignTableName: "billing.accounts", ForeignColumnNames: []string{"id"}},
+				{TableName: "orders", ColumnNames: []string{"user_id"}, ForeignTableName: "users", ForeignColumnNames: []string{"id"}},
+			}},
+			"users": {TableName: "users"},
+		}
+		foreign := map[string]DBInfo{
+			"billing.accounts": {TableName: "billing.accounts", ForeignKeys: []ForeignKeyInfo{
+				{TableName: "billing.accounts", ColumnNames: []string{"company_id"}, ForeignTableName: "crm.companies", ForeignColumnNames: []string{"id"}},
+			}},
+			"crm.companies": {TableName: "crm.companies"},
+		}
+		var described []string
+		describe := func(ctx context.Context, tableSchema, tableName string) (DBInfo, error) {
+			described = append(described, tableSchema+"."+tableName)
+			return foreign[tableSchema+"."+tableName], nil
+		}
+
+		assert.NoError(t, addForeignSchemaTables(context.Background(), schemaInfo, describe))
+		assert.Equal(t, []string{"billing.accounts", "crm.companies"}, described)
+		assert.Contains(t, schemaInfo, "billing.accounts")
+		assert.Contains(t, schemaInfo, "crm.companies")
+	})
+
+	t.Run("同じスキーマのテーブル名は修飾されないこと", func(t *testing.T) {
+		assert.Equal(t, "users", qualifiedTableName("public", "public", "users"))
+		assert.Equal(t, "billing.accounts", qualifiedTableName("public", "billing", "accounts"))
+	})
+}
+
+func Test_formatParentKey(t *testing.T) {
+	assert.Equal(t, "users.id=1", formatParentKey("users", []string{"id"}, []string{"1"}))
+	assert.Equal(t, "variants.(product_id, no)=(10, 2)", formatParentKey("variants", []string{"product_id", "no"}, []string{"10", "2"}))
+}
+
+func Test_updateStatement(t *testing.T) {
+	query := updateStatement("employees", []string{"org_id", "id"}, []string{"manager_id"}, func(n int) string { return fmt.Sprintf("$%d", n) })
+	assert.Equal(t, "UPDATE employees SET manager_id = $1 WHERE org_id = $2 AND id = $3", query)
+	assert.Equal(t, []interface{}{"7", "1", "42"}, updateArgs([]string{"1", "42"}, []string{"7"}))
+}
+
+// stubParentClient is the DBClient of ensureParentRecord tests. The methods it does not implement
+// panic through the nil DBClient.
+type stubParentClient struct {
+	DBClient
+	existing map[string]bool     // Existing records, by assignedKeyID
+	assigned map[string][]string // Keys EnsureParentRecordExists returns, by assignedKeyID of the referenced key
+	checks   int                 // Number of ParentRecordExists calls
+}
+
+func (c *stubParentClient) ParentRecordExists(ctx context.Context, dbInfo DBInfo, columnNames, values []string) (bool, error) {
+	c.checks++
+	return c.existing[assignedKeyID(dbInfo.TableName, columnNames, values)], nil
+}
+
+func (c *stubParentClient) EnsureParentRecordExists(ctx context.Context, parentDBInfo DBInfo, foreignColumnNames, foreignKeyValues []string, dbSchema map[string]DBInfo) ([]string, error) {
+	if key, ok := c.assigned[assignedKeyID(parentDBInfo.TableName, foreignColumnNames, foreignKeyValues)]; ok {
+		return key, nil
+	}
+	return foreignKeyValues, nil
+}
+
+// sequenceGenerator generates "<column>-<n>", counting the values it generated.
+type sequenceGenerator struct{ n int }
+
+func (g *sequenceGenerator) GenerateValue(tableName string, col ColumnInfo, unique bool) (interface{}, error) {
+	g.n++
+	return fmt.Sprintf("%s-%d", col.ColumnName, g.n), nil
+}
+
+func Test_ensureParentRecord(t *testing.T) {
+	users := DBInfo{
+		TableName:         "users",
+		Columns:           []ColumnInfo{{ColumnName: "id", DataType: IntegerType}, {ColumnName: "name", DataType: StringType, IsNullable: true}},
+		PrimaryKeyColumns: []string{"id"},
+	}
+	serialUsers := users
+	serialUsers.Columns = []ColumnInfo{{ColumnName: "id", DataType: IntegerType, IsAutoIncrement: true}, {ColumnName: "name", DataType: StringType, IsNullable: true}}
+
+	t.Run("参照された値で親レコードが作成されること", func(t *testing.T) {
+		settings := &parentRecordSettings{generator: &sequenceGenerator{}}
+		var created [][]string
+		settings.SetParentCreatedFunc(func(tableName string, columnNames, key []string) { created = append(created, key) })
+		var insertedCols []string
+		var insertedValues []interface{}
+		key, err := ensureParentRecord(context.Background(), &stubParentClient{}, settings, users, []string{"id"}, []string{"7"}, nil,
+			func(cols []string, values []interface{}, returning string) (interface{}, bool, error) {
+				assert.Empty(t, returning)
+				insertedCols, insertedValues = cols, values
+				return nil, true, nil
+			})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"7"}, key)
+		assert.Equal(t, []string{"id", "name"}, insertedCols)
+		assert.Equal(t, []interface{}{int64(7), nil}, insertedValues)
+		assert.Equal(t, map[string]int{"users": 1}, settings.CreatedParents())
+		assert.Equal(t, [][]string{{"7"}}, created)
+	})
+
+	t.Run("データベースが割り当てたキーが返されること", func(t *testing.T) {
+		settings := &parentRecordSettings{generator: &sequenceGenerator{}}
+		var insertedCols []string
+		key, err := ensureParentRecord(context.Background(), &stubParentClient{}, settings, serialUsers, []string{"id"}, []string{"7"}, nil,
+			func(cols []string, values []interface{}, returning string) (interface{}, bool, error) {
+				assert.Equal(t, "id", returning)
+				insertedCols = cols
+				return int64(42), true, nil
+			})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"42"}, key)
+		assert.Equal(t, []string{"name"}, insertedCols, "割り当てられる列はINSERTに含まれないこと")
+		assert.Equal(t, map[string]int{"users": 1}, settings.CreatedParents())
+	})
+
+	t.Run("既存の親レコードには挿入しないこと", func(t *testing.T) {
+		settings := &parentRecordSettings{generator: &sequenceGenerator{}}
+		client := &stubParentClient{existing: map[string]bool{assignedKeyID("users", []string{"id"}, []string{"7"}): true}}
+		key, err := ensureParentRecord(context.Background(), client, settings, users, []string{"id"}, []string{"7"}, nil,
+			func(cols []string, values []interface{}, returning string) (interface{}, bool, error) {
+				t.Fatal("the existing parent record must not be inserted")
+				return nil, false, nil
+			})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"7"}, key)
+		assert.Empty(t, settings.CreatedParents())
+	})
+
+	t.Run("挿入のエラーはテーブル名とともに返されること", func(t *testing.T) {
+		settings := &parentRecordSettings{generator: &sequenceGenerator{}}
+		_, err := ensureParentRecord(context.Background(), &stubParentClient{}, settings, users, []string{"id"}, []string{"7"}, nil,
+			func(cols []string, values []interface{}, returning string) (interface{}, bool, error) {
+				return nil, false, errors.New("permission denied")
+			})
+		assert.EqualError(t, err, "failed to insert parent record into users: permission denied")
+		assert.Empty(t, settings.CreatedParents())
+	})
+
+	accounts := DBInfo{
+		TableName:         "accounts",
+		Columns:           []ColumnInfo{{ColumnName: "id", DataType: IntegerType}, {ColumnName: "code", DataType: StringType}},
+		PrimaryKeyColumns: []string{"id"},
+		UniqueKeyColumns:  [][]string{{"code"}},
+	}
+
+	t.Run("ユニークキーが衝突した場合は新しい値で再試行すること", func(t *testing.T) {
+		settings := &parentRecordSettings{generator: &sequenceGenerator{}}
+		var codes []interface{}
+		key, err := ensureParentRecord(context.Background(), &stubParentClient{}, settings, accounts, []string{"id"}, []string{"7"}, nil,
+			func(cols []string, values []interface{}, returning string) (interface{}, bool, error) {
+				codes = append(codes, values[1])
+				return nil, len(codes) == 2, nil
+			})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"7"}, key)
+		assert.Equal(t, []interface{}{"code-1", "code-2"}, codes)
+		assert.Equal(t, map[string]int{"accounts": 1}, settings.CreatedParents())
+	})
+
+	t.Run("衝突が参照されたキー自体の場合は再試行しないこと", func(t *testing.T) {
+		settings := &parentRecordSettings{generator: &sequenceGenerator{}}
+		client := &stubParentClient{existing: make(map[string]bool)}
+		inserts := 0
+		key, err := ensureParentRecord(context.Background(), client, settings, accounts, []string{"id"}, []string{"7"}, nil,
+			func(cols []string, values []interface{}, returning string) (interface{}, bool, error) {
+				// Another session inserted the record in the meantime
+				inserts++
+				client.existing[assignedKeyID("accounts", []string{"id"}, []string{"7"})] = true
+				return nil, false, nil
+			})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"7"}, key)
+		assert.Equal(t, 1, inserts)
+		assert.Empty(t, settings.CreatedParents())
+	})
+
+	t.Run("maxParentInsertAttempts回衝突するとUniqueCollisionErrorになること", func(t *testing.T) {
+		settings := &parentRecordSettings{generator: &sequenceGenerator{}}
+		inserts := 0
+		_, err := ensureParentRecord(context.Background(), &stubParentClient{}, settings, accounts, []string{"id"}, []string{"7"}, nil,
+			func(cols []string, values []interface{}, returning string) (interface{}, bool, error) {
+				inserts++
+				return nil, false, nil
+			})
+		assert.ErrorIs(t, err, ErrUniqueCollision)
+		var collisionErr *UniqueCollisionError
+		require.ErrorAs(t, err, &collisionErr)
+		assert.Equal(t, maxParentInsertAttempts, collisionErr.Attempts)
+		assert.Equal(t, maxParentInsertAttempts, inserts)
+		assert.Empty(t, settings.CreatedParents())
+	})
+}
+
+func Test_ensureParentRecordExistsCommon(t *testing.T) {
+	t.Run("既定値だけの複合ユニークキーは1列が生成値になること", func(t *testing.T) {
+		plans := DBInfo{
+			TableName: "plans",
+			Columns: []ColumnInfo{
+				{ColumnName: "id", DataType: IntegerType},
+				{ColumnName: "tier", DataType: StringType, ColumnDefault: sql.NullString{String: "basic", Valid: true}},
+				{ColumnName: "region", DataType: StringType, ColumnDefault: sql.NullString{String: "eu", Valid: true}},
+			},
+			PrimaryKeyColumns: []string{"id"},
+			UniqueKeyColumns:  [][]string{{"tier", "region"}},
+		}
+		generator := &sequenceGenerator{}
+		cols, _, values, err := ensureParentRecordExistsCommon(context.Background(), &stubParentClient{}, generator, nil, plans, []string{"id"}, []string{"1"}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"id", "tier", "region"}, cols)
+		assert.Equal(t, []interface{}{int64(1), "tier-1", "eu"}, values)
+		assert.Equal(t, 1, generator.n)
+	})
+
+	t.Run("キー以外のNOT NULLの列には値が生成されNULL許容の列はNULLになること", func(t *testing.T) {
+		users := DBInfo{
+			TableName: "users",
+			Columns: []ColumnInfo{
+				{ColumnName: "id", DataType: IntegerType},
+				{ColumnName: "name", DataType: StringType},
+				{ColumnName: "status", DataType: StringType, ColumnDefault: sql.NullString{String: "active", Valid: true}},
+				{ColumnName: "note", DataType: StringType, IsNullable: true},
+			},
+			PrimaryKeyColumns: []string{"id"},
+		}
+		generator := &sequenceGenerator{}
+		_, _, values, err := ensureParentRecordExistsCommon(context.Background(), &stubParentClient{}, generator, nil, users, []string{"id"}, []string{"1"}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{int64(1), "name-1", "active", nil}, values)
+	})
+
+	t.Run("NULLの列を含む複合ユニークキーには値を生成しないこと", func(t *testing.T) {
+		plans := DBInfo{
+			TableName: "plans",
+			Columns: []ColumnInfo{
+				{ColumnName: "id", DataType: IntegerType},
+				{ColumnName: "tier", DataType: StringType, ColumnDefault: sql.NullString{String: "basic", Valid: true}},
+				{ColumnName: "region", DataType: StringType, IsNullable: true},
+			},
+			PrimaryKeyColumns: []string{"id"},
+			UniqueKeyColumns:  [][]string{{"tier", "region"}},
+		}
+		generator := &sequenceGenerator{}
+		_, _, values, err := ensureParentRecordExistsCommon(context.Background(), &stubParentClient{}, generator, nil, plans, []string{"id"}, []string{"1"}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{int64(1), "basic", nil}, values)
+		assert.Zero(t, generator.n)
+	})
+}
+
+func Test_recordAssignedKey(t *testing.T) {
+	organizations := DBInfo{
+		TableName:         "organizations",
+		Columns:           []ColumnInfo{{ColumnName: "id", DataType: IntegerType, IsAutoIncrement: true}, {ColumnName: "name", DataType: StringType, IsNullable: true}},
+		PrimaryKeyColumns: []string{"id"},
+	}
+
+	t.Run("割り当てられたキーは同じ参照値に対して再利用されること", func(t *testing.T) {
+		settings := &parentRecordSettings{generator: &sequenceGenerator{}}
+		client := &stubParentClient{}
+		inserts := 0
+		insert := func(cols []string, values []interface{}, returning string) (interface{}, bool, error) {
+			inserts++
+			return int64(40 + inserts), true, nil
+		}
+		first, err := ensureParentRecord(context.Background(), client, settings, organizations, []string{"id"}, []string{"7"}, nil, insert)
+		require.NoError(t, err)
+		second, err := ensureParentRecord(context.Background(), client, settings, organizations, []string{"id"}, []string{"7"}, nil, insert)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"41"}, first)
+		assert.Equal(t, first, second)
+		assert.Equal(t, 1, inserts)
+		assert.Equal(t, 1, client.checks, "記録されたキーはデータベースに問い合わせないこと")
+
+		other, err := ensureParentRecord(context.Background(), client, settings, organizations, []string{"id"}, []string{"8"}, nil, insert)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"42"}, other, "別の参照値には別の親レコードが作成されること")
+	})
+
+	t.Run("子はデータベースが割り当てた親のキーを参照するよう書き換えられること", func(t *testing.T) {
+		users := DBInfo{
+			TableName: "users",
+			Columns: []ColumnInfo{
+				{ColumnName: "id", DataType: IntegerType},
+				{ColumnName: "org_id", DataType: IntegerType, ColumnDefault: sql.NullString{String: "7", Valid: true}},
+			},
+			PrimaryKeyColumns: []string{"id"},
+			ForeignKeys:       []ForeignKeyInfo{{TableName: "users", ColumnNames: []string{"org_id"}, ForeignTableName: "organizations", ForeignColumnNames: []string{"id"}}},
+		}
+		client := &stubParentClient{assigned: map[string][]string{assignedKeyID("organizations", []string{"id"}, []string{"7"}): {"42"}}}
+		schema := map[string]DBInfo{"organizations": organizations, "users": users}
+		cols, _, values, err := ensureParentRecordExistsCommon(context.Background(), client, &sequenceGenerator{}, nil, users, []string{"id"}, []string{"1"}, schema)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"id", "org_id"}, cols)
+		assert.Equal(t, []interface{}{int64(1), int64(42)}, values)
+	})
+}
+
+func Test_SetParentTemplates(t *testing.T) {
+	accounts := DBInfo{
+		TableName: "accounts",
+		Columns: []ColumnInfo{
+			{ColumnName: "id", DataType: IntegerType},
+			{ColumnName: "code", DataType: StringType},
+			{ColumnName: "status", DataType: StringType, ColumnDefault: sql.NullString{String: "new", Valid: true}},
+			{ColumnName: "import_batch_id", DataType: StringType, IsNullable: true},
+		},
+		PrimaryKeyColumns: []string{"id"},
+		UniqueKeyColumns:  [][]string{{"code"}},
+	}
+
+	t.Run("テンプレートの値が既定値と生成値より優先されること", func(t *testing.T) {
+		settings := &parentRecordSettings{}
+		settings.SetParentTemplates(map[string]map[string]string{"Accounts": {"CODE": "fixed", "Status": "active"}})
+		assert.Equal(t, map[string]string{"code": "fixed", "status": "active"}, settings.template("ACCOUNTS"), "テーブル名と列名は大文字小文字を区別しないこと")
+
+		generator := &sequenceGenerator{}
+		_, _, values, err := ensureParentRecordExistsCommon(context.Background(), &stubParentClient{}, generator, settings.template("accounts"), accounts, []string{"id"}, []string{"1"}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{int64(1), "fixed", "active", nil}, values)
+		assert.Zero(t, generator.n)
+	})
+
+	t.Run("参照された値はテンプレートより優先されること", func(t *testing.T) {
+		settings := &parentRecordSettings{}
+		settings.SetParentTemplates(map[string]map[string]string{"accounts": {"id": "99"}})
+		_, _, values, err := ensureParentRecordExistsCommon(context.Background(), &stubParentClient{}, &sequenceGenerator{}, settings.template("accounts"), accounts, []string{"id"}, []string{"1"}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), values[0])
+	})
+
+	t.Run("バッチ列はテンプレートの値を上書きすること", func(t *testing.T) {
+		settings := &parentRecordSettings{}
+		settings.SetParentTemplates(map[string]map[string]string{"accounts": {"import_batch_id": "old"}})
+		settings.SetBatchColumn("IMPORT_BATCH_ID", "run-1")
+		assert.Equal(t, map[string]string{"import_batch_id": "run-1"}, settings.template("accounts"))
+		assert.Equal(t, map[string]string{"import_batch_id": "run-1"}, settings.template("users"), "テンプレートのないテーブルにも設定されること")
+		assert.Equal(t, map[string]string{"import_batch_id": "old"}, settings.templates["accounts"], "テンプレート自体は変更されないこと")
+	})
+}
+
+func Test_DBInfo_InsertColumns(t *testing.T) {
+	id := ColumnInfo{ColumnName: "id", DataType: IntegerType}
+	serial := ColumnInfo{ColumnName: "id", DataType: IntegerType, IsAutoIncrement: true}
+	name := ColumnInfo{ColumnName: "name", DataType: StringType}
+	total := ColumnInfo{ColumnName: "total", DataType: FloatType, IsGenerated: true}
+	tests := []struct {
+		name    string
+		columns []ColumnInfo
+		want    []ColumnInfo
+	}{
+		{"生成列がなければすべての列になること", []ColumnInfo{id, name}, []ColumnInfo{id, name}},
+		{"生成列は除かれ順序は保たれること", []ColumnInfo{id, total, name}, []ColumnInfo{id, name}},
+		{"自動採番の列は含まれること", []ColumnInfo{serial, name}, []ColumnInfo{serial, name}},
+		{"すべて生成列なら空になること", []ColumnInfo{total}, []ColumnInfo{}},
+		{"列がなければ空になること", nil, []ColumnInfo{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, DBInfo{TableName: "orders", Columns: tt.columns}.InsertColumns())
+		})
+	}
+}
@@ -4,9 +4,12 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"slices"
+	"sort"
 	"strings"
 
 	_ "github.com/ibmdb/go_ibm_db" // DB2 driver
@@ -14,23 +17,31 @@ import (
 
 // DB2DB implements the DBClient interface for DB2.
 type DB2DB struct {
+	parentRecordSettings
 	db *sql.DB
+	// schemaName is the upper-cased schema last passed to GetSchemaInfo, the schema of unqualified table names.
+	schemaName string
 }
 
 // NewDB2Client creates a new DB2DB instance.
-func NewDB2Client(connStr string) (DBClient, error) {
-	db, err := sql.Open("go_ibm_db", connStr)
+func NewDB2Client(ctx context.Context, connStr string, options ConnOptions) (DBClient, error) {
+	db, err := openDB("go_ibm_db", connStr, options)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database connection: %w", err)
 	}
-	if err = db.Ping(); err != nil {
+	if err = db.PingContext(ctx); err != nil {
 		db.Close()
-		return nil, fmt.Errorf("failed to connect to DB2 database: %w", err)
+		return nil, &ConnectionError{DBType: "DB2", Err: err}
 	}
 	log.Println("Successfully connected to DB2 database.")
 	return &DB2DB{db: db}, nil
 }
 
+// newDB2Dialect returns a DB2DB using db, e.g. to write the statements of DB2 to an SQL file.
+func newDB2Dialect(db *sql.DB, schemaName string) (sqlDialect, error) {
+	return &DB2DB{db: db, schemaName: strings.ToUpper(schemaName)}, nil
+}
+
 // GetDB returns the underlying *sql.DB connection.
 func (d *DB2DB) GetDB() *sql.DB {
 	return d.db
@@ -45,47 +56,70 @@ func (d *DB2DB) Close() error {
 }
 
 // GetSchemaInfo retrieves schema information for a given schema name from DB2.
-func (d *DB2DB) GetSchemaInfo(schemaName string) (map[string]DBInfo, error) {
+// Tables in other schemas that foreign keys reference are included as "SCHEMA.TABLE".
+func (d *DB2DB) GetSchemaInfo(ctx context.Context, schemaName string) (map[string]DBInfo, error) {
 	log.Printf("Retrieving schema for '%s' from DB2.\n", schemaName)
 
-	tables, err := d.getTableNames(schemaName)
+	tables, err := d.getTableNames(ctx, schemaName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get table names from schema '%s': %w", schemaName, err)
 	}
 
+	mainSchema := strings.ToUpper(schemaName) // Compared with the REFTABSCHEMA of foreign keys
+	d.schemaName = mainSchema
 	schemaInfo := make(map[string]DBInfo)
 	for _, tableName := range tables {
-		columns, err := d.getColumnInfo(tableName, schemaName)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get column info for table %s: %w", tableName, err)
-		}
-		primaryKeys, err := d.getPrimaryKeyColumns(tableName, schemaName)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get primary key info for table %s: %w", tableName, err)
+		if isImportRunsTable(tableName) {
+			continue
 		}
-		uniqueKeys, err := d.getUniqueKeyColumns(tableName, schemaName)
+		dbInfo, err := d.describeTable(ctx, mainSchema, mainSchema, tableName)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get unique key info for table %s: %w", tableName, err)
-		}
-		foreignKeys, err := d.getForeignKeyInfo(tableName, schemaName)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get foreign key info for table %s: %w", tableName, err)
-		}
-
-		schemaInfo[tableName] = DBInfo{
-			TableName:         tableName,
-			Columns:           columns,
-			PrimaryKeyColumns: primaryKeys,
-			UniqueKeyColumns:  uniqueKeys,
-			ForeignKeys:       foreignKeys,
+			return nil, err
 		}
+		schemaInfo[tableName] = dbInfo
 	}
 
+	describe := func(ctx context.Context, tableSchema, tableName string) (DBInfo, error) {
+		return d.describeTable(ctx, mainSchema, tableSchema, tableName)
+	}
+	if err := addForeignSchemaTables(ctx, schemaInfo, describe); err != nil {
+		return nil, err
+	}
 	return schemaInfo, nil
 }
 
-func (d *DB2DB) getTableNames(schemaName string) ([]string, error) {
-	rows, err := d.db.Query(`
+// describeTable introspects one table of tableSchema. mainSchema is the schema being imported.
+func (d *DB2DB) describeTable(ctx context.Context, mainSchema, tableSchema, tableName string) (DBInfo, error) {
+	qualifiedName := qualifiedTableName(mainSchema, tableSchema, tableName)
+	columns, err := d.getColumnInfo(ctx, tableName, tableSchema)
+	if err != nil {
+		return DBInfo{}, fmt.Errorf("failed to get column info for table %s: %w", qualifiedName, err)
+	}
+	primaryKeys, err := d.getPrimaryKeyColumns(ctx, tableName, tableSchema)
+	if err != nil {
+		return DBInfo{}, fmt.Errorf("failed to get primary key info for table %s: %w", qualifiedName, err)
+	}
+	uniqueKeys, err := d.getUniqueKeyColumns(ctx, tableName, tableSchema)
+	if err != nil {
+		return DBInfo{}, fmt.Errorf("failed to get unique key info for table %s: %w", qualifiedName, err)
+	}
+	foreignKeys, err := d.getForeignKeyInfo(ctx, mainSchema, tableName, tableSchema)
+	if err != nil {
+		return DBInfo{}, fmt.Errorf("failed to get foreign key info for table %s: %w", qualifiedName, err)
+	}
+
+	return DBInfo{
+		TableName:         qualifiedName,
+		Columns:           columns,
+		PrimaryKeyColumns: primaryKeys,
+		UniqueKeyColumns:  uniqueKeys,
+		ForeignKeys:       foreignKeys,
+	}, nil
+}
+
+func (d *DB2DB) getTableNames(ctx context.Context, schemaName string) ([]string, error) {
+	// Only regular tables: views ('V'), aliases ('A') and materialized query tables ('S') are skipped
+	rows, err := d.db.QueryContext(ctx, `
 		SELECT TABNAME
 		FROM SYSCAT.TABLES
 		WHERE TABSCHEMA = ? AND TYPE = 'T'
@@ -106,9 +140,9 @@ func (d *DB2DB) getTableNames(schemaName string) ([]string, error) {
 	return tables, nil
 }
 
-func (d *DB2DB) getColumnInfo(tableName, schemaName string) ([]ColumnInfo, error) {
-	rows, err := d.db.Query(`
-		SELECT COLNAME, TYPENAME, NULLS, DEFAULT
+func (d *DB2DB) getColumnInfo(ctx context.Context, tableName, schemaName string) ([]ColumnInfo, error) {
+	rows, err := d.db.QueryContext(ctx, `
+		SELECT COLNAME, TYPENAME, NULLS, DEFAULT, IDENTITY, GENERATED, COALESCE(REMARKS, '')
 		FROM SYSCAT.COLUMNS
 		WHERE TABSCHEMA = ? AND TABNAME = ?
 		ORDER BY COLNO
@@ -120,24 +154,28 @@ func (d *DB2DB) getColumnInfo(tableName, schemaName string) ([]ColumnInfo, error
 
 	var columns []ColumnInfo
 	for rows.Next() {
-		var colName, dataType, isNullableStr string
+		var colName, dataType, isNullableStr, identityStr, generatedStr, comment string
 		var colDefault sql.NullString
-		if err := rows.Scan(&colName, &dataType, &isNullableStr, &colDefault); err != nil {
+		if err := rows.Scan(&colName, &dataType, &isNullableStr, &colDefault, &identityStr, &generatedStr, &comment); err != nil {
 			return nil, fmt.Errorf("scan failed: %w", err)
 		}
 		isNullable := (isNullableStr == "Y") // DB2 uses 'Y' for nullable
 		columns = append(columns, ColumnInfo{
-			ColumnName:    colName,
-			DataType:      ParseDataType(dataType),
-			IsNullable:    isNullable,
-			ColumnDefault: colDefault,
+			ColumnName:      colName,
+			DataType:        ParseDataType(dataType),
+			IsNullable:      isNullable,
+			ColumnDefault:   colDefault,
+			IsAutoIncrement: identityStr == "Y",
+			// 'A' = GENERATED ALWAYS (identity or expression), 'D' = GENERATED BY DEFAULT
+			IsGenerated: generatedStr == "A",
+			Comment:     comment,
 		})
 	}
 	return columns, nil
 }
 
-func (d *DB2DB) getPrimaryKeyColumns(tableName, schemaName string) ([]string, error) {
-	rows, err := d.db.Query(`
+func (d *DB2DB) getPrimaryKeyColumns(ctx context.Context, tableName, schemaName string) ([]string, error) {
+	rows, err := d.db.QueryContext(ctx, `
 		SELECT COLNAME
 		FROM SYSCAT.KEYCOLUSE
 		WHERE TABSCHEMA = ? AND TABNAME = ? AND CONSTNAME IN (
@@ -163,10 +201,10 @@ func (d *DB2DB) getPrimaryKeyColumns(tableName, schemaName string) ([]string, er
 	return pks, nil
 }
 
-func (d *DB2DB) getUniqueKeyColumns(tableName, schemaName string) ([][]string, error) {
+func (d *DB2DB) getUniqueKeyColumns(ctx context.Context, tableName, schemaName string) ([][]string, error) {
 	// DB2 unique key information is a bit more complex to retrieve than PostgreSQL.
 	// This query attempts to get unique constraints that are not primary keys.
-	rows, err := d.db.Query(`
+	rows, err := d.db.QueryContext(ctx, `
 		SELECT LISTAGG(kcu.COLNAME, ',') WITHIN GROUP (ORDER BY kcu.COLSEQ) AS UNIQUE_COLUMNS
 		FROM SYSCAT.KEYCOLUSE kcu
 		JOIN SYSCAT.TABCONST tc ON kcu.CONSTNAME = tc.CONSTNAME AND kcu.TABSCHEMA = tc.TABSCHEMA AND kcu.TABNAME = tc.TABNAME
@@ -189,8 +227,8 @@ func (d *DB2DB) getUniqueKeyColumns(tableName, schemaName string) ([][]string, e
 	return uks, nil
 }
 
-func (d *DB2DB) getForeignKeyInfo(tableName, schemaName string) ([]ForeignKeyInfo, error) {
-	rows, err := d.db.Query(`
+func (d *DB2DB) getForeignKeyInfo(ctx context.Context, mainSchema, tableName, schemaName string) ([]ForeignKeyInfo, error) {
+	rows, err := d.db.QueryContext(ctx, `
 		SELECT
 			rc.CONSTNAME AS CONSTRAINT_NAME,
 			kcu.COLNAME AS COLUMN_NAME,
@@ -201,6 +239,7 @@ func (d *DB2DB) getForeignKeyInfo(tableName, schemaName string) ([]ForeignKeyInf
 		JOIN SYSCAT.KEYCOLUSE kcu ON rc.CONSTNAME = kcu.CONSTNAME AND rc.TABSCHEMA = kcu.TABSCHEMA AND rc.TABNAME = kcu.TABNAME
 		JOIN SYSCAT.KEYCOLUSE kcu_ref ON rc.REFKEYNAME = kcu_ref.CONSTNAME AND rc.REFTABSCHEMA = kcu_ref.TABSCHEMA AND rc.REFTABNAME = kcu_ref.TABNAME AND kcu.COLSEQ = kcu_ref.COLSEQ
 		WHERE rc.TABSCHEMA = ? AND rc.TABNAME = ?
+		ORDER BY rc.CONSTNAME, kcu.COLSEQ
 	`, strings.ToUpper(schemaName), strings.ToUpper(tableName))
 	if err != nil {
 		return nil, fmt.Errorf("query failed: %w", err)
@@ -209,35 +248,42 @@ func (d *DB2DB) getForeignKeyInfo(tableName, schemaName string) ([]ForeignKeyInf
 
 	var fks []ForeignKeyInfo
 	for rows.Next() {
-		var fk ForeignKeyInfo
-		fk.TableName = tableName      // Set the current table name
-		var foreignTableSchema string // Not directly used in ForeignKeyInfo, but needed for scan
-		if err := rows.Scan(&fk.ConstraintName, &fk.ColumnName, &foreignTableSchema, &fk.ForeignTableName, &fk.ForeignColumnName); err != nil {
+		var constraintName, columnName, foreignTableSchema, foreignTableName, foreignColumnName string
+		if err := rows.Scan(&constraintName, &columnName, &foreignTableSchema, &foreignTableName, &foreignColumnName); err != nil {
 			return nil, fmt.Errorf("scan failed: %w", err)
 		}
-		fks = append(fks, fk)
+		fks = appendForeignKeyColumn(fks, qualifiedTableName(mainSchema, strings.ToUpper(schemaName), tableName), constraintName, columnName,
+			qualifiedTableName(mainSchema, foreignTableSchema, foreignTableName), foreignColumnName)
 	}
 	return fks, nil
 }
 
 // PrepareInsertStatement prepares an UPSERT (MERGE) statement for DB2.
-func (d *DB2DB) PrepareInsertStatement(dbInfo DBInfo) (*sql.Stmt, error) {
+func (d *DB2DB) PrepareInsertStatement(ctx context.Context, dbInfo DBInfo) (*sql.Stmt, error) {
 	var cols []string
 	var placeholders []string
-	for _, colInfo := range dbInfo.Columns {
+	for _, colInfo := range dbInfo.InsertColumns() {
 		cols = append(cols, colInfo.ColumnName)
 		placeholders = append(placeholders, "?") // DB2 uses '?' for placeholders
 	}
 
-	// If no primary keys are defined, we cannot perform an upsert.
+	// MERGE matches rows on the primary key, so it needs every key column in the source row
+	generatedKey := false
+	for _, colInfo := range dbInfo.Columns {
+		if colInfo.IsGenerated && slices.Contains(dbInfo.PrimaryKeyColumns, colInfo.ColumnName) {
+			generatedKey = true
+		}
+	}
+
+	// If no primary keys are defined (or they are generated), we cannot perform an upsert.
 	// In this case, we fall back to a simple INSERT.
-	if len(dbInfo.PrimaryKeyColumns) == 0 {
+	if len(dbInfo.PrimaryKeyColumns) == 0 || generatedKey {
 		query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
 			dbInfo.TableName,
 			strings.Join(cols, ", "),
 			strings.Join(placeholders, ", "),
 		)
-		stmt, err := d.db.Prepare(query)
+		stmt, err := d.db.PrepareContext(ctx, query)
 		if err != nil {
 			return nil, fmt.Errorf("failed to prepare INSERT statement (no primary keys): %w", err)
 		}
@@ -258,7 +304,7 @@ func (d *DB2DB) PrepareInsertStatement(dbInfo DBInfo) (*sql.Stmt, error) {
 		pkMap[pkCol] = true
 	}
 
-	for _, colInfo := range dbInfo.Columns {
+	for _, colInfo := range dbInfo.InsertColumns() {
 		insertCols = append(insertCols, colInfo.ColumnName)
 		insertValuesFromSource = append(insertValuesFromSource, fmt.Sprintf("S.%s", colInfo.ColumnName))
 		if !pkMap[colInfo.ColumnName] {
@@ -295,64 +341,192 @@ func (d *DB2DB) PrepareInsertStatement(dbInfo DBInfo) (*sql.Stmt, error) {
 
 	query := mergeQueryBuilder.String()
 
-	stmt, err := d.db.Prepare(query)
+	stmt, err := d.db.PrepareContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to prepare MERGE statement: %w", err)
 	}
 	return stmt, nil
 }
 
-// ParentRecordExists checks if a record exists in the given table for a specific column and value in DB2.
-func (d *DB2DB) ParentRecordExists(dbInfo DBInfo, columnName, value string) (bool, error) {
-	query := fmt.Sprintf("SELECT 1 FROM %s WHERE %s = ?", dbInfo.TableName, columnName)
+// ParentRecordExists checks if a record exists in the given table for specific column values in DB2.
+func (d *DB2DB) ParentRecordExists(ctx context.Context, dbInfo DBInfo, columnNames, values []string) (bool, error) {
+	conditions := make([]string, len(columnNames))
+	args := make([]interface{}, len(values))
+	for i, colName := range columnNames {
+		conditions[i] = fmt.Sprintf("%s = ?", colName)
+		args[i] = values[i]
+	}
+	query := fmt.Sprintf("SELECT 1 FROM %s WHERE %s", dbInfo.TableName, strings.Join(conditions, " AND "))
 	var exists int
-	err := d.db.QueryRow(query, value).Scan(&exists)
+	err := d.db.QueryRowContext(ctx, query, args...).Scan(&exists)
 	if err == sql.ErrNoRows {
 		return false, nil
 	}
 	if err != nil {
-		return false, fmt.Errorf("failed to check existence of record in %s for %s=%s: %w", dbInfo.TableName, columnName, value, err)
+		return false, fmt.Errorf("failed to check existence of record %s: %w", formatParentKey(dbInfo.TableName, columnNames, values), err)
 	}
 	return true, nil
 }
 
-// EnsureParentRecordExists checks if a record with the given foreignKeyValue exists in the parent table.
-// If not, it creates a new record in the parent table with default values and the provided foreignKeyValue
-// for the foreignColumnName. This implementation is specific to DB2.
-func (d *DB2DB) EnsureParentRecordExists(parentDBInfo DBInfo, foreignColumnName, foreignKeyValue string, dbSchema map[string]DBInfo) error {
-	// Check if the parent record already exists
-	exists, err := d.ParentRecordExists(parentDBInfo, foreignColumnName, foreignKeyValue)
-	if err != nil {
-		return fmt.Errorf("failed to check parent record existence: %w", err)
-	}
-	if exists {
-		return nil // Parent record already exists
+// UpdateRow sets columns of an existing row.
+func (d *DB2DB) UpdateRow(ctx context.Context, dbInfo DBInfo, keyColumns, keyValues, columnNames, values []string) error {
+	query := updateStatement(dbInfo.TableName, keyColumns, columnNames, func(int) string { return "?" })
+	if _, err := d.db.ExecContext(ctx, query, updateArgs(keyValues, values)...); err != nil {
+		return fmt.Errorf("failed to update record %s: %w", formatParentKey(dbInfo.TableName, keyColumns, keyValues), err)
 	}
+	return nil
+}
 
-	// Parent record does not exist, create it
-	log.Printf("Creating missing parent record in table '%s' for column '%s' with value '%s'\n", parentDBInfo.TableName, foreignColumnName, foreignKeyValue)
+// ClearColumns sets columns of the rows matching filter to NULL.
+func (d *DB2DB) ClearColumns(ctx context.Context, dbInfo DBInfo, columnNames []string, filter RowFilter) error {
+	return clearColumns(ctx, d.db, dbInfo.TableName, dbInfo, columnNames, filter, func(int) string { return "?" }, 0)
+}
 
-	parentCols, _, parentValues, err := ensureParentRecordExistsCommon(d, parentDBInfo, foreignColumnName, foreignKeyValue, dbSchema)
-	if err != nil {
-		return err
-	}
+// DeleteRows deletes the rows of a table matching filter.
+func (d *DB2DB) DeleteRows(ctx context.Context, dbInfo DBInfo, filter RowFilter) (int64, error) {
+	return deleteRows(ctx, d.db, dbInfo.TableName, dbInfo, filter, func(int) string { return "?" }, 0)
+}
 
-	// Generate DB2-specific placeholders
-	parentPlaceholders := make([]string, len(parentCols))
-	for i := range parentCols {
-		parentPlaceholders[i] = "?"
-	}
+// BeginRowDeletion starts a transaction clearing and deleting rows like ClearColumns and DeleteRows.
+func (d *DB2DB) BeginRowDeletion(ctx context.Context) (RowDeletion, error) {
+	return beginRowDeletion(ctx, d.db, func(dbInfo DBInfo) string { return dbInfo.TableName }, func(int) string { return "?" })
+}
 
-	insertQuery := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
-		parentDBInfo.TableName,
-		strings.Join(parentCols, ", "),
-		strings.Join(parentPlaceholders, ", "),
-	)
+// EnsureParentRecordExists checks if a record with the given foreignKeyValues exists in the parent table.
+// If not, it creates a new record in the parent table with default values and the provided foreignKeyValues
+// for the foreignColumnNames. This implementation is specific to DB2.
+func (d *DB2DB) EnsureParentRecordExists(ctx context.Context, parentDBInfo DBInfo, foreignColumnNames, foreignKeyValues []string, dbSchema map[string]DBInfo) ([]string, error) {
+	return ensureParentRecord(ctx, d, &d.parentRecordSettings, parentDBInfo, foreignColumnNames, foreignKeyValues, dbSchema, func(parentCols []string, parentValues []interface{}, returning string) (interface{}, bool, error) {
+		// Generate DB2-specific placeholders
+		parentPlaceholders := make([]string, len(parentCols))
+		for i := range parentCols {
+			parentPlaceholders[i] = "?"
+		}
 
-	_, err = d.db.Exec(insertQuery, parentValues...)
-	if err != nil {
-		return fmt.Errorf("failed to insert parent record into %s: %w", parentDBInfo.TableName, err)
+		insertQuery := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+			parentDBInfo.TableName,
+			strings.Join(parentCols, ", "),
+			strings.Join(parentPlaceholders, ", "),
+		)
+		if len(parentCols) == 0 {
+			insertQuery = fmt.Sprintf("INSERT INTO %s VALUES (DEFAULT)", parentDBInfo.TableName)
+		}
+
+		var key interface{}
+		var err error
+		if returning != "" {
+			// Read the assigned key from the inserted row
+			err = d.db.QueryRowContext(ctx, fmt.Sprintf("SELECT %s FROM FINAL TABLE (%s)", returning, insertQuery), parentValues...).Scan(&key)
+		} else {
+			_, err = d.db.ExecContext(ctx, insertQuery, parentValues...)
+		}
+		if err != nil {
+			if isDB2UniqueViolation(err) {
+				return nil, false, nil
+			}
+			return nil, false, err
+		}
+		return key, true, nil
+	})
+}
+
+// isDB2UniqueViolation reports whether err is a duplicate key error (SQL0803N, SQLSTATE 23505).
+func isDB2UniqueViolation(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "SQL0803N") || strings.Contains(msg, "SQLSTATE=23505")
+}
+
+// ResetSequences restarts each identity column after the column's largest value.
+func (d *DB2DB) ResetSequences(ctx context.Context, dbInfo DBInfo) error {
+	for _, colInfo := range dbInfo.Columns {
+		if !colInfo.IsAutoIncrement {
+			continue
+		}
+		var maxValue sql.NullInt64
+		if err := d.db.QueryRowContext(ctx, fmt.Sprintf("SELECT MAX(%s) FROM %s", colInfo.ColumnName, dbInfo.TableName)).Scan(&maxValue); err != nil {
+			return fmt.Errorf("failed to read the largest value of %s.%s: %w", dbInfo.TableName, colInfo.ColumnName, err)
+		}
+		if !maxValue.Valid {
+			continue
+		}
+		if _, err := d.db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s RESTART WITH %d", dbInfo.TableName, colInfo.ColumnName, maxValue.Int64+1)); err != nil {
+			return fmt.Errorf("failed to restart identity of %s.%s: %w", dbInfo.TableName, colInfo.ColumnName, err)
+		}
 	}
+	return nil
+}
 
+// ReadRows reads the rows of a table matching filter.
+func (d *DB2DB) ReadRows(ctx context.Context, dbInfo DBInfo, columns []ColumnInfo, filter RowFilter, fn func(values []string) error) error {
+	return readFilteredRows(ctx, d.db, dbInfo.TableName, dbInfo, columns, filter, func(int) string { return "?" }, fn)
+}
+
+// CheckTableAccess verifies the privileges of the connection on a table.
+func (d *DB2DB) CheckTableAccess(ctx context.Context, dbInfo DBInfo, privileges []string) error {
+	return checkTableAccess(ctx, d.db, dbInfo.TableName, dbInfo, privileges)
+}
+
+// RecordImportRun adds run to the _IMPORT_RUNS table of the schema. DB2 has no CREATE TABLE IF NOT
+// EXISTS, so the catalog is checked first. The name must be quoted since it starts with an underscore.
+func (d *DB2DB) RecordImportRun(ctx context.Context, run ImportRun) error {
+	tableName := strings.ToUpper(ImportRunsTable)
+	table := `"` + tableName + `"`
+	if d.schemaName != "" {
+		table = `"` + d.schemaName + `".` + table
+	}
+	var count int
+	if err := d.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM SYSCAT.TABLES WHERE TABSCHEMA = COALESCE(NULLIF(?, ''), CURRENT SCHEMA) AND TABNAME = ?", d.schemaName, tableName).Scan(&count); err != nil {
+		return fmt.Errorf("failed to look up %s: %w", ImportRunsTable, err)
+	}
+	if count == 0 {
+		if _, err := d.db.ExecContext(ctx, createImportRunsStatement("CREATE TABLE", table, "CLOB(1M)", "TIMESTAMP")); err != nil {
+			return fmt.Errorf("failed to create %s: %w", ImportRunsTable, err)
+		}
+	}
+	query := insertImportRunStatement(table, func(int) string { return "?" })
+	if _, err := d.db.ExecContext(ctx, query, importRunArgs(run)...); err != nil {
+		return fmt.Errorf("failed to record import run %s: %w", run.RunID, err)
+	}
 	return nil
 }
+
+// RefreshMaterializedViews refreshes the materialized query tables defined on the given tables.
+func (d *DB2DB) RefreshMaterializedViews(ctx context.Context, tableNames []string) ([]string, error) {
+	var views []string
+	for _, tableName := range tableNames {
+		tableSchema := d.schemaName
+		if s, t, ok := strings.Cut(tableName, "."); ok {
+			tableSchema, tableName = s, t
+		}
+		rows, err := d.db.QueryContext(ctx, `
+			SELECT TABSCHEMA, TABNAME
+			FROM SYSCAT.TABDEP
+			WHERE DTYPE = 'S' AND BSCHEMA = ? AND BNAME = ?
+		`, strings.ToUpper(tableSchema), strings.ToUpper(tableName))
+		if err != nil {
+			return nil, fmt.Errorf("failed to find materialized query tables: %w", err)
+		}
+		for rows.Next() {
+			var viewSchema, viewName string
+			if err := rows.Scan(&viewSchema, &viewName); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("scan failed: %w", err)
+			}
+			if view := strings.TrimSpace(viewSchema) + "." + viewName; !slices.Contains(views, view) {
+				views = append(views, view)
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to find materialized query tables: %w", err)
+		}
+	}
+	sort.Strings(views)
+
+	for idx, view := range views {
+		if _, err := d.db.ExecContext(ctx, "REFRESH TABLE "+view); err != nil {
+			return views[:idx], fmt.Errorf("failed to refresh materialized query table %s: %w", view, err)
+		}
+	}
+	return views, nil
+}
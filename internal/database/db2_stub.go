@@ -4,29 +4,35 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 )
 
 // NewDB2Client returns an error indicating that DB2 support is not compiled.
-func NewDB2Client(connStr string) (DBClient, error) {
+func NewDB2Client(ctx context.Context, connStr string, options ConnOptions) (DBClient, error) {
+	return nil, fmt.Errorf("DB2 support not compiled. Build with -tags ibm_db to enable")
+}
+
+// newDB2Dialect returns an error indicating that DB2 support is not compiled.
+func newDB2Dialect(db *sql.DB, schemaName string) (sqlDialect, error) {
 	return nil, fmt.Errorf("DB2 support not compiled. Build with -tags ibm_db to enable")
 }
 
 // These are stub implementations to satisfy the DBClient interface when ibm_db is not built.
 type stubDB2Client struct{}
 
-func (s *stubDB2Client) GetSchemaInfo(schemaName string) (map[string]DBInfo, error) {
+func (s *stubDB2Client) GetSchemaInfo(ctx context.Context, schemaName string) (map[string]DBInfo, error) {
 	return nil, fmt.Errorf("DB2 support not compiled")
 }
-func (s *stubDB2Client) PrepareInsertStatement(dbInfo DBInfo) (*sql.Stmt, error) {
+func (s *stubDB2Client) PrepareInsertStatement(ctx context.Context, dbInfo DBInfo) (*sql.Stmt, error) {
 	return nil, fmt.Errorf("DB2 support not compiled")
 }
-func (s *stubDB2Client) ParentRecordExists(dbInfo DBInfo, columnName, value string) (bool, error) {
+func (s *stubDB2Client) ParentRecordExists(ctx context.Context, dbInfo DBInfo, columnNames, values []string) (bool, error) {
 	return false, fmt.Errorf("DB2 support not compiled")
 }
-func (s *stubDB2Client) EnsureParentRecordExists(parentDBInfo DBInfo, foreignColumnName, foreignKeyValue string, dbSchema map[string]DBInfo) error {
-	return fmt.Errorf("DB2 support not compiled")
+func (s *stubDB2Client) EnsureParentRecordExists(ctx context.Context, parentDBInfo DBInfo, foreignColumnNames, foreignKeyValues []string, dbSchema map[string]DBInfo) ([]string, error) {
+	return nil, fmt.Errorf("DB2 support not compiled")
 }
 func (s *stubDB2Client) GetDB() *sql.DB {
 	return nil
@@ -0,0 +1,184 @@
+package database
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ChangeKind tells whether a schema object was added, removed or modified.
+type ChangeKind string
+
+const (
+	ChangeAdded    ChangeKind = "+"
+	ChangeRemoved  ChangeKind = "-"
+	ChangeModified ChangeKind = "~"
+)
+
+// SchemaChange is one difference found by DiffSchemas.
+type SchemaChange struct {
+	Kind  ChangeKind
+	Table string
+	// Object names what changed within the table, e.g. "column email" or "foreign key fk_user".
+	// It is "table" when the whole table was added or removed.
+	Object string
+	// From and To describe the object before and after; From is empty for an added object and
+	// To for a removed one.
+	From, To string
+}
+
+func (c SchemaChange) String() string {
+	switch c.Kind {
+	case ChangeAdded:
+		return strings.TrimSpace(fmt.Sprintf("+ %s: %s %s", c.Table, c.Object, c.To))
+	case ChangeRemoved:
+		return strings.TrimSpace(fmt.Sprintf("- %s: %s %s", c.Table, c.Object, c.From))
+	default:
+		return fmt.Sprintf("~ %s: %s %s -> %s", c.Table, c.Object, c.From, c.To)
+	}
+}
+
+// DiffSchemas compares schema against baseline and returns the tables, columns, keys and foreign
+// keys that were added, removed or changed, ordered by table name.
+func DiffSchemas(baseline, schema map[string]DBInfo) []SchemaChange {
+	tableNames := make([]string, 0, len(baseline)+len(schema))
+	for tableName := range baseline {
+		tableNames = append(tableNames, tableName)
+	}
+	for tableName := range schema {
+		if _, ok := baseline[tableName]; !ok {
+			tableNames = append(tableNames, tableName)
+		}
+	}
+	sort.Strings(tableNames)
+
+	var changes []SchemaChange
+	for _, tableName := range tableNames {
+		before, inBaseline := baseline[tableName]
+		after, inSchema := schema[tableName]
+		switch {
+		case !inBaseline:
+			changes = append(changes, SchemaChange{Kind: ChangeAdded, Table: tableName, Object: "table"})
+		case !inSchema:
+			changes = append(changes, SchemaChange{Kind: ChangeRemoved, Table: tableName, Object: "table"})
+		default:
+			changes = append(changes, diffTable(tableName, before, after)...)
+		}
+	}
+	return changes
+}
+
+// diffTable compares two versions of the same table.
+func diffTable(tableName string, before, after DBInfo) []SchemaChange {
+	var changes []SchemaChange
+	change := func(kind ChangeKind, object, from, to string) {
+		changes = append(changes, SchemaChange{Kind: kind, Table: tableName, Object: object, From: from, To: to})
+	}
+
+	for _, colInfo := range after.Columns {
+		old, ok := findColumn(before, colInfo.ColumnName)
+		switch {
+		case !ok:
+			change(ChangeAdded, "column "+colInfo.ColumnName, "", describeColumn(colInfo))
+		case describeColumn(old) != describeColumn(colInfo):
+			change(ChangeModified, "column "+colInfo.ColumnName, describeColumn(old), describeColumn(colInfo))
+		}
+	}
+	for _, colInfo := range before.Columns {
+		if _, ok := findColumn(after, colInfo.ColumnName); !ok {
+			change(ChangeRemoved, "column "+colInfo.ColumnName, describeColumn(colInfo), "")
+		}
+	}
+
+	if !slices.Equal(before.PrimaryKeyColumns, after.PrimaryKeyColumns) {
+		switch {
+		case len(before.PrimaryKeyColumns) == 0:
+			change(ChangeAdded, "primary key", "", describeKey(after.PrimaryKeyColumns))
+		case len(after.PrimaryKeyColumns) == 0:
+			change(ChangeRemoved, "primary key", describeKey(before.PrimaryKeyColumns), "")
+		default:
+			change(ChangeModified, "primary key", describeKey(before.PrimaryKeyColumns), describeKey(after.PrimaryKeyColumns))
+		}
+	}
+
+	// Unique keys have no stable name across databases, so they are matched by their columns
+	hasKey := func(keys [][]string, key []string) bool {
+		return slices.ContainsFunc(keys, func(k []string) bool { return slices.Equal(k, key) })
+	}
+	for _, ukCols := range after.UniqueKeyColumns {
+		if !hasKey(before.UniqueKeyColumns, ukCols) {
+			change(ChangeAdded, "unique key", "", describeKey(ukCols))
+		}
+	}
+	for _, ukCols := range before.UniqueKeyColumns {
+		if !hasKey(after.UniqueKeyColumns, ukCols) {
+			change(ChangeRemoved, "unique key", describeKey(ukCols), "")
+		}
+	}
+
+	for _, fk := range after.ForeignKeys {
+		old, ok := findForeignKey(before, fk.ConstraintName)
+		switch {
+		case !ok:
+			change(ChangeAdded, "foreign key "+fk.ConstraintName, "", describeForeignKey(fk))
+		case describeForeignKey(old) != describeForeignKey(fk):
+			change(ChangeModified, "foreign key "+fk.ConstraintName, describeForeignKey(old), describeForeignKey(fk))
+		}
+	}
+	for _, fk := range before.ForeignKeys {
+		if _, ok := findForeignKey(after, fk.ConstraintName); !ok {
+			change(ChangeRemoved, "foreign key "+fk.ConstraintName, describeForeignKey(fk), "")
+		}
+	}
+
+	if !slices.Equal(before.InheritsFrom, after.InheritsFrom) {
+		change(ChangeModified, "inherits", describeKey(before.InheritsFrom), describeKey(after.InheritsFrom))
+	}
+	return changes
+}
+
+func findColumn(dbInfo DBInfo, columnName string) (ColumnInfo, bool) {
+	for _, colInfo := range dbInfo.Columns {
+		if colInfo.ColumnName == columnName {
+			return colInfo, true
+		}
+	}
+	return ColumnInfo{}, false
+}
+
+func findForeignKey(dbInfo DBInfo, constraintName string) (ForeignKeyInfo, bool) {
+	for _, fk := range dbInfo.ForeignKeys {
+		if fk.ConstraintName == constraintName {
+			return fk, true
+		}
+	}
+	return ForeignKeyInfo{}, false
+}
+
+// describeColumn formats the properties of a column that DiffSchemas compares,
+// e.g. "INTEGER NOT NULL AUTO INCREMENT".
+func describeColumn(colInfo ColumnInfo) string {
+	attrs := []string{colInfo.DataType.String()}
+	if !colInfo.IsNullable {
+		attrs = append(attrs, "NOT NULL")
+	}
+	if colInfo.ColumnDefault.Valid {
+		attrs = append(attrs, "DEFAULT "+colInfo.ColumnDefault.String)
+	}
+	if colInfo.IsAutoIncrement {
+		attrs = append(attrs, "AUTO INCREMENT")
+	}
+	if colInfo.IsGenerated {
+		attrs = append(attrs, "GENERATED")
+	}
+	return strings.Join(attrs, " ")
+}
+
+func describeKey(columnNames []string) string {
+	return "(" + strings.Join(columnNames, ", ") + ")"
+}
+
+func describeForeignKey(fk ForeignKeyInfo) string {
+	return fmt.Sprintf("%s REFERENCES %s %s", describeKey(fk.ColumnNames), fk.ForeignTableName, describeKey(fk.ForeignColumnNames))
+}
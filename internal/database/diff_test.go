@@ -0,0 +1,72 @@
+package database
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DiffSchemas(t *testing.T) {
+	baseline := map[string]DBInfo{
+		"users": {
+			TableName: "users",
+			Columns: []ColumnInfo{
+				{ColumnName: "id", DataType: IntegerType, IsAutoIncrement: true},
+				{ColumnName: "age", DataType: IntegerType, IsNullable: true},
+				{ColumnName: "nickname", DataType: StringType, IsNullable: true},
+			},
+			PrimaryKeyColumns: []string{"id"},
+			UniqueKeyColumns:  [][]string{{"nickname"}},
+		},
+		"legacy": {TableName: "legacy"},
+		"posts": {
+			TableName: "posts",
+			Columns:   []ColumnInfo{{ColumnName: "user_id", DataType: IntegerType}},
+			ForeignKeys: []ForeignKeyInfo{
+				{ConstraintName: "fk_user", TableName: "posts", ColumnNames: []string{"user_id"}, ForeignTableName: "users", ForeignColumnNames: []string{"id"}},
+			},
+		},
+	}
+	schema := map[string]DBInfo{
+		"users": {
+			TableName: "users",
+			Columns: []ColumnInfo{
+				{ColumnName: "id", DataType: IntegerType, IsAutoIncrement: true},
+				{ColumnName: "age", DataType: FloatType, ColumnDefault: sql.NullString{String: "0", Valid: true}},
+				{ColumnName: "email", DataType: StringType},
+			},
+			PrimaryKeyColumns: []string{"id"},
+			UniqueKeyColumns:  [][]string{{"email"}},
+		},
+		"posts": {
+			TableName: "posts",
+			Columns:   []ColumnInfo{{ColumnName: "user_id", DataType: IntegerType}},
+			ForeignKeys: []ForeignKeyInfo{
+				{ConstraintName: "fk_user", TableName: "posts", ColumnNames: []string{"user_id"}, ForeignTableName: "members", ForeignColumnNames: []string{"id"}},
+			},
+		},
+		"tags": {TableName: "tags"},
+	}
+
+	t.Run("追加・削除・変更が表示用の文字列で返されること", func(t *testing.T) {
+		var lines []string
+		for _, change := range DiffSchemas(baseline, schema) {
+			lines = append(lines, change.String())
+		}
+		assert.Equal(t, []string{
+			"- legacy: table",
+			"~ posts: foreign key fk_user (user_id) REFERENCES users (id) -> (user_id) REFERENCES members (id)",
+			"+ tags: table",
+			"~ users: column age INTEGER -> FLOAT NOT NULL DEFAULT 0",
+			"+ users: column email STRING NOT NULL",
+			"- users: column nickname STRING",
+			"+ users: unique key (email)",
+			"- users: unique key (nickname)",
+		}, lines)
+	})
+
+	t.Run("同じスキーマでは差分がないこと", func(t *testing.T) {
+		assert.Empty(t, DiffSchemas(schema, schema))
+	})
+}
@@ -1,30 +1,329 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"sort"
+	"sync"
 )
 
-// DBClient defines the interface for database operations.
+// DBClient defines the interface for database operations: what an import needs. The other
+// operations are optional capabilities a client implements as ParentConfigurer, RowUpdater,
+// RowDeleter, SequenceResetter, ViewRefresher, RowReader, AccessChecker and RunRecorder, checked
+// for with a type assertion like BatchExecutor and ModeInserter.
 type DBClient interface {
-	GetSchemaInfo(schemaName string) (map[string]DBInfo, error)
-	PrepareInsertStatement(dbInfo DBInfo) (*sql.Stmt, error)
-	ParentRecordExists(dbInfo DBInfo, columnName, value string) (bool, error)
-	EnsureParentRecordExists(parentDBInfo DBInfo, foreignColumnName, foreignKeyValue string, dbSchema map[string]DBInfo) error
+	GetSchemaInfo(ctx context.Context, schemaName string) (map[string]DBInfo, error)
+	PrepareInsertStatement(ctx context.Context, dbInfo DBInfo) (*sql.Stmt, error)
+	ParentRecordExists(ctx context.Context, dbInfo DBInfo, columnNames, values []string) (bool, error)
+	// EnsureParentRecordExists creates the referenced parent record if it is missing and returns the
+	// key children must reference, which differs from foreignKeyValues when the database assigns it.
+	EnsureParentRecordExists(ctx context.Context, parentDBInfo DBInfo, foreignColumnNames, foreignKeyValues []string, dbSchema map[string]DBInfo) ([]string, error)
 	GetDB() *sql.DB
 	Close() error
 }
 
-// NewDBClient creates a new DBClient based on the database type.
-func NewDBClient(dbType, connStr string) (DBClient, error) {
-	switch dbType {
-	case "postgres":
-		return NewPostgresDB(connStr)
-	case "db2":
-		return NewDB2Client(connStr)
-	case "mysql":
-		return NewMySQLDB(connStr)
-	default:
+// ParentConfigurer is implemented by the DBClients whose auto-created parent records can be
+// configured and counted. Without it, parents are created as the client sees fit, and the import
+// results do not count them.
+type ParentConfigurer interface {
+	SetValueGenerator(gen ValueGenerator)
+	SetParentTemplates(templates map[string]map[string]string)
+	// SetBatchColumn sets a column to value in every auto-created parent record that has it.
+	SetBatchColumn(columnName, value string)
+	// SetParentCreatedFunc sets a function called for each auto-created parent record.
+	SetParentCreatedFunc(fn func(tableName string, columnNames, key []string))
+	// CreatedParents returns the number of auto-created parent records per table.
+	CreatedParents() map[string]int
+}
+
+// RowUpdater is implemented by the DBClients that can update imported rows, which foreign keys
+// deferred to break a dependency cycle need.
+type RowUpdater interface {
+	// UpdateRow sets columnNames to values (in CSV string form) in the row identified by keyValues
+	// of keyColumns. It is used to fill in foreign keys deferred to break a dependency cycle.
+	UpdateRow(ctx context.Context, dbInfo DBInfo, keyColumns, keyValues, columnNames, values []string) error
+}
+
+// RowDeleter is implemented by the DBClients that can delete rows, for truncate and rollback.
+type RowDeleter interface {
+	// ClearColumns sets columnNames to NULL in the rows of the table of dbInfo matching filter, e.g.
+	// to break a cycle of foreign keys before the rows on it are deleted.
+	ClearColumns(ctx context.Context, dbInfo DBInfo, columnNames []string, filter RowFilter) error
+	// DeleteRows deletes the rows of the table of dbInfo matching filter and returns their number.
+	DeleteRows(ctx context.Context, dbInfo DBInfo, filter RowFilter) (int64, error)
+}
+
+// TxRowDeleter is implemented by the RowDeleters that can clear and delete rows in one transaction,
+// so that a deletion failing part way leaves every row in place.
+type TxRowDeleter interface {
+	// BeginRowDeletion starts a transaction whose ClearColumns and DeleteRows take effect on Commit.
+	// It returns an error matching errors.ErrUnsupported if the database cannot run them in one.
+	BeginRowDeletion(ctx context.Context) (RowDeletion, error)
+}
+
+// RowDeletion is a transaction begun by a TxRowDeleter.
+type RowDeletion interface {
+	RowDeleter
+	Commit() error
+	Rollback() error
+}
+
+// SequenceResetter is implemented by the DBClients of databases with sequences or identity
+// counters. Others have nothing to reset.
+type SequenceResetter interface {
+	// ResetSequences moves the sequences or identity counters behind the auto-increment columns of
+	// dbInfo past the largest value in the table, so that later inserts relying on them do not
+	// collide with explicitly imported keys.
+	ResetSequences(ctx context.Context, dbInfo DBInfo) error
+}
+
+// ViewRefresher is implemented by the DBClients of databases with materialized views. Others have
+// nothing to refresh.
+type ViewRefresher interface {
+	// RefreshMaterializedViews refreshes the materialized views that select from any of the given
+	// tables and returns their names.
+	RefreshMaterializedViews(ctx context.Context, tableNames []string) ([]string, error)
+}
+
+// RowReader is implemented by the DBClients that can read rows back, for export, diff and
+// imports from another database.
+type RowReader interface {
+	// ReadRows calls fn with each row of the table of dbInfo matching filter, ordered by the
+	// primary key, giving the values of columns in CSV string form (see FormatColumnValue).
+	ReadRows(ctx context.Context, dbInfo DBInfo, columns []ColumnInfo, filter RowFilter, fn func(values []string) error) error
+}
+
+// AccessChecker is implemented by the DBClients that can check their privileges, for doctor.
+type AccessChecker interface {
+	// CheckTableAccess verifies that the connection has each of privileges (PrivilegeSelect,
+	// PrivilegeInsert or PrivilegeUpdate) on the table of dbInfo, without changing any row. A missing
+	// privilege is reported as a *TableAccessError.
+	CheckTableAccess(ctx context.Context, dbInfo DBInfo, privileges []string) error
+}
+
+// RunRecorder is implemented by the DBClients that can record import runs.
+type RunRecorder interface {
+	// RecordImportRun adds run to ImportRunsTable, creating the table if it does not exist.
+	RecordImportRun(ctx context.Context, run ImportRun) error
+}
+
+var (
+	_ ParentConfigurer = (*PostgresDB)(nil)
+	_ ParentConfigurer = (*MySQLDB)(nil)
+	_ ParentConfigurer = (*H2DB)(nil)
+	_ ParentConfigurer = (*HANADB)(nil)
+	_ ParentConfigurer = (*TiDBDB)(nil)
+	_ ParentConfigurer = (*SingleStoreDB)(nil)
+	_ ParentConfigurer = (*SpannerDB)(nil)
+	_ ParentConfigurer = (*YugabyteDB)(nil)
+	_ ParentConfigurer = (*TrinoDB)(nil)
+	_ ParentConfigurer = (*VerticaDB)(nil)
+	_ ParentConfigurer = (*SQLFileDB)(nil)
+	_ ParentConfigurer = (*ReadSplitDB)(nil)
+	_ RowUpdater       = (*PostgresDB)(nil)
+	_ RowUpdater       = (*MySQLDB)(nil)
+	_ RowUpdater       = (*H2DB)(nil)
+	_ RowUpdater       = (*HANADB)(nil)
+	_ RowUpdater       = (*SpannerDB)(nil)
+	_ RowUpdater       = (*VerticaDB)(nil)
+	_ RowUpdater       = (*SQLFileDB)(nil)
+	_ RowUpdater       = (*ReadSplitDB)(nil)
+	_ RowDeleter       = (*PostgresDB)(nil)
+	_ RowDeleter       = (*MySQLDB)(nil)
+	_ RowDeleter       = (*TiDBDB)(nil)
+	_ RowDeleter       = (*H2DB)(nil)
+	_ RowDeleter       = (*HANADB)(nil)
+	_ RowDeleter       = (*SpannerDB)(nil)
+	_ RowDeleter       = (*VerticaDB)(nil)
+	_ RowDeleter       = (*SQLFileDB)(nil)
+	_ RowDeleter       = (*ReadSplitDB)(nil)
+	_ TxRowDeleter     = (*PostgresDB)(nil)
+	_ TxRowDeleter     = (*MySQLDB)(nil)
+	_ TxRowDeleter     = (*H2DB)(nil)
+	_ TxRowDeleter     = (*HANADB)(nil)
+	_ TxRowDeleter     = (*VerticaDB)(nil)
+	_ TxRowDeleter     = (*ReadSplitDB)(nil)
+	_ SequenceResetter = (*PostgresDB)(nil)
+	_ SequenceResetter = (*MySQLDB)(nil)
+	_ SequenceResetter = (*H2DB)(nil)
+	_ SequenceResetter = (*HANADB)(nil)
+	_ SequenceResetter = (*VerticaDB)(nil)
+	_ SequenceResetter = (*SQLFileDB)(nil)
+	_ SequenceResetter = (*ReadSplitDB)(nil)
+	_ ViewRefresher    = (*PostgresDB)(nil)
+	_ ViewRefresher    = (*MySQLDB)(nil)
+	_ ViewRefresher    = (*H2DB)(nil)
+	_ ViewRefresher    = (*HANADB)(nil)
+	_ ViewRefresher    = (*VerticaDB)(nil)
+	_ ViewRefresher    = (*SQLFileDB)(nil)
+	_ ViewRefresher    = (*ReadSplitDB)(nil)
+	_ RowReader        = (*PostgresDB)(nil)
+	_ RowReader        = (*MySQLDB)(nil)
+	_ RowReader        = (*H2DB)(nil)
+	_ RowReader        = (*HANADB)(nil)
+	_ RowReader        = (*SpannerDB)(nil)
+	_ RowReader        = (*TrinoDB)(nil)
+	_ RowReader        = (*VerticaDB)(nil)
+	_ RowReader        = (*SQLFileDB)(nil)
+	_ RowReader        = (*ReadSplitDB)(nil)
+	_ AccessChecker    = (*PostgresDB)(nil)
+	_ AccessChecker    = (*MySQLDB)(nil)
+	_ AccessChecker    = (*HANADB)(nil)
+	_ AccessChecker    = (*SpannerDB)(nil)
+	_ AccessChecker    = (*TrinoDB)(nil)
+	_ AccessChecker    = (*VerticaDB)(nil)
+	_ AccessChecker    = (*SQLFileDB)(nil)
+	_ AccessChecker    = (*ReadSplitDB)(nil)
+	_ RunRecorder      = (*PostgresDB)(nil)
+	_ RunRecorder      = (*MySQLDB)(nil)
+	_ RunRecorder      = (*H2DB)(nil)
+	_ RunRecorder      = (*HANADB)(nil)
+	_ RunRecorder      = (*TrinoDB)(nil)
+	_ RunRecorder      = (*VerticaDB)(nil)
+	_ RunRecorder      = (*SQLFileDB)(nil)
+	_ RunRecorder      = (*ReadSplitDB)(nil)
+)
+
+// ConnOptions configure how a client connects to its database. Each client keeps its own, so that
+// clients with different options can be used at the same time.
+type ConnOptions struct {
+	// TLS configures the TLS connections to the database.
+	TLS TLSOptions
+	// AWSIAMAuth makes the PostgreSQL and MySQL clients authenticate to Amazon RDS or Aurora with
+	// an IAM authentication token instead of a password. A token is generated for each new
+	// connection, since a token is only accepted for 15 minutes. The AWS credentials are read from
+	// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN, and the region from
+	// AWS_REGION, AWS_DEFAULT_REGION or else the host name of the RDS endpoint.
+	AWSIAMAuth bool
+	// SQLLog selects whether the client logs its SQL statements, e.g. to diagnose the SQL generated
+	// for a dialect. Statements are logged after they ran, with their duration and any error.
+	SQLLog SQLLogMode
+}
+
+// Factory connects to a database and returns a DBClient for it. ctx bounds the initial connection.
+type Factory func(ctx context.Context, connStr string, options ConnOptions) (DBClient, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = map[string]Factory{
+		"postgres": func(ctx context.Context, connStr string, options ConnOptions) (DBClient, error) {
+			client, err := NewPostgresDB(ctx, connStr, options)
+			if err != nil {
+				return nil, err
+			}
+			return client, nil
+		},
+		"mysql": func(ctx context.Context, connStr string, options ConnOptions) (DBClient, error) {
+			client, err := NewMySQLDB(ctx, connStr, options)
+			if err != nil {
+				return nil, err
+			}
+			return client, nil
+		},
+		"db2": NewDB2Client,
+		"h2": func(ctx context.Context, connStr string, options ConnOptions) (DBClient, error) {
+			client, err := NewH2DB(ctx, connStr, options)
+			if err != nil {
+				return nil, err
+			}
+			return client, nil
+		},
+		"hana": func(ctx context.Context, connStr string, options ConnOptions) (DBClient, error) {
+			client, err := NewHANADB(ctx, connStr, options)
+			if err != nil {
+				return nil, err
+			}
+			return client, nil
+		},
+		"tidb": func(ctx context.Context, connStr string, options ConnOptions) (DBClient, error) {
+			client, err := NewTiDBDB(ctx, connStr, options)
+			if err != nil {
+				return nil, err
+			}
+			return client, nil
+		},
+		"singlestore": func(ctx context.Context, connStr string, options ConnOptions) (DBClient, error) {
+			client, err := NewSingleStoreDB(ctx, connStr, options)
+			if err != nil {
+				return nil, err
+			}
+			return client, nil
+		},
+		"spanner": func(ctx context.Context, connStr string, options ConnOptions) (DBClient, error) {
+			client, err := NewSpannerDB(ctx, connStr, options)
+			if err != nil {
+				return nil, err
+			}
+			return client, nil
+		},
+		"yugabyte": func(ctx context.Context, connStr string, options ConnOptions) (DBClient, error) {
+			client, err := NewYugabyteDB(ctx, connStr, options)
+			if err != nil {
+				return nil, err
+			}
+			return client, nil
+		},
+		"trino": func(ctx context.Context, connStr string, options ConnOptions) (DBClient, error) {
+			client, err := NewTrinoDB(ctx, connStr, options)
+			if err != nil {
+				return nil, err
+			}
+			return client, nil
+		},
+		"vertica": func(ctx context.Context, connStr string, options ConnOptions) (DBClient, error) {
+			client, err := NewVerticaDB(ctx, connStr, options)
+			if err != nil {
+				return nil, err
+			}
+			return client, nil
+		},
+		// Nothing is connected to, so there are no connection options to apply
+		"sqlfile": func(ctx context.Context, connStr string, _ ConnOptions) (DBClient, error) {
+			client, err := NewSQLFileDB(ctx, connStr)
+			if err != nil {
+				return nil, err
+			}
+			return client, nil
+		},
+	}
+)
+
+// Register makes a database type available to NewDBClient under name, so that other packages
+// can add backends. It is meant to be called from an init function and panics if factory is
+// nil or name is already registered.
+func Register(name string, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	if factory == nil {
+		panic("database: Register factory is nil")
+	}
+	if _, dup := factories[name]; dup {
+		panic("database: Register called twice for database type " + name)
+	}
+	factories[name] = factory
+}
+
+// DBTypes lists the database types accepted by NewDBClient, sorted.
+func DBTypes() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewDBClient creates a new DBClient based on the database type, connecting with options. ctx
+// bounds the initial connection.
+func NewDBClient(ctx context.Context, dbType, connStr string, options ConnOptions) (DBClient, error) {
+	factoriesMu.RLock()
+	factory, ok := factories[dbType]
+	factoriesMu.RUnlock()
+	if !ok {
 		return nil, fmt.Errorf("unsupported database type: %s", dbType)
 	}
+	return factory(ctx, connStr, options)
 }
@@ -0,0 +1,34 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Register(t *testing.T) {
+	errFake := errors.New("fake connection")
+	Register("fake-warehouse", func(ctx context.Context, connStr string, options ConnOptions) (DBClient, error) {
+		return nil, errFake
+	})
+
+	t.Run("登録したデータベースの種類で接続できること", func(t *testing.T) {
+		_, err := NewDBClient(context.Background(), "fake-warehouse", "fake://", ConnOptions{})
+		require.ErrorIs(t, err, errFake)
+	})
+	t.Run("登録したデータベースの種類が一覧に含まれること", func(t *testing.T) {
+		assert.Equal(t, []string{"db2", "fake-warehouse", "h2", "hana", "mysql", "postgres", "singlestore", "spanner", "sqlfile", "tidb", "trino", "vertica", "yugabyte"}, DBTypes())
+	})
+	t.Run("同じ名前を二重に登録するとpanicすること", func(t *testing.T) {
+		assert.Panics(t, func() {
+			Register("postgres", func(ctx context.Context, connStr string, options ConnOptions) (DBClient, error) { return nil, nil })
+		})
+	})
+	t.Run("未登録のデータベースの種類はエラーになること", func(t *testing.T) {
+		_, err := NewDBClient(context.Background(), "unknown", "", ConnOptions{})
+		assert.EqualError(t, err, "unsupported database type: unknown")
+	})
+}
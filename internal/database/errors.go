@@ -0,0 +1,137 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Sentinel errors identifying the failure categories reported by the importer.
+// Use errors.Is to branch on a category and errors.As to retrieve the typed
+// error carrying the details.
+var (
+	ErrConnectionFailed   = errors.New("database connection failed")
+	ErrConversionFailed   = errors.New("value conversion failed")
+	ErrMissingParentTable = errors.New("parent table not found in schema info")
+	ErrMissingParent      = errors.New("referenced parent record does not exist")
+	ErrRowInsert          = errors.New("row insert failed")
+	ErrUniqueCollision    = errors.New("generated unique values kept colliding")
+	ErrSchemaDrift        = errors.New("schema differs from the baseline")
+	ErrAccessDenied       = errors.New("table access denied")
+)
+
+// ConversionError reports a CSV value that could not be converted to the column's data type.
+type ConversionError struct {
+	Value    string
+	DataType ColumnDataType
+	Err      error
+}
+
+func (e *ConversionError) Error() string {
+	return fmt.Sprintf("failed to convert '%s' to %s: %v", e.Value, strings.ToLower(e.DataType.String()), e.Err)
+}
+
+func (e *ConversionError) Unwrap() error { return e.Err }
+
+func (e *ConversionError) Is(target error) bool { return target == ErrConversionFailed }
+
+// MissingParentTableError reports a foreign key whose referenced table is absent from the schema info.
+type MissingParentTableError struct {
+	TableName      string
+	ConstraintName string
+}
+
+func (e *MissingParentTableError) Error() string {
+	return fmt.Sprintf("foreign table %s not found in schema info for foreign key %s", e.TableName, e.ConstraintName)
+}
+
+func (e *MissingParentTableError) Is(target error) bool { return target == ErrMissingParentTable }
+
+// MissingParentRecordError reports a foreign key value whose parent record does not exist
+// while automatic parent creation is disabled.
+type MissingParentRecordError struct {
+	TableName   string
+	ColumnNames []string
+	Values      []string
+}
+
+func (e *MissingParentRecordError) Error() string {
+	return fmt.Sprintf("parent record %s does not exist and automatic parent creation is disabled", formatParentKey(e.TableName, e.ColumnNames, e.Values))
+}
+
+func (e *MissingParentRecordError) Is(target error) bool { return target == ErrMissingParent }
+
+// UniqueCollisionError reports an auto-created parent record that could not be inserted because
+// its generated unique values collided with existing rows on every attempt.
+type UniqueCollisionError struct {
+	TableName   string
+	ColumnNames []string
+	Values      []string
+	Attempts    int
+}
+
+func (e *UniqueCollisionError) Error() string {
+	return fmt.Sprintf("could not create parent record %s: generated unique values collided with existing rows in %d attempts", formatParentKey(e.TableName, e.ColumnNames, e.Values), e.Attempts)
+}
+
+func (e *UniqueCollisionError) Is(target error) bool { return target == ErrUniqueCollision }
+
+// RowInsertError reports a CSV record that could not be written to its table.
+type RowInsertError struct {
+	TableName string
+	FilePath  string
+	// Line is the number of the record in the file, counting the header row; 0 if unknown.
+	Line   int
+	Record []string
+	Err    error
+}
+
+func (e *RowInsertError) Error() string {
+	location := e.FilePath
+	if e.Line > 0 {
+		location = fmt.Sprintf("%s:%d", location, e.Line)
+	}
+	return fmt.Sprintf("failed to insert record into %s from file %s: %v. Record: %v", e.TableName, location, e.Err, e.Record)
+}
+
+func (e *RowInsertError) Unwrap() error { return e.Err }
+
+func (e *RowInsertError) Is(target error) bool { return target == ErrRowInsert }
+
+// ConnectionError reports a failure to open or reach the target database.
+type ConnectionError struct {
+	DBType string
+	Err    error
+}
+
+func (e *ConnectionError) Error() string {
+	return fmt.Sprintf("failed to connect to %s database: %v", e.DBType, e.Err)
+}
+
+func (e *ConnectionError) Unwrap() error { return e.Err }
+
+func (e *ConnectionError) Is(target error) bool { return target == ErrConnectionFailed }
+
+// TableAccessError reports a privilege on a table that the connection lacks, found by CheckTableAccess.
+type TableAccessError struct {
+	TableName string
+	Privilege string
+	Err       error
+}
+
+func (e *TableAccessError) Error() string {
+	return fmt.Sprintf("cannot %s table %s: %v", e.Privilege, e.TableName, e.Err)
+}
+
+func (e *TableAccessError) Unwrap() error { return e.Err }
+
+func (e *TableAccessError) Is(target error) bool { return target == ErrAccessDenied }
+
+// formatParentKey renders a referenced key as "table.column=value", or
+// "table.(column1, column2)=(value1, value2)" for composite keys.
+func formatParentKey(tableName string, columnNames, values []string) string {
+	if len(columnNames) == 1 && len(values) == 1 {
+		return fmt.Sprintf("%s.%s=%s", tableName, columnNames[0], values[0])
+	}
+	return fmt.Sprintf("%s.(%s)=(%s)", tableName, strings.Join(columnNames, ", "), strings.Join(values, ", "))
+}
@@ -0,0 +1,397 @@
+package database
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ValueGenerator invents a value for a column when the importer needs data that is not
+// present in the CSV files, e.g. for the non-key columns of an automatically created parent record.
+// unique is true when the column takes part in a primary key or unique constraint.
+type ValueGenerator interface {
+	GenerateValue(tableName string, col ColumnInfo, unique bool) (interface{}, error)
+}
+
+// FakeKind names a family of fake values produced by FakeGenerator.
+type FakeKind string
+
+const (
+	FakeName      FakeKind = "name"
+	FakeFirstName FakeKind = "first_name"
+	FakeLastName  FakeKind = "last_name"
+	FakeEmail     FakeKind = "email"
+	FakeUsername  FakeKind = "username"
+	FakeCompany   FakeKind = "company"
+	FakeCity      FakeKind = "city"
+	FakePhone     FakeKind = "phone"
+	FakeURL       FakeKind = "url"
+	FakeWord      FakeKind = "word"
+	FakeSentence  FakeKind = "sentence"
+	FakeUUID      FakeKind = "uuid"
+	FakeHex       FakeKind = "hex"
+	FakeID        FakeKind = "id"
+	FakeNumber    FakeKind = "number"
+	FakePrice     FakeKind = "price"
+	FakeBool      FakeKind = "bool"
+	FakeDate      FakeKind = "date"
+	FakeTimestamp FakeKind = "timestamp"
+)
+
+var fakeKinds = []FakeKind{
+	FakeName, FakeFirstName, FakeLastName, FakeEmail, FakeUsername, FakeCompany, FakeCity, FakePhone, FakeURL,
+	FakeWord, FakeSentence, FakeUUID, FakeHex, FakeID, FakeNumber, FakePrice, FakeBool, FakeDate, FakeTimestamp,
+}
+
+// ParseFakeKind validates the name of a fake value kind.
+func ParseFakeKind(s string) (FakeKind, error) {
+	for _, kind := range fakeKinds {
+		if string(kind) == strings.ToLower(s) {
+			return kind, nil
+		}
+	}
+	return "", fmt.Errorf("unknown fake value kind '%s'", s)
+}
+
+var (
+	fakeFirstNames = []string{"Alice", "Bob", "Carol", "David", "Emma", "Frank", "Grace", "Henry", "Isabel", "Jack", "Karen", "Liam", "Mia", "Noah", "Olivia", "Paul", "Quinn", "Ruby", "Sam", "Tina", "Yuki", "Haruto", "Sakura", "Kenji"}
+	fakeLastNames  = []string{"Anderson", "Brown", "Clark", "Davis", "Evans", "Garcia", "Harris", "Johnson", "King", "Lee", "Miller", "Nelson", "Parker", "Roberts", "Smith", "Taylor", "Walker", "Young", "Sato", "Suzuki", "Tanaka", "Watanabe"}
+	fakeCompanies  = []string{"Acme", "Globex", "Initech", "Umbrella", "Stark", "Wayne", "Hooli", "Vandelay", "Soylent", "Cyberdyne", "Wonka", "Tyrell"}
+	fakeCompanySfx = []string{"Inc.", "LLC", "Corp.", "Group", "Holdings", "Co., Ltd."}
+	fakeCities     = []string{"Tokyo", "Osaka", "London", "Paris", "Berlin", "New York", "San Francisco", "Sydney", "Toronto", "Singapore", "Seoul", "Madrid"}
+	fakeWords      = []string{"alpha", "bravo", "cobalt", "delta", "ember", "falcon", "garnet", "harbor", "indigo", "juniper", "kestrel", "lumen", "meadow", "nimbus", "orchid", "prairie", "quartz", "river", "summit", "tundra", "umber", "velvet", "willow", "zephyr"}
+	fakeDomains    = []string{"example.com", "example.org", "example.net"}
+)
+
+// FakeGenerator is the default ValueGenerator. It produces plausible values such as person
+// names, email addresses and small positive IDs. The kind of value used for a column is chosen,
+// in order of precedence, from an explicit "table.column" assignment, a "column" assignment,
+// heuristics based on the column name, and finally a per-type default.
+type FakeGenerator struct {
+	mu          sync.Mutex
+	rnd         *rand.Rand
+	now         time.Time // Reference point for generated dates; zero means time.Now()
+	columnKinds map[string]FakeKind
+	typeKinds   map[ColumnDataType]FakeKind
+}
+
+// newRandomSource returns a PRNG seeded from a cryptographically random seed.
+func newRandomSource() *rand.Rand {
+	var seed [16]byte
+	if _, err := crand.Read(seed[:]); err != nil {
+		// Fall back to the clock; generated values only need to look random.
+		binary.LittleEndian.PutUint64(seed[:], uint64(time.Now().UnixNano()))
+	}
+	return rand.New(rand.NewPCG(binary.LittleEndian.Uint64(seed[:8]), binary.LittleEndian.Uint64(seed[8:])))
+}
+
+// newSeededSource returns a PRNG whose output is fully determined by seed.
+func newSeededSource(seed int64) *rand.Rand {
+	return rand.New(rand.NewPCG(uint64(seed), uint64(seed)^0x9e3779b97f4a7c15))
+}
+
+// seededReferenceTime anchors generated dates when a seed is used, so that
+// they do not depend on the time the import runs.
+var seededReferenceTime = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// NewFakeGenerator creates a FakeGenerator seeded from a cryptographically random seed.
+func NewFakeGenerator() *FakeGenerator {
+	return &FakeGenerator{
+		rnd:         newRandomSource(),
+		columnKinds: make(map[string]FakeKind),
+		typeKinds:   make(map[ColumnDataType]FakeKind),
+	}
+}
+
+// Seed makes the generated values reproducible: generators seeded with the same
+// value produce the same sequence of values for the same sequence of calls.
+func (g *FakeGenerator) Seed(seed int64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.rnd = newSeededSource(seed)
+	g.now = seededReferenceTime
+}
+
+// SetColumnKind assigns a fake value kind to a column. key is either "table.column" or "column".
+func (g *FakeGenerator) SetColumnKind(key string, kind FakeKind) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.columnKinds[strings.ToLower(key)] = kind
+}
+
+// SetTypeKind assigns the fake value kind used by default for all columns of a data type.
+func (g *FakeGenerator) SetTypeKind(dataType ColumnDataType, kind FakeKind) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.typeKinds[dataType] = kind
+}
+
+// ParseAssignments applies a comma-separated list of assignments such as
+// "users.email=email,name=company,type:STRING=word" to the generator.
+func (g *FakeGenerator) ParseAssignments(spec string) error {
+	for _, assignment := range strings.Split(spec, ",") {
+		assignment = strings.TrimSpace(assignment)
+		if assignment == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(assignment, "=")
+		if !ok {
+			return fmt.Errorf("invalid fake value assignment '%s' (expected key=kind)", assignment)
+		}
+		kind, err := ParseFakeKind(strings.TrimSpace(value))
+		if err != nil {
+			return err
+		}
+		key = strings.TrimSpace(key)
+		if typeName, isType := strings.CutPrefix(key, "type:"); isType {
+			dataType, err := ParseColumnDataTypeName(typeName)
+			if err != nil {
+				return err
+			}
+			g.SetTypeKind(dataType, kind)
+			continue
+		}
+		g.SetColumnKind(key, kind)
+	}
+	return nil
+}
+
+// GenerateValue implements ValueGenerator.
+func (g *FakeGenerator) GenerateValue(tableName string, col ColumnInfo, unique bool) (interface{}, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	kind := g.kindFor(tableName, col)
+	val := g.generateKind(kind, unique)
+	return coerceGeneratedValue(val, kind, col.DataType)
+}
+
+func (g *FakeGenerator) kindFor(tableName string, col ColumnInfo) FakeKind {
+	colName := strings.ToLower(col.ColumnName)
+	if kind, ok := g.columnKinds[strings.ToLower(tableName)+"."+colName]; ok {
+		return kind
+	}
+	if kind, ok := g.columnKinds[colName]; ok {
+		return kind
+	}
+	// A @faker hint in the column comment comes after explicit assignments but before any guess
+	if hint, ok := col.Hint("faker"); ok {
+		if kind, err := ParseFakeKind(hint); err == nil {
+			return kind
+		}
+	}
+	if col.DataType == StringType {
+		if kind, ok := guessKindFromName(tableName, colName); ok {
+			return kind
+		}
+	}
+	if col.DataType == FloatType && (strings.Contains(colName, "price") || strings.Contains(colName, "amount") || strings.Contains(colName, "cost")) {
+		return FakePrice
+	}
+	if kind, ok := g.typeKinds[col.DataType]; ok {
+		return kind
+	}
+	switch col.DataType {
+	case IntegerType:
+		return FakeID
+	case FloatType:
+		return FakeNumber
+	case BooleanType:
+		return FakeBool
+	case DateType:
+		return FakeDate
+	case TimestampType:
+		return FakeTimestamp
+	default:
+		return FakeWord
+	}
+}
+
+// guessKindFromName picks a fake value kind for a string column based on its (lower-cased) name.
+func guessKindFromName(tableName, colName string) (FakeKind, bool) {
+	lowerTable := strings.ToLower(tableName)
+	switch {
+	case strings.Contains(colName, "email"):
+		return FakeEmail, true
+	case strings.Contains(colName, "first_name"), strings.Contains(colName, "firstname"), strings.Contains(colName, "given_name"):
+		return FakeFirstName, true
+	case strings.Contains(colName, "last_name"), strings.Contains(colName, "lastname"), strings.Contains(colName, "surname"), strings.Contains(colName, "family_name"):
+		return FakeLastName, true
+	case strings.Contains(colName, "username"), strings.Contains(colName, "login"):
+		return FakeUsername, true
+	case strings.Contains(colName, "company"):
+		return FakeCompany, true
+	case strings.Contains(colName, "city"):
+		return FakeCity, true
+	case strings.Contains(colName, "phone"), strings.Contains(colName, "tel"):
+		return FakePhone, true
+	case strings.Contains(colName, "url"), strings.Contains(colName, "website"):
+		return FakeURL, true
+	case strings.Contains(colName, "uuid"), strings.Contains(colName, "guid"):
+		return FakeUUID, true
+	case strings.Contains(colName, "title"), strings.Contains(colName, "description"), strings.Contains(colName, "content"):
+		return FakeSentence, true
+	case colName == "name" || strings.HasSuffix(colName, "_name"):
+		for _, hint := range []string{"user", "person", "people", "customer", "member", "employee", "author", "staff"} {
+			if strings.Contains(lowerTable, hint) {
+				return FakeName, true
+			}
+		}
+		for _, hint := range []string{"org", "compan", "vendor", "supplier"} {
+			if strings.Contains(lowerTable, hint) {
+				return FakeCompany, true
+			}
+		}
+		return FakeWord, true
+	}
+	return "", false
+}
+
+func (g *FakeGenerator) pick(values []string) string {
+	return values[g.rnd.IntN(len(values))]
+}
+
+// uniqueSuffix returns a short numeric suffix that makes collisions of otherwise small value sets unlikely.
+func (g *FakeGenerator) uniqueSuffix(unique bool) string {
+	if !unique {
+		return ""
+	}
+	return fmt.Sprintf("%06d", g.rnd.IntN(1000000))
+}
+
+func (g *FakeGenerator) generateKind(kind FakeKind, unique bool) interface{} {
+	switch kind {
+	case FakeName:
+		name := g.pick(fakeFirstNames) + " " + g.pick(fakeLastNames)
+		if unique {
+			name += " " + g.uniqueSuffix(unique)
+		}
+		return name
+	case FakeFirstName:
+		return g.pick(fakeFirstNames) + g.uniqueSuffix(unique)
+	case FakeLastName:
+		return g.pick(fakeLastNames) + g.uniqueSuffix(unique)
+	case FakeEmail:
+		return fmt.Sprintf("%s.%s%s@%s", strings.ToLower(g.pick(fakeFirstNames)), strings.ToLower(g.pick(fakeLastNames)), g.uniqueSuffix(unique), g.pick(fakeDomains))
+	case FakeUsername:
+		return fmt.Sprintf("%s_%s%d", strings.ToLower(g.pick(fakeFirstNames)), g.pick(fakeWords), g.rnd.IntN(1000)) + g.uniqueSuffix(unique)
+	case FakeCompany:
+		company := g.pick(fakeCompanies) + " " + g.pick(fakeCompanySfx)
+		if unique {
+			company = g.pick(fakeCompanies) + " " + g.pick(fakeWords) + " " + g.uniqueSuffix(unique) + " " + g.pick(fakeCompanySfx)
+		}
+		return company
+	case FakeCity:
+		return g.pick(fakeCities) + g.uniqueSuffix(unique)
+	case FakePhone:
+		return fmt.Sprintf("0%d0-%04d-%04d", 7+g.rnd.IntN(3), g.rnd.IntN(10000), g.rnd.IntN(10000))
+	case FakeURL:
+		return fmt.Sprintf("https://%s%s.%s/", g.pick(fakeWords), g.uniqueSuffix(unique), g.pick(fakeDomains))
+	case FakeWord:
+		word := g.pick(fakeWords)
+		if unique {
+			word += "-" + g.uniqueSuffix(unique)
+		}
+		return word
+	case FakeSentence:
+		n := 3 + g.rnd.IntN(4)
+		words := make([]string, n)
+		for i := range words {
+			words[i] = g.pick(fakeWords)
+		}
+		words[0] = strings.ToUpper(words[0][:1]) + words[0][1:]
+		sentence := strings.Join(words, " ")
+		if unique {
+			sentence += " " + g.uniqueSuffix(unique)
+		}
+		return sentence
+	case FakeUUID:
+		var b [16]byte
+		binary.LittleEndian.PutUint64(b[:8], g.rnd.Uint64())
+		binary.LittleEndian.PutUint64(b[8:], g.rnd.Uint64())
+		b[6] = (b[6] & 0x0f) | 0x40 // Version 4
+		b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 variant
+		h := hex.EncodeToString(b[:])
+		return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+	case FakeHex:
+		var b [16]byte
+		binary.LittleEndian.PutUint64(b[:8], g.rnd.Uint64())
+		binary.LittleEndian.PutUint64(b[8:], g.rnd.Uint64())
+		return hex.EncodeToString(b[:])
+	case FakeID:
+		// Stay within the range of a 32-bit INT column so generated keys fit common schemas.
+		return int64(1 + g.rnd.Int32N(1<<31-2))
+	case FakeNumber:
+		return float64(g.rnd.IntN(100000)) / 100
+	case FakePrice:
+		return float64(100+g.rnd.IntN(99900)) / 100
+	case FakeBool:
+		return g.rnd.IntN(2) == 0
+	case FakeDate, FakeTimestamp:
+		// A random point within the last ten years
+		now := g.now
+		if now.IsZero() {
+			now = time.Now()
+		}
+		tenYearsAgo := now.AddDate(-10, 0, 0)
+		offset := time.Duration(g.rnd.Int64N(int64(now.Sub(tenYearsAgo)/time.Second))) * time.Second
+		t := tenYearsAgo.Add(offset)
+		if kind == FakeDate {
+			return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		}
+		return t.UTC().Truncate(time.Second)
+	default:
+		return g.pick(fakeWords)
+	}
+}
+
+// coerceGeneratedValue converts a generated value to the Go type expected for the column's data type.
+func coerceGeneratedValue(val interface{}, kind FakeKind, dataType ColumnDataType) (interface{}, error) {
+	switch dataType {
+	case StringType:
+		switch v := val.(type) {
+		case string:
+			return v, nil
+		case time.Time:
+			if kind == FakeDate {
+				return v.Format("2006-01-02"), nil
+			}
+			return v.Format(time.RFC3339), nil
+		default:
+			return fmt.Sprintf("%v", v), nil
+		}
+	case IntegerType:
+		switch v := val.(type) {
+		case int64:
+			return v, nil
+		case float64:
+			return int64(v), nil
+		case bool:
+			if v {
+				return int64(1), nil
+			}
+			return int64(0), nil
+		}
+	case FloatType:
+		switch v := val.(type) {
+		case float64:
+			return v, nil
+		case int64:
+			return float64(v), nil
+		}
+	case BooleanType:
+		if v, ok := val.(bool); ok {
+			return v, nil
+		}
+	case DateType, TimestampType:
+		if v, ok := val.(time.Time); ok {
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("fake value kind '%s' cannot produce %s values", kind, dataType.String())
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FakeGenerator(t *testing.T) {
+	t.Run("カラム名からそれらしい値が生成されること", func(t *testing.T) {
+		g := NewFakeGenerator()
+
+		email, err := g.GenerateValue("users", ColumnInfo{ColumnName: "email", DataType: StringType}, false)
+		require.NoError(t, err)
+		assert.Contains(t, email, "@example.")
+
+		id, err := g.GenerateValue("users", ColumnInfo{ColumnName: "id", DataType: IntegerType}, true)
+		require.NoError(t, err)
+		assert.Greater(t, id.(int64), int64(0))
+		assert.LessOrEqual(t, id.(int64), int64(1<<31-1))
+
+		createdAt, err := g.GenerateValue("users", ColumnInfo{ColumnName: "created_at", DataType: TimestampType}, false)
+		require.NoError(t, err)
+		assert.IsType(t, time.Time{}, createdAt)
+	})
+
+	t.Run("カラム・型ごとの指定が推測より優先されること", func(t *testing.T) {
+		g := NewFakeGenerator()
+		require.NoError(t, g.ParseAssignments("users.email=uuid, type:STRING=hex"))
+
+		email, err := g.GenerateValue("users", ColumnInfo{ColumnName: "email", DataType: StringType}, false)
+		require.NoError(t, err)
+		assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, email)
+
+		code, err := g.GenerateValue("tags", ColumnInfo{ColumnName: "code", DataType: StringType}, false)
+		require.NoError(t, err)
+		assert.Regexp(t, `^[0-9a-f]{32}$`, code)
+	})
+
+	t.Run("コメントの@fakerが推測より優先されること", func(t *testing.T) {
+		g := NewFakeGenerator()
+
+		contact, err := g.GenerateValue("users", ColumnInfo{ColumnName: "contact", DataType: StringType, Comment: "連絡先 @faker=email"}, false)
+		require.NoError(t, err)
+		assert.Contains(t, contact, "@example.")
+	})
+
+	t.Run("型に合わない指定はエラーになること", func(t *testing.T) {
+		g := NewFakeGenerator()
+		require.NoError(t, g.ParseAssignments("users.id=email"))
+
+		_, err := g.GenerateValue("users", ColumnInfo{ColumnName: "id", DataType: IntegerType}, true)
+		assert.Error(t, err)
+	})
+
+	t.Run("不正な指定はエラーになること", func(t *testing.T) {
+		assert.Error(t, NewFakeGenerator().ParseAssignments("users.email"))
+		assert.Error(t, NewFakeGenerator().ParseAssignments("users.email=unknown"))
+		assert.Error(t, NewFakeGenerator().ParseAssignments("type:BLOB=word"))
+	})
+}
+
+func Test_FakeGenerator_Seed(t *testing.T) {
+	t.Run("同じシードであれば同じ値が生成されること", func(t *testing.T) {
+		cols := []ColumnInfo{
+			{ColumnName: "name", DataType: StringType},
+			{ColumnName: "id", DataType: IntegerType},
+			{ColumnName: "created_at", DataType: TimestampType},
+		}
+		generate := func() []interface{} {
+			g := NewFakeGenerator()
+			g.Seed(42)
+			var values []interface{}
+			for _, col := range cols {
+				v, err := g.GenerateValue("users", col, true)
+				require.NoError(t, err)
+				values = append(values, v)
+			}
+			return values
+		}
+
+		assert.Equal(t, generate(), generate())
+	})
+}
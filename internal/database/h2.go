@@ -0,0 +1,415 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// H2DB implements the DBClient interface for the H2 database engine of JVM projects, reached
+// through the PostgreSQL protocol of its server (started with -pg) in PostgreSQL compatibility
+// mode. H2 emulates little of pg_catalog, so the schema is read from its INFORMATION_SCHEMA, and it
+// has no ON CONFLICT ... DO UPDATE or RETURNING, so rows are imported with H2's own MERGE and keys
+// of auto-created parent records are read back from a data change delta table. Statements use
+// the ? placeholders of H2's parser.
+type H2DB struct {
+	*PostgresDB
+}
+
+// NewH2DB creates a new H2DB instance. connStr is a PostgreSQL connection string to the H2 server,
+// e.g. "postgres://sa:sa@localhost:5435/test?sslmode=disable".
+func NewH2DB(ctx context.Context, connStr string, options ConnOptions) (*H2DB, error) {
+	db, err := openDB("postgres", connStr, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database connection: %w", err)
+	}
+	if err = db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, &ConnectionError{DBType: "H2", Err: err}
+	}
+	log.Println("Successfully connected to H2 database.")
+	return &H2DB{PostgresDB: &PostgresDB{db: db}}, nil
+}
+
+// h2DataType maps a DATA_TYPE of INFORMATION_SCHEMA.COLUMNS, e.g. "CHARACTER VARYING", to a
+// ColumnDataType. Unlike in MySQL, TINYINT is an integer rather than a boolean.
+func h2DataType(dataType string) ColumnDataType {
+	switch strings.ToUpper(dataType) {
+	case "CHARACTER LARGE OBJECT", "VARCHAR_IGNORECASE", "ENUM", "UUID", "JSON":
+		return StringType
+	case "TINYINT":
+		return IntegerType
+	case "TIME WITH TIME ZONE":
+		return TimestampType
+	default:
+		return ParseDataType(dataType)
+	}
+}
+
+// GetSchemaInfo retrieves schema information for a given schema name from H2. The schema is
+// matched regardless of case, since H2 upper-cases unquoted names unless DATABASE_TO_LOWER is set.
+// Tables in other schemas that foreign keys reference are included as "SCHEMA.TABLE".
+func (h *H2DB) GetSchemaInfo(ctx context.Context, schemaName string) (map[string]DBInfo, error) {
+	log.Printf("Retrieving schema for '%s' from H2.\n", schemaName)
+
+	mainSchema := schemaName
+	err := h.db.QueryRowContext(ctx, "SELECT SCHEMA_NAME FROM INFORMATION_SCHEMA.SCHEMATA WHERE UPPER(SCHEMA_NAME) = UPPER(?)", schemaName).Scan(&mainSchema)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to look up schema '%s': %w", schemaName, err)
+	}
+	h.schemaName = mainSchema
+
+	tables, err := h.getTableNames(ctx, mainSchema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get table names from schema '%s': %w", schemaName, err)
+	}
+
+	schemaInfo := make(map[string]DBInfo)
+	for _, tableName := range tables {
+		if isImportRunsTable(tableName) {
+			continue
+		}
+		dbInfo, err := h.describeTable(ctx, mainSchema, mainSchema, tableName)
+		if err != nil {
+			return nil, err
+		}
+		schemaInfo[tableName] = dbInfo
+	}
+
+	describe := func(ctx context.Context, tableSchema, tableName string) (DBInfo, error) {
+		return h.describeTable(ctx, mainSchema, tableSchema, tableName)
+	}
+	if err := addForeignSchemaTables(ctx, schemaInfo, describe); err != nil {
+		return nil, err
+	}
+	return schemaInfo, nil
+}
+
+// describeTable introspects one table of tableSchema. mainSchema is the schema being imported.
+func (h *H2DB) describeTable(ctx context.Context, mainSchema, tableSchema, tableName string) (DBInfo, error) {
+	qualifiedName := qualifiedTableName(mainSchema, tableSchema, tableName)
+	columns, err := h.getColumnInfo(ctx, tableSchema, tableName)
+	if err != nil {
+		return DBInfo{}, fmt.Errorf("failed to get column info for table %s: %w", qualifiedName, err)
+	}
+	primaryKeys, uniqueKeys, err := h.getKeyColumns(ctx, tableSchema, tableName)
+	if err != nil {
+		return DBInfo{}, fmt.Errorf("failed to get key info for table %s: %w", qualifiedName, err)
+	}
+	foreignKeys, err := h.getForeignKeyInfo(ctx, mainSchema, tableSchema, tableName)
+	if err != nil {
+		return DBInfo{}, fmt.Errorf("failed to get foreign key info for table %s: %w", qualifiedName, err)
+	}
+
+	return DBInfo{
+		TableName:         qualifiedName,
+		Columns:           columns,
+		PrimaryKeyColumns: primaryKeys,
+		UniqueKeyColumns:  uniqueKeys,
+		ForeignKeys:       foreignKeys,
+	}, nil
+}
+
+func (h *H2DB) getTableNames(ctx context.Context, schemaName string) ([]string, error) {
+	// H2 before 2.0 reports base tables as 'TABLE'
+	rows, err := h.db.QueryContext(ctx, `
+		SELECT TABLE_NAME
+		FROM INFORMATION_SCHEMA.TABLES
+		WHERE TABLE_SCHEMA = ? AND TABLE_TYPE IN ('BASE TABLE', 'TABLE')
+	`, schemaName)
+	if err != nil {
+		return nil, fmt.Errorf("query failed for schema '%s': %w", schemaName, err)
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var tableName string
+		if err := rows.Scan(&tableName); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		tables = append(tables, tableName)
+	}
+	return tables, rows.Err()
+}
+
+func (h *H2DB) getColumnInfo(ctx context.Context, schemaName, tableName string) ([]ColumnInfo, error) {
+	rows, err := h.db.QueryContext(ctx, `
+		SELECT COLUMN_NAME, DATA_TYPE, IS_NULLABLE, COLUMN_DEFAULT, IS_IDENTITY,
+			COALESCE(IDENTITY_GENERATION, ''), IS_GENERATED, COALESCE(REMARKS, '')
+		FROM INFORMATION_SCHEMA.COLUMNS
+		WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?
+		ORDER BY ORDINAL_POSITION
+	`, schemaName, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("query failed for table %s: %w", tableName, err)
+	}
+	defer rows.Close()
+
+	var columns []ColumnInfo
+	for rows.Next() {
+		var colName, dataType, isNullableStr, isIdentityStr, identityGeneration, isGeneratedStr, comment string
+		var colDefault sql.NullString
+		if err := rows.Scan(&colName, &dataType, &isNullableStr, &colDefault, &isIdentityStr, &identityGeneration, &isGeneratedStr, &comment); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		columns = append(columns, ColumnInfo{
+			ColumnName:      colName,
+			DataType:        h2DataType(dataType),
+			IsNullable:      isNullableStr == "YES",
+			ColumnDefault:   colDefault,
+			IsAutoIncrement: isIdentityStr == "YES",
+			// Generated columns and GENERATED ALWAYS AS IDENTITY reject explicit values
+			IsGenerated: isGeneratedStr == "ALWAYS" || identityGeneration == "ALWAYS",
+			Comment:     comment,
+		})
+	}
+	return columns, rows.Err()
+}
+
+// getKeyColumns returns the primary key columns and the columns of each unique constraint.
+func (h *H2DB) getKeyColumns(ctx context.Context, schemaName, tableName string) ([]string, [][]string, error) {
+	rows, err := h.db.QueryContext(ctx, `
+		SELECT tc.CONSTRAINT_NAME, tc.CONSTRAINT_TYPE, kcu.COLUMN_NAME
+		FROM INFORMATION_SCHEMA.TABLE_CONSTRAINTS AS tc
+		JOIN INFORMATION_SCHEMA.KEY_COLUMN_USAGE AS kcu
+			ON kcu.CONSTRAINT_SCHEMA = tc.CONSTRAINT_SCHEMA AND kcu.CONSTRAINT_NAME = tc.CONSTRAINT_NAME
+		WHERE tc.TABLE_SCHEMA = ? AND tc.TABLE_NAME = ? AND tc.CONSTRAINT_TYPE IN ('PRIMARY KEY', 'UNIQUE')
+		ORDER BY tc.CONSTRAINT_NAME, kcu.ORDINAL_POSITION
+	`, schemaName, tableName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("query failed for table %s: %w", tableName, err)
+	}
+	defer rows.Close()
+
+	var pks []string
+	var uks [][]string
+	var lastConstraint string
+	for rows.Next() {
+		var constraintName, constraintType, colName string
+		if err := rows.Scan(&constraintName, &constraintType, &colName); err != nil {
+			return nil, nil, fmt.Errorf("scan failed: %w", err)
+		}
+		switch {
+		case constraintType == "PRIMARY KEY":
+			pks = append(pks, colName)
+		case constraintName == lastConstraint:
+			uks[len(uks)-1] = append(uks[len(uks)-1], colName)
+		default:
+			uks = append(uks, []string{colName})
+		}
+		lastConstraint = constraintName
+	}
+	return pks, uks, rows.Err()
+}
+
+func (h *H2DB) getForeignKeyInfo(ctx context.Context, mainSchema, schemaName, tableName string) ([]ForeignKeyInfo, error) {
+	// Each column of a foreign key is paired with the column of the referenced unique constraint
+	// at its POSITION_IN_UNIQUE_CONSTRAINT
+	rows, err := h.db.QueryContext(ctx, `
+		SELECT rc.CONSTRAINT_NAME, kcu.COLUMN_NAME, rkcu.TABLE_SCHEMA, rkcu.TABLE_NAME, rkcu.COLUMN_NAME
+		FROM INFORMATION_SCHEMA.REFERENTIAL_CONSTRAINTS AS rc
+		JOIN INFORMATION_SCHEMA.KEY_COLUMN_USAGE AS kcu
+			ON kcu.CONSTRAINT_SCHEMA = rc.CONSTRAINT_SCHEMA AND kcu.CONSTRAINT_NAME = rc.CONSTRAINT_NAME
+		JOIN INFORMATION_SCHEMA.KEY_COLUMN_USAGE AS rkcu
+			ON rkcu.CONSTRAINT_SCHEMA = rc.UNIQUE_CONSTRAINT_SCHEMA AND rkcu.CONSTRAINT_NAME = rc.UNIQUE_CONSTRAINT_NAME
+			AND rkcu.ORDINAL_POSITION = kcu.POSITION_IN_UNIQUE_CONSTRAINT
+		WHERE kcu.TABLE_SCHEMA = ? AND kcu.TABLE_NAME = ?
+		ORDER BY rc.CONSTRAINT_NAME, kcu.ORDINAL_POSITION
+	`, schemaName, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var fks []ForeignKeyInfo
+	for rows.Next() {
+		var constraintName, columnName, foreignTableSchema, foreignTableName, foreignColumnName string
+		if err := rows.Scan(&constraintName, &columnName, &foreignTableSchema, &foreignTableName, &foreignColumnName); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		fks = appendForeignKeyColumn(fks, qualifiedTableName(mainSchema, schemaName, tableName), constraintName, columnName,
+			qualifiedTableName(mainSchema, foreignTableSchema, foreignTableName), foreignColumnName)
+	}
+	return fks, rows.Err()
+}
+
+// h2InsertStatement builds the statement that imports a row into table in mode. In InsertUpsert, it
+// is a MERGE matching rows on the primary key, or an INSERT if the table has no primary key or it is
+// generated. InsertSkip uses the ON CONFLICT DO NOTHING of the PostgreSQL mode.
+func h2InsertStatement(table string, dbInfo DBInfo, mode InsertMode) string {
+	var cols []string
+	var placeholders []string
+	for _, colInfo := range dbInfo.InsertColumns() {
+		cols = append(cols, colInfo.ColumnName)
+		placeholders = append(placeholders, "?")
+	}
+
+	insert := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(cols, ", "), strings.Join(placeholders, ", "))
+	switch mode {
+	case InsertOnly:
+		return insert
+	case InsertSkip:
+		return insert + " ON CONFLICT DO NOTHING"
+	}
+
+	generatedKey := false
+	for _, colInfo := range dbInfo.Columns {
+		if colInfo.IsGenerated && slices.Contains(dbInfo.PrimaryKeyColumns, colInfo.ColumnName) {
+			generatedKey = true
+		}
+	}
+	if len(dbInfo.PrimaryKeyColumns) == 0 || generatedKey {
+		return insert
+	}
+	return fmt.Sprintf("MERGE INTO %s (%s) KEY (%s) VALUES (%s)", table, strings.Join(cols, ", "),
+		strings.Join(dbInfo.PrimaryKeyColumns, ", "), strings.Join(placeholders, ", "))
+}
+
+// PrepareInsertStatement prepares a MERGE statement for H2.
+func (h *H2DB) PrepareInsertStatement(ctx context.Context, dbInfo DBInfo) (*sql.Stmt, error) {
+	return h.PrepareModeInsertStatement(ctx, dbInfo, InsertUpsert)
+}
+
+// PrepareModeInsertStatement prepares a statement of mode for H2.
+func (h *H2DB) PrepareModeInsertStatement(ctx context.Context, dbInfo DBInfo, mode InsertMode) (*sql.Stmt, error) {
+	stmt, err := h.db.PrepareContext(ctx, h2InsertStatement(h.quoteTable(dbInfo.TableName), dbInfo, mode))
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	return stmt, nil
+}
+
+// ParentRecordExists checks if a record exists in the given table for specific column values in H2.
+func (h *H2DB) ParentRecordExists(ctx context.Context, dbInfo DBInfo, columnNames, values []string) (bool, error) {
+	conditions := make([]string, len(columnNames))
+	args := make([]interface{}, len(values))
+	for i, colName := range columnNames {
+		conditions[i] = fmt.Sprintf("%s = ?", colName)
+		args[i] = values[i]
+	}
+	query := fmt.Sprintf("SELECT 1 FROM %s WHERE %s LIMIT 1", h.quoteTable(dbInfo.TableName), strings.Join(conditions, " AND "))
+	var exists int
+	err := h.db.QueryRowContext(ctx, query, args...).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check existence of record %s: %w", formatParentKey(dbInfo.TableName, columnNames, values), err)
+	}
+	return true, nil
+}
+
+// UpdateRow sets columns of an existing row.
+func (h *H2DB) UpdateRow(ctx context.Context, dbInfo DBInfo, keyColumns, keyValues, columnNames, values []string) error {
+	query := updateStatement(h.quoteTable(dbInfo.TableName), keyColumns, columnNames, func(int) string { return "?" })
+	if _, err := h.db.ExecContext(ctx, query, updateArgs(keyValues, values)...); err != nil {
+		return fmt.Errorf("failed to update record %s: %w", formatParentKey(dbInfo.TableName, keyColumns, keyValues), err)
+	}
+	return nil
+}
+
+// ClearColumns sets columns of the rows matching filter to NULL.
+func (h *H2DB) ClearColumns(ctx context.Context, dbInfo DBInfo, columnNames []string, filter RowFilter) error {
+	return clearColumns(ctx, h.db, h.quoteTable(dbInfo.TableName), dbInfo, columnNames, filter, func(int) string { return "?" }, 0)
+}
+
+// DeleteRows deletes the rows of a table matching filter.
+func (h *H2DB) DeleteRows(ctx context.Context, dbInfo DBInfo, filter RowFilter) (int64, error) {
+	return deleteRows(ctx, h.db, h.quoteTable(dbInfo.TableName), dbInfo, filter, func(int) string { return "?" }, 0)
+}
+
+// BeginRowDeletion starts a transaction clearing and deleting rows like ClearColumns and DeleteRows.
+func (h *H2DB) BeginRowDeletion(ctx context.Context) (RowDeletion, error) {
+	return beginRowDeletion(ctx, h.db, func(dbInfo DBInfo) string { return h.quoteTable(dbInfo.TableName) }, func(int) string { return "?" })
+}
+
+// EnsureParentRecordExists checks if a record with the given foreignKeyValues exists in the parent table.
+// If not, it creates a new record in the parent table with default values and the provided foreignKeyValues
+// for the foreignColumnNames. This implementation is specific to H2.
+func (h *H2DB) EnsureParentRecordExists(ctx context.Context, parentDBInfo DBInfo, foreignColumnNames, foreignKeyValues []string, dbSchema map[string]DBInfo) ([]string, error) {
+	return ensureParentRecord(ctx, h, &h.parentRecordSettings, parentDBInfo, foreignColumnNames, foreignKeyValues, dbSchema, func(parentCols []string, parentValues []interface{}, returning string) (interface{}, bool, error) {
+		parentPlaceholders := make([]string, len(parentCols))
+		for i := range parentCols {
+			parentPlaceholders[i] = "?"
+		}
+		table := h.quoteTable(parentDBInfo.TableName)
+		insertQuery := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(parentCols, ", "), strings.Join(parentPlaceholders, ", "))
+		if len(parentCols) == 0 {
+			insertQuery = fmt.Sprintf("INSERT INTO %s DEFAULT VALUES", table)
+		}
+
+		var key interface{}
+		var err error
+		if returning != "" {
+			// H2 has no RETURNING; the inserted row is selected from its data change delta table
+			err = h.db.QueryRowContext(ctx, fmt.Sprintf("SELECT %s FROM FINAL TABLE (%s)", returning, insertQuery), parentValues...).Scan(&key)
+		} else {
+			_, err = h.db.ExecContext(ctx, insertQuery, parentValues...)
+		}
+		if err != nil {
+			if isH2UniqueViolation(err) {
+				return nil, false, nil
+			}
+			return nil, false, err
+		}
+		return key, true, nil
+	})
+}
+
+// isH2UniqueViolation reports whether err is a duplicate key error, which H2 reports with the
+// SQLSTATE of PostgreSQL.
+func isH2UniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// ResetSequences restarts each identity column after the column's largest value.
+func (h *H2DB) ResetSequences(ctx context.Context, dbInfo DBInfo) error {
+	table := h.quoteTable(dbInfo.TableName)
+	for _, colInfo := range dbInfo.Columns {
+		if !colInfo.IsAutoIncrement {
+			continue
+		}
+		var maxValue sql.NullInt64
+		if err := h.db.QueryRowContext(ctx, fmt.Sprintf("SELECT MAX(%s) FROM %s", colInfo.ColumnName, table)).Scan(&maxValue); err != nil {
+			return fmt.Errorf("failed to read the largest value of %s.%s: %w", dbInfo.TableName, colInfo.ColumnName, err)
+		}
+		if !maxValue.Valid {
+			continue
+		}
+		if _, err := h.db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s RESTART WITH %d", table, colInfo.ColumnName, maxValue.Int64+1)); err != nil {
+			return fmt.Errorf("failed to restart identity of %s.%s: %w", dbInfo.TableName, colInfo.ColumnName, err)
+		}
+	}
+	return nil
+}
+
+// ReadRows reads the rows of a table matching filter.
+func (h *H2DB) ReadRows(ctx context.Context, dbInfo DBInfo, columns []ColumnInfo, filter RowFilter, fn func(values []string) error) error {
+	return readFilteredRows(ctx, h.db, h.quoteTable(dbInfo.TableName), dbInfo, columns, filter, func(int) string { return "?" }, fn)
+}
+
+// RecordImportRun adds run to the _import_runs table of the schema, with H2's names of the types.
+func (h *H2DB) RecordImportRun(ctx context.Context, run ImportRun) error {
+	table := h.quoteTable(ImportRunsTable)
+	if _, err := h.db.ExecContext(ctx, createImportRunsStatement("CREATE TABLE IF NOT EXISTS", table, "CHARACTER LARGE OBJECT", "TIMESTAMP WITH TIME ZONE")); err != nil {
+		return fmt.Errorf("failed to create %s: %w", ImportRunsTable, err)
+	}
+	query := insertImportRunStatement(table, func(int) string { return "?" })
+	if _, err := h.db.ExecContext(ctx, query, importRunArgs(run)...); err != nil {
+		return fmt.Errorf("failed to record import run %s: %w", run.RunID, err)
+	}
+	return nil
+}
+
+// RefreshMaterializedViews does nothing: H2 has no materialized views.
+func (h *H2DB) RefreshMaterializedViews(ctx context.Context, tableNames []string) ([]string, error) {
+	return nil, nil
+}
@@ -0,0 +1,52 @@
+package database
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_h2InsertStatement(t *testing.T) {
+	columns := []ColumnInfo{
+		{ColumnName: "id", DataType: IntegerType, IsAutoIncrement: true},
+		{ColumnName: "name", DataType: StringType},
+	}
+	t.Run("主キーのあるテーブルにはMERGEを使うこと", func(t *testing.T) {
+		stmt := h2InsertStatement(`"PUBLIC"."USERS"`, DBInfo{TableName: "USERS", Columns: columns, PrimaryKeyColumns: []string{"id"}}, InsertUpsert)
+		assert.Equal(t, `MERGE INTO "PUBLIC"."USERS" (id, name) KEY (id) VALUES (?, ?)`, stmt)
+	})
+	t.Run("主キーのないテーブルにはINSERTを使うこと", func(t *testing.T) {
+		stmt := h2InsertStatement(`"PUBLIC"."LOGS"`, DBInfo{TableName: "LOGS", Columns: columns}, InsertUpsert)
+		assert.Equal(t, `INSERT INTO "PUBLIC"."LOGS" (id, name) VALUES (?, ?)`, stmt)
+	})
+	t.Run("主キーがGENERATED ALWAYSの場合はINSERTを使うこと", func(t *testing.T) {
+		generated := []ColumnInfo{
+			{ColumnName: "id", DataType: IntegerType, IsAutoIncrement: true, IsGenerated: true},
+			{ColumnName: "name", DataType: StringType},
+		}
+		stmt := h2InsertStatement(`"PUBLIC"."USERS"`, DBInfo{TableName: "USERS", Columns: generated, PrimaryKeyColumns: []string{"id"}}, InsertUpsert)
+		assert.Equal(t, `INSERT INTO "PUBLIC"."USERS" (name) VALUES (?)`, stmt)
+	})
+}
+
+func Test_h2DataType(t *testing.T) {
+	assert.Equal(t, StringType, h2DataType("CHARACTER VARYING"))
+	assert.Equal(t, StringType, h2DataType("CHARACTER LARGE OBJECT"))
+	assert.Equal(t, StringType, h2DataType("UUID"))
+	assert.Equal(t, IntegerType, h2DataType("TINYINT"))
+	assert.Equal(t, IntegerType, h2DataType("BIGINT"))
+	assert.Equal(t, FloatType, h2DataType("DOUBLE PRECISION"))
+	assert.Equal(t, FloatType, h2DataType("NUMERIC"))
+	assert.Equal(t, BooleanType, h2DataType("BOOLEAN"))
+	assert.Equal(t, DateType, h2DataType("DATE"))
+	assert.Equal(t, TimestampType, h2DataType("TIMESTAMP WITH TIME ZONE"))
+	assert.Equal(t, TimestampType, h2DataType("TIME WITH TIME ZONE"))
+}
+
+func Test_isH2UniqueViolation(t *testing.T) {
+	assert.True(t, isH2UniqueViolation(fmt.Errorf("failed to insert: %w", &pq.Error{Code: "23505"})))
+	assert.False(t, isH2UniqueViolation(&pq.Error{Code: "23503"}))
+	assert.False(t, isH2UniqueViolation(fmt.Errorf("connection refused")))
+}
@@ -0,0 +1,11 @@
+//go:build hana
+// +build hana
+
+package database
+
+import (
+	_ "github.com/SAP/go-hdb/driver" // HANA driver
+)
+
+// hanaDriverCompiled reports whether the HANA driver is linked in.
+const hanaDriverCompiled = true
@@ -0,0 +1,7 @@
+//go:build !hana
+// +build !hana
+
+package database
+
+// hanaDriverCompiled reports whether the HANA driver is linked in.
+const hanaDriverCompiled = false
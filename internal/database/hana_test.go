@@ -0,0 +1,44 @@
+package database
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_hanaInsertStatement(t *testing.T) {
+	columns := []ColumnInfo{{ColumnName: "ID", DataType: IntegerType}, {ColumnName: "NAME", DataType: StringType}}
+
+	t.Run("主キーがあるテーブルにはUPSERTを使う", func(t *testing.T) {
+		dbInfo := DBInfo{TableName: "USERS", Columns: columns, PrimaryKeyColumns: []string{"ID"}}
+		assert.Equal(t, "UPSERT USERS (ID, NAME) VALUES (?, ?) WITH PRIMARY KEY", hanaInsertStatement(dbInfo))
+	})
+
+	t.Run("主キーがないテーブルにはINSERTを使う", func(t *testing.T) {
+		dbInfo := DBInfo{TableName: "LOGS", Columns: columns}
+		assert.Equal(t, "INSERT INTO LOGS (ID, NAME) VALUES (?, ?)", hanaInsertStatement(dbInfo))
+	})
+
+	t.Run("主キーが生成列の場合はINSERTを使う", func(t *testing.T) {
+		generated := []ColumnInfo{{ColumnName: "ID", DataType: IntegerType, IsAutoIncrement: true, IsGenerated: true}, columns[1]}
+		dbInfo := DBInfo{TableName: "USERS", Columns: generated, PrimaryKeyColumns: []string{"ID"}}
+		assert.Equal(t, "INSERT INTO USERS (NAME) VALUES (?)", hanaInsertStatement(dbInfo))
+	})
+}
+
+func Test_hanaDataType(t *testing.T) {
+	assert.Equal(t, StringType, hanaDataType("NVARCHAR"))
+	assert.Equal(t, StringType, hanaDataType("VARCHAR"))
+	assert.Equal(t, IntegerType, hanaDataType("TINYINT"))
+	assert.Equal(t, IntegerType, hanaDataType("BIGINT"))
+	assert.Equal(t, FloatType, hanaDataType("SMALLDECIMAL"))
+	assert.Equal(t, BooleanType, hanaDataType("BOOLEAN"))
+	assert.Equal(t, TimestampType, hanaDataType("SECONDDATE"))
+	assert.Equal(t, DateType, hanaDataType("DATE"))
+}
+
+func Test_isHANAUniqueViolation(t *testing.T) {
+	assert.True(t, isHANAUniqueViolation(errors.New("SQL Error 301 - unique constraint violated: Table(USERS)")))
+	assert.False(t, isHANAUniqueViolation(errors.New("SQL Error 259 - invalid table name")))
+}
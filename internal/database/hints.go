@@ -0,0 +1,107 @@
+package database
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Column comments may carry hints for the importer, written as "@name=value" anywhere in the
+// comment, e.g. "Date of birth @format=DD/MM/YYYY". Values containing spaces are double-quoted.
+// Supported hints:
+//
+//	@format  layout of CSV values of a DATE or TIMESTAMP column, e.g. DD/MM/YYYY or YYYY-MM-DD HH:mm:ss
+//	@faker   fake value kind used to generate values for the column, e.g. email (see ParseFakeKind)
+var hintPattern = regexp.MustCompile(`@(\w+)=("[^"]*"|\S+)`)
+
+// Hint returns the value of the named hint in the column comment.
+func (c ColumnInfo) Hint(name string) (string, bool) {
+	for _, m := range hintPattern.FindAllStringSubmatch(c.Comment, -1) {
+		if strings.EqualFold(m[1], name) {
+			return strings.Trim(m[2], `"`), true
+		}
+	}
+	return "", false
+}
+
+// dateLayoutReplacer turns the tokens of a @format hint into the reference values of a Go time layout.
+var dateLayoutReplacer = strings.NewReplacer("YYYY", "2006", "YY", "06", "MM", "01", "DD", "02", "HH", "15", "mm", "04", "ss", "05")
+
+// ConvertColumnValue converts a CSV value for col like ConvertToDBType. Dates and timestamps are
+// read in the layout of the column's @format hint, if it has one.
+func ConvertColumnValue(csvValue string, col ColumnInfo) (interface{}, error) {
+	if format, ok := col.Hint("format"); ok && csvValue != "" && (col.DataType == DateType || col.DataType == TimestampType) {
+		val, err := time.Parse(dateLayoutReplacer.Replace(format), csvValue)
+		if err != nil {
+			return nil, &ConversionError{Value: csvValue, DataType: col.DataType, Err: fmt.Errorf("expected %s: %w", format, err)}
+		}
+		return val, nil
+	}
+	return ConvertToDBType(csvValue, col.DataType, col.IsNullable, col.ColumnDefault)
+}
+
+// ConvertNativeValue converts a value read in a native type rather than as CSV text, such as a
+// value of an Arrow IPC file, for col. Unlike in CSV, nil is NULL (or the default of a NOT NULL
+// column) while "" is an empty string, and binary values are inserted as they are into string
+// and unknown columns. A value of a type other than the column's is converted from text, its CSV
+// string form, like a CSV value.
+func ConvertNativeValue(value interface{}, text string, col ColumnInfo) (interface{}, error) {
+	switch v := value.(type) {
+	case nil:
+		if col.IsNullable {
+			return nil, nil
+		}
+		return ConvertColumnValue("", col)
+	case string:
+		if col.DataType == StringType {
+			return v, nil
+		}
+	case []byte:
+		if col.DataType == StringType || col.DataType == UnknownType {
+			return v, nil
+		}
+	case int64:
+		switch col.DataType {
+		case IntegerType:
+			return v, nil
+		case FloatType:
+			return float64(v), nil
+		}
+	case float64:
+		if col.DataType == FloatType {
+			return v, nil
+		}
+	case bool:
+		if col.DataType == BooleanType {
+			return v, nil
+		}
+	case time.Time:
+		if col.DataType == DateType || col.DataType == TimestampType {
+			return v, nil
+		}
+	}
+	return ConvertColumnValue(text, col)
+}
+
+// CheckColumnHints describes each hint in the column comments of schema that cannot be honored,
+// such as an unknown fake value kind. Hints with other names are not reported, since comments
+// may use "@" for other purposes.
+func CheckColumnHints(schema map[string]DBInfo) []string {
+	var problems []string
+	for tableName, dbInfo := range schema {
+		for _, col := range dbInfo.Columns {
+			if _, ok := col.Hint("format"); ok && col.DataType != DateType && col.DataType != TimestampType {
+				problems = append(problems, fmt.Sprintf("%s.%s: @format is only used for DATE and TIMESTAMP columns, not %s", tableName, col.ColumnName, col.DataType))
+			}
+			if kind, ok := col.Hint("faker"); ok {
+				if _, err := ParseFakeKind(kind); err != nil {
+					problems = append(problems, fmt.Sprintf("%s.%s: @faker: %v", tableName, col.ColumnName, err))
+				}
+			}
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ColumnInfo_Hint(t *testing.T) {
+	col := ColumnInfo{Comment: `誕生日 @format=DD/MM/YYYY @faker="first_name"`}
+
+	format, ok := col.Hint("format")
+	assert.True(t, ok)
+	assert.Equal(t, "DD/MM/YYYY", format)
+	faker, ok := col.Hint("faker")
+	assert.True(t, ok)
+	assert.Equal(t, "first_name", faker)
+	_, ok = col.Hint("unknown")
+	assert.False(t, ok)
+}
+
+func Test_ConvertColumnValue(t *testing.T) {
+	t.Run("@formatの書式で日付が読まれること", func(t *testing.T) {
+		col := ColumnInfo{ColumnName: "birthday", DataType: DateType, Comment: "@format=DD/MM/YYYY"}
+		val, err := ConvertColumnValue("31/12/1990", col)
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(1990, 12, 31, 0, 0, 0, 0, time.UTC), val)
+
+		_, err = ConvertColumnValue("1990-12-31", col)
+		assert.ErrorIs(t, err, ErrConversionFailed)
+	})
+
+	t.Run("ヒントがない場合はConvertToDBTypeと同じであること", func(t *testing.T) {
+		val, err := ConvertColumnValue("1990-12-31", ColumnInfo{DataType: DateType})
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(1990, 12, 31, 0, 0, 0, 0, time.UTC), val)
+	})
+}
+
+func Test_ConvertNativeValue(t *testing.T) {
+	stamp := time.Date(2024, 3, 1, 12, 0, 0, 123456000, time.UTC)
+	tests := []struct {
+		name  string
+		value interface{}
+		text  string
+		col   ColumnInfo
+		want  interface{}
+	}{
+		{"nullはNULLになること", nil, "", ColumnInfo{DataType: StringType, IsNullable: true}, nil},
+		{"空文字列はNULLにならないこと", "", "", ColumnInfo{DataType: StringType, IsNullable: true}, ""},
+		{"NOT NULLのカラムのnullはデフォルト値になること", nil, "", ColumnInfo{DataType: IntegerType, ColumnDefault: sql.NullString{String: "7", Valid: true}}, int64(7)},
+		{"バイト列はそのまま挿入されること", []byte{0x00, 0xff}, "\x00\xff", ColumnInfo{DataType: UnknownType}, []byte{0x00, 0xff}},
+		{"整数は浮動小数点数のカラムにも挿入されること", int64(3), "3", ColumnInfo{DataType: FloatType}, 3.0},
+		{"タイムスタンプは秒未満も保たれること", stamp, "2024-03-01T12:00:00.123456Z", ColumnInfo{DataType: TimestampType}, stamp},
+		{"型の異なるカラムには文字列から変換されること", int64(42), "42", ColumnInfo{DataType: StringType}, "42"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			val, err := ConvertNativeValue(tt.value, tt.text, tt.col)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, val)
+		})
+	}
+
+	t.Run("変換できない値はConversionErrorになること", func(t *testing.T) {
+		_, err := ConvertNativeValue("abc", "abc", ColumnInfo{DataType: IntegerType})
+		assert.ErrorIs(t, err, ErrConversionFailed)
+	})
+}
+
+func Test_CheckColumnHints(t *testing.T) {
+	schema := map[string]DBInfo{
+		"users": {TableName: "users", Columns: []ColumnInfo{
+			{ColumnName: "name", DataType: StringType, Comment: "@format=DD/MM/YYYY"},
+			{ColumnName: "email", DataType: StringType, Comment: "@faker=mail @owner=crm"},
+			{ColumnName: "nickname", DataType: StringType, Comment: "@faker=username"},
+		}},
+	}
+	assert.Equal(t, []string{
+		"users.email: @faker: unknown fake value kind 'mail'",
+		"users.name: @format is only used for DATE and TIMESTAMP columns, not STRING",
+	}, CheckColumnHints(schema))
+}
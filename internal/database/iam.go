@@ -0,0 +1,269 @@
+package database
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// rdsTokenLifetime is how long an IAM authentication token is valid. RDS accepts no longer.
+const rdsTokenLifetime = 15 * time.Minute
+
+// awsCredentials are the credentials IAM authentication tokens are signed with.
+type awsCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // Set for temporary credentials, e.g. of an assumed role
+}
+
+// awsCredentialsFromEnv reads the credentials from the standard AWS environment variables, as set
+// e.g. by the CI step that assumes a role.
+func awsCredentialsFromEnv() (awsCredentials, error) {
+	creds := awsCredentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return awsCredentials{}, errors.New("AWS IAM authentication needs AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY to be set")
+	}
+	return creds, nil
+}
+
+// awsRegion returns the region of the RDS endpoint host: AWS_REGION or AWS_DEFAULT_REGION if set,
+// otherwise the label before "rds" in the host name, e.g. "ap-northeast-1" in
+// "db.abc123.ap-northeast-1.rds.amazonaws.com".
+func awsRegion(host string) (string, error) {
+	for _, name := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+		if region := os.Getenv(name); region != "" {
+			return region, nil
+		}
+	}
+	labels := strings.Split(host, ".")
+	for idx := 1; idx < len(labels); idx++ {
+		if labels[idx] == "rds" {
+			return labels[idx-1], nil
+		}
+	}
+	return "", fmt.Errorf("cannot tell the AWS region of %s; set AWS_REGION", host)
+}
+
+// rdsAuthToken generates an IAM authentication token for user at endpoint ("host:port"): a URL
+// presigned with Signature Version 4 for the rds-db:connect action, without its scheme.
+func rdsAuthToken(endpoint, region, user string, creds awsCredentials, now time.Time) string {
+	now = now.UTC()
+	date := now.Format("20060102")
+	amzDate := now.Format("20060102T150405Z")
+	scope := date + "/" + region + "/rds-db/aws4_request"
+
+	params := map[string]string{
+		"Action":              "connect",
+		"DBUser":              user,
+		"X-Amz-Algorithm":     "AWS4-HMAC-SHA256",
+		"X-Amz-Credential":    creds.AccessKeyID + "/" + scope,
+		"X-Amz-Date":          amzDate,
+		"X-Amz-Expires":       fmt.Sprint(int(rdsTokenLifetime.Seconds())),
+		"X-Amz-SignedHeaders": "host",
+	}
+	if creds.SessionToken != "" {
+		params["X-Amz-Security-Token"] = creds.SessionToken
+	}
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for idx, key := range keys {
+		pairs[idx] = awsURIEncode(key) + "=" + awsURIEncode(params[key])
+	}
+	query := strings.Join(pairs, "&")
+
+	emptyPayloadHash := sha256.Sum256(nil)
+	canonicalRequest := strings.Join([]string{"GET", "/", query, "host:" + endpoint + "\n", "host", hex.EncodeToString(emptyPayloadHash[:])}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, hex.EncodeToString(requestHash[:])}, "\n")
+	signature := hex.EncodeToString(hmacSHA256(awsSigningKey(creds.SecretAccessKey, date, region, "rds-db"), stringToSign))
+	return endpoint + "/?" + query + "&X-Amz-Signature=" + signature
+}
+
+// awsSigningKey derives the Signature Version 4 key of a day, region and service.
+func awsSigningKey(secretAccessKey, date, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	return hmacSHA256(key, "aws4_request")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// awsURIEncode percent-encodes s as Signature Version 4 requires: every byte but the unreserved
+// characters, with upper-case hex digits.
+func awsURIEncode(s string) string {
+	var b strings.Builder
+	for idx := 0; idx < len(s); idx++ {
+		c := s[idx]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// iamConnector is a driver.Connector that connects with a new IAM authentication token as the
+// password each time.
+type iamConnector struct {
+	driver     driver.Driver
+	driverName string
+	dsn        string
+	endpoint   string // host:port the token is signed for
+	region     string
+	user       string
+	creds      awsCredentials
+}
+
+// newIAMConnector returns the connector of dsn authenticating with IAM. Only the PostgreSQL and
+// MySQL drivers are supported, and the connection must use TLS, which RDS requires for IAM
+// authentication.
+func newIAMConnector(driverName, dsn string, drv driver.Driver) (*iamConnector, error) {
+	var host, port, user string
+	switch driverName {
+	case "postgres":
+		options, err := postgresOptions(dsn)
+		if err != nil {
+			return nil, err
+		}
+		if options["sslmode"] == "disable" {
+			return nil, errors.New("AWS IAM authentication requires SSL; remove sslmode=disable from the connection string")
+		}
+		host, port, user = options["host"], options["port"], options["user"]
+		if port == "" {
+			port = "5432"
+		}
+	case "mysql":
+		cfg, err := mysql.ParseDSN(dsn)
+		if err != nil {
+			return nil, err
+		}
+		// TLS options make the connections use TLS whatever the connection string says
+		_, tlsOptionsSet := drv.(mysqlTLSDriver)
+		if !tlsOptionsSet && (cfg.TLSConfig == "" || cfg.TLSConfig == "false") {
+			return nil, errors.New("AWS IAM authentication requires TLS; add tls=true (or another tls setting) to the connection string")
+		}
+		if host, port, err = net.SplitHostPort(cfg.Addr); err != nil {
+			host, port = cfg.Addr, "3306"
+		}
+		user = cfg.User
+	default:
+		return nil, fmt.Errorf("AWS IAM authentication is not supported for the %s driver (only PostgreSQL and MySQL)", driverName)
+	}
+	if host == "" || user == "" {
+		return nil, errors.New("AWS IAM authentication needs the host and the user in the connection string")
+	}
+	region, err := awsRegion(host)
+	if err != nil {
+		return nil, err
+	}
+	creds, err := awsCredentialsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return &iamConnector{driver: drv, driverName: driverName, dsn: dsn, endpoint: net.JoinHostPort(host, port), region: region, user: user, creds: creds}, nil
+}
+
+func (c *iamConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	dsn, err := c.dsnWithToken(rdsAuthToken(c.endpoint, c.region, c.user, c.creds, time.Now()))
+	if err != nil {
+		return nil, err
+	}
+	if driverCtx, ok := c.driver.(driver.DriverContext); ok {
+		connector, err := driverCtx.OpenConnector(dsn)
+		if err != nil {
+			return nil, err
+		}
+		return connector.Connect(ctx)
+	}
+	return dsnConnector{dsn: dsn, driver: c.driver}.Connect(ctx)
+}
+
+func (c *iamConnector) Driver() driver.Driver { return c.driver }
+
+// dsnWithToken returns the DSN with token as the password.
+func (c *iamConnector) dsnWithToken(token string) (string, error) {
+	if c.driverName == "mysql" {
+		cfg, err := mysql.ParseDSN(c.dsn)
+		if err != nil {
+			return "", err
+		}
+		cfg.Passwd = token
+		// The token is sent as a cleartext password, protected by TLS
+		cfg.AllowCleartextPasswords = true
+		return cfg.FormatDSN(), nil
+	}
+	dsn, err := postgresKeyValues(c.dsn)
+	if err != nil {
+		return "", err
+	}
+	// A later setting overrides an earlier one, including a password in the connection string
+	return dsn + " password=" + postgresQuote(token), nil
+}
+
+// postgresOptions parses a lib/pq connection string, a URL or "key=value" pairs, into its settings.
+func postgresOptions(dsn string) (map[string]string, error) {
+	dsn, err := postgresKeyValues(dsn)
+	if err != nil {
+		return nil, err
+	}
+	options := make(map[string]string)
+	s := strings.TrimSpace(dsn)
+	for s != "" {
+		key, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid connection string setting %q", s)
+		}
+		key = strings.TrimSpace(key)
+		rest = strings.TrimLeft(rest, " ")
+		var value strings.Builder
+		if strings.HasPrefix(rest, "'") {
+			// Quoted value, with backslash escapes
+			idx := 1
+			for ; idx < len(rest) && rest[idx] != '\''; idx++ {
+				if rest[idx] == '\\' && idx+1 < len(rest) {
+					idx++
+				}
+				value.WriteByte(rest[idx])
+			}
+			if idx >= len(rest) {
+				return nil, fmt.Errorf("unterminated quoted value of %s in the connection string", key)
+			}
+			rest = rest[idx+1:]
+		} else {
+			end := strings.IndexAny(rest, " \t\n")
+			if end < 0 {
+				end = len(rest)
+			}
+			value.WriteString(rest[:end])
+			rest = rest[end:]
+		}
+		options[key] = value.String()
+		s = strings.TrimSpace(rest)
+	}
+	return options, nil
+}
@@ -0,0 +1,43 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// InsertMode is how the insert statement of a table treats a row whose key is already in the table.
+type InsertMode string
+
+const (
+	InsertUpsert InsertMode = "upsert" // Update the existing row, as PrepareInsertStatement does.
+	InsertOnly   InsertMode = "insert" // Plain INSERT: the row fails with the database's duplicate key error.
+	InsertSkip   InsertMode = "skip"   // Keep the existing row; the row changes nothing.
+)
+
+// ParseInsertMode validates an insert mode name given in a configuration file.
+func ParseInsertMode(s string) (InsertMode, error) {
+	switch m := InsertMode(strings.ToLower(s)); m {
+	case InsertUpsert, InsertOnly, InsertSkip:
+		return m, nil
+	default:
+		return "", fmt.Errorf("unknown import mode '%s' (expected upsert, insert or skip)", s)
+	}
+}
+
+// ModeInserter is implemented by the DBClients that can prepare the insert statement of a table in
+// another InsertMode than InsertUpsert.
+type ModeInserter interface {
+	// PrepareModeInsertStatement prepares the statement inserting a row into the table of dbInfo
+	// in mode. The statement of InsertUpsert is that of PrepareInsertStatement.
+	PrepareModeInsertStatement(ctx context.Context, dbInfo DBInfo, mode InsertMode) (*sql.Stmt, error)
+}
+
+var (
+	_ ModeInserter = (*PostgresDB)(nil)
+	_ ModeInserter = (*MySQLDB)(nil)
+	_ ModeInserter = (*SingleStoreDB)(nil)
+	_ ModeInserter = (*H2DB)(nil)
+	_ ModeInserter = (*ReadSplitDB)(nil)
+)
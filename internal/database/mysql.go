@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
@@ -11,18 +12,19 @@ import (
 
 // MySQLDB implements the DBClient interface for MySQL.
 type MySQLDB struct {
+	parentRecordSettings
 	db *sql.DB
 }
 
 // NewMySQLDB creates a new MySQLDB instance.
-func NewMySQLDB(connStr string) (*MySQLDB, error) {
-	db, err := sql.Open("mysql", connStr)
+func NewMySQLDB(ctx context.Context, connStr string, options ConnOptions) (*MySQLDB, error) {
+	db, err := openDB("mysql", connStr, options)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database connection: %w", err)
 	}
-	if err = db.Ping(); err != nil {
+	if err = db.PingContext(ctx); err != nil {
 		db.Close()
-		return nil, fmt.Errorf("failed to connect to MySQL database: %w", err)
+		return nil, &ConnectionError{DBType: "MySQL", Err: err}
 	}
 	log.Println("Successfully connected to MySQL database.")
 	return &MySQLDB{db: db}, nil
@@ -42,47 +44,68 @@ func (m *MySQLDB) Close() error {
 }
 
 // GetSchemaInfo retrieves schema information for a given database name from MySQL.
-func (m *MySQLDB) GetSchemaInfo(dbName string) (map[string]DBInfo, error) {
+// Tables in other databases that foreign keys reference are included as "database.table".
+func (m *MySQLDB) GetSchemaInfo(ctx context.Context, dbName string) (map[string]DBInfo, error) {
 	log.Printf("Retrieving schema for '%s' from MySQL.\n", dbName)
 
-	tables, err := m.getTableNames(dbName)
+	tables, err := m.getTableNames(ctx, dbName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get table names from database '%s': %w", dbName, err)
 	}
 
 	schemaInfo := make(map[string]DBInfo)
 	for _, tableName := range tables {
-		columns, err := m.getColumnInfo(dbName, tableName)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get column info for table %s: %w", tableName, err)
-		}
-		primaryKeys, err := m.getPrimaryKeyColumns(dbName, tableName)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get primary key info for table %s: %w", tableName, err)
-		}
-		uniqueKeys, err := m.getUniqueKeyColumns(dbName, tableName)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get unique key info for table %s: %w", tableName, err)
+		if isImportRunsTable(tableName) {
+			continue
 		}
-		foreignKeys, err := m.getForeignKeyInfo(dbName, tableName)
+		dbInfo, err := m.describeTable(ctx, dbName, dbName, tableName)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get foreign key info for table %s: %w", tableName, err)
-		}
-
-		schemaInfo[tableName] = DBInfo{
-			TableName:         tableName,
-			Columns:           columns,
-			PrimaryKeyColumns: primaryKeys,
-			UniqueKeyColumns:  uniqueKeys,
-			ForeignKeys:       foreignKeys,
+			return nil, err
 		}
+		schemaInfo[tableName] = dbInfo
 	}
 
+	describe := func(ctx context.Context, tableDBName, tableName string) (DBInfo, error) {
+		return m.describeTable(ctx, dbName, tableDBName, tableName)
+	}
+	if err := addForeignSchemaTables(ctx, schemaInfo, describe); err != nil {
+		return nil, err
+	}
 	return schemaInfo, nil
 }
 
-func (m *MySQLDB) getTableNames(dbName string) ([]string, error) {
-	rows, err := m.db.Query(`
+// describeTable introspects one table of tableDBName. mainDBName is the database being imported.
+func (m *MySQLDB) describeTable(ctx context.Context, mainDBName, tableDBName, tableName string) (DBInfo, error) {
+	qualifiedName := qualifiedTableName(mainDBName, tableDBName, tableName)
+	columns, err := m.getColumnInfo(ctx, tableDBName, tableName)
+	if err != nil {
+		return DBInfo{}, fmt.Errorf("failed to get column info for table %s: %w", qualifiedName, err)
+	}
+	primaryKeys, err := m.getPrimaryKeyColumns(ctx, tableDBName, tableName)
+	if err != nil {
+		return DBInfo{}, fmt.Errorf("failed to get primary key info for table %s: %w", qualifiedName, err)
+	}
+	uniqueKeys, err := m.getUniqueKeyColumns(ctx, tableDBName, tableName)
+	if err != nil {
+		return DBInfo{}, fmt.Errorf("failed to get unique key info for table %s: %w", qualifiedName, err)
+	}
+	foreignKeys, err := m.getForeignKeyInfo(ctx, mainDBName, tableDBName, tableName)
+	if err != nil {
+		return DBInfo{}, fmt.Errorf("failed to get foreign key info for table %s: %w", qualifiedName, err)
+	}
+
+	return DBInfo{
+		TableName:         qualifiedName,
+		Columns:           columns,
+		PrimaryKeyColumns: primaryKeys,
+		UniqueKeyColumns:  uniqueKeys,
+		ForeignKeys:       foreignKeys,
+	}, nil
+}
+
+func (m *MySQLDB) getTableNames(ctx context.Context, dbName string) ([]string, error) {
+	// Views have table_type 'VIEW' and cannot be import targets
+	rows, err := m.db.QueryContext(ctx, `
 		SELECT table_name
 		FROM information_schema.tables
 		WHERE table_schema = ? AND table_type = 'BASE TABLE';
@@ -103,9 +126,9 @@ func (m *MySQLDB) getTableNames(dbName string) ([]string, error) {
 	return tables, nil
 }
 
-func (m *MySQLDB) getColumnInfo(dbName, tableName string) ([]ColumnInfo, error) {
-	rows, err := m.db.Query(`
-		SELECT column_name, data_type, is_nullable, column_default
+func (m *MySQLDB) getColumnInfo(ctx context.Context, dbName, tableName string) ([]ColumnInfo, error) {
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT column_name, data_type, is_nullable, column_default, extra, column_comment
 		FROM information_schema.columns
 		WHERE table_schema = ? AND table_name = ?
 		ORDER BY ordinal_position;
@@ -117,24 +140,29 @@ func (m *MySQLDB) getColumnInfo(dbName, tableName string) ([]ColumnInfo, error)
 
 	var columns []ColumnInfo
 	for rows.Next() {
-		var colName, dataType, isNullableStr string
+		var colName, dataType, isNullableStr, extra, comment string
 		var colDefault sql.NullString
-		if err := rows.Scan(&colName, &dataType, &isNullableStr, &colDefault); err != nil {
+		if err := rows.Scan(&colName, &dataType, &isNullableStr, &colDefault, &extra, &comment); err != nil {
 			return nil, fmt.Errorf("scan failed: %w", err)
 		}
 		isNullable := (isNullableStr == "YES")
+		extra = strings.ToLower(extra)
 		columns = append(columns, ColumnInfo{
-			ColumnName:    colName,
-			DataType:      ParseDataType(dataType),
-			IsNullable:    isNullable,
-			ColumnDefault: colDefault,
+			ColumnName:      colName,
+			DataType:        ParseDataType(dataType),
+			IsNullable:      isNullable,
+			ColumnDefault:   colDefault,
+			IsAutoIncrement: strings.Contains(extra, "auto_increment"),
+			// VIRTUAL GENERATED / STORED GENERATED; "DEFAULT_GENERATED" only marks expression defaults
+			IsGenerated: strings.Contains(extra, "virtual generated") || strings.Contains(extra, "stored generated"),
+			Comment:     comment,
 		})
 	}
 	return columns, nil
 }
 
-func (m *MySQLDB) getPrimaryKeyColumns(dbName, tableName string) ([]string, error) {
-	rows, err := m.db.Query(`
+func (m *MySQLDB) getPrimaryKeyColumns(ctx context.Context, dbName, tableName string) ([]string, error) {
+	rows, err := m.db.QueryContext(ctx, `
 		SELECT DISTINCT kcu.column_name
 		FROM information_schema.table_constraints AS tc
 		JOIN information_schema.key_column_usage AS kcu
@@ -160,8 +188,8 @@ func (m *MySQLDB) getPrimaryKeyColumns(dbName, tableName string) ([]string, erro
 	return pks, nil
 }
 
-func (m *MySQLDB) getUniqueKeyColumns(dbName, tableName string) ([][]string, error) {
-	rows, err := m.db.Query(`
+func (m *MySQLDB) getUniqueKeyColumns(ctx context.Context, dbName, tableName string) ([][]string, error) {
+	rows, err := m.db.QueryContext(ctx, `
 		SELECT GROUP_CONCAT(DISTINCT kcu.column_name ORDER BY kcu.ordinal_position)
 		FROM information_schema.table_constraints AS tc
 		JOIN information_schema.key_column_usage AS kcu
@@ -186,11 +214,12 @@ func (m *MySQLDB) getUniqueKeyColumns(dbName, tableName string) ([][]string, err
 	return uks, nil
 }
 
-func (m *MySQLDB) getForeignKeyInfo(dbName, tableName string) ([]ForeignKeyInfo, error) {
-	rows, err := m.db.Query(`
+func (m *MySQLDB) getForeignKeyInfo(ctx context.Context, mainDBName, dbName, tableName string) ([]ForeignKeyInfo, error) {
+	rows, err := m.db.QueryContext(ctx, `
 		SELECT
 			kcu.constraint_name,
 			kcu.column_name,
+			kcu.referenced_table_schema AS foreign_table_schema,
 			kcu.referenced_table_name AS foreign_table_name,
 			kcu.referenced_column_name AS foreign_column_name
 		FROM
@@ -198,7 +227,9 @@ func (m *MySQLDB) getForeignKeyInfo(dbName, tableName string) ([]ForeignKeyInfo,
 		WHERE
 			kcu.constraint_schema = ?
 			AND kcu.table_name = ?
-			AND kcu.referenced_table_name IS NOT NULL;
+			AND kcu.referenced_table_name IS NOT NULL
+		ORDER BY
+			kcu.constraint_name, kcu.ordinal_position;
 	`, dbName, tableName)
 	if err != nil {
 		return nil, fmt.Errorf("query failed: %w", err)
@@ -207,119 +238,194 @@ func (m *MySQLDB) getForeignKeyInfo(dbName, tableName string) ([]ForeignKeyInfo,
 
 	var fks []ForeignKeyInfo
 	for rows.Next() {
-		var fk ForeignKeyInfo
-		fk.TableName = tableName // Set the current table name
-		if err := rows.Scan(&fk.ConstraintName, &fk.ColumnName, &fk.ForeignTableName, &fk.ForeignColumnName); err != nil {
+		var constraintName, columnName, foreignTableSchema, foreignTableName, foreignColumnName string
+		if err := rows.Scan(&constraintName, &columnName, &foreignTableSchema, &foreignTableName, &foreignColumnName); err != nil {
 			return nil, fmt.Errorf("scan failed: %w", err)
 		}
+		fks = appendForeignKeyColumn(fks, qualifiedTableName(mainDBName, dbName, tableName), constraintName, columnName,
+			qualifiedTableName(mainDBName, foreignTableSchema, foreignTableName), foreignColumnName)
+	}
+	for _, fk := range fks {
 		log.Printf("DEBUG: Found foreign key: %+v\n", fk) // Add debug log
-		fks = append(fks, fk)
 	}
 	return fks, nil
 }
 
-// PrepareInsertStatement prepares an INSERT statement for MySQL.
-func (m *MySQLDB) PrepareInsertStatement(dbInfo DBInfo) (*sql.Stmt, error) {
+// mysqlInsertStatement builds the statement that imports a row into MySQL in mode. In InsertUpsert,
+// it is an INSERT updating the row with the same key on a duplicate key, an INSERT IGNORE if only
+// key columns are inserted, or a plain INSERT if the table has no primary key. In InsertSkip, a
+// duplicate key sets a column to itself, which leaves the row unchanged and, unlike INSERT IGNORE,
+// keeps the other errors. head is the statement up to VALUES, values the placeholders of a row and
+// tail the clause after the rows, if any. The columns of the primary key and fixedColumns are never
+// updated.
+func mysqlInsertStatement(dbInfo DBInfo, fixedColumns []string, mode InsertMode) (head, values, tail string) {
 	var cols []string
 	var placeholders []string
-	for _, colInfo := range dbInfo.Columns {
+	for _, colInfo := range dbInfo.InsertColumns() {
 		cols = append(cols, colInfo.ColumnName)
 		placeholders = append(placeholders, "?")
 	}
+	values = "(" + strings.Join(placeholders, ", ") + ")"
 
-	pkMap := make(map[string]bool)
-	for _, pkCol := range dbInfo.PrimaryKeyColumns {
-		pkMap[pkCol] = true
+	fixed := make(map[string]bool)
+	for _, colName := range dbInfo.PrimaryKeyColumns {
+		fixed[colName] = true
+	}
+	for _, colName := range fixedColumns {
+		fixed[colName] = true
 	}
 
-	var query string
-	if len(dbInfo.PrimaryKeyColumns) > 0 {
-		var updateClauses []string
-		for _, colInfo := range dbInfo.Columns {
-			if !pkMap[colInfo.ColumnName] {
-				updateClauses = append(updateClauses, fmt.Sprintf("%s = VALUES(%s)", colInfo.ColumnName, colInfo.ColumnName))
-			}
+	if mode == InsertOnly || (mode != InsertSkip && len(dbInfo.PrimaryKeyColumns) == 0) {
+		return fmt.Sprintf("INSERT INTO %s (%s) VALUES", dbInfo.TableName, strings.Join(cols, ", ")), values, ""
+	}
+	var updateClauses []string
+	for _, colInfo := range dbInfo.InsertColumns() {
+		if fixed[colInfo.ColumnName] {
+			continue
 		}
-
-		if len(updateClauses) > 0 {
-			query = fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON DUPLICATE KEY UPDATE %s",
-				dbInfo.TableName,
-				strings.Join(cols, ", "),
-				strings.Join(placeholders, ", "),
-				strings.Join(updateClauses, ", "),
-			)
-		} else {
-			// If only primary keys are present, and no other columns to update,
-			// use INSERT IGNORE to prevent errors on duplicate primary keys.
-			query = fmt.Sprintf("INSERT IGNORE INTO %s (%s) VALUES (%s)",
-				dbInfo.TableName,
-				strings.Join(cols, ", "),
-				strings.Join(placeholders, ", "),
-			)
+		if mode == InsertSkip {
+			updateClauses = []string{fmt.Sprintf("%s = %s", colInfo.ColumnName, colInfo.ColumnName)}
+			break
 		}
-	} else {
-		query = fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
-			dbInfo.TableName,
-			strings.Join(cols, ", "),
-			strings.Join(placeholders, ", "),
-		)
+		updateClauses = append(updateClauses, fmt.Sprintf("%s = VALUES(%s)", colInfo.ColumnName, colInfo.ColumnName))
 	}
+	if len(updateClauses) == 0 {
+		// If only primary keys are present, and no other columns to update,
+		// use INSERT IGNORE to prevent errors on duplicate primary keys.
+		return fmt.Sprintf("INSERT IGNORE INTO %s (%s) VALUES", dbInfo.TableName, strings.Join(cols, ", ")), values, ""
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES", dbInfo.TableName, strings.Join(cols, ", ")), values,
+		" ON DUPLICATE KEY UPDATE " + strings.Join(updateClauses, ", ")
+}
+
+// PrepareInsertStatement prepares an INSERT statement for MySQL.
+func (m *MySQLDB) PrepareInsertStatement(ctx context.Context, dbInfo DBInfo) (*sql.Stmt, error) {
+	return m.PrepareModeInsertStatement(ctx, dbInfo, InsertUpsert)
+}
 
-	stmt, err := m.db.Prepare(query)
+// PrepareModeInsertStatement prepares an INSERT statement of mode for MySQL.
+func (m *MySQLDB) PrepareModeInsertStatement(ctx context.Context, dbInfo DBInfo, mode InsertMode) (*sql.Stmt, error) {
+	head, values, tail := mysqlInsertStatement(dbInfo, nil, mode)
+	stmt, err := m.db.PrepareContext(ctx, head+" "+values+tail)
 	if err != nil {
 		return nil, fmt.Errorf("failed to prepare statement: %w", err)
 	}
 	return stmt, nil
 }
 
-// ParentRecordExists checks if a record exists in the given table for a specific column and value in MySQL.
-func (m *MySQLDB) ParentRecordExists(dbInfo DBInfo, columnName, value string) (bool, error) {
-	query := fmt.Sprintf("SELECT EXISTS(SELECT 1 FROM %s WHERE %s = ?)", dbInfo.TableName, columnName)
+// ParentRecordExists checks if a record exists in the given table for specific column values in MySQL.
+func (m *MySQLDB) ParentRecordExists(ctx context.Context, dbInfo DBInfo, columnNames, values []string) (bool, error) {
+	conditions := make([]string, len(columnNames))
+	args := make([]interface{}, len(values))
+	for i, colName := range columnNames {
+		conditions[i] = fmt.Sprintf("%s = ?", colName)
+		args[i] = values[i]
+	}
+	query := fmt.Sprintf("SELECT EXISTS(SELECT 1 FROM %s WHERE %s)", dbInfo.TableName, strings.Join(conditions, " AND "))
 	var exists bool
-	err := m.db.QueryRow(query, value).Scan(&exists)
+	err := m.db.QueryRowContext(ctx, query, args...).Scan(&exists)
 	if err != nil {
-		return false, fmt.Errorf("failed to check existence of record in %s for %s=%s: %w", dbInfo.TableName, columnName, value, err)
+		return false, fmt.Errorf("failed to check existence of record %s: %w", formatParentKey(dbInfo.TableName, columnNames, values), err)
 	}
 	return exists, nil
 }
 
-// EnsureParentRecordExists checks if a record with the given foreignKeyValue exists in the parent table.
-// If not, it creates a new record in the parent table with default values and the provided foreignKeyValue
-// for the foreignColumnName. This implementation is specific to MySQL.
-func (m *MySQLDB) EnsureParentRecordExists(parentDBInfo DBInfo, foreignColumnName, foreignKeyValue string, dbSchema map[string]DBInfo) error {
-	// Check if the parent record already exists
-	exists, err := m.ParentRecordExists(parentDBInfo, foreignColumnName, foreignKeyValue)
-	if err != nil {
-		return fmt.Errorf("failed to check parent record existence: %w", err)
-	}
-	if exists {
-		return nil // Parent record already exists
+// UpdateRow sets columns of an existing row.
+func (m *MySQLDB) UpdateRow(ctx context.Context, dbInfo DBInfo, keyColumns, keyValues, columnNames, values []string) error {
+	query := updateStatement(dbInfo.TableName, keyColumns, columnNames, func(int) string { return "?" })
+	if _, err := m.db.ExecContext(ctx, query, updateArgs(keyValues, values)...); err != nil {
+		return fmt.Errorf("failed to update record %s: %w", formatParentKey(dbInfo.TableName, keyColumns, keyValues), err)
 	}
+	return nil
+}
 
-	// Parent record does not exist, create it
-	log.Printf("Creating missing parent record in table '%s' for column '%s' with value '%s'\n", parentDBInfo.TableName, foreignColumnName, foreignKeyValue)
+// ClearColumns sets columns of the rows matching filter to NULL.
+func (m *MySQLDB) ClearColumns(ctx context.Context, dbInfo DBInfo, columnNames []string, filter RowFilter) error {
+	return clearColumns(ctx, m.db, dbInfo.TableName, dbInfo, columnNames, filter, func(int) string { return "?" }, 0)
+}
 
-	parentCols, _, parentValues, err := ensureParentRecordExistsCommon(m, parentDBInfo, foreignColumnName, foreignKeyValue, dbSchema)
-	if err != nil {
-		return err
-	}
+// DeleteRows deletes the rows of a table matching filter.
+func (m *MySQLDB) DeleteRows(ctx context.Context, dbInfo DBInfo, filter RowFilter) (int64, error) {
+	return deleteRows(ctx, m.db, dbInfo.TableName, dbInfo, filter, func(int) string { return "?" }, 0)
+}
 
-	// Generate MySQL-specific placeholders
-	parentPlaceholders := make([]string, len(parentCols))
-	for i := range parentCols {
-		parentPlaceholders[i] = "?"
-	}
+// BeginRowDeletion starts a transaction clearing and deleting rows like ClearColumns and DeleteRows.
+func (m *MySQLDB) BeginRowDeletion(ctx context.Context) (RowDeletion, error) {
+	return beginRowDeletion(ctx, m.db, func(dbInfo DBInfo) string { return dbInfo.TableName }, func(int) string { return "?" })
+}
+
+// EnsureParentRecordExists checks if a record with the given foreignKeyValues exists in the parent table.
+// If not, it creates a new record in the parent table with default values and the provided foreignKeyValues
+// for the foreignColumnNames. This implementation is specific to MySQL.
+func (m *MySQLDB) EnsureParentRecordExists(ctx context.Context, parentDBInfo DBInfo, foreignColumnNames, foreignKeyValues []string, dbSchema map[string]DBInfo) ([]string, error) {
+	return ensureParentRecord(ctx, m, &m.parentRecordSettings, parentDBInfo, foreignColumnNames, foreignKeyValues, dbSchema, func(parentCols []string, parentValues []interface{}, returning string) (interface{}, bool, error) {
+		// Generate MySQL-specific placeholders
+		parentPlaceholders := make([]string, len(parentCols))
+		for i := range parentCols {
+			parentPlaceholders[i] = "?"
+		}
 
-	insertQuery := fmt.Sprintf("INSERT IGNORE INTO %s (%s) VALUES (%s)",
-		parentDBInfo.TableName,
-		strings.Join(parentCols, ", "),
-		strings.Join(parentPlaceholders, ", "),
-	)
+		// INSERT IGNORE skips the row on a duplicate key
+		insertQuery := fmt.Sprintf("INSERT IGNORE INTO %s (%s) VALUES (%s)",
+			parentDBInfo.TableName,
+			strings.Join(parentCols, ", "),
+			strings.Join(parentPlaceholders, ", "),
+		)
 
-	_, err = m.db.Exec(insertQuery, parentValues...)
-	if err != nil {
-		return fmt.Errorf("failed to insert parent record into %s: %w", parentDBInfo.TableName, err)
-	}
+		result, err := m.db.ExecContext(ctx, insertQuery, parentValues...)
+		if err != nil {
+			return nil, false, err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return nil, false, err
+		}
+		if affected == 0 || returning == "" {
+			return nil, affected > 0, nil
+		}
 
+		// MySQL has no RETURNING; only AUTO_INCREMENT keys can be read back.
+		for _, colInfo := range parentDBInfo.Columns {
+			if colInfo.ColumnName == returning && !colInfo.IsAutoIncrement {
+				return nil, false, fmt.Errorf("cannot read back generated column %s.%s", parentDBInfo.TableName, returning)
+			}
+		}
+		key, err := result.LastInsertId()
+		if err != nil {
+			return nil, false, err
+		}
+		return key, true, nil
+	})
+}
+
+// ResetSequences does nothing: InnoDB raises the AUTO_INCREMENT counter past explicitly inserted values itself.
+func (m *MySQLDB) ResetSequences(ctx context.Context, dbInfo DBInfo) error {
 	return nil
 }
+
+// ReadRows reads the rows of a table matching filter.
+func (m *MySQLDB) ReadRows(ctx context.Context, dbInfo DBInfo, columns []ColumnInfo, filter RowFilter, fn func(values []string) error) error {
+	return readFilteredRows(ctx, m.db, dbInfo.TableName, dbInfo, columns, filter, func(int) string { return "?" }, fn)
+}
+
+// CheckTableAccess verifies the privileges of the connection on a table.
+func (m *MySQLDB) CheckTableAccess(ctx context.Context, dbInfo DBInfo, privileges []string) error {
+	return checkTableAccess(ctx, m.db, dbInfo.TableName, dbInfo, privileges)
+}
+
+// RecordImportRun adds run to the _import_runs table of the database.
+func (m *MySQLDB) RecordImportRun(ctx context.Context, run ImportRun) error {
+	if _, err := m.db.ExecContext(ctx, createImportRunsStatement("CREATE TABLE IF NOT EXISTS", ImportRunsTable, "TEXT", "DATETIME(6)")); err != nil {
+		return fmt.Errorf("failed to create %s: %w", ImportRunsTable, err)
+	}
+	query := insertImportRunStatement(ImportRunsTable, func(int) string { return "?" })
+	if _, err := m.db.ExecContext(ctx, query, importRunArgs(run)...); err != nil {
+		return fmt.Errorf("failed to record import run %s: %w", run.RunID, err)
+	}
+	return nil
+}
+
+// RefreshMaterializedViews does nothing: MySQL has no materialized views.
+func (m *MySQLDB) RefreshMaterializedViews(ctx context.Context, tableNames []string) ([]string, error) {
+	return nil, nil
+}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
@@ -11,21 +12,32 @@ import (
 
 // PostgresDB implements the DBClient interface for PostgreSQL.
 type PostgresDB struct {
+	parentRecordSettings
 	db *sql.DB
+	// schemaName is the schema last passed to GetSchemaInfo. Generated SQL qualifies table names
+	// with it instead of relying on the search_path.
+	schemaName string
+	// catalogColumns reads the columns from pg_attribute instead of information_schema.columns,
+	// whose views are slow to query on YugabyteDB.
+	catalogColumns bool
+	// returnsInserted makes the insert statements return (xmax = 0), which is false for a row an
+	// upsert updated, so that ExecInsert and ExecBatch report it as UpsertUpdated. YugabyteDB and
+	// H2 have no xmax.
+	returnsInserted bool
 }
 
 // NewPostgresDB creates a new PostgresDB instance.
-func NewPostgresDB(connStr string) (*PostgresDB, error) {
-	db, err := sql.Open("postgres", connStr)
+func NewPostgresDB(ctx context.Context, connStr string, options ConnOptions) (*PostgresDB, error) {
+	db, err := openDB("postgres", connStr, options)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database connection: %w", err)
 	}
-	if err = db.Ping(); err != nil {
+	if err = db.PingContext(ctx); err != nil {
 		db.Close()
-		return nil, fmt.Errorf("failed to connect to PostgreSQL database: %w", err)
+		return nil, &ConnectionError{DBType: "PostgreSQL", Err: err}
 	}
 	log.Println("Successfully connected to PostgreSQL database.")
-	return &PostgresDB{db: db}, nil
+	return &PostgresDB{db: db, returnsInserted: true}, nil
 }
 
 // GetDB returns the underlying *sql.DB connection.
@@ -42,50 +54,87 @@ func (p *PostgresDB) Close() error {
 }
 
 // GetSchemaInfo retrieves schema information for a given schema name from PostgreSQL.
-func (p *PostgresDB) GetSchemaInfo(schemaName string) (map[string]DBInfo, error) {
+// Tables in other schemas that foreign keys reference are included as "schema.table".
+func (p *PostgresDB) GetSchemaInfo(ctx context.Context, schemaName string) (map[string]DBInfo, error) {
 	log.Printf("Retrieving schema for '%s' from PostgreSQL.\n", schemaName)
+	p.schemaName = schemaName
 
-	tables, err := p.getTableNames(schemaName)
+	tables, err := p.getTableNames(ctx, schemaName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get table names from schema '%s': %w", schemaName, err)
 	}
 
 	schemaInfo := make(map[string]DBInfo)
 	for _, tableName := range tables {
-		columns, err := p.getColumnInfo(tableName)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get column info for table %s: %w", tableName, err)
-		}
-		primaryKeys, err := p.getPrimaryKeyColumns(tableName)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get primary key info for table %s: %w", tableName, err)
-		}
-		uniqueKeys, err := p.getUniqueKeyColumns(tableName)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get unique key info for table %s: %w", tableName, err)
+		if isImportRunsTable(tableName) {
+			continue
 		}
-		foreignKeys, err := p.getForeignKeyInfo(tableName)
+		dbInfo, err := p.describeTable(ctx, schemaName, schemaName, tableName)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get foreign key info for table %s: %w", tableName, err)
-		}
-
-		schemaInfo[tableName] = DBInfo{
-			TableName:         tableName,
-			Columns:           columns,
-			PrimaryKeyColumns: primaryKeys,
-			UniqueKeyColumns:  uniqueKeys,
-			ForeignKeys:       foreignKeys,
+			return nil, err
 		}
+		schemaInfo[tableName] = dbInfo
 	}
 
+	describe := func(ctx context.Context, tableSchema, tableName string) (DBInfo, error) {
+		return p.describeTable(ctx, schemaName, tableSchema, tableName)
+	}
+	if err := addForeignSchemaTables(ctx, schemaInfo, describe); err != nil {
+		return nil, err
+	}
 	return schemaInfo, nil
 }
 
-func (p *PostgresDB) getTableNames(schemaName string) ([]string, error) {
-	rows, err := p.db.Query(`
-		SELECT table_name
-		FROM information_schema.tables
-		WHERE table_schema = $1 AND table_type = 'BASE TABLE';
+// describeTable introspects one table of tableSchema. mainSchema is the schema being imported and
+// decides which table names are qualified.
+func (p *PostgresDB) describeTable(ctx context.Context, mainSchema, tableSchema, tableName string) (DBInfo, error) {
+	qualifiedName := qualifiedTableName(mainSchema, tableSchema, tableName)
+	columns, err := p.getColumnInfo(ctx, tableSchema, tableName)
+	if err != nil {
+		return DBInfo{}, fmt.Errorf("failed to get column info for table %s: %w", qualifiedName, err)
+	}
+	primaryKeys, err := p.getPrimaryKeyColumns(ctx, tableSchema, tableName)
+	if err != nil {
+		return DBInfo{}, fmt.Errorf("failed to get primary key info for table %s: %w", qualifiedName, err)
+	}
+	uniqueKeys, err := p.getUniqueKeyColumns(ctx, tableSchema, tableName)
+	if err != nil {
+		return DBInfo{}, fmt.Errorf("failed to get unique key info for table %s: %w", qualifiedName, err)
+	}
+	foreignKeys, err := p.getForeignKeyInfo(ctx, mainSchema, tableSchema, tableName)
+	if err != nil {
+		return DBInfo{}, fmt.Errorf("failed to get foreign key info for table %s: %w", qualifiedName, err)
+	}
+	inheritsFrom, err := p.getInheritedTables(ctx, mainSchema, tableSchema, tableName)
+	if err != nil {
+		return DBInfo{}, fmt.Errorf("failed to get inheritance info for table %s: %w", qualifiedName, err)
+	}
+	partitioned, hasInheritingTables, err := p.getTableKind(ctx, tableSchema, tableName)
+	if err != nil {
+		return DBInfo{}, fmt.Errorf("failed to get inheritance info for table %s: %w", qualifiedName, err)
+	}
+
+	return DBInfo{
+		TableName:           qualifiedName,
+		Columns:             columns,
+		PrimaryKeyColumns:   primaryKeys,
+		UniqueKeyColumns:    uniqueKeys,
+		ForeignKeys:         foreignKeys,
+		InheritsFrom:        inheritsFrom,
+		Partitioned:         partitioned,
+		HasInheritingTables: hasInheritingTables,
+	}, nil
+}
+
+func (p *PostgresDB) getTableNames(ctx context.Context, schemaName string) ([]string, error) {
+	// Partitioned tables ('p') are imported through the parent, which routes each row to its
+	// partition. The partitions themselves are left out so they are not imported on their own.
+	// Views ('v') and materialized views ('m') cannot be import targets and are skipped.
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT c.relname
+		FROM pg_class AS c
+		JOIN pg_namespace AS n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND c.relkind IN ('r', 'p') AND NOT c.relispartition;
 	`, schemaName)
 	if err != nil {
 		return nil, fmt.Errorf("query failed for schema '%s': %w", schemaName, err)
@@ -103,13 +152,42 @@ func (p *PostgresDB) getTableNames(schemaName string) ([]string, error) {
 	return tables, nil
 }
 
-func (p *PostgresDB) getColumnInfo(tableName string) ([]ColumnInfo, error) {
-	rows, err := p.db.Query(`
-		SELECT column_name, data_type, is_nullable, column_default
-		FROM information_schema.columns
-		WHERE table_name = $1
-		ORDER BY ordinal_position;
-	`, tableName)
+// pgColumnsQuery reads the columns of a table from information_schema.columns.
+const pgColumnsQuery = `
+	SELECT column_name, data_type, is_nullable, column_default, is_identity,
+		COALESCE(identity_generation, ''), is_generated,
+		COALESCE(col_description(format('%I.%I', table_schema, table_name)::regclass, ordinal_position::int), '')
+	FROM information_schema.columns
+	WHERE table_schema = $1 AND table_name = $2
+	ORDER BY ordinal_position;
+`
+
+// pgCatalogColumnsQuery reads the same as pgColumnsQuery from pg_attribute. attgenerated is read
+// through to_jsonb since releases based on PostgreSQL 11 do not have it.
+const pgCatalogColumnsQuery = `
+	SELECT a.attname,
+		format_type(CASE WHEN t.typtype = 'd' THEN t.typbasetype ELSE a.atttypid END, NULL),
+		CASE WHEN a.attnotnull THEN 'NO' ELSE 'YES' END,
+		CASE WHEN COALESCE(to_jsonb(a) ->> 'attgenerated', '') = 's' THEN NULL ELSE pg_get_expr(d.adbin, d.adrelid) END,
+		CASE WHEN a.attidentity IN ('a', 'd') THEN 'YES' ELSE 'NO' END,
+		CASE a.attidentity WHEN 'a' THEN 'ALWAYS' WHEN 'd' THEN 'BY DEFAULT' ELSE '' END,
+		CASE WHEN COALESCE(to_jsonb(a) ->> 'attgenerated', '') = 's' THEN 'ALWAYS' ELSE 'NEVER' END,
+		COALESCE(col_description(a.attrelid, a.attnum), '')
+	FROM pg_attribute AS a
+	JOIN pg_class AS c ON c.oid = a.attrelid
+	JOIN pg_namespace AS n ON n.oid = c.relnamespace
+	JOIN pg_type AS t ON t.oid = a.atttypid
+	LEFT JOIN pg_attrdef AS d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+	WHERE n.nspname = $1 AND c.relname = $2 AND a.attnum > 0 AND NOT a.attisdropped
+	ORDER BY a.attnum;
+`
+
+func (p *PostgresDB) getColumnInfo(ctx context.Context, schemaName, tableName string) ([]ColumnInfo, error) {
+	query := pgColumnsQuery
+	if p.catalogColumns {
+		query = pgCatalogColumnsQuery
+	}
+	rows, err := p.db.QueryContext(ctx, query, schemaName, tableName)
 	if err != nil {
 		return nil, fmt.Errorf("query failed for table %s: %w", tableName, err)
 	}
@@ -117,29 +195,35 @@ func (p *PostgresDB) getColumnInfo(tableName string) ([]ColumnInfo, error) {
 
 	var columns []ColumnInfo
 	for rows.Next() {
-		var colName, dataType, isNullableStr string
+		var colName, dataType, isNullableStr, isIdentityStr, identityGeneration, isGeneratedStr, comment string
 		var colDefault sql.NullString
-		if err := rows.Scan(&colName, &dataType, &isNullableStr, &colDefault); err != nil {
+		if err := rows.Scan(&colName, &dataType, &isNullableStr, &colDefault, &isIdentityStr, &identityGeneration, &isGeneratedStr, &comment); err != nil {
 			return nil, fmt.Errorf("scan failed: %w", err)
 		}
 		isNullable := (isNullableStr == "YES")
+		// serial/bigserial columns default to nextval(...); identity columns are flagged separately
+		isAutoIncrement := isIdentityStr == "YES" || (colDefault.Valid && strings.HasPrefix(colDefault.String, "nextval("))
 		columns = append(columns, ColumnInfo{
-			ColumnName:    colName,
-			DataType:      ParseDataType(dataType),
-			IsNullable:    isNullable,
-			ColumnDefault: colDefault,
+			ColumnName:      colName,
+			DataType:        ParseDataType(dataType),
+			IsNullable:      isNullable,
+			ColumnDefault:   colDefault,
+			IsAutoIncrement: isAutoIncrement,
+			// Stored generated columns and GENERATED ALWAYS AS IDENTITY reject explicit values
+			IsGenerated: isGeneratedStr == "ALWAYS" || identityGeneration == "ALWAYS",
+			Comment:     comment,
 		})
 	}
 	return columns, nil
 }
 
-func (p *PostgresDB) getPrimaryKeyColumns(tableName string) ([]string, error) {
-	rows, err := p.db.Query(`
+func (p *PostgresDB) getPrimaryKeyColumns(ctx context.Context, schemaName, tableName string) ([]string, error) {
+	rows, err := p.db.QueryContext(ctx, `
 		SELECT a.attname
 		FROM pg_index i
 		JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey)
-		WHERE i.indrelid = $1::regclass AND i.indisprimary;
-	`, tableName)
+		WHERE i.indrelid = format('%I.%I', $1::text, $2::text)::regclass AND i.indisprimary;
+	`, schemaName, tableName)
 	if err != nil {
 		return nil, fmt.Errorf("query failed: %w", err)
 	}
@@ -156,8 +240,8 @@ func (p *PostgresDB) getPrimaryKeyColumns(tableName string) ([]string, error) {
 	return pks, nil
 }
 
-func (p *PostgresDB) getUniqueKeyColumns(tableName string) ([][]string, error) {
-	rows, err := p.db.Query(`
+func (p *PostgresDB) getUniqueKeyColumns(ctx context.Context, schemaName, tableName string) ([][]string, error) {
+	rows, err := p.db.QueryContext(ctx, `
 		SELECT
 			array_agg(a.attname ORDER BY array_position(i.indkey, a.attnum)) AS unique_columns
 		FROM
@@ -165,12 +249,12 @@ func (p *PostgresDB) getUniqueKeyColumns(tableName string) ([][]string, error) {
 		JOIN
 			pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey)
 		WHERE
-			i.indrelid = $1::regclass
+			i.indrelid = format('%I.%I', $1::text, $2::text)::regclass
 			AND i.indisunique
 			AND NOT i.indisprimary -- Exclude primary keys, as they are already unique
 		GROUP BY
 			i.indexrelid;
-	`, tableName)
+	`, schemaName, tableName)
 	if err != nil {
 		return nil, fmt.Errorf("query failed: %w", err)
 	}
@@ -187,26 +271,37 @@ func (p *PostgresDB) getUniqueKeyColumns(tableName string) ([][]string, error) {
 	return uks, nil
 }
 
-func (p *PostgresDB) getForeignKeyInfo(tableName string) ([]ForeignKeyInfo, error) {
-	rows, err := p.db.Query(`
+func (p *PostgresDB) getForeignKeyInfo(ctx context.Context, mainSchema, schemaName, tableName string) ([]ForeignKeyInfo, error) {
+	// information_schema does not pair up the columns of composite keys, so read pg_constraint directly
+	rows, err := p.db.QueryContext(ctx, `
 		SELECT
-			tc.constraint_name,
-			kcu.column_name,
-			ccu.table_name AS foreign_table_name,
-			ccu.column_name AS foreign_column_name
+			con.conname AS constraint_name,
+			att.attname AS column_name,
+			fns.nspname AS foreign_table_schema,
+			ftbl.relname AS foreign_table_name,
+			fatt.attname AS foreign_column_name
 		FROM
-			information_schema.table_constraints AS tc
+			pg_constraint AS con
+		JOIN
+			pg_class AS tbl ON tbl.oid = con.conrelid
+		JOIN
+			pg_namespace AS ns ON ns.oid = tbl.relnamespace
+		JOIN
+			pg_class AS ftbl ON ftbl.oid = con.confrelid
 		JOIN
-			information_schema.key_column_usage AS kcu
-			ON tc.constraint_name = kcu.constraint_name
-			AND tc.table_schema = kcu.table_schema
+			pg_namespace AS fns ON fns.oid = ftbl.relnamespace
+		CROSS JOIN LATERAL
+			unnest(con.conkey, con.confkey) WITH ORDINALITY AS k(attnum, fattnum, ord)
 		JOIN
-			information_schema.constraint_column_usage AS ccu
-			ON ccu.constraint_name = tc.constraint_name
-			AND ccu.table_schema = tc.table_schema
+			pg_attribute AS att ON att.attrelid = con.conrelid AND att.attnum = k.attnum
+		JOIN
+			pg_attribute AS fatt ON fatt.attrelid = con.confrelid AND fatt.attnum = k.fattnum
 		WHERE
-			tc.constraint_type = 'FOREIGN KEY' AND tc.table_name = $1;
-	`, tableName)
+			con.contype = 'f' AND ns.nspname = $1 AND tbl.relname = $2
+			AND con.conparentid = 0 -- Skip the copies made for each partition of a referenced partitioned table
+		ORDER BY
+			con.conname, k.ord;
+	`, schemaName, tableName)
 	if err != nil {
 		return nil, fmt.Errorf("query failed: %w", err)
 	}
@@ -214,120 +309,341 @@ func (p *PostgresDB) getForeignKeyInfo(tableName string) ([]ForeignKeyInfo, erro
 
 	var fks []ForeignKeyInfo
 	for rows.Next() {
-		var fk ForeignKeyInfo
-		fk.TableName = tableName // Set the current table name
-		if err := rows.Scan(&fk.ConstraintName, &fk.ColumnName, &fk.ForeignTableName, &fk.ForeignColumnName); err != nil {
+		var constraintName, columnName, foreignTableSchema, foreignTableName, foreignColumnName string
+		if err := rows.Scan(&constraintName, &columnName, &foreignTableSchema, &foreignTableName, &foreignColumnName); err != nil {
 			return nil, fmt.Errorf("scan failed: %w", err)
 		}
+		fks = appendForeignKeyColumn(fks, qualifiedTableName(mainSchema, schemaName, tableName), constraintName, columnName,
+			qualifiedTableName(mainSchema, foreignTableSchema, foreignTableName), foreignColumnName)
+	}
+	for _, fk := range fks {
 		log.Printf("DEBUG: Found foreign key: %+v\n", fk) // Add debug log
-		fks = append(fks, fk)
 	}
 	return fks, nil
 }
 
-// PrepareInsertStatement prepares an INSERT statement for PostgreSQL.
-func (p *PostgresDB) PrepareInsertStatement(dbInfo DBInfo) (*sql.Stmt, error) {
+// getTableKind reports whether a table is partitioned, and whether other tables inherit from it
+// with INHERITS. The partitions of a partitioned table are in pg_inherits too, so they are only
+// counted as inheriting tables of an ordinary table.
+func (p *PostgresDB) getTableKind(ctx context.Context, schemaName, tableName string) (partitioned, hasInheritingTables bool, err error) {
+	err = p.db.QueryRowContext(ctx, `
+		SELECT c.relkind = 'p',
+			c.relkind = 'r' AND EXISTS(SELECT 1 FROM pg_inherits AS inh WHERE inh.inhparent = c.oid)
+		FROM pg_class AS c
+		JOIN pg_namespace AS n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND c.relname = $2;
+	`, schemaName, tableName).Scan(&partitioned, &hasInheritingTables)
+	if err != nil {
+		return false, false, fmt.Errorf("query failed: %w", err)
+	}
+	return partitioned, hasInheritingTables, nil
+}
+
+// getInheritedTables returns the parents of a table created with INHERITS. Partitions are
+// excluded from the table list, so their partitioned parents are not reported.
+func (p *PostgresDB) getInheritedTables(ctx context.Context, mainSchema, schemaName, tableName string) ([]string, error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT pns.nspname, par.relname
+		FROM pg_inherits AS inh
+		JOIN pg_class AS tbl ON tbl.oid = inh.inhrelid
+		JOIN pg_namespace AS ns ON ns.oid = tbl.relnamespace
+		JOIN pg_class AS par ON par.oid = inh.inhparent
+		JOIN pg_namespace AS pns ON pns.oid = par.relnamespace
+		WHERE ns.nspname = $1 AND tbl.relname = $2 AND par.relkind = 'r'
+		ORDER BY inh.inhseqno;
+	`, schemaName, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var parents []string
+	for rows.Next() {
+		var parentSchema, parentName string
+		if err := rows.Scan(&parentSchema, &parentName); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		parents = append(parents, qualifiedTableName(mainSchema, parentSchema, parentName))
+	}
+	return parents, nil
+}
+
+// quoteTable returns the schema-qualified, quoted name of a table for use in SQL. Names already
+// qualified as "schema.table" keep their own schema. Without a known schema the name is left as is,
+// e.g. when the schema information was not read from this connection.
+func (p *PostgresDB) quoteTable(tableName string) string {
+	schemaName := p.schemaName
+	if s, t, ok := strings.Cut(tableName, "."); ok {
+		schemaName, tableName = s, t
+	}
+	if schemaName == "" {
+		return tableName
+	}
+	return pq.QuoteIdentifier(schemaName) + "." + pq.QuoteIdentifier(tableName)
+}
+
+// postgresInsertStatement builds the statement that imports a row into table in mode. In
+// InsertUpsert, it updates the row with the same primary key, or does nothing if only key columns
+// are inserted; in InsertSkip, it does nothing on any conflict. Tables without a primary key, and
+// those of InsertOnly, get a plain INSERT. If returnsInserted is set, the statement returns
+// whether it inserted the row (see execReturningRow).
+func postgresInsertStatement(table string, dbInfo DBInfo, mode InsertMode, returnsInserted bool) string {
+	stmt := postgresModeInsert(table, dbInfo, mode)
+	if returnsInserted {
+		// xmax is 0 for a new row version unless ON CONFLICT DO UPDATE locked and replaced an existing row
+		stmt += " RETURNING (xmax = 0)"
+	}
+	return stmt
+}
+
+// postgresModeInsert builds the statement of postgresInsertStatement without its RETURNING clause.
+func postgresModeInsert(table string, dbInfo DBInfo, mode InsertMode) string {
 	var cols []string
 	var placeholders []string
-	for i, colInfo := range dbInfo.Columns {
+	for i, colInfo := range dbInfo.InsertColumns() {
 		cols = append(cols, colInfo.ColumnName)
 		placeholders = append(placeholders, fmt.Sprintf("$%d", i+1))
 	}
+	insert := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(cols, ", "), strings.Join(placeholders, ", "))
+
+	switch {
+	case mode == InsertOnly:
+		return insert
+	case mode == InsertSkip:
+		return insert + " ON CONFLICT DO NOTHING"
+	case len(dbInfo.PrimaryKeyColumns) == 0:
+		return insert
+	}
 
 	pkMap := make(map[string]bool)
 	for _, pkCol := range dbInfo.PrimaryKeyColumns {
 		pkMap[pkCol] = true
 	}
-
-	var query string
-	if len(dbInfo.PrimaryKeyColumns) > 0 {
-		var updateClauses []string
-		for _, colInfo := range dbInfo.Columns {
-			if !pkMap[colInfo.ColumnName] {
-				updateClauses = append(updateClauses, fmt.Sprintf("%s = EXCLUDED.%s", colInfo.ColumnName, colInfo.ColumnName))
-			}
-		}
-
-		if len(updateClauses) > 0 {
-			query = fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (%s) DO UPDATE SET %s",
-				dbInfo.TableName,
-				strings.Join(cols, ", "),
-				strings.Join(placeholders, ", "),
-				strings.Join(dbInfo.PrimaryKeyColumns, ", "),
-				strings.Join(updateClauses, ", "),
-			)
-		} else {
-			query = fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (%s) DO NOTHING",
-				dbInfo.TableName,
-				strings.Join(cols, ", "),
-				strings.Join(placeholders, ", "),
-				strings.Join(dbInfo.PrimaryKeyColumns, ", "),
-			)
+	var updateClauses []string
+	for _, colInfo := range dbInfo.InsertColumns() {
+		if !pkMap[colInfo.ColumnName] {
+			updateClauses = append(updateClauses, fmt.Sprintf("%s = EXCLUDED.%s", colInfo.ColumnName, colInfo.ColumnName))
 		}
-	} else {
-		query = fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
-			dbInfo.TableName,
-			strings.Join(cols, ", "),
-			strings.Join(placeholders, ", "),
-		)
 	}
+	if len(updateClauses) == 0 {
+		return fmt.Sprintf("%s ON CONFLICT (%s) DO NOTHING", insert, strings.Join(dbInfo.PrimaryKeyColumns, ", "))
+	}
+	return fmt.Sprintf("%s ON CONFLICT (%s) DO UPDATE SET %s", insert, strings.Join(dbInfo.PrimaryKeyColumns, ", "), strings.Join(updateClauses, ", "))
+}
 
-	stmt, err := p.db.Prepare(query)
+// PrepareInsertStatement prepares an INSERT statement for PostgreSQL.
+func (p *PostgresDB) PrepareInsertStatement(ctx context.Context, dbInfo DBInfo) (*sql.Stmt, error) {
+	return p.PrepareModeInsertStatement(ctx, dbInfo, InsertUpsert)
+}
+
+// PrepareModeInsertStatement prepares an INSERT statement of mode for PostgreSQL.
+func (p *PostgresDB) PrepareModeInsertStatement(ctx context.Context, dbInfo DBInfo, mode InsertMode) (*sql.Stmt, error) {
+	stmt, err := p.db.PrepareContext(ctx, postgresInsertStatement(p.quoteTable(dbInfo.TableName), dbInfo, mode, p.returnsInserted))
 	if err != nil {
 		return nil, fmt.Errorf("failed to prepare statement: %w", err)
 	}
 	return stmt, nil
 }
 
-// ParentRecordExists checks if a record exists in the given table for a specific column and value in PostgreSQL.
-func (p *PostgresDB) ParentRecordExists(dbInfo DBInfo, columnName, value string) (bool, error) {
-	query := fmt.Sprintf("SELECT EXISTS(SELECT 1 FROM %s WHERE %s = $1)", dbInfo.TableName, columnName)
+// ExecBatch executes the inserts of several rows in a transaction, retrying the rows that fail on
+// their own so that they do not fail the others; see BatchExecutor.
+func (p *PostgresDB) ExecBatch(ctx context.Context, stmt *sql.Stmt, args [][]interface{}, rowContext RowContextFunc) ([]BatchResult, error) {
+	return execBatch(ctx, p.db, stmt, args, rowContext, p.execRow)
+}
+
+// ExecInsert executes the insert of a row, telling an updated row from an inserted one on
+// PostgreSQL; see InsertExecutor.
+func (p *PostgresDB) ExecInsert(ctx context.Context, stmt *sql.Stmt, args []interface{}) BatchResult {
+	return p.execRow(ctx, stmt, args)
+}
+
+// execRow executes an insert statement of the client with the values of a single row.
+func (p *PostgresDB) execRow(ctx context.Context, stmt *sql.Stmt, args []interface{}) BatchResult {
+	if p.returnsInserted {
+		return execReturningRow(ctx, stmt, args)
+	}
+	return execRow(ctx, stmt, args)
+}
+
+// onlyTable returns the name of a table in SQL, after ONLY if other tables inherit from it, so that
+// their rows are left out. A partitioned table holds no rows of its own, so it is never given ONLY.
+func (p *PostgresDB) onlyTable(dbInfo DBInfo) string {
+	if dbInfo.HasInheritingTables {
+		return "ONLY " + p.quoteTable(dbInfo.TableName)
+	}
+	return p.quoteTable(dbInfo.TableName)
+}
+
+// ParentRecordExists checks if a record exists in the given table for specific column values in PostgreSQL.
+func (p *PostgresDB) ParentRecordExists(ctx context.Context, dbInfo DBInfo, columnNames, values []string) (bool, error) {
+	conditions := make([]string, len(columnNames))
+	args := make([]interface{}, len(values))
+	for i, colName := range columnNames {
+		conditions[i] = fmt.Sprintf("%s = $%d", colName, i+1)
+		args[i] = values[i]
+	}
+	// ONLY leaves out the rows of inheriting tables, which a foreign key to this table does not accept either.
+	// A partitioned table has no rows of its own, so the rows of its partitions are searched.
+	query := fmt.Sprintf("SELECT EXISTS(SELECT 1 FROM %s WHERE %s)", p.onlyTable(dbInfo), strings.Join(conditions, " AND "))
 	var exists bool
-	err := p.db.QueryRow(query, value).Scan(&exists)
+	err := p.db.QueryRowContext(ctx, query, args...).Scan(&exists)
 	if err != nil {
-		return false, fmt.Errorf("failed to check existence of record in %s for %s=%s: %w", dbInfo.TableName, columnName, value, err)
+		return false, fmt.Errorf("failed to check existence of record %s: %w", formatParentKey(dbInfo.TableName, columnNames, values), err)
 	}
 	return exists, nil
 }
 
-// EnsureParentRecordExists checks if a record with the given foreignKeyValue exists in the parent table.
-// If not, it creates a new record in the parent table with default values and the provided foreignKeyValue
-// for the foreignColumnName. This implementation is specific to PostgreSQL.
-func (p *PostgresDB) EnsureParentRecordExists(parentDBInfo DBInfo, foreignColumnName, foreignKeyValue string, dbSchema map[string]DBInfo) error {
-	// Check if the parent record already exists
-	exists, err := p.ParentRecordExists(parentDBInfo, foreignColumnName, foreignKeyValue)
-	if err != nil {
-		return fmt.Errorf("failed to check parent record existence: %w", err)
-	}
-	if exists {
-		return nil // Parent record already exists
+// UpdateRow sets columns of an existing row. For a table other tables inherit from, ONLY keeps their rows
+// with the same key unchanged.
+func (p *PostgresDB) UpdateRow(ctx context.Context, dbInfo DBInfo, keyColumns, keyValues, columnNames, values []string) error {
+	query := updateStatement(p.onlyTable(dbInfo), keyColumns, columnNames, func(n int) string { return fmt.Sprintf("$%d", n) })
+	if _, err := p.db.ExecContext(ctx, query, updateArgs(keyValues, values)...); err != nil {
+		return fmt.Errorf("failed to update record %s: %w", formatParentKey(dbInfo.TableName, keyColumns, keyValues), err)
 	}
+	return nil
+}
+
+// ClearColumns sets columns of the rows matching filter to NULL. For a table other tables inherit from,
+// ONLY keeps their rows unchanged.
+func (p *PostgresDB) ClearColumns(ctx context.Context, dbInfo DBInfo, columnNames []string, filter RowFilter) error {
+	return clearColumns(ctx, p.db, p.onlyTable(dbInfo), dbInfo, columnNames, filter, func(n int) string { return fmt.Sprintf("$%d", n) }, 0)
+}
 
-	// Parent record does not exist, create it
-	log.Printf("Creating missing parent record in table '%s' for column '%s' with value '%s'\n", parentDBInfo.TableName, foreignColumnName, foreignKeyValue)
+// DeleteRows deletes the rows of a table matching filter. For a table other tables inherit from, ONLY
+// keeps their rows, which are deleted with their own tables.
+func (p *PostgresDB) DeleteRows(ctx context.Context, dbInfo DBInfo, filter RowFilter) (int64, error) {
+	return deleteRows(ctx, p.db, p.onlyTable(dbInfo), dbInfo, filter, func(n int) string { return fmt.Sprintf("$%d", n) }, 0)
+}
 
-	parentCols, _, parentValues, err := ensureParentRecordExistsCommon(p, parentDBInfo, foreignColumnName, foreignKeyValue, dbSchema)
-	if err != nil {
-		return err
-	}
+// BeginRowDeletion starts a transaction clearing and deleting rows like ClearColumns and DeleteRows.
+func (p *PostgresDB) BeginRowDeletion(ctx context.Context) (RowDeletion, error) {
+	return beginRowDeletion(ctx, p.db, p.onlyTable, func(n int) string { return fmt.Sprintf("$%d", n) })
+}
+
+// EnsureParentRecordExists checks if a record with the given foreignKeyValues exists in the parent table.
+// If not, it creates a new record in the parent table with default values and the provided foreignKeyValues
+// for the foreignColumnNames. This implementation is specific to PostgreSQL.
+func (p *PostgresDB) EnsureParentRecordExists(ctx context.Context, parentDBInfo DBInfo, foreignColumnNames, foreignKeyValues []string, dbSchema map[string]DBInfo) ([]string, error) {
+	return ensureParentRecord(ctx, p, &p.parentRecordSettings, parentDBInfo, foreignColumnNames, foreignKeyValues, dbSchema, func(parentCols []string, parentValues []interface{}, returning string) (interface{}, bool, error) {
+		// Generate PostgreSQL-specific placeholders
+		parentPlaceholders := make([]string, len(parentCols))
+		for i := range parentCols {
+			parentPlaceholders[i] = fmt.Sprintf("$%d", i+1)
+		}
+
+		// ON CONFLICT DO NOTHING skips the row on any unique violation
+		insertQuery := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT DO NOTHING",
+			p.quoteTable(parentDBInfo.TableName),
+			strings.Join(parentCols, ", "),
+			strings.Join(parentPlaceholders, ", "),
+		)
+		if len(parentCols) == 0 {
+			insertQuery = fmt.Sprintf("INSERT INTO %s DEFAULT VALUES ON CONFLICT DO NOTHING", p.quoteTable(parentDBInfo.TableName))
+		}
+
+		if returning != "" {
+			var key interface{}
+			err := p.db.QueryRowContext(ctx, insertQuery+" RETURNING "+returning, parentValues...).Scan(&key)
+			if err == sql.ErrNoRows {
+				return nil, false, nil
+			}
+			if err != nil {
+				return nil, false, err
+			}
+			return key, true, nil
+		}
 
-	// Generate PostgreSQL-specific placeholders
-	parentPlaceholders := make([]string, len(parentCols))
-	for i := range parentCols {
-		parentPlaceholders[i] = fmt.Sprintf("$%d", i+1)
+		result, err := p.db.ExecContext(ctx, insertQuery, parentValues...)
+		if err != nil {
+			return nil, false, err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return nil, false, err
+		}
+		return nil, affected > 0, nil
+	})
+}
+
+// ResetSequences sets the sequence of each serial or identity column to the column's largest value.
+func (p *PostgresDB) ResetSequences(ctx context.Context, dbInfo DBInfo) error {
+	for _, colInfo := range dbInfo.Columns {
+		if !colInfo.IsAutoIncrement {
+			continue
+		}
+		// pg_get_serial_sequence is NULL for a nextval default on a sequence the column does not own,
+		// and setval then does nothing. An empty table has no maximum and is left alone too.
+		query := fmt.Sprintf("SELECT setval(pg_get_serial_sequence($1, $2), MAX(%s)) FROM %s HAVING MAX(%s) IS NOT NULL",
+			colInfo.ColumnName, p.quoteTable(dbInfo.TableName), colInfo.ColumnName)
+		if _, err := p.db.ExecContext(ctx, query, p.quoteTable(dbInfo.TableName), colInfo.ColumnName); err != nil {
+			return fmt.Errorf("failed to reset sequence of %s.%s: %w", dbInfo.TableName, colInfo.ColumnName, err)
+		}
 	}
+	return nil
+}
+
+// ReadRows reads the rows of a table matching filter. For a table other tables inherit from, ONLY leaves out
+// their rows, which are read with their own tables.
+func (p *PostgresDB) ReadRows(ctx context.Context, dbInfo DBInfo, columns []ColumnInfo, filter RowFilter, fn func(values []string) error) error {
+	return readFilteredRows(ctx, p.db, p.onlyTable(dbInfo), dbInfo, columns, filter, func(n int) string { return fmt.Sprintf("$%d", n) }, fn)
+}
 
-	insertQuery := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT DO NOTHING",
-		parentDBInfo.TableName,
-		strings.Join(parentCols, ", "),
-		strings.Join(parentPlaceholders, ", "),
-	)
-	// TODO: Consider UPSERT for parent record creation if primary key might conflict
+// CheckTableAccess verifies the privileges of the connection on a table.
+func (p *PostgresDB) CheckTableAccess(ctx context.Context, dbInfo DBInfo, privileges []string) error {
+	return checkTableAccess(ctx, p.db, p.quoteTable(dbInfo.TableName), dbInfo, privileges)
+}
+
+// RecordImportRun adds run to the _import_runs table of the schema.
+func (p *PostgresDB) RecordImportRun(ctx context.Context, run ImportRun) error {
+	table := p.quoteTable(ImportRunsTable)
+	if _, err := p.db.ExecContext(ctx, createImportRunsStatement("CREATE TABLE IF NOT EXISTS", table, "TEXT", "TIMESTAMPTZ")); err != nil {
+		return fmt.Errorf("failed to create %s: %w", ImportRunsTable, err)
+	}
+	query := insertImportRunStatement(table, func(n int) string { return fmt.Sprintf("$%d", n) })
+	if _, err := p.db.ExecContext(ctx, query, importRunArgs(run)...); err != nil {
+		return fmt.Errorf("failed to record import run %s: %w", run.RunID, err)
+	}
+	return nil
+}
 
-	_, err = p.db.Exec(insertQuery, parentValues...)
+// RefreshMaterializedViews refreshes the materialized views defined directly on the given tables.
+func (p *PostgresDB) RefreshMaterializedViews(ctx context.Context, tableNames []string) ([]string, error) {
+	relations := make([]string, len(tableNames))
+	for i, tableName := range tableNames {
+		relations[i] = p.quoteTable(tableName)
+	}
+	// A view's query is stored as a rewrite rule, which depends on every table the query reads
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT DISTINCT format('%I.%I', vns.nspname, v.relname)
+		FROM pg_depend AS d
+		JOIN pg_rewrite AS r ON r.oid = d.objid
+		JOIN pg_class AS v ON v.oid = r.ev_class
+		JOIN pg_namespace AS vns ON vns.oid = v.relnamespace
+		WHERE d.classid = 'pg_rewrite'::regclass AND d.refclassid = 'pg_class'::regclass
+			AND v.relkind = 'm' AND d.refobjid = ANY($1::regclass[])
+		ORDER BY 1;
+	`, pq.Array(relations))
 	if err != nil {
-		return fmt.Errorf("failed to insert parent record into %s: %w", parentDBInfo.TableName, err)
+		return nil, fmt.Errorf("failed to find materialized views: %w", err)
+	}
+	var views []string
+	for rows.Next() {
+		var view string
+		if err := rows.Scan(&view); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		views = append(views, view)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to find materialized views: %w", err)
 	}
 
-	return nil
+	for idx, view := range views {
+		if _, err := p.db.ExecContext(ctx, "REFRESH MATERIALIZED VIEW "+view); err != nil {
+			return views[:idx], fmt.Errorf("failed to refresh materialized view %s: %w", view, err)
+		}
+	}
+	return views, nil
 }
@@ -0,0 +1,350 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// catalogConnector answers the queries of a PostgresDB from canned rows, recording every
+// statement, so that the handling of catalog queries can be tested without a server.
+type catalogConnector struct {
+	// answer returns the columns and rows of a query.
+	answer func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error)
+	// exec, if set, returns the error of a statement.
+	exec    func(query string) error
+	queries []string
+	execs   []string
+}
+
+func (c *catalogConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return catalogConn{c}, nil
+}
+func (c *catalogConnector) Driver() driver.Driver { return nil }
+
+type catalogConn struct{ c *catalogConnector }
+
+func (conn catalogConn) Prepare(query string) (driver.Stmt, error) {
+	return catalogStmt{conn: conn, query: query}, nil
+}
+func (conn catalogConn) Close() error              { return nil }
+func (conn catalogConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+
+func (conn catalogConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	conn.c.queries = append(conn.c.queries, query)
+	columns, values, err := conn.c.answer(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &catalogRows{columns: columns, values: values}, nil
+}
+
+func (conn catalogConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	conn.c.execs = append(conn.c.execs, query)
+	if conn.c.exec != nil {
+		if err := conn.c.exec(query); err != nil {
+			return nil, err
+		}
+	}
+	return driver.RowsAffected(0), nil
+}
+
+// catalogStmt is a prepared statement of a catalogConn, answered like its unprepared queries.
+type catalogStmt struct {
+	conn  catalogConn
+	query string
+}
+
+func (s catalogStmt) Close() error  { return nil }
+func (s catalogStmt) NumInput() int { return -1 }
+func (s catalogStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, errors.New("not supported")
+}
+func (s catalogStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("not supported")
+}
+func (s catalogStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.conn.ExecContext(ctx, s.query, args)
+}
+func (s catalogStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.conn.QueryContext(ctx, s.query, args)
+}
+
+type catalogRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *catalogRows) Columns() []string { return r.columns }
+func (r *catalogRows) Close() error      { return nil }
+func (r *catalogRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+// newCatalogPostgresDB returns a PostgresDB of schema public whose queries c answers.
+func newCatalogPostgresDB(t *testing.T, c *catalogConnector) *PostgresDB {
+	db := sql.OpenDB(c)
+	t.Cleanup(func() { db.Close() })
+	return &PostgresDB{db: db, schemaName: "public"}
+}
+
+func Test_PostgresDB_quoteTable(t *testing.T) {
+	t.Run("GetSchemaInfo のスキーマで修飾されること", func(t *testing.T) {
+		p := &PostgresDB{schemaName: "sales"}
+		assert.Equal(t, `"sales"."orders"`, p.quoteTable("orders"))
+		assert.Equal(t, `"billing"."accounts"`, p.quoteTable("billing.accounts"))
+	})
+
+	t.Run("スキーマが不明な場合はそのままであること", func(t *testing.T) {
+		p := &PostgresDB{}
+		assert.Equal(t, "orders", p.quoteTable("orders"))
+	})
+}
+
+func Test_postgresInsertStatement(t *testing.T) {
+	columns := []ColumnInfo{{ColumnName: "id", DataType: IntegerType}, {ColumnName: "name", DataType: StringType}}
+	dbInfo := DBInfo{TableName: "users", Columns: columns, PrimaryKeyColumns: []string{"id"}}
+
+	t.Run("upsertでは主キーが重複した行を更新すること", func(t *testing.T) {
+		assert.Equal(t, `INSERT INTO "public"."users" (id, name) VALUES ($1, $2) ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name`,
+			postgresInsertStatement(`"public"."users"`, dbInfo, InsertUpsert, false))
+	})
+	t.Run("挿入したかどうかを返す場合はxmaxを返すこと", func(t *testing.T) {
+		assert.Equal(t, `INSERT INTO "public"."users" (id, name) VALUES ($1, $2) ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name RETURNING (xmax = 0)`,
+			postgresInsertStatement(`"public"."users"`, dbInfo, InsertUpsert, true))
+	})
+	t.Run("insertでは重複を扱わないこと", func(t *testing.T) {
+		assert.Equal(t, `INSERT INTO "public"."users" (id, name) VALUES ($1, $2)`, postgresInsertStatement(`"public"."users"`, dbInfo, InsertOnly, false))
+	})
+	t.Run("skipでは既存の行を残すこと", func(t *testing.T) {
+		assert.Equal(t, `INSERT INTO "public"."users" (id, name) VALUES ($1, $2) ON CONFLICT DO NOTHING`, postgresInsertStatement(`"public"."users"`, dbInfo, InsertSkip, false))
+	})
+}
+
+func Test_PostgresDB_ExecInsert(t *testing.T) {
+	tests := []struct {
+		name     string
+		rows     [][]driver.Value
+		affected int64
+	}{
+		{"挿入した行は1行と数えること", [][]driver.Value{{true}}, 1},
+		{"更新した行はUpsertUpdatedと数えること", [][]driver.Value{{false}}, UpsertUpdated},
+		{"何もしなかった行は0行と数えること", nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &catalogConnector{answer: func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+				return []string{"?column?"}, tt.rows, nil
+			}}
+			p := newCatalogPostgresDB(t, c)
+			p.returnsInserted = true
+			stmt, err := p.db.Prepare(postgresInsertStatement("users", DBInfo{TableName: "users", Columns: []ColumnInfo{{ColumnName: "id"}}}, InsertUpsert, true))
+			require.NoError(t, err)
+			defer stmt.Close()
+
+			result := p.ExecInsert(context.Background(), stmt, []interface{}{1})
+			require.NoError(t, result.Err)
+			assert.Equal(t, tt.affected, result.RowsAffected)
+		})
+	}
+}
+
+func Test_PostgresDB_partitionedTables(t *testing.T) {
+	t.Run("パーティションを除きパーティション親テーブルを含めること", func(t *testing.T) {
+		c := &catalogConnector{answer: func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+			assert.Equal(t, "sales", args[0].Value)
+			return []string{"relname"}, [][]driver.Value{{"measurements"}, {"users"}}, nil
+		}}
+		tables, err := newCatalogPostgresDB(t, c).getTableNames(context.Background(), "sales")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"measurements", "users"}, tables)
+		require.Len(t, c.queries, 1)
+		assert.Contains(t, c.queries[0], "c.relkind IN ('r', 'p')", "パーティション親テーブル ('p') が対象になること")
+		assert.Contains(t, c.queries[0], "NOT c.relispartition", "パーティションは個別にインポートされないこと")
+	})
+
+	t.Run("パーティション親テーブルへの外部キーはパーティションごとのコピーを除いて1つになること", func(t *testing.T) {
+		c := &catalogConnector{answer: func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+			return []string{"conname", "attname", "nspname", "relname", "attname"}, [][]driver.Value{
+				{"fk_measurement", "city_id", "public", "measurements", "city_id"},
+				{"fk_measurement", "logdate", "public", "measurements", "logdate"},
+				{"fk_station", "station_id", "metrics", "stations", "id"},
+			}, nil
+		}}
+		fks, err := newCatalogPostgresDB(t, c).getForeignKeyInfo(context.Background(), "public", "public", "readings")
+		require.NoError(t, err)
+		assert.Equal(t, []ForeignKeyInfo{
+			{ConstraintName: "fk_measurement", TableName: "readings", ColumnNames: []string{"city_id", "logdate"}, ForeignTableName: "measurements", ForeignColumnNames: []string{"city_id", "logdate"}},
+			{ConstraintName: "fk_station", TableName: "readings", ColumnNames: []string{"station_id"}, ForeignTableName: "metrics.stations", ForeignColumnNames: []string{"id"}},
+		}, fks)
+		require.Len(t, c.queries, 1)
+		assert.Contains(t, c.queries[0], "con.conparentid = 0")
+	})
+
+	t.Run("継承元はパーティション親テーブルを除き修飾されること", func(t *testing.T) {
+		c := &catalogConnector{answer: func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+			return []string{"nspname", "relname"}, [][]driver.Value{{"public", "vehicles"}, {"audit", "tracked"}}, nil
+		}}
+		parents, err := newCatalogPostgresDB(t, c).getInheritedTables(context.Background(), "public", "public", "cars")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"vehicles", "audit.tracked"}, parents)
+		require.Len(t, c.queries, 1)
+		assert.Contains(t, c.queries[0], "par.relkind = 'r'", "パーティションのパーティション親テーブルは継承元にならないこと")
+	})
+
+	t.Run("テーブルの種類がpg_classとpg_inheritsから読まれること", func(t *testing.T) {
+		c := &catalogConnector{answer: func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+			assert.Equal(t, "public", args[0].Value)
+			assert.Equal(t, "measurements", args[1].Value)
+			return []string{"partitioned", "inherited"}, [][]driver.Value{{true, false}}, nil
+		}}
+		partitioned, hasInheritingTables, err := newCatalogPostgresDB(t, c).getTableKind(context.Background(), "public", "measurements")
+		require.NoError(t, err)
+		assert.True(t, partitioned)
+		assert.False(t, hasInheritingTables)
+		require.Len(t, c.queries, 1)
+		assert.Contains(t, c.queries[0], "c.relkind = 'r' AND EXISTS(SELECT 1 FROM pg_inherits", "パーティションは継承するテーブルに数えられないこと")
+	})
+
+	t.Run("ONLYは継承元のテーブルにだけ付けられること", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			dbInfo DBInfo
+			table  string
+		}{
+			{"パーティション親テーブル", DBInfo{TableName: "measurements", Partitioned: true}, `"public"."measurements"`},
+			{"INHERITSの継承元", DBInfo{TableName: "vehicles", HasInheritingTables: true}, `ONLY "public"."vehicles"`},
+			{"通常のテーブル", DBInfo{TableName: "users"}, `"public"."users"`},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				ctx := context.Background()
+				c := &catalogConnector{answer: func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+					if strings.HasPrefix(query, "SELECT EXISTS") {
+						return []string{"exists"}, [][]driver.Value{{true}}, nil
+					}
+					return []string{"id"}, nil, nil
+				}}
+				p := newCatalogPostgresDB(t, c)
+				columns := []ColumnInfo{{ColumnName: "id", DataType: IntegerType}}
+
+				_, err := p.ParentRecordExists(ctx, tt.dbInfo, []string{"id"}, []string{"1"})
+				require.NoError(t, err)
+				require.NoError(t, p.ReadRows(ctx, tt.dbInfo, columns, RowFilter{}, func(values []string) error { return nil }))
+				require.NoError(t, p.UpdateRow(ctx, tt.dbInfo, []string{"id"}, []string{"1"}, []string{"name"}, []string{"a"}))
+				require.NoError(t, p.ClearColumns(ctx, tt.dbInfo, []string{"name"}, RowFilter{}))
+				_, err = p.DeleteRows(ctx, tt.dbInfo, RowFilter{})
+				require.NoError(t, err)
+
+				require.Len(t, c.queries, 2)
+				assert.Contains(t, c.queries[0], "FROM "+tt.table+" WHERE")
+				assert.Contains(t, c.queries[1], "FROM "+tt.table)
+				require.Len(t, c.execs, 3)
+				assert.True(t, strings.HasPrefix(c.execs[0], "UPDATE "+tt.table+" SET"), c.execs[0])
+				assert.True(t, strings.HasPrefix(c.execs[1], "UPDATE "+tt.table+" SET"), c.execs[1])
+				assert.True(t, strings.HasPrefix(c.execs[2], "DELETE FROM "+tt.table), c.execs[2])
+			})
+		}
+	})
+
+	t.Run("カタログの問い合わせのエラーが返されること", func(t *testing.T) {
+		c := &catalogConnector{answer: func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+			return nil, nil, errors.New("permission denied for table pg_class")
+		}}
+		_, err := newCatalogPostgresDB(t, c).getTableNames(context.Background(), "sales")
+		assert.EqualError(t, err, "query failed for schema 'sales': permission denied for table pg_class")
+	})
+}
+
+func Test_PostgresDB_RefreshMaterializedViews(t *testing.T) {
+	views := func(names ...string) func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		return func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+			rows := make([][]driver.Value, len(names))
+			for idx, name := range names {
+				rows[idx] = []driver.Value{name}
+			}
+			return []string{"format"}, rows, nil
+		}
+	}
+
+	t.Run("テーブルに依存するマテリアライズドビューが順にリフレッシュされること", func(t *testing.T) {
+		c := &catalogConnector{}
+		c.answer = func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+			assert.Equal(t, `{"\"public\".\"orders\"","\"sales\".\"items\""}`, args[0].Value)
+			return views("public.daily_sales", "sales.item_totals")(query, args)
+		}
+		refreshed, err := newCatalogPostgresDB(t, c).RefreshMaterializedViews(context.Background(), []string{"orders", "sales.items"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"public.daily_sales", "sales.item_totals"}, refreshed)
+		assert.Equal(t, []string{"REFRESH MATERIALIZED VIEW public.daily_sales", "REFRESH MATERIALIZED VIEW sales.item_totals"}, c.execs)
+		require.Len(t, c.queries, 1)
+		assert.Contains(t, c.queries[0], "v.relkind = 'm'", "通常のビューはリフレッシュしないこと")
+	})
+
+	t.Run("マテリアライズドビューがなければ何もしないこと", func(t *testing.T) {
+		c := &catalogConnector{answer: views()}
+		refreshed, err := newCatalogPostgresDB(t, c).RefreshMaterializedViews(context.Background(), []string{"orders"})
+		require.NoError(t, err)
+		assert.Empty(t, refreshed)
+		assert.Empty(t, c.execs)
+	})
+
+	t.Run("リフレッシュに失敗した場合はそれまでのビューとエラーが返されること", func(t *testing.T) {
+		c := &catalogConnector{answer: views("public.a", "public.b", "public.c")}
+		c.exec = func(query string) error {
+			if strings.HasSuffix(query, "public.b") {
+				return errors.New("permission denied")
+			}
+			return nil
+		}
+		refreshed, err := newCatalogPostgresDB(t, c).RefreshMaterializedViews(context.Background(), []string{"orders"})
+		assert.EqualError(t, err, "failed to refresh materialized view public.b: permission denied")
+		assert.Equal(t, []string{"public.a"}, refreshed)
+		assert.Len(t, c.execs, 2, "失敗したビューの後はリフレッシュしないこと")
+	})
+}
+
+func Test_PostgresDB_getTableNames(t *testing.T) {
+	// pgClass is a pg_class of every kind of relation, answered through the relkind filter of the query
+	pgClass := []struct {
+		name        string
+		relkind     string
+		ispartition bool
+	}{
+		{"users", "r", false},
+		{"active_users", "v", false},
+		{"daily_sales", "m", false},
+		{"measurements", "p", false},
+		{"measurements_2024", "r", true},
+		{"user_seq", "S", false},
+	}
+	relkinds := regexp.MustCompile(`relkind IN \(([^)]*)\)`)
+	c := &catalogConnector{answer: func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		match := relkinds.FindStringSubmatch(query)
+		require.NotNil(t, match)
+		var rows [][]driver.Value
+		for _, rel := range pgClass {
+			if strings.Contains(match[1], "'"+rel.relkind+"'") && !(rel.ispartition && strings.Contains(query, "NOT c.relispartition")) {
+				rows = append(rows, []driver.Value{rel.name})
+			}
+		}
+		return []string{"relname"}, rows, nil
+	}}
+
+	tables, err := newCatalogPostgresDB(t, c).getTableNames(context.Background(), "public")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"users", "measurements"}, tables, "ビューとマテリアライズドビューはインポート先にならないこと")
+}
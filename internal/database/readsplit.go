@@ -0,0 +1,182 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ReadSplitDB is a DBClient that sends the reads of an import, the schema introspection, the
+// parent record existence checks and the reads of rows, to a reader endpoint such as a read
+// replica of a cluster, and everything else to the writer, to reduce the load on the primary.
+//
+// A replica may lag behind the writer, so a parent record the reader does not find is looked up
+// again on the writer before it is reported missing; records it finds exist on the writer too.
+type ReadSplitDB struct {
+	DBClient // The writer
+	reader   DBClient
+}
+
+// NewReadSplitDB returns a client reading from reader and writing to writer. Both must be clients
+// of the same database type and see the same schema.
+func NewReadSplitDB(writer, reader DBClient) *ReadSplitDB {
+	return &ReadSplitDB{DBClient: writer, reader: reader}
+}
+
+func (r *ReadSplitDB) GetSchemaInfo(ctx context.Context, schemaName string) (map[string]DBInfo, error) {
+	return r.reader.GetSchemaInfo(ctx, schemaName)
+}
+
+func (r *ReadSplitDB) ParentRecordExists(ctx context.Context, dbInfo DBInfo, columnNames, values []string) (bool, error) {
+	exists, err := r.reader.ParentRecordExists(ctx, dbInfo, columnNames, values)
+	if err != nil || exists {
+		return exists, err
+	}
+	return r.DBClient.ParentRecordExists(ctx, dbInfo, columnNames, values)
+}
+
+func (r *ReadSplitDB) ReadRows(ctx context.Context, dbInfo DBInfo, columns []ColumnInfo, filter RowFilter, fn func(values []string) error) error {
+	reader, ok := r.reader.(RowReader)
+	if !ok {
+		return errors.New("reading rows is not supported by the database")
+	}
+	return reader.ReadRows(ctx, dbInfo, columns, filter, fn)
+}
+
+// The optional capabilities below are those of the writer.
+
+func (r *ReadSplitDB) SetValueGenerator(gen ValueGenerator) {
+	if configurer, ok := r.DBClient.(ParentConfigurer); ok {
+		configurer.SetValueGenerator(gen)
+	}
+}
+
+func (r *ReadSplitDB) SetParentTemplates(templates map[string]map[string]string) {
+	if configurer, ok := r.DBClient.(ParentConfigurer); ok {
+		configurer.SetParentTemplates(templates)
+	}
+}
+
+func (r *ReadSplitDB) SetBatchColumn(columnName, value string) {
+	if configurer, ok := r.DBClient.(ParentConfigurer); ok {
+		configurer.SetBatchColumn(columnName, value)
+	}
+}
+
+func (r *ReadSplitDB) SetParentCreatedFunc(fn func(tableName string, columnNames, key []string)) {
+	if configurer, ok := r.DBClient.(ParentConfigurer); ok {
+		configurer.SetParentCreatedFunc(fn)
+	}
+}
+
+func (r *ReadSplitDB) CreatedParents() map[string]int {
+	if configurer, ok := r.DBClient.(ParentConfigurer); ok {
+		return configurer.CreatedParents()
+	}
+	return nil
+}
+
+func (r *ReadSplitDB) UpdateRow(ctx context.Context, dbInfo DBInfo, keyColumns, keyValues, columnNames, values []string) error {
+	updater, ok := r.DBClient.(RowUpdater)
+	if !ok {
+		return errors.New("updating rows is not supported by the database")
+	}
+	return updater.UpdateRow(ctx, dbInfo, keyColumns, keyValues, columnNames, values)
+}
+
+func (r *ReadSplitDB) ClearColumns(ctx context.Context, dbInfo DBInfo, columnNames []string, filter RowFilter) error {
+	deleter, ok := r.DBClient.(RowDeleter)
+	if !ok {
+		return errors.New("deleting rows is not supported by the database")
+	}
+	return deleter.ClearColumns(ctx, dbInfo, columnNames, filter)
+}
+
+func (r *ReadSplitDB) DeleteRows(ctx context.Context, dbInfo DBInfo, filter RowFilter) (int64, error) {
+	deleter, ok := r.DBClient.(RowDeleter)
+	if !ok {
+		return 0, errors.New("deleting rows is not supported by the database")
+	}
+	return deleter.DeleteRows(ctx, dbInfo, filter)
+}
+
+func (r *ReadSplitDB) BeginRowDeletion(ctx context.Context) (RowDeletion, error) {
+	deleter, ok := r.DBClient.(TxRowDeleter)
+	if !ok {
+		return nil, fmt.Errorf("deleting rows in one transaction is not supported by the database: %w", errors.ErrUnsupported)
+	}
+	return deleter.BeginRowDeletion(ctx)
+}
+
+// ResetSequences resets the sequences of the writer, if it has any.
+func (r *ReadSplitDB) ResetSequences(ctx context.Context, dbInfo DBInfo) error {
+	if resetter, ok := r.DBClient.(SequenceResetter); ok {
+		return resetter.ResetSequences(ctx, dbInfo)
+	}
+	return nil
+}
+
+// RefreshMaterializedViews refreshes the materialized views of the writer, if it has any.
+func (r *ReadSplitDB) RefreshMaterializedViews(ctx context.Context, tableNames []string) ([]string, error) {
+	if refresher, ok := r.DBClient.(ViewRefresher); ok {
+		return refresher.RefreshMaterializedViews(ctx, tableNames)
+	}
+	return nil, nil
+}
+
+func (r *ReadSplitDB) CheckTableAccess(ctx context.Context, dbInfo DBInfo, privileges []string) error {
+	checker, ok := r.DBClient.(AccessChecker)
+	if !ok {
+		return errors.New("checking table access is not supported by the database")
+	}
+	return checker.CheckTableAccess(ctx, dbInfo, privileges)
+}
+
+func (r *ReadSplitDB) RecordImportRun(ctx context.Context, run ImportRun) error {
+	recorder, ok := r.DBClient.(RunRecorder)
+	if !ok {
+		return errors.New("recording import runs is not supported by the database")
+	}
+	return recorder.RecordImportRun(ctx, run)
+}
+
+// ExecBatch executes the batch on the writer, or row by row if the writer cannot execute batches.
+func (r *ReadSplitDB) ExecBatch(ctx context.Context, stmt *sql.Stmt, args [][]interface{}, rowContext RowContextFunc) ([]BatchResult, error) {
+	if batcher, ok := r.DBClient.(BatchExecutor); ok {
+		return batcher.ExecBatch(ctx, stmt, args, rowContext)
+	}
+	return execRows(ctx, stmt, args, rowContext)
+}
+
+// ExecInsert executes the insert of a row on the writer, through its ExecInsert if it has one.
+func (r *ReadSplitDB) ExecInsert(ctx context.Context, stmt *sql.Stmt, args []interface{}) BatchResult {
+	if executor, ok := r.DBClient.(InsertExecutor); ok {
+		return executor.ExecInsert(ctx, stmt, args)
+	}
+	return execRow(ctx, stmt, args)
+}
+
+// DefaultBatchSize returns the default batch size of the writer, or 0 if it has none.
+func (r *ReadSplitDB) DefaultBatchSize(dbInfo DBInfo) int {
+	if sizer, ok := r.DBClient.(BatchSizer); ok {
+		return sizer.DefaultBatchSize(dbInfo)
+	}
+	return 0
+}
+
+// PrepareModeInsertStatement prepares the statement on the writer, if it supports mode.
+func (r *ReadSplitDB) PrepareModeInsertStatement(ctx context.Context, dbInfo DBInfo, mode InsertMode) (*sql.Stmt, error) {
+	if inserter, ok := r.DBClient.(ModeInserter); ok {
+		return inserter.PrepareModeInsertStatement(ctx, dbInfo, mode)
+	}
+	if mode == InsertUpsert {
+		return r.DBClient.PrepareInsertStatement(ctx, dbInfo)
+	}
+	return nil, fmt.Errorf("import mode %s is not supported by the database", mode)
+}
+
+// Close closes the connections to both endpoints.
+func (r *ReadSplitDB) Close() error {
+	return errors.Join(r.DBClient.Close(), r.reader.Close())
+}
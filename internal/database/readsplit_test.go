@@ -0,0 +1,106 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEndpoint records the calls made to one endpoint of a ReadSplitDB. The methods it does not
+// implement panic through the nil DBClient.
+type fakeEndpoint struct {
+	DBClient
+	name    string
+	parents map[string]bool // Existing parent records, by key value
+	calls   *[]string
+	closed  bool
+}
+
+func (f *fakeEndpoint) GetSchemaInfo(ctx context.Context, schemaName string) (map[string]DBInfo, error) {
+	*f.calls = append(*f.calls, f.name+" GetSchemaInfo")
+	return map[string]DBInfo{"users": {TableName: "users"}}, nil
+}
+
+func (f *fakeEndpoint) ParentRecordExists(ctx context.Context, dbInfo DBInfo, columnNames, values []string) (bool, error) {
+	*f.calls = append(*f.calls, f.name+" ParentRecordExists "+values[0])
+	return f.parents[values[0]], nil
+}
+
+func (f *fakeEndpoint) ReadRows(ctx context.Context, dbInfo DBInfo, columns []ColumnInfo, filter RowFilter, fn func(values []string) error) error {
+	*f.calls = append(*f.calls, f.name+" ReadRows")
+	return fn([]string{"1"})
+}
+
+func (f *fakeEndpoint) DeleteRows(ctx context.Context, dbInfo DBInfo, filter RowFilter) (int64, error) {
+	*f.calls = append(*f.calls, f.name+" DeleteRows")
+	return 1, nil
+}
+
+func (f *fakeEndpoint) ClearColumns(ctx context.Context, dbInfo DBInfo, columnNames []string, filter RowFilter) error {
+	*f.calls = append(*f.calls, f.name+" ClearColumns")
+	return nil
+}
+
+func (f *fakeEndpoint) Close() error {
+	f.closed = true
+	return nil
+}
+
+func Test_ReadSplitDB(t *testing.T) {
+	ctx := context.Background()
+	users := DBInfo{TableName: "users"}
+
+	newClient := func() (*ReadSplitDB, *fakeEndpoint, *fakeEndpoint, *[]string) {
+		calls := &[]string{}
+		writer := &fakeEndpoint{name: "writer", parents: map[string]bool{"1": true, "2": true}, calls: calls}
+		reader := &fakeEndpoint{name: "reader", parents: map[string]bool{"1": true}, calls: calls}
+		return NewReadSplitDB(writer, reader), writer, reader, calls
+	}
+
+	t.Run("スキーマ情報と行の読み取りはリーダーに送られる", func(t *testing.T) {
+		client, _, _, calls := newClient()
+		schema, err := client.GetSchemaInfo(ctx, "public")
+		require.NoError(t, err)
+		assert.Contains(t, schema, "users")
+		require.NoError(t, client.ReadRows(ctx, users, nil, RowFilter{}, func(values []string) error { return nil }))
+		assert.Equal(t, []string{"reader GetSchemaInfo", "reader ReadRows"}, *calls)
+	})
+
+	t.Run("書き込みはライターに送られる", func(t *testing.T) {
+		client, _, _, calls := newClient()
+		_, err := client.DeleteRows(ctx, users, RowFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"writer DeleteRows"}, *calls)
+	})
+
+	t.Run("リーダーで見つかった親レコードはライターに問い合わせない", func(t *testing.T) {
+		client, _, _, calls := newClient()
+		exists, err := client.ParentRecordExists(ctx, users, []string{"id"}, []string{"1"})
+		require.NoError(t, err)
+		assert.True(t, exists)
+		assert.Equal(t, []string{"reader ParentRecordExists 1"}, *calls)
+	})
+
+	t.Run("リーダーで見つからない親レコードはライターで確認する", func(t *testing.T) {
+		client, _, _, calls := newClient()
+		exists, err := client.ParentRecordExists(ctx, users, []string{"id"}, []string{"2"})
+		require.NoError(t, err)
+		assert.True(t, exists, "レプリカの遅延で見つからないだけのレコード")
+		exists, err = client.ParentRecordExists(ctx, users, []string{"id"}, []string{"3"})
+		require.NoError(t, err)
+		assert.False(t, exists)
+		assert.Equal(t, []string{
+			"reader ParentRecordExists 2", "writer ParentRecordExists 2",
+			"reader ParentRecordExists 3", "writer ParentRecordExists 3",
+		}, *calls)
+	})
+
+	t.Run("Closeは両方の接続を閉じる", func(t *testing.T) {
+		client, writer, reader, _ := newClient()
+		require.NoError(t, client.Close())
+		assert.True(t, writer.closed)
+		assert.True(t, reader.closed)
+	})
+}
@@ -0,0 +1,170 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RowFilter narrows the rows ReadRows reads. The zero value reads every row.
+type RowFilter struct {
+	// Where is an SQL condition the rows must match, e.g. "id IN (1, 2)".
+	Where string
+	// KeyColumns and Keys, if set, limit the rows to those holding one of Keys in KeyColumns,
+	// e.g. the parent rows referenced by a foreign key. The values are in CSV string form.
+	KeyColumns []string
+	Keys       [][]string
+}
+
+// keyBatchSize is the number of keys of a RowFilter looked up per statement.
+const keyBatchSize = 100
+
+// selectRowsStatement builds the SELECT of columns from table (the dialect's name for it in SQL),
+// ordered by the primary key, if any, so that exports are reproducible. The rows are filtered by
+// filter.Where and keys, a batch of filter.Keys, numbering the placeholders with placeholder.
+func selectRowsStatement(table string, dbInfo DBInfo, columns []ColumnInfo, filter RowFilter, keys [][]string, placeholder func(n int) string) (string, []interface{}) {
+	names := make([]string, len(columns))
+	for idx, colInfo := range columns {
+		names[idx] = colInfo.ColumnName
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s", strings.Join(names, ", "), table)
+	where, args := filterCondition(dbInfo, filter, keys, placeholder)
+	query += where
+	if len(dbInfo.PrimaryKeyColumns) > 0 {
+		query += " ORDER BY " + strings.Join(dbInfo.PrimaryKeyColumns, ", ")
+	}
+	return query, args
+}
+
+// filterCondition builds the WHERE clause, with a leading space, of the rows matching filter.Where
+// and keys, a batch of filter.Keys. It is empty for the zero filter.
+func filterCondition(dbInfo DBInfo, filter RowFilter, keys [][]string, placeholder func(n int) string) (string, []interface{}) {
+	var conditions []string
+	if filter.Where != "" {
+		conditions = append(conditions, "("+filter.Where+")")
+	}
+	var args []interface{}
+	if len(filter.KeyColumns) > 0 {
+		keyColumns := make([]ColumnInfo, len(filter.KeyColumns))
+		for idx, colName := range filter.KeyColumns {
+			keyColumns[idx] = ColumnInfo{ColumnName: colName, DataType: StringType}
+			for _, colInfo := range dbInfo.Columns {
+				if colInfo.ColumnName == colName {
+					keyColumns[idx] = colInfo
+				}
+			}
+		}
+		matches := make([]string, len(keys))
+		for keyIdx, key := range keys {
+			parts := make([]string, len(keyColumns))
+			for idx, colInfo := range keyColumns {
+				args = append(args, keyArg(key[idx], colInfo))
+				parts[idx] = fmt.Sprintf("%s = %s", colInfo.ColumnName, placeholder(len(args)))
+			}
+			matches[keyIdx] = "(" + strings.Join(parts, " AND ") + ")"
+		}
+		conditions = append(conditions, "("+strings.Join(matches, " OR ")+")")
+	}
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// keyBatches calls fn with filter.Keys in batches of keyBatchSize, or once with no keys if the
+// filter has no KeyColumns.
+func keyBatches(filter RowFilter, fn func(keys [][]string) error) error {
+	if len(filter.KeyColumns) == 0 {
+		return fn(nil)
+	}
+	for start := 0; start < len(filter.Keys); start += keyBatchSize {
+		if err := fn(filter.Keys[start:min(start+keyBatchSize, len(filter.Keys))]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keyArg converts a key value to the type of its column, so that it compares as the column does.
+func keyArg(value string, colInfo ColumnInfo) interface{} {
+	converted, err := ConvertToDBType(value, colInfo.DataType, true, sql.NullString{})
+	if err != nil {
+		return value
+	}
+	return converted
+}
+
+// readFilteredRows reads the rows of table matching filter, looking up its keys in batches, and
+// calls fn with each row like readRows.
+func readFilteredRows(ctx context.Context, db *sql.DB, table string, dbInfo DBInfo, columns []ColumnInfo, filter RowFilter, placeholder func(n int) string, fn func(values []string) error) error {
+	return keyBatches(filter, func(keys [][]string) error {
+		query, args := selectRowsStatement(table, dbInfo, columns, filter, keys, placeholder)
+		return readRows(ctx, db, query, args, dbInfo.TableName, columns, fn)
+	})
+}
+
+// readRows runs query with args and calls fn with each row, its values in CSV string form (see
+// FormatColumnValue). An error returned by fn stops the reading and is returned.
+func readRows(ctx context.Context, db *sql.DB, query string, args []interface{}, tableName string, columns []ColumnInfo, fn func(values []string) error) error {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to read rows of table %s: %w", tableName, err)
+	}
+	defer rows.Close()
+
+	raw := make([]interface{}, len(columns))
+	dest := make([]interface{}, len(columns))
+	for idx := range raw {
+		dest[idx] = &raw[idx]
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return fmt.Errorf("failed to read row of table %s: %w", tableName, err)
+		}
+		values := make([]string, len(columns))
+		for idx, colInfo := range columns {
+			values[idx] = FormatColumnValue(raw[idx], colInfo)
+		}
+		if err := fn(values); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read rows of table %s: %w", tableName, err)
+	}
+	return nil
+}
+
+// FormatColumnValue converts a value read from a column into the CSV string form the importer
+// accepts for the column's type: dates as YYYY-MM-DD and timestamps in RFC 3339. NULL is empty.
+func FormatColumnValue(value interface{}, colInfo ColumnInfo) string {
+	if b, ok := value.([]byte); ok {
+		// Drivers return many types as text, e.g. MySQL without parseTime
+		value = string(b)
+	}
+	switch v := value.(type) {
+	case time.Time:
+		if colInfo.DataType == DateType {
+			return v.Format("2006-01-02")
+		}
+		return v.Format(time.RFC3339Nano)
+	case string:
+		if colInfo.DataType == BooleanType {
+			// MySQL stores booleans as TINYINT(1), DB2 may return them as text
+			if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+				return strconv.FormatBool(n != 0)
+			}
+		}
+		return v
+	case int64:
+		if colInfo.DataType == BooleanType {
+			return strconv.FormatBool(v != 0)
+		}
+		return strconv.FormatInt(v, 10)
+	default:
+		return FormatValue(v)
+	}
+}
@@ -0,0 +1,61 @@
+package database
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_selectRowsStatement(t *testing.T) {
+	columns := []ColumnInfo{{ColumnName: "id"}, {ColumnName: "name"}}
+	question := func(int) string { return "?" }
+
+	t.Run("主キーの順に読み込むこと", func(t *testing.T) {
+		dbInfo := DBInfo{TableName: "users", PrimaryKeyColumns: []string{"id"}}
+		query, args := selectRowsStatement("users", dbInfo, columns, RowFilter{}, nil, question)
+		assert.Equal(t, "SELECT id, name FROM users ORDER BY id", query)
+		assert.Empty(t, args)
+	})
+
+	t.Run("主キーがない場合は順序を指定しないこと", func(t *testing.T) {
+		query, _ := selectRowsStatement("logs", DBInfo{TableName: "logs"}, columns, RowFilter{}, nil, question)
+		assert.Equal(t, "SELECT id, name FROM logs", query)
+	})
+
+	t.Run("条件とキーで絞り込むこと", func(t *testing.T) {
+		dbInfo := DBInfo{
+			TableName:         "members",
+			Columns:           []ColumnInfo{{ColumnName: "org_id", DataType: IntegerType}, {ColumnName: "code", DataType: StringType}},
+			PrimaryKeyColumns: []string{"org_id", "code"},
+		}
+		filter := RowFilter{Where: "active = 1", KeyColumns: []string{"org_id", "code"}}
+		query, args := selectRowsStatement("members", dbInfo, columns, filter, [][]string{{"1", "a"}, {"2", "b"}}, func(n int) string { return fmt.Sprintf("$%d", n) })
+		assert.Equal(t, "SELECT id, name FROM members WHERE (active = 1) AND ((org_id = $1 AND code = $2) OR (org_id = $3 AND code = $4)) ORDER BY org_id, code", query)
+		assert.Equal(t, []interface{}{int64(1), "a", int64(2), "b"}, args)
+	})
+}
+
+func Test_FormatColumnValue(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 30, 0, 500000000, time.UTC)
+	tests := []struct {
+		name     string
+		value    interface{}
+		dataType ColumnDataType
+		want     string
+	}{
+		{"NULLは空文字列になること", nil, StringType, ""},
+		{"日付はYYYY-MM-DDになること", at, DateType, "2024-05-01"},
+		{"タイムスタンプはRFC 3339になること", at, TimestampType, "2024-05-01T12:30:00.5Z"},
+		{"バイト列は文字列になること", []byte("12.50"), FloatType, "12.50"},
+		{"整数で表された真偽値はtrue/falseになること", int64(1), BooleanType, "true"},
+		{"文字列で表された真偽値はtrue/falseになること", []byte("0"), BooleanType, "false"},
+		{"整数はそのまま出力されること", int64(42), IntegerType, "42"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, FormatColumnValue(tt.value, ColumnInfo{DataType: tt.dataType}))
+		})
+	}
+}
@@ -0,0 +1,291 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"regexp/syntax"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// GenerationRule declares how values for a single column are invented.
+// Exactly one of Pattern, Range (Min/Max), Choices or Sequence should be set.
+type GenerationRule struct {
+	Pattern  string        // Regular expression the generated string must match
+	Min, Max *float64      // Inclusive numeric range; integer columns use the integers within it
+	Choices  []string      // Values picked uniformly at random
+	Sequence *SequenceRule // Monotonically increasing values
+}
+
+// SequenceRule generates Start, Start+Step, Start+2*Step, ... optionally rendered through Format (fmt verbs, e.g. "ORD-%05d").
+type SequenceRule struct {
+	Start  int64
+	Step   int64
+	Format string
+}
+
+// Validate reports whether the rule is well-formed. Whether a range holds an integer for the
+// integer columns it applies to is checked by RuleGenerator.CheckSchema.
+func (r *GenerationRule) Validate() error {
+	set := 0
+	if r.Pattern != "" {
+		if _, err := syntax.Parse(r.Pattern, syntax.Perl); err != nil {
+			return fmt.Errorf("invalid pattern '%s': %w", r.Pattern, err)
+		}
+		set++
+	}
+	if r.Min != nil || r.Max != nil {
+		if r.Min == nil || r.Max == nil {
+			return fmt.Errorf("range requires both min and max")
+		}
+		if !isFinite(*r.Min) || !isFinite(*r.Max) {
+			return fmt.Errorf("range min %v and max %v must be finite numbers", *r.Min, *r.Max)
+		}
+		if *r.Min > *r.Max {
+			return fmt.Errorf("range min %v is greater than max %v", *r.Min, *r.Max)
+		}
+		set++
+	}
+	if len(r.Choices) > 0 {
+		set++
+	}
+	if r.Sequence != nil {
+		set++
+	}
+	if set != 1 {
+		return fmt.Errorf("exactly one of pattern, min/max, choices or sequence must be set")
+	}
+	return nil
+}
+
+func isFinite(f float64) bool {
+	return !math.IsNaN(f) && !math.IsInf(f, 0)
+}
+
+// integerRange returns the integers within the range of the rule: min rounded up and max rounded
+// down. It fails when the range holds no integer or exceeds the range of int64.
+func (r *GenerationRule) integerRange() (int64, int64, error) {
+	lo, hi := math.Ceil(*r.Min), math.Floor(*r.Max)
+	if lo > hi {
+		return 0, 0, fmt.Errorf("range min %v and max %v hold no integer", *r.Min, *r.Max)
+	}
+	// float64(math.MaxInt64) rounds up to 2^63, which does not fit
+	if lo < math.MinInt64 || hi >= math.MaxInt64 {
+		return 0, 0, fmt.Errorf("range min %v and max %v exceed 64-bit integers", *r.Min, *r.Max)
+	}
+	return int64(lo), int64(hi), nil
+}
+
+// RuleGenerator is a ValueGenerator that applies per-column GenerationRules and delegates
+// all other columns to a fallback generator. Rules are keyed by "table.column" or "column".
+type RuleGenerator struct {
+	mu        sync.Mutex
+	rnd       *rand.Rand
+	rules     map[string]*GenerationRule
+	patterns  map[*GenerationRule]*syntax.Regexp // Parsed Pattern of each rule
+	sequences map[string]int64
+	fallback  ValueGenerator
+}
+
+// NewRuleGenerator creates a RuleGenerator. rules must already be validated.
+func NewRuleGenerator(rules map[string]*GenerationRule, fallback ValueGenerator) *RuleGenerator {
+	lowerRules := make(map[string]*GenerationRule, len(rules))
+	patterns := make(map[*GenerationRule]*syntax.Regexp)
+	for key, rule := range rules {
+		lowerRules[strings.ToLower(key)] = rule
+		if rule.Pattern != "" {
+			if re, err := syntax.Parse(rule.Pattern, syntax.Perl); err == nil {
+				patterns[rule] = re.Simplify()
+			}
+		}
+	}
+	return &RuleGenerator{
+		rnd:       newRandomSource(),
+		rules:     lowerRules,
+		patterns:  patterns,
+		sequences: make(map[string]int64),
+		fallback:  fallback,
+	}
+}
+
+func (g *RuleGenerator) ruleFor(tableName, columnName string) (string, *GenerationRule, bool) {
+	key := strings.ToLower(tableName + "." + columnName)
+	if rule, ok := g.rules[key]; ok {
+		return key, rule, true
+	}
+	if rule, ok := g.rules[strings.ToLower(columnName)]; ok {
+		// Sequences on a bare column name are still tracked per table.
+		return key, rule, true
+	}
+	return "", nil, false
+}
+
+// CheckSchema reports the first rule that cannot generate values for a column of schema it
+// applies to: a range holding no integer for an integer column.
+func (g *RuleGenerator) CheckSchema(schema map[string]DBInfo) error {
+	var tableNames []string
+	for tableName := range schema {
+		tableNames = append(tableNames, tableName)
+	}
+	sort.Strings(tableNames)
+	for _, tableName := range tableNames {
+		for _, col := range schema[tableName].Columns {
+			_, rule, ok := g.ruleFor(tableName, col.ColumnName)
+			if !ok || rule.Min == nil || rule.Max == nil || col.DataType != IntegerType {
+				continue
+			}
+			if _, _, err := rule.integerRange(); err != nil {
+				return fmt.Errorf("generation rule for %s.%s: %w", tableName, col.ColumnName, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Seed makes the values generated from rules reproducible. The fallback generator is seeded separately.
+func (g *RuleGenerator) Seed(seed int64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.rnd = newSeededSource(seed)
+	g.sequences = make(map[string]int64)
+}
+
+// GenerateValue implements ValueGenerator.
+func (g *RuleGenerator) GenerateValue(tableName string, col ColumnInfo, unique bool) (interface{}, error) {
+	raw, ok, err := g.GenerateString(tableName, col)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return g.fallback.GenerateValue(tableName, col, unique)
+	}
+	return ConvertToDBType(raw, col.DataType, false, sql.NullString{})
+}
+
+// GenerateString generates the CSV-style string form of a value for a column that has a rule.
+// ok is false when no rule is declared for the column.
+func (g *RuleGenerator) GenerateString(tableName string, col ColumnInfo) (string, bool, error) {
+	key, rule, ok := g.ruleFor(tableName, col.ColumnName)
+	if !ok {
+		return "", false, nil
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	raw, err := g.generateRaw(key, rule, col.DataType)
+	if err != nil {
+		return "", true, fmt.Errorf("failed to generate value for %s.%s: %w", tableName, col.ColumnName, err)
+	}
+	return raw, true, nil
+}
+
+func (g *RuleGenerator) generateRaw(key string, rule *GenerationRule, dataType ColumnDataType) (string, error) {
+	switch {
+	case rule.Pattern != "":
+		re, ok := g.patterns[rule]
+		if !ok {
+			return "", fmt.Errorf("invalid pattern '%s'", rule.Pattern)
+		}
+		var sb strings.Builder
+		g.generateFromRegexp(&sb, re)
+		return sb.String(), nil
+	case rule.Min != nil && rule.Max != nil:
+		if dataType == IntegerType {
+			lo, hi, err := rule.integerRange()
+			if err != nil {
+				return "", err
+			}
+			// hi-lo+1 overflows int64 for wide ranges, so the offset is drawn as a uint64
+			span := uint64(hi) - uint64(lo)
+			if span == math.MaxUint64 {
+				return strconv.FormatInt(int64(g.rnd.Uint64()), 10), nil
+			}
+			return strconv.FormatInt(lo+int64(g.rnd.Uint64N(span+1)), 10), nil
+		}
+		return strconv.FormatFloat(*rule.Min+g.rnd.Float64()*(*rule.Max-*rule.Min), 'f', -1, 64), nil
+	case len(rule.Choices) > 0:
+		return rule.Choices[g.rnd.IntN(len(rule.Choices))], nil
+	case rule.Sequence != nil:
+		step := rule.Sequence.Step
+		if step == 0 {
+			step = 1
+		}
+		next, started := g.sequences[key]
+		if !started {
+			next = rule.Sequence.Start
+		}
+		g.sequences[key] = next + step
+		if rule.Sequence.Format != "" {
+			return fmt.Sprintf(rule.Sequence.Format, next), nil
+		}
+		return strconv.FormatInt(next, 10), nil
+	default:
+		return "", fmt.Errorf("empty generation rule")
+	}
+}
+
+// maxRegexpRepeat caps unbounded repetitions (*, +, {n,}) when generating from a pattern.
+const maxRegexpRepeat = 8
+
+// generateFromRegexp writes a random string matching re to sb.
+func (g *RuleGenerator) generateFromRegexp(sb *strings.Builder, re *syntax.Regexp) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		for _, r := range re.Rune {
+			sb.WriteRune(r)
+		}
+	case syntax.OpCharClass:
+		// re.Rune holds inclusive [lo, hi] pairs
+		total := 0
+		for i := 0; i < len(re.Rune); i += 2 {
+			total += int(re.Rune[i+1]-re.Rune[i]) + 1
+		}
+		if total == 0 {
+			return
+		}
+		n := g.rnd.IntN(total)
+		for i := 0; i < len(re.Rune); i += 2 {
+			size := int(re.Rune[i+1]-re.Rune[i]) + 1
+			if n < size {
+				sb.WriteRune(re.Rune[i] + rune(n))
+				return
+			}
+			n -= size
+		}
+	case syntax.OpAnyCharNotNL, syntax.OpAnyChar:
+		sb.WriteRune(rune('a' + g.rnd.IntN(26)))
+	case syntax.OpCapture:
+		g.generateFromRegexp(sb, re.Sub[0])
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			g.generateFromRegexp(sb, sub)
+		}
+	case syntax.OpAlternate:
+		g.generateFromRegexp(sb, re.Sub[g.rnd.IntN(len(re.Sub))])
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		lo, hi := 0, maxRegexpRepeat
+		switch re.Op {
+		case syntax.OpPlus:
+			lo = 1
+		case syntax.OpQuest:
+			hi = 1
+		case syntax.OpRepeat:
+			lo = re.Min
+			if re.Max >= 0 {
+				hi = re.Max
+			} else {
+				hi = re.Min + maxRegexpRepeat
+			}
+		}
+		n := lo + g.rnd.IntN(hi-lo+1)
+		for i := 0; i < n; i++ {
+			g.generateFromRegexp(sb, re.Sub[0])
+		}
+	default:
+		// Anchors, word boundaries and empty matches produce no output.
+	}
+}
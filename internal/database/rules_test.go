@@ -0,0 +1,105 @@
+package database
+
+import (
+	"math"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RuleGenerator(t *testing.T) {
+	min, max := 10.0, 20.0
+	rules := map[string]*GenerationRule{
+		"users.code":   {Pattern: `[A-Z]{3}-\d{4}`},
+		"users.age":    {Min: &min, Max: &max},
+		"status":       {Choices: []string{"active", "inactive"}},
+		"orders.order": {Sequence: &SequenceRule{Start: 1000, Step: 5, Format: "ORD-%05d"}},
+	}
+	for key, rule := range rules {
+		require.NoError(t, rule.Validate(), key)
+	}
+	g := NewRuleGenerator(rules, NewFakeGenerator())
+
+	t.Run("正規表現にマッチする文字列が生成されること", func(t *testing.T) {
+		for i := 0; i < 20; i++ {
+			v, err := g.GenerateValue("users", ColumnInfo{ColumnName: "code", DataType: StringType}, false)
+			require.NoError(t, err)
+			assert.Regexp(t, regexp.MustCompile(`^[A-Z]{3}-\d{4}$`), v)
+		}
+	})
+
+	t.Run("範囲内の値が生成されること", func(t *testing.T) {
+		for i := 0; i < 20; i++ {
+			v, err := g.GenerateValue("users", ColumnInfo{ColumnName: "age", DataType: IntegerType}, false)
+			require.NoError(t, err)
+			assert.GreaterOrEqual(t, v.(int64), int64(10))
+			assert.LessOrEqual(t, v.(int64), int64(20))
+		}
+	})
+
+	t.Run("カラム名だけのルールが全テーブルに適用されること", func(t *testing.T) {
+		v, err := g.GenerateValue("accounts", ColumnInfo{ColumnName: "status", DataType: StringType}, false)
+		require.NoError(t, err)
+		assert.Contains(t, []string{"active", "inactive"}, v)
+	})
+
+	t.Run("連番が生成されること", func(t *testing.T) {
+		for _, want := range []string{"ORD-01000", "ORD-01005", "ORD-01010"} {
+			v, ok, err := g.GenerateString("orders", ColumnInfo{ColumnName: "order", DataType: StringType})
+			require.NoError(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, want, v)
+		}
+	})
+
+	t.Run("ルールがないカラムはフォールバックに委譲されること", func(t *testing.T) {
+		_, ok, err := g.GenerateString("users", ColumnInfo{ColumnName: "name", DataType: StringType})
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		v, err := g.GenerateValue("users", ColumnInfo{ColumnName: "email", DataType: StringType}, false)
+		require.NoError(t, err)
+		assert.Contains(t, v, "@")
+	})
+
+	t.Run("不正なルールはエラーになること", func(t *testing.T) {
+		assert.Error(t, (&GenerationRule{}).Validate())
+		assert.Error(t, (&GenerationRule{Pattern: "("}).Validate())
+		assert.Error(t, (&GenerationRule{Min: &min}).Validate())
+		assert.Error(t, (&GenerationRule{Min: &max, Max: &min}).Validate())
+		assert.Error(t, (&GenerationRule{Pattern: "a", Choices: []string{"b"}}).Validate())
+		nan := math.NaN()
+		assert.Error(t, (&GenerationRule{Min: &min, Max: &nan}).Validate())
+	})
+
+	t.Run("整数の範囲は下限を切り上げ上限を切り捨てること", func(t *testing.T) {
+		lo, hi := 1.2, 2.8
+		g := NewRuleGenerator(map[string]*GenerationRule{"n": {Min: &lo, Max: &hi}}, NewFakeGenerator())
+		for i := 0; i < 20; i++ {
+			v, err := g.GenerateValue("t", ColumnInfo{ColumnName: "n", DataType: IntegerType}, false)
+			require.NoError(t, err)
+			assert.Equal(t, int64(2), v)
+		}
+	})
+
+	t.Run("int64全体の範囲でもパニックしないこと", func(t *testing.T) {
+		lo, hi := -math.Pow(2, 63), math.Pow(2, 63)-1024
+		g := NewRuleGenerator(map[string]*GenerationRule{"n": {Min: &lo, Max: &hi}}, NewFakeGenerator())
+		_, err := g.GenerateValue("t", ColumnInfo{ColumnName: "n", DataType: IntegerType}, false)
+		assert.NoError(t, err)
+	})
+
+	t.Run("整数を含まない範囲は整数カラムに対してエラーになること", func(t *testing.T) {
+		lo, hi := 1.2, 1.8
+		rule := &GenerationRule{Min: &lo, Max: &hi}
+		require.NoError(t, rule.Validate())
+		g := NewRuleGenerator(map[string]*GenerationRule{"users.score": rule}, NewFakeGenerator())
+		err := g.CheckSchema(map[string]DBInfo{"users": {TableName: "users", Columns: []ColumnInfo{{ColumnName: "score", DataType: IntegerType}}}})
+		assert.ErrorContains(t, err, "users.score")
+		assert.NoError(t, g.CheckSchema(map[string]DBInfo{"users": {TableName: "users", Columns: []ColumnInfo{{ColumnName: "score", DataType: FloatType}}}}))
+		_, err = g.GenerateValue("users", ColumnInfo{ColumnName: "score", DataType: IntegerType}, false)
+		assert.Error(t, err)
+	})
+}
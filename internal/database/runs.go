@@ -0,0 +1,78 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ImportRunsTable is the table RecordImportRun writes to. It is created on first use.
+const ImportRunsTable = "_import_runs"
+
+// ImportRun describes one import run, as recorded in ImportRunsTable.
+type ImportRun struct {
+	RunID      string
+	StartedAt  time.Time
+	FinishedAt time.Time
+	// Files are the CSV files the run was given, stored one per line.
+	Files          []string
+	RowsRead       int
+	RowsInserted   int
+	RowsUpdated    int
+	RowsRejected   int
+	ParentsCreated int
+	// Version is the version of the binary that ran the import.
+	Version string
+	// Outcome is "success" or "failure".
+	Outcome string
+	// Error is the error that failed the run, if any.
+	Error string
+}
+
+// importRunColumns are the columns of ImportRunsTable, in the order of importRunArgs.
+var importRunColumns = []string{"run_id", "started_at", "finished_at", "files", "rows_read", "rows_inserted", "rows_updated", "rows_rejected", "parents_created", "version", "outcome", "error"}
+
+// createImportRunsStatement builds the CREATE TABLE statement of ImportRunsTable. prefix is the
+// statement up to the table name, e.g. "CREATE TABLE IF NOT EXISTS", and textType and timeType
+// are the dialect's types for unbounded text and timestamps.
+func createImportRunsStatement(prefix, table, textType, timeType string) string {
+	return fmt.Sprintf(`%s %s (
+		run_id VARCHAR(64) NOT NULL PRIMARY KEY,
+		started_at %s NOT NULL,
+		finished_at %s NOT NULL,
+		files %s,
+		rows_read INTEGER NOT NULL,
+		rows_inserted INTEGER NOT NULL,
+		rows_updated INTEGER NOT NULL,
+		rows_rejected INTEGER NOT NULL,
+		parents_created INTEGER NOT NULL,
+		version VARCHAR(255) NOT NULL,
+		outcome VARCHAR(16) NOT NULL,
+		error %s
+	)`, prefix, table, timeType, timeType, textType, textType)
+}
+
+// insertImportRunStatement builds the INSERT of a row into ImportRunsTable.
+func insertImportRunStatement(table string, placeholder func(n int) string) string {
+	placeholders := make([]string, len(importRunColumns))
+	for idx := range importRunColumns {
+		placeholders[idx] = placeholder(idx + 1)
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(importRunColumns, ", "), strings.Join(placeholders, ", "))
+}
+
+// importRunArgs returns the arguments of a statement built by insertImportRunStatement.
+func importRunArgs(run ImportRun) []interface{} {
+	var runErr interface{}
+	if run.Error != "" {
+		runErr = run.Error
+	}
+	return []interface{}{run.RunID, run.StartedAt.UTC(), run.FinishedAt.UTC(), strings.Join(run.Files, "\n"),
+		run.RowsRead, run.RowsInserted, run.RowsUpdated, run.RowsRejected, run.ParentsCreated, run.Version, run.Outcome, runErr}
+}
+
+// isImportRunsTable reports whether tableName is ImportRunsTable, which GetSchemaInfo leaves out
+// so that the run history is never imported into, generated for or listed with the schema.
+func isImportRunsTable(tableName string) bool {
+	return strings.EqualFold(tableName, ImportRunsTable)
+}
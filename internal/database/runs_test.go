@@ -0,0 +1,30 @@
+package database
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_insertImportRunStatement(t *testing.T) {
+	query := insertImportRunStatement("_import_runs", func(n int) string { return fmt.Sprintf("$%d", n) })
+	assert.Equal(t, "INSERT INTO _import_runs (run_id, started_at, finished_at, files, rows_read, rows_inserted, rows_updated, rows_rejected, parents_created, version, outcome, error) "+
+		"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)", query)
+
+	started := time.Date(2024, 5, 1, 12, 0, 0, 0, time.FixedZone("JST", 9*60*60))
+	run := ImportRun{RunID: "r1", StartedAt: started, FinishedAt: started.Add(time.Minute), Files: []string{"a.csv", "b.csv"},
+		RowsRead: 3, RowsInserted: 2, RowsUpdated: 1, RowsRejected: 1, Version: "dev", Outcome: "success"}
+	args := importRunArgs(run)
+	assert.Len(t, args, len(importRunColumns))
+	assert.Equal(t, started.UTC(), args[1])
+	assert.Equal(t, "a.csv\nb.csv", args[3])
+	assert.Nil(t, args[11], "成功した実行のerrorはNULLになること")
+}
+
+func Test_isImportRunsTable(t *testing.T) {
+	assert.True(t, isImportRunsTable("_import_runs"))
+	assert.True(t, isImportRunsTable("_IMPORT_RUNS"))
+	assert.False(t, isImportRunsTable("import_runs"))
+}
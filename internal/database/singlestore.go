@@ -0,0 +1,208 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// singlestoreColumnstoreBatchSize is the default number of rows inserted together into a
+// columnstore table. Rows inserted one by one each go through the in-memory segment of the table
+// before a background flush writes them to disk, which a multi-row INSERT largely avoids.
+const singlestoreColumnstoreBatchSize = 1000
+
+// singlestoreMaxPlaceholders is the largest number of placeholders of a prepared statement.
+const singlestoreMaxPlaceholders = 65535
+
+// singlestoreShardKey matches the SHARD KEY of a SHOW CREATE TABLE, capturing its column list.
+var singlestoreShardKey = regexp.MustCompile("(?i)SHARD KEY (?:`[^`]*` )?\\(([^)]*)\\)")
+
+// singlestoreLayout is how a SingleStore table stores its rows.
+type singlestoreLayout struct {
+	columnstore bool
+	// shardKey are the columns whose hash decides the partition of a row. They cannot be updated.
+	shardKey []string
+}
+
+// singlestoreInsert is a statement of PrepareInsertStatement in the parts of mysqlInsertStatement,
+// from which ExecBatch builds a multi-row INSERT.
+type singlestoreInsert struct {
+	head, values, tail string
+	columns            int
+}
+
+// SingleStoreDB implements the DBClient interface for SingleStore (formerly MemSQL), which speaks
+// the MySQL protocol and has the same information_schema as MySQL. Unlike MySQLDB, it never updates
+// the shard key columns on a duplicate key, which SingleStore rejects, and inserts the rows of
+// columnstore tables in multi-row INSERTs of singlestoreColumnstoreBatchSize rows by default.
+type SingleStoreDB struct {
+	*MySQLDB
+
+	mu      sync.Mutex
+	layouts map[string]singlestoreLayout
+	inserts map[*sql.Stmt]singlestoreInsert
+}
+
+var (
+	_ BatchExecutor = (*SingleStoreDB)(nil)
+	_ BatchSizer    = (*SingleStoreDB)(nil)
+)
+
+// NewSingleStoreDB creates a new SingleStoreDB instance. connStr is a MySQL connection string.
+func NewSingleStoreDB(ctx context.Context, connStr string, options ConnOptions) (*SingleStoreDB, error) {
+	db, err := openDB("mysql", connStr, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database connection: %w", err)
+	}
+	if err = db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, &ConnectionError{DBType: "SingleStore", Err: err}
+	}
+	log.Println("Successfully connected to SingleStore database.")
+	return &SingleStoreDB{
+		MySQLDB: &MySQLDB{db: db},
+		layouts: make(map[string]singlestoreLayout),
+		inserts: make(map[*sql.Stmt]singlestoreInsert),
+	}, nil
+}
+
+// parseShardKey returns the columns of the SHARD KEY in the output of SHOW CREATE TABLE. It is
+// empty for keyless sharding and for reference tables, which are not sharded.
+func parseShardKey(createTable string) []string {
+	match := singlestoreShardKey.FindStringSubmatch(createTable)
+	if match == nil {
+		return nil
+	}
+	var columns []string
+	for _, col := range strings.Split(match[1], ",") {
+		if col = strings.Trim(strings.TrimSpace(col), "`"); col != "" {
+			columns = append(columns, col)
+		}
+	}
+	return columns
+}
+
+// tableLayout looks up how a table stores its rows, once per table.
+func (s *SingleStoreDB) tableLayout(ctx context.Context, tableName string) (singlestoreLayout, error) {
+	s.mu.Lock()
+	layout, ok := s.layouts[tableName]
+	s.mu.Unlock()
+	if ok {
+		return layout, nil
+	}
+
+	dbName, name, qualified := strings.Cut(tableName, ".")
+	if !qualified {
+		dbName, name = "", tableName
+	}
+	var storageType string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT STORAGE_TYPE
+		FROM information_schema.TABLES
+		WHERE TABLE_SCHEMA = COALESCE(NULLIF(?, ''), DATABASE()) AND TABLE_NAME = ?
+	`, dbName, name).Scan(&storageType)
+	if err != nil {
+		return singlestoreLayout{}, fmt.Errorf("failed to look up the storage type of table %s: %w", tableName, err)
+	}
+	var shownName, createTable string
+	if err := s.db.QueryRowContext(ctx, "SHOW CREATE TABLE "+tableName).Scan(&shownName, &createTable); err != nil {
+		return singlestoreLayout{}, fmt.Errorf("failed to look up the shard key of table %s: %w", tableName, err)
+	}
+	layout = singlestoreLayout{
+		columnstore: strings.EqualFold(storageType, "COLUMNSTORE"),
+		shardKey:    parseShardKey(createTable),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.layouts[tableName] = layout
+	return layout, nil
+}
+
+// PrepareInsertStatement prepares an INSERT statement for SingleStore, which leaves the shard key
+// out of the columns updated on a duplicate key.
+func (s *SingleStoreDB) PrepareInsertStatement(ctx context.Context, dbInfo DBInfo) (*sql.Stmt, error) {
+	return s.PrepareModeInsertStatement(ctx, dbInfo, InsertUpsert)
+}
+
+// PrepareModeInsertStatement prepares an INSERT statement of mode for SingleStore.
+func (s *SingleStoreDB) PrepareModeInsertStatement(ctx context.Context, dbInfo DBInfo, mode InsertMode) (*sql.Stmt, error) {
+	layout, err := s.tableLayout(ctx, dbInfo.TableName)
+	if err != nil {
+		return nil, err
+	}
+	head, values, tail := mysqlInsertStatement(dbInfo, layout.shardKey, mode)
+	stmt, err := s.db.PrepareContext(ctx, head+" "+values+tail)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inserts[stmt] = singlestoreInsert{head: head, values: values, tail: tail, columns: len(dbInfo.InsertColumns())}
+	return stmt, nil
+}
+
+// DefaultBatchSize returns singlestoreColumnstoreBatchSize for columnstore tables. The rows of
+// rowstore tables are inserted one by one, as in MySQL.
+func (s *SingleStoreDB) DefaultBatchSize(dbInfo DBInfo) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.layouts[dbInfo.TableName].columnstore {
+		return singlestoreColumnstoreBatchSize
+	}
+	return 0
+}
+
+// multiRowInsert builds the INSERT of rows rows of insert.
+func (insert singlestoreInsert) multiRowInsert(rows int) string {
+	values := make([]string, rows)
+	for idx := range values {
+		values[idx] = insert.values
+	}
+	return insert.head + " " + strings.Join(values, ", ") + insert.tail
+}
+
+// ExecBatch inserts the rows of args with multi-row INSERTs of the statement, each of which
+// SingleStore runs atomically. If one fails, its rows are inserted one by one with stmt instead,
+// reporting the error of each failed row. The number of rows each row changed is not known.
+func (s *SingleStoreDB) ExecBatch(ctx context.Context, stmt *sql.Stmt, args [][]interface{}, rowContext RowContextFunc) ([]BatchResult, error) {
+	s.mu.Lock()
+	insert, ok := s.inserts[stmt]
+	s.mu.Unlock()
+	if !ok || insert.columns == 0 {
+		return execRows(ctx, stmt, args, rowContext)
+	}
+
+	results := make([]BatchResult, len(args))
+	chunkSize := max(singlestoreMaxPlaceholders/insert.columns, 1)
+	for start := 0; start < len(args); start += chunkSize {
+		chunk := args[start:min(start+chunkSize, len(args))]
+		flat := make([]interface{}, 0, len(chunk)*insert.columns)
+		for _, rowArgs := range chunk {
+			flat = append(flat, rowArgs...)
+		}
+		chunkCtx, done := rowContext(ctx)
+		_, err := s.db.ExecContext(chunkCtx, insert.multiRowInsert(len(chunk)), flat...)
+		done()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil {
+			log.Printf("Warning: INSERT of %d rows failed, inserting them one by one: %v\n", len(chunk), err)
+			rowResults, err := execRows(ctx, stmt, chunk, rowContext)
+			if err != nil {
+				return nil, err
+			}
+			copy(results[start:], rowResults)
+			continue
+		}
+		for idx := range chunk {
+			results[start+idx].RowsAffected = -1
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,66 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_mysqlInsertStatement(t *testing.T) {
+	columns := []ColumnInfo{{ColumnName: "id", DataType: IntegerType}, {ColumnName: "tenant_id", DataType: IntegerType}, {ColumnName: "name", DataType: StringType}}
+
+	t.Run("主キー以外の列を重複時に更新すること", func(t *testing.T) {
+		head, values, tail := mysqlInsertStatement(DBInfo{TableName: "users", Columns: columns, PrimaryKeyColumns: []string{"id"}}, nil, InsertUpsert)
+		assert.Equal(t, "INSERT INTO users (id, tenant_id, name) VALUES", head)
+		assert.Equal(t, "(?, ?, ?)", values)
+		assert.Equal(t, " ON DUPLICATE KEY UPDATE tenant_id = VALUES(tenant_id), name = VALUES(name)", tail)
+	})
+
+	t.Run("シャードキーの列は重複時に更新しないこと", func(t *testing.T) {
+		_, _, tail := mysqlInsertStatement(DBInfo{TableName: "users", Columns: columns, PrimaryKeyColumns: []string{"id"}}, []string{"tenant_id"}, InsertUpsert)
+		assert.Equal(t, " ON DUPLICATE KEY UPDATE name = VALUES(name)", tail)
+	})
+
+	t.Run("更新する列がない場合はINSERT IGNOREを使う", func(t *testing.T) {
+		head, _, tail := mysqlInsertStatement(DBInfo{TableName: "users", Columns: columns[:2], PrimaryKeyColumns: []string{"id"}}, []string{"tenant_id"}, InsertUpsert)
+		assert.Equal(t, "INSERT IGNORE INTO users (id, tenant_id) VALUES", head)
+		assert.Empty(t, tail)
+	})
+
+	t.Run("主キーがないテーブルにはINSERTを使う", func(t *testing.T) {
+		head, _, tail := mysqlInsertStatement(DBInfo{TableName: "logs", Columns: columns}, nil, InsertUpsert)
+		assert.Equal(t, "INSERT INTO logs (id, tenant_id, name) VALUES", head)
+		assert.Empty(t, tail)
+	})
+
+	t.Run("insertでは重複を扱わないこと", func(t *testing.T) {
+		head, _, tail := mysqlInsertStatement(DBInfo{TableName: "users", Columns: columns, PrimaryKeyColumns: []string{"id"}}, nil, InsertOnly)
+		assert.Equal(t, "INSERT INTO users (id, tenant_id, name) VALUES", head)
+		assert.Empty(t, tail)
+	})
+
+	t.Run("skipでは重複時に行を変更しないこと", func(t *testing.T) {
+		head, _, tail := mysqlInsertStatement(DBInfo{TableName: "users", Columns: columns, PrimaryKeyColumns: []string{"id"}}, []string{"tenant_id"}, InsertSkip)
+		assert.Equal(t, "INSERT INTO users (id, tenant_id, name) VALUES", head)
+		assert.Equal(t, " ON DUPLICATE KEY UPDATE name = name", tail)
+	})
+}
+
+func Test_parseShardKey(t *testing.T) {
+	createTable := "CREATE TABLE `orders` (\n  `id` bigint(20) NOT NULL,\n  `tenant_id` int(11) NOT NULL,\n" +
+		"  PRIMARY KEY (`tenant_id`,`id`),\n  SHARD KEY `__SHARDKEY` (`tenant_id`, `id`),\n  SORT KEY `__UNORDERED` ()\n)"
+	assert.Equal(t, []string{"tenant_id", "id"}, parseShardKey(createTable))
+	assert.Empty(t, parseShardKey("CREATE TABLE `events` (\n  `id` bigint(20),\n  SHARD KEY ()\n)"), "キーなしのシャーディング")
+	assert.Empty(t, parseShardKey("CREATE REFERENCE TABLE `codes` (\n  `code` varchar(8),\n  PRIMARY KEY (`code`)\n)"))
+}
+
+func Test_singlestoreInsert_multiRowInsert(t *testing.T) {
+	insert := singlestoreInsert{head: "INSERT INTO users (id, name) VALUES", values: "(?, ?)", tail: " ON DUPLICATE KEY UPDATE name = VALUES(name)", columns: 2}
+	assert.Equal(t, "INSERT INTO users (id, name) VALUES (?, ?), (?, ?), (?, ?) ON DUPLICATE KEY UPDATE name = VALUES(name)", insert.multiRowInsert(3))
+}
+
+func Test_SingleStoreDB_DefaultBatchSize(t *testing.T) {
+	client := &SingleStoreDB{layouts: map[string]singlestoreLayout{"events": {columnstore: true}, "sessions": {}}}
+	assert.Equal(t, singlestoreColumnstoreBatchSize, client.DefaultBatchSize(DBInfo{TableName: "events"}))
+	assert.Equal(t, 0, client.DefaultBatchSize(DBInfo{TableName: "sessions"}))
+}
@@ -0,0 +1,137 @@
+package database
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// snapshotVersion is the version of the schema snapshot format written by WriteSchemaSnapshot.
+const snapshotVersion = 1
+
+// SchemaSnapshot is a schema read from a database, saved so that it can be reused
+// without querying the catalog again or compared with the live schema later.
+type SchemaSnapshot struct {
+	Version int               `json:"version"`
+	DBType  string            `json:"db_type"`
+	Schema  string            `json:"schema"`
+	Tables  map[string]DBInfo `json:"tables"`
+}
+
+// WriteSchemaSnapshot writes the tables of a schema as JSON.
+func WriteSchemaSnapshot(w io.Writer, dbType, schemaName string, tables map[string]DBInfo) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(SchemaSnapshot{Version: snapshotVersion, DBType: dbType, Schema: schemaName, Tables: tables})
+}
+
+// WriteSchemaSnapshotYAML writes the tables of a schema as YAML, with the same fields as
+// WriteSchemaSnapshot.
+func WriteSchemaSnapshotYAML(w io.Writer, dbType, schemaName string, tables map[string]DBInfo) error {
+	data, err := json.Marshal(SchemaSnapshot{Version: snapshotVersion, DBType: dbType, Schema: schemaName, Tables: tables})
+	if err != nil {
+		return err
+	}
+	// JSON is YAML; decoding it into a node keeps the order of the fields
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	blockStyle(&node)
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// blockStyle clears the flow and quoting styles of the JSON a node was decoded from, so that it
+// is encoded as block YAML. Strings that would read as another type are still quoted.
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+// ReadSchemaSnapshot reads a snapshot written by WriteSchemaSnapshot or WriteSchemaSnapshotYAML.
+func ReadSchemaSnapshot(r io.Reader) (*SchemaSnapshot, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		var v any
+		if err := yaml.Unmarshal(data, &v); err != nil {
+			return nil, fmt.Errorf("invalid schema snapshot: %w", err)
+		}
+		if data, err = json.Marshal(v); err != nil {
+			return nil, fmt.Errorf("invalid schema snapshot: %w", err)
+		}
+	}
+	var snapshot SchemaSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("invalid schema snapshot: %w", err)
+	}
+	if snapshot.Version != snapshotVersion {
+		return nil, fmt.Errorf("unsupported schema snapshot version %d", snapshot.Version)
+	}
+	return &snapshot, nil
+}
+
+// MarshalText writes the type by name, e.g. "INTEGER".
+func (cdt ColumnDataType) MarshalText() ([]byte, error) {
+	return []byte(cdt.String()), nil
+}
+
+// UnmarshalText reads a type written by MarshalText.
+func (cdt *ColumnDataType) UnmarshalText(text []byte) error {
+	if string(text) == UnknownType.String() {
+		*cdt = UnknownType
+		return nil
+	}
+	parsed, err := ParseColumnDataTypeName(string(text))
+	if err != nil {
+		return err
+	}
+	*cdt = parsed
+	return nil
+}
+
+// columnInfoJSON is the JSON form of ColumnInfo.
+type columnInfoJSON struct {
+	ColumnName      string         `json:"name"`
+	DataType        ColumnDataType `json:"data_type"`
+	IsNullable      bool           `json:"nullable"`
+	ColumnDefault   *string        `json:"default,omitempty"`
+	IsAutoIncrement bool           `json:"auto_increment,omitempty"`
+	IsGenerated     bool           `json:"generated,omitempty"`
+	Comment         string         `json:"comment,omitempty"`
+}
+
+// MarshalJSON writes the column with a null default omitted.
+func (c ColumnInfo) MarshalJSON() ([]byte, error) {
+	v := columnInfoJSON{ColumnName: c.ColumnName, DataType: c.DataType, IsNullable: c.IsNullable, IsAutoIncrement: c.IsAutoIncrement, IsGenerated: c.IsGenerated, Comment: c.Comment}
+	if c.ColumnDefault.Valid {
+		v.ColumnDefault = &c.ColumnDefault.String
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON reads a column written by MarshalJSON.
+func (c *ColumnInfo) UnmarshalJSON(data []byte) error {
+	var v columnInfoJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*c = ColumnInfo{ColumnName: v.ColumnName, DataType: v.DataType, IsNullable: v.IsNullable, IsAutoIncrement: v.IsAutoIncrement, IsGenerated: v.IsGenerated, Comment: v.Comment}
+	if v.ColumnDefault != nil {
+		c.ColumnDefault = sql.NullString{String: *v.ColumnDefault, Valid: true}
+	}
+	return nil
+}
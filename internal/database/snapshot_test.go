@@ -0,0 +1,55 @@
+package database
+
+import (
+	"bytes"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SchemaSnapshot(t *testing.T) {
+	tables := map[string]DBInfo{
+		"orders": {
+			TableName: "orders",
+			Columns: []ColumnInfo{
+				{ColumnName: "id", DataType: IntegerType, IsAutoIncrement: true},
+				{ColumnName: "status", DataType: StringType, ColumnDefault: sql.NullString{String: "'new'", Valid: true}},
+				{ColumnName: "total", DataType: UnknownType, IsNullable: true, IsGenerated: true},
+			},
+			PrimaryKeyColumns: []string{"id"},
+			ForeignKeys: []ForeignKeyInfo{
+				{ConstraintName: "fk_user", TableName: "orders", ColumnNames: []string{"user_id"}, ForeignTableName: "users", ForeignColumnNames: []string{"id"}},
+			},
+		},
+	}
+
+	t.Run("書き出したスキーマを読み戻せること", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, WriteSchemaSnapshot(&buf, "postgres", "public", tables))
+		assert.Contains(t, buf.String(), `"data_type": "INTEGER"`)
+
+		snapshot, err := ReadSchemaSnapshot(&buf)
+		require.NoError(t, err)
+		assert.Equal(t, "postgres", snapshot.DBType)
+		assert.Equal(t, "public", snapshot.Schema)
+		assert.Equal(t, tables, snapshot.Tables)
+	})
+	t.Run("YAMLで書き出したスキーマを読み戻せること", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, WriteSchemaSnapshotYAML(&buf, "postgres", "public", tables))
+		assert.Contains(t, buf.String(), "data_type: INTEGER")
+		assert.True(t, strings.HasPrefix(buf.String(), "version: 1\ndb_type: postgres\n"))
+
+		snapshot, err := ReadSchemaSnapshot(&buf)
+		require.NoError(t, err)
+		assert.Equal(t, "postgres", snapshot.DBType)
+		assert.Equal(t, tables, snapshot.Tables)
+	})
+	t.Run("未対応のバージョンはエラーになること", func(t *testing.T) {
+		_, err := ReadSchemaSnapshot(strings.NewReader(`{"version": 99, "tables": {}}`))
+		assert.EqualError(t, err, "unsupported schema snapshot version 99")
+	})
+}
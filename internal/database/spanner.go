@@ -0,0 +1,707 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// errSpannerRowExists is the error of an insert mutation whose row already exists.
+var errSpannerRowExists = errors.New("row already exists")
+
+// spannerMutation is a row written to Spanner with a mutation instead of DML.
+type spannerMutation struct {
+	Table   string
+	Columns []string
+	Values  []driver.Value
+	Types   []ColumnDataType // Type of each column, e.g. to write a time.Time to a DATE column as a date
+	// Insert makes the mutation fail with errSpannerRowExists if the row exists, instead of
+	// updating it.
+	Insert bool
+}
+
+// spannerBackend is the part of SpannerDB that talks to Spanner through the Spanner client. It is
+// only compiled with the spanner build tag; see spanner_client.go.
+type spannerBackend interface {
+	// Write applies mutations with BatchWrite, each in a group of its own so that a rejected row
+	// does not keep the others from being written. It returns the error of each mutation.
+	Write(ctx context.Context, mutations []spannerMutation) ([]error, error)
+	// Query runs a read-only query whose parameters are @p1, @p2, ...
+	Query(ctx context.Context, query string, args []driver.Value) (spannerRowIterator, error)
+	// Update runs a DML statement in a read-write transaction and returns the number of rows it
+	// changed. If partitioned is set, it runs as partitioned DML, which has no limit on the number
+	// of rows but returns a lower bound of their number.
+	Update(ctx context.Context, query string, args []driver.Value, partitioned bool) (int64, error)
+	Close() error
+}
+
+// spannerRowIterator reads the rows of a query.
+type spannerRowIterator interface {
+	Columns() []string
+	// Next returns the values of the next row, or io.EOF after the last.
+	Next() ([]driver.Value, error)
+	Stop()
+}
+
+// spannerDefaultBatchSize is the number of rows SpannerDB writes per BatchWrite unless the
+// connection string says otherwise.
+const spannerDefaultBatchSize = 1
+
+// SpannerDB implements the DBClient interface for Google Cloud Spanner (GoogleSQL dialect). Rows
+// are written with InsertOrUpdate mutations through BatchWrite instead of DML, and a table
+// interleaved in a parent table is given a foreign key to it, so that parents are imported, or
+// created, first. Import runs are not recorded: creating the _import_runs table needs a schema
+// change, which Spanner only makes through its admin API.
+//
+// The statements of the importer still go through database/sql: the connections of GetDB turn
+// the INSERT statements of SpannerDB into mutations and run the others as queries or DML.
+type SpannerDB struct {
+	parentRecordSettings
+	db        *sql.DB
+	connector *spannerConnector
+}
+
+// NewSpannerDB creates a new SpannerDB instance. connStr is the database, e.g.
+// "projects/p/instances/i/databases/d", optionally followed by "?batch_size=500" to buffer that
+// many rows per BatchWrite. The Spanner emulator is used if SPANNER_EMULATOR_HOST is set.
+func NewSpannerDB(ctx context.Context, connStr string, options ConnOptions) (*SpannerDB, error) {
+	database, rawQuery, _ := strings.Cut(connStr, "?")
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, fmt.Errorf("invalid spanner options %q: %w", rawQuery, err)
+	}
+	batchSize := spannerDefaultBatchSize
+	if value := query.Get("batch_size"); value != "" {
+		if batchSize, err = strconv.Atoi(value); err != nil || batchSize < 1 {
+			return nil, fmt.Errorf("invalid spanner batch_size %q: must be a positive number", value)
+		}
+	}
+	if options.TLS.enabled() {
+		return nil, errors.New("TLS options are not supported for Spanner; its client always connects with TLS verified against Google's certificates")
+	}
+	backend, err := newSpannerBackend(ctx, database)
+	if err != nil {
+		return nil, &ConnectionError{DBType: "Spanner", Err: err}
+	}
+	s := newSpannerDB(backend, batchSize)
+	if err := s.db.PingContext(ctx); err != nil {
+		s.Close()
+		return nil, &ConnectionError{DBType: "Spanner", Err: err}
+	}
+	log.Println("Successfully connected to Spanner database.")
+	return s, nil
+}
+
+// newSpannerDB creates a SpannerDB over backend.
+func newSpannerDB(backend spannerBackend, batchSize int) *SpannerDB {
+	connector := &spannerConnector{backend: backend, batchSize: batchSize, types: make(map[string]map[string]ColumnDataType)}
+	db := sql.OpenDB(connector)
+	// Buffered rows must be flushed before the statements that follow them
+	db.SetMaxOpenConns(1)
+	return &SpannerDB{db: db, connector: connector}
+}
+
+// GetDB returns the database/sql handle whose connections write through the Spanner client.
+func (s *SpannerDB) GetDB() *sql.DB {
+	return s.db
+}
+
+// Close writes the buffered rows and closes the client.
+func (s *SpannerDB) Close() error {
+	err := s.connector.flush(context.Background())
+	return errors.Join(err, s.db.Close(), s.connector.backend.Close())
+}
+
+// spannerPlaceholder returns the placeholder of the nth parameter.
+func spannerPlaceholder(n int) string {
+	return fmt.Sprintf("@p%d", n)
+}
+
+// spannerKeyPlaceholder returns the placeholder of the nth parameter compared with colInfo. Time
+// parameters are timestamps, which DATE columns are not compared with.
+func spannerKeyPlaceholder(colInfo ColumnInfo, n int) string {
+	if colInfo.DataType == DateType {
+		return fmt.Sprintf("DATE(%s, 'UTC')", spannerPlaceholder(n))
+	}
+	return spannerPlaceholder(n)
+}
+
+// spannerDataType maps a SPANNER_TYPE of INFORMATION_SCHEMA.COLUMNS, e.g. "STRING(MAX)", to a
+// ColumnDataType.
+func spannerDataType(spannerType string) ColumnDataType {
+	baseType, _, _ := strings.Cut(spannerType, "(")
+	switch strings.ToUpper(baseType) {
+	case "STRING", "JSON":
+		return StringType
+	case "INT64":
+		return IntegerType
+	case "FLOAT64", "FLOAT32", "NUMERIC":
+		return FloatType
+	case "BOOL":
+		return BooleanType
+	case "DATE":
+		return DateType
+	case "TIMESTAMP":
+		return TimestampType
+	default:
+		log.Printf("Warning: Unknown database data type '%s'. Mapping to UnknownType.\n", spannerType)
+		return UnknownType
+	}
+}
+
+// GetSchemaInfo retrieves the tables of a named schema from Spanner. "public", the default of
+// --schema, and "" are the default schema. A table interleaved in a parent table references it
+// with a foreign key named "INTERLEAVE IN PARENT <parent>" on the parent's primary key columns,
+// which lead its own primary key.
+func (s *SpannerDB) GetSchemaInfo(ctx context.Context, schemaName string) (map[string]DBInfo, error) {
+	if schemaName == "public" {
+		schemaName = ""
+	}
+	log.Printf("Retrieving schema for '%s' from Spanner.\n", schemaName)
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT TABLE_NAME, COALESCE(PARENT_TABLE_NAME, '')
+		FROM INFORMATION_SCHEMA.TABLES
+		WHERE TABLE_SCHEMA = @p1 AND TABLE_TYPE = 'BASE TABLE'
+		ORDER BY TABLE_NAME`, schemaName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get table names from schema '%s': %w", schemaName, err)
+	}
+	parents := make(map[string]string)
+	var tables []string
+	for rows.Next() {
+		var tableName, parentName string
+		if err := rows.Scan(&tableName, &parentName); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		if isImportRunsTable(tableName) {
+			continue
+		}
+		tables = append(tables, tableName)
+		parents[tableName] = parentName
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get table names from schema '%s': %w", schemaName, err)
+	}
+
+	schemaInfo := make(map[string]DBInfo)
+	for _, tableName := range tables {
+		dbInfo, err := s.describeTable(ctx, schemaName, tableName)
+		if err != nil {
+			return nil, err
+		}
+		schemaInfo[tableName] = dbInfo
+	}
+	addInterleaveForeignKeys(schemaInfo, parents)
+	s.connector.setTypes(schemaInfo)
+	return schemaInfo, nil
+}
+
+// addInterleaveForeignKeys adds to each table interleaved in a parent table, as given by parents,
+// a foreign key to the parent's primary key.
+func addInterleaveForeignKeys(schemaInfo map[string]DBInfo, parents map[string]string) {
+	for tableName, parentName := range parents {
+		parent, ok := schemaInfo[parentName]
+		dbInfo, found := schemaInfo[tableName]
+		if parentName == "" || !ok || !found || len(parent.PrimaryKeyColumns) == 0 {
+			continue
+		}
+		dbInfo.ForeignKeys = append(dbInfo.ForeignKeys, ForeignKeyInfo{
+			ConstraintName:     "INTERLEAVE IN PARENT " + parentName,
+			TableName:          tableName,
+			ColumnNames:        append([]string(nil), parent.PrimaryKeyColumns...),
+			ForeignTableName:   parentName,
+			ForeignColumnNames: append([]string(nil), parent.PrimaryKeyColumns...),
+		})
+		schemaInfo[tableName] = dbInfo
+	}
+}
+
+// describeTable introspects one table.
+func (s *SpannerDB) describeTable(ctx context.Context, schemaName, tableName string) (DBInfo, error) {
+	dbInfo := DBInfo{TableName: tableName}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT COLUMN_NAME, SPANNER_TYPE, IS_NULLABLE, COALESCE(IS_GENERATED, 'NEVER')
+		FROM INFORMATION_SCHEMA.COLUMNS
+		WHERE TABLE_SCHEMA = @p1 AND TABLE_NAME = @p2
+		ORDER BY ORDINAL_POSITION`, schemaName, tableName)
+	if err != nil {
+		return DBInfo{}, fmt.Errorf("failed to get column info for table %s: %w", tableName, err)
+	}
+	for rows.Next() {
+		var colName, spannerType, isNullable, isGenerated string
+		if err := rows.Scan(&colName, &spannerType, &isNullable, &isGenerated); err != nil {
+			rows.Close()
+			return DBInfo{}, fmt.Errorf("scan failed: %w", err)
+		}
+		dbInfo.Columns = append(dbInfo.Columns, ColumnInfo{
+			ColumnName:  colName,
+			DataType:    spannerDataType(spannerType),
+			IsNullable:  isNullable == "YES",
+			IsGenerated: isGenerated == "ALWAYS",
+		})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return DBInfo{}, fmt.Errorf("failed to get column info for table %s: %w", tableName, err)
+	}
+
+	// The primary key is the index named PRIMARY_KEY; unique keys are the other unique indexes
+	rows, err = s.db.QueryContext(ctx, `
+		SELECT i.INDEX_NAME, ic.COLUMN_NAME
+		FROM INFORMATION_SCHEMA.INDEXES i
+		JOIN INFORMATION_SCHEMA.INDEX_COLUMNS ic
+			ON ic.TABLE_SCHEMA = i.TABLE_SCHEMA AND ic.TABLE_NAME = i.TABLE_NAME AND ic.INDEX_NAME = i.INDEX_NAME
+		WHERE i.TABLE_SCHEMA = @p1 AND i.TABLE_NAME = @p2 AND i.IS_UNIQUE AND ic.ORDINAL_POSITION IS NOT NULL
+		ORDER BY i.INDEX_NAME, ic.ORDINAL_POSITION`, schemaName, tableName)
+	if err != nil {
+		return DBInfo{}, fmt.Errorf("failed to get key info for table %s: %w", tableName, err)
+	}
+	var lastIndex string
+	for rows.Next() {
+		var indexName, colName string
+		if err := rows.Scan(&indexName, &colName); err != nil {
+			rows.Close()
+			return DBInfo{}, fmt.Errorf("scan failed: %w", err)
+		}
+		switch {
+		case indexName == "PRIMARY_KEY":
+			dbInfo.PrimaryKeyColumns = append(dbInfo.PrimaryKeyColumns, colName)
+		case indexName == lastIndex:
+			last := len(dbInfo.UniqueKeyColumns) - 1
+			dbInfo.UniqueKeyColumns[last] = append(dbInfo.UniqueKeyColumns[last], colName)
+		default:
+			dbInfo.UniqueKeyColumns = append(dbInfo.UniqueKeyColumns, []string{colName})
+		}
+		lastIndex = indexName
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return DBInfo{}, fmt.Errorf("failed to get key info for table %s: %w", tableName, err)
+	}
+
+	rows, err = s.db.QueryContext(ctx, `
+		SELECT kcu.CONSTRAINT_NAME, kcu.COLUMN_NAME, pk.TABLE_NAME, pk.COLUMN_NAME
+		FROM INFORMATION_SCHEMA.REFERENTIAL_CONSTRAINTS rc
+		JOIN INFORMATION_SCHEMA.KEY_COLUMN_USAGE kcu
+			ON kcu.CONSTRAINT_SCHEMA = rc.CONSTRAINT_SCHEMA AND kcu.CONSTRAINT_NAME = rc.CONSTRAINT_NAME
+		JOIN INFORMATION_SCHEMA.KEY_COLUMN_USAGE pk
+			ON pk.CONSTRAINT_SCHEMA = rc.UNIQUE_CONSTRAINT_SCHEMA AND pk.CONSTRAINT_NAME = rc.UNIQUE_CONSTRAINT_NAME
+			AND pk.ORDINAL_POSITION = kcu.POSITION_IN_UNIQUE_CONSTRAINT
+		WHERE kcu.TABLE_SCHEMA = @p1 AND kcu.TABLE_NAME = @p2
+		ORDER BY kcu.CONSTRAINT_NAME, kcu.ORDINAL_POSITION`, schemaName, tableName)
+	if err != nil {
+		return DBInfo{}, fmt.Errorf("failed to get foreign key info for table %s: %w", tableName, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var constraintName, colName, foreignTableName, foreignColName string
+		if err := rows.Scan(&constraintName, &colName, &foreignTableName, &foreignColName); err != nil {
+			return DBInfo{}, fmt.Errorf("scan failed: %w", err)
+		}
+		dbInfo.ForeignKeys = appendForeignKeyColumn(dbInfo.ForeignKeys, tableName, constraintName, colName, foreignTableName, foreignColName)
+	}
+	if err := rows.Err(); err != nil {
+		return DBInfo{}, fmt.Errorf("failed to get foreign key info for table %s: %w", tableName, err)
+	}
+	return dbInfo, nil
+}
+
+// PrepareInsertStatement prepares the statement the connections of SpannerDB turn into an
+// InsertOrUpdate mutation of the row.
+func (s *SpannerDB) PrepareInsertStatement(ctx context.Context, dbInfo DBInfo) (*sql.Stmt, error) {
+	stmt, err := s.db.PrepareContext(ctx, spannerInsertStatement("INSERT OR UPDATE", dbInfo.TableName, dbInfo.InsertColumns()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	return stmt, nil
+}
+
+// spannerInsertStatement builds an INSERT of columns into table. verb is "INSERT OR UPDATE" or
+// "INSERT OR IGNORE".
+func spannerInsertStatement(verb, table string, columns []ColumnInfo) string {
+	names := make([]string, len(columns))
+	placeholders := make([]string, len(columns))
+	for idx, colInfo := range columns {
+		names[idx] = colInfo.ColumnName
+		placeholders[idx] = spannerPlaceholder(idx + 1)
+	}
+	return fmt.Sprintf("%s INTO %s (%s) VALUES (%s)", verb, table, strings.Join(names, ", "), strings.Join(placeholders, ", "))
+}
+
+// spannerKeyArgs converts the values of key columns for the placeholders of spannerKeyPlaceholder.
+func spannerKeyArgs(dbInfo DBInfo, columnNames, values []string) []interface{} {
+	args := make([]interface{}, len(values))
+	for idx, colName := range columnNames {
+		args[idx] = keyArg(values[idx], spannerColumn(dbInfo, colName))
+	}
+	return args
+}
+
+// spannerColumn returns the column of dbInfo named colName, or a string column if there is none.
+func spannerColumn(dbInfo DBInfo, colName string) ColumnInfo {
+	for _, colInfo := range dbInfo.Columns {
+		if colInfo.ColumnName == colName {
+			return colInfo
+		}
+	}
+	return ColumnInfo{ColumnName: colName, DataType: StringType}
+}
+
+// ParentRecordExists checks if a record exists in the given table for specific column values in Spanner.
+func (s *SpannerDB) ParentRecordExists(ctx context.Context, dbInfo DBInfo, columnNames, values []string) (bool, error) {
+	conditions := make([]string, len(columnNames))
+	for idx, colName := range columnNames {
+		conditions[idx] = fmt.Sprintf("%s = %s", colName, spannerKeyPlaceholder(spannerColumn(dbInfo, colName), idx+1))
+	}
+	query := fmt.Sprintf("SELECT EXISTS(SELECT 1 FROM %s WHERE %s)", dbInfo.TableName, strings.Join(conditions, " AND "))
+	var exists bool
+	if err := s.db.QueryRowContext(ctx, query, spannerKeyArgs(dbInfo, columnNames, values)...).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check existence of record %s: %w", formatParentKey(dbInfo.TableName, columnNames, values), err)
+	}
+	return exists, nil
+}
+
+// UpdateRow sets columns of an existing row.
+func (s *SpannerDB) UpdateRow(ctx context.Context, dbInfo DBInfo, keyColumns, keyValues, columnNames, values []string) error {
+	assignments := make([]string, len(columnNames))
+	for idx, colName := range columnNames {
+		assignments[idx] = fmt.Sprintf("%s = %s", colName, spannerKeyPlaceholder(spannerColumn(dbInfo, colName), idx+1))
+	}
+	conditions := make([]string, len(keyColumns))
+	for idx, colName := range keyColumns {
+		conditions[idx] = fmt.Sprintf("%s = %s", colName, spannerKeyPlaceholder(spannerColumn(dbInfo, colName), len(columnNames)+idx+1))
+	}
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s", dbInfo.TableName, strings.Join(assignments, ", "), strings.Join(conditions, " AND "))
+	args := append(spannerKeyArgs(dbInfo, columnNames, values), spannerKeyArgs(dbInfo, keyColumns, keyValues)...)
+	if _, err := s.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to update record %s: %w", formatParentKey(dbInfo.TableName, keyColumns, keyValues), err)
+	}
+	return nil
+}
+
+// spannerFilter returns filter with a condition matching every row if it has none, since Spanner
+// requires a WHERE clause in UPDATE and DELETE statements.
+func spannerFilter(filter RowFilter) RowFilter {
+	if filter.Where == "" && len(filter.KeyColumns) == 0 {
+		filter.Where = "TRUE"
+	}
+	return filter
+}
+
+// ClearColumns sets columns of the rows matching filter to NULL with partitioned DML, which is
+// not bound by the mutation limit of a transaction.
+func (s *SpannerDB) ClearColumns(ctx context.Context, dbInfo DBInfo, columnNames []string, filter RowFilter) error {
+	if err := s.connector.flush(ctx); err != nil {
+		return err
+	}
+	assignments := make([]string, len(columnNames))
+	for idx, colName := range columnNames {
+		assignments[idx] = colName + " = NULL"
+	}
+	return keyBatches(filter, func(keys [][]string) error {
+		where, args := filterCondition(dbInfo, spannerFilter(filter), keys, spannerPlaceholder)
+		query := fmt.Sprintf("UPDATE %s SET %s%s", dbInfo.TableName, strings.Join(assignments, ", "), where)
+		if _, err := s.connector.backend.Update(ctx, query, driverValues(args), true); err != nil {
+			return fmt.Errorf("failed to clear columns %s of table %s: %w", strings.Join(columnNames, ", "), dbInfo.TableName, err)
+		}
+		return nil
+	})
+}
+
+// DeleteRows deletes the rows of a table matching filter with partitioned DML. Spanner only
+// reports a lower bound of the number of rows partitioned DML deletes.
+func (s *SpannerDB) DeleteRows(ctx context.Context, dbInfo DBInfo, filter RowFilter) (int64, error) {
+	if err := s.connector.flush(ctx); err != nil {
+		return 0, err
+	}
+	var deleted int64
+	err := keyBatches(filter, func(keys [][]string) error {
+		where, args := filterCondition(dbInfo, spannerFilter(filter), keys, spannerPlaceholder)
+		affected, err := s.connector.backend.Update(ctx, "DELETE FROM "+dbInfo.TableName+where, driverValues(args), true)
+		if err != nil {
+			return fmt.Errorf("failed to delete rows of table %s: %w", dbInfo.TableName, err)
+		}
+		deleted += affected
+		return nil
+	})
+	return deleted, err
+}
+
+// driverValues converts the arguments of a statement to driver values, like database/sql does.
+func driverValues(args []interface{}) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for idx, arg := range args {
+		value, err := driver.DefaultParameterConverter.ConvertValue(arg)
+		if err != nil {
+			value = fmt.Sprint(arg)
+		}
+		values[idx] = value
+	}
+	return values
+}
+
+// EnsureParentRecordExists checks if a record with the given foreignKeyValues exists in the parent
+// table and, if not, writes one with an Insert mutation. Spanner does not assign keys, so parent
+// records referenced by database-assigned columns cannot be created.
+func (s *SpannerDB) EnsureParentRecordExists(ctx context.Context, parentDBInfo DBInfo, foreignColumnNames, foreignKeyValues []string, dbSchema map[string]DBInfo) ([]string, error) {
+	return ensureParentRecord(ctx, s, &s.parentRecordSettings, parentDBInfo, foreignColumnNames, foreignKeyValues, dbSchema, func(parentCols []string, parentValues []interface{}, returning string) (interface{}, bool, error) {
+		if returning != "" {
+			return nil, false, fmt.Errorf("cannot read back generated column %s.%s", parentDBInfo.TableName, returning)
+		}
+		columns := make([]ColumnInfo, len(parentCols))
+		for idx, colName := range parentCols {
+			columns[idx] = ColumnInfo{ColumnName: colName}
+		}
+		result, err := s.db.ExecContext(ctx, spannerInsertStatement("INSERT OR IGNORE", parentDBInfo.TableName, columns), parentValues...)
+		if err != nil {
+			return nil, false, err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return nil, false, err
+		}
+		return nil, affected > 0, nil
+	})
+}
+
+// ReadRows reads the rows of a table matching filter.
+func (s *SpannerDB) ReadRows(ctx context.Context, dbInfo DBInfo, columns []ColumnInfo, filter RowFilter, fn func(values []string) error) error {
+	return readFilteredRows(ctx, s.db, dbInfo.TableName, dbInfo, columns, filter, spannerPlaceholder, fn)
+}
+
+// CheckTableAccess verifies that the table can be read. Writing cannot be checked without
+// writing a row, since Spanner has no transactions that roll back mutations after applying them;
+// the INSERT and UPDATE privileges are assumed.
+func (s *SpannerDB) CheckTableAccess(ctx context.Context, dbInfo DBInfo, privileges []string) error {
+	for _, privilege := range privileges {
+		if privilege != PrivilegeSelect {
+			continue
+		}
+		rows, err := s.db.QueryContext(ctx, fmt.Sprintf("SELECT 1 FROM %s WHERE 1 = 0", dbInfo.TableName))
+		if err == nil {
+			err = rows.Close()
+		}
+		if err != nil {
+			return &TableAccessError{TableName: dbInfo.TableName, Privilege: privilege, Err: err}
+		}
+	}
+	return nil
+}
+
+// spannerInsertPattern matches the statements of spannerInsertStatement.
+var spannerInsertPattern = regexp.MustCompile(`^INSERT OR (UPDATE|IGNORE) INTO (\S+) \(([^)]*)\) VALUES `)
+
+// spannerConnector is a driver.Connector whose connections turn the INSERT statements of SpannerDB
+// into mutations, buffering up to batchSize rows, and run the other statements through the
+// backend. The buffered rows are written before any other statement runs.
+type spannerConnector struct {
+	backend   spannerBackend
+	batchSize int
+
+	mu     sync.Mutex
+	types  map[string]map[string]ColumnDataType // Column types per table, from the schema
+	buffer []spannerMutation
+}
+
+func (c *spannerConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return &spannerConn{c}, nil
+}
+func (c *spannerConnector) Driver() driver.Driver { return spannerDriver{c} }
+
+// setTypes records the column types of the tables, which mutations are converted by.
+func (c *spannerConnector) setTypes(schemaInfo map[string]DBInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for tableName, dbInfo := range schemaInfo {
+		types := make(map[string]ColumnDataType)
+		for _, colInfo := range dbInfo.Columns {
+			types[colInfo.ColumnName] = colInfo.DataType
+		}
+		c.types[tableName] = types
+	}
+}
+
+// write writes or buffers the row of an INSERT statement and returns the number of rows written.
+// Rows inserted without updating an existing row, i.e. parent records, are written immediately,
+// since the caller needs to know whether the row existed.
+func (c *spannerConnector) write(ctx context.Context, m spannerMutation) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	m.Types = make([]ColumnDataType, len(m.Columns))
+	for idx, colName := range m.Columns {
+		m.Types[idx] = c.types[m.Table][colName]
+	}
+	if !m.Insert && c.batchSize > 1 {
+		// A batch holds the rows of one table: the groups of a BatchWrite are applied in no particular order
+		if len(c.buffer) > 0 && c.buffer[0].Table != m.Table {
+			if err := c.flushLocked(ctx); err != nil {
+				return 0, err
+			}
+		}
+		c.buffer = append(c.buffer, m)
+		if len(c.buffer) < c.batchSize {
+			return 1, nil
+		}
+		return 1, c.flushLocked(ctx)
+	}
+
+	if err := c.flushLocked(ctx); err != nil {
+		return 0, err
+	}
+	errs, err := c.backend.Write(ctx, []spannerMutation{m})
+	if err == nil {
+		err = errs[0]
+	}
+	if errors.Is(err, errSpannerRowExists) && m.Insert {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
+// flush writes the buffered rows.
+func (c *spannerConnector) flush(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.flushLocked(ctx)
+}
+
+// flushLocked writes the buffered rows. The rows Spanner rejects were already reported as
+// written; each is logged, and the error names how many there were.
+func (c *spannerConnector) flushLocked(ctx context.Context) error {
+	if len(c.buffer) == 0 {
+		return nil
+	}
+	mutations := c.buffer
+	c.buffer = nil
+	errs, err := c.backend.Write(ctx, mutations)
+	if err != nil {
+		return fmt.Errorf("failed to write %d row(s) of table %s: %w", len(mutations), mutations[0].Table, err)
+	}
+	var failed []error
+	for idx, rowErr := range errs {
+		if rowErr != nil {
+			log.Printf("Error: Spanner rejected buffered row of table %s %v: %v\n", mutations[idx].Table, mutations[idx].Values, rowErr)
+			failed = append(failed, rowErr)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d buffered row(s) of table %s were not written: %w", len(failed), mutations[0].Table, errors.Join(failed...))
+	}
+	return nil
+}
+
+// spannerDriver is the driver of a spannerConnector. Connections are only opened through it.
+type spannerDriver struct{ c *spannerConnector }
+
+func (d spannerDriver) Open(name string) (driver.Conn, error) { return &spannerConn{d.c}, nil }
+
+// spannerConn runs statements through a spannerConnector.
+type spannerConn struct{ c *spannerConnector }
+
+func (c *spannerConn) Prepare(query string) (driver.Stmt, error) {
+	return &spannerStmt{conn: c, query: query}, nil
+}
+func (c *spannerConn) Close() error { return nil }
+func (c *spannerConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported for Spanner")
+}
+
+func (c *spannerConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	values := namedValues(args)
+	if match := spannerInsertPattern.FindStringSubmatch(query); match != nil {
+		columns := strings.Split(match[3], ", ")
+		if len(columns) != len(values) {
+			return nil, fmt.Errorf("statement has %d columns but %d values: %s", len(columns), len(values), query)
+		}
+		written, err := c.c.write(ctx, spannerMutation{Table: match[2], Columns: columns, Values: values, Insert: match[1] == "IGNORE"})
+		if err != nil {
+			return nil, err
+		}
+		return driver.RowsAffected(written), nil
+	}
+	if err := c.c.flush(ctx); err != nil {
+		return nil, err
+	}
+	affected, err := c.c.backend.Update(ctx, query, values, false)
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(affected), nil
+}
+
+func (c *spannerConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if err := c.c.flush(ctx); err != nil {
+		return nil, err
+	}
+	it, err := c.c.backend.Query(ctx, query, namedValues(args))
+	if err != nil {
+		return nil, err
+	}
+	return &spannerRows{it: it}, nil
+}
+
+func (c *spannerConn) Ping(ctx context.Context) error {
+	rows, err := c.QueryContext(ctx, "SELECT 1", nil)
+	if err != nil {
+		return err
+	}
+	return rows.Close()
+}
+
+type spannerStmt struct {
+	conn  *spannerConn
+	query string
+}
+
+func (s *spannerStmt) Close() error  { return nil }
+func (s *spannerStmt) NumInput() int { return -1 }
+
+func (s *spannerStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), valueArgs(args))
+}
+
+func (s *spannerStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), valueArgs(args))
+}
+
+func (s *spannerStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.conn.ExecContext(ctx, s.query, args)
+}
+
+func (s *spannerStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.conn.QueryContext(ctx, s.query, args)
+}
+
+// spannerRows are the rows of a query read from a spannerRowIterator.
+type spannerRows struct{ it spannerRowIterator }
+
+func (r *spannerRows) Columns() []string { return r.it.Columns() }
+
+func (r *spannerRows) Close() error {
+	r.it.Stop()
+	return nil
+}
+
+func (r *spannerRows) Next(dest []driver.Value) error {
+	values, err := r.it.Next()
+	if err != nil {
+		return err
+	}
+	copy(dest, values)
+	return nil
+}
@@ -0,0 +1,213 @@
+//go:build spanner
+// +build spanner
+
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+)
+
+// spannerClient is the spannerBackend of the Spanner client.
+type spannerClient struct {
+	client *spanner.Client
+}
+
+// newSpannerBackend connects to database, e.g. "projects/p/instances/i/databases/d".
+func newSpannerBackend(ctx context.Context, database string) (spannerBackend, error) {
+	client, err := spanner.NewClient(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+	return &spannerClient{client: client}, nil
+}
+
+// mutation converts m to a mutation of the Spanner client.
+func (c *spannerClient) mutation(m spannerMutation) *spanner.Mutation {
+	values := make([]interface{}, len(m.Values))
+	for idx, value := range m.Values {
+		if t, ok := value.(time.Time); ok && m.Types[idx] == DateType {
+			values[idx] = civil.DateOf(t)
+			continue
+		}
+		values[idx] = value
+	}
+	if m.Insert {
+		return spanner.Insert(m.Table, m.Columns, values)
+	}
+	return spanner.InsertOrUpdate(m.Table, m.Columns, values)
+}
+
+func (c *spannerClient) Write(ctx context.Context, mutations []spannerMutation) ([]error, error) {
+	groups := make([]*spanner.MutationGroup, len(mutations))
+	for idx, m := range mutations {
+		groups[idx] = &spanner.MutationGroup{Mutations: []*spanner.Mutation{c.mutation(m)}}
+	}
+	errs := make([]error, len(mutations))
+	err := c.client.BatchWrite(ctx, groups).Do(func(r *sppb.BatchWriteResponse) error {
+		if r.Status == nil || codes.Code(r.Status.Code) == codes.OK {
+			return nil
+		}
+		var groupErr error = errors.New(r.Status.Message)
+		if codes.Code(r.Status.Code) == codes.AlreadyExists {
+			groupErr = fmt.Errorf("%w: %s", errSpannerRowExists, r.Status.Message)
+		}
+		for _, idx := range r.Indexes {
+			errs[idx] = groupErr
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return errs, nil
+}
+
+// statement builds a statement whose parameters are @p1, @p2, ...
+func (c *spannerClient) statement(query string, args []driver.Value) spanner.Statement {
+	stmt := spanner.NewStatement(query)
+	for idx, arg := range args {
+		stmt.Params["p"+strconv.Itoa(idx+1)] = arg
+	}
+	return stmt
+}
+
+func (c *spannerClient) Query(ctx context.Context, query string, args []driver.Value) (spannerRowIterator, error) {
+	it := &spannerClientRows{it: c.client.Single().Query(ctx, c.statement(query, args))}
+	// The columns are known once the first row, or the end of the rows, is read
+	row, err := it.it.Next()
+	if err != nil && err != iterator.Done {
+		it.it.Stop()
+		return nil, err
+	}
+	it.first, it.done = row, err == iterator.Done
+	if it.it.Metadata != nil {
+		for _, field := range it.it.Metadata.GetRowType().GetFields() {
+			it.columns = append(it.columns, field.GetName())
+		}
+	}
+	return it, nil
+}
+
+func (c *spannerClient) Update(ctx context.Context, query string, args []driver.Value, partitioned bool) (int64, error) {
+	stmt := c.statement(query, args)
+	if partitioned {
+		return c.client.PartitionedUpdate(ctx, stmt)
+	}
+	var affected int64
+	_, err := c.client.ReadWriteTransaction(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+		var err error
+		affected, err = txn.Update(ctx, stmt)
+		return err
+	})
+	return affected, err
+}
+
+func (c *spannerClient) Close() error {
+	c.client.Close()
+	return nil
+}
+
+// spannerClientRows reads the rows of a query of the Spanner client.
+type spannerClientRows struct {
+	it      *spanner.RowIterator
+	columns []string
+	first   *spanner.Row // The row read to learn the columns, until Next returns it
+	done    bool
+}
+
+func (r *spannerClientRows) Columns() []string { return r.columns }
+func (r *spannerClientRows) Stop()             { r.it.Stop() }
+
+func (r *spannerClientRows) Next() ([]driver.Value, error) {
+	row := r.first
+	r.first = nil
+	if row == nil {
+		if r.done {
+			return nil, io.EOF
+		}
+		var err error
+		if row, err = r.it.Next(); err == iterator.Done {
+			r.done = true
+			return nil, io.EOF
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	values := make([]driver.Value, row.Size())
+	for idx := range values {
+		var column spanner.GenericColumnValue
+		if err := row.Column(idx, &column); err != nil {
+			return nil, err
+		}
+		value, err := spannerValue(column)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", row.ColumnName(idx), err)
+		}
+		values[idx] = value
+	}
+	return values, nil
+}
+
+// spannerValue converts a column value of the Spanner client to a driver value.
+func spannerValue(column spanner.GenericColumnValue) (driver.Value, error) {
+	switch column.Type.GetCode() {
+	case sppb.TypeCode_INT64:
+		var v spanner.NullInt64
+		if err := column.Decode(&v); err != nil || !v.Valid {
+			return nil, err
+		}
+		return v.Int64, nil
+	case sppb.TypeCode_FLOAT64:
+		var v spanner.NullFloat64
+		if err := column.Decode(&v); err != nil || !v.Valid {
+			return nil, err
+		}
+		return v.Float64, nil
+	case sppb.TypeCode_BOOL:
+		var v spanner.NullBool
+		if err := column.Decode(&v); err != nil || !v.Valid {
+			return nil, err
+		}
+		return v.Bool, nil
+	case sppb.TypeCode_TIMESTAMP:
+		var v spanner.NullTime
+		if err := column.Decode(&v); err != nil || !v.Valid {
+			return nil, err
+		}
+		return v.Time, nil
+	case sppb.TypeCode_DATE:
+		var v spanner.NullDate
+		if err := column.Decode(&v); err != nil || !v.Valid {
+			return nil, err
+		}
+		return v.Date.In(time.UTC), nil
+	case sppb.TypeCode_BYTES:
+		var v []byte
+		if err := column.Decode(&v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	default:
+		// STRING, NUMERIC, JSON and anything else is read as its string form
+		var v spanner.NullString
+		if err := column.Decode(&v); err != nil {
+			return fmt.Sprint(column.Value), nil
+		}
+		if !v.Valid {
+			return nil, nil
+		}
+		return v.StringVal, nil
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !spanner
+// +build !spanner
+
+package database
+
+import (
+	"context"
+	"fmt"
+)
+
+// newSpannerBackend returns an error indicating that Spanner support is not compiled.
+func newSpannerBackend(ctx context.Context, database string) (spannerBackend, error) {
+	return nil, fmt.Errorf("Spanner support not compiled. Build with -tags spanner to enable")
+}
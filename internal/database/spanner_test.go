@@ -0,0 +1,137 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSpanner is a spannerBackend that records the mutations and statements it is given.
+type fakeSpanner struct {
+	writes  [][]spannerMutation
+	queries []string
+	updates []string
+	exists  map[string]bool // Tables whose Insert mutations fail with errSpannerRowExists
+}
+
+func (f *fakeSpanner) Write(ctx context.Context, mutations []spannerMutation) ([]error, error) {
+	f.writes = append(f.writes, mutations)
+	errs := make([]error, len(mutations))
+	for idx, m := range mutations {
+		if m.Insert && f.exists[m.Table] {
+			errs[idx] = errSpannerRowExists
+		}
+	}
+	return errs, nil
+}
+
+func (f *fakeSpanner) Query(ctx context.Context, query string, args []driver.Value) (spannerRowIterator, error) {
+	f.queries = append(f.queries, query)
+	return &fakeSpannerRows{rows: [][]driver.Value{{true}}}, nil
+}
+
+func (f *fakeSpanner) Update(ctx context.Context, query string, args []driver.Value, partitioned bool) (int64, error) {
+	f.updates = append(f.updates, query)
+	return 2, nil
+}
+
+func (f *fakeSpanner) Close() error { return nil }
+
+type fakeSpannerRows struct{ rows [][]driver.Value }
+
+func (r *fakeSpannerRows) Columns() []string { return []string{"exists"} }
+func (r *fakeSpannerRows) Stop()             {}
+func (r *fakeSpannerRows) Next() ([]driver.Value, error) {
+	if len(r.rows) == 0 {
+		return nil, io.EOF
+	}
+	row := r.rows[0]
+	r.rows = r.rows[1:]
+	return row, nil
+}
+
+func Test_spannerDataType(t *testing.T) {
+	assert.Equal(t, StringType, spannerDataType("STRING(MAX)"))
+	assert.Equal(t, IntegerType, spannerDataType("INT64"))
+	assert.Equal(t, FloatType, spannerDataType("NUMERIC"))
+	assert.Equal(t, DateType, spannerDataType("DATE"))
+	assert.Equal(t, TimestampType, spannerDataType("TIMESTAMP"))
+	assert.Equal(t, UnknownType, spannerDataType("ARRAY<INT64>"))
+}
+
+func Test_addInterleaveForeignKeys(t *testing.T) {
+	schemaInfo := map[string]DBInfo{
+		"singers": {TableName: "singers", PrimaryKeyColumns: []string{"singer_id"}},
+		"albums":  {TableName: "albums", PrimaryKeyColumns: []string{"singer_id", "album_id"}},
+		"songs":   {TableName: "songs", PrimaryKeyColumns: []string{"singer_id", "album_id", "track_id"}},
+	}
+	addInterleaveForeignKeys(schemaInfo, map[string]string{"singers": "", "albums": "singers", "songs": "albums"})
+
+	assert.Empty(t, schemaInfo["singers"].ForeignKeys)
+	assert.Equal(t, []ForeignKeyInfo{{
+		ConstraintName:     "INTERLEAVE IN PARENT albums",
+		TableName:          "songs",
+		ColumnNames:        []string{"singer_id", "album_id"},
+		ForeignTableName:   "albums",
+		ForeignColumnNames: []string{"singer_id", "album_id"},
+	}}, schemaInfo["songs"].ForeignKeys)
+	assert.Equal(t, []string{"singer_id"}, schemaInfo["albums"].ForeignKeys[0].ColumnNames)
+}
+
+func Test_SpannerDB(t *testing.T) {
+	ctx := context.Background()
+	dbInfo := DBInfo{
+		TableName:         "singers",
+		PrimaryKeyColumns: []string{"singer_id"},
+		Columns: []ColumnInfo{
+			{ColumnName: "singer_id", DataType: IntegerType},
+			{ColumnName: "name", DataType: StringType, IsNullable: true},
+		},
+	}
+
+	t.Run("行はバッチサイズごとにミューテーションとして書き込まれる", func(t *testing.T) {
+		backend := &fakeSpanner{}
+		s := newSpannerDB(backend, 2)
+		stmt, err := s.PrepareInsertStatement(ctx, dbInfo)
+		require.NoError(t, err)
+		for _, id := range []int64{1, 2, 3} {
+			_, err := stmt.ExecContext(ctx, id, "name")
+			require.NoError(t, err)
+		}
+		require.Len(t, backend.writes, 1)
+		assert.Equal(t, []driver.Value{int64(2), "name"}, backend.writes[0][1].Values)
+
+		// Rows are written before the following statement runs
+		_, err = s.ParentRecordExists(ctx, dbInfo, []string{"singer_id"}, []string{"3"})
+		require.NoError(t, err)
+		require.Len(t, backend.writes, 2)
+		assert.Equal(t, "singers", backend.writes[1][0].Table)
+		assert.Equal(t, []string{"singer_id", "name"}, backend.writes[1][0].Columns)
+		assert.False(t, backend.writes[1][0].Insert)
+		require.NoError(t, s.Close())
+	})
+
+	t.Run("既存の親レコードは作成済みとして扱われない", func(t *testing.T) {
+		backend := &fakeSpanner{exists: map[string]bool{"singers": true}}
+		s := newSpannerDB(backend, 1)
+		result, err := s.db.ExecContext(ctx, spannerInsertStatement("INSERT OR IGNORE", "singers", dbInfo.Columns), int64(1), "name")
+		require.NoError(t, err)
+		affected, err := result.RowsAffected()
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), affected)
+		assert.True(t, backend.writes[0][0].Insert)
+	})
+
+	t.Run("条件のない削除はパーティション化DMLで全行を対象にする", func(t *testing.T) {
+		backend := &fakeSpanner{}
+		s := newSpannerDB(backend, 1)
+		deleted, err := s.DeleteRows(ctx, dbInfo, RowFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), deleted)
+		assert.Equal(t, []string{"DELETE FROM singers WHERE (TRUE)"}, backend.updates)
+	})
+}
@@ -0,0 +1,379 @@
+package database
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SQLFileDB is the "sqlfile" database type. Instead of running the statements of an import, it
+// writes them with their values inlined to a .sql file, in the SQL of another database type (the
+// dialect), for review or to be run by someone who has access to the database.
+//
+// The connection string is the path of the file followed by options, e.g.
+// "import.sql?schema=schema.json&dialect=postgres". schema is a schema snapshot, e.g. written by
+// schema dump, which stands in for the catalog; dialect defaults to the database type it was read
+// from. Nothing can be read back from the file, so parent records are never found and created once
+// each, with statements that leave existing rows alone, and tables read as empty.
+type SQLFileDB struct {
+	sqlDialect // The client of the dialect, connected to the recorder
+	recorder   *sqlRecorder
+	tables     map[string]DBInfo
+
+	mu      sync.Mutex
+	parents map[string][]string // Keys of the parent records written, by formatParentKey
+}
+
+// sqlDialect is the client of a dialect of SQLFileDB, which writes every kind of statement.
+type sqlDialect interface {
+	DBClient
+	ParentConfigurer
+	RowUpdater
+	RowDeleter
+	SequenceResetter
+	ViewRefresher
+	RowReader
+}
+
+// NewSQLFileDB creates the file of connStr and a client writing to it.
+func NewSQLFileDB(ctx context.Context, connStr string) (*SQLFileDB, error) {
+	path, rawQuery, _ := strings.Cut(connStr, "?")
+	options, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sqlfile options %q: %w", rawQuery, err)
+	}
+	if path == "" {
+		return nil, errors.New("sqlfile connection string has no file path")
+	}
+	schemaFile := options.Get("schema")
+	if schemaFile == "" {
+		return nil, errors.New("sqlfile connection string has no schema option, e.g. out.sql?schema=schema.json")
+	}
+
+	f, err := os.Open(schemaFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open schema snapshot: %w", err)
+	}
+	snapshot, err := ReadSchemaSnapshot(f)
+	f.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema snapshot %s: %w", schemaFile, err)
+	}
+	dialect := options.Get("dialect")
+	if dialect == "" {
+		dialect = snapshot.DBType
+	}
+	if dialect == "tidb" || dialect == "singlestore" {
+		dialect = "mysql" // TiDB and SingleStore run MySQL's SQL
+	}
+	if dialect == "yugabyte" {
+		dialect = "postgres" // YugabyteDB runs PostgreSQL's SQL
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SQL file: %w", err)
+	}
+	recorder := &sqlRecorder{file: out, w: bufio.NewWriter(out), dialect: dialect}
+	db := sql.OpenDB(recorder)
+	// A single connection writes the statements in the order they are run
+	db.SetMaxOpenConns(1)
+
+	var client sqlDialect
+	switch dialect {
+	case "postgres":
+		client = &PostgresDB{db: db, schemaName: snapshot.Schema}
+	case "mysql":
+		client = &MySQLDB{db: db}
+	case "db2":
+		client, err = newDB2Dialect(db, snapshot.Schema)
+	case "hana":
+		client = &HANADB{db: db, schemaName: strings.ToUpper(snapshot.Schema)}
+	default:
+		err = fmt.Errorf("unsupported sqlfile dialect: %q (expected postgres, mysql, db2 or hana)", dialect)
+	}
+	if err != nil {
+		db.Close()
+		recorder.close()
+		os.Remove(path)
+		return nil, err
+	}
+	log.Printf("Writing %s statements to %s instead of running them.\n", dialect, path)
+	return &SQLFileDB{sqlDialect: client, recorder: recorder, tables: snapshot.Tables, parents: make(map[string][]string)}, nil
+}
+
+// GetSchemaInfo returns the tables of the schema snapshot, whatever schemaName is.
+func (s *SQLFileDB) GetSchemaInfo(ctx context.Context, schemaName string) (map[string]DBInfo, error) {
+	tables := make(map[string]DBInfo, len(s.tables))
+	for name, dbInfo := range s.tables {
+		tables[name] = dbInfo
+	}
+	return tables, nil
+}
+
+// ParentRecordExists reports whether the parent record was written to the file before.
+func (s *SQLFileDB) ParentRecordExists(ctx context.Context, dbInfo DBInfo, columnNames, values []string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.parents[formatParentKey(dbInfo.TableName, columnNames, values)]
+	return ok, nil
+}
+
+// EnsureParentRecordExists writes the INSERT of a parent record once per key. Keys the database
+// assigns cannot be read back from a file, so such parent records are an error.
+func (s *SQLFileDB) EnsureParentRecordExists(ctx context.Context, parentDBInfo DBInfo, foreignColumnNames, foreignKeyValues []string, dbSchema map[string]DBInfo) ([]string, error) {
+	for _, colInfo := range parentDBInfo.Columns {
+		for _, colName := range foreignColumnNames {
+			if colInfo.ColumnName == colName && isDatabaseAssigned(colInfo) {
+				return nil, fmt.Errorf("cannot create parent record %s in an SQL file: the database assigns %s.%s",
+					formatParentKey(parentDBInfo.TableName, foreignColumnNames, foreignKeyValues), parentDBInfo.TableName, colName)
+			}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := formatParentKey(parentDBInfo.TableName, foreignColumnNames, foreignKeyValues)
+	if key, ok := s.parents[id]; ok {
+		return key, nil
+	}
+	key, err := s.sqlDialect.EnsureParentRecordExists(ctx, parentDBInfo, foreignColumnNames, foreignKeyValues, dbSchema)
+	if err != nil {
+		return nil, err
+	}
+	s.parents[id] = key
+	return key, nil
+}
+
+// CheckTableAccess succeeds: the privileges are those of whoever runs the file.
+func (s *SQLFileDB) CheckTableAccess(ctx context.Context, dbInfo DBInfo, privileges []string) error {
+	return nil
+}
+
+// RecordImportRun does nothing, since the statements have not run yet.
+func (s *SQLFileDB) RecordImportRun(ctx context.Context, run ImportRun) error {
+	return nil
+}
+
+// Close writes out the remaining statements and closes the file.
+func (s *SQLFileDB) Close() error {
+	return errors.Join(s.sqlDialect.Close(), s.recorder.close())
+}
+
+// sqlRecorder is a driver.Connector whose connections write each statement they execute to a
+// file, with the bound values inlined as literals of the dialect.
+type sqlRecorder struct {
+	mu      sync.Mutex
+	file    *os.File
+	w       *bufio.Writer
+	dialect string
+	err     error // The first error writing the file
+}
+
+func (r *sqlRecorder) Connect(ctx context.Context) (driver.Conn, error) { return &recorderConn{r}, nil }
+func (r *sqlRecorder) Driver() driver.Driver                            { return recorderDriver{r} }
+
+// write appends a statement to the file.
+func (r *sqlRecorder) write(query string, args []driver.NamedValue) error {
+	statement, err := renderStatement(r.dialect, query, args)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err == nil {
+		_, r.err = fmt.Fprintf(r.w, "%s;\n", statement)
+	}
+	return r.err
+}
+
+func (r *sqlRecorder) close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err == nil {
+		r.err = r.w.Flush()
+	}
+	return errors.Join(r.err, r.file.Close())
+}
+
+// recorderDriver is the driver of a sqlRecorder. Connections are only opened through the recorder.
+type recorderDriver struct{ r *sqlRecorder }
+
+func (d recorderDriver) Open(name string) (driver.Conn, error) { return &recorderConn{d.r}, nil }
+
+// recorderConn writes the statements it executes. Queries return no rows, except the existence
+// checks of parent records, which answer false, as the rows the file inserts cannot be read.
+type recorderConn struct{ r *sqlRecorder }
+
+func (c *recorderConn) Prepare(query string) (driver.Stmt, error) {
+	return &recorderStmt{conn: c, query: query}, nil
+}
+func (c *recorderConn) Close() error              { return nil }
+func (c *recorderConn) Begin() (driver.Tx, error) { return recorderTx{}, nil }
+
+func (c *recorderConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if err := c.r.write(query, args); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c *recorderConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if strings.HasPrefix(query, "SELECT EXISTS(") {
+		return &recorderRows{columns: []string{"exists"}, values: [][]driver.Value{{false}}}, nil
+	}
+	return &recorderRows{}, nil
+}
+
+// recorderTx does nothing: the statements are written as they run and are not rolled back.
+type recorderTx struct{}
+
+func (recorderTx) Commit() error   { return nil }
+func (recorderTx) Rollback() error { return nil }
+
+type recorderStmt struct {
+	conn  *recorderConn
+	query string
+}
+
+func (s *recorderStmt) Close() error  { return nil }
+func (s *recorderStmt) NumInput() int { return -1 }
+
+func (s *recorderStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), valueArgs(args))
+}
+
+func (s *recorderStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), valueArgs(args))
+}
+
+func (s *recorderStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.conn.ExecContext(ctx, s.query, args)
+}
+
+func (s *recorderStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.conn.QueryContext(ctx, s.query, args)
+}
+
+// valueArgs converts the arguments of the Exec and Query methods of older drivers.
+func valueArgs(values []driver.Value) []driver.NamedValue {
+	args := make([]driver.NamedValue, len(values))
+	for idx, value := range values {
+		args[idx] = driver.NamedValue{Ordinal: idx + 1, Value: value}
+	}
+	return args
+}
+
+// recorderRows are the fixed rows of a query.
+type recorderRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *recorderRows) Columns() []string { return r.columns }
+func (r *recorderRows) Close() error      { return nil }
+
+func (r *recorderRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+// renderStatement replaces the placeholders of query ($1, $2, ... for postgres, ? otherwise) with
+// the literals of args. Placeholders in string literals and quoted identifiers are left alone.
+func renderStatement(dialect, query string, args []driver.NamedValue) (string, error) {
+	var b strings.Builder
+	next := 0
+	var quote byte
+	for idx := 0; idx < len(query); idx++ {
+		c := query[idx]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '?' && dialect != "postgres":
+			if next >= len(args) {
+				return "", fmt.Errorf("statement has more placeholders than values: %s", query)
+			}
+			b.WriteString(sqlLiteral(dialect, args[next].Value))
+			next++
+			continue
+		case c == '$' && dialect == "postgres" && idx+1 < len(query) && query[idx+1] >= '0' && query[idx+1] <= '9':
+			end := idx + 1
+			for end < len(query) && query[end] >= '0' && query[end] <= '9' {
+				end++
+			}
+			n, _ := strconv.Atoi(query[idx+1 : end])
+			if n < 1 || n > len(args) {
+				return "", fmt.Errorf("statement refers to value $%d of %d: %s", n, len(args), query)
+			}
+			b.WriteString(sqlLiteral(dialect, args[n-1].Value))
+			idx = end - 1
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String(), nil
+}
+
+// sqlLiteral writes a bound value as an SQL literal of the dialect. Times are written in UTC,
+// without the time of day when it is midnight so that they also fit DATE columns.
+func sqlLiteral(dialect string, v driver.Value) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		if v {
+			return "TRUE"
+		}
+		return "FALSE"
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case []byte:
+		switch dialect {
+		case "postgres":
+			return `'\x` + hex.EncodeToString(v) + "'"
+		case "db2":
+			return "BX'" + strings.ToUpper(hex.EncodeToString(v)) + "'"
+		default:
+			return "X'" + hex.EncodeToString(v) + "'"
+		}
+	case time.Time:
+		v = v.UTC()
+		if v.Equal(v.Truncate(24 * time.Hour)) {
+			return "'" + v.Format("2006-01-02") + "'"
+		}
+		literal := v.Format("2006-01-02 15:04:05.999999")
+		if dialect == "postgres" {
+			literal += "+00"
+		}
+		return "'" + literal + "'"
+	default:
+		s := fmt.Sprint(v)
+		if dialect == "mysql" {
+			// MySQL reads backslashes in string literals as escapes unless NO_BACKSLASH_ESCAPES is set
+			s = strings.ReplaceAll(s, `\`, `\\`)
+		}
+		return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+	}
+}
@@ -0,0 +1,123 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_renderStatement(t *testing.T) {
+	at := time.Date(2024, 6, 1, 12, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		dialect string
+		query   string
+		args    []interface{}
+		want    string
+	}{
+		{"postgresでは$nを値に置き換えること", "postgres", "INSERT INTO t (a, b, c) VALUES ($1, $2, $3)", []interface{}{int64(1), "O'Brien", nil},
+			"INSERT INTO t (a, b, c) VALUES (1, 'O''Brien', NULL)"},
+		{"mysqlでは?を値に置き換えバックスラッシュをエスケープすること", "mysql", "INSERT INTO t (a, b) VALUES (?, ?)", []interface{}{true, `C:\tmp`},
+			`INSERT INTO t (a, b) VALUES (TRUE, 'C:\\tmp')`},
+		{"引用符の中の?は置き換えないこと", "mysql", "UPDATE t SET a = ? WHERE b = '?'", []interface{}{1.5},
+			"UPDATE t SET a = 1.5 WHERE b = '?'"},
+		{"日時はUTCで書き出すこと", "postgres", "INSERT INTO t (a, b) VALUES ($1, $2)", []interface{}{at, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+			"INSERT INTO t (a, b) VALUES ('2024-06-01 12:30:00+00', '2024-06-01')"},
+		{"バイト列は16進で書き出すこと", "db2", "INSERT INTO t (a) VALUES (?)", []interface{}{[]byte{0xca, 0xfe}},
+			"INSERT INTO t (a) VALUES (BX'CAFE')"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := make([]driver.Value, len(tt.args))
+			for idx, arg := range tt.args {
+				args[idx] = arg
+			}
+			got, err := renderStatement(tt.dialect, tt.query, valueArgs(args))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+	t.Run("値が足りない場合はエラーになること", func(t *testing.T) {
+		_, err := renderStatement("postgres", "INSERT INTO t (a) VALUES ($2)", valueArgs([]driver.Value{int64(1)}))
+		assert.Error(t, err)
+	})
+}
+
+func Test_SQLFileDB(t *testing.T) {
+	dir := t.TempDir()
+	tables := map[string]DBInfo{
+		"users": {
+			TableName:         "users",
+			Columns:           []ColumnInfo{{ColumnName: "id", DataType: IntegerType}, {ColumnName: "name", DataType: StringType, IsNullable: true}},
+			PrimaryKeyColumns: []string{"id"},
+		},
+		"orders": {
+			TableName:         "orders",
+			Columns:           []ColumnInfo{{ColumnName: "id", DataType: IntegerType, IsAutoIncrement: true}},
+			PrimaryKeyColumns: []string{"id"},
+		},
+	}
+	schemaFile := filepath.Join(dir, "schema.json")
+	f, err := os.Create(schemaFile)
+	require.NoError(t, err)
+	require.NoError(t, WriteSchemaSnapshot(f, "postgres", "public", tables))
+	require.NoError(t, f.Close())
+
+	ctx := context.Background()
+	out := filepath.Join(dir, "import.sql")
+	client, err := NewDBClient(ctx, "sqlfile", out+"?schema="+schemaFile, ConnOptions{})
+	require.NoError(t, err)
+
+	schemaInfo, err := client.GetSchemaInfo(ctx, "public")
+	require.NoError(t, err)
+	assert.Equal(t, tables, schemaInfo)
+
+	stmt, err := client.PrepareInsertStatement(ctx, tables["users"])
+	require.NoError(t, err)
+	_, err = stmt.ExecContext(ctx, int64(1), "Alice")
+	require.NoError(t, err)
+	require.NoError(t, stmt.Close())
+
+	for range 2 {
+		key, err := client.EnsureParentRecordExists(ctx, tables["users"], []string{"id"}, []string{"2"}, tables)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"2"}, key)
+	}
+	_, err = client.EnsureParentRecordExists(ctx, tables["orders"], []string{"id"}, []string{"5"}, tables)
+	assert.ErrorContains(t, err, "the database assigns orders.id")
+	require.NoError(t, client.Close())
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, `INSERT INTO "public"."users" (id, name) VALUES (1, 'Alice') ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name;
+INSERT INTO "public"."users" (id, name) VALUES (2, NULL) ON CONFLICT DO NOTHING;
+`, string(data))
+
+	t.Run("hana方言ではUPSERT文を書き出すこと", func(t *testing.T) {
+		hanaOut := filepath.Join(dir, "hana.sql")
+		client, err := NewSQLFileDB(ctx, hanaOut+"?schema="+schemaFile+"&dialect=hana")
+		require.NoError(t, err)
+		stmt, err := client.PrepareInsertStatement(ctx, tables["users"])
+		require.NoError(t, err)
+		_, err = stmt.ExecContext(ctx, int64(1), "Alice")
+		require.NoError(t, err)
+		require.NoError(t, stmt.Close())
+		require.NoError(t, client.Close())
+
+		data, err := os.ReadFile(hanaOut)
+		require.NoError(t, err)
+		assert.Equal(t, "UPSERT users (id, name) VALUES (1, 'Alice') WITH PRIMARY KEY;\n", string(data))
+	})
+
+	t.Run("未対応の方言はエラーになること", func(t *testing.T) {
+		_, err := NewSQLFileDB(ctx, filepath.Join(dir, "other.sql")+"?schema="+schemaFile+"&dialect=oracle")
+		assert.ErrorContains(t, err, `unsupported sqlfile dialect: "oracle"`)
+		assert.NoFileExists(t, filepath.Join(dir, "other.sql"))
+	})
+}
@@ -0,0 +1,350 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// SQLLogMode selects whether the SQL statements sent to the database are logged.
+type SQLLogMode string
+
+const (
+	// SQLLogOff logs no statements.
+	SQLLogOff SQLLogMode = ""
+	// SQLLogStatements logs each statement with its placeholders; the bound values are redacted.
+	SQLLogStatements SQLLogMode = "statements"
+	// SQLLogValues logs each statement together with its bound values.
+	SQLLogValues SQLLogMode = "values"
+)
+
+// ParseSQLLogMode parses "off", "statements" or "values".
+func ParseSQLLogMode(s string) (SQLLogMode, error) {
+	switch mode := SQLLogMode(s); mode {
+	case "off":
+		return SQLLogOff, nil
+	case SQLLogOff, SQLLogStatements, SQLLogValues:
+		return mode, nil
+	default:
+		return SQLLogOff, fmt.Errorf("unknown SQL log mode %q (expected off, statements or values)", s)
+	}
+}
+
+// openDB opens a database like sql.Open. When options.SQLLog is on, the connections go through a
+// wrapper of the driver that logs each statement; otherwise the driver is used directly. With
+// AWS IAM authentication, each connection is opened with a new token; see
+// ConnOptions.AWSIAMAuth. The connection string is changed to use the TLS options of options.
+func openDB(driverName, dsn string, options ConnOptions) (*sql.DB, error) {
+	return openRetryingDB(driverName, dsn, options, nil)
+}
+
+// openRetryingDB opens a database like openDB. If retryable is not nil, the connections go through
+// the wrapper of the driver also when SQL logging is off, and it runs a statement outside a
+// transaction again, up to maxStatementAttempts times, while it fails with an error retryable
+// reports true for.
+func openRetryingDB(driverName, dsn string, options ConnOptions, retryable func(error) bool) (*sql.DB, error) {
+	db, err := sql.Open(driverName, dsn)
+	mode := options.SQLLog
+	if err != nil || (mode == SQLLogOff && retryable == nil && !options.AWSIAMAuth && !options.TLS.enabled()) {
+		return db, err
+	}
+	drv := db.Driver()
+	db.Close()
+
+	if dsn, drv, err = applyTLSOptions(driverName, dsn, drv, options.TLS); err != nil {
+		return nil, err
+	}
+	var connector driver.Connector = dsnConnector{dsn: dsn, driver: drv}
+	if options.AWSIAMAuth {
+		if connector, err = newIAMConnector(driverName, dsn, drv); err != nil {
+			return nil, err
+		}
+	} else if driverCtx, ok := drv.(driver.DriverContext); ok {
+		if connector, err = driverCtx.OpenConnector(dsn); err != nil {
+			return nil, err
+		}
+	}
+	if mode == SQLLogOff && retryable == nil {
+		return sql.OpenDB(connector), nil
+	}
+	return sql.OpenDB(&loggingConnector{Connector: connector, mode: mode, retryable: retryable}), nil
+}
+
+// maxStatementAttempts bounds how often a statement failing with a retryable error is run.
+const maxStatementAttempts = 5
+
+// statementRetryDelay is the wait before the first retry of a statement. It grows with the square
+// of the attempt.
+var statementRetryDelay = 100 * time.Millisecond
+
+// logStatement logs a statement that was run, unless the driver skipped it.
+func logStatement(mode SQLLogMode, query string, args []driver.NamedValue, started time.Time, err error) {
+	if mode == SQLLogOff || errors.Is(err, driver.ErrSkip) {
+		return
+	}
+	msg := fmt.Sprintf("SQL (%s): %s", time.Since(started).Round(time.Microsecond), strings.Join(strings.Fields(query), " "))
+	if len(args) > 0 {
+		if mode == SQLLogValues {
+			values := make([]string, len(args))
+			for idx, arg := range args {
+				values[idx] = formatSQLValue(arg.Value)
+			}
+			msg += fmt.Sprintf(" [%s]", strings.Join(values, ", "))
+		} else {
+			msg += fmt.Sprintf(" [%d values redacted]", len(args))
+		}
+	}
+	if err != nil {
+		msg += fmt.Sprintf(" failed: %v", err)
+	}
+	log.Println(msg)
+}
+
+// formatSQLValue formats a bound value for the log.
+func formatSQLValue(v driver.Value) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return fmt.Sprintf("%q", v)
+	case []byte:
+		return fmt.Sprintf("%q", v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// dsnConnector is the driver.Connector of a driver that does not provide one.
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func (c dsnConnector) Connect(ctx context.Context) (driver.Conn, error) { return c.driver.Open(c.dsn) }
+func (c dsnConnector) Driver() driver.Driver                            { return c.driver }
+
+// loggingConnector opens connections that log their statements and retry the failed ones.
+type loggingConnector struct {
+	driver.Connector
+	mode      SQLLogMode
+	retryable func(error) bool
+}
+
+func (c *loggingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &loggingConn{Conn: conn, mode: c.mode, retryable: c.retryable}, nil
+}
+
+// loggingConn logs the statements run on a connection and retries those failing with a
+// retryable error. Each optional driver interface is forwarded to the wrapped connection, falling
+// back to what database/sql does without it.
+type loggingConn struct {
+	driver.Conn
+	mode      SQLLogMode
+	retryable func(error) bool
+	inTx      bool // A statement in a transaction is not retried: its failure may have aborted the transaction
+}
+
+// retry reports whether a statement that failed with err on attempt should be run again, after
+// waiting a little longer with each attempt.
+func (c *loggingConn) retry(ctx context.Context, err error, attempt int) bool {
+	if err == nil || c.retryable == nil || c.inTx || attempt >= maxStatementAttempts || !c.retryable(err) {
+		return false
+	}
+	log.Printf("Warning: Retrying statement after a retryable error (attempt %d/%d): %v\n", attempt, maxStatementAttempts, err)
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(time.Duration(attempt*attempt) * statementRetryDelay):
+		return true
+	}
+}
+
+func (c *loggingConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *loggingConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if prepCtx, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = prepCtx.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &loggingStmt{Stmt: stmt, conn: c, query: query}, nil
+}
+
+func (c *loggingConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	var tx driver.Tx
+	var err error
+	if beginTx, ok := c.Conn.(driver.ConnBeginTx); ok {
+		tx, err = beginTx.BeginTx(ctx, opts)
+	} else if opts.Isolation != 0 || opts.ReadOnly {
+		return nil, errors.New("sql: driver does not support non-default transaction options")
+	} else {
+		tx, err = c.Conn.Begin()
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.inTx = true
+	return &loggingTx{Tx: tx, conn: c}, nil
+}
+
+// loggingTx tracks the end of a transaction of a loggingConn.
+type loggingTx struct {
+	driver.Tx
+	conn *loggingConn
+}
+
+func (t *loggingTx) Commit() error {
+	t.conn.inTx = false
+	return t.Tx.Commit()
+}
+
+func (t *loggingTx) Rollback() error {
+	t.conn.inTx = false
+	return t.Tx.Rollback()
+}
+
+func (c *loggingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		// database/sql then prepares the statement, which is logged when it runs
+		return nil, driver.ErrSkip
+	}
+	for attempt := 1; ; attempt++ {
+		started := time.Now()
+		result, err := execer.ExecContext(ctx, query, args)
+		logStatement(c.mode, query, args, started, err)
+		if !c.retry(ctx, err, attempt) {
+			return result, err
+		}
+	}
+}
+
+func (c *loggingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	for attempt := 1; ; attempt++ {
+		started := time.Now()
+		rows, err := queryer.QueryContext(ctx, query, args)
+		logStatement(c.mode, query, args, started, err)
+		if !c.retry(ctx, err, attempt) {
+			return rows, err
+		}
+	}
+}
+
+func (c *loggingConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *loggingConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *loggingConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+func (c *loggingConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// loggingStmt logs and retries each execution of a prepared statement.
+type loggingStmt struct {
+	driver.Stmt
+	conn  *loggingConn // The connection the statement was prepared on
+	query string
+}
+
+func (s *loggingStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	for attempt := 1; ; attempt++ {
+		started := time.Now()
+		var result driver.Result
+		var err error
+		if execer, ok := s.Stmt.(driver.StmtExecContext); ok {
+			result, err = execer.ExecContext(ctx, args)
+		} else {
+			result, err = s.Stmt.Exec(namedValues(args))
+		}
+		logStatement(s.conn.mode, s.query, args, started, err)
+		if !s.conn.retry(ctx, err, attempt) {
+			return result, err
+		}
+	}
+}
+
+func (s *loggingStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	for attempt := 1; ; attempt++ {
+		started := time.Now()
+		var rows driver.Rows
+		var err error
+		if queryer, ok := s.Stmt.(driver.StmtQueryContext); ok {
+			rows, err = queryer.QueryContext(ctx, args)
+		} else {
+			rows, err = s.Stmt.Query(namedValues(args))
+		}
+		logStatement(s.conn.mode, s.query, args, started, err)
+		if !s.conn.retry(ctx, err, attempt) {
+			return rows, err
+		}
+	}
+}
+
+func (s *loggingStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	// database/sql only asks the connection when the statement has no checker of its own
+	if checker, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	if checker, ok := s.conn.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// ColumnConverter keeps the column converters of drivers that have them.
+func (s *loggingStmt) ColumnConverter(idx int) driver.ValueConverter {
+	if converter, ok := s.Stmt.(driver.ColumnConverter); ok {
+		return converter.ColumnConverter(idx)
+	}
+	return driver.DefaultParameterConverter
+}
+
+// namedValues converts the arguments for the Exec and Query methods of older drivers.
+func namedValues(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for idx, arg := range args {
+		values[idx] = arg.Value
+	}
+	return values
+}
@@ -0,0 +1,171 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDriver accepts every statement; it only has the methods every driver must have.
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) { return fakeConn{}, nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{}, nil }
+func (fakeConn) Close() error                              { return nil }
+func (fakeConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+type fakeStmt struct{}
+
+func (fakeStmt) Close() error                                    { return nil }
+func (fakeStmt) NumInput() int                                   { return -1 }
+func (fakeStmt) Exec(args []driver.Value) (driver.Result, error) { return driver.RowsAffected(1), nil }
+func (fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+func init() {
+	sql.Register("sqllog-test", fakeDriver{})
+}
+
+func Test_openDB(t *testing.T) {
+	var logs bytes.Buffer
+	output := log.Writer()
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(output) })
+
+	tests := []struct {
+		name string
+		mode SQLLogMode
+		want string
+	}{
+		{"statementsでは値を伏せてSQLを出力すること", SQLLogStatements, "INSERT INTO users (id, name) VALUES (?, ?) [2 values redacted]"},
+		{"valuesでは値とともにSQLを出力すること", SQLLogValues, `INSERT INTO users (id, name) VALUES (?, ?) [1, "Alice"]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.Reset()
+			db, err := openDB("sqllog-test", "", ConnOptions{SQLLog: tt.mode})
+			require.NoError(t, err)
+			defer db.Close()
+
+			_, err = db.ExecContext(context.Background(), "INSERT INTO users (id, name)\n\tVALUES (?, ?)", 1, "Alice")
+			require.NoError(t, err)
+			assert.Contains(t, logs.String(), tt.want)
+			assert.Equal(t, 1, bytes.Count(logs.Bytes(), []byte("SQL (")), "準備したステートメントは一度だけ出力されること")
+		})
+	}
+
+	t.Run("offではドライバーをそのまま使いSQLを出力しないこと", func(t *testing.T) {
+		logs.Reset()
+		db, err := openDB("sqllog-test", "", ConnOptions{})
+		require.NoError(t, err)
+		defer db.Close()
+
+		_, err = db.ExecContext(context.Background(), "DELETE FROM users")
+		require.NoError(t, err)
+		assert.Empty(t, logs.String())
+	})
+
+	t.Run("クライアントごとのモードで出力すること", func(t *testing.T) {
+		logs.Reset()
+		logged, err := openDB("sqllog-test", "", ConnOptions{SQLLog: SQLLogStatements})
+		require.NoError(t, err)
+		defer logged.Close()
+		quiet, err := openDB("sqllog-test", "", ConnOptions{})
+		require.NoError(t, err)
+		defer quiet.Close()
+
+		_, err = quiet.ExecContext(context.Background(), "DELETE FROM orders")
+		require.NoError(t, err)
+		_, err = logged.ExecContext(context.Background(), "DELETE FROM users")
+		require.NoError(t, err)
+		assert.Contains(t, logs.String(), "DELETE FROM users")
+		assert.NotContains(t, logs.String(), "DELETE FROM orders")
+	})
+}
+
+func Test_ParseSQLLogMode(t *testing.T) {
+	mode, err := ParseSQLLogMode("off")
+	assert.NoError(t, err)
+	assert.Equal(t, SQLLogOff, mode)
+	mode, err = ParseSQLLogMode("values")
+	assert.NoError(t, err)
+	assert.Equal(t, SQLLogValues, mode)
+	_, err = ParseSQLLogMode("all")
+	assert.Error(t, err)
+}
+
+// flakyDriver fails the first failures statements run on its connections with errFlaky.
+type flakyDriver struct{ failures *int }
+
+var errFlaky = errors.New("flaky")
+
+func (d flakyDriver) Open(name string) (driver.Conn, error) {
+	return flakyConn{fakeConn{}, d.failures}, nil
+}
+
+type flakyConn struct {
+	fakeConn
+	failures *int
+}
+
+func (c flakyConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if *c.failures > 0 {
+		*c.failures--
+		return nil, errFlaky
+	}
+	return driver.RowsAffected(1), nil
+}
+
+var flakyFailures int
+
+func init() {
+	sql.Register("sqllog-flaky", flakyDriver{failures: &flakyFailures})
+}
+
+func Test_openRetryingDB(t *testing.T) {
+	isFlaky := func(err error) bool { return errors.Is(err, errFlaky) }
+	delay := statementRetryDelay
+	statementRetryDelay = time.Millisecond
+	t.Cleanup(func() { statementRetryDelay = delay })
+
+	t.Run("再試行できるエラーでは文を再実行すること", func(t *testing.T) {
+		flakyFailures = 2
+		db, err := openRetryingDB("sqllog-flaky", "", ConnOptions{}, isFlaky)
+		require.NoError(t, err)
+		defer db.Close()
+		_, err = db.ExecContext(context.Background(), "DELETE FROM users")
+		require.NoError(t, err)
+		assert.Equal(t, 0, flakyFailures)
+	})
+
+	t.Run("試行回数の上限を超えるとエラーを返すこと", func(t *testing.T) {
+		flakyFailures = maxStatementAttempts
+		db, err := openRetryingDB("sqllog-flaky", "", ConnOptions{}, isFlaky)
+		require.NoError(t, err)
+		defer db.Close()
+		_, err = db.ExecContext(context.Background(), "DELETE FROM users")
+		assert.ErrorIs(t, err, errFlaky)
+		flakyFailures = 0
+	})
+
+	t.Run("再試行しないエラーはそのまま返すこと", func(t *testing.T) {
+		flakyFailures = 1
+		db, err := openRetryingDB("sqllog-flaky", "", ConnOptions{}, func(error) bool { return false })
+		require.NoError(t, err)
+		defer db.Close()
+		_, err = db.ExecContext(context.Background(), "DELETE FROM users")
+		assert.ErrorIs(t, err, errFlaky)
+	})
+}
@@ -0,0 +1,74 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// tidbBatchSize is the number of rows a single UPDATE or DELETE of many rows changes in TiDB. A
+// transaction over more rows may exceed TiDB's transaction size limit ("transaction too large").
+const tidbBatchSize = 10000
+
+// tidbRetryableErrors are the TiDB error codes of statements aborted by a conflicting transaction
+// or a briefly unavailable part of the cluster. Run again, such a statement usually succeeds.
+var tidbRetryableErrors = []uint16{
+	1205, // Lock wait timeout exceeded
+	1213, // Deadlock found
+	8002, // SELECT FOR UPDATE write conflict
+	8022, // Error in the transaction commit; safe to retry
+	8027, // Schema is out of date
+	8028, // Information schema changed
+	9001, // PD server timeout
+	9002, // TiKV server timeout
+	9003, // TiKV server is busy
+	9005, // Region is unavailable
+	9007, // Write conflict
+}
+
+// isRetryableTiDBError reports whether err is one of tidbRetryableErrors.
+func isRetryableTiDBError(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && slices.Contains(tidbRetryableErrors, mysqlErr.Number)
+}
+
+// TiDBDB implements the DBClient interface for TiDB, which speaks the MySQL protocol and has the
+// same information_schema as MySQL. Unlike MySQLDB, it runs the statements TiDB aborted with a
+// retryable error again, and clears or deletes many rows in batches of tidbBatchSize rows.
+type TiDBDB struct {
+	*MySQLDB
+}
+
+// NewTiDBDB creates a new TiDBDB instance. connStr is a MySQL connection string.
+func NewTiDBDB(ctx context.Context, connStr string, options ConnOptions) (*TiDBDB, error) {
+	db, err := openRetryingDB("mysql", connStr, options, isRetryableTiDBError)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database connection: %w", err)
+	}
+	if err = db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, &ConnectionError{DBType: "TiDB", Err: err}
+	}
+	log.Println("Successfully connected to TiDB database.")
+	return &TiDBDB{MySQLDB: &MySQLDB{db: db}}, nil
+}
+
+// ClearColumns sets columns of the rows matching filter to NULL, tidbBatchSize rows at a time.
+func (t *TiDBDB) ClearColumns(ctx context.Context, dbInfo DBInfo, columnNames []string, filter RowFilter) error {
+	return clearColumns(ctx, t.db, dbInfo.TableName, dbInfo, columnNames, filter, func(int) string { return "?" }, tidbBatchSize)
+}
+
+// DeleteRows deletes the rows of a table matching filter, tidbBatchSize rows at a time.
+func (t *TiDBDB) DeleteRows(ctx context.Context, dbInfo DBInfo, filter RowFilter) (int64, error) {
+	return deleteRows(ctx, t.db, dbInfo.TableName, dbInfo, filter, func(int) string { return "?" }, tidbBatchSize)
+}
+
+// BeginRowDeletion reports errors.ErrUnsupported: the rows are deleted tidbBatchSize at a time to
+// stay within TiDB's transaction size limit, which one transaction over all of them would exceed.
+func (t *TiDBDB) BeginRowDeletion(ctx context.Context) (RowDeletion, error) {
+	return nil, fmt.Errorf("deleting rows in one transaction is not supported by TiDB: %w", errors.ErrUnsupported)
+}
@@ -0,0 +1,17 @@
+package database
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_isRetryableTiDBError(t *testing.T) {
+	assert.True(t, isRetryableTiDBError(&mysql.MySQLError{Number: 9007, Message: "Write conflict"}))
+	assert.True(t, isRetryableTiDBError(fmt.Errorf("failed to insert: %w", &mysql.MySQLError{Number: 1213})))
+	assert.False(t, isRetryableTiDBError(&mysql.MySQLError{Number: 8004, Message: "Transaction is too large"}))
+	assert.False(t, isRetryableTiDBError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"}))
+	assert.False(t, isRetryableTiDBError(fmt.Errorf("connection refused")))
+}
@@ -0,0 +1,260 @@
+package database
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+// TLSOptions configure the TLS connections to the database, the same way for every database type
+// whose driver can take them per connection: PostgreSQL, MySQL and the databases speaking their
+// protocols, and, with the settings their drivers have, DB2 and HANA. Trino, Vertica and Spanner
+// reject them; see applyTLSOptions. Empty fields keep what the connection string says.
+type TLSOptions struct {
+	// CAFile is a PEM bundle of the certificate authorities the server certificate is verified
+	// against, instead of the system's.
+	CAFile string
+	// CertFile and KeyFile are the PEM client certificate and key presented to servers that
+	// require mutual TLS. Both or neither must be set.
+	CertFile, KeyFile string
+	// ServerName is the name the server certificate is verified for, if it differs from the host
+	// connected to, e.g. when connecting through a tunnel or to an IP address.
+	ServerName string
+}
+
+// enabled reports whether any option is set.
+func (o TLSOptions) enabled() bool {
+	return o != TLSOptions{}
+}
+
+// Validate returns an error if only one of the client certificate and key is set.
+func (o TLSOptions) Validate() error {
+	if (o.CertFile == "") != (o.KeyFile == "") {
+		return errors.New("a TLS client certificate and its key must be given together")
+	}
+	return nil
+}
+
+// applyTLSOptions returns dsn changed to connect with options, and the driver to connect with.
+// The driver is wrapped if lib/pq must verify the server certificate for another name than the
+// host, and for MySQL, whose driver takes a TLS configuration only by name from a registry shared
+// by the process otherwise. Setting any option makes the connections use TLS and, if CAFile or
+// ServerName is set, verify the server certificate.
+func applyTLSOptions(driverName, dsn string, drv driver.Driver, options TLSOptions) (string, driver.Driver, error) {
+	if !options.enabled() {
+		return dsn, drv, nil
+	}
+	if err := options.Validate(); err != nil {
+		return "", nil, err
+	}
+	switch driverName {
+	case "postgres":
+		return postgresTLSDSN(dsn, drv, options)
+	case "mysql":
+		if _, err := mysql.ParseDSN(dsn); err != nil {
+			return "", nil, err
+		}
+		tlsConfig, err := options.tlsConfig()
+		if err != nil {
+			return "", nil, err
+		}
+		return dsn, mysqlTLSDriver{Driver: drv, tlsConfig: tlsConfig}, nil
+	case "go_ibm_db":
+		// The DB2 CLI driver reads client certificates from a keystore, not from PEM files
+		if options.CertFile != "" || options.ServerName != "" {
+			return "", nil, errors.New("DB2 connections support only a TLS CA bundle; configure client certificates in the keystore of the DB2 client")
+		}
+		return strings.TrimSuffix(dsn, ";") + ";Security=SSL;SSLServerCertificate=" + options.CAFile + ";", drv, nil
+	case hanaDriverName:
+		// go-hdb has no connection string setting for a client certificate
+		if options.CertFile != "" {
+			return "", nil, errors.New("HANA connections do not support a TLS client certificate")
+		}
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return "", nil, err
+		}
+		query := u.Query()
+		if options.CAFile != "" {
+			query.Set("TLSRootCAFile", options.CAFile)
+		}
+		if options.ServerName != "" {
+			query.Set("TLSServerName", options.ServerName)
+		}
+		u.RawQuery = query.Encode()
+		return u.String(), drv, nil
+	case trinoDriverName:
+		// trino-go-client takes TLS settings only through HTTP clients registered for the whole process
+		return "", nil, errors.New("TLS options are not supported for Trino; use an https:// connection string with the SSLCertPath setting instead")
+	case verticaDriverName:
+		// vertica-sql-go takes a TLS configuration only by name from a registry shared by the process
+		return "", nil, errors.New("TLS options are not supported for Vertica; use the tlsmode setting of the connection string instead")
+	default:
+		return "", nil, fmt.Errorf("TLS options are not supported for the %s driver", driverName)
+	}
+}
+
+// tlsConfig loads the files of the options into a TLS configuration.
+func (o TLSOptions) tlsConfig() (*tls.Config, error) {
+	cfg := &tls.Config{ServerName: o.ServerName, MinVersion: tls.VersionTLS12}
+	if o.CAFile != "" {
+		pem, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read TLS CA bundle: %w", err)
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in TLS CA bundle %s", o.CAFile)
+		}
+	}
+	if o.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// postgresTLSDSN adds the settings of options to a lib/pq connection string. The server
+// certificate is verified for the host, or ServerName, if a CA bundle or ServerName is given,
+// unless the connection string asks to verify only the CA.
+func postgresTLSDSN(dsn string, drv driver.Driver, options TLSOptions) (string, driver.Driver, error) {
+	settings, err := postgresOptions(dsn)
+	if err != nil {
+		return "", nil, err
+	}
+	if settings["sslmode"] == "disable" {
+		return "", nil, errors.New("TLS options cannot be used with sslmode=disable")
+	}
+	kv, err := postgresKeyValues(dsn)
+	if err != nil {
+		return "", nil, err
+	}
+	add := func(key, value string) {
+		if value != "" {
+			kv += " " + key + "=" + postgresQuote(value)
+		}
+	}
+	if (options.CAFile != "" || options.ServerName != "") && settings["sslmode"] != "verify-ca" {
+		add("sslmode", "verify-full")
+	}
+	add("sslrootcert", options.CAFile)
+	add("sslcert", options.CertFile)
+	add("sslkey", options.KeyFile)
+	if options.ServerName != "" {
+		drv = serverNameDriver{Driver: drv, serverName: options.ServerName}
+	}
+	return kv, drv, nil
+}
+
+// mysqlTLSDriver connects with the go-sql-driver/mysql driver using tlsConfig, whatever the tls
+// setting of the connection string.
+type mysqlTLSDriver struct {
+	driver.Driver
+	tlsConfig *tls.Config
+}
+
+func (d mysqlTLSDriver) Open(dsn string) (driver.Conn, error) {
+	connector, err := d.OpenConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return connector.Connect(context.Background())
+}
+
+func (d mysqlTLSDriver) OpenConnector(dsn string) (driver.Connector, error) {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	// The driver verifies the certificate for the host of the connection string if ServerName is empty
+	cfg.TLS = d.tlsConfig.Clone()
+	return mysql.NewConnector(cfg)
+}
+
+// postgresKeyValues returns a lib/pq connection string in the "key=value" form, to which settings
+// can be appended. A later setting overrides an earlier one.
+func postgresKeyValues(dsn string) (string, error) {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		return pq.ParseURL(dsn)
+	}
+	return dsn, nil
+}
+
+// postgresQuote quotes a value of a lib/pq connection string.
+func postgresQuote(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// serverNameDriver makes lib/pq verify the server certificate for serverName. lib/pq verifies it
+// for the host setting, so the host is replaced with serverName and the connection is dialed to
+// the original host.
+type serverNameDriver struct {
+	driver.Driver
+	serverName string
+}
+
+func (d serverNameDriver) Open(dsn string) (driver.Conn, error) {
+	connector, err := d.OpenConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return connector.Connect(context.Background())
+}
+
+func (d serverNameDriver) OpenConnector(dsn string) (driver.Connector, error) {
+	settings, err := postgresOptions(dsn)
+	if err != nil {
+		return nil, err
+	}
+	kv, err := postgresKeyValues(dsn)
+	if err != nil {
+		return nil, err
+	}
+	connector, err := pq.NewConnector(kv + " host=" + postgresQuote(d.serverName))
+	if err != nil {
+		return nil, err
+	}
+	host := settings["host"]
+	if host == "" {
+		host = "localhost"
+	}
+	connector.Dialer(hostDialer{host: host})
+	return connector, nil
+}
+
+// hostDialer dials host whatever host it is asked for, on the port it is asked for.
+type hostDialer struct{ host string }
+
+func (d hostDialer) address(address string) string {
+	if _, port, err := net.SplitHostPort(address); err == nil {
+		return net.JoinHostPort(d.host, port)
+	}
+	return address
+}
+
+func (d hostDialer) Dial(network, address string) (net.Conn, error) {
+	return net.Dial(network, d.address(address))
+}
+
+func (d hostDialer) DialTimeout(network, address string, timeout time.Duration) (net.Conn, error) {
+	return net.DialTimeout(network, d.address(address), timeout)
+}
+
+func (d hostDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, network, d.address(address))
+}
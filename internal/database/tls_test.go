@@ -0,0 +1,142 @@
+package database
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestCertificate writes a self-signed certificate and its key as PEM files in dir.
+func writeTestCertificate(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "db.internal"},
+		DNSNames:              []string{"db.internal"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+func Test_TLSOptions_Validate(t *testing.T) {
+	assert.ErrorContains(t, TLSOptions{CertFile: "cert.pem"}.Validate(), "must be given together")
+	assert.NoError(t, TLSOptions{CAFile: "ca.pem"}.Validate())
+
+	_, _, err := applyTLSOptions("postgres", "host=db", &pq.Driver{}, TLSOptions{KeyFile: "key.pem"})
+	assert.ErrorContains(t, err, "must be given together")
+}
+
+func Test_applyTLSOptions(t *testing.T) {
+	certFile, keyFile := writeTestCertificate(t, t.TempDir())
+	options := TLSOptions{CAFile: certFile, CertFile: certFile, KeyFile: keyFile}
+
+	t.Run("オプションがなければ接続文字列を変えないこと", func(t *testing.T) {
+		dsn, drv, err := applyTLSOptions("postgres", "postgres://app@db/app", &pq.Driver{}, TLSOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, "postgres://app@db/app", dsn)
+		assert.Equal(t, &pq.Driver{}, drv)
+	})
+
+	t.Run("PostgreSQLではssl設定を加えてサーバー証明書を検証すること", func(t *testing.T) {
+		dsn, _, err := applyTLSOptions("postgres", "postgres://app@db.example.com/app?sslmode=require", &pq.Driver{}, options)
+		require.NoError(t, err)
+		settings, err := postgresOptions(dsn)
+		require.NoError(t, err)
+		assert.Equal(t, "verify-full", settings["sslmode"])
+		assert.Equal(t, certFile, settings["sslrootcert"])
+		assert.Equal(t, certFile, settings["sslcert"])
+		assert.Equal(t, keyFile, settings["sslkey"])
+		assert.Equal(t, "db.example.com", settings["host"])
+
+		dsn, _, err = applyTLSOptions("postgres", "host=db sslmode=verify-ca", &pq.Driver{}, options)
+		require.NoError(t, err)
+		settings, err = postgresOptions(dsn)
+		require.NoError(t, err)
+		assert.Equal(t, "verify-ca", settings["sslmode"])
+
+		_, _, err = applyTLSOptions("postgres", "host=db sslmode=disable", &pq.Driver{}, options)
+		assert.ErrorContains(t, err, "sslmode=disable")
+	})
+
+	t.Run("PostgreSQLでサーバー名を指定すると元のホストに接続して証明書をその名前で検証すること", func(t *testing.T) {
+		dsn, drv, err := applyTLSOptions("postgres", "host=10.0.0.5 port=6432 user=app", &pq.Driver{}, TLSOptions{ServerName: "db.internal"})
+		require.NoError(t, err)
+		require.IsType(t, serverNameDriver{}, drv)
+		_, err = drv.(serverNameDriver).OpenConnector(dsn)
+		require.NoError(t, err)
+		assert.Equal(t, "10.0.0.5:6432", hostDialer{host: "10.0.0.5"}.address("db.internal:6432"))
+	})
+
+	t.Run("MySQLでは接続ごとのTLS設定で接続すること", func(t *testing.T) {
+		dsn, drv, err := applyTLSOptions("mysql", "app:secret@tcp(db.example.com:3306)/app", &mysql.MySQLDriver{}, options)
+		require.NoError(t, err)
+		assert.Equal(t, "app:secret@tcp(db.example.com:3306)/app", dsn)
+		require.IsType(t, mysqlTLSDriver{}, drv)
+		assert.Len(t, drv.(mysqlTLSDriver).tlsConfig.Certificates, 1)
+		_, err = drv.(mysqlTLSDriver).OpenConnector(dsn)
+		require.NoError(t, err)
+
+		// Clients with other options do not share a TLS configuration
+		_, other, err := applyTLSOptions("mysql", dsn, &mysql.MySQLDriver{}, TLSOptions{CAFile: certFile})
+		require.NoError(t, err)
+		assert.Empty(t, other.(mysqlTLSDriver).tlsConfig.Certificates)
+		assert.Len(t, drv.(mysqlTLSDriver).tlsConfig.Certificates, 1)
+
+		_, _, err = applyTLSOptions("mysql", "app@tcp(db:3306)/app", nil, TLSOptions{CAFile: keyFile})
+		assert.ErrorContains(t, err, "no certificates found")
+	})
+
+	t.Run("DB2とHANAは対応している設定だけを接続文字列に加えること", func(t *testing.T) {
+		dsn, _, err := applyTLSOptions("go_ibm_db", "HOSTNAME=db;PORT=50001;", nil, TLSOptions{CAFile: certFile})
+		require.NoError(t, err)
+		assert.Equal(t, "HOSTNAME=db;PORT=50001;Security=SSL;SSLServerCertificate="+certFile+";", dsn)
+		_, _, err = applyTLSOptions("go_ibm_db", "HOSTNAME=db", nil, options)
+		assert.ErrorContains(t, err, "keystore")
+
+		dsn, _, err = applyTLSOptions(hanaDriverName, "hdb://app:secret@db:39017?defaultSchema=APP", nil, TLSOptions{CAFile: "/ca.pem", ServerName: "hana.internal"})
+		require.NoError(t, err)
+		assert.Equal(t, "hdb://app:secret@db:39017?TLSRootCAFile=%2Fca.pem&TLSServerName=hana.internal&defaultSchema=APP", dsn)
+		_, _, err = applyTLSOptions(hanaDriverName, "hdb://db:39017", nil, options)
+		assert.ErrorContains(t, err, "client certificate")
+	})
+	t.Run("TrinoとVerticaでは指定できないこと", func(t *testing.T) {
+		_, _, err := applyTLSOptions(trinoDriverName, "https://app@trino:8443?catalog=iceberg", nil, TLSOptions{CAFile: certFile})
+		assert.ErrorContains(t, err, "not supported for Trino")
+		_, _, err = applyTLSOptions(verticaDriverName, "vertica://app@vertica:5433/db", nil, TLSOptions{CAFile: certFile})
+		assert.ErrorContains(t, err, "not supported for Vertica")
+	})
+}
+
+func Test_NewSpannerDB_TLSOptions(t *testing.T) {
+	_, err := NewSpannerDB(context.Background(), "projects/p/instances/i/databases/d", ConnOptions{TLS: TLSOptions{CAFile: "ca.pem"}})
+	assert.ErrorContains(t, err, "not supported for Spanner")
+}
@@ -0,0 +1,320 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+)
+
+// trinoDriverName is the database/sql driver of trino-go-client, which is only linked in with the
+// trino build tag; see trino_driver.go.
+const trinoDriverName = "trino"
+
+// TrinoDB implements the DBClient interface for Trino (and Presto). Tables of the lakehouse
+// connectors behind Trino have no keys and generally no UPDATE or DELETE, so rows are only inserted:
+// TrinoDB is neither a RowUpdater nor a RowDeleter.
+type TrinoDB struct {
+	parentRecordSettings
+	db *sql.DB
+	// schemaName is the schema last passed to GetSchemaInfo. Generated SQL qualifies table names
+	// with it; the catalog is the one of the DSN.
+	schemaName string
+	// columnTypes holds the declared type of each column by table, e.g. "varchar(32)". Trino does
+	// not coerce values to narrower column types on INSERT, so values are cast to them.
+	columnTypes map[string]map[string]string
+}
+
+// NewTrinoDB creates a new TrinoDB instance. connStr is a trino-go-client DSN, e.g.
+// "http://user@localhost:8080?catalog=iceberg&schema=app".
+func NewTrinoDB(ctx context.Context, connStr string, options ConnOptions) (*TrinoDB, error) {
+	if !trinoDriverCompiled {
+		return nil, errors.New("Trino support not compiled. Build with -tags trino to enable")
+	}
+	db, err := openDB(trinoDriverName, connStr, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database connection: %w", err)
+	}
+	if err = db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, &ConnectionError{DBType: "Trino", Err: err}
+	}
+	log.Println("Successfully connected to Trino.")
+	return &TrinoDB{db: db, columnTypes: make(map[string]map[string]string)}, nil
+}
+
+// GetDB returns the underlying *sql.DB connection.
+func (t *TrinoDB) GetDB() *sql.DB {
+	return t.db
+}
+
+// Close closes the database connection.
+func (t *TrinoDB) Close() error {
+	if t.db != nil {
+		return t.db.Close()
+	}
+	return nil
+}
+
+// trinoTypeParameters matches the parameters of a type, e.g. "(3)" in "timestamp(3) with time zone".
+var trinoTypeParameters = regexp.MustCompile(`\([^)]*\)`)
+
+// trinoDataType maps a TYPE_NAME of SYSTEM.JDBC.COLUMNS, e.g. "varchar(80)", to a ColumnDataType.
+// Unlike in MySQL, TINYINT is an integer rather than a boolean.
+func trinoDataType(typeName string) ColumnDataType {
+	baseType := strings.TrimSpace(trinoTypeParameters.ReplaceAllString(strings.ToLower(typeName), ""))
+	switch baseType {
+	case "uuid", "json", "ipaddress":
+		return StringType
+	case "tinyint":
+		return IntegerType
+	case "time with time zone":
+		return TimestampType
+	default:
+		return ParseDataType(baseType)
+	}
+}
+
+// GetSchemaInfo retrieves schema information for a given schema name of the catalog of the DSN
+// from Trino. Trino exposes no keys, so the tables have neither primary nor foreign keys.
+func (t *TrinoDB) GetSchemaInfo(ctx context.Context, schemaName string) (map[string]DBInfo, error) {
+	log.Printf("Retrieving schema for '%s' from Trino.\n", schemaName)
+	t.schemaName = strings.ToLower(schemaName) // Trino identifiers are lower-cased
+
+	tables, err := t.getTableNames(ctx, t.schemaName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get table names from schema '%s': %w", schemaName, err)
+	}
+
+	schemaInfo := make(map[string]DBInfo)
+	for _, tableName := range tables {
+		if isImportRunsTable(tableName) {
+			continue
+		}
+		columns, err := t.getColumnInfo(ctx, t.schemaName, tableName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get column info for table %s: %w", tableName, err)
+		}
+		schemaInfo[tableName] = DBInfo{TableName: tableName, Columns: columns}
+	}
+	return schemaInfo, nil
+}
+
+func (t *TrinoDB) getTableNames(ctx context.Context, schemaName string) ([]string, error) {
+	// SYSTEM.JDBC.TABLES is what the Trino JDBC driver lists tables from; views are left out
+	rows, err := t.db.QueryContext(ctx, `
+		SELECT table_name
+		FROM system.jdbc.tables
+		WHERE table_cat = current_catalog AND table_schem = ? AND table_type = 'TABLE'
+	`, schemaName)
+	if err != nil {
+		return nil, fmt.Errorf("query failed for schema '%s': %w", schemaName, err)
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var tableName string
+		if err := rows.Scan(&tableName); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		tables = append(tables, tableName)
+	}
+	return tables, rows.Err()
+}
+
+func (t *TrinoDB) getColumnInfo(ctx context.Context, schemaName, tableName string) ([]ColumnInfo, error) {
+	rows, err := t.db.QueryContext(ctx, `
+		SELECT column_name, type_name, is_nullable, column_def, COALESCE(remarks, '')
+		FROM system.jdbc.columns
+		WHERE table_cat = current_catalog AND table_schem = ? AND table_name = ?
+		ORDER BY ordinal_position
+	`, schemaName, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("query failed for table %s: %w", tableName, err)
+	}
+	defer rows.Close()
+
+	var columns []ColumnInfo
+	types := make(map[string]string)
+	for rows.Next() {
+		var colName, typeName, isNullableStr, comment string
+		var colDefault sql.NullString
+		if err := rows.Scan(&colName, &typeName, &isNullableStr, &colDefault, &comment); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		columns = append(columns, ColumnInfo{
+			ColumnName:    colName,
+			DataType:      trinoDataType(typeName),
+			IsNullable:    isNullableStr != "NO",
+			ColumnDefault: colDefault,
+			Comment:       comment,
+		})
+		types[colName] = typeName
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	t.columnTypes[tableName] = types
+	return columns, nil
+}
+
+// quoteTable returns the schema-qualified, quoted name of a table for use in SQL. Names already
+// qualified as "schema.table" keep their own schema. Without a known schema the name is left as is.
+func (t *TrinoDB) quoteTable(tableName string) string {
+	schemaName := t.schemaName
+	if s, n, ok := strings.Cut(tableName, "."); ok {
+		schemaName, tableName = s, n
+	}
+	if schemaName == "" {
+		return tableName
+	}
+	return trinoQuoteIdentifier(schemaName) + "." + trinoQuoteIdentifier(tableName)
+}
+
+// trinoQuoteIdentifier quotes an identifier for Trino.
+func trinoQuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// trinoInsertStatement builds the INSERT of the given columns into table, casting each value to
+// the declared type of its column in types, if known.
+func trinoInsertStatement(table string, columns []string, types map[string]string) string {
+	placeholders := make([]string, len(columns))
+	for idx, colName := range columns {
+		placeholders[idx] = "?"
+		if typeName, ok := types[colName]; ok {
+			placeholders[idx] = fmt.Sprintf("CAST(? AS %s)", typeName)
+		}
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+}
+
+// PrepareInsertStatement prepares an INSERT statement for Trino.
+func (t *TrinoDB) PrepareInsertStatement(ctx context.Context, dbInfo DBInfo) (*sql.Stmt, error) {
+	var cols []string
+	for _, colInfo := range dbInfo.InsertColumns() {
+		cols = append(cols, colInfo.ColumnName)
+	}
+	stmt, err := t.db.PrepareContext(ctx, trinoInsertStatement(t.quoteTable(dbInfo.TableName), cols, t.columnTypes[dbInfo.TableName]))
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	return stmt, nil
+}
+
+// ParentRecordExists checks if a record exists in the given table for specific column values in Trino.
+// Trino does not compare text with other types, so the values are converted to their columns' types.
+func (t *TrinoDB) ParentRecordExists(ctx context.Context, dbInfo DBInfo, columnNames, values []string) (bool, error) {
+	conditions := make([]string, len(columnNames))
+	args := make([]interface{}, len(values))
+	for i, colName := range columnNames {
+		conditions[i] = fmt.Sprintf("%s = ?", colName)
+		colInfo := ColumnInfo{ColumnName: colName, DataType: StringType}
+		for _, c := range dbInfo.Columns {
+			if c.ColumnName == colName {
+				colInfo = c
+			}
+		}
+		args[i] = keyArg(values[i], colInfo)
+	}
+	query := fmt.Sprintf("SELECT 1 FROM %s WHERE %s LIMIT 1", t.quoteTable(dbInfo.TableName), strings.Join(conditions, " AND "))
+	var exists int
+	err := t.db.QueryRowContext(ctx, query, args...).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check existence of record %s: %w", formatParentKey(dbInfo.TableName, columnNames, values), err)
+	}
+	return true, nil
+}
+
+// EnsureParentRecordExists checks if a record with the given foreignKeyValues exists in the parent table.
+// If not, it creates a new record in the parent table with default values and the provided foreignKeyValues
+// for the foreignColumnNames. This implementation is specific to Trino.
+func (t *TrinoDB) EnsureParentRecordExists(ctx context.Context, parentDBInfo DBInfo, foreignColumnNames, foreignKeyValues []string, dbSchema map[string]DBInfo) ([]string, error) {
+	return ensureParentRecord(ctx, t, &t.parentRecordSettings, parentDBInfo, foreignColumnNames, foreignKeyValues, dbSchema, func(parentCols []string, parentValues []interface{}, returning string) (interface{}, bool, error) {
+		if returning != "" {
+			// Trino assigns no keys, so none can be read back
+			return nil, false, fmt.Errorf("cannot read back generated column %s.%s", parentDBInfo.TableName, returning)
+		}
+		if len(parentCols) == 0 {
+			return nil, false, fmt.Errorf("cannot create a record of %s without values", parentDBInfo.TableName)
+		}
+		insertQuery := trinoInsertStatement(t.quoteTable(parentDBInfo.TableName), parentCols, t.columnTypes[parentDBInfo.TableName])
+		if _, err := t.db.ExecContext(ctx, insertQuery, parentValues...); err != nil {
+			return nil, false, err
+		}
+		return nil, true, nil
+	})
+}
+
+// ReadRows reads the rows of a table matching filter.
+func (t *TrinoDB) ReadRows(ctx context.Context, dbInfo DBInfo, columns []ColumnInfo, filter RowFilter, fn func(values []string) error) error {
+	return readFilteredRows(ctx, t.db, t.quoteTable(dbInfo.TableName), dbInfo, columns, filter, func(int) string { return "?" }, fn)
+}
+
+// CheckTableAccess verifies the privileges of the connection on a table. Trino has no transactions
+// to roll the statements back in, so they are validated with EXPLAIN, which checks access control
+// without running them. UPDATE is not checked since rows are only inserted.
+func (t *TrinoDB) CheckTableAccess(ctx context.Context, dbInfo DBInfo, privileges []string) error {
+	table := t.quoteTable(dbInfo.TableName)
+	var cols []string
+	for _, colInfo := range dbInfo.InsertColumns() {
+		cols = append(cols, colInfo.ColumnName)
+	}
+	for _, privilege := range privileges {
+		var query string
+		switch privilege {
+		case PrivilegeSelect:
+			query = fmt.Sprintf("SELECT 1 FROM %s WHERE 1 = 0", table)
+		case PrivilegeInsert:
+			if len(cols) == 0 {
+				continue
+			}
+			query = fmt.Sprintf("EXPLAIN (TYPE VALIDATE) INSERT INTO %s (%s) SELECT %s FROM %s WHERE 1 = 0", table, strings.Join(cols, ", "), strings.Join(cols, ", "), table)
+		case PrivilegeUpdate:
+			continue
+		default:
+			return fmt.Errorf("unknown privilege %q", privilege)
+		}
+		rows, err := t.db.QueryContext(ctx, query)
+		if err == nil {
+			err = rows.Close()
+		}
+		if err != nil {
+			return &TableAccessError{TableName: dbInfo.TableName, Privilege: privilege, Err: err}
+		}
+	}
+	return nil
+}
+
+// trinoImportRunTypes are the types of the columns of ImportRunsTable in Trino, which lakehouse
+// connectors create without the constraints of createImportRunsStatement.
+var trinoImportRunTypes = map[string]string{
+	"run_id": "varchar(64)", "started_at": "timestamp(6) with time zone", "finished_at": "timestamp(6) with time zone",
+	"files": "varchar", "rows_read": "integer", "rows_inserted": "integer", "rows_updated": "integer", "rows_rejected": "integer",
+	"parents_created": "integer", "version": "varchar(255)", "outcome": "varchar(16)", "error": "varchar",
+}
+
+// RecordImportRun adds run to the _import_runs table of the schema.
+func (t *TrinoDB) RecordImportRun(ctx context.Context, run ImportRun) error {
+	table := t.quoteTable(ImportRunsTable)
+	columns := make([]string, len(importRunColumns))
+	for idx, colName := range importRunColumns {
+		columns[idx] = colName + " " + trinoImportRunTypes[colName]
+	}
+	create := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", table, strings.Join(columns, ", "))
+	if _, err := t.db.ExecContext(ctx, create); err != nil {
+		return fmt.Errorf("failed to create %s: %w", ImportRunsTable, err)
+	}
+	query := trinoInsertStatement(table, importRunColumns, trinoImportRunTypes)
+	if _, err := t.db.ExecContext(ctx, query, importRunArgs(run)...); err != nil {
+		return fmt.Errorf("failed to record import run %s: %w", run.RunID, err)
+	}
+	return nil
+}
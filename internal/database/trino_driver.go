@@ -0,0 +1,11 @@
+//go:build trino
+// +build trino
+
+package database
+
+import (
+	_ "github.com/trinodb/trino-go-client/trino" // Trino driver
+)
+
+// trinoDriverCompiled reports whether the Trino driver is linked in.
+const trinoDriverCompiled = true
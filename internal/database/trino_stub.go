@@ -0,0 +1,7 @@
+//go:build !trino
+// +build !trino
+
+package database
+
+// trinoDriverCompiled reports whether the Trino driver is linked in.
+const trinoDriverCompiled = false
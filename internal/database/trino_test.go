@@ -0,0 +1,40 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_trinoInsertStatement(t *testing.T) {
+	t.Run("列の型が分かる場合はその型にキャストすること", func(t *testing.T) {
+		types := map[string]string{"id": "integer", "created_at": "timestamp(3) with time zone"}
+		assert.Equal(t, `INSERT INTO "app"."events" (id, created_at) VALUES (CAST(? AS integer), CAST(? AS timestamp(3) with time zone))`,
+			trinoInsertStatement(`"app"."events"`, []string{"id", "created_at"}, types))
+	})
+
+	t.Run("列の型が分からない場合はキャストしないこと", func(t *testing.T) {
+		assert.Equal(t, "INSERT INTO events (id, name) VALUES (CAST(? AS bigint), ?)",
+			trinoInsertStatement("events", []string{"id", "name"}, map[string]string{"id": "bigint"}))
+	})
+}
+
+func Test_trinoDataType(t *testing.T) {
+	assert.Equal(t, StringType, trinoDataType("varchar(80)"))
+	assert.Equal(t, StringType, trinoDataType("varchar"))
+	assert.Equal(t, StringType, trinoDataType("uuid"))
+	assert.Equal(t, IntegerType, trinoDataType("tinyint"))
+	assert.Equal(t, IntegerType, trinoDataType("bigint"))
+	assert.Equal(t, FloatType, trinoDataType("decimal(10,2)"))
+	assert.Equal(t, FloatType, trinoDataType("double"))
+	assert.Equal(t, BooleanType, trinoDataType("boolean"))
+	assert.Equal(t, DateType, trinoDataType("date"))
+	assert.Equal(t, TimestampType, trinoDataType("timestamp(3)"))
+	assert.Equal(t, TimestampType, trinoDataType("timestamp(6) with time zone"))
+}
+
+func Test_TrinoDB_quoteTable(t *testing.T) {
+	client := &TrinoDB{schemaName: "app"}
+	assert.Equal(t, `"app"."events"`, client.quoteTable("events"))
+	assert.Equal(t, `"ref"."codes"`, client.quoteTable("ref.codes"))
+}
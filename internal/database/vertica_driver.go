@@ -0,0 +1,28 @@
+//go:build vertica
+// +build vertica
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"io"
+
+	vertigo "github.com/vertica/vertica-sql-go" // Vertica driver
+)
+
+// verticaDriverCompiled reports whether the Vertica driver is linked in.
+const verticaDriverCompiled = true
+
+// copyFromStdin executes a COPY ... FROM STDIN statement, streaming r to the server.
+func copyFromStdin(ctx context.Context, db *sql.DB, query string, r io.Reader) (int64, error) {
+	vCtx := vertigo.NewVerticaContext(ctx)
+	if err := vCtx.SetCopyInputStream(r); err != nil {
+		return 0, err
+	}
+	result, err := db.ExecContext(vCtx, query)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,19 @@
+//go:build !vertica
+// +build !vertica
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+)
+
+// verticaDriverCompiled reports whether the Vertica driver is linked in.
+const verticaDriverCompiled = false
+
+// copyFromStdin executes a COPY ... FROM STDIN statement, streaming r to the server.
+func copyFromStdin(ctx context.Context, db *sql.DB, query string, r io.Reader) (int64, error) {
+	return 0, errors.New("Vertica support not compiled")
+}
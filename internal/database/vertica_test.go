@@ -0,0 +1,63 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_verticaInsertStatements(t *testing.T) {
+	columns := []ColumnInfo{
+		{ColumnName: "id", DataType: IntegerType, IsAutoIncrement: true, IsGenerated: true},
+		{ColumnName: "name", DataType: StringType},
+		{ColumnName: "age", DataType: IntegerType},
+	}
+	insert, copyQuery := verticaInsertStatements(`"public"."users"`, DBInfo{TableName: "users", Columns: columns})
+	assert.Equal(t, `INSERT INTO "public"."users" (name, age) VALUES (?, ?)`, insert, "IDENTITY列には値を指定しないこと")
+	assert.Equal(t, `COPY "public"."users" (name, age) FROM STDIN DELIMITER ',' ENCLOSED BY '"' ABORT ON ERROR`, copyQuery)
+}
+
+func Test_verticaDataType(t *testing.T) {
+	assert.Equal(t, StringType, verticaDataType("varchar(80)"))
+	assert.Equal(t, StringType, verticaDataType("long varchar(1048576)"))
+	assert.Equal(t, StringType, verticaDataType("uuid"))
+	assert.Equal(t, IntegerType, verticaDataType("int"))
+	assert.Equal(t, FloatType, verticaDataType("numeric(10,2)"))
+	assert.Equal(t, FloatType, verticaDataType("float"))
+	assert.Equal(t, BooleanType, verticaDataType("boolean"))
+	assert.Equal(t, DateType, verticaDataType("date"))
+	assert.Equal(t, TimestampType, verticaDataType("timestamptz"))
+	assert.Equal(t, TimestampType, verticaDataType("timestamp"))
+}
+
+func Test_verticaCopyData(t *testing.T) {
+	t.Run("NULLは空のフィールド、空文字列は囲まれた空のフィールドになること", func(t *testing.T) {
+		data, err := verticaCopyData([][]interface{}{{nil, "", 0}})
+		require.NoError(t, err)
+		assert.Equal(t, ",\"\",\"0\"\n", string(data))
+	})
+
+	t.Run("引用符、バックスラッシュ、改行がエスケープされること", func(t *testing.T) {
+		data, err := verticaCopyData([][]interface{}{{`say "hi"`, `C:\tmp`, "a,b\nc"}})
+		require.NoError(t, err)
+		assert.Equal(t, "\"say \\\"hi\\\"\",\"C:\\\\tmp\",\"a,b\\\nc\"\n", string(data))
+	})
+
+	t.Run("真偽値、数値、日時が変換されること", func(t *testing.T) {
+		jst := time.FixedZone("JST", 9*60*60)
+		data, err := verticaCopyData([][]interface{}{
+			{true, int64(42), 1.5, time.Date(2024, 1, 2, 9, 30, 0, 0, jst)},
+			{false, int32(7), float32(0.25), time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "\"t\",\"42\",\"1.5\",\"2024-01-02 00:30:00+00\"\n\"f\",\"7\",\"0.25\",\"2024-01-02\"\n", string(data))
+	})
+}
+
+func Test_isVerticaUniqueViolation(t *testing.T) {
+	assert.True(t, isVerticaUniqueViolation(errors.New("Error: [23505] Duplicate key values: 'id=1' -- violates constraint 'public.users.C_PRIMARY'")))
+	assert.False(t, isVerticaUniqueViolation(errors.New("Error: [42V01] Relation \"users\" does not exist")))
+}
@@ -0,0 +1,65 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// yugabyteRetryableCodes are the SQLSTATEs of YugabyteDB statements aborted by a conflicting
+// transaction. Run again, such a statement usually succeeds.
+var yugabyteRetryableCodes = []pq.ErrorCode{
+	"40001", // serialization_failure, incl. "Restart read required" and transaction conflicts
+	"40P01", // deadlock_detected
+}
+
+// yugabyteRetryableMessages are parts of the messages of the internal errors (XX000) YugabyteDB
+// reports while a tablet moves or the catalog changes, which pass after a moment.
+var yugabyteRetryableMessages = []string{
+	"Try again",
+	"Leader not ready to serve requests",
+	"Catalog Version Mismatch",
+	"schema version mismatch",
+}
+
+// isRetryableYugabyteError reports whether err is one of yugabyteRetryableCodes or an internal
+// error with one of yugabyteRetryableMessages.
+func isRetryableYugabyteError(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	if slices.Contains(yugabyteRetryableCodes, pqErr.Code) {
+		return true
+	}
+	return pqErr.Code == "XX000" && slices.ContainsFunc(yugabyteRetryableMessages, func(msg string) bool {
+		return strings.Contains(pqErr.Message, msg)
+	})
+}
+
+// YugabyteDB implements the DBClient interface for YugabyteDB, whose YSQL API speaks the
+// PostgreSQL protocol and has the same catalog as PostgreSQL. Unlike PostgresDB, it runs the
+// statements YugabyteDB aborted with a retryable error again, and reads the columns of tables from
+// pg_attribute rather than the slower information_schema.
+type YugabyteDB struct {
+	*PostgresDB
+}
+
+// NewYugabyteDB creates a new YugabyteDB instance. connStr is a PostgreSQL connection string.
+func NewYugabyteDB(ctx context.Context, connStr string, options ConnOptions) (*YugabyteDB, error) {
+	db, err := openRetryingDB("postgres", connStr, options, isRetryableYugabyteError)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database connection: %w", err)
+	}
+	if err = db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, &ConnectionError{DBType: "YugabyteDB", Err: err}
+	}
+	log.Println("Successfully connected to YugabyteDB database.")
+	return &YugabyteDB{PostgresDB: &PostgresDB{db: db, catalogColumns: true}}, nil
+}
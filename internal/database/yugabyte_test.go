@@ -0,0 +1,18 @@
+package database
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_isRetryableYugabyteError(t *testing.T) {
+	assert.True(t, isRetryableYugabyteError(&pq.Error{Code: "40001", Message: "Restart read required"}))
+	assert.True(t, isRetryableYugabyteError(fmt.Errorf("failed to insert: %w", &pq.Error{Code: "40P01"})))
+	assert.True(t, isRetryableYugabyteError(&pq.Error{Code: "XX000", Message: "Leader not ready to serve requests"}))
+	assert.False(t, isRetryableYugabyteError(&pq.Error{Code: "XX000", Message: "Illegal state"}))
+	assert.False(t, isRetryableYugabyteError(&pq.Error{Code: "23505", Message: "duplicate key value violates unique constraint"}))
+	assert.False(t, isRetryableYugabyteError(fmt.Errorf("connection refused")))
+}
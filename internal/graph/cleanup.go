@@ -0,0 +1,20 @@
+package graph
+
+import (
+	"slices"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+)
+
+// CleanupOrder returns the order to empty the tables of schemaInfo in without violating a foreign
+// key: the reverse of ImportOrder, so each table comes before the tables it references. Foreign
+// keys on a cycle are deferred as by ImportOrder; their columns must be set to NULL before any
+// table is emptied.
+func CleanupOrder(schemaInfo map[string]database.DBInfo, rules OrderRules) ([]string, []database.ForeignKeyInfo, error) {
+	order, deferred, err := ImportOrder(schemaInfo, rules)
+	if err != nil {
+		return nil, nil, err
+	}
+	slices.Reverse(order)
+	return order, deferred, nil
+}
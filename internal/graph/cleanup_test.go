@@ -0,0 +1,18 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/k-wa-wa/db-auto-importer/e2e_test/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CleanupOrder(t *testing.T) {
+	t.Run("参照するテーブルが参照先より先に並ぶこと", func(t *testing.T) {
+		order, deferred, err := CleanupOrder(common.ExpectedDBInfo, OrderRules{})
+		require.NoError(t, err)
+		assert.Empty(t, deferred)
+		assert.Equal(t, []string{"posts", "product_tags", "users", "tags", "products", "organizations"}, order)
+	})
+}
@@ -0,0 +1,215 @@
+package graph
+
+import (
+	"errors"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+)
+
+// ImportOrder returns the order to import the tables of schemaInfo in, like TopologicalSort,
+// with the dependencies of rules added.
+// When the foreign keys form a cycle, it defers foreign keys on the cycle until the rest can be
+// ordered, and returns them: rows are inserted with their columns set to NULL and the values
+// are filled in once every table is imported. Only foreign keys whose columns are all nullable
+// and outside the primary key of the referencing table can be deferred, since the key is what
+// the row is found by again. If a cycle has no such foreign key, the *CycleError is returned.
+func ImportOrder(schemaInfo map[string]database.DBInfo, rules OrderRules) ([]string, []database.ForeignKeyInfo, error) {
+	g, deferred, err := breakCycles(schemaInfo, rules)
+	if err != nil {
+		return nil, nil, err
+	}
+	order, err := g.TopologicalSort()
+	return order, deferred, err
+}
+
+// ImportWaves is like ImportOrder, but groups the tables into waves as Waves does.
+func ImportWaves(schemaInfo map[string]database.DBInfo, rules OrderRules) ([][]string, []database.ForeignKeyInfo, error) {
+	g, deferred, err := breakCycles(schemaInfo, rules)
+	if err != nil {
+		return nil, nil, err
+	}
+	waves, err := g.Waves()
+	return waves, deferred, err
+}
+
+// breakCycles builds the graph of schemaInfo and rules without the foreign keys ImportOrder defers.
+func breakCycles(schemaInfo map[string]database.DBInfo, rules OrderRules) (*Graph, []database.ForeignKeyInfo, error) {
+	g := NewGraph(schemaInfo)
+	if err := rules.Apply(g); err != nil {
+		return nil, nil, err
+	}
+	var deferred []database.ForeignKeyInfo
+	for {
+		_, err := g.TopologicalSort()
+		var cycleErr *CycleError
+		if !errors.As(err, &cycleErr) {
+			return g, deferred, err
+		}
+		fk, ok := g.deferrableForeignKey(schemaInfo, cycleErr.Tables)
+		if !ok {
+			return nil, nil, err
+		}
+		g.removeEdge(fk.ForeignTableName, fk.TableName, fk.ConstraintName)
+		deferred = append(deferred, fk)
+	}
+}
+
+// deferrableForeignKey returns the first foreign key of the unresolved tables that lies on a
+// cycle and can be deferred.
+func (g *Graph) deferrableForeignKey(schemaInfo map[string]database.DBInfo, unresolved []string) (database.ForeignKeyInfo, bool) {
+	for _, tableName := range unresolved {
+		dbInfo := schemaInfo[tableName]
+		for _, fk := range dbInfo.ForeignKeys {
+			if g.Nodes[fk.ForeignTableName] == nil || !canDefer(dbInfo, fk) {
+				continue
+			}
+			// The edge runs from the referenced table to this one, so it closes a cycle if this
+			// table leads back to the referenced table
+			if g.reaches(tableName, fk.ForeignTableName) {
+				return fk, true
+			}
+		}
+	}
+	return database.ForeignKeyInfo{}, false
+}
+
+func canDefer(dbInfo database.DBInfo, fk database.ForeignKeyInfo) bool {
+	if len(dbInfo.PrimaryKeyColumns) == 0 {
+		return false
+	}
+	for _, colName := range fk.ColumnNames {
+		if slices.Contains(dbInfo.PrimaryKeyColumns, colName) {
+			return false
+		}
+		nullable := false
+		for _, colInfo := range dbInfo.Columns {
+			if colInfo.ColumnName == colName {
+				nullable = colInfo.IsNullable
+			}
+		}
+		if !nullable {
+			return false
+		}
+	}
+	return true
+}
+
+// reaches reports whether to can be reached from from by following edges.
+func (g *Graph) reaches(from, to string) bool {
+	visited := map[string]bool{from: true}
+	queue := []string{from}
+	for len(queue) > 0 {
+		tableName := queue[0]
+		queue = queue[1:]
+		if tableName == to {
+			return true
+		}
+		for _, neighbor := range g.Nodes[tableName].Edges {
+			if !visited[neighbor.TableName] {
+				visited[neighbor.TableName] = true
+				queue = append(queue, neighbor.TableName)
+			}
+		}
+	}
+	return false
+}
+
+// removeEdge removes the edge from parent to child made by the named foreign key.
+func (g *Graph) removeEdge(parent, child, constraintName string) {
+	parentNode, childNode := g.Nodes[parent], g.Nodes[child]
+	if idx := slices.Index(parentNode.Edges, childNode); idx >= 0 {
+		parentNode.Edges = slices.Delete(parentNode.Edges, idx, idx+1)
+		childNode.InDegree--
+	}
+	edge := [2]string{parent, child}
+	if idx := slices.Index(g.constraints[edge], constraintName); idx >= 0 {
+		g.constraints[edge] = slices.Delete(g.constraints[edge], idx, idx+1)
+	}
+}
+
+// Cycle is a chain of tables in which each table references the next and the last references
+// the first.
+type Cycle struct {
+	Tables []string
+	// Constraints[i] names the foreign keys by which Tables[i] references the next table.
+	Constraints [][]string
+}
+
+// String formats the cycle as e.g. "orders -> customers (fk_last_order) -> orders (fk_customer)".
+func (c Cycle) String() string {
+	var b strings.Builder
+	b.WriteString(c.Tables[0])
+	for idx := range c.Tables {
+		b.WriteString(" -> " + c.Tables[(idx+1)%len(c.Tables)])
+		if idx >= len(c.Constraints) {
+			continue
+		}
+		if names := slices.DeleteFunc(slices.Clone(c.Constraints[idx]), func(name string) bool { return name == "" }); len(names) > 0 {
+			b.WriteString(" (" + strings.Join(names, ", ") + ")")
+		}
+	}
+	return b.String()
+}
+
+// findCycles returns a shortest cycle for each group of unresolved tables that reference each
+// other, starting from the group's first table by name. Unresolved tables that only depend on a
+// cycle are not part of any.
+func (g *Graph) findCycles(unresolved []string) []Cycle {
+	covered := make(map[string]bool)
+	var cycles []Cycle
+	for _, start := range unresolved {
+		if covered[start] {
+			continue
+		}
+		path := g.shortestCycle(start)
+		if path == nil {
+			continue
+		}
+		for _, tableName := range unresolved {
+			if g.reaches(start, tableName) && g.reaches(tableName, start) {
+				covered[tableName] = true
+			}
+		}
+		// Edges run from parent to child, so the references run along the path backwards
+		slices.Reverse(path[1:])
+		c := Cycle{Tables: path, Constraints: make([][]string, len(path))}
+		for idx, tableName := range path {
+			c.Constraints[idx] = g.constraints[[2]string{path[(idx+1)%len(path)], tableName}]
+		}
+		cycles = append(cycles, c)
+	}
+	return cycles
+}
+
+// shortestCycle returns the tables of a shortest path of edges from start back to start, or nil
+// if start is not on a cycle.
+func (g *Graph) shortestCycle(start string) []string {
+	prev := make(map[string]string)
+	queue := []string{start}
+	for len(queue) > 0 {
+		tableName := queue[0]
+		queue = queue[1:]
+		neighbors := make([]string, 0, len(g.Nodes[tableName].Edges))
+		for _, neighbor := range g.Nodes[tableName].Edges {
+			neighbors = append(neighbors, neighbor.TableName)
+		}
+		sort.Strings(neighbors)
+		for _, neighbor := range neighbors {
+			if neighbor == start {
+				path := []string{tableName}
+				for path[0] != start {
+					path = append([]string{prev[path[0]]}, path...)
+				}
+				return path
+			}
+			if _, seen := prev[neighbor]; !seen {
+				prev[neighbor] = tableName
+				queue = append(queue, neighbor)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ImportOrder(t *testing.T) {
+	columns := func(nullable ...string) []database.ColumnInfo {
+		cols := []database.ColumnInfo{{ColumnName: "id"}}
+		for _, colName := range nullable {
+			cols = append(cols, database.ColumnInfo{ColumnName: colName, IsNullable: true})
+		}
+		return cols
+	}
+
+	t.Run("NULL許容の外部キーを遅延して循環を解消すること", func(t *testing.T) {
+		schemaInfo := map[string]database.DBInfo{
+			"departments": {
+				TableName:         "departments",
+				Columns:           columns("manager_id"),
+				PrimaryKeyColumns: []string{"id"},
+				ForeignKeys: []database.ForeignKeyInfo{
+					{ConstraintName: "fk_manager", TableName: "departments", ColumnNames: []string{"manager_id"}, ForeignTableName: "employees"},
+				},
+			},
+			"employees": {
+				TableName:         "employees",
+				Columns:           append(columns("mentor_id"), database.ColumnInfo{ColumnName: "department_id"}),
+				PrimaryKeyColumns: []string{"id"},
+				ForeignKeys: []database.ForeignKeyInfo{
+					{ConstraintName: "fk_department", TableName: "employees", ColumnNames: []string{"department_id"}, ForeignTableName: "departments"},
+					{ConstraintName: "fk_mentor", TableName: "employees", ColumnNames: []string{"mentor_id"}, ForeignTableName: "employees"},
+				},
+			},
+			"badges": {
+				TableName: "badges",
+				ForeignKeys: []database.ForeignKeyInfo{
+					{ConstraintName: "fk_badge_employee", TableName: "badges", ColumnNames: []string{"employee_id"}, ForeignTableName: "employees"},
+				},
+			},
+		}
+
+		order, deferred, err := ImportOrder(schemaInfo, OrderRules{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"departments", "employees", "badges"}, order)
+		var names []string
+		for _, fk := range deferred {
+			names = append(names, fk.ConstraintName)
+		}
+		assert.Equal(t, []string{"fk_manager", "fk_mentor"}, names)
+	})
+
+	t.Run("遅延できる外部キーがない循環はエラーとなること", func(t *testing.T) {
+		schemaInfo := map[string]database.DBInfo{
+			"tableA": {
+				TableName:         "tableA",
+				Columns:           columns(),
+				PrimaryKeyColumns: []string{"id"},
+				ForeignKeys: []database.ForeignKeyInfo{
+					{ConstraintName: "fk_b", TableName: "tableA", ColumnNames: []string{"b_id"}, ForeignTableName: "tableB"},
+				},
+			},
+			"tableB": {
+				// Nullable, but the table has no primary key to find the row by
+				TableName: "tableB",
+				Columns:   columns("a_id"),
+				ForeignKeys: []database.ForeignKeyInfo{
+					{ConstraintName: "fk_a", TableName: "tableB", ColumnNames: []string{"a_id"}, ForeignTableName: "tableA"},
+				},
+			},
+		}
+
+		_, _, err := ImportOrder(schemaInfo, OrderRules{})
+		var cycleErr *CycleError
+		require.ErrorAs(t, err, &cycleErr)
+		assert.Equal(t, []string{"tableA", "tableB"}, cycleErr.Tables)
+	})
+}
+
+func Test_findCycles(t *testing.T) {
+	t.Run("循環ごとにテーブルと外部キーが報告されること", func(t *testing.T) {
+		fk := func(name, table, parent string) database.ForeignKeyInfo {
+			return database.ForeignKeyInfo{ConstraintName: name, TableName: table, ForeignTableName: parent}
+		}
+		schemaInfo := map[string]database.DBInfo{
+			"customers": {TableName: "customers", ForeignKeys: []database.ForeignKeyInfo{fk("fk_last_order", "customers", "orders")}},
+			"orders":    {TableName: "orders", ForeignKeys: []database.ForeignKeyInfo{fk("fk_customer", "orders", "customers")}},
+			"invoices":  {TableName: "invoices", ForeignKeys: []database.ForeignKeyInfo{fk("fk_order", "invoices", "orders")}},
+			"nodes":     {TableName: "nodes", ForeignKeys: []database.ForeignKeyInfo{fk("fk_parent", "nodes", "nodes")}},
+			"a":         {TableName: "a", ForeignKeys: []database.ForeignKeyInfo{fk("fk_a_b", "a", "b")}},
+			"b":         {TableName: "b", ForeignKeys: []database.ForeignKeyInfo{fk("fk_b_c", "b", "c")}},
+			"c":         {TableName: "c", ForeignKeys: []database.ForeignKeyInfo{fk("fk_c_a", "c", "a")}},
+		}
+
+		_, err := NewGraph(schemaInfo).TopologicalSort()
+		var cycleErr *CycleError
+		require.ErrorAs(t, err, &cycleErr)
+		var cycles []string
+		for _, c := range cycleErr.Cycles {
+			cycles = append(cycles, c.String())
+		}
+		assert.Equal(t, []string{
+			"a -> b (fk_a_b) -> c (fk_b_c) -> a (fk_c_a)",
+			"customers -> orders (fk_last_order) -> customers (fk_customer)",
+			"nodes -> nodes (fk_parent)",
+		}, cycles)
+		assert.Contains(t, err.Error(), "Cycles: a -> b (fk_a_b)")
+		assert.Contains(t, cycleErr.Tables, "invoices")
+	})
+}
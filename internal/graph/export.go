@@ -0,0 +1,112 @@
+package graph
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+)
+
+// Format is a notation the dependency graph can be exported in.
+type Format string
+
+const (
+	FormatDOT     Format = "dot"     // Graphviz
+	FormatMermaid Format = "mermaid" // Mermaid flowchart
+)
+
+// ParseFormat validates a graph export format given on the command line.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case FormatDOT, FormatMermaid:
+		return f, nil
+	default:
+		return "", fmt.Errorf("invalid graph format %q (expected dot or mermaid)", s)
+	}
+}
+
+// exportEdge points from a table to a table that depends on it, i.e. in import order.
+type exportEdge struct {
+	from, to string
+	label    string
+	inherits bool
+}
+
+// Export writes the dependency graph of schemaInfo to w. Edges point from a referenced table to
+// the table referencing it, so they read in import order, and are labelled with the foreign key's
+// constraint name; inheritance is drawn as a dashed edge. Foreign keys to tables outside the schema
+// are left out. Unlike TopologicalSort it also writes graphs with cycles, which it helps to find.
+func Export(w io.Writer, schemaInfo map[string]database.DBInfo, format Format) error {
+	tableNames := make([]string, 0, len(schemaInfo))
+	for tableName := range schemaInfo {
+		tableNames = append(tableNames, tableName)
+	}
+	sort.Strings(tableNames)
+
+	var edges []exportEdge
+	for _, tableName := range tableNames {
+		dbInfo := schemaInfo[tableName]
+		for _, fk := range dbInfo.ForeignKeys {
+			if _, ok := schemaInfo[fk.ForeignTableName]; ok {
+				edges = append(edges, exportEdge{from: fk.ForeignTableName, to: tableName, label: fk.ConstraintName})
+			}
+		}
+		for _, parent := range dbInfo.InheritsFrom {
+			if _, ok := schemaInfo[parent]; ok {
+				edges = append(edges, exportEdge{from: parent, to: tableName, label: "inherits", inherits: true})
+			}
+		}
+	}
+
+	var b strings.Builder
+	switch format {
+	case FormatDOT:
+		writeDOT(&b, tableNames, edges)
+	case FormatMermaid:
+		writeMermaid(&b, tableNames, edges)
+	default:
+		return fmt.Errorf("unsupported graph format %q", format)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeDOT(b *strings.Builder, tableNames []string, edges []exportEdge) {
+	b.WriteString("digraph tables {\n\trankdir=LR;\n\tnode [shape=box];\n")
+	for _, tableName := range tableNames {
+		fmt.Fprintf(b, "\t%s;\n", strconv.Quote(tableName))
+	}
+	for _, e := range edges {
+		attrs := "label=" + strconv.Quote(e.label)
+		if e.inherits {
+			attrs += ", style=dashed"
+		}
+		fmt.Fprintf(b, "\t%s -> %s [%s];\n", strconv.Quote(e.from), strconv.Quote(e.to), attrs)
+	}
+	b.WriteString("}\n")
+}
+
+func writeMermaid(b *strings.Builder, tableNames []string, edges []exportEdge) {
+	// Table names may contain characters Mermaid does not accept in node IDs, so nodes are numbered
+	ids := make(map[string]string, len(tableNames))
+	b.WriteString("flowchart LR\n")
+	for idx, tableName := range tableNames {
+		ids[tableName] = fmt.Sprintf("t%d", idx)
+		fmt.Fprintf(b, "    %s[\"%s\"]\n", ids[tableName], mermaidText(tableName))
+	}
+	for _, e := range edges {
+		arrow := "-->"
+		if e.inherits {
+			arrow = "-.->"
+		}
+		fmt.Fprintf(b, "    %s %s|\"%s\"| %s\n", ids[e.from], arrow, mermaidText(e.label), ids[e.to])
+	}
+}
+
+// mermaidText escapes the double quotes that would end a quoted Mermaid label.
+func mermaidText(s string) string {
+	return strings.ReplaceAll(s, `"`, "#quot;")
+}
@@ -0,0 +1,56 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Export(t *testing.T) {
+	schemaInfo := map[string]database.DBInfo{
+		"users": {TableName: "users"},
+		"posts": {
+			TableName: "posts",
+			ForeignKeys: []database.ForeignKeyInfo{
+				{ConstraintName: "fk_posts_user", TableName: "posts", ForeignTableName: "users"},
+				{ConstraintName: "fk_posts_missing", TableName: "posts", ForeignTableName: "missing"},
+			},
+		},
+		"archived_posts": {TableName: "archived_posts", InheritsFrom: []string{"posts"}},
+	}
+
+	t.Run("DOT形式で出力されること", func(t *testing.T) {
+		var b strings.Builder
+		require.NoError(t, Export(&b, schemaInfo, FormatDOT))
+		assert.Equal(t, `digraph tables {
+	rankdir=LR;
+	node [shape=box];
+	"archived_posts";
+	"posts";
+	"users";
+	"posts" -> "archived_posts" [label="inherits", style=dashed];
+	"users" -> "posts" [label="fk_posts_user"];
+}
+`, b.String())
+	})
+
+	t.Run("Mermaid形式で出力されること", func(t *testing.T) {
+		var b strings.Builder
+		require.NoError(t, Export(&b, schemaInfo, FormatMermaid))
+		assert.Equal(t, `flowchart LR
+    t0["archived_posts"]
+    t1["posts"]
+    t2["users"]
+    t1 -.->|"inherits"| t0
+    t2 -->|"fk_posts_user"| t1
+`, b.String())
+	})
+
+	t.Run("未知の形式はエラーとなること", func(t *testing.T) {
+		_, err := ParseFormat("svg")
+		assert.Error(t, err)
+	})
+}
@@ -1,16 +1,46 @@
 package graph
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"sort"
+	"strings"
 
-	"db-auto-importer/internal/database"
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
 )
 
+// ErrCycleDetected is matched (via errors.Is) by the error returned from TopologicalSort
+// when the foreign key dependencies contain a cycle.
+var ErrCycleDetected = errors.New("cycle detected in table dependencies")
+
+// CycleError reports the tables that could not be ordered because of a dependency cycle.
+type CycleError struct {
+	// Tables are all tables left unordered, including those that only depend on a cycle.
+	Tables []string
+	// Cycles holds one cycle for each group of tables that reference each other.
+	Cycles []Cycle
+}
+
+func (e *CycleError) Error() string {
+	msg := "cycle detected in table dependencies. Cannot determine a valid import order."
+	if len(e.Cycles) > 0 {
+		cycles := make([]string, len(e.Cycles))
+		for idx, c := range e.Cycles {
+			cycles[idx] = c.String()
+		}
+		msg += fmt.Sprintf(" Cycles: %s.", strings.Join(cycles, "; "))
+	}
+	return fmt.Sprintf("%s Unresolved tables: %s", msg, strings.Join(e.Tables, ", "))
+}
+
+func (e *CycleError) Is(target error) bool { return target == ErrCycleDetected }
+
 // Graph represents the dependency graph of tables.
 type Graph struct {
 	Nodes map[string]*Node
+	// constraints names the foreign keys behind each edge, keyed by the parent and child table
+	constraints map[[2]string][]string
 }
 
 // Node represents a table in the dependency graph.
@@ -26,6 +56,7 @@ func NewGraph(schemaInfo map[string]database.DBInfo) *Graph {
 	for tableName := range schemaInfo {
 		nodes[tableName] = &Node{TableName: tableName}
 	}
+	g := &Graph{Nodes: nodes, constraints: make(map[[2]string][]string)}
 
 	for _, dbInfo := range schemaInfo {
 		for _, fk := range dbInfo.ForeignKeys {
@@ -42,11 +73,25 @@ func NewGraph(schemaInfo map[string]database.DBInfo) *Graph {
 			// This is actually reversed for topological sort.
 			// For topological sort, we need edges from parent to child.
 			// So, parentNode has an edge to childNode.
-			parentNode.Edges = append(parentNode.Edges, childNode)
-			childNode.InDegree++
+			g.addEdge(fk.ForeignTableName, fk.TableName, fk.ConstraintName)
+		}
+		// An inheriting table is imported after the table it inherits from, so that a parent's
+		// rows are in place before rows of the same hierarchy are added through the child
+		for _, parentName := range dbInfo.InheritsFrom {
+			if nodes[parentName] != nil && nodes[dbInfo.TableName] != nil {
+				g.addEdge(parentName, dbInfo.TableName, "INHERITS")
+			}
 		}
 	}
-	return &Graph{Nodes: nodes}
+	return g
+}
+
+// addEdge makes child depend on parent.
+func (g *Graph) addEdge(parent, child, constraintName string) {
+	g.Nodes[parent].Edges = append(g.Nodes[parent].Edges, g.Nodes[child])
+	g.Nodes[child].InDegree++
+	edge := [2]string{parent, child}
+	g.constraints[edge] = append(g.constraints[edge], constraintName)
 }
 
 // TopologicalSort performs a topological sort on the graph to determine import order.
@@ -90,8 +135,60 @@ func (g *Graph) TopologicalSort() ([]string, error) {
 
 	// Check for cycles
 	if len(order) != len(g.Nodes) {
-		return nil, fmt.Errorf("cycle detected in table dependencies. Cannot determine a valid import order.")
+		var unresolved []string
+		for tableName, inDegree := range currentInDegrees {
+			if inDegree > 0 {
+				unresolved = append(unresolved, tableName)
+			}
+		}
+		sort.Strings(unresolved)
+		return nil, &CycleError{Tables: unresolved, Cycles: g.findCycles(unresolved)}
 	}
 
 	return order, nil
 }
+
+// Waves groups the tables into dependency levels: the first wave holds the tables that depend on
+// no other table, and each later wave the tables whose parents are all in earlier waves. Tables of
+// the same wave do not depend on each other, so they can be imported in parallel once the previous
+// waves are done. Each wave is sorted by name. A cycle is reported like TopologicalSort does.
+func (g *Graph) Waves() ([][]string, error) {
+	currentInDegrees := make(map[string]int, len(g.Nodes))
+	var wave []string
+	for tableName, node := range g.Nodes {
+		currentInDegrees[tableName] = node.InDegree
+		if node.InDegree == 0 {
+			wave = append(wave, tableName)
+		}
+	}
+
+	var waves [][]string
+	resolved := 0
+	for len(wave) > 0 {
+		sort.Strings(wave)
+		waves = append(waves, wave)
+		resolved += len(wave)
+		var next []string
+		for _, tableName := range wave {
+			for _, neighbor := range g.Nodes[tableName].Edges {
+				currentInDegrees[neighbor.TableName]--
+				if currentInDegrees[neighbor.TableName] == 0 {
+					next = append(next, neighbor.TableName)
+				}
+			}
+		}
+		wave = next
+	}
+
+	if resolved != len(g.Nodes) {
+		var unresolved []string
+		for tableName, inDegree := range currentInDegrees {
+			if inDegree > 0 {
+				unresolved = append(unresolved, tableName)
+			}
+		}
+		sort.Strings(unresolved)
+		return nil, &CycleError{Tables: unresolved, Cycles: g.findCycles(unresolved)}
+	}
+	return waves, nil
+}
@@ -1,8 +1,8 @@
 package graph
 
 import (
-	"db-auto-importer/e2e_test/common"
-	"db-auto-importer/internal/database"
+	"github.com/k-wa-wa/db-auto-importer/e2e_test/common"
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -46,5 +46,42 @@ func Test_TopologicalSort(t *testing.T) {
 		_, err := graph.TopologicalSort()
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "cycle detected", "Should detect a cycle and return an error")
+		assert.ErrorIs(t, err, ErrCycleDetected)
+
+		var cycleErr *CycleError
+		require.ErrorAs(t, err, &cycleErr)
+		assert.Equal(t, []string{"tableA", "tableB", "tableC"}, cycleErr.Tables)
+	})
+
+	t.Run("継承したテーブルは継承元のテーブルの後に並ぶこと", func(t *testing.T) {
+		schemaInfo := map[string]database.DBInfo{
+			"a_measurements_2023": {TableName: "a_measurements_2023", InheritsFrom: []string{"measurements"}},
+			"measurements":        {TableName: "measurements"},
+			"z_archive":           {TableName: "z_archive", InheritsFrom: []string{"legacy.archive"}},
+		}
+
+		sorted, err := NewGraph(schemaInfo).TopologicalSort()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"measurements", "z_archive", "a_measurements_2023"}, sorted)
+	})
+}
+
+func Test_Waves(t *testing.T) {
+	t.Run("依存関係の深さごとにテーブルがまとめられること", func(t *testing.T) {
+		waves, err := NewGraph(common.ExpectedDBInfo).Waves()
+		require.NoError(t, err)
+		assert.Equal(t, [][]string{{"organizations", "products", "tags"}, {"product_tags", "users"}, {"posts"}}, waves)
+	})
+
+	t.Run("循環参照がある場合にエラーを返すこと", func(t *testing.T) {
+		schemaInfo := map[string]database.DBInfo{
+			"root":   {TableName: "root"},
+			"tableA": {TableName: "tableA", ForeignKeys: []database.ForeignKeyInfo{{TableName: "tableA", ForeignTableName: "tableB"}}},
+			"tableB": {TableName: "tableB", ForeignKeys: []database.ForeignKeyInfo{{TableName: "tableB", ForeignTableName: "tableA"}}},
+		}
+		_, err := NewGraph(schemaInfo).Waves()
+		var cycleErr *CycleError
+		require.ErrorAs(t, err, &cycleErr)
+		assert.Equal(t, []string{"tableA", "tableB"}, cycleErr.Tables)
 	})
 }
@@ -0,0 +1,56 @@
+package graph
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// OrderRules adjust the import order for dependencies the foreign keys do not declare.
+type OrderRules struct {
+	// First lists tables to import before all others, in the given order.
+	First []string
+	// After maps a table to tables that must be imported before it.
+	After map[string][]string
+}
+
+// orderRuleConstraint names the edges added by OrderRules in cycle reports.
+const orderRuleConstraint = "import_order"
+
+// Apply adds the dependencies of the rules to g. A table listed in First that references a
+// table not listed before it makes a cycle.
+func (r OrderRules) Apply(g *Graph) error {
+	for _, tableName := range r.First {
+		if g.Nodes[tableName] == nil {
+			return fmt.Errorf("import_order.first: table %s not found in the database schema", tableName)
+		}
+	}
+	for idx, tableName := range r.First {
+		if idx > 0 {
+			g.addEdge(r.First[idx-1], tableName, orderRuleConstraint)
+		}
+	}
+	if len(r.First) > 0 {
+		last := r.First[len(r.First)-1]
+		// Edges are added in name order so that the sort stays deterministic
+		for _, tableName := range slices.Sorted(maps.Keys(g.Nodes)) {
+			if !slices.Contains(r.First, tableName) {
+				g.addEdge(last, tableName, orderRuleConstraint)
+			}
+		}
+	}
+
+	for _, tableName := range slices.Sorted(maps.Keys(r.After)) {
+		parents := r.After[tableName]
+		if g.Nodes[tableName] == nil {
+			return fmt.Errorf("import_order.after.%s: table not found in the database schema", tableName)
+		}
+		for _, parent := range parents {
+			if g.Nodes[parent] == nil {
+				return fmt.Errorf("import_order.after.%s: table %s not found in the database schema", tableName, parent)
+			}
+			g.addEdge(parent, tableName, orderRuleConstraint)
+		}
+	}
+	return nil
+}
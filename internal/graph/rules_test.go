@@ -0,0 +1,46 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/k-wa-wa/db-auto-importer/e2e_test/common"
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_OrderRules(t *testing.T) {
+	t.Run("firstのテーブルが指定した順に先頭に並ぶこと", func(t *testing.T) {
+		order, _, err := ImportOrder(common.ExpectedDBInfo, OrderRules{First: []string{"tags", "products"}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"tags", "products", "organizations", "product_tags", "users", "posts"}, order)
+	})
+
+	// customers has no foreign keys, so without rules it is imported first
+	schemaInfo := map[string]database.DBInfo{
+		"customers": {TableName: "customers"},
+		"orders":    {TableName: "orders"},
+		"order_items": {
+			TableName:   "order_items",
+			ForeignKeys: []database.ForeignKeyInfo{{ConstraintName: "fk_order", TableName: "order_items", ForeignTableName: "orders"}},
+		},
+	}
+
+	t.Run("afterで指定した依存関係が順序に反映されること", func(t *testing.T) {
+		order, _, err := ImportOrder(schemaInfo, OrderRules{After: map[string][]string{"customers": {"order_items"}}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"orders", "order_items", "customers"}, order)
+	})
+
+	t.Run("外部キーと矛盾する指定は循環として報告されること", func(t *testing.T) {
+		_, _, err := ImportOrder(schemaInfo, OrderRules{First: []string{"order_items"}})
+		var cycleErr *CycleError
+		require.ErrorAs(t, err, &cycleErr)
+		assert.Contains(t, err.Error(), "order_items -> orders (fk_order) -> order_items (import_order)")
+	})
+
+	t.Run("存在しないテーブルはエラーとなること", func(t *testing.T) {
+		_, _, err := ImportOrder(schemaInfo, OrderRules{After: map[string][]string{"orders": {"accounts"}}})
+		assert.ErrorContains(t, err, "import_order.after.orders: table accounts not found")
+	})
+}
@@ -0,0 +1,48 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+)
+
+// Subset returns the part of schemaInfo that importing the given tables depends on: the tables
+// themselves and every table they reference, directly or through other tables. The referenced
+// tables that were not given are also returned, sorted; they are where parent records may be
+// created.
+func Subset(schemaInfo map[string]database.DBInfo, tables []string) (map[string]database.DBInfo, []string, error) {
+	subset := make(map[string]database.DBInfo)
+	selected := make(map[string]bool, len(tables))
+	queue := make([]string, 0, len(tables))
+	for _, tableName := range tables {
+		if _, ok := schemaInfo[tableName]; !ok {
+			return nil, nil, fmt.Errorf("table %s not found in the database schema", tableName)
+		}
+		selected[tableName] = true
+		queue = append(queue, tableName)
+	}
+
+	var ancestors []string
+	for len(queue) > 0 {
+		tableName := queue[0]
+		queue = queue[1:]
+		if _, seen := subset[tableName]; seen {
+			continue
+		}
+		dbInfo, ok := schemaInfo[tableName]
+		if !ok {
+			continue
+		}
+		subset[tableName] = dbInfo
+		if !selected[tableName] {
+			ancestors = append(ancestors, tableName)
+		}
+		for _, fk := range dbInfo.ForeignKeys {
+			queue = append(queue, fk.ForeignTableName)
+		}
+		queue = append(queue, dbInfo.InheritsFrom...)
+	}
+	sort.Strings(ancestors)
+	return subset, ancestors, nil
+}
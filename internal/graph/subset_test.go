@@ -0,0 +1,25 @@
+package graph
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/k-wa-wa/db-auto-importer/e2e_test/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Subset(t *testing.T) {
+	t.Run("指定したテーブルと参照先のテーブルが含まれること", func(t *testing.T) {
+		subset, ancestors, err := Subset(common.ExpectedDBInfo, []string{"posts", "tags"})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"posts", "tags", "users", "organizations"}, slices.Collect(maps.Keys(subset)))
+		assert.Equal(t, []string{"organizations", "users"}, ancestors)
+	})
+
+	t.Run("存在しないテーブルはエラーとなること", func(t *testing.T) {
+		_, _, err := Subset(common.ExpectedDBInfo, []string{"comments"})
+		assert.EqualError(t, err, "table comments not found in the database schema")
+	})
+}
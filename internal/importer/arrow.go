@@ -0,0 +1,366 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+)
+
+// arrowExtensions are the extensions of Arrow IPC files, in the file (Feather) or streaming format.
+var arrowExtensions = []string{".arrow", ".arrows", ".feather", ".ipc"}
+
+// arrowFileMagic starts and ends the Arrow IPC file format.
+const arrowFileMagic = "ARROW1"
+
+// isArrowFile reports whether name is an Arrow IPC file by its extension.
+func isArrowFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, arrowExt := range arrowExtensions {
+		if ext == arrowExt {
+			return true
+		}
+	}
+	return false
+}
+
+// IsInputFile reports whether name is a file an import reads: a CSV or an Arrow IPC file.
+func IsInputFile(name string) bool {
+	return strings.EqualFold(filepath.Ext(name), ".csv") || isArrowFile(name)
+}
+
+// openInput opens an input of src as CSV. Arrow IPC inputs are converted on the fly, with a
+// header row of the column names when hasHeader is set, so that they can be validated, compared
+// and counted like CSV files. Imports read them with openArrowRecords instead, to keep the
+// native values.
+func openInput(ctx context.Context, src Source, name string, hasHeader bool) (io.ReadCloser, error) {
+	file, err := src.Open(ctx, name)
+	if err != nil || !isArrowFile(name) {
+		return file, err
+	}
+	return newArrowCSVReader(file, hasHeader), nil
+}
+
+// openInputFile opens a local input file as CSV like openInput.
+func openInputFile(filePath string, hasHeader bool) (io.ReadCloser, error) {
+	return openInput(context.Background(), FileSource{filePath}, filePath, hasHeader)
+}
+
+// arrowCSVReader converts an Arrow IPC input to CSV as it is read.
+type arrowCSVReader struct {
+	*io.PipeReader
+	file io.Closer
+}
+
+// newArrowCSVReader returns the rows of the Arrow IPC data of file as CSV, in the text form of
+// arrowRecordReader.Read. Closing it closes file.
+func newArrowCSVReader(file io.ReadCloser, hasHeader bool) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(writeArrowCSV(pw, file, hasHeader))
+	}()
+	return &arrowCSVReader{PipeReader: pr, file: file}
+}
+
+func writeArrowCSV(w io.Writer, r io.Reader, hasHeader bool) error {
+	records, err := newArrowRecordReader(r, hasHeader)
+	if err != nil {
+		return err
+	}
+	defer records.release()
+	cw := csv.NewWriter(w)
+	for {
+		record, err := records.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Close stops the conversion and closes the file.
+func (a *arrowCSVReader) Close() error {
+	a.PipeReader.Close()
+	return a.file.Close()
+}
+
+// arrowRecordReader reads the rows of Arrow IPC data, in the file format (also known as Feather
+// version 2) or the streaming format. Each row is returned in CSV string form, for the steps of
+// an import that work on text, and kept as native values for the conversion to the column types.
+type arrowRecordReader struct {
+	batches arrowBatchReader
+	file    io.Closer
+	columns []string
+	header  bool // Whether Read returns the column names before the first row
+	batch   arrow.RecordBatch
+	next    int // Row of batch Read returns next
+	values  []interface{}
+}
+
+// arrowBatchReader reads the record batches of an ipc.Reader or an ipc.FileReader. Read returns
+// io.EOF after the last batch, and each batch is valid until the next call to Read.
+type arrowBatchReader interface {
+	Schema() *arrow.Schema
+	Read() (arrow.RecordBatch, error)
+}
+
+// openArrowRecords opens an Arrow IPC input of src. Closing the reader closes the input.
+func openArrowRecords(ctx context.Context, src Source, name string, hasHeader bool) (*arrowRecordReader, error) {
+	file, err := src.Open(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	records, err := newArrowRecordReader(file, hasHeader)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	records.file = file
+	return records, nil
+}
+
+// newArrowRecordReader reads the schema of the Arrow IPC data of r. The file format locates its
+// record batches by the footer at its end, so it is read in place from a local file and into
+// memory from other inputs.
+func newArrowRecordReader(r io.Reader, hasHeader bool) (records *arrowRecordReader, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			records, err = nil, fmt.Errorf("malformed Arrow IPC data: %v", p)
+		}
+	}()
+	var batches arrowBatchReader
+	file, seekable := r.(ipc.ReadAtSeeker)
+	magic := make([]byte, len(arrowFileMagic))
+	if seekable {
+		if _, err := file.ReadAt(magic, 0); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("failed to read Arrow IPC data: %w", err)
+		}
+	} else {
+		br := bufio.NewReaderSize(r, 64<<10)
+		peeked, _ := br.Peek(len(arrowFileMagic))
+		copy(magic, peeked)
+		r = br
+	}
+	switch {
+	case string(magic) != arrowFileMagic:
+		reader, err := ipc.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Arrow IPC schema: %w", err)
+		}
+		batches = reader
+	case seekable:
+		if batches, err = ipc.NewFileReader(file); err != nil {
+			return nil, fmt.Errorf("failed to read Arrow file: %w", err)
+		}
+	default:
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Arrow file: %w", err)
+		}
+		if batches, err = ipc.NewFileReader(bytes.NewReader(data)); err != nil {
+			return nil, fmt.Errorf("failed to read Arrow file: %w", err)
+		}
+	}
+	records = &arrowRecordReader{batches: batches, header: hasHeader}
+	for _, field := range batches.Schema().Fields() {
+		if !arrowTypeSupported(field.Type) {
+			records.release()
+			return nil, fmt.Errorf("column %q has Arrow type %s, which is not supported", field.Name, field.Type)
+		}
+		records.columns = append(records.columns, field.Name)
+	}
+	return records, nil
+}
+
+// arrowTypeSupported reports whether the values of dataType can be read: the flat types a table
+// can hold, but not nested types such as lists and structs.
+func arrowTypeSupported(dataType arrow.DataType) bool {
+	switch dataType.ID() {
+	case arrow.NULL, arrow.BOOL, arrow.INT8, arrow.INT16, arrow.INT32, arrow.INT64,
+		arrow.UINT8, arrow.UINT16, arrow.UINT32, arrow.UINT64,
+		arrow.FLOAT16, arrow.FLOAT32, arrow.FLOAT64,
+		arrow.DECIMAL32, arrow.DECIMAL64, arrow.DECIMAL128, arrow.DECIMAL256,
+		arrow.STRING, arrow.LARGE_STRING, arrow.STRING_VIEW,
+		arrow.BINARY, arrow.LARGE_BINARY, arrow.BINARY_VIEW, arrow.FIXED_SIZE_BINARY,
+		arrow.DATE32, arrow.DATE64, arrow.TIME32, arrow.TIME64, arrow.TIMESTAMP:
+		return true
+	case arrow.DICTIONARY:
+		return arrowTypeSupported(dataType.(*arrow.DictionaryType).ValueType)
+	default:
+		return false
+	}
+}
+
+// Columns returns the names of the columns.
+func (a *arrowRecordReader) Columns() []string { return a.columns }
+
+// Read returns the column names first if the reader was opened with a header, and then the values
+// of each row in CSV string form: numbers in decimal notation, booleans as true or false, dates
+// as YYYY-MM-DD, timestamps in RFC 3339 in UTC, times of day as hh:mm:ss with fractional seconds,
+// and binary values as their bytes. Null values are empty. It returns io.EOF after the last row.
+// Malformed data is reported as an error.
+func (a *arrowRecordReader) Read() (record []string, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			record, err = nil, fmt.Errorf("malformed Arrow IPC data: %v", p)
+		}
+	}()
+	if a.header {
+		a.header = false
+		a.values = nil
+		return a.columns, nil
+	}
+	for a.batch == nil || a.next >= int(a.batch.NumRows()) {
+		batch, err := a.batches.Read()
+		if err == io.EOF {
+			return nil, err
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Arrow IPC data: %w", err)
+		}
+		a.batch, a.next = batch, 0
+	}
+	record = make([]string, len(a.columns))
+	a.values = make([]interface{}, len(a.columns))
+	for idx, column := range a.batch.Columns() {
+		a.values[idx], record[idx] = arrowValue(column, a.next)
+	}
+	a.next++
+	return record, nil
+}
+
+// Values returns the native values of the row Read returned last, nil for null values and for
+// the header. Integers are int64 (or their decimal string if they do not fit), floating point
+// numbers float64, dates and timestamps time.Time, binary values []byte, and decimals and times
+// of day their string form.
+func (a *arrowRecordReader) Values() []interface{} { return a.values }
+
+// release frees the record batches. The reader cannot be read afterwards.
+func (a *arrowRecordReader) release() {
+	switch batches := a.batches.(type) {
+	case *ipc.Reader:
+		batches.Release()
+	case *ipc.FileReader:
+		batches.Close()
+	}
+	a.batches, a.batch = nil, nil
+}
+
+// Close closes the input.
+func (a *arrowRecordReader) Close() error {
+	a.release()
+	if a.file == nil {
+		return nil
+	}
+	return a.file.Close()
+}
+
+// arrowValue returns the native value of row of column and its CSV string form.
+func arrowValue(column arrow.Array, row int) (interface{}, string) {
+	if column.IsNull(row) {
+		return nil, ""
+	}
+	switch c := column.(type) {
+	case *array.Boolean:
+		return c.Value(row), strconv.FormatBool(c.Value(row))
+	case *array.Int8:
+		return arrowInt(int64(c.Value(row)))
+	case *array.Int16:
+		return arrowInt(int64(c.Value(row)))
+	case *array.Int32:
+		return arrowInt(int64(c.Value(row)))
+	case *array.Int64:
+		return arrowInt(c.Value(row))
+	case *array.Uint8:
+		return arrowInt(int64(c.Value(row)))
+	case *array.Uint16:
+		return arrowInt(int64(c.Value(row)))
+	case *array.Uint32:
+		return arrowInt(int64(c.Value(row)))
+	case *array.Uint64:
+		if v := c.Value(row); v > math.MaxInt64 {
+			text := strconv.FormatUint(v, 10)
+			return text, text
+		}
+		return arrowInt(int64(c.Value(row)))
+	case *array.Float16:
+		return arrowFloat(float64(c.Value(row).Float32()), 32)
+	case *array.Float32:
+		return arrowFloat(float64(c.Value(row)), 32)
+	case *array.Float64:
+		return arrowFloat(c.Value(row), 64)
+	case *array.Decimal32, *array.Decimal64, *array.Decimal128, *array.Decimal256:
+		text := column.ValueStr(row)
+		return text, text
+	case *array.String:
+		return c.Value(row), c.Value(row)
+	case *array.LargeString:
+		return c.Value(row), c.Value(row)
+	case *array.StringView:
+		return c.Value(row), c.Value(row)
+	case *array.Binary:
+		return arrowBytes(c.Value(row))
+	case *array.LargeBinary:
+		return arrowBytes(c.Value(row))
+	case *array.BinaryView:
+		return arrowBytes(c.Value(row))
+	case *array.FixedSizeBinary:
+		return arrowBytes(c.Value(row))
+	case *array.Date32:
+		return arrowDate(c.Value(row).ToTime())
+	case *array.Date64:
+		return arrowDate(c.Value(row).ToTime())
+	case *array.Time32:
+		return arrowTimeOfDay(c.Value(row).ToTime(c.DataType().(*arrow.Time32Type).Unit))
+	case *array.Time64:
+		return arrowTimeOfDay(c.Value(row).ToTime(c.DataType().(*arrow.Time64Type).Unit))
+	case *array.Timestamp:
+		// Values without a time zone are taken as UTC
+		t := c.Value(row).ToTime(c.DataType().(*arrow.TimestampType).Unit).UTC()
+		return t, t.Format(time.RFC3339Nano)
+	case *array.Dictionary:
+		return arrowValue(c.Dictionary(), c.GetValueIndex(row))
+	default:
+		// Null columns, whose values are all null
+		return nil, ""
+	}
+}
+
+func arrowInt(v int64) (interface{}, string) { return v, strconv.FormatInt(v, 10) }
+
+// arrowFloat returns v with the shortest decimal notation that reads back as the value of bitSize bits.
+func arrowFloat(v float64, bitSize int) (interface{}, string) {
+	return v, strconv.FormatFloat(v, 'f', -1, bitSize)
+}
+
+// arrowBytes copies b, which is only valid while its record batch is. An empty value stays
+// non-nil, so that it is not taken for a null value.
+func arrowBytes(b []byte) (interface{}, string) {
+	return append([]byte{}, b...), string(b)
+}
+
+func arrowDate(t time.Time) (interface{}, string) { return t, t.Format(time.DateOnly) }
+
+// arrowTimeOfDay returns the time of day of t, as there is no column type for it.
+func arrowTimeOfDay(t time.Time) (interface{}, string) {
+	text := t.Format("15:04:05.999999999")
+	return text, text
+}
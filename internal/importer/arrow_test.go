@@ -0,0 +1,229 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The Arrow files of testdata were written by the Go implementation of Arrow, except
+// colors_dictionary.arrows, whose messages were built with its flatbuffers code since the
+// version used then could not write dictionaries.
+
+// copyArrowTestdata copies an Arrow file of testdata into a temporary directory as name.
+func copyArrowTestdata(t *testing.T, testdata, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", testdata))
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+	return path
+}
+
+// readArrowTestdata returns the rows of an Arrow file of testdata, in text form and as native values.
+func readArrowTestdata(t *testing.T, name string) ([]string, [][]string, [][]interface{}) {
+	t.Helper()
+	records, err := openArrowRecords(context.Background(), FileSource{}, filepath.Join("testdata", name), false)
+	require.NoError(t, err)
+	defer records.Close()
+	var rows [][]string
+	var values [][]interface{}
+	for {
+		record, err := records.Read()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		rows = append(rows, record)
+		values = append(values, records.Values())
+	}
+	return records.Columns(), rows, values
+}
+
+func Test_arrowRecordReader(t *testing.T) {
+	t.Run("各型の値がCSVの文字列と元の型の値として読めること", func(t *testing.T) {
+		columns, rows, values := readArrowTestdata(t, "types.arrow")
+		assert.Equal(t, []string{
+			"id", "small", "count", "big", "ratio", "score", "half", "active", "name", "raw", "code",
+			"price", "birthday", "day", "opens", "closes", "created_at", "local_at", "nothing",
+		}, columns)
+		assert.Equal(t, [][]string{
+			{"1", "-128", "4294967295", "18446744073709551615", "0.5", "3.141592653589793", "1.5", "true",
+				"Alice", "ab", "JPN", "123.45", "1970-01-01", "1970-01-01", "00:00:00", "00:00:00",
+				"1970-01-01T00:00:00Z", "2024-03-01T12:00:00Z", ""},
+			{"2", "0", "", "1", "-1.25", "", "-0.25", "false",
+				"", "", "USA", "-0.05", "2024-03-01", "2024-03-01", "09:00:00", "18:00:00",
+				"2024-03-01T12:00:00.123456Z", "1970-01-01T00:00:00Z", ""},
+			{"-3", "127", "7", "0", "3", "0.0000001", "65504", "",
+				"日本語, \"quoted\"", "xyz", "FRA", "", "1969-12-31", "1970-01-02", "12:34:56.789", "23:59:59.999999",
+				"1969-12-31T23:59:59Z", "1970-01-01T00:00:01Z", ""},
+		}, rows)
+
+		first := values[0]
+		assert.Equal(t, int64(1), first[0])
+		assert.Equal(t, "18446744073709551615", first[3], "int64に収まらない値は文字列になること")
+		assert.Equal(t, 0.5, first[4])
+		assert.Equal(t, true, first[7])
+		assert.Equal(t, "Alice", first[8])
+		assert.Equal(t, []byte("ab"), first[9])
+		assert.Equal(t, "123.45", first[11])
+		assert.Equal(t, time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), first[17])
+		assert.Nil(t, first[18])
+		second := values[1]
+		assert.Nil(t, second[2])
+		assert.Equal(t, []byte{}, second[9], "空のバイト列はnullと区別されること")
+		assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), second[12])
+	})
+
+	t.Run("nullと空文字列が区別されること", func(t *testing.T) {
+		_, rows, values := readArrowTestdata(t, "nulls.arrows")
+		assert.Equal(t, [][]string{{"1", "", "\x00\xff,"}, {"2", "", ""}, {"3", "Carol", ""}}, rows)
+		assert.Equal(t, [][]interface{}{
+			{int64(1), "", []byte{0x00, 0xff, ','}},
+			{int64(2), nil, nil},
+			{int64(3), "Carol", []byte{}},
+		}, values)
+	})
+
+	t.Run("圧縮されたデータが読めること", func(t *testing.T) {
+		// Both files hold two record batches of the rows 1-600 and 601-1000
+		for _, name := range []string{"users_lz4.arrows", "users_zstd.feather"} {
+			t.Run(name, func(t *testing.T) {
+				columns, rows, _ := readArrowTestdata(t, name)
+				assert.Equal(t, []string{"id", "name"}, columns)
+				require.Len(t, rows, 1000)
+				for i, row := range rows {
+					id := i + 1
+					want := []string{fmt.Sprint(id), fmt.Sprintf("user-%d", id%10)}
+					if id%7 == 0 {
+						want[1] = ""
+					}
+					require.Equal(t, want, row)
+				}
+			})
+		}
+	})
+
+	t.Run("辞書エンコードされた列が差分の辞書も含めて読めること", func(t *testing.T) {
+		columns, rows, _ := readArrowTestdata(t, "colors_dictionary.arrows")
+		assert.Equal(t, []string{"id", "color"}, columns)
+		assert.Equal(t, [][]string{
+			{"1", "red"}, {"2", "blue"}, {"3", ""}, {"4", "green"}, {"5", "yellow"}, {"6", "red"},
+		}, rows)
+	})
+
+	t.Run("対応していない型はエラーになること", func(t *testing.T) {
+		_, err := openArrowRecords(context.Background(), FileSource{}, filepath.Join("testdata", "list.arrow"), true)
+		assert.ErrorContains(t, err, `column "tags" has Arrow type list`)
+	})
+
+	t.Run("壊れたデータはパニックせずエラーになること", func(t *testing.T) {
+		data, err := os.ReadFile(filepath.Join("testdata", "types.arrow"))
+		require.NoError(t, err)
+		readAll := func(data []byte) error {
+			records, err := newArrowRecordReader(bytes.NewReader(data), false)
+			if err != nil {
+				return err
+			}
+			defer records.release()
+			for {
+				if _, err := records.Read(); err != nil {
+					if err == io.EOF {
+						return nil
+					}
+					return err
+				}
+			}
+		}
+		for _, n := range []int{20, 100, 300, 1000, len(data) / 2} {
+			assert.Error(t, readAll(data[:n]), n)
+		}
+		corrupt := bytes.Clone(data)
+		for i := 16; i < 200; i += 7 {
+			corrupt[i] ^= 0xA5
+		}
+		assert.NotPanics(t, func() { readAll(corrupt) })
+	})
+
+	t.Run("Arrowのデータでない場合はエラーになること", func(t *testing.T) {
+		_, err := newArrowRecordReader(bytes.NewReader([]byte("id,name\n1,Alice\n")), true)
+		assert.Error(t, err)
+	})
+}
+
+func Test_ArrowInput(t *testing.T) {
+	// 1000 rows of id and name, with an empty name every 7th row
+	path := copyArrowTestdata(t, "users_lz4.arrows", "users.arrows")
+
+	t.Run("CSVに変換して読めること", func(t *testing.T) {
+		for _, hasHeader := range []bool{true, false} {
+			file, err := openInputFile(path, hasHeader)
+			require.NoError(t, err)
+			scanner := bufio.NewScanner(file)
+			var lines []string
+			for scanner.Scan() {
+				lines = append(lines, scanner.Text())
+			}
+			require.NoError(t, scanner.Err())
+			require.NoError(t, file.Close())
+
+			if hasHeader {
+				require.Len(t, lines, 1001)
+				assert.Equal(t, []string{"id,name", "1,user-1", "2,user-2"}, lines[:3])
+				assert.Equal(t, "7,", lines[7])
+			} else {
+				require.Len(t, lines, 1000)
+				assert.Equal(t, "1,user-1", lines[0])
+			}
+		}
+	})
+
+	t.Run("途中で閉じられること", func(t *testing.T) {
+		file, err := openInputFile(path, true)
+		require.NoError(t, err)
+		buf := make([]byte, 10)
+		_, err = file.Read(buf)
+		require.NoError(t, err)
+		assert.NoError(t, file.Close())
+	})
+
+	t.Run("行数が数えられること", func(t *testing.T) {
+		count, err := countCSVRecords(context.Background(), FileSource{path}, path, true)
+		require.NoError(t, err)
+		assert.Equal(t, 1000, count)
+	})
+
+	t.Run("マニフェストにはファイル全体のハッシュと行数が記録されること", func(t *testing.T) {
+		feather := copyArrowTestdata(t, "users_zstd.feather", "users.feather")
+		file, err := describeFile(feather, true)
+		require.NoError(t, err)
+		info, err := os.Stat(feather)
+		require.NoError(t, err)
+		assert.Equal(t, info.Size(), file.Size)
+		assert.Equal(t, 1000, file.Rows)
+	})
+
+	t.Run("Arrowでないファイルはエラーになること", func(t *testing.T) {
+		broken := filepath.Join(t.TempDir(), "users.arrow")
+		require.NoError(t, os.WriteFile(broken, []byte("id,name\n1,a\n"), 0o644))
+		_, err := countCSVRecords(context.Background(), FileSource{broken}, broken, true)
+		assert.Error(t, err)
+	})
+
+	t.Run("Arrowの拡張子のファイルも入力として列挙されること", func(t *testing.T) {
+		files, err := getCSVFiles(filepath.Dir(path))
+		require.NoError(t, err)
+		assert.Equal(t, []string{path}, files)
+		assert.True(t, IsInputFile("orders.Feather"))
+		assert.False(t, IsInputFile("orders.parquet"))
+	})
+}
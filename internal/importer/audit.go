@@ -0,0 +1,36 @@
+package importer
+
+import (
+	"strings"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+)
+
+// auditValue returns the configured value of an audit column, matching the column name case-insensitively.
+// The BatchColumn is an audit column whose value is the id of the current run.
+func (i *Importer) auditValue(colName string) (string, bool) {
+	if i.isBatchColumn(colName) {
+		return i.batchID, true
+	}
+	for name, value := range i.AuditColumns {
+		if strings.EqualFold(name, colName) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// positionalColumnMap maps the table's column names to their index in a CSV file without a header.
+// The columns are expected in table order, leaving out audit columns, which CSV files never contain.
+func (i *Importer) positionalColumnMap(dbInfo database.DBInfo) map[string]int {
+	columnMap := make(map[string]int, len(dbInfo.Columns))
+	idx := 0
+	for _, colInfo := range dbInfo.Columns {
+		if _, ok := i.auditValue(colInfo.ColumnName); ok {
+			continue
+		}
+		columnMap[colInfo.ColumnName] = idx
+		idx++
+	}
+	return columnMap
+}
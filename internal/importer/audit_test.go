@@ -0,0 +1,37 @@
+package importer
+
+import (
+	"testing"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_positionalColumnMap(t *testing.T) {
+	dbInfo := database.DBInfo{
+		TableName: "users",
+		Columns: []database.ColumnInfo{
+			{ColumnName: "id"},
+			{ColumnName: "created_by"},
+			{ColumnName: "name"},
+			{ColumnName: "ROW_VERSION"},
+		},
+	}
+
+	t.Run("監査カラムを除いた順に列が割り当てられること", func(t *testing.T) {
+		i := &Importer{AuditColumns: map[string]string{"created_by": "importer", "row_version": "1"}}
+		assert.Equal(t, map[string]int{"id": 0, "name": 1}, i.positionalColumnMap(dbInfo))
+	})
+
+	t.Run("監査カラムがない場合はテーブルの順に割り当てられること", func(t *testing.T) {
+		i := &Importer{}
+		assert.Equal(t, map[string]int{"id": 0, "created_by": 1, "name": 2, "ROW_VERSION": 3}, i.positionalColumnMap(dbInfo))
+	})
+	t.Run("バッチカラムも監査カラムとして扱われること", func(t *testing.T) {
+		i := &Importer{BatchColumn: "Created_By", batchID: "run-1"}
+		assert.Equal(t, map[string]int{"id": 0, "name": 1, "ROW_VERSION": 2}, i.positionalColumnMap(dbInfo))
+		value, ok := i.auditValue("created_by")
+		assert.True(t, ok)
+		assert.Equal(t, "run-1", value)
+	})
+}
@@ -0,0 +1,114 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+)
+
+// isBatchColumn reports whether colName is the BatchColumn, matching case-insensitively.
+func (i *Importer) isBatchColumn(colName string) bool {
+	return i.BatchColumn != "" && strings.EqualFold(i.BatchColumn, colName)
+}
+
+// startBatch tags the rows written from now on, including auto-created parent records, with
+// runID in BatchColumn. The returned function stops tagging them.
+func (i *Importer) startBatch(runID string) func() {
+	if i.BatchColumn == "" {
+		return func() {}
+	}
+	if !i.hasBatchColumn() {
+		log.Printf("Warning: No table has the batch column %s; the written rows are not tagged.\n", i.BatchColumn)
+	}
+	i.batchID = runID
+	configurer, ok := i.DBClient.(database.ParentConfigurer)
+	if ok {
+		configurer.SetBatchColumn(i.BatchColumn, runID)
+	} else if !i.NoAutoParents {
+		log.Printf("Warning: The database client cannot tag auto-created parent records with the batch column %s.\n", i.BatchColumn)
+	}
+	log.Printf("Tagging the written rows with %s = %s.\n", i.BatchColumn, runID)
+	return func() {
+		i.batchID = ""
+		if ok {
+			configurer.SetBatchColumn("", "")
+		}
+	}
+}
+
+// hasBatchColumn reports whether any table of the schema has the BatchColumn.
+func (i *Importer) hasBatchColumn() bool {
+	for _, dbInfo := range i.DBSchema {
+		for _, colInfo := range dbInfo.Columns {
+			if i.isBatchColumn(colInfo.ColumnName) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// existingRowUpdater returns the RowUpdater that updates the rows of the table of dbInfo that
+// already exist, or nil if they are upserted. While a run is tagged, an upsert would also tag
+// the rows it updates, and rolling back the run would then delete them; updated instead, these
+// rows keep the BatchColumn of the run that inserted them.
+func (i *Importer) existingRowUpdater(dbInfo database.DBInfo) database.RowUpdater {
+	if i.batchID == "" || len(dbInfo.PrimaryKeyColumns) == 0 || i.batchColumnOf(dbInfo) == "" {
+		return nil
+	}
+	if mode := i.Tables[dbInfo.TableName].Mode; mode != "" && mode != database.InsertUpsert {
+		return nil
+	}
+	updater, ok := i.DBClient.(database.RowUpdater)
+	if !ok {
+		log.Printf("Warning: The existing rows of table %s are tagged with the batch column when updated; rolling back the run deletes them.\n", dbInfo.TableName)
+		return nil
+	}
+	return updater
+}
+
+// existingRowKey returns the primary key of the row of csvValues if it is already in the table of
+// dbInfo, or nil if the row is new.
+func (i *Importer) existingRowKey(ctx context.Context, dbInfo database.DBInfo, csvValues map[string]string) ([]string, error) {
+	keyValues := make([]string, len(dbInfo.PrimaryKeyColumns))
+	for idx, colName := range dbInfo.PrimaryKeyColumns {
+		if keyValues[idx] = csvValues[colName]; keyValues[idx] == "" {
+			return nil, nil // The database assigns the key, so the row is new
+		}
+	}
+	stmtCtx, cancel := i.statementContext(ctx, dbInfo.TableName, parentStatement("existing row check on", dbInfo.TableName, dbInfo.PrimaryKeyColumns, keyValues))
+	exists, err := i.DBClient.ParentRecordExists(stmtCtx, dbInfo, dbInfo.PrimaryKeyColumns, keyValues)
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check existing row of %s.%v (value: %v): %w", dbInfo.TableName, dbInfo.PrimaryKeyColumns, keyValues, err)
+	}
+	if !exists {
+		return nil, nil
+	}
+	return keyValues, nil
+}
+
+// updateExistingRow updates the row of csvValues identified by keyValues, leaving out the key, the
+// BatchColumn and the columns in skip.
+func (i *Importer) updateExistingRow(ctx context.Context, updater database.RowUpdater, dbInfo database.DBInfo, keyValues []string, csvValues map[string]string, skip map[string]bool) error {
+	var columnNames, values []string
+	for _, colInfo := range dbInfo.InsertColumns() {
+		if skip[colInfo.ColumnName] || i.isBatchColumn(colInfo.ColumnName) || slices.Contains(dbInfo.PrimaryKeyColumns, colInfo.ColumnName) {
+			continue
+		}
+		columnNames = append(columnNames, colInfo.ColumnName)
+		values = append(values, csvValues[colInfo.ColumnName])
+	}
+	if len(columnNames) == 0 {
+		return nil
+	}
+	stmtCtx, cancel := i.statementContext(ctx, dbInfo.TableName, func() string {
+		return fmt.Sprintf("UPDATE %s SET (%s) WHERE (%s) = (%s)", dbInfo.TableName, strings.Join(columnNames, ", "), strings.Join(dbInfo.PrimaryKeyColumns, ", "), strings.Join(keyValues, ", "))
+	})
+	defer cancel()
+	return updater.UpdateRow(stmtCtx, dbInfo, dbInfo.PrimaryKeyColumns, keyValues, columnNames, values)
+}
@@ -0,0 +1,128 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// checkpointInterval is the number of rows between two writes of the state file. After an
+// interruption, at most this many rows of a file are attempted again.
+const checkpointInterval = 1000
+
+// Checkpoint records how far each CSV file has been imported, so that an interrupted import
+// can resume where it left off. Every row is committed as it is inserted, or with its batch, so
+// the rows before the recorded offset are already in the database.
+type Checkpoint struct {
+	path  string
+	Files map[string]*FileCheckpoint `json:"files"`
+}
+
+// FileCheckpoint is the progress of one CSV file. Size and ModTime identify the version of the
+// file the progress belongs to; a file that changed since is imported from the start.
+type FileCheckpoint struct {
+	Size     int64     `json:"size"`
+	ModTime  time.Time `json:"mod_time"`
+	Offset   int64     `json:"offset"` // Byte offset just past the last processed row
+	Rows     int       `json:"rows"`   // Number of data rows processed
+	Complete bool      `json:"complete"`
+
+	unsaved int // Rows processed since the state file was last written
+}
+
+// LoadCheckpoint reads the state file at path. A missing file yields an empty checkpoint.
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	c := &Checkpoint{path: path, Files: make(map[string]*FileCheckpoint)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("failed to parse state file %s: %w", path, err)
+	}
+	if c.Files == nil {
+		c.Files = make(map[string]*FileCheckpoint)
+	}
+	return c, nil
+}
+
+// file returns the progress of the CSV file at filePath, starting over if the file changed.
+func (c *Checkpoint) file(filePath string) (*FileCheckpoint, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat CSV file %s: %w", filePath, err)
+	}
+	state, ok := c.Files[filePath]
+	if ok && state.Size == info.Size() && state.ModTime.Equal(info.ModTime()) {
+		return state, nil
+	}
+	if ok {
+		log.Printf("Warning: CSV file %s changed since the previous run. Importing it from the start.\n", filePath)
+	}
+	state = &FileCheckpoint{Size: info.Size(), ModTime: info.ModTime()}
+	c.Files[filePath] = state
+	return state, nil
+}
+
+// advance records that the row ending at offset was processed.
+func (c *Checkpoint) advance(state *FileCheckpoint, offset int64) error {
+	state.Offset = offset
+	state.Rows++
+	state.unsaved++
+	if state.unsaved < checkpointInterval {
+		return nil
+	}
+	return c.save()
+}
+
+// commit writes the state file if rows were processed since it was last written. It is called
+// once a batch is committed, so that a crash never makes the next run insert its rows again.
+func (c *Checkpoint) commit() error {
+	for _, state := range c.Files {
+		if state.unsaved > 0 {
+			return c.save()
+		}
+	}
+	return nil
+}
+
+// complete records that every row of the file was processed.
+func (c *Checkpoint) complete(state *FileCheckpoint) error {
+	state.Complete = true
+	return c.save()
+}
+
+// save writes the state file. It writes to a temporary file first, so an interruption
+// never leaves a truncated state file behind.
+func (c *Checkpoint) save() error {
+	for _, state := range c.Files {
+		state.unsaved = 0
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state file %s: %w", c.path, err)
+	}
+	tmpPath := c.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write state file %s: %w", tmpPath, err)
+	}
+	if err := os.Rename(tmpPath, c.path); err != nil {
+		return fmt.Errorf("failed to write state file %s: %w", c.path, err)
+	}
+	return nil
+}
+
+// remove deletes the state file once every file has been imported, so the next run starts afresh.
+func (c *Checkpoint) remove() error {
+	c.Files = make(map[string]*FileCheckpoint)
+	if err := os.Remove(c.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove state file %s: %w", c.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Checkpoint(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "users.csv")
+	statePath := filepath.Join(dir, "state.json")
+	require.NoError(t, os.WriteFile(csvPath, []byte("id\n1\n2\n"), 0o644))
+
+	t.Run("保存した進捗を読み込めること", func(t *testing.T) {
+		c, err := LoadCheckpoint(statePath)
+		require.NoError(t, err)
+		state, err := c.file(csvPath)
+		require.NoError(t, err)
+		require.NoError(t, c.advance(state, 5))
+		require.NoError(t, c.save())
+
+		loaded, err := LoadCheckpoint(statePath)
+		require.NoError(t, err)
+		resumed, err := loaded.file(csvPath)
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), resumed.Offset)
+		assert.Equal(t, 1, resumed.Rows)
+		assert.False(t, resumed.Complete)
+	})
+
+	t.Run("コミット時に未保存の進捗が書き込まれること", func(t *testing.T) {
+		c, err := LoadCheckpoint(statePath)
+		require.NoError(t, err)
+		state, err := c.file(csvPath)
+		require.NoError(t, err)
+		require.NoError(t, c.advance(state, 7))
+		require.NoError(t, c.commit())
+
+		loaded, err := LoadCheckpoint(statePath)
+		require.NoError(t, err)
+		resumed, err := loaded.file(csvPath)
+		require.NoError(t, err)
+		assert.Equal(t, int64(7), resumed.Offset)
+		assert.Equal(t, 2, resumed.Rows)
+	})
+
+	t.Run("ファイルが変更された場合は最初からになること", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(csvPath, []byte("id\n1\n2\n3\n"), 0o644))
+		require.NoError(t, os.Chtimes(csvPath, time.Now(), time.Now().Add(time.Hour)))
+
+		loaded, err := LoadCheckpoint(statePath)
+		require.NoError(t, err)
+		state, err := loaded.file(csvPath)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), state.Offset)
+		assert.Equal(t, 0, state.Rows)
+	})
+
+	t.Run("削除後は状態ファイルが存在しないこと", func(t *testing.T) {
+		c, err := LoadCheckpoint(statePath)
+		require.NoError(t, err)
+		require.NoError(t, c.remove())
+		_, err = os.Stat(statePath)
+		assert.True(t, os.IsNotExist(err))
+	})
+}
@@ -0,0 +1,211 @@
+package importer
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+)
+
+// closureTable is a table written by exportClosure.
+type closureTable struct {
+	dbInfo   database.DBInfo
+	columns  []database.ColumnInfo
+	filePath string
+	file     *os.File
+	w        *csv.Writer
+	rows     int
+	// root is set while the rows selected by where (or all rows, if empty) are still to be read.
+	root  bool
+	where string
+	// written holds the rows written so far, by primary key (or all values, without one).
+	written map[string]bool
+	// requests holds the keys referenced by the rows of other tables, by referenced columns.
+	requests map[string]*keyRequest
+}
+
+// keyRequest collects the keys of a table referenced through the same columns.
+type keyRequest struct {
+	columns []string
+	pending map[string][]string
+	fetched map[string]bool
+}
+
+// exportClosure writes the rows selected by where and SelectedTables, and the rows they reference,
+// to dir. The tables are read in reverse import order, so that the rows referencing a table are
+// usually read before it; tables found to be referenced later, e.g. in a cycle, are read again for
+// the new keys only.
+func (i *Importer) exportClosure(ctx context.Context, dir string, order []string, where map[string]string) error {
+	reader, ok := i.DBClient.(database.RowReader)
+	if !ok {
+		return errors.New("reading rows is not supported by the database")
+	}
+	tables := make(map[string]*closureTable)
+	defer func() {
+		for _, t := range tables {
+			t.file.Close()
+		}
+	}()
+	table := func(tableName string) (*closureTable, error) {
+		if t, ok := tables[tableName]; ok {
+			return t, nil
+		}
+		t := &closureTable{dbInfo: i.DBSchema[tableName], written: make(map[string]bool), requests: make(map[string]*keyRequest)}
+		var header []string
+		t.columns, header = i.csvColumns(t.dbInfo)
+		t.filePath = filepath.Join(dir, i.csvFileName(tableName))
+		file, err := os.Create(t.filePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create CSV file %s: %w", t.filePath, err)
+		}
+		t.file, t.w = file, csv.NewWriter(file)
+		tables[tableName] = t
+		if err := t.w.Write(header); err != nil {
+			return nil, fmt.Errorf("failed to write CSV file %s: %w", t.filePath, err)
+		}
+		return t, nil
+	}
+	for _, tableName := range append(slices.Sorted(maps.Keys(where)), i.SelectedTables...) {
+		t, err := table(tableName)
+		if err != nil {
+			return err
+		}
+		t.root, t.where = true, where[tableName]
+	}
+
+	// write writes a row not written yet and requests the rows it references
+	write := func(t *closureTable, values []string) error {
+		id := closureRowID(t, values)
+		if t.written[id] {
+			return nil
+		}
+		t.written[id] = true
+		t.rows++
+		// The rows are told apart and their references followed by their values before masking
+		masked, err := i.exportValues(t.dbInfo.TableName, t.columns, values)
+		if err != nil {
+			return err
+		}
+		if err := t.w.Write(masked); err != nil {
+			return err
+		}
+		for _, fk := range t.dbInfo.ForeignKeys {
+			if _, ok := i.DBSchema[fk.ForeignTableName]; !ok {
+				return &database.MissingParentTableError{TableName: fk.ForeignTableName, ConstraintName: fk.ConstraintName}
+			}
+			key, ok := closureValues(t.columns, fk.ColumnNames, values)
+			if !ok {
+				continue
+			}
+			parent, err := table(fk.ForeignTableName)
+			if err != nil {
+				return err
+			}
+			parent.request(fk.ForeignColumnNames, key)
+		}
+		return nil
+	}
+
+	for read := true; read; {
+		read = false
+		for idx := len(order) - 1; idx >= 0; idx-- {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			t, ok := tables[order[idx]]
+			if !ok {
+				continue
+			}
+			var filters []database.RowFilter
+			if t.root {
+				filters = append(filters, database.RowFilter{Where: t.where})
+				t.root = false
+			}
+			for _, name := range slices.Sorted(maps.Keys(t.requests)) {
+				req := t.requests[name]
+				if len(req.pending) == 0 {
+					continue
+				}
+				filter := database.RowFilter{KeyColumns: req.columns}
+				for _, id := range slices.Sorted(maps.Keys(req.pending)) {
+					filter.Keys = append(filter.Keys, req.pending[id])
+					req.fetched[id] = true
+				}
+				clear(req.pending)
+				filters = append(filters, filter)
+			}
+			for _, filter := range filters {
+				read = true
+				err := reader.ReadRows(ctx, t.dbInfo, t.columns, filter, func(values []string) error {
+					return write(t, values)
+				})
+				if err != nil {
+					return fmt.Errorf("failed to export table %s: %w", t.dbInfo.TableName, err)
+				}
+			}
+		}
+	}
+
+	for _, tableName := range order {
+		t, ok := tables[tableName]
+		if !ok {
+			continue
+		}
+		t.w.Flush()
+		if err := t.w.Error(); err != nil {
+			return fmt.Errorf("failed to write CSV file %s: %w", t.filePath, err)
+		}
+		if err := t.file.Close(); err != nil {
+			return fmt.Errorf("failed to write CSV file %s: %w", t.filePath, err)
+		}
+		log.Printf("Exported %d row(s) of table %s to %s.\n", t.rows, tableName, t.filePath)
+	}
+	return nil
+}
+
+// request asks for the row holding key in columns, unless it has been read already.
+func (t *closureTable) request(columns, key []string) {
+	name := strings.Join(columns, ",")
+	req, ok := t.requests[name]
+	if !ok {
+		req = &keyRequest{columns: columns, pending: make(map[string][]string), fetched: make(map[string]bool)}
+		t.requests[name] = req
+	}
+	id := strings.Join(key, "\x00")
+	if !req.fetched[id] {
+		req.pending[id] = key
+	}
+}
+
+// closureRowID identifies a row by its primary key, or by all its values if the table has none.
+func closureRowID(t *closureTable, values []string) string {
+	if key, ok := closureValues(t.columns, t.dbInfo.PrimaryKeyColumns, values); ok {
+		return strings.Join(key, "\x00")
+	}
+	return strings.Join(values, "\x00")
+}
+
+// closureValues returns the values of columnNames in a row of columns; false if any is missing
+// or empty, as an empty foreign key references nothing.
+func closureValues(columns []database.ColumnInfo, columnNames, values []string) ([]string, bool) {
+	if len(columnNames) == 0 {
+		return nil, false
+	}
+	key := make([]string, len(columnNames))
+	for idx, colName := range columnNames {
+		colIdx := slices.IndexFunc(columns, func(c database.ColumnInfo) bool { return c.ColumnName == colName })
+		if colIdx == -1 || values[colIdx] == "" {
+			return nil, false
+		}
+		key[idx] = values[colIdx]
+	}
+	return key, true
+}
@@ -0,0 +1,101 @@
+package importer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// filterClient serves ReadRows from fixed rows, honoring the keys of a filter and a Where of the
+// form "column = value".
+type filterClient struct {
+	database.DBClient
+	rows map[string][][]string
+}
+
+func (c *filterClient) ReadRows(ctx context.Context, dbInfo database.DBInfo, columns []database.ColumnInfo, filter database.RowFilter, fn func(values []string) error) error {
+	value := func(row []string, colName string) string {
+		return row[slices.IndexFunc(columns, func(c database.ColumnInfo) bool { return c.ColumnName == colName })]
+	}
+	for _, row := range c.rows[dbInfo.TableName] {
+		if filter.Where != "" {
+			colName, want, _ := strings.Cut(filter.Where, " = ")
+			if value(row, colName) != want {
+				continue
+			}
+		}
+		if len(filter.KeyColumns) > 0 && !slices.ContainsFunc(filter.Keys, func(key []string) bool {
+			for idx, colName := range filter.KeyColumns {
+				if value(row, colName) != key[idx] {
+					return false
+				}
+			}
+			return true
+		}) {
+			continue
+		}
+		if err := fn(slices.Clone(row)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func Test_ExportCSVFiles_where(t *testing.T) {
+	i := &Importer{
+		DBSchema: map[string]database.DBInfo{
+			"orgs": {TableName: "orgs", Columns: []database.ColumnInfo{{ColumnName: "id"}}, PrimaryKeyColumns: []string{"id"}},
+			"users": {
+				TableName:         "users",
+				Columns:           []database.ColumnInfo{{ColumnName: "id"}, {ColumnName: "org_id", IsNullable: true}},
+				PrimaryKeyColumns: []string{"id"},
+				ForeignKeys:       []database.ForeignKeyInfo{{ConstraintName: "fk_org", TableName: "users", ColumnNames: []string{"org_id"}, ForeignTableName: "orgs", ForeignColumnNames: []string{"id"}}},
+			},
+			"orders": {
+				TableName:         "orders",
+				Columns:           []database.ColumnInfo{{ColumnName: "id"}, {ColumnName: "user_id"}},
+				PrimaryKeyColumns: []string{"id"},
+				ForeignKeys:       []database.ForeignKeyInfo{{ConstraintName: "fk_user", TableName: "orders", ColumnNames: []string{"user_id"}, ForeignTableName: "users", ForeignColumnNames: []string{"id"}}},
+			},
+			"items": {TableName: "items", Columns: []database.ColumnInfo{{ColumnName: "id"}}, PrimaryKeyColumns: []string{"id"}},
+		},
+		DBClient: &filterClient{rows: map[string][][]string{
+			"orgs":   {{"100"}, {"200"}},
+			"users":  {{"1", "100"}, {"2", "200"}, {"3", ""}},
+			"orders": {{"10", "1"}, {"11", "2"}, {"12", "1"}, {"13", "3"}},
+			"items":  {{"7"}},
+		}},
+	}
+	read := func(t *testing.T, path string) string {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	t.Run("選択した行と参照される親の行だけが書き出されること", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, i.ExportCSVFiles(context.Background(), dir, map[string]string{"orders": "user_id = 1"}))
+		assert.Equal(t, "id,user_id\n10,1\n12,1\n", read(t, filepath.Join(dir, "orders.csv")))
+		assert.Equal(t, "id,org_id\n1,100\n", read(t, filepath.Join(dir, "users.csv")))
+		assert.Equal(t, "id\n100\n", read(t, filepath.Join(dir, "orgs.csv")))
+		assert.NoFileExists(t, filepath.Join(dir, "items.csv"))
+	})
+
+	t.Run("空の外部キーは親を参照しないこと", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, i.ExportCSVFiles(context.Background(), dir, map[string]string{"orders": "id = 13"}))
+		assert.Equal(t, "id,org_id\n3,\n", read(t, filepath.Join(dir, "users.csv")))
+		assert.NoFileExists(t, filepath.Join(dir, "orgs.csv"))
+	})
+
+	t.Run("存在しないテーブルはエラーになること", func(t *testing.T) {
+		assert.Error(t, i.ExportCSVFiles(context.Background(), t.TempDir(), map[string]string{"payments": "id = 1"}))
+	})
+}
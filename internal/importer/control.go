@@ -0,0 +1,90 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrTableAborted reports that the import of a table was aborted through its TableControl.
+var ErrTableAborted = errors.New("table import aborted")
+
+// TableControl pauses, resumes and aborts the imports of individual tables while an import runs,
+// e.g. from an interactive UI. Its methods may be called from any goroutine. A paused table stops
+// before its next row until it is resumed; an aborted table stops reading its file, keeping the
+// rows already inserted, and the import continues with the next table.
+type TableControl struct {
+	mu      sync.Mutex
+	paused  map[string]bool
+	aborted map[string]bool
+	changed chan struct{} // Closed, and replaced, when a table is resumed or aborted
+}
+
+// NewTableControl creates a TableControl with no paused or aborted tables.
+func NewTableControl() *TableControl {
+	return &TableControl{paused: make(map[string]bool), aborted: make(map[string]bool), changed: make(chan struct{})}
+}
+
+// Pause pauses the import of table.
+func (c *TableControl) Pause(table string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.paused[table] = true
+}
+
+// Resume resumes the import of a paused table.
+func (c *TableControl) Resume(table string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.paused, table)
+	c.notify()
+}
+
+// Abort aborts the import of table. It cannot be undone.
+func (c *TableControl) Abort(table string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.aborted[table] = true
+	c.notify()
+}
+
+// Paused reports whether the import of table is paused.
+func (c *TableControl) Paused(table string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.paused[table]
+}
+
+// Aborted reports whether the import of table was aborted.
+func (c *TableControl) Aborted(table string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.aborted[table]
+}
+
+// notify wakes the waits. c.mu must be held.
+func (c *TableControl) notify() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// wait blocks while table is paused. It returns ErrTableAborted if the table is aborted, and the
+// error of ctx if ctx is done first.
+func (c *TableControl) wait(ctx context.Context, table string) error {
+	for {
+		c.mu.Lock()
+		aborted, paused, changed := c.aborted[table], c.paused[table], c.changed
+		c.mu.Unlock()
+		if aborted {
+			return ErrTableAborted
+		}
+		if !paused {
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
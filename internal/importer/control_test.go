@@ -0,0 +1,45 @@
+package importer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_TableControl(t *testing.T) {
+	t.Run("一時停止したテーブルは再開するまで待つこと", func(t *testing.T) {
+		c := NewTableControl()
+		c.Pause("users")
+		assert.NoError(t, c.wait(context.Background(), "orders"), "他のテーブルは待たないこと")
+
+		waited := make(chan error)
+		go func() { waited <- c.wait(context.Background(), "users") }()
+		select {
+		case <-waited:
+			t.Fatal("wait returned while the table is paused")
+		case <-time.After(20 * time.Millisecond):
+		}
+		c.Resume("users")
+		assert.NoError(t, <-waited)
+	})
+
+	t.Run("中止したテーブルは一時停止中でもErrTableAbortedを返すこと", func(t *testing.T) {
+		c := NewTableControl()
+		c.Pause("users")
+		waited := make(chan error)
+		go func() { waited <- c.wait(context.Background(), "users") }()
+		c.Abort("users")
+		assert.ErrorIs(t, <-waited, ErrTableAborted)
+		assert.True(t, c.Aborted("users"))
+	})
+
+	t.Run("コンテキストがキャンセルされると待機を終えること", func(t *testing.T) {
+		c := NewTableControl()
+		c.Pause("users")
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		assert.ErrorIs(t, c.wait(ctx, "users"), context.Canceled)
+	})
+}
@@ -0,0 +1,449 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+)
+
+// FileFormat is a format of the files ConvertFiles reads and writes.
+type FileFormat string
+
+const (
+	FormatCSV     FileFormat = "csv"     // Comma-separated values, with a header row unless told otherwise
+	FormatJSONL   FileFormat = "jsonl"   // One JSON object per line, keyed by the CSV header
+	FormatParquet FileFormat = "parquet" // Apache Parquet, with a typed column per CSV column
+)
+
+// ParseFileFormat parses "csv", "jsonl" ("ndjson" is accepted as an alias) or "parquet".
+func ParseFileFormat(s string) (FileFormat, error) {
+	switch strings.ToLower(s) {
+	case "csv":
+		return FormatCSV, nil
+	case "jsonl", "ndjson":
+		return FormatJSONL, nil
+	case "parquet":
+		return FormatParquet, nil
+	default:
+		return "", fmt.Errorf("unknown file format %q (expected csv, jsonl or parquet)", s)
+	}
+}
+
+// fileFormatOf returns the format of a file by its extension.
+func fileFormatOf(path string) (FileFormat, bool) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return FormatCSV, true
+	case ".jsonl", ".ndjson":
+		return FormatJSONL, true
+	case ".parquet":
+		return FormatParquet, true
+	default:
+		return "", false
+	}
+}
+
+// ConvertFiles rewrites the CSV, JSONL and Parquet files in inDir (or those of the SelectedTables) to files
+// of format to in outDir, named after the input with the extension of the format. Files already in
+// that format are skipped.
+//
+// The values are typed by the columns of the file's table: in JSONL, numbers and booleans are
+// written as JSON numbers and booleans, dates as YYYY-MM-DD, timestamps in RFC 3339 and empty
+// cells as null. Values that do not fit their column are kept as strings with a warning, and the
+// columns of files without a table are strings. JSON objects and arrays cannot be written to CSV.
+// In Parquet, the columns are optional INT64, DOUBLE, BOOLEAN, DATE, TIMESTAMP (microseconds) or
+// STRING columns by the same types, with empty cells as nulls; values that do not fit their column
+// are an error. Parquet inputs must be flat, and their values are read in the CSV form of their
+// logical types, e.g. dates as YYYY-MM-DD and decimals with their scale.
+// hasHeader tells whether the CSV inputs have a header row; the CSV files written always have one.
+func (i *Importer) ConvertFiles(ctx context.Context, inDir, outDir string, to FileFormat, hasHeader bool) error {
+	entries, err := os.ReadDir(inDir)
+	if err != nil {
+		return fmt.Errorf("failed to read directory %s: %w", inDir, err)
+	}
+	var files []string
+	for _, entry := range entries {
+		if _, ok := fileFormatOf(entry.Name()); ok && !entry.IsDir() {
+			files = append(files, filepath.Join(inDir, entry.Name()))
+		}
+	}
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory %s: %w", outDir, err)
+	}
+
+	for _, filePath := range files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		from, _ := fileFormatOf(filePath)
+		if from == to {
+			log.Printf("Skipping %s, which is already %s.\n", filePath, to)
+			continue
+		}
+		tableName := i.fileTable(filePath)
+		if len(i.SelectedTables) > 0 && !slices.Contains(i.SelectedTables, tableName) {
+			continue
+		}
+		dbInfo, ok := i.DBSchema[tableName]
+		if !ok {
+			log.Printf("Warning: No table found for %s. Its values are converted as strings.\n", filePath)
+			dbInfo = database.DBInfo{TableName: tableName}
+		}
+
+		base := filepath.Base(filePath)
+		outPath := filepath.Join(outDir, strings.TrimSuffix(base, filepath.Ext(base))+"."+string(to))
+		rows, err := i.convertFile(filePath, outPath, dbInfo, to, hasHeader)
+		if err != nil {
+			return err
+		}
+		log.Printf("Converted %d row(s) of %s to %s.\n", rows, filePath, outPath)
+	}
+	return nil
+}
+
+// fileTable returns the table of a file: the table claiming it through TableOptions.File, or the
+// one named after it.
+func (i *Importer) fileTable(filePath string) string {
+	for tableName, opts := range i.Tables {
+		if opts.File == filepath.Base(filePath) {
+			return tableName
+		}
+	}
+	return strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+}
+
+// convertFile converts a single file and returns the number of rows written.
+func (i *Importer) convertFile(inPath, outPath string, dbInfo database.DBInfo, to FileFormat, hasHeader bool) (int, error) {
+	in, err := os.Open(inPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open %s: %w", inPath, err)
+	}
+	defer in.Close()
+	var records recordReader
+	switch from, _ := fileFormatOf(inPath); from {
+	case FormatCSV:
+		records, err = i.newCSVRecordReader(in, inPath, dbInfo, hasHeader)
+	case FormatJSONL:
+		records = newJSONLRecordReader(in, inPath)
+	default:
+		records, err = newParquetRecordReader(in, inPath)
+	}
+	if err != nil {
+		return 0, err
+	}
+	out, err := os.Create(outPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create %s: %w", outPath, err)
+	}
+	defer out.Close()
+
+	w := bufio.NewWriter(out)
+	rows, err := i.copyRecords(records, w, to, dbInfo)
+	if err != nil {
+		return 0, err
+	}
+	if err := w.Flush(); err != nil {
+		return 0, fmt.Errorf("failed to write %s: %w", outPath, err)
+	}
+	if err := out.Close(); err != nil {
+		return 0, fmt.Errorf("failed to write %s: %w", outPath, err)
+	}
+	return rows, nil
+}
+
+// recordReader reads the records of an input file in CSV string form, null as empty.
+type recordReader interface {
+	// header returns the name of each value of the records, or nil while it is not known.
+	header() []string
+	// read returns the next record, or io.EOF after the last one.
+	read() ([]string, error)
+	// pos returns the position of the last record read, for messages.
+	pos() string
+}
+
+// recordWriter writes records to an output file.
+type recordWriter interface {
+	// write writes a record read at pos.
+	write(record []string, pos string) error
+	// close writes what the format needs after the last record.
+	close() error
+}
+
+// copyRecords writes the records of records to w in format to and returns their number. The
+// output is started once the header is known, so inputs without records and header leave it empty.
+func (i *Importer) copyRecords(records recordReader, w io.Writer, to FileFormat, dbInfo database.DBInfo) (int, error) {
+	var writer recordWriter
+	rows := 0
+	for {
+		record, err := records.read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return rows, err
+		}
+		if writer == nil {
+			if writer, err = i.newRecordWriter(w, to, records.header(), dbInfo); err != nil {
+				return rows, err
+			}
+		}
+		if err := writer.write(record, records.pos()); err != nil {
+			return rows, err
+		}
+		rows++
+	}
+	if writer == nil && records.header() != nil {
+		var err error
+		if writer, err = i.newRecordWriter(w, to, records.header(), dbInfo); err != nil {
+			return rows, err
+		}
+	}
+	if writer == nil {
+		return rows, nil
+	}
+	return rows, writer.close()
+}
+
+// newRecordWriter returns a writer of records of format to with the given header, typed by the
+// columns of dbInfo it names.
+func (i *Importer) newRecordWriter(w io.Writer, to FileFormat, header []string, dbInfo database.DBInfo) (recordWriter, error) {
+	switch to {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		return &csvRecordWriter{writer: cw}, cw.Write(header)
+	case FormatJSONL:
+		return &jsonlRecordWriter{w: w, keys: header, columns: i.headerColumns(dbInfo, header)}, nil
+	default:
+		return newParquetRecordWriter(w, dbInfo.TableName, header, i.headerColumns(dbInfo, header))
+	}
+}
+
+// headerColumns returns the column of each index of header that names one of the table.
+func (i *Importer) headerColumns(dbInfo database.DBInfo, header []string) map[int]database.ColumnInfo {
+	columnMap, _ := i.mapCSVHeader(dbInfo, header)
+	columns := make(map[int]database.ColumnInfo)
+	for _, colInfo := range dbInfo.Columns {
+		if idx, ok := columnMap[colInfo.ColumnName]; ok {
+			columns[idx] = colInfo
+		}
+	}
+	return columns
+}
+
+// csvRecordReader reads the records of a CSV file, named by its header or, when the file has
+// none, by the columns of the table.
+type csvRecordReader struct {
+	reader    *csv.Reader
+	filePath  string
+	keys      []string
+	hasHeader bool
+	line      int
+}
+
+func (i *Importer) newCSVRecordReader(r io.Reader, filePath string, dbInfo database.DBInfo, hasHeader bool) (*csvRecordReader, error) {
+	c := &csvRecordReader{reader: csv.NewReader(r), filePath: filePath, hasHeader: hasHeader}
+	if !hasHeader {
+		columnMap := i.positionalColumnMap(dbInfo)
+		c.keys = make([]string, len(columnMap))
+		for _, colInfo := range dbInfo.Columns {
+			if idx, ok := columnMap[colInfo.ColumnName]; ok {
+				c.keys[idx] = colInfo.ColumnName
+			}
+		}
+		return c, nil
+	}
+	header, err := c.reader.Read()
+	if err == io.EOF {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header from %s: %w", filePath, err)
+	}
+	c.keys = header
+	c.line = 1
+	return c, nil
+}
+
+func (c *csvRecordReader) header() []string { return c.keys }
+
+func (c *csvRecordReader) pos() string { return fmt.Sprintf("%s:%d", c.filePath, c.line) }
+
+func (c *csvRecordReader) read() ([]string, error) {
+	for {
+		record, err := c.reader.Read()
+		if err == io.EOF {
+			return nil, err
+		}
+		c.line++
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", c.filePath, err)
+		}
+		if c.hasHeader && c.line == 2 && isHintRow(record) {
+			continue
+		}
+		if len(record) > len(c.keys) {
+			return nil, fmt.Errorf("%s: %d values for %d columns", c.pos(), len(record), len(c.keys))
+		}
+		return record, nil
+	}
+}
+
+// csvRecordWriter writes records as CSV, after the header row.
+type csvRecordWriter struct {
+	writer *csv.Writer
+}
+
+func (c *csvRecordWriter) write(record []string, _ string) error { return c.writer.Write(record) }
+
+func (c *csvRecordWriter) close() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+// jsonlRecordReader reads the JSON objects of a JSONL file as records. The header holds the keys
+// of the first object in their order; later objects may leave out keys but not add any.
+type jsonlRecordReader struct {
+	dec      *json.Decoder
+	filePath string
+	keys     []string
+	objects  int
+}
+
+func newJSONLRecordReader(r io.Reader, filePath string) *jsonlRecordReader {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	return &jsonlRecordReader{dec: dec, filePath: filePath}
+}
+
+func (j *jsonlRecordReader) header() []string { return j.keys }
+
+func (j *jsonlRecordReader) pos() string { return fmt.Sprintf("%s: object %d", j.filePath, j.objects) }
+
+func (j *jsonlRecordReader) read() ([]string, error) {
+	keys, values, err := decodeObject(j.dec)
+	if err == io.EOF {
+		return nil, err
+	}
+	j.objects++
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", j.pos(), err)
+	}
+	if j.keys == nil {
+		j.keys = keys
+	}
+	record := make([]string, len(j.keys))
+	for idx, key := range keys {
+		pos := slices.Index(j.keys, key)
+		if pos == -1 {
+			return nil, fmt.Errorf("%s: key %q is not in the first object", j.pos(), key)
+		}
+		record[pos] = values[idx]
+	}
+	return record, nil
+}
+
+// jsonlRecordWriter writes each record as a JSON object keyed by the header, with the values
+// typed by their columns.
+type jsonlRecordWriter struct {
+	w       io.Writer
+	keys    []string
+	columns map[int]database.ColumnInfo // Column of each index of the header
+}
+
+func (j *jsonlRecordWriter) write(record []string, pos string) error {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for idx, value := range record {
+		if idx > 0 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(j.keys[idx])
+		b.Write(key)
+		b.WriteByte(':')
+		typed, err := json.Marshal(jsonValue(value, j.columns[idx], pos))
+		if err != nil {
+			return fmt.Errorf("%s: %w", pos, err)
+		}
+		b.Write(typed)
+	}
+	b.WriteString("}\n")
+	_, err := j.w.Write(b.Bytes())
+	return err
+}
+
+func (j *jsonlRecordWriter) close() error { return nil }
+
+// jsonValue converts a CSV value to the JSON value of its column's type. Columns of unknown type,
+// and values that do not fit their column, stay strings.
+func jsonValue(value string, colInfo database.ColumnInfo, pos string) interface{} {
+	if value == "" {
+		return nil
+	}
+	switch colInfo.DataType {
+	case database.StringType, database.UnknownType:
+		return value
+	}
+	converted, err := database.ConvertColumnValue(value, colInfo)
+	if err != nil {
+		log.Printf("Warning: %s: Keeping value '%s' of column %s (%s) as a string: %v\n", pos, value, colInfo.ColumnName, colInfo.DataType, err)
+		return value
+	}
+	if t, ok := converted.(time.Time); ok {
+		return database.FormatColumnValue(t, colInfo)
+	}
+	return converted
+}
+
+// decodeObject reads the next JSON object from dec and returns its keys in order with their
+// values in CSV string form. null is empty.
+func decodeObject(dec *json.Decoder) ([]string, []string, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, nil, err
+	}
+	if tok != json.Delim('{') {
+		return nil, nil, fmt.Errorf("expected a JSON object, got %v", tok)
+	}
+	var keys, values []string
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key := tok.(string)
+		tok, err = dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		var value string
+		switch v := tok.(type) {
+		case nil:
+		case string:
+			value = v
+		case bool:
+			value = fmt.Sprint(v)
+		case json.Number:
+			value = v.String()
+		default:
+			return nil, nil, fmt.Errorf("value of %q is not a string, number, boolean or null", key)
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, nil, err
+	}
+	return keys, values, nil
+}
@@ -0,0 +1,97 @@
+package importer
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ConvertFiles(t *testing.T) {
+	i := &Importer{DBSchema: map[string]database.DBInfo{
+		"users": {
+			TableName: "users",
+			Columns: []database.ColumnInfo{
+				{ColumnName: "id", DataType: database.IntegerType},
+				{ColumnName: "name", DataType: database.StringType},
+				{ColumnName: "active", DataType: database.BooleanType, IsNullable: true},
+				{ColumnName: "born", DataType: database.DateType, IsNullable: true},
+			},
+		},
+	}}
+	write := func(t *testing.T, path, content string) {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	read := func(t *testing.T, path string) string {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	t.Run("CSVをスキーマの型に従ってJSONLに変換すること", func(t *testing.T) {
+		in, out := t.TempDir(), t.TempDir()
+		write(t, filepath.Join(in, "users.csv"), "id,name,active,born\n1,Alice,true,1990-01-02\n2,007,,\n")
+		write(t, filepath.Join(in, "tags.csv"), "id\n1\n")
+		require.NoError(t, i.ConvertFiles(context.Background(), in, out, FormatJSONL, true))
+		assert.Equal(t, `{"id":1,"name":"Alice","active":true,"born":"1990-01-02"}`+"\n"+`{"id":2,"name":"007","active":null,"born":null}`+"\n", read(t, filepath.Join(out, "users.jsonl")))
+		assert.Equal(t, `{"id":"1"}`+"\n", read(t, filepath.Join(out, "tags.jsonl")))
+	})
+
+	t.Run("JSONLをCSVに変換すること", func(t *testing.T) {
+		in, out := t.TempDir(), t.TempDir()
+		write(t, filepath.Join(in, "users.jsonl"), `{"id":1,"name":"Alice","active":true}`+"\n"+`{"name":"Bob, Jr.","id":2,"active":null}`+"\n")
+		require.NoError(t, i.ConvertFiles(context.Background(), in, out, FormatCSV, true))
+		assert.Equal(t, "id,name,active\n1,Alice,true\n2,\"Bob, Jr.\",\n", read(t, filepath.Join(out, "users.csv")))
+	})
+
+	t.Run("最初のオブジェクトにないキーはエラーになること", func(t *testing.T) {
+		in := t.TempDir()
+		write(t, filepath.Join(in, "users.jsonl"), `{"id":1}`+"\n"+`{"id":2,"name":"Bob"}`+"\n")
+		assert.ErrorContains(t, i.ConvertFiles(context.Background(), in, t.TempDir(), FormatCSV, true), `key "name" is not in the first object`)
+	})
+
+	t.Run("CSVをParquetに変換し、CSVとJSONLに戻せること", func(t *testing.T) {
+		in, parquetDir := t.TempDir(), t.TempDir()
+		write(t, filepath.Join(in, "users.csv"), "name,id,active,born\nAlice,1,true,1990-01-02\n\"Bob, Jr.\",2,,\n")
+		require.NoError(t, i.ConvertFiles(context.Background(), in, parquetDir, FormatParquet, true))
+
+		csvDir, jsonlDir := t.TempDir(), t.TempDir()
+		require.NoError(t, i.ConvertFiles(context.Background(), parquetDir, csvDir, FormatCSV, true))
+		assert.Equal(t, "name,id,active,born\nAlice,1,true,1990-01-02\n\"Bob, Jr.\",2,,\n", read(t, filepath.Join(csvDir, "users.csv")))
+		require.NoError(t, i.ConvertFiles(context.Background(), parquetDir, jsonlDir, FormatJSONL, true))
+		assert.Equal(t, `{"name":"Alice","id":1,"active":true,"born":"1990-01-02"}`+"\n"+`{"name":"Bob, Jr.","id":2,"active":null,"born":null}`+"\n", read(t, filepath.Join(jsonlDir, "users.jsonl")))
+	})
+
+	t.Run("列の型に合わない値をParquetに書くとエラーになること", func(t *testing.T) {
+		in := t.TempDir()
+		write(t, filepath.Join(in, "users.jsonl"), `{"id":"x"}`+"\n")
+		assert.ErrorContains(t, i.ConvertFiles(context.Background(), in, t.TempDir(), FormatParquet, true), "users.jsonl: object 1: value 'x' of column id does not fit its type")
+	})
+
+	t.Run("形式の名前を解釈すること", func(t *testing.T) {
+		format, err := ParseFileFormat("Parquet")
+		require.NoError(t, err)
+		assert.Equal(t, FormatParquet, format)
+		_, err = ParseFileFormat("xlsx")
+		assert.Error(t, err)
+	})
+}
+
+func Test_formatDecimal(t *testing.T) {
+	for _, tc := range []struct {
+		unscaled int64
+		scale    int
+		want     string
+	}{
+		{12345, 2, "123.45"},
+		{-5, 3, "-0.005"},
+		{42, 0, "42"},
+	} {
+		assert.Equal(t, tc.want, formatDecimal(big.NewInt(tc.unscaled), tc.scale))
+	}
+}
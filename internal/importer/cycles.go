@@ -0,0 +1,111 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+)
+
+// deferredForeignKey is a foreign key value left NULL when its row was inserted, to be filled in
+// by applyDeferredForeignKeys.
+type deferredForeignKey struct {
+	dbInfo   database.DBInfo
+	fk       database.ForeignKeyInfo
+	key      []string // Primary key values of the row
+	values   []string // Foreign key values from the CSV record
+	filePath string
+	line     int
+	record   []string
+}
+
+// setDeferredForeignKeys records the foreign keys whose values are filled in after the import.
+func (i *Importer) setDeferredForeignKeys(fks []database.ForeignKeyInfo) {
+	i.deferredFKs = make(map[string]map[string]bool)
+	i.clearPendingForeignKeys()
+	for _, fk := range fks {
+		log.Printf("Deferring foreign key %s of table %s to break a dependency cycle; its values are set after all tables are imported.\n", fk.ConstraintName, fk.TableName)
+		if i.deferredFKs[fk.TableName] == nil {
+			i.deferredFKs[fk.TableName] = make(map[string]bool)
+		}
+		i.deferredFKs[fk.TableName][fk.ConstraintName] = true
+	}
+}
+
+// deferForeignKey returns the pending update for fk if fk is deferred and the row can be found
+// again by its primary key. Rows without primary key values, e.g. because the database assigns
+// them, keep the foreign key and have their parent record checked as usual.
+func (i *Importer) deferForeignKey(dbInfo database.DBInfo, fk database.ForeignKeyInfo, csvValues map[string]string, fkValues []string) (deferredForeignKey, bool) {
+	if !i.deferredFKs[dbInfo.TableName][fk.ConstraintName] {
+		return deferredForeignKey{}, false
+	}
+	key := make([]string, len(dbInfo.PrimaryKeyColumns))
+	for idx, colName := range dbInfo.PrimaryKeyColumns {
+		if key[idx] = csvValues[colName]; key[idx] == "" {
+			return deferredForeignKey{}, false
+		}
+	}
+	return deferredForeignKey{dbInfo: dbInfo, fk: fk, key: key, values: fkValues}, true
+}
+
+// applyDeferredForeignKeys sets the deferred foreign key values of the inserted rows, now that
+// the parent records have been imported. Parents still missing are created, or the row is
+// reported as rejected if NoAutoParents is set.
+func (i *Importer) applyDeferredForeignKeys(ctx context.Context) error {
+	defer i.clearPendingForeignKeys()
+	n := i.spilledFKs + len(i.pendingFKs)
+	if n == 0 {
+		return nil
+	}
+	updater, ok := i.DBClient.(database.RowUpdater)
+	if !ok {
+		return errors.New("updating rows is not supported by the database")
+	}
+	log.Printf("Setting %d deferred foreign key value(s)...\n", n)
+	return i.eachPendingForeignKey(func(p deferredForeignKey) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		parentDBInfo := i.DBSchema[p.fk.ForeignTableName]
+		values := p.values
+		operation := "parent record check or creation on"
+		if i.NoAutoParents {
+			operation = "parent record check on"
+		}
+		stmtCtx, cancel := i.statementContext(ctx, p.fk.ForeignTableName, parentStatement(operation, p.fk.ForeignTableName, p.fk.ForeignColumnNames, values))
+		if i.NoAutoParents {
+			exists, err := i.DBClient.ParentRecordExists(stmtCtx, parentDBInfo, p.fk.ForeignColumnNames, values)
+			cancel()
+			if err != nil {
+				return fmt.Errorf("failed to check parent record for %s.%v (value: %v): %w", p.fk.ForeignTableName, p.fk.ForeignColumnNames, values, err)
+			}
+			if !exists {
+				i.rejectRow(ctx, &database.RowInsertError{TableName: p.dbInfo.TableName, FilePath: p.filePath, Line: p.line, Record: p.record, Err: &database.MissingParentRecordError{TableName: p.fk.ForeignTableName, ColumnNames: p.fk.ForeignColumnNames, Values: values}})
+				return nil
+			}
+		} else {
+			var err error
+			values, err = i.DBClient.EnsureParentRecordExists(stmtCtx, parentDBInfo, p.fk.ForeignColumnNames, values, i.DBSchema)
+			cancel()
+			if err != nil {
+				return fmt.Errorf("failed to ensure parent record exists for %s.%v (value: %v): %w", p.fk.ForeignTableName, p.fk.ForeignColumnNames, p.values, err)
+			}
+		}
+
+		stmtCtx, cancel = i.statementContext(ctx, p.dbInfo.TableName, func() string {
+			return fmt.Sprintf("UPDATE %s SET (%s) WHERE (%s) = (%s)", p.dbInfo.TableName, strings.Join(p.fk.ColumnNames, ", "), strings.Join(p.dbInfo.PrimaryKeyColumns, ", "), strings.Join(p.key, ", "))
+		})
+		err := updater.UpdateRow(stmtCtx, p.dbInfo, p.dbInfo.PrimaryKeyColumns, p.key, p.fk.ColumnNames, values)
+		cancel()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			i.rejectRow(ctx, &database.RowInsertError{TableName: p.dbInfo.TableName, FilePath: p.filePath, Line: p.line, Record: p.record, Err: err})
+		}
+		return nil
+	})
+}
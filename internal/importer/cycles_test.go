@@ -0,0 +1,33 @@
+package importer
+
+import (
+	"testing"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_deferForeignKey(t *testing.T) {
+	dbInfo := database.DBInfo{TableName: "employees", PrimaryKeyColumns: []string{"id"}}
+	fk := database.ForeignKeyInfo{ConstraintName: "fk_mentor", TableName: "employees", ColumnNames: []string{"mentor_id"}, ForeignTableName: "employees"}
+	i := &Importer{}
+	i.setDeferredForeignKeys([]database.ForeignKeyInfo{fk})
+
+	t.Run("遅延する外部キーは主キーの値とともに記録されること", func(t *testing.T) {
+		d, ok := i.deferForeignKey(dbInfo, fk, map[string]string{"id": "2", "mentor_id": "1"}, []string{"1"})
+		assert.True(t, ok)
+		assert.Equal(t, []string{"2"}, d.key)
+		assert.Equal(t, []string{"1"}, d.values)
+	})
+
+	t.Run("主キーの値がない行は遅延しないこと", func(t *testing.T) {
+		_, ok := i.deferForeignKey(dbInfo, fk, map[string]string{"id": "", "mentor_id": "1"}, []string{"1"})
+		assert.False(t, ok)
+	})
+
+	t.Run("遅延しない外部キーは記録されないこと", func(t *testing.T) {
+		other := database.ForeignKeyInfo{ConstraintName: "fk_department", TableName: "employees", ColumnNames: []string{"department_id"}, ForeignTableName: "departments"}
+		_, ok := i.deferForeignKey(dbInfo, other, map[string]string{"id": "2", "department_id": "5"}, []string{"5"})
+		assert.False(t, ok)
+	})
+}
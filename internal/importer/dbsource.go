@@ -0,0 +1,115 @@
+package importer
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+)
+
+// DBSource reads the tables of another database as CSV inputs with a header row of the column
+// names, so that an import moves their rows between databases, even of different engines, through
+// the same conversion as CSV files. The rows are streamed from the database as they are imported.
+type DBSource struct {
+	DBClient database.DBClient
+	// DBSchema is the schema of the source database; each of its tables is an input.
+	DBSchema map[string]database.DBInfo
+	// Files names the input of a table, e.g. to match the file an import expects for it; other
+	// tables are named after themselves with a .csv extension.
+	Files map[string]string
+}
+
+// List returns the input names of the tables.
+func (d *DBSource) List(ctx context.Context) ([]string, error) {
+	var names []string
+	for tableName := range d.DBSchema {
+		names = append(names, d.fileName(tableName))
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+// Open starts reading the rows of the table named name. The rows are read while the returned
+// reader is read; closing it early stops the query.
+func (d *DBSource) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	var dbInfo database.DBInfo
+	found := false
+	for tableName, info := range d.DBSchema {
+		if d.fileName(tableName) == name {
+			dbInfo, found = info, true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("table of %s not found in the source database", name)
+	}
+	reader, ok := d.DBClient.(database.RowReader)
+	if !ok {
+		return nil, errors.New("reading rows is not supported by the source database")
+	}
+
+	pr, pw := io.Pipe()
+	r := &tableReader{PipeReader: pr, done: make(chan struct{})}
+	go func() {
+		defer close(r.done)
+		header := make([]string, len(dbInfo.Columns))
+		for idx, colInfo := range dbInfo.Columns {
+			header[idx] = colInfo.ColumnName
+		}
+		w := csv.NewWriter(pw)
+		err := w.Write(header)
+		if err == nil {
+			err = reader.ReadRows(ctx, dbInfo, dbInfo.Columns, database.RowFilter{}, w.Write)
+		}
+		if err == nil {
+			w.Flush()
+			err = w.Error()
+		}
+		if err != nil {
+			err = fmt.Errorf("failed to read table %s from the source database: %w", dbInfo.TableName, err)
+		}
+		pw.CloseWithError(err)
+	}()
+	return r, nil
+}
+
+func (d *DBSource) fileName(tableName string) string {
+	if name, ok := d.Files[tableName]; ok {
+		return name
+	}
+	return tableName + ".csv"
+}
+
+// tableReader is the reading end of a table streamed by DBSource.Open.
+type tableReader struct {
+	*io.PipeReader
+	done chan struct{}
+}
+
+// Close stops the query if the rows have not all been read, and waits for it to end.
+func (r *tableReader) Close() error {
+	err := r.PipeReader.Close()
+	<-r.done
+	return err
+}
+
+// ImportDatabase imports the rows of the tables of another database like ImportSource imports
+// CSV files. Its tables are matched to those of DBSchema by name, ignoring case, as engines fold
+// unquoted names differently; tables with no match are handled like unmapped CSV files.
+func (i *Importer) ImportDatabase(ctx context.Context, dbClient database.DBClient, dbSchema map[string]database.DBInfo) (*ImportResult, error) {
+	files := make(map[string]string)
+	for sourceTable := range dbSchema {
+		for tableName := range i.DBSchema {
+			if strings.EqualFold(tableName, sourceTable) {
+				files[sourceTable] = i.csvFileName(tableName)
+				break
+			}
+		}
+	}
+	return i.ImportSource(ctx, &DBSource{DBClient: dbClient, DBSchema: dbSchema, Files: files}, true)
+}
@@ -0,0 +1,295 @@
+package importer
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/k-wa-wa/db-auto-importer/internal/graph"
+)
+
+// Kinds of RowChange.
+const (
+	RowInserted = "+" // The row is in the CSV file only, and would be inserted
+	RowUpdated  = "~" // The row is in both, with different values, and would be updated
+	RowRemoved  = "-" // The row is in the table only; an import leaves it as it is
+)
+
+// RowChange is a row that differs between a CSV file and its table.
+type RowChange struct {
+	Kind string
+	// Key identifies the row by its primary key, e.g. "id=1"; empty for rows inserted without one.
+	Key string
+	// Line is the line of the row in the CSV file, counting the header row; 0 for removed rows.
+	Line int
+	// Columns lists the changed columns of an updated row.
+	Columns []ColumnChange
+}
+
+// ColumnChange is a column value an import would change.
+type ColumnChange struct {
+	Column   string
+	Old, New string
+}
+
+func (r RowChange) String() string {
+	key := r.Key
+	if key == "" {
+		key = fmt.Sprintf("line %d", r.Line)
+	}
+	if r.Kind != RowUpdated {
+		return r.Kind + " " + key
+	}
+	columns := make([]string, len(r.Columns))
+	for idx, c := range r.Columns {
+		columns[idx] = fmt.Sprintf("%s: %s -> %s", c.Column, strconv.Quote(c.Old), strconv.Quote(c.New))
+	}
+	return fmt.Sprintf("%s %s %s", r.Kind, key, strings.Join(columns, ", "))
+}
+
+// TableDiff compares the rows of a CSV file with those of its table.
+type TableDiff struct {
+	Table    string
+	FilePath string
+	Changes  []RowChange
+	// Unchanged is the number of rows of the CSV file that match their row in the table.
+	Unchanged int
+}
+
+// Count returns the number of changes of a kind.
+func (d TableDiff) Count(kind string) int {
+	n := 0
+	for _, change := range d.Changes {
+		if change.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+func (d TableDiff) String() string {
+	return fmt.Sprintf("%s: %d to insert, %d to update, %d unchanged, %d only in the table", d.Table, d.Count(RowInserted), d.Count(RowUpdated), d.Unchanged, d.Count(RowRemoved))
+}
+
+// DiffCSVFiles compares the CSV files in csvDir with the rows of their tables without writing to
+// the database, matching the rows by primary key. Only the columns of a CSV file are compared, as
+// they would be written; values are compared after conversion, so that e.g. "1.50" matches 1.5.
+// Tables without a primary key cannot be compared and are skipped with a warning. Each table's
+// rows are held in memory while its file is compared.
+func (i *Importer) DiffCSVFiles(ctx context.Context, csvDir string, hasHeader bool) ([]TableDiff, error) {
+	files, err := getCSVFiles(csvDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get CSV files from %s: %w", csvDir, err)
+	}
+	csvFilesMap := i.mapCSVFiles(files)
+	for _, filePath := range i.findUnmappedFiles(csvFilesMap) {
+		if i.UnmappedFilePolicy == UnmappedFileIgnore {
+			break
+		}
+		log.Printf("Warning: CSV file %s has no corresponding table in the database schema and is not compared.\n", filePath)
+	}
+	order, _, err := graph.ImportOrder(i.DBSchema, i.OrderRules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine import order: %w", err)
+	}
+	csvFilesMap, _, err = i.selectFiles(csvFilesMap)
+	if err != nil {
+		return nil, err
+	}
+
+	var diffs []TableDiff
+	for _, tableName := range order {
+		filePath, ok := csvFilesMap[tableName]
+		if !ok {
+			continue
+		}
+		dbInfo := i.DBSchema[tableName]
+		if len(dbInfo.PrimaryKeyColumns) == 0 {
+			log.Printf("Warning: Table %s has no primary key; %s is not compared.\n", tableName, filePath)
+			continue
+		}
+		diff, err := i.diffCSVFile(ctx, filePath, dbInfo, hasHeader)
+		if err != nil {
+			return nil, err
+		}
+		diffs = append(diffs, diff)
+	}
+	return diffs, nil
+}
+
+// diffCSVFile compares a CSV file with the rows of its table.
+func (i *Importer) diffCSVFile(ctx context.Context, filePath string, dbInfo database.DBInfo, hasHeader bool) (TableDiff, error) {
+	diff := TableDiff{Table: dbInfo.TableName, FilePath: filePath}
+	file, err := openInputFile(filePath, hasHeader)
+	if err != nil {
+		return diff, fmt.Errorf("failed to open CSV file %s: %w", filePath, err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	var columnMap map[string]int
+	line := 0
+	if hasHeader {
+		csvHeader, err := reader.Read()
+		if err != nil {
+			return diff, fmt.Errorf("failed to read CSV header from %s: %w", filePath, err)
+		}
+		line++
+		columnMap, _ = i.mapCSVHeader(dbInfo, csvHeader)
+	} else {
+		columnMap = i.positionalColumnMap(dbInfo)
+	}
+
+	// The key columns are read first, followed by the compared columns
+	var columns []database.ColumnInfo
+	for _, colName := range dbInfo.PrimaryKeyColumns {
+		for _, colInfo := range dbInfo.Columns {
+			if colInfo.ColumnName == colName {
+				columns = append(columns, colInfo)
+			}
+		}
+	}
+	keyCount := len(columns)
+	if keyCount != len(dbInfo.PrimaryKeyColumns) {
+		return diff, fmt.Errorf("primary key columns of table %s not found in its columns", dbInfo.TableName)
+	}
+	csvColumns, _ := i.csvColumns(dbInfo)
+	for _, colInfo := range csvColumns {
+		if _, ok := columnMap[colInfo.ColumnName]; ok && !isKeyColumn(dbInfo, colInfo.ColumnName) {
+			columns = append(columns, colInfo)
+		}
+	}
+
+	dbReader, ok := i.DBClient.(database.RowReader)
+	if !ok {
+		return diff, errors.New("reading rows is not supported by the database")
+	}
+	rows := make(map[string][]string)
+	err = dbReader.ReadRows(ctx, dbInfo, columns, database.RowFilter{}, func(values []string) error {
+		for idx, colInfo := range columns {
+			values[idx] = normalizeDBValue(values[idx], colInfo)
+		}
+		rows[rowKey(dbInfo.PrimaryKeyColumns, values[:keyCount])] = values
+		return nil
+	})
+	if err != nil {
+		return diff, fmt.Errorf("failed to read table %s: %w", dbInfo.TableName, err)
+	}
+
+	seen := make(map[string]bool)
+	for {
+		if err := ctx.Err(); err != nil {
+			return diff, err
+		}
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return diff, fmt.Errorf("failed to read CSV record from %s: %w", filePath, err)
+		}
+		if hasHeader && line == 2 && isHintRow(record) {
+			continue
+		}
+
+		values := make([]string, len(columns))
+		complete := true
+		for idx, colInfo := range columns {
+			csvVal := ""
+			if colIdx, ok := columnMap[colInfo.ColumnName]; ok && colIdx < len(record) {
+				csvVal = record[colIdx]
+			}
+			if idx < keyCount && csvVal == "" {
+				complete = false
+			}
+			values[idx] = normalizeCSVValue(csvVal, colInfo)
+		}
+		if !complete {
+			// The database assigns the key, so the row is always new
+			diff.Changes = append(diff.Changes, RowChange{Kind: RowInserted, Line: line})
+			continue
+		}
+		key := rowKey(dbInfo.PrimaryKeyColumns, values[:keyCount])
+		seen[key] = true
+		old, ok := rows[key]
+		if !ok {
+			diff.Changes = append(diff.Changes, RowChange{Kind: RowInserted, Key: key, Line: line})
+			continue
+		}
+		var changed []ColumnChange
+		for idx := keyCount; idx < len(columns); idx++ {
+			if old[idx] != values[idx] {
+				changed = append(changed, ColumnChange{Column: columns[idx].ColumnName, Old: old[idx], New: values[idx]})
+			}
+		}
+		if len(changed) == 0 {
+			diff.Unchanged++
+			continue
+		}
+		diff.Changes = append(diff.Changes, RowChange{Kind: RowUpdated, Key: key, Line: line, Columns: changed})
+	}
+
+	var removed []string
+	for key := range rows {
+		if !seen[key] {
+			removed = append(removed, key)
+		}
+	}
+	slices.Sort(removed)
+	for _, key := range removed {
+		diff.Changes = append(diff.Changes, RowChange{Kind: RowRemoved, Key: key})
+	}
+	return diff, nil
+}
+
+func isKeyColumn(dbInfo database.DBInfo, colName string) bool {
+	return slices.Contains(dbInfo.PrimaryKeyColumns, colName)
+}
+
+// rowKey renders a primary key as "id=1", or "(a, b)=(1, 2)" for composite keys.
+func rowKey(columnNames, values []string) string {
+	if len(columnNames) == 1 {
+		return columnNames[0] + "=" + values[0]
+	}
+	return fmt.Sprintf("(%s)=(%s)", strings.Join(columnNames, ", "), strings.Join(values, ", "))
+}
+
+// normalizeCSVValue converts a CSV value as an import would and renders it like a value read from
+// the database. Values that do not convert are compared as they are.
+func normalizeCSVValue(value string, colInfo database.ColumnInfo) string {
+	converted, err := database.ConvertColumnValue(value, colInfo)
+	if err != nil {
+		return value
+	}
+	return formatNormalized(converted, colInfo)
+}
+
+// normalizeDBValue renders a value read from the database like normalizeCSVValue.
+func normalizeDBValue(value string, colInfo database.ColumnInfo) string {
+	if value == "" {
+		return ""
+	}
+	converted, err := database.ConvertToDBType(value, colInfo.DataType, true, sql.NullString{})
+	if err != nil {
+		return value
+	}
+	return formatNormalized(converted, colInfo)
+}
+
+func formatNormalized(value interface{}, colInfo database.ColumnInfo) string {
+	if t, ok := value.(time.Time); ok {
+		// The database may return the same instant in its own time zone
+		value = t.UTC()
+	}
+	return database.FormatColumnValue(value, colInfo)
+}
@@ -0,0 +1,57 @@
+package importer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DiffCSVFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "users.csv"), []byte("id,name,score,joined\n1,alice,1.50,2024-01-01T09:00:00+09:00\n2,bobby,2,\n3,carol,3,\n,dave,4,\n"), 0o644))
+	i := &Importer{
+		DBSchema: map[string]database.DBInfo{
+			"users": {
+				TableName: "users",
+				Columns: []database.ColumnInfo{
+					{ColumnName: "id", DataType: database.IntegerType},
+					{ColumnName: "name", DataType: database.StringType},
+					{ColumnName: "score", DataType: database.FloatType, IsNullable: true},
+					{ColumnName: "joined", DataType: database.TimestampType, IsNullable: true},
+					{ColumnName: "note", DataType: database.StringType, IsNullable: true},
+				},
+				PrimaryKeyColumns: []string{"id"},
+			},
+		},
+		DBClient: &rowsClient{rows: map[string][][]string{"users": {
+			{"1", "alice", "1.5", "2024-01-01T00:00:00Z"},
+			{"2", "bob", "2", ""},
+			{"4", "erin", "", ""},
+		}}},
+	}
+
+	diffs, err := i.DiffCSVFiles(context.Background(), dir, true)
+	require.NoError(t, err)
+	require.Len(t, diffs, 1)
+	diff := diffs[0]
+
+	t.Run("変換後の値が同じ行は変更なしとなること", func(t *testing.T) {
+		assert.Equal(t, 1, diff.Unchanged)
+	})
+	t.Run("行の追加・更新・テーブルのみの行が報告されること", func(t *testing.T) {
+		assert.Equal(t, []RowChange{
+			{Kind: RowUpdated, Key: "id=2", Line: 3, Columns: []ColumnChange{{Column: "name", Old: "bob", New: "bobby"}}},
+			{Kind: RowInserted, Key: "id=3", Line: 4},
+			{Kind: RowInserted, Line: 5},
+			{Kind: RowRemoved, Key: "id=4"},
+		}, diff.Changes)
+		assert.Equal(t, "users: 2 to insert, 1 to update, 1 unchanged, 1 only in the table", diff.String())
+		assert.Equal(t, `~ id=2 name: "bob" -> "bobby"`, diff.Changes[0].String())
+		assert.Equal(t, "+ line 5", diff.Changes[2].String())
+	})
+}
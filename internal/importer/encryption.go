@@ -0,0 +1,161 @@
+package importer
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+)
+
+// EncryptionMethod names how a column's values are encrypted during import.
+type EncryptionMethod string
+
+const (
+	EncryptAESGCM EncryptionMethod = "aes-gcm" // AES-GCM in the importer, base64 of the nonce and the ciphertext
+	EncryptPGP    EncryptionMethod = "pgp"     // pgcrypto's pgp_sym_encrypt on the PostgreSQL server
+)
+
+// EncryptionRule declares how a single column is encrypted.
+type EncryptionRule struct {
+	Method EncryptionMethod
+	// Key is the key material: for aes-gcm, a base64-encoded key of 16, 24 or 32 bytes (AES-128,
+	// AES-192 or AES-256); for pgp, the passphrase.
+	Key string
+}
+
+// Validate reports whether the rule is well-formed.
+func (r EncryptionRule) Validate() error {
+	switch r.Method {
+	case EncryptAESGCM:
+		_, err := r.aead()
+		return err
+	case EncryptPGP:
+		if r.Key == "" {
+			return errors.New("empty pgp passphrase")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown encryption method '%s' (expected aes-gcm or pgp)", r.Method)
+	}
+}
+
+// aead returns the AES-GCM cipher of an aes-gcm rule.
+func (r EncryptionRule) aead() (cipher.AEAD, error) {
+	key, err := base64.StdEncoding.DecodeString(r.Key)
+	if err != nil {
+		return nil, fmt.Errorf("aes-gcm key is not base64: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("aes-gcm key must be 16, 24 or 32 bytes, got %d", len(key))
+	}
+	return cipher.NewGCM(block)
+}
+
+// Encryptor encrypts CSV values according to per-column rules keyed by "table.column" or "column".
+// Each value is encrypted with a fresh nonce or salt, so equal values do not stay equal.
+type Encryptor struct {
+	rules map[string]EncryptionRule
+	aeads map[string]cipher.AEAD
+}
+
+// NewEncryptor creates an Encryptor, validating the rules.
+func NewEncryptor(rules map[string]EncryptionRule) (*Encryptor, error) {
+	e := &Encryptor{rules: make(map[string]EncryptionRule, len(rules)), aeads: make(map[string]cipher.AEAD)}
+	for key, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return nil, fmt.Errorf("encryption rule for '%s': %w", key, err)
+		}
+		key = strings.ToLower(key)
+		e.rules[key] = rule
+		if rule.Method == EncryptAESGCM {
+			e.aeads[key], _ = rule.aead()
+		}
+	}
+	return e, nil
+}
+
+// UsesPGP reports whether any column is encrypted with pgp, which needs PostgreSQL with pgcrypto.
+func (e *Encryptor) UsesPGP() bool {
+	for _, rule := range e.rules {
+		if rule.Method == EncryptPGP {
+			return true
+		}
+	}
+	return false
+}
+
+// Encrypt returns the encrypted form of value for the column. Empty values are returned unchanged,
+// so that they are still inserted as NULL. pgp values are encrypted by the database of dbClient;
+// they are ASCII-armored for string columns and bytea (in hex form) otherwise.
+func (e *Encryptor) Encrypt(ctx context.Context, dbClient database.DBClient, tableName string, col database.ColumnInfo, value string) (string, error) {
+	if value == "" {
+		return value, nil
+	}
+	key, ok := e.ruleKey(tableName, col.ColumnName)
+	if !ok {
+		return value, nil
+	}
+
+	rule := e.rules[key]
+	switch rule.Method {
+	case EncryptAESGCM:
+		aead := e.aeads[key]
+		nonce := make([]byte, aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return "", fmt.Errorf("failed to encrypt %s.%s: %w", tableName, col.ColumnName, err)
+		}
+		return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte(value), nil)), nil
+	case EncryptPGP:
+		db := dbClient.GetDB()
+		if db == nil {
+			return "", fmt.Errorf("failed to encrypt %s.%s: pgp encryption needs a PostgreSQL connection", tableName, col.ColumnName)
+		}
+		query := "SELECT '\\x' || encode(pgp_sym_encrypt($1, $2), 'hex')"
+		if col.DataType == database.StringType {
+			query = "SELECT armor(pgp_sym_encrypt($1, $2))"
+		}
+		var encrypted string
+		if err := db.QueryRowContext(ctx, query, value, rule.Key).Scan(&encrypted); err != nil {
+			return "", fmt.Errorf("failed to encrypt %s.%s with pgp_sym_encrypt (is the pgcrypto extension installed?): %w", tableName, col.ColumnName, err)
+		}
+		return encrypted, nil
+	default:
+		return "", fmt.Errorf("unknown encryption method '%s' for %s.%s", rule.Method, tableName, col.ColumnName)
+	}
+}
+
+// ruleKey returns the key of the rule of a column, preferring one keyed by "table.column".
+func (e *Encryptor) ruleKey(tableName, colName string) (string, bool) {
+	key := strings.ToLower(tableName + "." + colName)
+	if _, ok := e.rules[key]; ok {
+		return key, true
+	}
+	key = strings.ToLower(colName)
+	_, ok := e.rules[key]
+	return key, ok
+}
+
+// KeyWarnings describes the key columns of dbSchema (primary, unique and foreign keys and the
+// columns foreign keys reference) that are encrypted. Encrypted values differ on every import, so
+// references to them no longer match and re-imports insert new rows instead of updating.
+func (e *Encryptor) KeyWarnings(dbSchema map[string]database.DBInfo) []string {
+	keyColumns := schemaKeyColumns(dbSchema)
+	var warnings []string
+	for _, tableName := range slices.Sorted(maps.Keys(keyColumns)) {
+		for _, colName := range slices.Sorted(maps.Keys(keyColumns[tableName])) {
+			if _, ok := e.ruleKey(tableName, colName); ok {
+				warnings = append(warnings, fmt.Sprintf("Key column %s.%s is encrypted; its encrypted values differ on every import, so references to it will not match.", tableName, colName))
+			}
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,110 @@
+package importer
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"database/sql"
+	"encoding/base64"
+	"testing"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// noDBClient is a DBClient without a database/sql connection, like the SQL file writer.
+type noDBClient struct {
+	database.DBClient
+}
+
+func (noDBClient) GetDB() *sql.DB { return nil }
+
+func Test_Encryptor(t *testing.T) {
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	aesKey := base64.StdEncoding.EncodeToString(key)
+	ssn := database.ColumnInfo{ColumnName: "ssn", DataType: database.StringType}
+	ctx := context.Background()
+
+	decrypt := func(t *testing.T, encrypted string) string {
+		t.Helper()
+		data, err := base64.StdEncoding.DecodeString(encrypted)
+		require.NoError(t, err)
+		block, err := aes.NewCipher(key)
+		require.NoError(t, err)
+		aead, err := cipher.NewGCM(block)
+		require.NoError(t, err)
+		plain, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+		require.NoError(t, err)
+		return string(plain)
+	}
+
+	t.Run("aes-gcmで暗号化した値が復号できること", func(t *testing.T) {
+		e, err := NewEncryptor(map[string]EncryptionRule{"users.ssn": {Method: EncryptAESGCM, Key: aesKey}})
+		require.NoError(t, err)
+		first, err := e.Encrypt(ctx, noDBClient{}, "users", ssn, "123-45-6789")
+		require.NoError(t, err)
+		second, err := e.Encrypt(ctx, noDBClient{}, "users", ssn, "123-45-6789")
+		require.NoError(t, err)
+
+		assert.NotEqual(t, first, second, "each value has its own nonce")
+		assert.Equal(t, "123-45-6789", decrypt(t, first))
+		assert.Equal(t, "123-45-6789", decrypt(t, second))
+	})
+
+	t.Run("空の値と対象外のカラムは変更しないこと", func(t *testing.T) {
+		e, err := NewEncryptor(map[string]EncryptionRule{"USERS.SSN": {Method: EncryptAESGCM, Key: aesKey}})
+		require.NoError(t, err)
+		value, err := e.Encrypt(ctx, noDBClient{}, "users", ssn, "")
+		require.NoError(t, err)
+		assert.Equal(t, "", value)
+		value, err = e.Encrypt(ctx, noDBClient{}, "orders", ssn, "123")
+		require.NoError(t, err)
+		assert.Equal(t, "123", value)
+	})
+
+	t.Run("テーブル名付きのルールが優先されること", func(t *testing.T) {
+		e, err := NewEncryptor(map[string]EncryptionRule{
+			"ssn":       {Method: EncryptPGP, Key: "passphrase"},
+			"users.ssn": {Method: EncryptAESGCM, Key: aesKey},
+		})
+		require.NoError(t, err)
+		assert.True(t, e.UsesPGP())
+		value, err := e.Encrypt(ctx, noDBClient{}, "users", ssn, "123")
+		require.NoError(t, err)
+		assert.Equal(t, "123", decrypt(t, value))
+
+		_, err = e.Encrypt(ctx, noDBClient{}, "customers", ssn, "123")
+		assert.ErrorContains(t, err, "needs a PostgreSQL connection")
+	})
+
+	t.Run("不正なルールはエラーになること", func(t *testing.T) {
+		tests := []struct {
+			name string
+			rule EncryptionRule
+		}{
+			{"不明な方式", EncryptionRule{Method: "rot13", Key: aesKey}},
+			{"base64でない鍵", EncryptionRule{Method: EncryptAESGCM, Key: "not base64!"}},
+			{"長さの不正な鍵", EncryptionRule{Method: EncryptAESGCM, Key: base64.StdEncoding.EncodeToString([]byte("short"))}},
+			{"空のパスフレーズ", EncryptionRule{Method: EncryptPGP}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := NewEncryptor(map[string]EncryptionRule{"users.ssn": tt.rule})
+				assert.Error(t, err)
+			})
+		}
+	})
+
+	t.Run("暗号化するキーカラムが警告されること", func(t *testing.T) {
+		e, err := NewEncryptor(map[string]EncryptionRule{"email": {Method: EncryptAESGCM, Key: aesKey}, "users.ssn": {Method: EncryptAESGCM, Key: aesKey}})
+		require.NoError(t, err)
+		warnings := e.KeyWarnings(map[string]database.DBInfo{
+			"users": {TableName: "users", PrimaryKeyColumns: []string{"id"}, UniqueKeyColumns: [][]string{{"email"}}},
+		})
+		assert.Equal(t, []string{"Key column users.email is encrypted; its encrypted values differ on every import, so references to it will not match."}, warnings)
+	})
+}
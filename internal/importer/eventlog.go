@@ -0,0 +1,107 @@
+package importer
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+)
+
+// EventLog writes the progress of CSV imports as newline-delimited JSON, one object per event,
+// for tools that parse the output of a run. It is an Observer; it is also an io.Writer that turns
+// each line written to it, e.g. by the log package, into a "log" event, so that a single stream
+// carries both. Every object has "time" and "event" fields. The events are:
+//
+//   - file_started: table, file
+//   - row_rejected: table, file, line, error, record
+//   - table_finished: table, file and the counts of TableSummary, with error if the file failed
+//   - import_finished: tables and the total counts, with error if the import failed
+//   - log: level ("info", "warning" or "error") and message
+type EventLog struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewEventLog creates an EventLog writing to w.
+func NewEventLog(w io.Writer) *EventLog {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &EventLog{enc: enc}
+}
+
+var _ Observer = (*EventLog)(nil)
+
+// write encodes one event. Encoding errors are dropped: the log cannot report its own failure.
+func (l *EventLog) write(event string, fields map[string]interface{}) {
+	fields["time"] = time.Now().UTC().Format(time.RFC3339Nano)
+	fields["event"] = event
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.enc.Encode(fields)
+}
+
+func (l *EventLog) FileStarted(table, filePath string) {
+	l.write("file_started", map[string]interface{}{"table": table, "file": filePath})
+}
+
+// RowsProcessed writes nothing; an event per row would drown the others.
+func (l *EventLog) RowsProcessed(table string, done, total int) {}
+
+func (l *EventLog) RowFailed(err *database.RowInsertError) {
+	fields := map[string]interface{}{"table": err.TableName, "file": err.FilePath, "error": err.Err.Error(), "record": err.Record}
+	if err.Line > 0 {
+		fields["line"] = err.Line
+	}
+	l.write("row_rejected", fields)
+}
+
+func (l *EventLog) FileFinished(summary TableSummary, err error) {
+	fields := map[string]interface{}{
+		"table":           summary.Table,
+		"file":            summary.File,
+		"rows_read":       summary.RowsRead,
+		"rows_inserted":   summary.RowsInserted,
+		"rows_updated":    summary.RowsUpdated,
+		"rows_skipped":    summary.RowsSkipped,
+		"rows_rejected":   summary.RowsRejected,
+		"elapsed_seconds": summary.Elapsed.Seconds(),
+	}
+	if err != nil {
+		fields["error"] = err.Error()
+	}
+	l.write("table_finished", fields)
+}
+
+func (l *EventLog) ImportFinished(summaries []TableSummary, err error) {
+	result := ImportResult{Tables: summaries}
+	fields := map[string]interface{}{
+		"tables":          len(summaries),
+		"rows_inserted":   result.RowsInserted(),
+		"rows_updated":    result.RowsUpdated(),
+		"rows_rejected":   result.RowsRejected(),
+		"parents_created": result.ParentsCreated(),
+	}
+	if err != nil {
+		fields["error"] = err.Error()
+	}
+	l.write("import_finished", fields)
+}
+
+// Write writes each line of p as a log event. The level is taken from the "Warning:" or
+// "Error" prefix of the message, if any.
+func (l *EventLog) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		level := "info"
+		switch lower := strings.ToLower(line); {
+		case strings.HasPrefix(lower, "warning"):
+			level = "warning"
+		case strings.HasPrefix(lower, "error"):
+			level = "error"
+		}
+		l.write("log", map[string]interface{}{"level": level, "message": line})
+	}
+	return len(p), nil
+}
@@ -0,0 +1,56 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_EventLog(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewEventLog(&buf)
+	l.FileStarted("users", "data/users.csv")
+	l.RowsProcessed("users", 1, 2)
+	l.RowFailed(&database.RowInsertError{TableName: "users", FilePath: "data/users.csv", Line: 3, Record: []string{"2", "<b>"}, Err: errors.New("duplicate key")})
+	l.FileFinished(TableSummary{Table: "users", File: "data/users.csv", RowsRead: 2, RowsInserted: 1, RowsRejected: 1, Elapsed: time.Second}, nil)
+	l.ImportFinished([]TableSummary{{Table: "users", RowsInserted: 1, RowsRejected: 1}, {Table: "organizations", ParentsCreated: 1}}, errors.New("1 record(s) failed"))
+	l.Write([]byte("Warning: something odd\n"))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 5, "行ごとの進捗はイベントとして出力しないこと")
+	events := make([]map[string]interface{}, len(lines))
+	for idx, line := range lines {
+		require.NoError(t, json.Unmarshal([]byte(line), &events[idx]), line)
+		assert.NotEmpty(t, events[idx]["time"])
+	}
+
+	assert.Equal(t, "file_started", events[0]["event"])
+	assert.Equal(t, "users", events[0]["table"])
+
+	assert.Equal(t, "row_rejected", events[1]["event"])
+	assert.Equal(t, float64(3), events[1]["line"])
+	assert.Equal(t, "duplicate key", events[1]["error"])
+	assert.Equal(t, []interface{}{"2", "<b>"}, events[1]["record"])
+	assert.Contains(t, lines[1], `"<b>"`, "HTMLの文字はエスケープしないこと")
+
+	assert.Equal(t, "table_finished", events[2]["event"])
+	assert.Equal(t, float64(1), events[2]["rows_inserted"])
+	assert.Equal(t, float64(1), events[2]["elapsed_seconds"])
+	assert.NotContains(t, events[2], "error")
+
+	assert.Equal(t, "import_finished", events[3]["event"])
+	assert.Equal(t, float64(2), events[3]["tables"])
+	assert.Equal(t, float64(1), events[3]["parents_created"])
+	assert.Equal(t, "1 record(s) failed", events[3]["error"])
+
+	assert.Equal(t, "log", events[4]["event"])
+	assert.Equal(t, "warning", events[4]["level"])
+	assert.Equal(t, "Warning: something odd", events[4]["message"])
+}
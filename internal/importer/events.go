@@ -0,0 +1,55 @@
+package importer
+
+import (
+	"context"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+)
+
+// Event is sent on Importer.Events while a CSV import runs. It is one of TableStarted,
+// RowRejected, ParentCreated or TableFinished.
+type Event interface {
+	isEvent()
+}
+
+// TableStarted is sent before the CSV file of a table is read.
+type TableStarted struct {
+	Table string
+	File  string
+}
+
+// RowRejected is sent for each CSV record that could not be inserted.
+type RowRejected struct {
+	Err *database.RowInsertError
+}
+
+// ParentCreated is sent for each parent record created automatically for a foreign key.
+type ParentCreated struct {
+	Table       string
+	ColumnNames []string
+	// Key holds the values of ColumnNames, including any the database assigned.
+	Key []string
+}
+
+// TableFinished is sent once the CSV file of a table has been processed.
+type TableFinished struct {
+	Summary TableSummary
+	// Err is the error that stopped the table, if any.
+	Err error
+}
+
+func (TableStarted) isEvent()  {}
+func (RowRejected) isEvent()   {}
+func (ParentCreated) isEvent() {}
+func (TableFinished) isEvent() {}
+
+// emit sends e on Events, if set. It blocks until the event is received or ctx is cancelled.
+func (i *Importer) emit(ctx context.Context, e Event) {
+	if i.Events == nil {
+		return
+	}
+	select {
+	case i.Events <- e:
+	case <-ctx.Done():
+	}
+}
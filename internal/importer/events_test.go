@@ -0,0 +1,27 @@
+package importer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_emit(t *testing.T) {
+	t.Run("Eventsに送信されること", func(t *testing.T) {
+		events := make(chan Event, 1)
+		i := &Importer{Events: events}
+		i.emit(context.Background(), TableStarted{Table: "users", File: "users.csv"})
+		assert.Equal(t, TableStarted{Table: "users", File: "users.csv"}, <-events)
+	})
+	t.Run("キャンセルされた場合は受信されなくてもブロックしないこと", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		i := &Importer{Events: make(chan Event)}
+		i.emit(ctx, TableStarted{Table: "users"})
+	})
+	t.Run("Eventsが設定されていない場合は何もしないこと", func(t *testing.T) {
+		i := &Importer{}
+		i.emit(context.Background(), TableStarted{Table: "users"})
+	})
+}
@@ -0,0 +1,155 @@
+package importer
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/k-wa-wa/db-auto-importer/internal/graph"
+)
+
+// ExportCSVFiles writes the rows of every table, or of the SelectedTables, to CSV files with a
+// header row in dir, in import order. The files are named, and their columns headed, the way
+// ImportCSVFiles reads them (see TableOptions), so that the data can be imported elsewhere.
+// Generated columns and audit columns, which imports never read, are left out.
+//
+// If MaskExports is set, the values are anonymized with the Masker as imports would, so that the
+// files can be imported into other environments without masking.
+//
+// where, if not empty, selects the rows of some tables by an SQL condition, e.g. "id IN (1, 2)".
+// Then only these rows and those of the SelectedTables are exported, together with every row they
+// reference, directly or indirectly, so that the files import as a consistent slice of the data.
+func (i *Importer) ExportCSVFiles(ctx context.Context, dir string, where map[string]string) error {
+	order, _, err := graph.ImportOrder(i.DBSchema, i.OrderRules)
+	if err != nil {
+		return fmt.Errorf("failed to determine export order: %w", err)
+	}
+	for _, tableName := range append(slices.Sorted(maps.Keys(where)), i.SelectedTables...) {
+		if _, ok := i.DBSchema[tableName]; !ok {
+			return fmt.Errorf("invalid table selection: table %s not found in the database schema", tableName)
+		}
+	}
+	if i.MaskExports {
+		if i.Masker == nil {
+			return fmt.Errorf("cannot mask the export: no masking rules are configured")
+		}
+		for _, warning := range i.Masker.KeyWarnings(i.DBSchema) {
+			log.Printf("Warning: %s\n", warning)
+		}
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create export directory %s: %w", dir, err)
+	}
+	if len(where) > 0 {
+		return i.exportClosure(ctx, dir, order, where)
+	}
+
+	for _, tableName := range order {
+		if len(i.SelectedTables) > 0 && !slices.Contains(i.SelectedTables, tableName) {
+			continue
+		}
+		if err := i.exportTable(ctx, i.DBSchema[tableName], dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportTable writes the rows of a table to its CSV file in dir.
+func (i *Importer) exportTable(ctx context.Context, dbInfo database.DBInfo, dir string) error {
+	reader, ok := i.DBClient.(database.RowReader)
+	if !ok {
+		return errors.New("reading rows is not supported by the database")
+	}
+	filePath := filepath.Join(dir, i.csvFileName(dbInfo.TableName))
+	columns, header := i.csvColumns(dbInfo)
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to create CSV file %s: %w", filePath, err)
+	}
+	defer file.Close()
+	w := csv.NewWriter(file)
+	if err := w.Write(header); err != nil {
+		return fmt.Errorf("failed to write CSV file %s: %w", filePath, err)
+	}
+	rows := 0
+	err = reader.ReadRows(ctx, dbInfo, columns, database.RowFilter{}, func(values []string) error {
+		rows++
+		values, err := i.exportValues(dbInfo.TableName, columns, values)
+		if err != nil {
+			return err
+		}
+		return w.Write(values)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export table %s: %w", dbInfo.TableName, err)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV file %s: %w", filePath, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write CSV file %s: %w", filePath, err)
+	}
+	log.Printf("Exported %d row(s) of table %s to %s.\n", rows, dbInfo.TableName, filePath)
+	return nil
+}
+
+// exportValues returns the values of a row as they are written to its CSV file, masked if
+// MaskExports is set.
+func (i *Importer) exportValues(tableName string, columns []database.ColumnInfo, values []string) ([]string, error) {
+	if !i.MaskExports {
+		return values, nil
+	}
+	masked := make([]string, len(values))
+	for idx, colInfo := range columns {
+		value, err := i.Masker.Mask(tableName, colInfo, values[idx])
+		if err != nil {
+			return nil, err
+		}
+		masked[idx] = value
+	}
+	return masked, nil
+}
+
+// csvFileName returns the name of the CSV file imports read for a table.
+func (i *Importer) csvFileName(tableName string) string {
+	if fileName := i.Tables[tableName].File; fileName != "" {
+		return fileName
+	}
+	return tableName + ".csv"
+}
+
+// csvColumns returns the columns imports read from the CSV file of a table, leaving out generated
+// and audit columns, with the header of each.
+func (i *Importer) csvColumns(dbInfo database.DBInfo) ([]database.ColumnInfo, []string) {
+	var columns []database.ColumnInfo
+	var header []string
+	for _, colInfo := range dbInfo.InsertColumns() {
+		if _, audit := i.auditValue(colInfo.ColumnName); audit {
+			continue
+		}
+		columns = append(columns, colInfo)
+		header = append(header, exportHeader(i.Tables[dbInfo.TableName].ColumnMap, colInfo.ColumnName))
+	}
+	return columns, header
+}
+
+// exportHeader returns the CSV header of a column: the header mapped to it in columnMap, if any.
+func exportHeader(columnMap map[string]string, colName string) string {
+	for _, header := range slices.Sorted(maps.Keys(columnMap)) {
+		if strings.EqualFold(columnMap[header], colName) {
+			return header
+		}
+	}
+	return colName
+}
@@ -0,0 +1,251 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/k-wa-wa/db-auto-importer/internal/graph"
+)
+
+// GenerateData fabricates rows for every table in the schema without reading any CSV files.
+// Tables are filled in dependency order; foreign key columns reference rows generated for the
+// parent table (or an automatically created parent when none were generated). rowsPerTable
+// overrides defaultRows for individual tables; a count of 0 skips the table. Rows that cannot be
+// inserted are logged and counted by FailedRows rather than returned as an error.
+func (i *Importer) GenerateData(ctx context.Context, rowsPerTable map[string]int, defaultRows int) error {
+	if i.ValueGenerator == nil {
+		return fmt.Errorf("no value generator configured for data generation")
+	}
+
+	dependencyGraph := graph.NewGraph(i.DBSchema)
+	if err := i.OrderRules.Apply(dependencyGraph); err != nil {
+		return err
+	}
+	order, err := dependencyGraph.TopologicalSort()
+	if err != nil {
+		return fmt.Errorf("failed to determine generation order: %w", err)
+	}
+	log.Printf("Determined generation order: %v\n", order)
+
+	i.failedRows = 0
+	defer i.startBatch(newRunID())()
+	var generated []string
+	parentsBefore := i.createdParents()
+	// written returns the generated tables and those that received auto-created parents
+	written := func() []string {
+		tables := slices.Clone(generated)
+		for tableName, n := range i.createdParents() {
+			if n > parentsBefore[tableName] && !slices.Contains(tables, tableName) {
+				tables = append(tables, tableName)
+			}
+		}
+		return tables
+	}
+	defer func() {
+		i.resetSequences(context.WithoutCancel(ctx), written())
+	}()
+	// Generated values of referenced keys, keyed by keyPoolID, in CSV string form
+	keyPool := make(map[string][][]string)
+	// Referenced keys of each table
+	referenced := make(map[string][][]string)
+	for _, dbInfo := range i.DBSchema {
+		for _, fk := range dbInfo.ForeignKeys {
+			id := keyPoolID(fk.ForeignTableName, fk.ForeignColumnNames)
+			if _, ok := keyPool[id]; !ok {
+				keyPool[id] = nil
+				referenced[fk.ForeignTableName] = append(referenced[fk.ForeignTableName], fk.ForeignColumnNames)
+			}
+		}
+	}
+
+	for _, tableName := range order {
+		rows := defaultRows
+		if n, ok := rowsPerTable[tableName]; ok {
+			rows = n
+		}
+		if rows <= 0 {
+			continue
+		}
+
+		dbInfo := i.DBSchema[tableName]
+		log.Printf("Generating %d rows for table %s...\n", rows, tableName)
+		generated = append(generated, tableName)
+		if err := i.generateTableRows(ctx, dbInfo, rows, keyPool, referenced[tableName]); err != nil {
+			return fmt.Errorf("failed to generate data for table %s: %w", tableName, err)
+		}
+		log.Printf("Finished generating rows for table %s.\n", tableName)
+	}
+
+	if i.RefreshMaterializedViews {
+		if err := i.refreshMaterializedViews(ctx, written()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// keyPoolID identifies the generated values of a referenced key in the key pool.
+func keyPoolID(tableName string, columnNames []string) string {
+	return tableName + "." + strings.Join(columnNames, ",")
+}
+
+func (i *Importer) generateTableRows(ctx context.Context, dbInfo database.DBInfo, rows int, keyPool map[string][][]string, referencedKeys [][]string) error {
+	dbInfo = i.excludeColumns(dbInfo)
+	uniqueCols := make(map[string]bool)
+	for _, pkCol := range dbInfo.PrimaryKeyColumns {
+		uniqueCols[pkCol] = true
+	}
+	for _, ukCols := range dbInfo.UniqueKeyColumns {
+		for _, ukCol := range ukCols {
+			uniqueCols[ukCol] = true
+		}
+	}
+	insertColumns := dbInfo.InsertColumns()
+	colIdxByName := make(map[string]int, len(insertColumns))
+	for colIdx, colInfo := range insertColumns {
+		colIdxByName[colInfo.ColumnName] = colIdx
+	}
+	fkCols := make(map[string]bool)
+	for _, fk := range dbInfo.ForeignKeys {
+		for _, colName := range fk.ColumnNames {
+			fkCols[colName] = true
+		}
+	}
+
+	stmt, err := i.DBClient.PrepareInsertStatement(ctx, dbInfo)
+	if err != nil {
+		return fmt.Errorf("failed to prepare insert statement for table %s: %w", dbInfo.TableName, err)
+	}
+	defer stmt.Close()
+
+	for row := 0; row < rows; row++ {
+		values := make([]interface{}, len(insertColumns))
+		for colIdx, colInfo := range insertColumns {
+			if fkCols[colInfo.ColumnName] {
+				continue
+			}
+			if auditVal, ok := i.auditValue(colInfo.ColumnName); ok {
+				val, err := database.ConvertColumnValue(auditVal, colInfo)
+				if err != nil {
+					return fmt.Errorf("invalid audit column value for %s.%s: %w", dbInfo.TableName, colInfo.ColumnName, err)
+				}
+				values[colIdx] = val
+				continue
+			}
+			val, err := i.ValueGenerator.GenerateValue(dbInfo.TableName, colInfo, uniqueCols[colInfo.ColumnName])
+			if err != nil {
+				return fmt.Errorf("failed to generate value for column %s: %w", colInfo.ColumnName, err)
+			}
+			values[colIdx] = val
+		}
+		for _, fk := range dbInfo.ForeignKeys {
+			fkValues, err := i.pickForeignKeyValues(ctx, fk, insertColumns, colIdxByName, keyPool, row)
+			if err != nil {
+				return err
+			}
+			for idx, colName := range fk.ColumnNames {
+				if colIdx, ok := colIdxByName[colName]; ok {
+					values[colIdx] = fkValues[idx]
+				}
+			}
+		}
+
+		stmtCtx, cancel := i.statementContext(ctx, dbInfo.TableName, insertStatement(dbInfo))
+		_, err := stmt.ExecContext(stmtCtx, values...)
+		cancel()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			rowErr := &database.RowInsertError{TableName: dbInfo.TableName, FilePath: "(generated)", Err: err}
+			log.Printf("Error: %v\n", rowErr)
+			i.failedRows++
+			continue
+		}
+
+		for _, keyCols := range referencedKeys {
+			key := make([]string, 0, len(keyCols))
+			for _, colName := range keyCols {
+				colIdx, ok := colIdxByName[colName]
+				if !ok || values[colIdx] == nil {
+					break
+				}
+				key = append(key, database.FormatValue(values[colIdx]))
+			}
+			if len(key) == len(keyCols) {
+				id := keyPoolID(dbInfo.TableName, keyCols)
+				keyPool[id] = append(keyPool[id], key)
+			}
+		}
+	}
+	return nil
+}
+
+// pickForeignKeyValues chooses the values of a foreign key from the rows generated for the
+// parent table, cycling through them so that the result does not depend on a random source.
+// If the parent has no generated rows, values are invented and the parent record
+// is created on demand (or the key is left NULL when allowed).
+func (i *Importer) pickForeignKeyValues(ctx context.Context, fk database.ForeignKeyInfo, insertColumns []database.ColumnInfo, colIdxByName map[string]int, keyPool map[string][][]string, row int) ([]interface{}, error) {
+	parentDBInfo, ok := i.DBSchema[fk.ForeignTableName]
+	if !ok {
+		return nil, &database.MissingParentTableError{TableName: fk.ForeignTableName, ConstraintName: fk.ConstraintName}
+	}
+
+	nullable := false
+	for _, colName := range fk.ColumnNames {
+		if colIdx, ok := colIdxByName[colName]; ok && insertColumns[colIdx].IsNullable {
+			nullable = true
+		}
+	}
+
+	var fkValues []string
+	if pool := keyPool[keyPoolID(fk.ForeignTableName, fk.ForeignColumnNames)]; len(pool) > 0 {
+		fkValues = pool[row%len(pool)]
+	} else {
+		if nullable {
+			// NULL in any column of the key satisfies the constraint
+			return make([]interface{}, len(fk.ColumnNames)), nil
+		}
+		for _, foreignColName := range fk.ForeignColumnNames {
+			var parentCol database.ColumnInfo
+			for _, c := range parentDBInfo.Columns {
+				if c.ColumnName == foreignColName {
+					parentCol = c
+					break
+				}
+			}
+			generated, err := i.ValueGenerator.GenerateValue(parentDBInfo.TableName, parentCol, true)
+			if err != nil {
+				return nil, fmt.Errorf("failed to generate value for foreign key %s: %w", fk.ConstraintName, err)
+			}
+			fkValues = append(fkValues, database.FormatValue(generated))
+		}
+		stmtCtx, cancel := i.statementContext(ctx, fk.ForeignTableName, parentStatement("parent record check or creation on", fk.ForeignTableName, fk.ForeignColumnNames, fkValues))
+		parentKey, err := i.DBClient.EnsureParentRecordExists(stmtCtx, parentDBInfo, fk.ForeignColumnNames, fkValues, i.DBSchema)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to ensure parent record exists for %s.%v (value: %v): %w", fk.ForeignTableName, fk.ForeignColumnNames, fkValues, err)
+		}
+		fkValues = parentKey
+	}
+
+	converted := make([]interface{}, len(fk.ColumnNames))
+	for idx, colName := range fk.ColumnNames {
+		colIdx, ok := colIdxByName[colName]
+		if !ok {
+			continue
+		}
+		colInfo := insertColumns[colIdx]
+		val, err := database.ConvertToDBType(fkValues[idx], colInfo.DataType, colInfo.IsNullable, colInfo.ColumnDefault)
+		if err != nil {
+			return nil, err
+		}
+		converted[idx] = val
+	}
+	return converted, nil
+}
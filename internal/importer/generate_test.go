@@ -0,0 +1,56 @@
+package importer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GenerateData(t *testing.T) {
+	schema := map[string]database.DBInfo{
+		"organizations": fakeSchema["organizations"],
+		"users":         fakeSchema["users"],
+		"orders": {
+			TableName: "orders",
+			Columns: []database.ColumnInfo{
+				{ColumnName: "id", DataType: database.IntegerType},
+				{ColumnName: "user_id", DataType: database.IntegerType},
+			},
+			PrimaryKeyColumns: []string{"id"},
+			ForeignKeys:       []database.ForeignKeyInfo{{TableName: "orders", ColumnNames: []string{"user_id"}, ForeignTableName: "users", ForeignColumnNames: []string{"id"}}},
+		},
+	}
+
+	t.Run("外部キーの順に各テーブルの行が生成されること", func(t *testing.T) {
+		client := newFakeClient(schema)
+		i := &Importer{DBSchema: schema, DBClient: client, ValueGenerator: database.NewFakeGenerator()}
+
+		require.NoError(t, i.GenerateData(context.Background(), map[string]int{"orders": 5}, 3))
+		assert.Equal(t, []string{
+			"organizations", "organizations", "organizations",
+			"users", "users", "users",
+			"orders", "orders", "orders", "orders", "orders",
+		}, client.inserted)
+		for _, user := range client.rows["users"] {
+			assert.True(t, client.find("organizations", []string{"id"}, []string{user["org_id"]}), "ユーザーは生成された組織を参照すること")
+		}
+		for _, order := range client.rows["orders"] {
+			assert.True(t, client.find("users", []string{"id"}, []string{order["user_id"]}), "注文は生成されたユーザーを参照すること")
+		}
+		assert.Empty(t, client.CreatedParents())
+		assert.Zero(t, i.FailedRows())
+	})
+
+	t.Run("行数0のテーブルは生成されず親は自動作成されること", func(t *testing.T) {
+		client := newFakeClient(schema)
+		i := &Importer{DBSchema: schema, DBClient: client, ValueGenerator: database.NewFakeGenerator()}
+
+		require.NoError(t, i.GenerateData(context.Background(), map[string]int{"users": 0}, 2))
+		assert.Len(t, client.rows["organizations"], 2)
+		assert.Len(t, client.rows["orders"], 2)
+		assert.Equal(t, map[string]int{"users": 2}, client.CreatedParents())
+	})
+}
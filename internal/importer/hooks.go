@@ -0,0 +1,26 @@
+package importer
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrSkipRow is returned by a BeforeRow hook to leave a row out without treating it as an error.
+var ErrSkipRow = errors.New("row skipped by hook")
+
+// Hooks are optional callbacks run during an import. They let embedders enrich rows, veto
+// inserts or collect metrics without changing the import loop. Nil hooks are skipped.
+type Hooks struct {
+	// BeforeTable runs before a table's CSV file is read. An error aborts the import.
+	BeforeTable func(ctx context.Context, table, filePath string) error
+	// AfterTable runs once a table's CSV file has been processed, with the table's summary
+	// and the error that stopped it, if any.
+	AfterTable func(ctx context.Context, summary TableSummary, err error)
+	// BeforeRow runs for each row before its parent records are checked and it is inserted.
+	// row maps column names to the CSV values (after masking and generation) and may be
+	// modified. Returning ErrSkipRow skips the row; any other error rejects it.
+	BeforeRow func(ctx context.Context, table string, row map[string]string) error
+	// AfterRow runs after each row with the values that were used and the error that
+	// rejected it, or nil if it was inserted. It is not called for rows skipped by BeforeRow.
+	AfterRow func(ctx context.Context, table string, row map[string]string, err error)
+}
@@ -0,0 +1,126 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Hooks(t *testing.T) {
+	files := map[string]string{
+		"organizations.csv": "id,name\n1,Acme\n",
+		"users.csv":         "id,name,org_id\n1,Alice,1\n2,Bob,1\n",
+	}
+	// recordingHooks records the hook calls of an import in order
+	recordingHooks := func(calls *[]string) Hooks {
+		return Hooks{
+			BeforeTable: func(ctx context.Context, table, filePath string) error {
+				*calls = append(*calls, "BeforeTable "+table)
+				return nil
+			},
+			AfterTable: func(ctx context.Context, summary TableSummary, err error) {
+				*calls = append(*calls, fmt.Sprintf("AfterTable %s inserted=%d err=%v", summary.Table, summary.RowsInserted, err))
+			},
+			BeforeRow: func(ctx context.Context, table string, row map[string]string) error {
+				*calls = append(*calls, "BeforeRow "+table+" "+row["id"])
+				return nil
+			},
+			AfterRow: func(ctx context.Context, table string, row map[string]string, err error) {
+				*calls = append(*calls, fmt.Sprintf("AfterRow %s %s err=%v", table, row["id"], err))
+			},
+		}
+	}
+
+	t.Run("フックがテーブルと行ごとに順に呼ばれること", func(t *testing.T) {
+		var calls []string
+		i := &Importer{DBSchema: fakeSchema, DBClient: newFakeClient(fakeSchema), Hooks: recordingHooks(&calls)}
+		_, err := i.ImportCSVFiles(context.Background(), writeCSVFiles(t, files), true)
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"BeforeTable organizations",
+			"BeforeRow organizations 1",
+			"AfterRow organizations 1 err=<nil>",
+			"AfterTable organizations inserted=1 err=<nil>",
+			"BeforeTable users",
+			"BeforeRow users 1",
+			"AfterRow users 1 err=<nil>",
+			"BeforeRow users 2",
+			"AfterRow users 2 err=<nil>",
+			"AfterTable users inserted=2 err=<nil>",
+		}, calls)
+	})
+
+	t.Run("BeforeRowで変更した値が挿入されること", func(t *testing.T) {
+		client := newFakeClient(fakeSchema)
+		i := &Importer{DBSchema: fakeSchema, DBClient: client}
+		i.Hooks.BeforeRow = func(ctx context.Context, table string, row map[string]string) error {
+			if table == "users" {
+				row["name"] += " (imported)"
+			}
+			return nil
+		}
+		_, err := i.ImportCSVFiles(context.Background(), writeCSVFiles(t, files), true)
+		require.NoError(t, err)
+		assert.True(t, client.find("users", []string{"id", "name"}, []string{"1", "Alice (imported)"}))
+	})
+
+	t.Run("ErrSkipRowの行はスキップされAfterRowは呼ばれないこと", func(t *testing.T) {
+		var calls []string
+		client := newFakeClient(fakeSchema)
+		i := &Importer{DBSchema: fakeSchema, DBClient: client, Hooks: recordingHooks(&calls)}
+		i.Hooks.BeforeRow = func(ctx context.Context, table string, row map[string]string) error {
+			if table == "users" && row["id"] == "2" {
+				return ErrSkipRow
+			}
+			return nil
+		}
+		result, err := i.ImportCSVFiles(context.Background(), writeCSVFiles(t, files), true)
+		require.NoError(t, err)
+		assert.NotContains(t, calls, "AfterRow users 2 err=<nil>")
+		assert.False(t, client.find("users", []string{"id"}, []string{"2"}))
+		assert.Equal(t, 1, result.Tables[1].RowsSkipped)
+		assert.Zero(t, result.Failed)
+	})
+
+	t.Run("BeforeRowのエラーは行を拒否しAfterRowに渡されること", func(t *testing.T) {
+		var calls []string
+		client := newFakeClient(fakeSchema)
+		i := &Importer{DBSchema: fakeSchema, DBClient: client, Hooks: recordingHooks(&calls)}
+		vetoed := errors.New("vetoed")
+		i.Hooks.BeforeRow = func(ctx context.Context, table string, row map[string]string) error {
+			if table == "users" && row["id"] == "2" {
+				return vetoed
+			}
+			return nil
+		}
+		result, err := i.ImportCSVFiles(context.Background(), writeCSVFiles(t, files), true)
+		require.NoError(t, err)
+		assert.Contains(t, calls, "AfterRow users 2 err=vetoed")
+		assert.False(t, client.find("users", []string{"id"}, []string{"2"}))
+		require.Len(t, result.Rejected, 1)
+		assert.ErrorIs(t, result.Rejected[0], vetoed)
+		assert.ErrorIs(t, result.Rejected[0], database.ErrRowInsert)
+	})
+
+	t.Run("BeforeTableのエラーはインポートを中止すること", func(t *testing.T) {
+		var calls []string
+		client := newFakeClient(fakeSchema)
+		i := &Importer{DBSchema: fakeSchema, DBClient: client, Hooks: recordingHooks(&calls)}
+		i.Hooks.BeforeTable = func(ctx context.Context, table, filePath string) error {
+			calls = append(calls, "BeforeTable "+table)
+			if table == "organizations" {
+				return errors.New("maintenance window")
+			}
+			return nil
+		}
+		_, err := i.ImportCSVFiles(context.Background(), writeCSVFiles(t, files), true)
+		assert.ErrorContains(t, err, "maintenance window")
+		assert.Equal(t, []string{"BeforeTable organizations"}, calls, "後続のテーブルと行のフックは呼ばれないこと")
+		assert.Empty(t, client.inserted)
+	})
+}
@@ -1,30 +1,174 @@
 package importer
 
 import (
+	"context"
+	"database/sql"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
-	"db-auto-importer/internal/database"
-	"db-auto-importer/internal/graph"
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/k-wa-wa/db-auto-importer/internal/graph"
 )
 
+// ErrUnmappedFiles is returned when CSV files without a corresponding table are found
+// and the unmapped-file policy is UnmappedFileFail.
+var ErrUnmappedFiles = errors.New("CSV files with no corresponding table")
+
+// UnmappedFilePolicy controls how CSV files that do not match any table are handled.
+type UnmappedFilePolicy string
+
+const (
+	UnmappedFileWarn   UnmappedFilePolicy = "warn"   // Log each file and a summary, then continue.
+	UnmappedFileFail   UnmappedFilePolicy = "fail"   // Abort before importing anything.
+	UnmappedFileIgnore UnmappedFilePolicy = "ignore" // Skip silently.
+)
+
+// ParseUnmappedFilePolicy validates a policy name given on the command line.
+func ParseUnmappedFilePolicy(s string) (UnmappedFilePolicy, error) {
+	switch p := UnmappedFilePolicy(strings.ToLower(s)); p {
+	case UnmappedFileWarn, UnmappedFileFail, UnmappedFileIgnore:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown unmapped-file policy '%s' (expected warn, fail or ignore)", s)
+	}
+}
+
+// TableOptions holds per-table import settings.
+type TableOptions struct {
+	// File is the name of the CSV file in the CSV directory to read for the table instead of "<table>.csv".
+	File string
+	// ColumnMap maps CSV header names to column names where they differ.
+	ColumnMap map[string]string
+	// WatermarkColumn is the column of the table an incremental import compares with its
+	// watermark, instead of Importer.WatermarkColumn.
+	WatermarkColumn string
+	// BatchSize, if positive, is the batch size of the table instead of Importer.BatchSize.
+	BatchSize int
+	// Mode is how rows whose key is already in the table are treated. Empty means
+	// database.InsertUpsert; other modes need a database.ModeInserter.
+	Mode database.InsertMode
+	// Parallelism, if positive, is the number of batches of the table inserted at the same time
+	// instead of Importer.Concurrency, each in a transaction on its own connection. It needs batched
+	// inserts (see BatchSize).
+	Parallelism int
+	// ExcludeColumns are columns left out of the inserts of the table, e.g. updated_at, so that the
+	// database's defaults or triggers fill them. CSV values of the columns are ignored.
+	ExcludeColumns []string
+}
+
 // Importer handles the CSV parsing and data import logic.
 type Importer struct {
-	DBSchema map[string]database.DBInfo
-	DBClient database.DBClient // Use the DBClient interface
+	DBSchema           map[string]database.DBInfo
+	DBClient           database.DBClient // Use the DBClient interface
+	UnmappedFilePolicy UnmappedFilePolicy
+	// ValueGenerator invents values when data is fabricated without CSV files (see GenerateData).
+	ValueGenerator database.ValueGenerator
+	// CellGenerator, if set, fills empty or missing CSV cells of columns that have a generation rule.
+	CellGenerator *database.RuleGenerator
+	// NoAutoParents rejects records referencing a missing parent instead of creating the parent record.
+	NoAutoParents bool
+	// Masker, if set, anonymizes CSV values before they are inserted. Parent records are looked up
+	// by the masked values, so a masked foreign key only matches a parent whose key is masked the
+	// same way, e.g. by the same hash rule.
+	Masker *Masker
+	// MaskExports makes ExportCSVFiles anonymize the values it writes with Masker instead.
+	MaskExports bool
+	// Encryptor, if set, encrypts CSV values, after masking and generation, before they are inserted.
+	Encryptor *Encryptor
+	// Tables holds per-table options, keyed by table name.
+	Tables map[string]TableOptions
+	// SelectedTables, if set, limits imports to the CSV files of these tables. Parent records may
+	// still be created in the tables they reference.
+	SelectedTables []string
+	// OrderRules adjust the import order for dependencies the foreign keys do not declare.
+	OrderRules graph.OrderRules
+	// AuditColumns holds the values (in CSV string form) of columns that CSV files never contain,
+	// such as created_by, keyed by column name. They apply to every table with the column and
+	// replace any CSV value; an empty value leaves the column to its default or NULL.
+	AuditColumns map[string]string
+	// Progress, if set, reports the progress of each CSV file.
+	Progress *Progress
+	// Checkpoint, if set, records the progress of each CSV file so an interrupted import can resume.
+	Checkpoint *Checkpoint
+	// Watermarks, if set, make imports incremental: only the CSV rows whose watermark column is
+	// larger than the watermark stored for their table are imported, and the watermark of each
+	// table is advanced once the table has been imported without rejected rows.
+	Watermarks *Watermarks
+	// WatermarkColumn is the watermark column of the tables that have it and do not name one in
+	// their TableOptions. Tables without a watermark column are imported in full.
+	WatermarkColumn string
+	// BatchSize, if larger than 1, is the number of CSV rows inserted together in a transaction on
+	// the databases that support it (see database.BatchExecutor). A row that fails is retried on
+	// its own and rejected like any other, without failing the rest of its batch. If 0, each table
+	// uses the default batch size of the database (see database.BatchSizer), if any.
+	BatchSize int
+	// Concurrency, if larger than 1, is the number of batches of each table inserted at the same
+	// time, unless the TableOptions of the table set its Parallelism.
+	Concurrency int
+	// StatementTimeout, if positive, bounds each insert and each parent record check or creation.
+	StatementTimeout time.Duration
+	// SlowThreshold, if positive, is the duration above which an insert, parent record check or
+	// creation, or update is logged as a slow statement, e.g. to find lock waits or missing indexes.
+	SlowThreshold time.Duration
+	// RefreshMaterializedViews refreshes the materialized views over the written tables after an import.
+	RefreshMaterializedViews bool
+	// RecordRuns adds a row describing each CSV import to the database's _import_runs table.
+	RecordRuns bool
+	// MaxMemory, if positive, limits the memory the buffers kept until the end of an import may
+	// use: deferred foreign key values beyond it are spilled to a temporary file and rejected
+	// records are no longer kept in ImportResult.Rejected, only logged and counted.
+	MaxMemory int64
+	// BatchColumn, if set, is a column that every row written by an import or data generation,
+	// including auto-created parent records, is given the id of the run in, so that the rows of a
+	// run can be queried or deleted. Like the AuditColumns, CSV files never contain it.
+	BatchColumn string
+	// Hooks are called around each table and row.
+	Hooks Hooks
+	// Observer, if set, is notified of the progress of CSV imports.
+	Observer Observer
+	// Control, if set, pauses and aborts the imports of individual tables while an import runs.
+	Control *TableControl
+	// Events, if set, receives an Event for each table started or finished, row rejected and
+	// parent record created during CSV imports. Sends block, so it must be read until the import
+	// returns; the importer never closes it.
+	Events chan<- Event
+
+	failedRows int                        // Number of records that could not be inserted during the current run
+	rejected   []*database.RowInsertError // Records that could not be inserted during the current run
+	summaries  []TableSummary             // Per-table results of the current run
+	// parentsBefore holds the parent records created before the current run, so the summary counts only this run's
+	parentsBefore map[string]int
+	// deferredFKs holds the constraint names of the foreign keys deferred to break a cycle, per table
+	deferredFKs map[string]map[string]bool
+	pendingFKs  []deferredForeignKey // Deferred foreign key values of the rows inserted so far
+	spilledFKs  int                  // Number of deferred foreign key values moved to spillFile
+	spillFile   *os.File             // Temporary file the deferred foreign key values are spilled to; see MaxMemory
+	// bufferedBytes estimates the memory of pendingFKs and rejected, limited by MaxMemory
+	bufferedBytes   int64
+	rejectedDropped int    // Number of rejected records not kept in rejected because of MaxMemory
+	batchID         string // Value of BatchColumn during the current run
 }
 
-// NewImporter creates a new Importer instance.
-func NewImporter(dbSchema map[string]database.DBInfo, dbClient database.DBClient) (*Importer, error) {
-	return &Importer{
-		DBSchema: dbSchema,
-		DBClient: dbClient,
-	}, nil
+// NewImporter creates a new Importer instance configured by opts.
+func NewImporter(dbSchema map[string]database.DBInfo, dbClient database.DBClient, opts ...Option) (*Importer, error) {
+	i := &Importer{
+		DBSchema:           dbSchema,
+		DBClient:           dbClient,
+		UnmappedFilePolicy: UnmappedFileWarn,
+	}
+	for _, opt := range opts {
+		opt(i)
+	}
+	return i, nil
 }
 
 // Close closes the database connection.
@@ -34,25 +178,108 @@ func (i *Importer) Close() error {
 
 // ImportCSVFiles reads CSV files from the given directory and imports them into the database.
 // The 'hasHeader' parameter indicates whether all CSV files in the directory have a header row.
-func (i *Importer) ImportCSVFiles(csvDir string, hasHeader bool) error {
-	csvFilesMap := make(map[string]string) // Map table name to CSV file path
-	files, err := getCSVFiles(csvDir)
+// The result covers the tables processed so far even when an error is returned; it is nil only
+// if the import did not start.
+func (i *Importer) ImportCSVFiles(ctx context.Context, csvDir string, hasHeader bool) (*ImportResult, error) {
+	return i.ImportSource(ctx, DirSource(csvDir), hasHeader)
+}
+
+// ImportSource imports every input of src like ImportCSVFiles.
+func (i *Importer) ImportSource(ctx context.Context, src Source, hasHeader bool) (*ImportResult, error) {
+	files, err := src.List(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get CSV files from %s: %w", csvDir, err)
+		return nil, fmt.Errorf("failed to get CSV files: %w", err)
 	}
-	for _, filePath := range files {
-		tableName := strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
-		csvFilesMap[tableName] = filePath
+	csvFilesMap := i.mapCSVFiles(files)
+
+	unmappedFiles := i.findUnmappedFiles(csvFilesMap)
+	if len(unmappedFiles) > 0 {
+		switch i.UnmappedFilePolicy {
+		case UnmappedFileFail:
+			return nil, fmt.Errorf("%w: %s", ErrUnmappedFiles, strings.Join(unmappedFiles, ", "))
+		case UnmappedFileIgnore:
+		default:
+			for _, filePath := range unmappedFiles {
+				log.Printf("WARNING: CSV file %s has no corresponding table in the database schema and will NOT be imported.\n", filePath)
+			}
+			defer log.Printf("WARNING: %d CSV file(s) were skipped because no corresponding table exists: %s\n", len(unmappedFiles), strings.Join(unmappedFiles, ", "))
+		}
 	}
 
+	return i.importTables(ctx, src, csvFilesMap, hasHeader)
+}
+
+// ImportFiles imports the given CSV files, e.g. files that appeared in a watched directory,
+// in dependency order. Files with no corresponding table are skipped, with a warning unless the
+// policy is UnmappedFileIgnore.
+func (i *Importer) ImportFiles(ctx context.Context, files []string, hasHeader bool) (*ImportResult, error) {
+	csvFilesMap := i.mapCSVFiles(files)
+	if i.UnmappedFilePolicy == UnmappedFileIgnore {
+		return i.importTables(ctx, FileSource(files), csvFilesMap, hasHeader)
+	}
+	for _, filePath := range i.findUnmappedFiles(csvFilesMap) {
+		log.Printf("WARNING: CSV file %s has no corresponding table in the database schema and will NOT be imported.\n", filePath)
+	}
+	return i.importTables(ctx, FileSource(files), csvFilesMap, hasHeader)
+}
+
+// importTables imports the CSV file of each table in csvFilesMap in dependency order.
+func (i *Importer) importTables(ctx context.Context, src Source, csvFilesMap map[string]string, hasHeader bool) (result *ImportResult, err error) {
 	// Determine import order based on foreign key constraints
-	dependencyGraph := graph.NewGraph(i.DBSchema)
-	importOrder, err := dependencyGraph.TopologicalSort()
+	importOrder, deferredFKs, err := graph.ImportOrder(i.DBSchema, i.OrderRules)
 	if err != nil {
-		return fmt.Errorf("failed to determine import order: %w", err)
+		return nil, fmt.Errorf("failed to determine import order: %w", err)
 	}
 
 	log.Printf("Determined import order: %v\n", importOrder)
+	i.setDeferredForeignKeys(deferredFKs)
+
+	csvFilesMap, ancestors, err := i.selectFiles(csvFilesMap)
+	if err != nil {
+		return nil, err
+	}
+	if len(ancestors) > 0 && !i.NoAutoParents {
+		log.Printf("Warning: Auto-created parent records may be written to tables outside the selection: %s\n", strings.Join(ancestors, ", "))
+	}
+
+	i.failedRows = 0
+	i.rejected = nil
+	i.bufferedBytes, i.rejectedDropped = 0, 0
+	i.summaries = nil
+	i.parentsBefore = i.createdParents()
+	// Every return below yields a result, covering the tables processed so far
+	started := time.Now()
+	var runID string
+	if i.RecordRuns || i.BatchColumn != "" {
+		runID = newRunID()
+	}
+	defer i.startBatch(runID)()
+	defer func() {
+		result = &ImportResult{RunID: runID, Tables: i.Summary(), Rejected: i.rejected, Failed: i.failedRows, Elapsed: time.Since(started)}
+	}()
+	if i.RecordRuns {
+		// Registered before the observer and sequence resets, so it runs after them and records the final outcome
+		defer func() {
+			i.recordRun(context.WithoutCancel(ctx), runID, started, csvFilesMap, err)
+		}()
+	}
+	if i.Observer != nil {
+		defer func() {
+			i.Observer.ImportFinished(i.Summary(), err)
+		}()
+	}
+	// The values of a failed import are not set, but their spill file must go
+	defer i.clearPendingForeignKeys()
+	// Also after a failure or cancellation, since the rows written so far stay in the database
+	defer func() {
+		i.resetSequences(context.WithoutCancel(ctx), writtenTables(i.Summary()))
+	}()
+	if configurer, ok := i.DBClient.(database.ParentConfigurer); ok && i.Events != nil {
+		configurer.SetParentCreatedFunc(func(tableName string, columnNames, key []string) {
+			i.emit(ctx, ParentCreated{Table: tableName, ColumnNames: columnNames, Key: key})
+		})
+		defer configurer.SetParentCreatedFunc(nil)
+	}
 
 	for _, tableName := range importOrder {
 		filePath, ok := csvFilesMap[tableName]
@@ -67,28 +294,92 @@ func (i *Importer) ImportCSVFiles(csvDir string, hasHeader bool) error {
 			continue
 		}
 
+		if i.Hooks.BeforeTable != nil {
+			if err := i.Hooks.BeforeTable(ctx, tableName, filePath); err != nil {
+				return nil, fmt.Errorf("failed to import %s: %w", filePath, err)
+			}
+		}
 		log.Printf("Importing data from %s into table %s...\n", filePath, tableName)
+		if i.Observer != nil {
+			i.Observer.FileStarted(tableName, filePath)
+		}
+		i.emit(ctx, TableStarted{Table: tableName, File: filePath})
 		// Pass the hasHeader flag directly to ImportSingleCSV
-		if err := i.ImportSingleCSV(filePath, dbInfo, hasHeader); err != nil {
-			return fmt.Errorf("failed to import %s: %w", filePath, err)
+		err := i.importCSV(ctx, src, filePath, dbInfo, hasHeader)
+		if i.Observer != nil {
+			i.Observer.FileFinished(i.summaries[len(i.summaries)-1], err)
+		}
+		i.emit(ctx, TableFinished{Summary: i.summaries[len(i.summaries)-1], Err: err})
+		if i.Hooks.AfterTable != nil {
+			i.Hooks.AfterTable(ctx, i.summaries[len(i.summaries)-1], err)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to import %s: %w", filePath, err)
 		}
 		log.Printf("Finished importing %s.\n", filePath)
 	}
 
-	return nil
+	if err := i.applyDeferredForeignKeys(ctx); err != nil {
+		return nil, err
+	}
+
+	if i.Checkpoint != nil && !slices.ContainsFunc(i.summaries, func(s TableSummary) bool { return s.Aborted }) {
+		if err := i.Checkpoint.remove(); err != nil {
+			return nil, err
+		}
+	}
+
+	if i.RefreshMaterializedViews {
+		if err := i.refreshMaterializedViews(ctx, writtenTables(i.Summary())); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, nil
 }
 
-func (i *Importer) ImportSingleCSV(filePath string, dbInfo database.DBInfo, hasHeader bool) error {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return fmt.Errorf("failed to open CSV file %s: %w", filePath, err)
+// ImportSingleCSV imports one local CSV file into the table described by dbInfo.
+func (i *Importer) ImportSingleCSV(ctx context.Context, filePath string, dbInfo database.DBInfo, hasHeader bool) error {
+	return i.importCSV(ctx, FileSource{filePath}, filePath, dbInfo, hasHeader)
+}
+
+func (i *Importer) importCSV(ctx context.Context, src Source, filePath string, dbInfo database.DBInfo, hasHeader bool) error {
+	dbInfo = i.excludeColumns(dbInfo)
+	summary := TableSummary{Table: dbInfo.TableName, File: filePath}
+	started := time.Now()
+	defer func() {
+		summary.Elapsed = time.Since(started)
+		i.summaries = append(i.summaries, summary)
+	}()
+
+	// Arrow IPC inputs are read in text form like CSV, but their values are inserted in their
+	// native types unless a step of the import rewrites them
+	var reader *csv.Reader
+	var records interface{ Read() ([]string, error) }
+	var arrowRecords *arrowRecordReader
+	var localFile *os.File
+	var err error
+	if isArrowFile(filePath) {
+		arrowRecords, err = openArrowRecords(ctx, src, filePath, hasHeader)
+		if err != nil {
+			return fmt.Errorf("failed to open Arrow IPC file %s: %w", filePath, err)
+		}
+		defer arrowRecords.Close()
+		records = arrowRecords
+	} else {
+		file, err := src.Open(ctx, filePath)
+		if err != nil {
+			return fmt.Errorf("failed to open CSV file %s: %w", filePath, err)
+		}
+		defer file.Close()
+		reader = csv.NewReader(file)
+		records = reader
+		localFile, _ = file.(*os.File)
 	}
-	defer file.Close()
 
-	reader := csv.NewReader(file)
 	var csvHeader []string
 	if hasHeader {
-		csvHeader, err = reader.Read() // Read header row
+		csvHeader, err = records.Read() // Read header row
 		if err != nil {
 			return fmt.Errorf("failed to read CSV header from %s: %w", filePath, err)
 		}
@@ -97,89 +388,712 @@ func (i *Importer) ImportSingleCSV(filePath string, dbInfo database.DBInfo, hasH
 	// Map CSV columns to database columns
 	columnMap := make(map[string]int) // Maps DB column name to CSV column index
 	if hasHeader {
-		for _, colInfo := range dbInfo.Columns {
-			found := false
-			for csvIdx, csvColName := range csvHeader {
-				if strings.EqualFold(colInfo.ColumnName, csvColName) {
-					columnMap[colInfo.ColumnName] = csvIdx
-					found = true
-					break
-				}
+		columnMap, _ = i.mapCSVHeader(dbInfo, csvHeader)
+		for _, colInfo := range dbInfo.InsertColumns() {
+			if _, audit := i.auditValue(colInfo.ColumnName); audit {
+				continue
 			}
-			if !found {
+			if _, found := columnMap[colInfo.ColumnName]; !found {
 				log.Printf("Warning: Column '%s' in table '%s' not found in CSV header. Will use default/null.\n", colInfo.ColumnName, dbInfo.TableName)
 			}
 		}
 	} else {
 		// If no header, assume CSV columns are in the same order as DB columns based on dbInfo.Columns order.
-		// This creates a positional mapping from DB column name to its expected CSV index.
-		for idx, colInfo := range dbInfo.Columns {
-			columnMap[colInfo.ColumnName] = idx
-		}
+		columnMap = i.positionalColumnMap(dbInfo)
+	}
+	watermark, err := i.newWatermarkFilter(dbInfo, columnMap)
+	if err != nil {
+		return err
 	}
 
-	stmt, err := i.DBClient.PrepareInsertStatement(dbInfo)
+	stmt, err := i.prepareInsert(ctx, dbInfo)
 	if err != nil {
 		return fmt.Errorf("failed to prepare insert statement for table %s: %w", dbInfo.TableName, err)
 	}
 	defer stmt.Close()
 
+	// Resume after the rows processed by an earlier, interrupted run
+	var state *FileCheckpoint
+	var baseOffset int64
+	// A hint row of a CSV template directly follows the header; it is skipped but keeps its line
+	hintRow := false
+	seekable := localFile != nil
+	if i.Checkpoint != nil && !seekable {
+		log.Printf("Warning: %s is not a local CSV file and cannot be resumed; its progress is not recorded.\n", filePath)
+	}
+	if i.Checkpoint != nil && seekable {
+		state, err = i.Checkpoint.file(filePath)
+		if err != nil {
+			return err
+		}
+		if state.Complete {
+			log.Printf("Skipping %s: already imported by a previous run.\n", filePath)
+			return nil
+		}
+		defer func() {
+			// Keep the rows processed before a failure
+			if !state.Complete {
+				if err := i.Checkpoint.save(); err != nil {
+					log.Printf("Warning: %v\n", err)
+				}
+			}
+		}()
+		if state.Offset > 0 {
+			log.Printf("Resuming %s after row %d.\n", filePath, state.Rows)
+			if hasHeader {
+				if record, err := reader.Read(); err == nil {
+					hintRow = isHintRow(record)
+				}
+			}
+			if _, err := localFile.Seek(state.Offset, io.SeekStart); err != nil {
+				return fmt.Errorf("failed to resume CSV file %s: %w", filePath, err)
+			}
+			reader = csv.NewReader(localFile)
+			records = reader
+			baseOffset = state.Offset
+		}
+	}
+
+	var progress *fileProgress
+	var total, done int
+	if state != nil {
+		done = state.Rows
+	}
+	if i.Progress != nil || i.Observer != nil {
+		total, err = countCSVRecords(ctx, src, filePath, hasHeader)
+		if err != nil {
+			return err
+		}
+	}
+	if i.Progress != nil {
+		progress = i.Progress.start(filepath.Base(filePath), total)
+		progress.done = done
+		defer progress.finish()
+	}
+
+	// finishRow records the outcome of the insert of a row; affected is -1 if it is unknown, and
+	// database.UpsertUpdated if the row updated an existing one
+	finishRow := func(row batchedRow, affected int64, err error) error {
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			i.rejectRow(ctx, &database.RowInsertError{TableName: dbInfo.TableName, FilePath: filePath, Line: row.line, Record: row.record, Err: err})
+			summary.RowsRejected++
+			i.afterRow(ctx, dbInfo.TableName, row.csvValues, err)
+			return nil
+		}
+		if affected == 0 {
+			summary.RowsSkipped++
+		} else {
+			if affected == database.UpsertUpdated {
+				summary.RowsUpdated++
+			} else {
+				summary.RowsInserted++
+			}
+			for _, d := range row.deferred {
+				d.filePath, d.line, d.record = filePath, row.line, row.record
+				if err := i.addPendingForeignKey(d); err != nil {
+					return err
+				}
+			}
+		}
+		if watermark != nil {
+			watermark.imported(row.record)
+		}
+		i.afterRow(ctx, dbInfo.TableName, row.csvValues, nil)
+		return nil
+	}
+
+	// Rows are inserted in batches if the database can retry the failed rows of a batch on their
+	// own. The checkpoint only advances past the rows of a batch once the batch is done.
+	tableOpts := i.Tables[dbInfo.TableName]
+	batchSize := i.BatchSize
+	if tableOpts.BatchSize > 0 {
+		batchSize = tableOpts.BatchSize
+	}
+	if sizer, ok := i.DBClient.(database.BatchSizer); ok && batchSize == 0 {
+		batchSize = sizer.DefaultBatchSize(dbInfo)
+	}
+	batcher, _ := i.DBClient.(database.BatchExecutor)
+	if batchSize <= 1 {
+		batcher = nil
+	}
+	parallelism := i.tableParallelism(dbInfo.TableName)
+	if parallelism > 1 && batcher == nil {
+		log.Printf("Warning: Rows of table %s are not inserted in batches; parallelism %d is ignored.\n", dbInfo.TableName, parallelism)
+	}
+	var batch []batchedRow
+	var heldOffsets []int64
+	// Up to parallelism batches are inserted at the same time; their outcomes are recorded in order
+	var running []*runningBatch
+	defer func() {
+		for _, b := range running {
+			<-b.done
+		}
+	}()
+	// finishBatch waits for the oldest running batch and records the outcome of its rows
+	finishBatch := func() error {
+		b := running[0]
+		running = running[1:]
+		<-b.done
+		if b.err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("failed to insert a batch of %d rows into table %s: %w", len(b.rows), dbInfo.TableName, b.err)
+		}
+		for idx, row := range b.rows {
+			if err := finishRow(row, b.results[idx].RowsAffected, b.results[idx].Err); err != nil {
+				return err
+			}
+		}
+		if state == nil {
+			return nil
+		}
+		for _, offset := range b.offsets {
+			if err := i.Checkpoint.advance(state, offset); err != nil {
+				return err
+			}
+		}
+		return i.Checkpoint.commit()
+	}
+	// flush starts inserting the queued rows and waits until at most keep batches are running
+	flush := func(keep int) error {
+		if len(batch) > 0 || len(heldOffsets) > 0 {
+			b := &runningBatch{rows: batch, offsets: heldOffsets, done: make(chan struct{})}
+			running = append(running, b)
+			batch, heldOffsets = nil, nil
+			go func() {
+				defer close(b.done)
+				if len(b.rows) == 0 {
+					return
+				}
+				args := make([][]interface{}, len(b.rows))
+				for idx, row := range b.rows {
+					args[idx] = row.values
+				}
+				b.results, b.err = batcher.ExecBatch(ctx, stmt, args, func(ctx context.Context) (context.Context, func()) {
+					return i.statementContext(ctx, dbInfo.TableName, insertStatement(dbInfo))
+				})
+			}()
+		}
+		for len(running) > keep {
+			if err := finishBatch(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	existingUpdater := i.existingRowUpdater(dbInfo)
+	// A row may reference a row of the same table queued before it, which must be inserted first
+	selfReferencing := false
+	for _, fk := range dbInfo.ForeignKeys {
+		if fk.ForeignTableName == dbInfo.TableName {
+			selfReferencing = true
+		}
+	}
+
+	queued := false // Whether the previous row was queued in batch, with its offset held
 	for {
-		record, err := reader.Read()
+		// The previous row has been committed (or rejected) by now, unless it was queued
+		if state != nil && summary.RowsRead > 0 && !queued {
+			if offset := baseOffset + reader.InputOffset(); len(batch) > 0 || len(running) > 0 {
+				heldOffsets = append(heldOffsets, offset)
+			} else if err := i.Checkpoint.advance(state, offset); err != nil {
+				return err
+			}
+		}
+		queued = false
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if i.Control != nil {
+			if err := i.Control.wait(ctx, dbInfo.TableName); errors.Is(err, ErrTableAborted) {
+				summary.Aborted = true
+				break
+			} else if err != nil {
+				return err
+			}
+		}
+		record, err := records.Read()
 		if err == io.EOF {
 			break
 		}
 		if err != nil {
 			return fmt.Errorf("failed to read CSV record from %s: %w", filePath, err)
 		}
+		var natives []interface{}
+		if arrowRecords != nil {
+			natives = arrowRecords.Values()
+		}
+		if hasHeader && baseOffset == 0 && summary.RowsRead == 0 && !hintRow && isHintRow(record) {
+			hintRow = true
+			continue
+		}
+		if progress != nil {
+			progress.add()
+		}
+		done++
+		if i.Observer != nil {
+			i.Observer.RowsProcessed(dbInfo.TableName, done, total)
+		}
+		summary.RowsRead++
+		// Counted like validation counts lines: the header is line 1
+		line := done
+		if hasHeader {
+			line++
+		}
+		if hintRow {
+			line++
+		}
+		if watermark != nil {
+			newer, err := watermark.newer(record)
+			if err != nil {
+				i.rejectRow(ctx, &database.RowInsertError{TableName: dbInfo.TableName, FilePath: filePath, Line: line, Record: record, Err: err})
+				summary.RowsRejected++
+				continue
+			}
+			if !newer {
+				summary.RowsSkipped++
+				continue
+			}
+		}
 
-		// Prepare values for insertion
-		values := make([]interface{}, len(dbInfo.Columns))
-		for colIdx, colInfo := range dbInfo.Columns {
+		// Prepare values for insertion. Generated columns are computed by the database, so their CSV values are dropped.
+		insertColumns := dbInfo.InsertColumns()
+		csvValues := make(map[string]string, len(insertColumns))
+		// The native values of the columns read from an Arrow IPC input, null values included
+		nativeValues := make(map[string]interface{})
+		for _, colInfo := range insertColumns {
+			if auditVal, ok := i.auditValue(colInfo.ColumnName); ok {
+				csvValues[colInfo.ColumnName] = auditVal
+				continue
+			}
 			csvVal := ""
+			var native interface{}
 			if idx, ok := columnMap[colInfo.ColumnName]; ok && idx < len(record) {
 				csvVal = record[idx]
+				if natives != nil {
+					native = natives[idx]
+					nativeValues[colInfo.ColumnName] = native
+				}
+			}
+			if i.Masker != nil {
+				csvVal, err = i.Masker.Mask(dbInfo.TableName, colInfo, csvVal)
+				if err != nil {
+					return err
+				}
 			}
+			// An empty string of an Arrow IPC input is a value, not a missing one
+			if csvVal == "" && native == nil && i.CellGenerator != nil {
+				generated, ok, err := i.CellGenerator.GenerateString(dbInfo.TableName, colInfo)
+				if err != nil {
+					return err
+				}
+				if ok {
+					csvVal = generated
+				}
+			}
+			if i.Encryptor != nil {
+				csvVal, err = i.Encryptor.Encrypt(ctx, i.DBClient, dbInfo.TableName, colInfo, csvVal)
+				if err != nil {
+					return err
+				}
+			}
+			csvValues[colInfo.ColumnName] = csvVal
+		}
 
-			for _, fk := range dbInfo.ForeignKeys {
-				if fk.ColumnName == colInfo.ColumnName {
-					parentDBInfo, ok := i.DBSchema[fk.ForeignTableName]
-					if !ok {
-						return fmt.Errorf("foreign table %s not found in schema info for foreign key %s", fk.ForeignTableName, fk.ConstraintName)
-					}
+		var rejectErr error
+		if i.Hooks.BeforeRow != nil {
+			err := i.Hooks.BeforeRow(ctx, dbInfo.TableName, csvValues)
+			if errors.Is(err, ErrSkipRow) {
+				summary.RowsSkipped++
+				continue
+			}
+			rejectErr = err
+		}
+
+		if selfReferencing && rejectErr == nil {
+			if err := flush(0); err != nil {
+				return err
+			}
+		}
 
-					fkValue := csvVal
-					if fkValue == "" {
-						continue
-					}
+		// Check (or create) the parent record of each foreign key using all of its columns together
+		var deferred []deferredForeignKey
+		for _, fk := range dbInfo.ForeignKeys {
+			if rejectErr != nil {
+				break
+			}
+			parentDBInfo, ok := i.DBSchema[fk.ForeignTableName]
+			if !ok {
+				return &database.MissingParentTableError{TableName: fk.ForeignTableName, ConstraintName: fk.ConstraintName}
+			}
 
-					err := i.DBClient.EnsureParentRecordExists(parentDBInfo, fk.ForeignColumnName, fkValue, i.DBSchema)
-					if err != nil {
-						return fmt.Errorf("failed to ensure parent record exists for %s.%s (value: %s): %w", fk.ForeignTableName, fk.ForeignColumnName, fkValue, err)
-					}
+			// A key with any empty column references nothing
+			fkValues := make([]string, len(fk.ColumnNames))
+			complete := true
+			for idx, colName := range fk.ColumnNames {
+				fkValues[idx] = csvValues[colName]
+				if fkValues[idx] == "" {
+					complete = false
+				}
+			}
+			if !complete {
+				continue
+			}
+			if d, ok := i.deferForeignKey(dbInfo, fk, csvValues, fkValues); ok {
+				deferred = append(deferred, d)
+				continue
+			}
+
+			if i.NoAutoParents {
+				stmtCtx, cancel := i.statementContext(ctx, fk.ForeignTableName, parentStatement("parent record check on", fk.ForeignTableName, fk.ForeignColumnNames, fkValues))
+				exists, err := i.DBClient.ParentRecordExists(stmtCtx, parentDBInfo, fk.ForeignColumnNames, fkValues)
+				cancel()
+				if err != nil {
+					return fmt.Errorf("failed to check parent record for %s.%v (value: %v): %w", fk.ForeignTableName, fk.ForeignColumnNames, fkValues, err)
+				}
+				if !exists {
+					rejectErr = &database.MissingParentRecordError{TableName: fk.ForeignTableName, ColumnNames: fk.ForeignColumnNames, Values: fkValues}
 					break
 				}
+				continue
 			}
 
-			convertedVal, err := database.ConvertToDBType(csvVal, colInfo.DataType, colInfo.IsNullable, colInfo.ColumnDefault)
+			stmtCtx, cancel := i.statementContext(ctx, fk.ForeignTableName, parentStatement("parent record check or creation on", fk.ForeignTableName, fk.ForeignColumnNames, fkValues))
+			parentKey, err := i.DBClient.EnsureParentRecordExists(stmtCtx, parentDBInfo, fk.ForeignColumnNames, fkValues, i.DBSchema)
+			cancel()
 			if err != nil {
-				log.Printf("Warning: Failed to convert value '%s' for column %s (%s) in table %s: %v. Skipping this value.\n", csvVal, colInfo.ColumnName, colInfo.DataType, dbInfo.TableName, err)
-				values[colIdx] = nil
-			} else {
+				return fmt.Errorf("failed to ensure parent record exists for %s.%v (value: %v): %w", fk.ForeignTableName, fk.ForeignColumnNames, fkValues, err)
+			}
+			// The database may have assigned the parent a different key than the CSV values
+			for idx, colName := range fk.ColumnNames {
+				csvValues[colName] = parentKey[idx]
+			}
+		}
+
+		// Deferred foreign keys are inserted as NULL until their parent records are imported
+		nulled := make(map[string]bool)
+		for _, d := range deferred {
+			for _, colName := range d.fk.ColumnNames {
+				nulled[colName] = true
+			}
+		}
+		values := make([]interface{}, len(insertColumns))
+		if rejectErr == nil {
+			for colIdx, colInfo := range insertColumns {
+				if nulled[colInfo.ColumnName] {
+					continue
+				}
+				var convertedVal interface{}
+				var err error
+				if native, ok := nativeValues[colInfo.ColumnName]; ok && csvValues[colInfo.ColumnName] == record[columnMap[colInfo.ColumnName]] {
+					convertedVal, err = database.ConvertNativeValue(native, csvValues[colInfo.ColumnName], colInfo)
+				} else {
+					convertedVal, err = database.ConvertColumnValue(csvValues[colInfo.ColumnName], colInfo)
+				}
+				if err != nil {
+					rejectErr = fmt.Errorf("column %s: %w", colInfo.ColumnName, err)
+					break
+				}
 				values[colIdx] = convertedVal
 			}
 		}
 
-		_, err = stmt.Exec(values...)
-		if err != nil {
-			log.Printf("Error inserting record into %s from file %s: %v. Record: %v\n", dbInfo.TableName, filePath, err, record)
+		if rejectErr != nil {
+			i.rejectRow(ctx, &database.RowInsertError{TableName: dbInfo.TableName, FilePath: filePath, Line: line, Record: record, Err: rejectErr})
+			summary.RowsRejected++
+			i.afterRow(ctx, dbInfo.TableName, csvValues, rejectErr)
 			continue
 		}
+
+		row := batchedRow{values: values, line: line, record: record, csvValues: csvValues, deferred: deferred}
+		if existingUpdater != nil {
+			key, err := i.existingRowKey(ctx, dbInfo, csvValues)
+			if err != nil {
+				return err
+			}
+			if key != nil {
+				updateErr := i.updateExistingRow(ctx, existingUpdater, dbInfo, key, csvValues, nulled)
+				if err := finishRow(row, database.UpsertUpdated, updateErr); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if batcher != nil {
+			batch = append(batch, row)
+			queued = true
+			if state != nil {
+				heldOffsets = append(heldOffsets, baseOffset+reader.InputOffset())
+			}
+			if len(batch) >= batchSize {
+				if err := flush(parallelism - 1); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		stmtCtx, cancel := i.statementContext(ctx, dbInfo.TableName, insertStatement(dbInfo))
+		result := i.execInsert(stmtCtx, stmt, values)
+		cancel()
+		if err := finishRow(row, result.RowsAffected, result.Err); err != nil {
+			return err
+		}
+	}
+	if err := flush(0); err != nil {
+		return err
+	}
+	if summary.Aborted {
+		// The rest of the file is neither imported nor covered by the watermark, and a resumed run continues it
+		log.Printf("Warning: Import of table %s aborted after %d row(s) of %s.\n", dbInfo.TableName, summary.RowsRead, filePath)
+		return nil
+	}
+
+	if watermark != nil && summary.RowsRejected > 0 {
+		// Advancing past the rejected rows would leave them out of every later run
+		log.Printf("Warning: %d row(s) of table %s were rejected; its watermark is not advanced, so the next run imports them again.\n", summary.RowsRejected, dbInfo.TableName)
+	} else if watermark != nil {
+		if err := i.advanceWatermark(watermark); err != nil {
+			return err
+		}
+	}
+	if state != nil {
+		return i.Checkpoint.complete(state)
+	}
+	return nil
+}
+
+// batchedRow is a row of a CSV file ready to be inserted, with what reporting its outcome needs.
+type batchedRow struct {
+	values    []interface{}
+	line      int
+	record    []string
+	csvValues map[string]string
+	deferred  []deferredForeignKey // Foreign keys inserted as NULL until their parent records are imported
+}
+
+// runningBatch is a batch of rows being inserted, with the checkpoint offsets held until it is done.
+type runningBatch struct {
+	rows    []batchedRow
+	offsets []int64
+	results []database.BatchResult
+	err     error
+	done    chan struct{} // Closed once results and err are set
+}
+
+// execInsert executes the insert statement stmt of prepareInsert with the values of a row.
+func (i *Importer) execInsert(ctx context.Context, stmt *sql.Stmt, values []interface{}) database.BatchResult {
+	if executor, ok := i.DBClient.(database.InsertExecutor); ok {
+		return executor.ExecInsert(ctx, stmt, values)
+	}
+	result, err := stmt.ExecContext(ctx, values...)
+	if err != nil {
+		return database.BatchResult{Err: err}
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		affected = -1
+	}
+	return database.BatchResult{RowsAffected: affected}
+}
+
+// prepareInsert prepares the insert statement of the table of dbInfo in the mode of its TableOptions.
+func (i *Importer) prepareInsert(ctx context.Context, dbInfo database.DBInfo) (*sql.Stmt, error) {
+	mode := i.Tables[dbInfo.TableName].Mode
+	if mode == "" || mode == database.InsertUpsert {
+		return i.DBClient.PrepareInsertStatement(ctx, dbInfo)
+	}
+	inserter, ok := i.DBClient.(database.ModeInserter)
+	if !ok {
+		return nil, fmt.Errorf("import mode %s is not supported by the database", mode)
+	}
+	return inserter.PrepareModeInsertStatement(ctx, dbInfo, mode)
+}
+
+// excludeColumns returns dbInfo with the ExcludeColumns of its table marked as generated, which
+// leaves them out of its inserts. The schema itself is not changed.
+func (i *Importer) excludeColumns(dbInfo database.DBInfo) database.DBInfo {
+	excluded := i.Tables[dbInfo.TableName].ExcludeColumns
+	if len(excluded) == 0 {
+		return dbInfo
+	}
+	dbInfo.Columns = slices.Clone(dbInfo.Columns)
+	for idx, colInfo := range dbInfo.Columns {
+		if slices.ContainsFunc(excluded, func(name string) bool { return strings.EqualFold(name, colInfo.ColumnName) }) {
+			dbInfo.Columns[idx].IsGenerated = true
+		}
 	}
+	return dbInfo
+}
+
+// tableParallelism returns the number of batches of tableName inserted at the same time: the
+// Parallelism of its TableOptions if set, or else Concurrency, and at least 1.
+func (i *Importer) tableParallelism(tableName string) int {
+	if parallelism := i.Tables[tableName].Parallelism; parallelism > 0 {
+		return parallelism
+	}
+	return max(i.Concurrency, 1)
+}
+
+// rejectRow reports a record that could not be inserted.
+func (i *Importer) rejectRow(ctx context.Context, rowErr *database.RowInsertError) {
+	log.Printf("Error: %v\n", rowErr)
+	i.failedRows++
+	i.keepRejected(rowErr)
+	if i.Observer != nil {
+		i.Observer.RowFailed(rowErr)
+	}
+	i.emit(ctx, RowRejected{Err: rowErr})
+}
+
+// afterRow calls the AfterRow hook, if any.
+func (i *Importer) afterRow(ctx context.Context, tableName string, row map[string]string, err error) {
+	if i.Hooks.AfterRow != nil {
+		i.Hooks.AfterRow(ctx, tableName, row, err)
+	}
+}
 
+// refreshMaterializedViews refreshes the materialized views over the given tables.
+func (i *Importer) refreshMaterializedViews(ctx context.Context, tables []string) error {
+	refresher, ok := i.DBClient.(database.ViewRefresher)
+	if !ok || len(tables) == 0 {
+		return nil
+	}
+	views, err := refresher.RefreshMaterializedViews(ctx, tables)
+	for _, view := range views {
+		log.Printf("Refreshed materialized view %s.\n", view)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to refresh materialized views: %w", err)
+	}
 	return nil
 }
 
+// statementContext bounds a single database operation on table by StatementTimeout. The returned
+// function must be called when the operation is done; it warns about the operation, as described
+// by statement, if it took longer than SlowThreshold.
+func (i *Importer) statementContext(ctx context.Context, table string, statement func() string) (context.Context, func()) {
+	cancel := func() {}
+	if i.StatementTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, i.StatementTimeout)
+	}
+	if i.SlowThreshold <= 0 {
+		return ctx, cancel
+	}
+	started := time.Now()
+	return ctx, func() {
+		cancel()
+		if elapsed := time.Since(started); elapsed > i.SlowThreshold {
+			log.Printf("Warning: slow statement on table %s took %s (threshold %s): %s\n", table, elapsed.Round(time.Millisecond), i.SlowThreshold, statement())
+		}
+	}
+}
+
+// insertStatement describes the insert of a row into dbInfo for slow statement warnings.
+func insertStatement(dbInfo database.DBInfo) func() string {
+	return func() string {
+		var cols []string
+		for _, colInfo := range dbInfo.InsertColumns() {
+			cols = append(cols, colInfo.ColumnName)
+		}
+		return fmt.Sprintf("INSERT INTO %s (%s)", dbInfo.TableName, strings.Join(cols, ", "))
+	}
+}
+
+// parentStatement describes a parent record check (or creation) for slow statement warnings.
+func parentStatement(operation, table string, columnNames, values []string) func() string {
+	return func() string {
+		return fmt.Sprintf("%s %s (%s) = (%s)", operation, table, strings.Join(columnNames, ", "), strings.Join(values, ", "))
+	}
+}
+
+// mapCSVFiles maps table names to CSV file paths. A file belongs to the table named after it,
+// unless a table claims it through TableOptions.File.
+func (i *Importer) mapCSVFiles(files []string) map[string]string {
+	claimed := make(map[string]string) // File name to table name
+	for tableName, opts := range i.Tables {
+		if opts.File != "" {
+			claimed[opts.File] = tableName
+		}
+	}
+
+	csvFilesMap := make(map[string]string)
+	for _, filePath := range files {
+		tableName, ok := claimed[filepath.Base(filePath)]
+		if !ok {
+			tableName = strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+			if _, renamed := csvFilesMap[tableName]; renamed {
+				continue
+			}
+		}
+		csvFilesMap[tableName] = filePath
+	}
+	return csvFilesMap
+}
+
+// selectFiles narrows csvFilesMap to SelectedTables, if set. It also returns the tables outside
+// the selection that the selected tables reference, directly or indirectly.
+func (i *Importer) selectFiles(csvFilesMap map[string]string) (map[string]string, []string, error) {
+	if len(i.SelectedTables) == 0 {
+		return csvFilesMap, nil, nil
+	}
+	_, ancestors, err := graph.Subset(i.DBSchema, i.SelectedTables)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid table selection: %w", err)
+	}
+	selected := make(map[string]string, len(i.SelectedTables))
+	for _, tableName := range i.SelectedTables {
+		if filePath, ok := csvFilesMap[tableName]; ok {
+			selected[tableName] = filePath
+		}
+	}
+	return selected, ancestors, nil
+}
+
+// mapCSVHeader maps the table's column names to their index in the CSV header, honoring the
+// table's ColumnMap. It also returns the header names that match no column.
+func (i *Importer) mapCSVHeader(dbInfo database.DBInfo, csvHeader []string) (map[string]int, []string) {
+	aliases := i.Tables[dbInfo.TableName].ColumnMap
+	columnMap := make(map[string]int)
+	var unknown []string
+	for csvIdx, csvColName := range csvHeader {
+		name := csvColName
+		if alias, ok := aliases[csvColName]; ok {
+			name = alias
+		}
+		found := false
+		for _, colInfo := range dbInfo.Columns {
+			if strings.EqualFold(colInfo.ColumnName, name) {
+				if _, dup := columnMap[colInfo.ColumnName]; !dup {
+					columnMap[colInfo.ColumnName] = csvIdx
+				}
+				found = true
+				break
+			}
+		}
+		if !found {
+			unknown = append(unknown, csvColName)
+		}
+	}
+	return columnMap, unknown
+}
+
+// findUnmappedFiles returns the sorted paths of CSV files whose name matches no table in the schema.
+func (i *Importer) findUnmappedFiles(csvFilesMap map[string]string) []string {
+	var unmapped []string
+	for tableName, filePath := range csvFilesMap {
+		if _, ok := i.DBSchema[tableName]; !ok {
+			unmapped = append(unmapped, filePath)
+		}
+	}
+	sort.Strings(unmapped)
+	return unmapped
+}
+
+// getCSVFiles returns the paths of the CSV and Arrow IPC files in dir.
 func getCSVFiles(dir string) ([]string, error) {
 	var csvFiles []string
 	entries, err := os.ReadDir(dir)
@@ -188,7 +1102,7 @@ func getCSVFiles(dir string) ([]string, error) {
 	}
 
 	for _, entry := range entries {
-		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".csv") {
+		if !entry.IsDir() && IsInputFile(entry.Name()) {
 			csvFiles = append(csvFiles, filepath.Join(dir, entry.Name()))
 		}
 	}
@@ -0,0 +1,407 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient is a DBClient keeping the rows of each table in memory, in CSV string form. Inserts
+// run through a database/sql connector, so the importer prepares and executes them as usual.
+type fakeClient struct {
+	database.DBClient
+	schema map[string]database.DBInfo
+	// insertErr, if set, returns the error of inserting row into tableName.
+	insertErr func(tableName string, row map[string]string) error
+	// upsert makes an insert replace the row with the same primary key, reporting it the way MySQL does.
+	upsert bool
+
+	mu       sync.Mutex
+	db       *sql.DB
+	rows     map[string][]map[string]string
+	args     map[string][][]driver.Value // Values of the inserts into each table, as the driver got them
+	inserted []string                    // Tables of the inserted rows, in order, parents included
+	parents  map[string]int
+}
+
+func newFakeClient(schema map[string]database.DBInfo) *fakeClient {
+	c := &fakeClient{schema: schema, rows: make(map[string][]map[string]string), args: make(map[string][][]driver.Value), parents: make(map[string]int)}
+	c.db = sql.OpenDB(fakeConnector{c})
+	return c
+}
+
+func (c *fakeClient) PrepareInsertStatement(ctx context.Context, dbInfo database.DBInfo) (*sql.Stmt, error) {
+	return c.db.PrepareContext(ctx, dbInfo.TableName)
+}
+
+// insert adds a row of tableName with the values of its insert columns and returns the
+// number of affected rows.
+func (c *fakeClient) insert(tableName string, args []driver.Value) (int64, error) {
+	row := make(map[string]string)
+	for idx, colInfo := range c.schema[tableName].InsertColumns() {
+		row[colInfo.ColumnName] = database.FormatValue(args[idx])
+	}
+	if c.insertErr != nil {
+		if err := c.insertErr(tableName, row); err != nil {
+			return 0, err
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.args[tableName] = append(c.args[tableName], args)
+	if c.upsert {
+		primaryKey := c.schema[tableName].PrimaryKeyColumns
+		for idx, existing := range c.rows[tableName] {
+			if !slices.ContainsFunc(primaryKey, func(colName string) bool { return existing[colName] != row[colName] }) {
+				c.rows[tableName][idx] = row
+				return database.UpsertUpdated, nil
+			}
+		}
+	}
+	c.rows[tableName] = append(c.rows[tableName], row)
+	c.inserted = append(c.inserted, tableName)
+	return 1, nil
+}
+
+func (c *fakeClient) find(tableName string, columnNames, values []string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.ContainsFunc(c.rows[tableName], func(row map[string]string) bool {
+		for idx, colName := range columnNames {
+			if row[colName] != values[idx] {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func (c *fakeClient) ParentRecordExists(ctx context.Context, dbInfo database.DBInfo, columnNames, values []string) (bool, error) {
+	return c.find(dbInfo.TableName, columnNames, values), nil
+}
+
+func (c *fakeClient) EnsureParentRecordExists(ctx context.Context, parentDBInfo database.DBInfo, foreignColumnNames, foreignKeyValues []string, dbSchema map[string]database.DBInfo) ([]string, error) {
+	if c.find(parentDBInfo.TableName, foreignColumnNames, foreignKeyValues) {
+		return foreignKeyValues, nil
+	}
+	row := make(map[string]string)
+	for idx, colName := range foreignColumnNames {
+		row[colName] = foreignKeyValues[idx]
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rows[parentDBInfo.TableName] = append(c.rows[parentDBInfo.TableName], row)
+	c.inserted = append(c.inserted, parentDBInfo.TableName)
+	c.parents[parentDBInfo.TableName]++
+	return foreignKeyValues, nil
+}
+
+func (c *fakeClient) CreatedParents() map[string]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	created := make(map[string]int, len(c.parents))
+	for tableName, n := range c.parents {
+		created[tableName] = n
+	}
+	return created
+}
+
+func (c *fakeClient) SetValueGenerator(gen database.ValueGenerator) {}
+
+func (c *fakeClient) SetParentTemplates(templates map[string]map[string]string) {}
+
+func (c *fakeClient) SetBatchColumn(columnName, value string) {}
+
+func (c *fakeClient) SetParentCreatedFunc(fn func(tableName string, columnNames, key []string)) {}
+
+func (c *fakeClient) GetDB() *sql.DB { return c.db }
+
+var _ database.ParentConfigurer = (*fakeClient)(nil)
+
+// fakeConnector opens connections whose statements insert into the tables of a fakeClient.
+// The query of a statement is the name of its table.
+type fakeConnector struct{ c *fakeClient }
+
+func (f fakeConnector) Connect(ctx context.Context) (driver.Conn, error) { return fakeConn(f), nil }
+func (f fakeConnector) Driver() driver.Driver                            { return nil }
+
+type fakeConn struct{ c *fakeClient }
+
+func (f fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return fakeStmt{c: f.c, table: query}, nil
+}
+func (f fakeConn) Close() error { return nil }
+func (f fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+type fakeStmt struct {
+	c     *fakeClient
+	table string
+}
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	affected, err := s.c.insert(s.table, args)
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(affected), nil
+}
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("queries are not supported")
+}
+
+// writeCSVFiles writes the files of contents, keyed by name, to a new directory and returns it.
+func writeCSVFiles(t *testing.T, contents map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range contents {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	return dir
+}
+
+// fakeSchema has users referencing organizations.
+var fakeSchema = map[string]database.DBInfo{
+	"organizations": {
+		TableName:         "organizations",
+		Columns:           []database.ColumnInfo{{ColumnName: "id", DataType: database.IntegerType}, {ColumnName: "name", DataType: database.StringType, IsNullable: true}},
+		PrimaryKeyColumns: []string{"id"},
+	},
+	"users": {
+		TableName: "users",
+		Columns: []database.ColumnInfo{
+			{ColumnName: "id", DataType: database.IntegerType},
+			{ColumnName: "name", DataType: database.StringType},
+			{ColumnName: "org_id", DataType: database.IntegerType, IsNullable: true},
+		},
+		PrimaryKeyColumns: []string{"id"},
+		ForeignKeys:       []database.ForeignKeyInfo{{TableName: "users", ColumnNames: []string{"org_id"}, ForeignTableName: "organizations", ForeignColumnNames: []string{"id"}}},
+	},
+}
+
+func Test_ImportCSVFiles(t *testing.T) {
+	t.Run("挿入できない行があってもエラーにせず結果に数えること", func(t *testing.T) {
+		client := newFakeClient(fakeSchema)
+		client.insertErr = func(tableName string, row map[string]string) error {
+			if row["name"] == "bad" {
+				return errors.New("check constraint violated")
+			}
+			return nil
+		}
+		i := &Importer{DBSchema: fakeSchema, DBClient: client}
+		dir := writeCSVFiles(t, map[string]string{"users.csv": "id,name,org_id\n1,Alice,\n2,bad,\n3,Carol,\n"})
+
+		result, err := i.ImportCSVFiles(context.Background(), dir, true)
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Failed)
+		assert.Equal(t, 1, i.FailedRows())
+		require.Len(t, result.Rejected, 1)
+		assert.ErrorIs(t, result.Rejected[0], database.ErrRowInsert)
+		assert.Equal(t, 2, result.RowsInserted())
+	})
+
+	t.Run("型に変換できない値のある行は拒否されること", func(t *testing.T) {
+		client := newFakeClient(fakeSchema)
+		i := &Importer{DBSchema: fakeSchema, DBClient: client}
+		dir := writeCSVFiles(t, map[string]string{"users.csv": "id,name,org_id\n1,Alice,\nabc,Bob,\n"})
+
+		result, err := i.ImportCSVFiles(context.Background(), dir, true)
+		require.NoError(t, err)
+		require.Len(t, result.Rejected, 1)
+		assert.ErrorIs(t, result.Rejected[0], database.ErrRowInsert)
+		assert.ErrorIs(t, result.Rejected[0], database.ErrConversionFailed)
+		var convErr *database.ConversionError
+		require.ErrorAs(t, result.Rejected[0], &convErr)
+		assert.Equal(t, "abc", convErr.Value)
+		assert.ErrorContains(t, result.Rejected[0], "column id")
+		assert.Equal(t, 1, result.RowsInserted())
+		assert.False(t, client.find("users", []string{"name"}, []string{"Bob"}))
+	})
+
+	t.Run("NoAutoParentsでは親のない行は拒否され親は作成されないこと", func(t *testing.T) {
+		client := newFakeClient(fakeSchema)
+		i := &Importer{DBSchema: fakeSchema, DBClient: client, NoAutoParents: true}
+		dir := writeCSVFiles(t, map[string]string{
+			"organizations.csv": "id,name\n1,Acme\n",
+			"users.csv":         "id,name,org_id\n1,Alice,1\n2,Bob,9\n",
+		})
+
+		result, err := i.ImportCSVFiles(context.Background(), dir, true)
+		require.NoError(t, err)
+		require.Len(t, result.Rejected, 1)
+		assert.ErrorIs(t, result.Rejected[0], database.ErrMissingParent)
+		assert.Equal(t, 1, result.Failed)
+		assert.Empty(t, client.CreatedParents())
+		assert.Len(t, client.rows["organizations"], 1)
+		assert.False(t, client.find("users", []string{"id"}, []string{"2"}))
+		assert.True(t, client.find("users", []string{"id"}, []string{"1"}))
+	})
+
+	t.Run("自動作成した親の数は結果に数えること", func(t *testing.T) {
+		client := newFakeClient(fakeSchema)
+		i := &Importer{DBSchema: fakeSchema, DBClient: client}
+		dir := writeCSVFiles(t, map[string]string{"users.csv": "id,name,org_id\n1,Alice,1\n2,Bob,1\n"})
+
+		result, err := i.ImportCSVFiles(context.Background(), dir, true)
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.ParentsCreated())
+	})
+
+	t.Run("ParentConfigurerを実装しないクライアントでもインポートできること", func(t *testing.T) {
+		client := newFakeClient(fakeSchema)
+		i := &Importer{DBSchema: fakeSchema, DBClient: struct{ database.DBClient }{client}, BatchColumn: "import_batch_id"}
+		dir := writeCSVFiles(t, map[string]string{"users.csv": "id,name,org_id\n1,Alice,1\n"})
+
+		result, err := i.ImportCSVFiles(context.Background(), dir, true)
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.RowsInserted())
+		assert.Zero(t, result.ParentsCreated())
+		assert.True(t, client.find("organizations", []string{"id"}, []string{"1"}))
+	})
+
+	t.Run("先頭の値が#で始まる行もヒント行とせずに全てインポートすること", func(t *testing.T) {
+		client := newFakeClient(fakeSchema)
+		i := &Importer{DBSchema: fakeSchema, DBClient: client}
+		dir := writeCSVFiles(t, map[string]string{"organizations.csv": "name,id\n#general,1\n#random,2\n"})
+
+		result, err := i.ImportCSVFiles(context.Background(), dir, true)
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.RowsInserted())
+		assert.True(t, client.find("organizations", []string{"id", "name"}, []string{"1", "#general"}))
+		assert.True(t, client.find("organizations", []string{"id", "name"}, []string{"2", "#random"}))
+	})
+
+	t.Run("upsertで更新された行は挿入とは別に数えること", func(t *testing.T) {
+		client := newFakeClient(fakeSchema)
+		client.upsert = true
+		client.rows["organizations"] = []map[string]string{{"id": "1", "name": "Acme"}}
+		i := &Importer{DBSchema: fakeSchema, DBClient: client}
+		dir := writeCSVFiles(t, map[string]string{"organizations.csv": "id,name\n1,Acme Inc.\n2,Globex\n"})
+
+		result, err := i.ImportCSVFiles(context.Background(), dir, true)
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.RowsInserted())
+		assert.Equal(t, 1, result.RowsUpdated())
+		assert.True(t, client.find("organizations", []string{"id", "name"}, []string{"1", "Acme Inc."}))
+	})
+
+	t.Run("Arrowの値は元の型のまま挿入されること", func(t *testing.T) {
+		schema := map[string]database.DBInfo{"files": {
+			TableName: "files",
+			Columns: []database.ColumnInfo{
+				{ColumnName: "id", DataType: database.IntegerType},
+				{ColumnName: "name", DataType: database.StringType, IsNullable: true},
+				{ColumnName: "data", DataType: database.UnknownType, IsNullable: true},
+			},
+			PrimaryKeyColumns: []string{"id"},
+		}}
+		client := newFakeClient(schema)
+		i := &Importer{DBSchema: schema, DBClient: client}
+		path := copyArrowTestdata(t, "nulls.arrows", "files.arrows")
+
+		result, err := i.ImportCSVFiles(context.Background(), filepath.Dir(path), true)
+		require.NoError(t, err)
+		assert.Equal(t, 3, result.RowsInserted())
+		assert.Equal(t, [][]driver.Value{
+			{int64(1), "", []byte{0x00, 0xff, ','}},
+			{int64(2), nil, nil},
+			{int64(3), "Carol", []byte{}},
+		}, client.args["files"], "nullと空文字列が区別され、バイト列はそのまま挿入されること")
+	})
+
+	t.Run("テンプレートのヒント行は読み飛ばすこと", func(t *testing.T) {
+		client := newFakeClient(fakeSchema)
+		i := &Importer{DBSchema: fakeSchema, DBClient: client}
+		dir := writeCSVFiles(t, map[string]string{"organizations.csv": "id,name\n" + HintPrefix + "INTEGER; required,STRING\n1,Acme\n"})
+
+		result, err := i.ImportCSVFiles(context.Background(), dir, true)
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.RowsInserted())
+		assert.Len(t, client.rows["organizations"], 1)
+	})
+
+	t.Run("一括挿入できないデータベースではWithConcurrencyの並列度が無視されることが警告されること", func(t *testing.T) {
+		var logs bytes.Buffer
+		output := log.Writer()
+		log.SetOutput(&logs)
+		t.Cleanup(func() { log.SetOutput(output) })
+		client := newFakeClient(fakeSchema)
+		i, err := NewImporter(fakeSchema, client, WithConcurrency(2))
+		require.NoError(t, err)
+		dir := writeCSVFiles(t, map[string]string{"organizations.csv": "id,name\n1,Acme\n"})
+
+		result, err := i.ImportCSVFiles(context.Background(), dir, true)
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.RowsInserted())
+		assert.Contains(t, logs.String(), "Warning: Rows of table organizations are not inserted in batches; parallelism 2 is ignored.")
+	})
+}
+
+func Test_statementContext(t *testing.T) {
+	var logs bytes.Buffer
+	output := log.Writer()
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(output) })
+	dbInfo := database.DBInfo{TableName: "users", Columns: []database.ColumnInfo{{ColumnName: "id"}, {ColumnName: "name"}}}
+
+	t.Run("閾値を超えた操作はテーブルとSQLとともに警告されること", func(t *testing.T) {
+		logs.Reset()
+		i := &Importer{SlowThreshold: time.Millisecond}
+		_, done := i.statementContext(context.Background(), "users", insertStatement(dbInfo))
+		time.Sleep(5 * time.Millisecond)
+		done()
+		assert.Contains(t, logs.String(), "Warning: slow statement on table users took")
+		assert.Contains(t, logs.String(), "INSERT INTO users (id, name)")
+	})
+
+	t.Run("閾値以内の操作は警告されないこと", func(t *testing.T) {
+		logs.Reset()
+		i := &Importer{SlowThreshold: time.Hour}
+		_, done := i.statementContext(context.Background(), "organizations", parentStatement("parent record check on", "organizations", []string{"id"}, []string{"1"}))
+		done()
+		assert.Empty(t, logs.String())
+	})
+
+	t.Run("StatementTimeoutで操作の期限が設定されること", func(t *testing.T) {
+		i := &Importer{StatementTimeout: time.Minute}
+		ctx, done := i.statementContext(context.Background(), "users", insertStatement(dbInfo))
+		defer done()
+		_, ok := ctx.Deadline()
+		assert.True(t, ok)
+	})
+}
+
+func Test_prepareInsert(t *testing.T) {
+	i := &Importer{
+		DBClient: noDBClient{},
+		Tables:   map[string]TableOptions{"events": {Mode: database.InsertOnly}},
+	}
+	_, err := i.prepareInsert(context.Background(), database.DBInfo{TableName: "events"})
+	assert.EqualError(t, err, "import mode insert is not supported by the database", "ModeInserterでないデータベースではエラーとなること")
+}
+
+func Test_excludeColumns(t *testing.T) {
+	dbInfo := database.DBInfo{TableName: "products", Columns: []database.ColumnInfo{{ColumnName: "id"}, {ColumnName: "name"}, {ColumnName: "updated_at"}}}
+	i := &Importer{Tables: map[string]TableOptions{"products": {ExcludeColumns: []string{"UPDATED_AT"}}}}
+
+	excluded := i.excludeColumns(dbInfo)
+	assert.Equal(t, []database.ColumnInfo{{ColumnName: "id"}, {ColumnName: "name"}}, excluded.InsertColumns(), "除外したカラムはINSERTに含まれないこと")
+	assert.Len(t, excluded.Columns, 3, "CSVの列の位置は変わらないこと")
+	assert.False(t, dbInfo.Columns[2].IsGenerated, "スキーマは変更されないこと")
+}
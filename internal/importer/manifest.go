@@ -0,0 +1,178 @@
+package importer
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// ManifestFileName is the name of the manifest in the CSV directory unless another path is given.
+const ManifestFileName = "manifest.json"
+
+// ErrManifestMismatch is returned when the CSV files do not match their manifest.
+var ErrManifestMismatch = errors.New("CSV files do not match the manifest")
+
+// Manifest lists the CSV files of a directory as they were when it was generated, so that an
+// incomplete or corrupted copy of the directory can be detected before it is imported.
+type Manifest struct {
+	CreatedAt time.Time      `json:"created_at"`
+	Files     []ManifestFile `json:"files"`
+}
+
+// ManifestFile describes one CSV file of a Manifest.
+type ManifestFile struct {
+	Name   string `json:"name"` // File name, relative to the directory
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"` // Hex-encoded SHA-256 of the file's contents
+	Rows   int    `json:"rows"`   // Number of records, not counting the header row
+}
+
+// GenerateManifest describes the CSV files in dir. hasHeader tells whether their first record is
+// a header row, which is not counted.
+func GenerateManifest(dir string, hasHeader bool) (*Manifest, error) {
+	files, err := getCSVFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	m := &Manifest{CreatedAt: time.Now().UTC(), Files: []ManifestFile{}}
+	for _, filePath := range files {
+		file, err := describeFile(filePath, hasHeader)
+		if err != nil {
+			return nil, err
+		}
+		m.Files = append(m.Files, file)
+	}
+	return m, nil
+}
+
+// describeFile reads a CSV or Arrow file once to hash it and count its records.
+func describeFile(filePath string, hasHeader bool) (ManifestFile, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return ManifestFile{}, fmt.Errorf("failed to open CSV file %s: %w", filePath, err)
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	counter := &countingReader{r: io.TeeReader(f, hash)}
+	var input io.Reader = counter
+	if isArrowFile(filePath) {
+		converted := newArrowCSVReader(io.NopCloser(counter), hasHeader)
+		defer converted.Close()
+		input = converted
+	}
+	reader := csv.NewReader(input)
+	reader.FieldsPerRecord = -1
+	rows := 0
+	for {
+		_, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return ManifestFile{}, fmt.Errorf("failed to read CSV file %s: %w", filePath, err)
+		}
+		rows++
+	}
+	if hasHeader && rows > 0 {
+		rows--
+	}
+	// Hash the rest of the file, such as the footer of an Arrow file
+	if _, err := io.Copy(io.Discard, counter); err != nil {
+		return ManifestFile{}, fmt.Errorf("failed to read CSV file %s: %w", filePath, err)
+	}
+	return ManifestFile{
+		Name:   filepath.Base(filePath),
+		Size:   counter.n,
+		SHA256: hex.EncodeToString(hash.Sum(nil)),
+		Rows:   rows,
+	}, nil
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// LoadManifest reads the manifest at path.
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest %s: %w", path, err)
+	}
+	m := &Manifest{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", path, err)
+	}
+	return m, nil
+}
+
+// Save writes the manifest to path as indented JSON.
+func (m *Manifest) Save(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write manifest %s: %w", path, err)
+	}
+	return nil
+}
+
+// Verify compares the CSV files in dir with the manifest and returns a description of each
+// difference: a listed file that is missing or whose size, contents or number of rows differ, and
+// a file that is not listed. hasHeader must be the same as when the manifest was generated.
+func (m *Manifest) Verify(dir string, hasHeader bool) ([]string, error) {
+	files, err := getCSVFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	var problems []string
+	for _, want := range m.Files {
+		filePath := filepath.Join(dir, want.Name)
+		if !slices.Contains(files, filePath) {
+			problems = append(problems, fmt.Sprintf("%s: missing", want.Name))
+			continue
+		}
+		got, err := describeFile(filePath, hasHeader)
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			problems = append(problems, fmt.Sprintf("%s: %v (the file may be incomplete)", want.Name, parseErr))
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case got.Size != want.Size:
+			problems = append(problems, fmt.Sprintf("%s: %d bytes and %d rows, expected %d bytes and %d rows (the file may be incomplete)", want.Name, got.Size, got.Rows, want.Size, want.Rows))
+		case got.SHA256 != want.SHA256:
+			problems = append(problems, fmt.Sprintf("%s: SHA-256 %s, expected %s", want.Name, got.SHA256, want.SHA256))
+		case got.Rows != want.Rows:
+			// Only possible if the manifest was generated with another header setting or edited
+			problems = append(problems, fmt.Sprintf("%s: %d rows, expected %d", want.Name, got.Rows, want.Rows))
+		}
+	}
+	for _, filePath := range files {
+		name := filepath.Base(filePath)
+		if !slices.ContainsFunc(m.Files, func(f ManifestFile) bool { return f.Name == name }) {
+			problems = append(problems, fmt.Sprintf("%s: not listed in the manifest", name))
+		}
+	}
+	return problems, nil
+}
@@ -0,0 +1,74 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Manifest(t *testing.T) {
+	write := func(t *testing.T, path, content string) {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	setup := func(t *testing.T) string {
+		dir := t.TempDir()
+		write(t, filepath.Join(dir, "users.csv"), "id,name\n1,Alice\n2,\"Bob\nSmith\"\n")
+		write(t, filepath.Join(dir, "orders.csv"), "id\n")
+		write(t, filepath.Join(dir, "notes.txt"), "not a CSV file")
+		return dir
+	}
+
+	t.Run("CSVファイルのサイズ・ハッシュ・行数を記録すること", func(t *testing.T) {
+		dir := setup(t)
+		m, err := GenerateManifest(dir, true)
+		require.NoError(t, err)
+		require.Len(t, m.Files, 2)
+		assert.Equal(t, "orders.csv", m.Files[0].Name)
+		assert.Equal(t, int64(3), m.Files[0].Size)
+		assert.Equal(t, 0, m.Files[0].Rows)
+		assert.Equal(t, "users.csv", m.Files[1].Name)
+		assert.Equal(t, int64(30), m.Files[1].Size)
+		assert.Equal(t, 2, m.Files[1].Rows)
+		assert.Len(t, m.Files[1].SHA256, 64)
+
+		path := filepath.Join(dir, ManifestFileName)
+		require.NoError(t, m.Save(path))
+		loaded, err := LoadManifest(path)
+		require.NoError(t, err)
+		problems, err := loaded.Verify(dir, true)
+		require.NoError(t, err)
+		assert.Empty(t, problems)
+	})
+
+	t.Run("欠けた・変更された・記載のないファイルを検出すること", func(t *testing.T) {
+		dir := setup(t)
+		m, err := GenerateManifest(dir, true)
+		require.NoError(t, err)
+		require.NoError(t, os.Remove(filepath.Join(dir, "orders.csv")))
+		write(t, filepath.Join(dir, "users.csv"), "id,name\n1,Alice\n2,\"Bob\nSmitt\"\n")
+		write(t, filepath.Join(dir, "tags.csv"), "id\n1\n")
+
+		problems, err := m.Verify(dir, true)
+		require.NoError(t, err)
+		require.Len(t, problems, 3)
+		assert.Equal(t, "orders.csv: missing", problems[0])
+		assert.Contains(t, problems[1], "users.csv: SHA-256")
+		assert.Equal(t, "tags.csv: not listed in the manifest", problems[2])
+	})
+
+	t.Run("途中で切れたファイルを検出すること", func(t *testing.T) {
+		dir := setup(t)
+		m, err := GenerateManifest(dir, true)
+		require.NoError(t, err)
+		write(t, filepath.Join(dir, "users.csv"), "id,name\n1,Alice\n2,\"Bob\n")
+
+		problems, err := m.Verify(dir, true)
+		require.NoError(t, err)
+		require.Len(t, problems, 1)
+		assert.Contains(t, problems[0], "users.csv:")
+		assert.Contains(t, problems[0], "the file may be incomplete")
+	})
+}
@@ -0,0 +1,177 @@
+package importer
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+)
+
+// MaskingMethod names an anonymization transform applied to CSV values during import.
+type MaskingMethod string
+
+const (
+	MaskHash           MaskingMethod = "hash"            // SHA-256 of the (salted) value, hex encoded
+	MaskRedact         MaskingMethod = "redact"          // Fixed replacement text
+	MaskFaker          MaskingMethod = "faker"           // Fake value of the column's kind
+	MaskPreserveFormat MaskingMethod = "preserve-format" // Random letters/digits in the same positions
+)
+
+// MaskingRule declares how a single column is anonymized.
+type MaskingRule struct {
+	Method      MaskingMethod
+	Kind        database.FakeKind // faker: kind of fake value; guessed from the column when empty
+	Replacement string            // redact: replacement text, "***" when empty
+	Salt        string            // hash, preserve-format: mixed into the value before hashing
+}
+
+// Validate reports whether the rule is well-formed.
+func (r MaskingRule) Validate() error {
+	switch r.Method {
+	case MaskHash, MaskRedact, MaskPreserveFormat:
+		return nil
+	case MaskFaker:
+		if r.Kind != "" {
+			if _, err := database.ParseFakeKind(string(r.Kind)); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown masking method '%s' (expected hash, redact, faker or preserve-format)", r.Method)
+	}
+}
+
+// Masker anonymizes CSV values according to per-column rules keyed by "table.column" or "column".
+// hash and preserve-format are deterministic, so equal inputs (e.g. a key and the foreign keys
+// referencing it) stay equal after masking.
+type Masker struct {
+	rules map[string]MaskingRule
+	faker *database.FakeGenerator
+}
+
+// NewMasker creates a Masker. faker is used for MaskFaker rules.
+func NewMasker(rules map[string]MaskingRule, faker *database.FakeGenerator) *Masker {
+	m := &Masker{rules: make(map[string]MaskingRule, len(rules)), faker: faker}
+	for key, rule := range rules {
+		key = strings.ToLower(key)
+		m.rules[key] = rule
+		if rule.Method == MaskFaker && rule.Kind != "" {
+			faker.SetColumnKind(key, rule.Kind)
+		}
+	}
+	return m
+}
+
+// Mask returns the anonymized form of value for the column. Empty values are returned unchanged.
+func (m *Masker) Mask(tableName string, col database.ColumnInfo, value string) (string, error) {
+	if value == "" {
+		return value, nil
+	}
+	rule, ok := m.rule(tableName, col.ColumnName)
+	if !ok {
+		return value, nil
+	}
+
+	switch rule.Method {
+	case MaskHash:
+		sum := sha256.Sum256([]byte(rule.Salt + value))
+		return hex.EncodeToString(sum[:]), nil
+	case MaskRedact:
+		if rule.Replacement == "" {
+			return "***", nil
+		}
+		return rule.Replacement, nil
+	case MaskFaker:
+		generated, err := m.faker.GenerateValue(tableName, col, false)
+		if err != nil {
+			return "", fmt.Errorf("failed to mask %s.%s: %w", tableName, col.ColumnName, err)
+		}
+		return database.FormatValue(generated), nil
+	case MaskPreserveFormat:
+		return preserveFormat(rule.Salt, value), nil
+	default:
+		return "", fmt.Errorf("unknown masking method '%s' for %s.%s", rule.Method, tableName, col.ColumnName)
+	}
+}
+
+// rule returns the rule of a column, preferring one keyed by "table.column".
+func (m *Masker) rule(tableName, colName string) (MaskingRule, bool) {
+	rule, ok := m.rules[strings.ToLower(tableName+"."+colName)]
+	if !ok {
+		rule, ok = m.rules[strings.ToLower(colName)]
+	}
+	return rule, ok
+}
+
+// KeyWarnings describes the key columns of dbSchema (primary, unique and foreign keys and the
+// columns foreign keys reference) masked with faker or redact. Their masked values are not derived
+// from the original ones, so references to them no longer match and unique values may repeat.
+func (m *Masker) KeyWarnings(dbSchema map[string]database.DBInfo) []string {
+	keyColumns := schemaKeyColumns(dbSchema)
+	var warnings []string
+	for _, tableName := range slices.Sorted(maps.Keys(keyColumns)) {
+		for _, colName := range slices.Sorted(maps.Keys(keyColumns[tableName])) {
+			rule, ok := m.rule(tableName, colName)
+			if !ok || rule.Method == MaskHash || rule.Method == MaskPreserveFormat {
+				continue
+			}
+			warnings = append(warnings, fmt.Sprintf("Key column %s.%s is masked with %s; references to it will not match and its values may repeat. Use hash or preserve-format instead.", tableName, colName, rule.Method))
+		}
+	}
+	return warnings
+}
+
+// schemaKeyColumns returns the key columns of each table of dbSchema: the columns of primary,
+// unique and foreign keys, and the columns foreign keys reference.
+func schemaKeyColumns(dbSchema map[string]database.DBInfo) map[string]map[string]bool {
+	keyColumns := make(map[string]map[string]bool)
+	add := func(tableName string, colNames ...string) {
+		if keyColumns[tableName] == nil {
+			keyColumns[tableName] = make(map[string]bool)
+		}
+		for _, colName := range colNames {
+			keyColumns[tableName][colName] = true
+		}
+	}
+	for tableName, dbInfo := range dbSchema {
+		add(tableName, dbInfo.PrimaryKeyColumns...)
+		for _, ukCols := range dbInfo.UniqueKeyColumns {
+			add(tableName, ukCols...)
+		}
+		for _, fk := range dbInfo.ForeignKeys {
+			add(tableName, fk.ColumnNames...)
+			add(fk.ForeignTableName, fk.ForeignColumnNames...)
+		}
+	}
+	return keyColumns
+}
+
+// preserveFormat replaces letters with random letters of the same case and digits with random
+// digits, keeping every other character. The randomness is derived from the value itself.
+func preserveFormat(salt, value string) string {
+	sum := sha256.Sum256([]byte(salt + value))
+	rnd := rand.New(rand.NewPCG(binary.LittleEndian.Uint64(sum[:8]), binary.LittleEndian.Uint64(sum[8:16])))
+
+	var sb strings.Builder
+	for _, r := range value {
+		switch {
+		case unicode.IsDigit(r):
+			sb.WriteRune(rune('0' + rnd.IntN(10)))
+		case unicode.IsUpper(r) && r < unicode.MaxASCII:
+			sb.WriteRune(rune('A' + rnd.IntN(26)))
+		case unicode.IsLower(r) && r < unicode.MaxASCII:
+			sb.WriteRune(rune('a' + rnd.IntN(26)))
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,147 @@
+package importer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ExportCSVFiles_masking(t *testing.T) {
+	schema := map[string]database.DBInfo{
+		"users": {
+			TableName:         "users",
+			Columns:           []database.ColumnInfo{{ColumnName: "id"}, {ColumnName: "email"}},
+			PrimaryKeyColumns: []string{"id"},
+		},
+		"orders": {
+			TableName:         "orders",
+			Columns:           []database.ColumnInfo{{ColumnName: "id"}, {ColumnName: "user_id"}},
+			PrimaryKeyColumns: []string{"id"},
+			ForeignKeys:       []database.ForeignKeyInfo{{ConstraintName: "fk_user", TableName: "orders", ColumnNames: []string{"user_id"}, ForeignTableName: "users", ForeignColumnNames: []string{"id"}}},
+		},
+	}
+	client := &filterClient{rows: map[string][][]string{
+		"users":  {{"1", "alice@example.com"}, {"2", "bob@example.com"}},
+		"orders": {{"10", "1"}, {"11", "2"}},
+	}}
+	masker := NewMasker(map[string]MaskingRule{
+		"users.email": {Method: MaskRedact},
+		"user_id":     {Method: MaskHash},
+		"users.id":    {Method: MaskHash},
+	}, database.NewFakeGenerator())
+	hash := func(value string) string {
+		sum := sha256.Sum256([]byte(value))
+		return hex.EncodeToString(sum[:])
+	}
+	read := func(t *testing.T, path string) string {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	t.Run("書き出す値がマスキングされること", func(t *testing.T) {
+		i := &Importer{DBSchema: schema, DBClient: client, Masker: masker, MaskExports: true}
+		dir := t.TempDir()
+		require.NoError(t, i.ExportCSVFiles(context.Background(), dir, nil))
+		assert.Equal(t, "id,email\n"+hash("1")+",***\n"+hash("2")+",***\n", read(t, filepath.Join(dir, "users.csv")))
+		assert.Equal(t, "id,user_id\n10,"+hash("1")+"\n11,"+hash("2")+"\n", read(t, filepath.Join(dir, "orders.csv")))
+	})
+
+	t.Run("参照される行はマスキング前の値で辿ること", func(t *testing.T) {
+		i := &Importer{DBSchema: schema, DBClient: client, Masker: masker, MaskExports: true}
+		dir := t.TempDir()
+		require.NoError(t, i.ExportCSVFiles(context.Background(), dir, map[string]string{"orders": "id = 11"}))
+		assert.Equal(t, "id,email\n"+hash("2")+",***\n", read(t, filepath.Join(dir, "users.csv")))
+	})
+
+	t.Run("MaskExportsを指定しない場合はマスキングしないこと", func(t *testing.T) {
+		i := &Importer{DBSchema: schema, DBClient: client, Masker: masker}
+		dir := t.TempDir()
+		require.NoError(t, i.ExportCSVFiles(context.Background(), dir, nil))
+		assert.Equal(t, "id,email\n1,alice@example.com\n2,bob@example.com\n", read(t, filepath.Join(dir, "users.csv")))
+	})
+
+	t.Run("マスキングのルールがない場合はエラーになること", func(t *testing.T) {
+		i := &Importer{DBSchema: schema, DBClient: client, MaskExports: true}
+		assert.Error(t, i.ExportCSVFiles(context.Background(), t.TempDir(), nil))
+	})
+}
+
+func Test_Masker_KeyWarnings(t *testing.T) {
+	schema := map[string]database.DBInfo{
+		"users": {TableName: "users", PrimaryKeyColumns: []string{"id"}, UniqueKeyColumns: [][]string{{"email"}}},
+		"orders": {
+			TableName:   "orders",
+			ForeignKeys: []database.ForeignKeyInfo{{ConstraintName: "fk_user", TableName: "orders", ColumnNames: []string{"user_id"}, ForeignTableName: "users", ForeignColumnNames: []string{"id"}}},
+		},
+	}
+	masker := NewMasker(map[string]MaskingRule{
+		"users.id": {Method: MaskHash},
+		"email":    {Method: MaskFaker},
+		"user_id":  {Method: MaskRedact},
+		"name":     {Method: MaskRedact},
+	}, database.NewFakeGenerator())
+
+	warnings := masker.KeyWarnings(schema)
+	require.Len(t, warnings, 2)
+	assert.Contains(t, warnings[0], "orders.user_id is masked with redact")
+	assert.Contains(t, warnings[1], "users.email is masked with faker")
+}
+
+func Test_ImportCSVFiles_masking(t *testing.T) {
+	schema := map[string]database.DBInfo{
+		"users": {
+			TableName:         "users",
+			Columns:           []database.ColumnInfo{{ColumnName: "id", DataType: database.StringType}, {ColumnName: "email", DataType: database.StringType}},
+			PrimaryKeyColumns: []string{"id"},
+		},
+		"orders": {
+			TableName:         "orders",
+			Columns:           []database.ColumnInfo{{ColumnName: "id", DataType: database.IntegerType}, {ColumnName: "user_id", DataType: database.StringType}},
+			PrimaryKeyColumns: []string{"id"},
+			ForeignKeys:       []database.ForeignKeyInfo{{ConstraintName: "fk_user", TableName: "orders", ColumnNames: []string{"user_id"}, ForeignTableName: "users", ForeignColumnNames: []string{"id"}}},
+		},
+	}
+	hash := func(value string) string {
+		sum := sha256.Sum256([]byte(value))
+		return hex.EncodeToString(sum[:])
+	}
+	dir := writeCSVFiles(t, map[string]string{
+		"users.csv":  "id,email\n1,alice@example.com\n",
+		"orders.csv": "id,user_id\n10,1\n",
+	})
+
+	t.Run("挿入する値がマスキングされ外部キーはマスキングされた親と一致すること", func(t *testing.T) {
+		client := newFakeClient(schema)
+		masker := NewMasker(map[string]MaskingRule{
+			"users.email": {Method: MaskRedact},
+			"users.id":    {Method: MaskHash},
+			"user_id":     {Method: MaskHash},
+		}, database.NewFakeGenerator())
+		i := &Importer{DBSchema: schema, DBClient: client, Masker: masker}
+
+		_, err := i.ImportCSVFiles(context.Background(), dir, true)
+		require.NoError(t, err)
+		assert.Equal(t, []map[string]string{{"id": hash("1"), "email": "***"}}, client.rows["users"])
+		assert.Equal(t, []map[string]string{{"id": "10", "user_id": hash("1")}}, client.rows["orders"])
+		assert.Empty(t, client.CreatedParents(), "マスキングされた外部キーで親が見つかること")
+	})
+
+	t.Run("親のキーが同じようにマスキングされない場合は一致しないこと", func(t *testing.T) {
+		client := newFakeClient(schema)
+		masker := NewMasker(map[string]MaskingRule{"user_id": {Method: MaskHash}}, database.NewFakeGenerator())
+		i := &Importer{DBSchema: schema, DBClient: client, Masker: masker}
+
+		_, err := i.ImportCSVFiles(context.Background(), dir, true)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int{"users": 1}, client.CreatedParents())
+		assert.True(t, client.find("users", []string{"id"}, []string{hash("1")}), "マスキングされた値で親が作成されること")
+	})
+}
@@ -0,0 +1,152 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+)
+
+// The buffers an import keeps until its end, the deferred foreign key values and the rejected
+// records of ImportResult.Rejected, are limited to half of MaxMemory; the rest is left to the
+// rows being imported and the database driver. Sizes are estimates of the heap used.
+func (i *Importer) bufferBudget() int64 {
+	return i.MaxMemory / 2
+}
+
+// overBudget reports whether buffering size more bytes would exceed the budget.
+func (i *Importer) overBudget(size int64) bool {
+	return i.MaxMemory > 0 && i.bufferedBytes+size > i.bufferBudget()
+}
+
+// stringsSize estimates the heap used by values.
+func stringsSize(values []string) int64 {
+	size := int64(24 + 16*len(values))
+	for _, v := range values {
+		size += int64(len(v))
+	}
+	return size
+}
+
+func (d deferredForeignKey) size() int64 {
+	return 128 + stringsSize(d.key) + stringsSize(d.values) + stringsSize(d.record) + int64(len(d.filePath))
+}
+
+func rowErrorSize(rowErr *database.RowInsertError) int64 {
+	return 128 + stringsSize(rowErr.Record) + int64(len(rowErr.FilePath))
+}
+
+// keepRejected adds a rejected record to the result, unless the budget is used up: the record is
+// still logged and counted, and the observers are notified of it.
+func (i *Importer) keepRejected(rowErr *database.RowInsertError) {
+	size := rowErrorSize(rowErr)
+	if i.overBudget(size) {
+		if i.rejectedDropped == 0 {
+			log.Printf("Warning: The rejected records exceed the memory limit; further ones are only logged, not kept in the result.\n")
+		}
+		i.rejectedDropped++
+		return
+	}
+	i.bufferedBytes += size
+	i.rejected = append(i.rejected, rowErr)
+}
+
+// addPendingForeignKey buffers a deferred foreign key value. When the budget is used up, the
+// buffered values are spilled to a temporary file, read back by applyDeferredForeignKeys.
+func (i *Importer) addPendingForeignKey(d deferredForeignKey) error {
+	size := d.size()
+	if i.overBudget(size) && len(i.pendingFKs) > 0 {
+		if err := i.spillPendingForeignKeys(); err != nil {
+			return err
+		}
+	}
+	i.bufferedBytes += size
+	i.pendingFKs = append(i.pendingFKs, d)
+	return nil
+}
+
+// spilledForeignKey is a deferredForeignKey in the spill file.
+type spilledForeignKey struct {
+	Table      string   `json:"table"`
+	Constraint string   `json:"constraint"`
+	Key        []string `json:"key"`
+	Values     []string `json:"values"`
+	File       string   `json:"file"`
+	Line       int      `json:"line"`
+	Record     []string `json:"record"`
+}
+
+// spillPendingForeignKeys moves the buffered deferred foreign key values to the spill file.
+func (i *Importer) spillPendingForeignKeys() error {
+	if i.spillFile == nil {
+		file, err := os.CreateTemp("", "db-auto-importer-deferred-*.jsonl")
+		if err != nil {
+			return fmt.Errorf("failed to create spill file for deferred foreign keys: %w", err)
+		}
+		log.Printf("Spilling deferred foreign key values to %s to stay within the memory limit.\n", file.Name())
+		i.spillFile = file
+	}
+	enc := json.NewEncoder(i.spillFile)
+	for _, d := range i.pendingFKs {
+		spilled := spilledForeignKey{Table: d.dbInfo.TableName, Constraint: d.fk.ConstraintName, Key: d.key, Values: d.values, File: d.filePath, Line: d.line, Record: d.record}
+		if err := enc.Encode(spilled); err != nil {
+			return fmt.Errorf("failed to write spill file %s: %w", i.spillFile.Name(), err)
+		}
+		i.bufferedBytes -= d.size()
+	}
+	i.spilledFKs += len(i.pendingFKs)
+	i.pendingFKs = nil
+	return nil
+}
+
+// eachPendingForeignKey calls fn with the spilled deferred foreign key values, in the order they
+// were added, and then with the buffered ones.
+func (i *Importer) eachPendingForeignKey(fn func(d deferredForeignKey) error) error {
+	if i.spillFile != nil {
+		if _, err := i.spillFile.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("failed to read spill file %s: %w", i.spillFile.Name(), err)
+		}
+		dec := json.NewDecoder(i.spillFile)
+		for {
+			var spilled spilledForeignKey
+			if err := dec.Decode(&spilled); err == io.EOF {
+				break
+			} else if err != nil {
+				return fmt.Errorf("failed to read spill file %s: %w", i.spillFile.Name(), err)
+			}
+			dbInfo := i.DBSchema[spilled.Table]
+			idx := slices.IndexFunc(dbInfo.ForeignKeys, func(fk database.ForeignKeyInfo) bool { return fk.ConstraintName == spilled.Constraint })
+			if idx == -1 {
+				return fmt.Errorf("foreign key %s of table %s in spill file %s not found", spilled.Constraint, spilled.Table, i.spillFile.Name())
+			}
+			d := deferredForeignKey{dbInfo: dbInfo, fk: dbInfo.ForeignKeys[idx], key: spilled.Key, values: spilled.Values, filePath: spilled.File, line: spilled.Line, record: spilled.Record}
+			if err := fn(d); err != nil {
+				return err
+			}
+		}
+	}
+	for _, d := range i.pendingFKs {
+		if err := fn(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// clearPendingForeignKeys drops the deferred foreign key values and removes the spill file.
+func (i *Importer) clearPendingForeignKeys() {
+	for _, d := range i.pendingFKs {
+		i.bufferedBytes -= d.size()
+	}
+	i.pendingFKs = nil
+	i.spilledFKs = 0
+	if i.spillFile != nil {
+		i.spillFile.Close()
+		os.Remove(i.spillFile.Name())
+		i.spillFile = nil
+	}
+}
@@ -0,0 +1,61 @@
+package importer
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_memoryLimit(t *testing.T) {
+	fk := database.ForeignKeyInfo{ConstraintName: "fk_mentor", TableName: "employees", ColumnNames: []string{"mentor_id"}, ForeignTableName: "employees"}
+	dbInfo := database.DBInfo{TableName: "employees", PrimaryKeyColumns: []string{"id"}, ForeignKeys: []database.ForeignKeyInfo{fk}}
+
+	t.Run("上限を超えた遅延外部キーは一時ファイルに退避され、順に読み戻されること", func(t *testing.T) {
+		i := &Importer{DBSchema: map[string]database.DBInfo{"employees": dbInfo}, MaxMemory: 1000}
+		for _, id := range []string{"1", "2", "3", "4", "5"} {
+			require.NoError(t, i.addPendingForeignKey(deferredForeignKey{dbInfo: dbInfo, fk: fk, key: []string{id}, values: []string{"9"}, filePath: "employees.csv", line: 2, record: []string{id, "9"}}))
+		}
+		require.NotNil(t, i.spillFile)
+		spillPath := i.spillFile.Name()
+		assert.Positive(t, i.spilledFKs)
+		assert.LessOrEqual(t, i.bufferedBytes, i.bufferBudget())
+
+		var keys []string
+		err := i.eachPendingForeignKey(func(d deferredForeignKey) error {
+			assert.Equal(t, "fk_mentor", d.fk.ConstraintName)
+			assert.Equal(t, []string{"9"}, d.values)
+			keys = append(keys, d.key[0])
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"1", "2", "3", "4", "5"}, keys)
+
+		i.clearPendingForeignKeys()
+		_, err = os.Stat(spillPath)
+		assert.True(t, errors.Is(err, os.ErrNotExist))
+	})
+
+	t.Run("上限を超えた拒否レコードは結果に保持されないこと", func(t *testing.T) {
+		i := &Importer{MaxMemory: 600}
+		for n := 0; n < 5; n++ {
+			i.keepRejected(&database.RowInsertError{TableName: "employees", Record: []string{"1", "9"}})
+		}
+		assert.Len(t, i.rejected, 1)
+		assert.Equal(t, 4, i.rejectedDropped)
+	})
+
+	t.Run("上限がない場合は全て保持されること", func(t *testing.T) {
+		i := &Importer{}
+		for n := 0; n < 5; n++ {
+			i.keepRejected(&database.RowInsertError{TableName: "employees"})
+			require.NoError(t, i.addPendingForeignKey(deferredForeignKey{dbInfo: dbInfo, fk: fk}))
+		}
+		assert.Len(t, i.rejected, 5)
+		assert.Len(t, i.pendingFKs, 5)
+		assert.Nil(t, i.spillFile)
+	})
+}
@@ -0,0 +1,66 @@
+package importer
+
+import "github.com/k-wa-wa/db-auto-importer/internal/database"
+
+// Observer is notified of the progress of a CSV import, for example to drive a progress UI in
+// an application embedding the importer. Its methods are called from the importing goroutine
+// and should return quickly.
+type Observer interface {
+	// FileStarted is called before the CSV file of a table is read.
+	FileStarted(table, filePath string)
+	// RowsProcessed is called after each row with the number of rows processed so far
+	// (including rows processed by an earlier run that is being resumed) and the row count of the file.
+	RowsProcessed(table string, done, total int)
+	// RowFailed is called for each rejected row.
+	RowFailed(err *database.RowInsertError)
+	// FileFinished is called once a file has been processed, with the error that stopped it, if any.
+	FileFinished(summary TableSummary, err error)
+	// ImportFinished is called at the end of an import with the summary of every table.
+	ImportFinished(summaries []TableSummary, err error)
+}
+
+// MultiObserver returns an Observer that notifies each of observers in turn. Nil observers are skipped.
+func MultiObserver(observers ...Observer) Observer {
+	var nonNil multiObserver
+	for _, o := range observers {
+		if o != nil {
+			nonNil = append(nonNil, o)
+		}
+	}
+	if len(nonNil) == 1 {
+		return nonNil[0]
+	}
+	return nonNil
+}
+
+type multiObserver []Observer
+
+func (m multiObserver) FileStarted(table, filePath string) {
+	for _, o := range m {
+		o.FileStarted(table, filePath)
+	}
+}
+
+func (m multiObserver) RowsProcessed(table string, done, total int) {
+	for _, o := range m {
+		o.RowsProcessed(table, done, total)
+	}
+}
+
+func (m multiObserver) RowFailed(err *database.RowInsertError) {
+	for _, o := range m {
+		o.RowFailed(err)
+	}
+}
+
+func (m multiObserver) FileFinished(summary TableSummary, err error) {
+	for _, o := range m {
+		o.FileFinished(summary, err)
+	}
+}
+
+func (m multiObserver) ImportFinished(summaries []TableSummary, err error) {
+	for _, o := range m {
+		o.ImportFinished(summaries, err)
+	}
+}
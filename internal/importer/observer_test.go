@@ -0,0 +1,92 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingObserver records the callbacks of an import in order.
+type recordingObserver struct{ calls []string }
+
+func (o *recordingObserver) FileStarted(table, filePath string) {
+	o.calls = append(o.calls, "FileStarted "+table)
+}
+
+func (o *recordingObserver) RowsProcessed(table string, done, total int) {
+	o.calls = append(o.calls, fmt.Sprintf("RowsProcessed %s %d/%d", table, done, total))
+}
+
+func (o *recordingObserver) RowFailed(err *database.RowInsertError) {
+	o.calls = append(o.calls, fmt.Sprintf("RowFailed %s:%d", err.TableName, err.Line))
+}
+
+func (o *recordingObserver) FileFinished(summary TableSummary, err error) {
+	o.calls = append(o.calls, fmt.Sprintf("FileFinished %s inserted=%d rejected=%d err=%v", summary.Table, summary.RowsInserted, summary.RowsRejected, err))
+}
+
+func (o *recordingObserver) ImportFinished(summaries []TableSummary, err error) {
+	o.calls = append(o.calls, fmt.Sprintf("ImportFinished tables=%d err=%v", len(summaries), err))
+}
+
+func Test_Observer(t *testing.T) {
+	t.Run("ファイルと行ごとに順にコールバックされること", func(t *testing.T) {
+		client := newFakeClient(fakeSchema)
+		client.insertErr = func(tableName string, row map[string]string) error {
+			if row["name"] == "bad" {
+				return errors.New("check constraint violated")
+			}
+			return nil
+		}
+		observer := &recordingObserver{}
+		i := &Importer{DBSchema: fakeSchema, DBClient: client, Observer: observer}
+		dir := writeCSVFiles(t, map[string]string{
+			"organizations.csv": "id,name\n1,Acme\n",
+			"users.csv":         "id,name,org_id\n1,Alice,1\n2,bad,1\n",
+		})
+
+		_, err := i.ImportCSVFiles(context.Background(), dir, true)
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"FileStarted organizations",
+			"RowsProcessed organizations 1/1",
+			"FileFinished organizations inserted=1 rejected=0 err=<nil>",
+			"FileStarted users",
+			"RowsProcessed users 1/2",
+			"RowsProcessed users 2/2",
+			"RowFailed users:3",
+			"FileFinished users inserted=1 rejected=1 err=<nil>",
+			"ImportFinished tables=2 err=<nil>",
+		}, observer.calls)
+	})
+
+	t.Run("中止されたインポートでもImportFinishedがエラーとともに呼ばれること", func(t *testing.T) {
+		observer := &recordingObserver{}
+		i := &Importer{DBSchema: fakeSchema, DBClient: newFakeClient(fakeSchema), Observer: observer}
+		i.Hooks.BeforeTable = func(ctx context.Context, table, filePath string) error {
+			if table == "users" {
+				return errors.New("maintenance window")
+			}
+			return nil
+		}
+		dir := writeCSVFiles(t, map[string]string{
+			"organizations.csv": "id,name\n1,Acme\n",
+			"users.csv":         "id,name,org_id\n1,Alice,1\n",
+		})
+
+		_, err := i.ImportCSVFiles(context.Background(), dir, true)
+		require.Error(t, err)
+		assert.Equal(t, []string{
+			"FileStarted organizations",
+			"RowsProcessed organizations 1/1",
+			"FileFinished organizations inserted=1 rejected=0 err=<nil>",
+			"ImportFinished tables=1 err=failed to import " + filepath.Join(dir, "users.csv") + ": maintenance window",
+		}, observer.calls)
+	})
+}
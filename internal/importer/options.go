@@ -0,0 +1,157 @@
+package importer
+
+import (
+	"time"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/k-wa-wa/db-auto-importer/internal/graph"
+)
+
+// Option configures an Importer created by NewImporter.
+type Option func(*Importer)
+
+// WithValueGenerator sets the generator used to invent values for data generation.
+func WithValueGenerator(gen database.ValueGenerator) Option {
+	return func(i *Importer) { i.ValueGenerator = gen }
+}
+
+// WithCellGenerator fills empty or missing CSV cells of columns that have a generation rule.
+func WithCellGenerator(gen *database.RuleGenerator) Option {
+	return func(i *Importer) { i.CellGenerator = gen }
+}
+
+// WithMasker anonymizes CSV values before they are inserted.
+func WithMasker(masker *Masker) Option {
+	return func(i *Importer) { i.Masker = masker }
+}
+
+// WithMaskedExports anonymizes the values ExportCSVFiles writes with the Masker, so that the
+// exported files hold no personal data.
+func WithMaskedExports() Option {
+	return func(i *Importer) { i.MaskExports = true }
+}
+
+// WithEncryptor encrypts the CSV values of the columns of its rules before they are inserted.
+func WithEncryptor(encryptor *Encryptor) Option {
+	return func(i *Importer) { i.Encryptor = encryptor }
+}
+
+// WithTables sets per-table options, keyed by table name.
+func WithTables(tables map[string]TableOptions) Option {
+	return func(i *Importer) { i.Tables = tables }
+}
+
+// WithAuditColumns fills the given columns of every table with fixed values instead of CSV values.
+func WithAuditColumns(columns map[string]string) Option {
+	return func(i *Importer) { i.AuditColumns = columns }
+}
+
+// WithSelectedTables limits imports to the CSV files of the given tables.
+func WithSelectedTables(tables []string) Option {
+	return func(i *Importer) { i.SelectedTables = tables }
+}
+
+// WithOrderRules adds dependencies the foreign keys do not declare to the import order.
+func WithOrderRules(rules graph.OrderRules) Option {
+	return func(i *Importer) { i.OrderRules = rules }
+}
+
+// WithUnmappedFilePolicy sets how CSV files that do not match any table are handled.
+func WithUnmappedFilePolicy(policy UnmappedFilePolicy) Option {
+	return func(i *Importer) { i.UnmappedFilePolicy = policy }
+}
+
+// WithNoAutoParents rejects records referencing a missing parent instead of creating the parent record.
+func WithNoAutoParents() Option {
+	return func(i *Importer) { i.NoAutoParents = true }
+}
+
+// WithStrictMode makes the importer refuse anything it would otherwise work around: records
+// referencing a missing parent are rejected and CSV files without a table fail the import.
+func WithStrictMode() Option {
+	return func(i *Importer) {
+		i.NoAutoParents = true
+		i.UnmappedFilePolicy = UnmappedFileFail
+	}
+}
+
+// WithBatchSize inserts the CSV rows in transactions of size rows where the database supports it.
+// 0 uses the default batch size of the database for each table.
+func WithBatchSize(size int) Option {
+	return func(i *Importer) { i.BatchSize = size }
+}
+
+// WithConcurrency inserts up to n batches of each table at the same time, each in a transaction on
+// its own connection. The Parallelism of a table's TableOptions overrides it.
+func WithConcurrency(n int) Option {
+	return func(i *Importer) { i.Concurrency = n }
+}
+
+// WithStatementTimeout bounds each insert and each parent record check or creation.
+func WithStatementTimeout(timeout time.Duration) Option {
+	return func(i *Importer) { i.StatementTimeout = timeout }
+}
+
+// WithSlowThreshold logs the inserts and parent record checks that take longer than threshold.
+func WithSlowThreshold(threshold time.Duration) Option {
+	return func(i *Importer) { i.SlowThreshold = threshold }
+}
+
+// WithMaterializedViewRefresh refreshes the materialized views over the written tables after an import.
+func WithMaterializedViewRefresh() Option {
+	return func(i *Importer) { i.RefreshMaterializedViews = true }
+}
+
+// WithRunHistory records each CSV import in the database's _import_runs table.
+func WithRunHistory() Option {
+	return func(i *Importer) { i.RecordRuns = true }
+}
+
+// WithMaxMemory limits the memory of the buffers kept until the end of an import; see Importer.MaxMemory.
+func WithMaxMemory(bytes int64) Option {
+	return func(i *Importer) { i.MaxMemory = bytes }
+}
+
+// WithBatchColumn tags every written row with the id of the run in the given column.
+func WithBatchColumn(column string) Option {
+	return func(i *Importer) { i.BatchColumn = column }
+}
+
+// WithProgress reports the progress of each CSV file.
+func WithProgress(progress *Progress) Option {
+	return func(i *Importer) { i.Progress = progress }
+}
+
+// WithCheckpoint records the progress of each CSV file so an interrupted import can resume.
+func WithCheckpoint(checkpoint *Checkpoint) Option {
+	return func(i *Importer) { i.Checkpoint = checkpoint }
+}
+
+// WithWatermarks makes imports incremental with the watermarks of w, comparing column in the tables
+// that have it unless their TableOptions name another.
+func WithWatermarks(w *Watermarks, column string) Option {
+	return func(i *Importer) {
+		i.Watermarks = w
+		i.WatermarkColumn = column
+	}
+}
+
+// WithHooks sets the callbacks run around each table and row.
+func WithHooks(hooks Hooks) Option {
+	return func(i *Importer) { i.Hooks = hooks }
+}
+
+// WithObserver sets the observer notified of the progress of CSV imports.
+func WithObserver(observer Observer) Option {
+	return func(i *Importer) { i.Observer = observer }
+}
+
+// WithControl sets the TableControl that pauses and aborts the imports of individual tables.
+func WithControl(control *TableControl) Option {
+	return func(i *Importer) { i.Control = control }
+}
+
+// WithEvents sets the channel that receives import events; see Importer.Events.
+func WithEvents(events chan<- Event) Option {
+	return func(i *Importer) { i.Events = events }
+}
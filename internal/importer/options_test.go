@@ -0,0 +1,40 @@
+package importer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NewImporter(t *testing.T) {
+	t.Run("オプションを指定しない場合は既定値になること", func(t *testing.T) {
+		imp, err := NewImporter(nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, UnmappedFileWarn, imp.UnmappedFilePolicy)
+		assert.False(t, imp.NoAutoParents)
+	})
+	t.Run("オプションが順に適用されること", func(t *testing.T) {
+		imp, err := NewImporter(nil, nil, WithStrictMode(), WithUnmappedFilePolicy(UnmappedFileIgnore), WithStatementTimeout(time.Second))
+		require.NoError(t, err)
+		assert.True(t, imp.NoAutoParents)
+		assert.Equal(t, UnmappedFileIgnore, imp.UnmappedFilePolicy)
+		assert.Equal(t, time.Second, imp.StatementTimeout)
+	})
+	t.Run("WithConcurrencyはテーブルごとの並列度の既定値になること", func(t *testing.T) {
+		imp, err := NewImporter(nil, nil, WithConcurrency(4), WithTables(map[string]TableOptions{
+			"orders": {Parallelism: 2},
+			"users":  {Parallelism: 1},
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, 4, imp.Concurrency)
+		assert.Equal(t, 4, imp.tableParallelism("products"))
+		assert.Equal(t, 2, imp.tableParallelism("orders"))
+		assert.Equal(t, 1, imp.tableParallelism("users"))
+
+		imp, err = NewImporter(nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 1, imp.tableParallelism("products"))
+	})
+}
@@ -0,0 +1,283 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/parquet-go/parquet-go"
+)
+
+// parquetNode returns the Parquet type of the values of a column of dataType.
+func parquetNode(dataType database.ColumnDataType) parquet.Node {
+	switch dataType {
+	case database.IntegerType:
+		return parquet.Int(64)
+	case database.FloatType:
+		return parquet.Leaf(parquet.DoubleType)
+	case database.BooleanType:
+		return parquet.Leaf(parquet.BooleanType)
+	case database.DateType:
+		return parquet.Date()
+	case database.TimestampType:
+		return parquet.Timestamp(parquet.Microsecond)
+	default:
+		return parquet.String()
+	}
+}
+
+// orderedGroup is a parquet.Group whose fields are in the order of names rather than sorted, so
+// that the columns of a Parquet file keep the order of the header.
+type orderedGroup struct {
+	parquet.Group
+	names []string
+}
+
+func (g orderedGroup) Fields() []parquet.Field {
+	fields := g.Group.Fields()
+	sort.SliceStable(fields, func(a, b int) bool {
+		return slices.Index(g.names, fields[a].Name()) < slices.Index(g.names, fields[b].Name())
+	})
+	return fields
+}
+
+// parquetRecordWriter writes records as the rows of a Parquet file.
+type parquetRecordWriter struct {
+	writer  *parquet.Writer
+	columns []database.ColumnInfo // Column of each value; those of values without one are strings
+}
+
+func newParquetRecordWriter(w io.Writer, name string, header []string, columns map[int]database.ColumnInfo) (*parquetRecordWriter, error) {
+	group := orderedGroup{Group: parquet.Group{}, names: header}
+	p := &parquetRecordWriter{columns: make([]database.ColumnInfo, len(header))}
+	for idx, key := range header {
+		if _, dup := group.Group[key]; dup {
+			return nil, fmt.Errorf("column %q appears more than once", key)
+		}
+		colInfo, ok := columns[idx]
+		if !ok {
+			colInfo = database.ColumnInfo{ColumnName: key, DataType: database.StringType}
+		}
+		p.columns[idx] = colInfo
+		group.Group[key] = parquet.Optional(parquetNode(colInfo.DataType))
+	}
+	p.writer = parquet.NewWriter(w, parquet.NewSchema(name, group))
+	return p, nil
+}
+
+func (p *parquetRecordWriter) write(record []string, pos string) error {
+	row := make(parquet.Row, len(p.columns))
+	for idx, colInfo := range p.columns {
+		var value string
+		if idx < len(record) {
+			value = record[idx]
+		}
+		v, err := parquetValue(value, colInfo)
+		if err != nil {
+			return fmt.Errorf("%s: %w", pos, err)
+		}
+		definitionLevel := 1
+		if v.IsNull() {
+			definitionLevel = 0
+		}
+		row[idx] = v.Level(0, definitionLevel, idx)
+	}
+	_, err := p.writer.WriteRows([]parquet.Row{row})
+	return err
+}
+
+func (p *parquetRecordWriter) close() error { return p.writer.Close() }
+
+// parquetValue converts a CSV value to the Parquet value of its column's type. Empty values are
+// null, and values that do not fit their column are an error.
+func parquetValue(value string, colInfo database.ColumnInfo) (parquet.Value, error) {
+	if value == "" {
+		return parquet.NullValue(), nil
+	}
+	switch colInfo.DataType {
+	case database.IntegerType, database.FloatType, database.BooleanType, database.DateType, database.TimestampType:
+	default:
+		return parquet.ByteArrayValue([]byte(value)), nil
+	}
+	converted, err := database.ConvertColumnValue(value, colInfo)
+	if err != nil {
+		return parquet.Value{}, fmt.Errorf("value '%s' of column %s does not fit its type %s: %w", value, colInfo.ColumnName, colInfo.DataType, err)
+	}
+	switch v := converted.(type) {
+	case int64:
+		return parquet.Int64Value(v), nil
+	case float64:
+		return parquet.DoubleValue(v), nil
+	case bool:
+		return parquet.BooleanValue(v), nil
+	case time.Time:
+		if colInfo.DataType == database.DateType {
+			days := time.Date(v.Year(), v.Month(), v.Day(), 0, 0, 0, 0, time.UTC).Unix() / (24 * 60 * 60)
+			return parquet.Int32Value(int32(days)), nil
+		}
+		return parquet.Int64Value(v.UnixMicro()), nil
+	default:
+		return parquet.Value{}, fmt.Errorf("value '%s' of column %s converts to unexpected %T", value, colInfo.ColumnName, converted)
+	}
+}
+
+// parquetRecordReader reads the rows of a flat Parquet file as records named by its columns.
+type parquetRecordReader struct {
+	reader   *parquet.Reader
+	filePath string
+	keys     []string
+	types    []parquet.Type // Type of each column
+	rows     []parquet.Row  // Rows read ahead
+	next     int            // Index of the next row in rows
+	row      int            // Number of the last row returned
+}
+
+func newParquetRecordReader(f *os.File, filePath string) (*parquetRecordReader, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filePath, err)
+	}
+	file, err := parquet.OpenFile(f, info.Size())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Parquet file %s: %w", filePath, err)
+	}
+	p := &parquetRecordReader{filePath: filePath}
+	for _, field := range file.Schema().Fields() {
+		if !field.Leaf() || field.Repeated() {
+			return nil, fmt.Errorf("%s: column %s is not a single value; only flat Parquet files can be converted", filePath, field.Name())
+		}
+		p.keys = append(p.keys, field.Name())
+		p.types = append(p.types, field.Type())
+	}
+	p.reader = parquet.NewReader(file)
+	return p, nil
+}
+
+func (p *parquetRecordReader) header() []string { return p.keys }
+
+func (p *parquetRecordReader) pos() string { return fmt.Sprintf("%s: row %d", p.filePath, p.row) }
+
+func (p *parquetRecordReader) read() ([]string, error) {
+	if p.next == len(p.rows) {
+		p.rows = slices.Grow(p.rows[:0], 128)[:128]
+		n, err := p.reader.ReadRows(p.rows)
+		p.rows, p.next = p.rows[:n], 0
+		if n == 0 {
+			if err == nil || err == io.EOF {
+				return nil, io.EOF
+			}
+			return nil, fmt.Errorf("failed to read %s: %w", p.filePath, err)
+		}
+	}
+	row := p.rows[p.next]
+	p.next++
+	p.row++
+	record := make([]string, len(p.keys))
+	for _, v := range row {
+		value, err := formatParquetValue(v, p.types[v.Column()])
+		if err != nil {
+			return nil, fmt.Errorf("%s: column %s: %w", p.pos(), p.keys[v.Column()], err)
+		}
+		record[v.Column()] = value
+	}
+	return record, nil
+}
+
+// julianUnixEpoch is the Julian day of 1970-01-01, the day INT96 timestamps count from.
+const julianUnixEpoch = 2440588
+
+// formatParquetValue returns a Parquet value in the CSV form of its logical type: dates as
+// YYYY-MM-DD, timestamps in RFC 3339 (without a zone when they are not adjusted to UTC), decimals
+// with their scale and UUIDs in their hyphenated form. Null is empty.
+func formatParquetValue(v parquet.Value, typ parquet.Type) (string, error) {
+	if v.IsNull() {
+		return "", nil
+	}
+	if lt := typ.LogicalType(); lt != nil {
+		switch {
+		case lt.Date != nil:
+			return time.Unix(int64(v.Int32())*24*60*60, 0).UTC().Format("2006-01-02"), nil
+		case lt.Timestamp != nil:
+			var t time.Time
+			switch unit := lt.Timestamp.Unit; {
+			case unit.Millis != nil:
+				t = time.UnixMilli(v.Int64())
+			case unit.Micros != nil:
+				t = time.UnixMicro(v.Int64())
+			default:
+				t = time.Unix(0, v.Int64())
+			}
+			if !lt.Timestamp.IsAdjustedToUTC {
+				return t.UTC().Format("2006-01-02 15:04:05.999999999"), nil
+			}
+			return t.UTC().Format(time.RFC3339Nano), nil
+		case lt.Decimal != nil:
+			unscaled := new(big.Int)
+			switch v.Kind() {
+			case parquet.Int32:
+				unscaled.SetInt64(int64(v.Int32()))
+			case parquet.Int64:
+				unscaled.SetInt64(v.Int64())
+			default:
+				b := v.ByteArray()
+				unscaled.SetBytes(b)
+				if len(b) > 0 && b[0]&0x80 != 0 {
+					unscaled.Sub(unscaled, new(big.Int).Lsh(big.NewInt(1), uint(len(b)*8)))
+				}
+			}
+			return formatDecimal(unscaled, int(lt.Decimal.Scale)), nil
+		case lt.UUID != nil:
+			b := v.ByteArray()
+			if len(b) != 16 {
+				return "", fmt.Errorf("UUID of %d bytes", len(b))
+			}
+			return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+		case lt.Integer != nil && !lt.Integer.IsSigned:
+			if v.Kind() == parquet.Int32 {
+				return strconv.FormatUint(uint64(v.Uint32()), 10), nil
+			}
+			return strconv.FormatUint(v.Uint64(), 10), nil
+		}
+	}
+	switch v.Kind() {
+	case parquet.Boolean:
+		return strconv.FormatBool(v.Boolean()), nil
+	case parquet.Int32:
+		return strconv.FormatInt(int64(v.Int32()), 10), nil
+	case parquet.Int64:
+		return strconv.FormatInt(v.Int64(), 10), nil
+	case parquet.Int96:
+		// Legacy timestamps: nanoseconds of the day in the low 64 bits, the Julian day in the high 32
+		i96 := v.Int96()
+		t := time.Unix((int64(i96[2])-julianUnixEpoch)*24*60*60, i96.Int64())
+		return t.UTC().Format(time.RFC3339Nano), nil
+	case parquet.Float:
+		return strconv.FormatFloat(float64(v.Float()), 'g', -1, 32), nil
+	case parquet.Double:
+		return strconv.FormatFloat(v.Double(), 'g', -1, 64), nil
+	default:
+		return string(v.ByteArray()), nil
+	}
+}
+
+// formatDecimal returns unscaled / 10^scale in decimal notation.
+func formatDecimal(unscaled *big.Int, scale int) string {
+	digits := new(big.Int).Abs(unscaled).String()
+	if scale > 0 {
+		if len(digits) <= scale {
+			digits = strings.Repeat("0", scale-len(digits)+1) + digits
+		}
+		digits = digits[:len(digits)-scale] + "." + digits[len(digits)-scale:]
+	}
+	if unscaled.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
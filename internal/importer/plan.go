@@ -0,0 +1,63 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/k-wa-wa/db-auto-importer/internal/graph"
+)
+
+// ImportPlan is what ImportSource would do with a source, worked out without writing anything.
+type ImportPlan struct {
+	// Tables lists every table of the schema in import order, or with SelectedTables set, the
+	// selected tables and the tables they reference.
+	Tables []PlannedTable
+	// ReferencedTables are the tables outside SelectedTables that the selected tables reference.
+	ReferencedTables []string
+	// DeferredForeignKeys are the foreign keys whose values are set after all tables, to break a cycle.
+	DeferredForeignKeys []database.ForeignKeyInfo
+	// UnmappedFiles are the inputs that match no table, sorted.
+	UnmappedFiles []string
+}
+
+// PlannedTable is a table of an ImportPlan.
+type PlannedTable struct {
+	Table string
+	// File is the input the table is imported from, or empty if the source has none for it.
+	File string
+}
+
+func (t PlannedTable) String() string {
+	if t.File == "" {
+		return t.Table + " (no file)"
+	}
+	return fmt.Sprintf("%s (%s)", t.Table, t.File)
+}
+
+// PlanImport returns the order the inputs of src would be imported in by ImportSource.
+func (i *Importer) PlanImport(ctx context.Context, src Source) (*ImportPlan, error) {
+	files, err := src.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get CSV files: %w", err)
+	}
+	csvFilesMap := i.mapCSVFiles(files)
+
+	order, deferred, err := graph.ImportOrder(i.DBSchema, i.OrderRules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine import order: %w", err)
+	}
+	plan := &ImportPlan{DeferredForeignKeys: deferred, UnmappedFiles: i.findUnmappedFiles(csvFilesMap)}
+	csvFilesMap, plan.ReferencedTables, err = i.selectFiles(csvFilesMap)
+	if err != nil {
+		return nil, err
+	}
+	for _, tableName := range order {
+		if len(i.SelectedTables) > 0 && !slices.Contains(i.SelectedTables, tableName) && !slices.Contains(plan.ReferencedTables, tableName) {
+			continue
+		}
+		plan.Tables = append(plan.Tables, PlannedTable{Table: tableName, File: csvFilesMap[tableName]})
+	}
+	return plan, nil
+}
@@ -0,0 +1,36 @@
+package importer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_PlanImport(t *testing.T) {
+	schema := map[string]database.DBInfo{
+		"users": {TableName: "users"},
+		"posts": {
+			TableName:   "posts",
+			ForeignKeys: []database.ForeignKeyInfo{{ConstraintName: "fk_user", TableName: "posts", ForeignTableName: "users"}},
+		},
+	}
+	i := &Importer{DBSchema: schema}
+
+	plan, err := i.PlanImport(context.Background(), FileSource([]string{"data/posts.csv", "data/comments.csv"}))
+	require.NoError(t, err)
+	assert.Equal(t, []PlannedTable{{Table: "users"}, {Table: "posts", File: "data/posts.csv"}}, plan.Tables)
+	assert.Equal(t, []string{"data/comments.csv"}, plan.UnmappedFiles)
+	assert.Equal(t, "users (no file)", plan.Tables[0].String())
+
+	t.Run("選択したテーブルと参照先だけが含まれること", func(t *testing.T) {
+		schema["tags"] = database.DBInfo{TableName: "tags"}
+		i := &Importer{DBSchema: schema, SelectedTables: []string{"posts"}}
+		plan, err := i.PlanImport(context.Background(), FileSource([]string{"data/posts.csv", "data/tags.csv"}))
+		require.NoError(t, err)
+		assert.Equal(t, []PlannedTable{{Table: "users"}, {Table: "posts", File: "data/posts.csv"}}, plan.Tables)
+		assert.Equal(t, []string{"users"}, plan.ReferencedTables)
+	})
+}
@@ -0,0 +1,131 @@
+package importer
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Progress reports how far the import of each CSV file has come. On a terminal it redraws a
+// single status line; otherwise it writes a plain line at a fixed interval, which suits CI logs.
+type Progress struct {
+	w   io.Writer
+	tty bool
+	// Interval is the minimum time between two reports.
+	Interval time.Duration
+
+	now func() time.Time // Replaced in tests
+}
+
+// NewProgress creates a Progress writing to w. tty selects the redrawn status line.
+func NewProgress(w io.Writer, tty bool) *Progress {
+	interval := 10 * time.Second
+	if tty {
+		interval = 200 * time.Millisecond
+	}
+	return &Progress{w: w, tty: tty, Interval: interval, now: time.Now}
+}
+
+// IsTerminal reports whether f is a character device such as an interactive terminal.
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// fileProgress tracks the rows of one file.
+type fileProgress struct {
+	p          *Progress
+	name       string
+	total      int
+	done       int
+	started    time.Time
+	lastReport time.Time
+}
+
+// start begins reporting for a file with total data rows.
+func (p *Progress) start(name string, total int) *fileProgress {
+	now := p.now()
+	return &fileProgress{p: p, name: name, total: total, started: now, lastReport: now}
+}
+
+// add records one processed row and reports if the interval has passed.
+func (f *fileProgress) add() {
+	f.done++
+	if now := f.p.now(); now.Sub(f.lastReport) >= f.p.Interval {
+		f.lastReport = now
+		f.report(now)
+	}
+}
+
+// finish writes the final report for the file.
+func (f *fileProgress) finish() {
+	f.report(f.p.now())
+	if f.p.tty {
+		fmt.Fprintln(f.p.w)
+	}
+}
+
+func (f *fileProgress) report(now time.Time) {
+	line := f.status(now.Sub(f.started))
+	if f.p.tty {
+		fmt.Fprintf(f.p.w, "\r%s\033[K", line) // Clear the rest of the previous, possibly longer, line
+		return
+	}
+	fmt.Fprintln(f.p.w, line)
+}
+
+// status formats rows done / total, throughput and the estimated time remaining.
+func (f *fileProgress) status(elapsed time.Duration) string {
+	percent := 100
+	if f.total > 0 {
+		percent = f.done * 100 / f.total
+	}
+	rate := 0.0
+	if elapsed > 0 {
+		rate = float64(f.done) / elapsed.Seconds()
+	}
+	eta := "--"
+	if f.done >= f.total {
+		eta = "0s"
+	} else if rate > 0 {
+		eta = time.Duration(float64(f.total-f.done) / rate * float64(time.Second)).Round(time.Second).String()
+	}
+	return fmt.Sprintf("%s: %d/%d rows (%d%%), %.0f rows/s, ETA %s", f.name, f.done, f.total, percent, rate, eta)
+}
+
+// countCSVRecords returns the number of data rows in a CSV input, excluding the header row and
+// the hint row of a CSV template.
+// Malformed lines are counted too, so the total matches the rows the import will visit.
+func countCSVRecords(ctx context.Context, src Source, filePath string, hasHeader bool) (int, error) {
+	file, err := openInput(ctx, src, filePath, hasHeader)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open CSV file %s: %w", filePath, err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	count := 0
+	hintRow := false
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if _, ok := err.(*csv.ParseError); err != nil && !ok {
+			return 0, fmt.Errorf("failed to read CSV file %s: %w", filePath, err)
+		}
+		if hasHeader && count == 1 && !hintRow && err == nil && isHintRow(record) {
+			hintRow = true // The hint row of a CSV template
+			continue
+		}
+		count++
+	}
+	if hasHeader && count > 0 {
+		count--
+	}
+	return count, nil
+}
@@ -0,0 +1,66 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_fileProgress(t *testing.T) {
+	t.Run("行数、速度、残り時間が表示されること", func(t *testing.T) {
+		f := &fileProgress{name: "users.csv", total: 100, done: 25}
+		assert.Equal(t, "users.csv: 25/100 rows (25%), 5 rows/s, ETA 15s", f.status(5*time.Second))
+	})
+
+	t.Run("速度が分からない場合は残り時間を表示しないこと", func(t *testing.T) {
+		f := &fileProgress{name: "users.csv", total: 100}
+		assert.Equal(t, "users.csv: 0/100 rows (0%), 0 rows/s, ETA --", f.status(0))
+	})
+
+	t.Run("端末以外では間隔ごとに1行ずつ出力されること", func(t *testing.T) {
+		var buf bytes.Buffer
+		clock := time.Unix(0, 0)
+		p := NewProgress(&buf, false)
+		p.now = func() time.Time { return clock }
+
+		f := p.start("users.csv", 3)
+		clock = clock.Add(5 * time.Second)
+		f.add()
+		clock = clock.Add(5 * time.Second)
+		f.add()
+		f.add()
+		f.finish()
+		assert.Equal(t, "users.csv: 2/3 rows (66%), 0 rows/s, ETA 5s\nusers.csv: 3/3 rows (100%), 0 rows/s, ETA 0s\n", buf.String())
+	})
+}
+
+func Test_countCSVRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.csv")
+	require.NoError(t, os.WriteFile(path, []byte("id,name\n1,\"multi\nline\"\n2,b\n"), 0o644))
+
+	t.Run("ヘッダー行を除いたレコード数を返すこと", func(t *testing.T) {
+		count, err := countCSVRecords(context.Background(), FileSource{path}, path, true)
+		require.NoError(t, err)
+		assert.Equal(t, 2, count)
+	})
+
+	t.Run("ヘッダーなしの場合は全てのレコードを数えること", func(t *testing.T) {
+		count, err := countCSVRecords(context.Background(), FileSource{path}, path, false)
+		require.NoError(t, err)
+		assert.Equal(t, 3, count)
+	})
+
+	t.Run("テンプレートのヒント行は数えないこと", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "users.csv")
+		require.NoError(t, os.WriteFile(path, []byte("id,name\n#db-auto-importer-hint: INTEGER,TEXT\n#1,a\n"), 0o644))
+		count, err := countCSVRecords(context.Background(), FileSource{path}, path, true)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+	})
+}
@@ -0,0 +1,58 @@
+package importer
+
+import (
+	"time"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+)
+
+// ImportResult describes the outcome of ImportCSVFiles or ImportFiles.
+type ImportResult struct {
+	// RunID identifies the run in the _import_runs table and in Importer.BatchColumn; it is empty
+	// unless one of them is set.
+	RunID string
+	// Tables holds the per-table summaries, as returned by Importer.Summary.
+	Tables []TableSummary
+	// Rejected holds the records that could not be inserted, in the order they were read.
+	Rejected []*database.RowInsertError
+	// Failed is the number of records that could not be inserted, including those left out of
+	// Rejected by MaxMemory. Rejected records do not make the import return an error.
+	Failed  int
+	Elapsed time.Duration
+}
+
+// RowsInserted returns the number of rows inserted into all tables.
+func (r *ImportResult) RowsInserted() int {
+	n := 0
+	for _, s := range r.Tables {
+		n += s.RowsInserted
+	}
+	return n
+}
+
+// RowsUpdated returns the number of existing rows updated in all tables.
+func (r *ImportResult) RowsUpdated() int {
+	n := 0
+	for _, s := range r.Tables {
+		n += s.RowsUpdated
+	}
+	return n
+}
+
+// RowsRejected returns the number of rows rejected across all tables.
+func (r *ImportResult) RowsRejected() int {
+	n := 0
+	for _, s := range r.Tables {
+		n += s.RowsRejected
+	}
+	return n
+}
+
+// ParentsCreated returns the number of parent records created automatically in all tables.
+func (r *ImportResult) ParentsCreated() int {
+	n := 0
+	for _, s := range r.Tables {
+		n += s.ParentsCreated
+	}
+	return n
+}
@@ -0,0 +1,20 @@
+package importer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ImportResult(t *testing.T) {
+	t.Run("全テーブルの件数が合計されること", func(t *testing.T) {
+		result := &ImportResult{Tables: []TableSummary{
+			{Table: "users", RowsInserted: 3, RowsRejected: 1},
+			{Table: "orders", RowsInserted: 5, RowsRejected: 2, ParentsCreated: 1},
+			{Table: "products", ParentsCreated: 4},
+		}}
+		assert.Equal(t, 8, result.RowsInserted())
+		assert.Equal(t, 3, result.RowsRejected())
+		assert.Equal(t, 5, result.ParentsCreated())
+	})
+}
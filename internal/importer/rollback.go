@@ -0,0 +1,108 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+)
+
+// PlanRollback returns the plan to delete the rows a run tagged in BatchColumn: the tables with
+// the column, each before the tables it references. Cleared holds the foreign keys on a cycle
+// between them, and Referencing the tables without the column that reference them.
+func (i *Importer) PlanRollback() (*TruncatePlan, error) {
+	if i.BatchColumn == "" {
+		return nil, errors.New("no batch column is set; only the rows of runs tagged with one can be rolled back")
+	}
+	var tables []string
+	for tableName, dbInfo := range i.DBSchema {
+		if i.batchColumnOf(dbInfo) != "" {
+			tables = append(tables, tableName)
+		}
+	}
+	if len(tables) == 0 {
+		return nil, fmt.Errorf("no table has the batch column %s", i.BatchColumn)
+	}
+	return i.planDeletion(tables)
+}
+
+// RollbackRun deletes the rows of the tables of plan whose BatchColumn holds runID, i.e. the rows
+// the run inserted and the parent records it created, and returns their number. The rows a run
+// updated keep the BatchColumn of the run that inserted them (see existingRowUpdater), so they are
+// left in place with their new values. The columns of the Cleared foreign keys are set to NULL in
+// these rows first. If the database supports it, everything is done in one transaction, so that a
+// failure deletes nothing.
+func (i *Importer) RollbackRun(ctx context.Context, plan *TruncatePlan, runID string) (int64, error) {
+	deleter, ok := i.DBClient.(database.RowDeleter)
+	if !ok {
+		return 0, errors.New("deleting rows is not supported by the database")
+	}
+	var tx database.RowDeletion
+	if txDeleter, ok := deleter.(database.TxRowDeleter); ok {
+		var err error
+		tx, err = txDeleter.BeginRowDeletion(ctx)
+		switch {
+		case errors.Is(err, errors.ErrUnsupported):
+			log.Printf("Warning: The rows are not deleted in one transaction: %v\n", err)
+		case err != nil:
+			return 0, err
+		default:
+			deleter = tx
+		}
+	}
+	total, err := i.deleteRunRows(ctx, deleter, plan, runID)
+	if tx == nil {
+		return total, err
+	}
+	if err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			log.Printf("Warning: Failed to roll back the deletion: %v\n", rollbackErr)
+		}
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit the deletion: %w", err)
+	}
+	return total, nil
+}
+
+// deleteRunRows clears the Cleared foreign keys and deletes the rows of runID with deleter.
+func (i *Importer) deleteRunRows(ctx context.Context, deleter database.RowDeleter, plan *TruncatePlan, runID string) (int64, error) {
+	filter := func(dbInfo database.DBInfo) database.RowFilter {
+		return database.RowFilter{KeyColumns: []string{i.batchColumnOf(dbInfo)}, Keys: [][]string{{runID}}}
+	}
+	for _, fk := range plan.Cleared {
+		dbInfo := i.DBSchema[fk.TableName]
+		if err := deleter.ClearColumns(ctx, dbInfo, fk.ColumnNames, filter(dbInfo)); err != nil {
+			return 0, err
+		}
+	}
+	var total int64
+	for _, tableName := range plan.Tables {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+		dbInfo := i.DBSchema[tableName]
+		deleted, err := deleter.DeleteRows(ctx, dbInfo, filter(dbInfo))
+		if err != nil {
+			return total, err
+		}
+		if deleted > 0 {
+			log.Printf("Deleted %d row(s) of run %s from table %s.\n", deleted, runID, tableName)
+		}
+		total += deleted
+	}
+	return total, nil
+}
+
+// batchColumnOf returns the name of the BatchColumn in a table, or "" if the table does not have it.
+func (i *Importer) batchColumnOf(dbInfo database.DBInfo) string {
+	for _, colInfo := range dbInfo.Columns {
+		if i.isBatchColumn(colInfo.ColumnName) {
+			return colInfo.ColumnName
+		}
+	}
+	return ""
+}
@@ -0,0 +1,122 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rollbackClient records the filters RollbackRun deletes rows by.
+type rollbackClient struct {
+	database.DBClient
+	filters map[string]database.RowFilter
+	deleted []string
+}
+
+func (c *rollbackClient) DeleteRows(ctx context.Context, dbInfo database.DBInfo, filter database.RowFilter) (int64, error) {
+	c.deleted = append(c.deleted, dbInfo.TableName)
+	c.filters[dbInfo.TableName] = filter
+	return 2, nil
+}
+
+func (c *rollbackClient) ClearColumns(ctx context.Context, dbInfo database.DBInfo, columnNames []string, filter database.RowFilter) error {
+	return nil
+}
+
+// txRollbackClient deletes rows in a transaction, failing to delete from failTable.
+type txRollbackClient struct {
+	*rollbackClient
+	failTable             string
+	committed, rolledBack bool
+}
+
+func (c *txRollbackClient) BeginRowDeletion(ctx context.Context) (database.RowDeletion, error) {
+	return &txRollbackDeletion{c}, nil
+}
+
+type txRollbackDeletion struct{ *txRollbackClient }
+
+func (d *txRollbackDeletion) DeleteRows(ctx context.Context, dbInfo database.DBInfo, filter database.RowFilter) (int64, error) {
+	if dbInfo.TableName == d.failTable {
+		return 0, errors.New("foreign key violation")
+	}
+	return d.rollbackClient.DeleteRows(ctx, dbInfo, filter)
+}
+
+func (d *txRollbackDeletion) Commit() error   { d.committed = true; return nil }
+func (d *txRollbackDeletion) Rollback() error { d.rolledBack = true; return nil }
+
+func Test_RollbackRun(t *testing.T) {
+	schema := map[string]database.DBInfo{
+		"users": {
+			TableName:         "users",
+			Columns:           []database.ColumnInfo{{ColumnName: "id"}, {ColumnName: "Import_Batch_Id", IsNullable: true}},
+			PrimaryKeyColumns: []string{"id"},
+		},
+		"orders": {
+			TableName:         "orders",
+			Columns:           []database.ColumnInfo{{ColumnName: "id"}, {ColumnName: "user_id"}, {ColumnName: "import_batch_id", IsNullable: true}},
+			PrimaryKeyColumns: []string{"id"},
+			ForeignKeys:       []database.ForeignKeyInfo{{TableName: "orders", ColumnNames: []string{"user_id"}, ForeignTableName: "users", ForeignColumnNames: []string{"id"}}},
+		},
+		"reviews": {
+			TableName:         "reviews",
+			Columns:           []database.ColumnInfo{{ColumnName: "id"}, {ColumnName: "user_id"}},
+			PrimaryKeyColumns: []string{"id"},
+			ForeignKeys:       []database.ForeignKeyInfo{{TableName: "reviews", ColumnNames: []string{"user_id"}, ForeignTableName: "users", ForeignColumnNames: []string{"id"}}},
+		},
+	}
+
+	t.Run("バッチカラムを持つテーブルから実行の行を参照する側から順に削除すること", func(t *testing.T) {
+		client := &rollbackClient{filters: make(map[string]database.RowFilter)}
+		i := &Importer{DBSchema: schema, DBClient: client, BatchColumn: "import_batch_id"}
+		plan, err := i.PlanRollback()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"orders", "users"}, plan.Tables)
+		assert.Equal(t, map[string][]string{"users": {"reviews"}}, plan.Referencing)
+
+		deleted, err := i.RollbackRun(context.Background(), plan, "run-1")
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), deleted)
+		assert.Equal(t, []string{"orders", "users"}, client.deleted)
+		assert.Equal(t, database.RowFilter{KeyColumns: []string{"Import_Batch_Id"}, Keys: [][]string{{"run-1"}}}, client.filters["users"])
+	})
+
+	t.Run("トランザクションに対応している場合は1つのトランザクションで削除すること", func(t *testing.T) {
+		client := &txRollbackClient{rollbackClient: &rollbackClient{filters: make(map[string]database.RowFilter)}}
+		i := &Importer{DBSchema: schema, DBClient: client, BatchColumn: "import_batch_id"}
+		plan, err := i.PlanRollback()
+		require.NoError(t, err)
+
+		deleted, err := i.RollbackRun(context.Background(), plan, "run-1")
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), deleted)
+		assert.True(t, client.committed)
+		assert.False(t, client.rolledBack)
+	})
+
+	t.Run("途中で失敗した場合はトランザクションをロールバックすること", func(t *testing.T) {
+		client := &txRollbackClient{rollbackClient: &rollbackClient{filters: make(map[string]database.RowFilter)}, failTable: "users"}
+		i := &Importer{DBSchema: schema, DBClient: client, BatchColumn: "import_batch_id"}
+		plan, err := i.PlanRollback()
+		require.NoError(t, err)
+
+		deleted, err := i.RollbackRun(context.Background(), plan, "run-1")
+		assert.ErrorContains(t, err, "foreign key violation")
+		assert.Zero(t, deleted)
+		assert.Equal(t, []string{"orders"}, client.deleted)
+		assert.True(t, client.rolledBack)
+		assert.False(t, client.committed)
+	})
+
+	t.Run("バッチカラムがない場合はエラーになること", func(t *testing.T) {
+		_, err := (&Importer{DBSchema: schema}).PlanRollback()
+		assert.Error(t, err)
+		_, err = (&Importer{DBSchema: schema, BatchColumn: "created_by"}).PlanRollback()
+		assert.ErrorContains(t, err, "no table has the batch column created_by")
+	})
+}
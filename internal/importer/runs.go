@@ -0,0 +1,57 @@
+package importer
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/k-wa-wa/db-auto-importer/internal/version"
+)
+
+// newRunID returns a random identifier for an import run.
+func newRunID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// recordRun adds a row for the run to the database's import run history. A failure is only
+// logged: the rows of the run are already written, and the history must not fail the import.
+func (i *Importer) recordRun(ctx context.Context, runID string, started time.Time, csvFilesMap map[string]string, err error) {
+	run := database.ImportRun{
+		RunID:      runID,
+		StartedAt:  started,
+		FinishedAt: time.Now(),
+		Version:    version.String(),
+		Outcome:    "success",
+	}
+	for _, filePath := range csvFilesMap {
+		run.Files = append(run.Files, filePath)
+	}
+	sort.Strings(run.Files)
+	for _, s := range i.Summary() {
+		run.RowsRead += s.RowsRead
+		run.RowsInserted += s.RowsInserted
+		run.RowsUpdated += s.RowsUpdated
+		run.RowsRejected += s.RowsRejected
+		run.ParentsCreated += s.ParentsCreated
+	}
+	if err != nil {
+		run.Outcome = "failure"
+		run.Error = err.Error()
+	}
+	recorder, ok := i.DBClient.(database.RunRecorder)
+	if !ok {
+		log.Printf("Warning: recording import runs is not supported by the database.\n")
+		return
+	}
+	if err := recorder.RecordImportRun(ctx, run); err != nil {
+		log.Printf("Warning: failed to record the import run in %s: %v\n", database.ImportRunsTable, err)
+		return
+	}
+	log.Printf("Recorded import run %s in %s.\n", runID, database.ImportRunsTable)
+}
@@ -0,0 +1,39 @@
+package importer
+
+import (
+	"context"
+	"log"
+	"slices"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+)
+
+// resetSequences advances the sequences of the auto-increment columns of the given tables past the
+// imported keys. Failures are only logged: the rows are already written and the sequences can be
+// fixed by hand.
+func (i *Importer) resetSequences(ctx context.Context, tableNames []string) {
+	resetter, ok := i.DBClient.(database.SequenceResetter)
+	if !ok {
+		return
+	}
+	for _, tableName := range tableNames {
+		dbInfo, ok := i.DBSchema[tableName]
+		if !ok || !slices.ContainsFunc(dbInfo.Columns, func(c database.ColumnInfo) bool { return c.IsAutoIncrement }) {
+			continue
+		}
+		if err := resetter.ResetSequences(ctx, dbInfo); err != nil {
+			log.Printf("Warning: %v. Later inserts that rely on the sequence may collide with imported keys.\n", err)
+		}
+	}
+}
+
+// writtenTables returns the tables that received rows, either from a CSV file or as auto-created parents.
+func writtenTables(summaries []TableSummary) []string {
+	var tables []string
+	for _, s := range summaries {
+		if s.RowsInserted > 0 || s.ParentsCreated > 0 {
+			tables = append(tables, s.Table)
+		}
+	}
+	return tables
+}
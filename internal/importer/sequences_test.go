@@ -0,0 +1,18 @@
+package importer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_writtenTables(t *testing.T) {
+	t.Run("行を挿入したテーブルと親レコードを作成したテーブルが返されること", func(t *testing.T) {
+		summaries := []TableSummary{
+			{Table: "users", RowsRead: 2, RowsInserted: 2},
+			{Table: "posts", RowsRead: 1, RowsRejected: 1},
+			{Table: "organizations", ParentsCreated: 1},
+		}
+		assert.Equal(t, []string{"users", "organizations"}, writtenTables(summaries))
+	})
+}
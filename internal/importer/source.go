@@ -0,0 +1,95 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Source provides the CSV inputs of an import. The table of an input is named after the
+// base name of the input without its extension, unless a table claims it through TableOptions.File.
+// Inputs named with the extension .arrow, .arrows, .feather or .ipc are read as Arrow IPC data.
+type Source interface {
+	// List returns the names of the CSV inputs.
+	List(ctx context.Context) ([]string, error)
+	// Open opens an input returned by List. An input opened as an *os.File can be resumed
+	// from a checkpoint; others are always read from the start.
+	Open(ctx context.Context, name string) (io.ReadCloser, error)
+}
+
+// DirSource reads the CSV files in a local directory.
+type DirSource string
+
+// List returns the paths of the .csv files, and the Arrow IPC files, in the directory.
+func (d DirSource) List(ctx context.Context) ([]string, error) {
+	return getCSVFiles(string(d))
+}
+
+// Open opens the file at path name.
+func (d DirSource) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	return os.Open(name)
+}
+
+// FileSource reads the listed local CSV files.
+type FileSource []string
+
+// List returns the file paths.
+func (f FileSource) List(ctx context.Context) ([]string, error) {
+	return f, nil
+}
+
+// Open opens the file at path name.
+func (f FileSource) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	return os.Open(name)
+}
+
+// HTTPSource downloads CSV files relative to a base URL, e.g. from a static file server or an
+// object store serving pre-signed or public URLs.
+type HTTPSource struct {
+	// BaseURL is the URL the file names are resolved against.
+	BaseURL string
+	// Files holds the names of the CSV files, such as "users.csv".
+	Files []string
+	// Client is used for the requests; http.DefaultClient if nil.
+	Client *http.Client
+}
+
+// List returns the file names.
+func (h *HTTPSource) List(ctx context.Context) ([]string, error) {
+	return h.Files, nil
+}
+
+// Open downloads the named file.
+func (h *HTTPSource) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	base, err := url.Parse(strings.TrimSuffix(h.BaseURL, "/") + "/")
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL %s: %w", h.BaseURL, err)
+	}
+	ref, err := url.Parse(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid file name %s: %w", name, err)
+	}
+	fileURL := base.ResolveReference(ref).String()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s", fileURL, resp.Status)
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,105 @@
+package importer
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DirSource(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "users.csv"), []byte("id\n1\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("memo"), 0o644))
+
+	t.Run("CSVファイルのみが列挙されること", func(t *testing.T) {
+		names, err := DirSource(dir).List(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, []string{filepath.Join(dir, "users.csv")}, names)
+	})
+}
+
+func Test_HTTPSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/exports/users.csv" {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, "id\n1\n")
+	}))
+	defer server.Close()
+	src := &HTTPSource{BaseURL: server.URL + "/exports", Files: []string{"users.csv"}}
+
+	t.Run("ベースURLからの相対パスで取得できること", func(t *testing.T) {
+		body, err := src.Open(context.Background(), "users.csv")
+		require.NoError(t, err)
+		defer body.Close()
+		data, err := io.ReadAll(body)
+		require.NoError(t, err)
+		assert.Equal(t, "id\n1\n", string(data))
+	})
+	t.Run("存在しないファイルはエラーになること", func(t *testing.T) {
+		_, err := src.Open(context.Background(), "orders.csv")
+		assert.ErrorContains(t, err, "404 Not Found")
+	})
+}
+
+// rowsClient serves ReadRows from fixed rows; the other methods of DBClient are not used.
+type rowsClient struct {
+	database.DBClient
+	rows map[string][][]string
+}
+
+func (c *rowsClient) ReadRows(ctx context.Context, dbInfo database.DBInfo, columns []database.ColumnInfo, filter database.RowFilter, fn func(values []string) error) error {
+	for _, row := range c.rows[dbInfo.TableName] {
+		if err := fn(slices.Clone(row)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func Test_DBSource(t *testing.T) {
+	src := &DBSource{
+		DBClient: &rowsClient{rows: map[string][][]string{"USERS": {{"1", "a,b"}, {"2", ""}}}},
+		DBSchema: map[string]database.DBInfo{
+			"USERS":  {TableName: "USERS", Columns: []database.ColumnInfo{{ColumnName: "ID"}, {ColumnName: "NAME"}}},
+			"ORDERS": {TableName: "ORDERS", Columns: []database.ColumnInfo{{ColumnName: "ID"}}},
+		},
+		Files: map[string]string{"USERS": "users.csv"},
+	}
+
+	t.Run("テーブルごとの入力が列挙されること", func(t *testing.T) {
+		names, err := src.List(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"ORDERS.csv", "users.csv"}, names)
+	})
+
+	t.Run("テーブルの行がヘッダー付きのCSVとして読めること", func(t *testing.T) {
+		body, err := src.Open(context.Background(), "users.csv")
+		require.NoError(t, err)
+		data, err := io.ReadAll(body)
+		require.NoError(t, err)
+		require.NoError(t, body.Close())
+		assert.Equal(t, "ID,NAME\n1,\"a,b\"\n2,\n", string(data))
+	})
+
+	t.Run("途中で閉じても読み込みが終了すること", func(t *testing.T) {
+		body, err := src.Open(context.Background(), "users.csv")
+		require.NoError(t, err)
+		assert.NoError(t, body.Close())
+	})
+
+	t.Run("存在しないテーブルはエラーになること", func(t *testing.T) {
+		_, err := src.Open(context.Background(), "items.csv")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,113 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+)
+
+// TableSummary reports what an import did to one table.
+type TableSummary struct {
+	Table string `json:"table"`
+	File  string `json:"file,omitempty"`
+	// RowsRead is the number of data rows read from the CSV file.
+	RowsRead int `json:"rows_read"`
+	// RowsInserted is the number of rows inserted as new rows.
+	RowsInserted int `json:"rows_inserted"`
+	// RowsUpdated is the number of rows that updated an existing row with the same key. Only
+	// PostgreSQL and the MySQL family tell updated rows apart; the other databases count them as
+	// inserted.
+	RowsUpdated int `json:"rows_updated"`
+	// RowsSkipped is the number of rows the database accepted without changing anything.
+	RowsSkipped int `json:"rows_skipped"`
+	// RowsRejected is the number of rows that failed, e.g. because of a missing parent or a constraint.
+	RowsRejected int `json:"rows_rejected"`
+	// ParentsCreated is the number of rows auto-created in this table as parents of other rows.
+	ParentsCreated int `json:"parents_created"`
+	// Aborted reports that the import of the table was aborted through a TableControl before the
+	// end of its file.
+	Aborted bool          `json:"aborted,omitempty"`
+	Elapsed time.Duration `json:"-"`
+}
+
+// MarshalJSON writes Elapsed in seconds.
+func (s TableSummary) MarshalJSON() ([]byte, error) {
+	type plain TableSummary
+	return json.Marshal(struct {
+		plain
+		ElapsedSeconds float64 `json:"elapsed_seconds"`
+	}{plain(s), s.Elapsed.Seconds()})
+}
+
+func (s TableSummary) String() string {
+	name := s.Table
+	if s.File != "" {
+		name = fmt.Sprintf("%s (%s)", s.Table, s.File)
+	}
+	if s.Aborted {
+		name += " [aborted]"
+	}
+	return fmt.Sprintf("%s: read %d, inserted %d, updated %d, skipped %d, rejected %d, parents created %d, %s",
+		name, s.RowsRead, s.RowsInserted, s.RowsUpdated, s.RowsSkipped, s.RowsRejected, s.ParentsCreated, s.Elapsed.Round(time.Millisecond))
+}
+
+// createdParents returns the number of parent records the DBClient auto-created so far per table.
+// It is empty if the DBClient does not count them.
+func (i *Importer) createdParents() map[string]int {
+	if configurer, ok := i.DBClient.(database.ParentConfigurer); ok {
+		if created := configurer.CreatedParents(); created != nil {
+			return created
+		}
+	}
+	return make(map[string]int)
+}
+
+// FailedRows returns the number of records the last import or GenerateData run could not insert.
+func (i *Importer) FailedRows() int {
+	return i.failedRows
+}
+
+// Summary returns the per-table summary of the last ImportCSVFiles or ImportFiles run, in import order.
+// Tables that only received auto-created parent records come last.
+func (i *Importer) Summary() []TableSummary {
+	summaries := append([]TableSummary(nil), i.summaries...)
+	created := i.createdParents()
+	for tableName, n := range i.parentsBefore {
+		if created[tableName] -= n; created[tableName] <= 0 {
+			delete(created, tableName)
+		}
+	}
+	seen := make(map[string]bool, len(summaries))
+	for idx := range summaries {
+		summaries[idx].ParentsCreated = created[summaries[idx].Table]
+		seen[summaries[idx].Table] = true
+	}
+	var parentOnly []string
+	for tableName := range created {
+		if !seen[tableName] {
+			parentOnly = append(parentOnly, tableName)
+		}
+	}
+	sort.Strings(parentOnly)
+	for _, tableName := range parentOnly {
+		summaries = append(summaries, TableSummary{Table: tableName, ParentsCreated: created[tableName]})
+	}
+	return summaries
+}
+
+// WriteSummaryJSON writes the summary as a JSON document. version identifies the binary that produced it.
+func WriteSummaryJSON(w io.Writer, version string, summaries []TableSummary) error {
+	if summaries == nil {
+		summaries = []TableSummary{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(struct {
+		Version string         `json:"version"`
+		Tables  []TableSummary `json:"tables"`
+	}{version, summaries})
+}
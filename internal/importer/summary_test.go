@@ -0,0 +1,34 @@
+package importer
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_WriteSummaryJSON(t *testing.T) {
+	t.Run("テーブルごとの件数と経過秒数が出力されること", func(t *testing.T) {
+		var buf bytes.Buffer
+		summaries := []TableSummary{
+			{Table: "users", File: "users.csv", RowsRead: 3, RowsInserted: 2, RowsRejected: 1, ParentsCreated: 1, Elapsed: 1500 * time.Millisecond},
+		}
+		require.NoError(t, WriteSummaryJSON(&buf, "v1.0.0", summaries))
+		assert.JSONEq(t, `{
+			"version": "v1.0.0",
+			"tables": [{
+				"table": "users", "file": "users.csv",
+				"rows_read": 3, "rows_inserted": 2, "rows_updated": 0, "rows_skipped": 0, "rows_rejected": 1,
+				"parents_created": 1, "elapsed_seconds": 1.5
+			}]
+		}`, buf.String())
+	})
+
+	t.Run("テーブルがない場合は空の配列を出力すること", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, WriteSummaryJSON(&buf, "dev", nil))
+		assert.JSONEq(t, `{"version": "dev", "tables": []}`, buf.String())
+	})
+}
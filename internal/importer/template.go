@@ -0,0 +1,113 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+)
+
+// HintPrefix starts the first cell of the hint row of a CSV template. Imports skip a row starting
+// with it if it directly follows the header, so that filled-in templates import as they are. It
+// names the tool so that data which merely starts with "#" is never taken for a hint.
+const HintPrefix = "#db-auto-importer-hint: "
+
+// isHintRow reports whether a record, read directly after the header, is a hint row.
+func isHintRow(record []string) bool {
+	return len(record) > 0 && strings.HasPrefix(record[0], HintPrefix)
+}
+
+// WriteCSVTemplates writes a CSV file without rows for every table, or for the SelectedTables, to
+// dir: the header imports expect and a hint row describing the value each column takes. Existing
+// files are only replaced if overwrite is set.
+func (i *Importer) WriteCSVTemplates(dir string, overwrite bool) error {
+	var tableNames []string
+	for tableName := range i.DBSchema {
+		if len(i.SelectedTables) == 0 || slices.Contains(i.SelectedTables, tableName) {
+			tableNames = append(tableNames, tableName)
+		}
+	}
+	for _, tableName := range i.SelectedTables {
+		if _, ok := i.DBSchema[tableName]; !ok {
+			return fmt.Errorf("invalid table selection: table %s not found in the database schema", tableName)
+		}
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create template directory %s: %w", dir, err)
+	}
+
+	slices.Sort(tableNames)
+	for _, tableName := range tableNames {
+		dbInfo := i.DBSchema[tableName]
+		filePath := filepath.Join(dir, i.csvFileName(tableName))
+		columns, header := i.csvColumns(dbInfo)
+		if len(columns) == 0 {
+			log.Printf("Warning: Table %s has no columns to import; no template is written.\n", tableName)
+			continue
+		}
+		hints := make([]string, len(columns))
+		for idx, colInfo := range columns {
+			hints[idx] = columnHint(dbInfo, colInfo)
+		}
+		hints[0] = HintPrefix + hints[0]
+
+		flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+		if overwrite {
+			flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		}
+		file, err := os.OpenFile(filePath, flags, 0o644)
+		if errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("template %s already exists", filePath)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create template %s: %w", filePath, err)
+		}
+		w := csv.NewWriter(file)
+		w.Write(header)
+		w.Write(hints)
+		w.Flush()
+		if err := w.Error(); err != nil {
+			file.Close()
+			return fmt.Errorf("failed to write template %s: %w", filePath, err)
+		}
+		if err := file.Close(); err != nil {
+			return fmt.Errorf("failed to write template %s: %w", filePath, err)
+		}
+		log.Printf("Wrote template %s for table %s.\n", filePath, tableName)
+	}
+	return nil
+}
+
+// columnHint describes the values a column takes, e.g. "DATE YYYY-MM-DD; required".
+func columnHint(dbInfo database.DBInfo, colInfo database.ColumnInfo) string {
+	hint := colInfo.DataType.String()
+	switch colInfo.DataType {
+	case database.DateType:
+		hint += " YYYY-MM-DD"
+	case database.TimestampType:
+		hint += " YYYY-MM-DDThh:mm:ssZ"
+	case database.BooleanType:
+		hint += " true/false"
+	}
+	parts := []string{hint}
+	switch {
+	case colInfo.IsAutoIncrement:
+		parts = append(parts, "assigned by the database if empty")
+	case colInfo.ColumnDefault.Valid:
+		parts = append(parts, "default "+colInfo.ColumnDefault.String)
+	case !colInfo.IsNullable:
+		parts = append(parts, "required")
+	}
+	for _, fk := range dbInfo.ForeignKeys {
+		if idx := slices.Index(fk.ColumnNames, colInfo.ColumnName); idx != -1 {
+			parts = append(parts, fmt.Sprintf("references %s.%s", fk.ForeignTableName, fk.ForeignColumnNames[idx]))
+		}
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,68 @@
+package importer
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_WriteCSVTemplates(t *testing.T) {
+	i := &Importer{
+		DBSchema: map[string]database.DBInfo{
+			"users": {
+				TableName: "users",
+				Columns: []database.ColumnInfo{
+					{ColumnName: "id", DataType: database.IntegerType, IsAutoIncrement: true},
+					{ColumnName: "name", DataType: database.StringType},
+					{ColumnName: "active", DataType: database.BooleanType, ColumnDefault: sql.NullString{String: "true", Valid: true}},
+					{ColumnName: "created_by", DataType: database.StringType},
+				},
+			},
+			"orders": {
+				TableName: "orders",
+				Columns: []database.ColumnInfo{
+					{ColumnName: "id", DataType: database.IntegerType},
+					{ColumnName: "user_id", DataType: database.IntegerType, IsNullable: true},
+					{ColumnName: "ordered_on", DataType: database.DateType, IsNullable: true},
+				},
+				ForeignKeys: []database.ForeignKeyInfo{
+					{ConstraintName: "fk_user", TableName: "orders", ColumnNames: []string{"user_id"}, ForeignTableName: "users", ForeignColumnNames: []string{"id"}},
+				},
+			},
+		},
+		AuditColumns: map[string]string{"created_by": "importer"},
+	}
+
+	t.Run("ヘッダーとヒント行が書き出されること", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, i.WriteCSVTemplates(dir, false))
+
+		users, err := os.ReadFile(filepath.Join(dir, "users.csv"))
+		require.NoError(t, err)
+		assert.Equal(t, "id,name,active\n#db-auto-importer-hint: INTEGER; assigned by the database if empty,STRING; required,BOOLEAN true/false; default true\n", string(users))
+
+		orders, err := os.ReadFile(filepath.Join(dir, "orders.csv"))
+		require.NoError(t, err)
+		assert.Equal(t, "id,user_id,ordered_on\n#db-auto-importer-hint: INTEGER; required,INTEGER; references users.id,DATE YYYY-MM-DD\n", string(orders))
+	})
+
+	t.Run("既存のファイルは上書きしないこと", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "orders.csv")
+		require.NoError(t, os.WriteFile(path, []byte("id\n1\n"), 0o644))
+
+		selected := *i
+		selected.SelectedTables = []string{"orders"}
+		assert.Error(t, selected.WriteCSVTemplates(dir, false))
+		require.NoError(t, selected.WriteCSVTemplates(dir, true))
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "#db-auto-importer-hint: INTEGER")
+		assert.NoFileExists(t, filepath.Join(dir, "users.csv"))
+	})
+}
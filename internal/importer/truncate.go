@@ -0,0 +1,108 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/k-wa-wa/db-auto-importer/internal/graph"
+)
+
+// TruncatePlan lists the tables TruncateTables empties, or RollbackRun deletes the rows of a run
+// from, and how.
+type TruncatePlan struct {
+	// Tables are the tables to empty, each before the tables it references.
+	Tables []string
+	// Cleared are the foreign keys on a cycle between Tables, whose columns are set to NULL
+	// before any table is emptied.
+	Cleared []database.ForeignKeyInfo
+	// Referencing maps each table of Tables to the tables outside Tables that reference it. Their
+	// rows keep the table's rows from being deleted.
+	Referencing map[string][]string
+}
+
+// PlanTruncate returns the plan to empty the SelectedTables or, if none are selected, the tables
+// with a CSV file in csvDir.
+func (i *Importer) PlanTruncate(csvDir string) (*TruncatePlan, error) {
+	tables := i.SelectedTables
+	for _, tableName := range tables {
+		if _, ok := i.DBSchema[tableName]; !ok {
+			return nil, fmt.Errorf("invalid table selection: table %s not found in the database schema", tableName)
+		}
+	}
+	if len(tables) == 0 {
+		files, err := getCSVFiles(csvDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get CSV files from %s: %w", csvDir, err)
+		}
+		for tableName := range i.mapCSVFiles(files) {
+			if _, ok := i.DBSchema[tableName]; ok {
+				tables = append(tables, tableName)
+			}
+		}
+	}
+	if len(tables) == 0 {
+		return nil, errors.New("no tables to empty")
+	}
+
+	return i.planDeletion(tables)
+}
+
+// planDeletion returns the plan to delete rows of tables, which must be in the schema.
+func (i *Importer) planDeletion(tables []string) (*TruncatePlan, error) {
+	order, deferred, err := graph.CleanupOrder(i.DBSchema, i.OrderRules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine the order to delete the rows in: %w", err)
+	}
+	plan := &TruncatePlan{Referencing: make(map[string][]string)}
+	for _, tableName := range order {
+		if slices.Contains(tables, tableName) {
+			plan.Tables = append(plan.Tables, tableName)
+		}
+	}
+	for _, fk := range deferred {
+		if slices.Contains(tables, fk.TableName) && slices.Contains(tables, fk.ForeignTableName) {
+			plan.Cleared = append(plan.Cleared, fk)
+		}
+	}
+	for _, tableName := range order {
+		if slices.Contains(tables, tableName) {
+			continue
+		}
+		for _, fk := range i.DBSchema[tableName].ForeignKeys {
+			if slices.Contains(tables, fk.ForeignTableName) && !slices.Contains(plan.Referencing[fk.ForeignTableName], tableName) {
+				plan.Referencing[fk.ForeignTableName] = append(plan.Referencing[fk.ForeignTableName], tableName)
+			}
+		}
+	}
+	return plan, nil
+}
+
+// TruncateTables deletes every row of the tables of plan, first setting the columns of its
+// Cleared foreign keys to NULL. It stops at the first table that cannot be emptied, e.g. because
+// a table outside the plan still references its rows.
+func (i *Importer) TruncateTables(ctx context.Context, plan *TruncatePlan) error {
+	deleter, ok := i.DBClient.(database.RowDeleter)
+	if !ok {
+		return errors.New("deleting rows is not supported by the database")
+	}
+	for _, fk := range plan.Cleared {
+		if err := deleter.ClearColumns(ctx, i.DBSchema[fk.TableName], fk.ColumnNames, database.RowFilter{}); err != nil {
+			return err
+		}
+	}
+	for _, tableName := range plan.Tables {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		deleted, err := deleter.DeleteRows(ctx, i.DBSchema[tableName], database.RowFilter{})
+		if err != nil {
+			return err
+		}
+		log.Printf("Deleted %d row(s) of table %s.\n", deleted, tableName)
+	}
+	return nil
+}
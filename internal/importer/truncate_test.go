@@ -0,0 +1,86 @@
+package importer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// truncateClient records the statements TruncateTables asks for.
+type truncateClient struct {
+	database.DBClient
+	calls []string
+}
+
+func (c *truncateClient) ClearColumns(ctx context.Context, dbInfo database.DBInfo, columnNames []string, filter database.RowFilter) error {
+	c.calls = append(c.calls, "clear "+dbInfo.TableName+"."+strings.Join(columnNames, ","))
+	return nil
+}
+
+func (c *truncateClient) DeleteRows(ctx context.Context, dbInfo database.DBInfo, filter database.RowFilter) (int64, error) {
+	c.calls = append(c.calls, "delete "+dbInfo.TableName)
+	return 1, nil
+}
+
+func Test_TruncateTables(t *testing.T) {
+	fk := func(table, column, parent string) database.ForeignKeyInfo {
+		return database.ForeignKeyInfo{ConstraintName: "fk_" + table + "_" + column, TableName: table, ColumnNames: []string{column}, ForeignTableName: parent, ForeignColumnNames: []string{"id"}}
+	}
+	schema := map[string]database.DBInfo{
+		"orgs": {
+			TableName:         "orgs",
+			Columns:           []database.ColumnInfo{{ColumnName: "id"}, {ColumnName: "owner_id", IsNullable: true}},
+			PrimaryKeyColumns: []string{"id"},
+			ForeignKeys:       []database.ForeignKeyInfo{fk("orgs", "owner_id", "users")},
+		},
+		"users": {
+			TableName:         "users",
+			Columns:           []database.ColumnInfo{{ColumnName: "id"}, {ColumnName: "org_id"}},
+			PrimaryKeyColumns: []string{"id"},
+			ForeignKeys:       []database.ForeignKeyInfo{fk("users", "org_id", "orgs")},
+		},
+		"orders": {
+			TableName:         "orders",
+			Columns:           []database.ColumnInfo{{ColumnName: "id"}, {ColumnName: "user_id"}},
+			PrimaryKeyColumns: []string{"id"},
+			ForeignKeys:       []database.ForeignKeyInfo{fk("orders", "user_id", "users")},
+		},
+	}
+
+	t.Run("参照するテーブルから順に空にし循環する外部キーを先にNULLにすること", func(t *testing.T) {
+		client := &truncateClient{}
+		i := &Importer{DBSchema: schema, DBClient: client}
+		dir := t.TempDir()
+		for _, name := range []string{"orders.csv", "users.csv", "orgs.csv", "unknown.csv"} {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("id\n"), 0o644))
+		}
+		plan, err := i.PlanTruncate(dir)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"orders", "users", "orgs"}, plan.Tables)
+		assert.Empty(t, plan.Referencing)
+
+		require.NoError(t, i.TruncateTables(context.Background(), plan))
+		assert.Equal(t, []string{"clear orgs.owner_id", "delete orders", "delete users", "delete orgs"}, client.calls)
+	})
+
+	t.Run("選択したテーブルを参照する他のテーブルを示すこと", func(t *testing.T) {
+		i := &Importer{DBSchema: schema, DBClient: &truncateClient{}, SelectedTables: []string{"users"}}
+		plan, err := i.PlanTruncate(t.TempDir())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"users"}, plan.Tables)
+		assert.Empty(t, plan.Cleared)
+		assert.Equal(t, map[string][]string{"users": {"orders", "orgs"}}, plan.Referencing)
+	})
+
+	t.Run("対象のテーブルがない場合はエラーになること", func(t *testing.T) {
+		i := &Importer{DBSchema: schema, DBClient: &truncateClient{}}
+		_, err := i.PlanTruncate(t.TempDir())
+		assert.Error(t, err)
+	})
+}
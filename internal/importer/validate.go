@@ -0,0 +1,139 @@
+package importer
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/k-wa-wa/db-auto-importer/internal/graph"
+)
+
+// ErrValidationFailed is returned when CSV files would not import cleanly.
+var ErrValidationFailed = errors.New("CSV validation failed")
+
+// ValidationIssue describes a problem found in a CSV file. Line is 0 for file-level issues.
+type ValidationIssue struct {
+	FilePath string
+	Line     int
+	Column   string
+	Message  string
+}
+
+func (v ValidationIssue) String() string {
+	location := v.FilePath
+	if v.Line > 0 {
+		location = fmt.Sprintf("%s:%d", location, v.Line)
+	}
+	if v.Column != "" {
+		location = fmt.Sprintf("%s [%s]", location, v.Column)
+	}
+	return location + ": " + v.Message
+}
+
+// ValidateCSVFiles checks the CSV files in csvDir against the schema without writing to the database.
+// It reports unmapped files (unless the policy is UnmappedFileIgnore), header columns that do not exist
+// in the table, malformed CSV lines and values that cannot be converted to the column type.
+// An error is returned only if validation itself could not run, e.g. because of a dependency cycle.
+func (i *Importer) ValidateCSVFiles(ctx context.Context, csvDir string, hasHeader bool) ([]ValidationIssue, error) {
+	files, err := getCSVFiles(csvDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get CSV files from %s: %w", csvDir, err)
+	}
+	csvFilesMap := i.mapCSVFiles(files)
+
+	var issues []ValidationIssue
+	if i.UnmappedFilePolicy != UnmappedFileIgnore {
+		for _, filePath := range i.findUnmappedFiles(csvFilesMap) {
+			issues = append(issues, ValidationIssue{FilePath: filePath, Message: "no corresponding table in the database schema"})
+		}
+	}
+
+	order, _, err := graph.ImportOrder(i.DBSchema, i.OrderRules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine import order: %w", err)
+	}
+	csvFilesMap, _, err = i.selectFiles(csvFilesMap)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tableName := range order {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		filePath, ok := csvFilesMap[tableName]
+		if !ok {
+			continue
+		}
+		dbInfo := i.DBSchema[tableName]
+		for _, fk := range dbInfo.ForeignKeys {
+			if _, ok := i.DBSchema[fk.ForeignTableName]; !ok {
+				return nil, &database.MissingParentTableError{TableName: fk.ForeignTableName, ConstraintName: fk.ConstraintName}
+			}
+		}
+		fileIssues, err := i.validateCSVFile(filePath, i.excludeColumns(dbInfo), hasHeader)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, fileIssues...)
+	}
+	return issues, nil
+}
+
+func (i *Importer) validateCSVFile(filePath string, dbInfo database.DBInfo, hasHeader bool) ([]ValidationIssue, error) {
+	file, err := openInputFile(filePath, hasHeader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open CSV file %s: %w", filePath, err)
+	}
+	defer file.Close()
+
+	var issues []ValidationIssue
+	reader := csv.NewReader(file)
+	columnMap := make(map[string]int)
+	line := 0
+	if hasHeader {
+		csvHeader, err := reader.Read()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV header from %s: %w", filePath, err)
+		}
+		line++
+		var unknown []string
+		columnMap, unknown = i.mapCSVHeader(dbInfo, csvHeader)
+		for _, csvColName := range unknown {
+			issues = append(issues, ValidationIssue{FilePath: filePath, Line: line, Column: csvColName, Message: fmt.Sprintf("column does not exist in table %s", dbInfo.TableName)})
+		}
+	} else {
+		columnMap = i.positionalColumnMap(dbInfo)
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			issues = append(issues, ValidationIssue{FilePath: filePath, Line: line, Message: err.Error()})
+			continue
+		}
+		if hasHeader && line == 2 && isHintRow(record) {
+			continue
+		}
+		for _, colInfo := range dbInfo.InsertColumns() {
+			csvVal := ""
+			if idx, ok := columnMap[colInfo.ColumnName]; ok && idx < len(record) {
+				csvVal = record[idx]
+			}
+			if auditVal, ok := i.auditValue(colInfo.ColumnName); ok {
+				csvVal = auditVal
+			}
+			if _, err := database.ConvertColumnValue(csvVal, colInfo); err != nil {
+				issues = append(issues, ValidationIssue{FilePath: filePath, Line: line, Column: colInfo.ColumnName, Message: err.Error()})
+			}
+		}
+	}
+	return issues, nil
+}
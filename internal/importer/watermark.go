@@ -0,0 +1,202 @@
+package importer
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+)
+
+// Watermarks record, per table, the largest value of a column such as updated_at or a sequence
+// number that has been imported, so that an incremental import only imports the CSV rows with a
+// larger value. They are kept in a JSON file between runs.
+type Watermarks struct {
+	path   string
+	Tables map[string]*TableWatermark `json:"tables"`
+}
+
+// TableWatermark is the watermark of one table.
+type TableWatermark struct {
+	Column    string    `json:"column"`
+	Value     string    `json:"value"` // In CSV string form
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// LoadWatermarks reads the watermark file at path. A missing file yields no watermarks, so the
+// first run imports every row.
+func LoadWatermarks(path string) (*Watermarks, error) {
+	w := &Watermarks{path: path, Tables: make(map[string]*TableWatermark)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return w, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read watermark file %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, w); err != nil {
+		return nil, fmt.Errorf("failed to parse watermark file %s: %w", path, err)
+	}
+	if w.Tables == nil {
+		w.Tables = make(map[string]*TableWatermark)
+	}
+	return w, nil
+}
+
+// save writes the watermark file, through a temporary file like Checkpoint.save.
+func (w *Watermarks) save() error {
+	data, err := json.MarshalIndent(w, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode watermark file %s: %w", w.path, err)
+	}
+	tmpPath := w.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write watermark file %s: %w", tmpPath, err)
+	}
+	if err := os.Rename(tmpPath, w.path); err != nil {
+		return fmt.Errorf("failed to write watermark file %s: %w", w.path, err)
+	}
+	return nil
+}
+
+// watermarkFilter skips the CSV rows of a table that are not newer than its stored watermark and
+// tracks the largest value imported.
+type watermarkFilter struct {
+	table  string
+	column database.ColumnInfo
+	index  int         // Index of the column in the CSV records
+	last   interface{} // Stored watermark, converted; nil if there is none
+	max    interface{} // Largest value imported by this run, converted
+	maxCSV string
+}
+
+// watermarkColumn returns the watermark column of the table of dbInfo: the one of its
+// TableOptions, or else WatermarkColumn if the table has it.
+func (i *Importer) watermarkColumn(dbInfo database.DBInfo) (database.ColumnInfo, bool, error) {
+	name := i.Tables[dbInfo.TableName].WatermarkColumn
+	explicit := name != ""
+	if !explicit {
+		name = i.WatermarkColumn
+	}
+	if name == "" {
+		return database.ColumnInfo{}, false, nil
+	}
+	for _, col := range dbInfo.Columns {
+		if col.ColumnName == name {
+			return col, true, nil
+		}
+	}
+	if explicit {
+		return database.ColumnInfo{}, false, fmt.Errorf("watermark column %s not found in table %s", name, dbInfo.TableName)
+	}
+	return database.ColumnInfo{}, false, nil
+}
+
+// newWatermarkFilter returns the filter of the table of dbInfo, or nil if it is imported in full.
+func (i *Importer) newWatermarkFilter(dbInfo database.DBInfo, columnMap map[string]int) (*watermarkFilter, error) {
+	if i.Watermarks == nil {
+		return nil, nil
+	}
+	col, ok, err := i.watermarkColumn(dbInfo)
+	if err != nil || !ok {
+		return nil, err
+	}
+	switch col.DataType {
+	case database.StringType, database.IntegerType, database.FloatType, database.DateType, database.TimestampType:
+	default:
+		return nil, fmt.Errorf("watermark column %s.%s has type %s, which cannot be ordered", dbInfo.TableName, col.ColumnName, col.DataType)
+	}
+	index, ok := columnMap[col.ColumnName]
+	if !ok {
+		return nil, fmt.Errorf("watermark column %s of table %s is not in the CSV file", col.ColumnName, dbInfo.TableName)
+	}
+
+	f := &watermarkFilter{table: dbInfo.TableName, column: col, index: index}
+	stored, ok := i.Watermarks.Tables[dbInfo.TableName]
+	switch {
+	case !ok:
+		log.Printf("No watermark stored for table %s. Importing every row.\n", dbInfo.TableName)
+	case stored.Column != col.ColumnName:
+		log.Printf("Warning: The watermark of table %s was stored for column %s, not %s. Importing every row.\n", dbInfo.TableName, stored.Column, col.ColumnName)
+	default:
+		if f.last, err = f.convert(stored.Value); err != nil {
+			return nil, fmt.Errorf("invalid watermark of table %s: %w", dbInfo.TableName, err)
+		}
+		log.Printf("Importing the rows of table %s with %s after %s.\n", dbInfo.TableName, col.ColumnName, stored.Value)
+	}
+	return f, nil
+}
+
+// convert converts a value of the column for comparison.
+func (f *watermarkFilter) convert(csvValue string) (interface{}, error) {
+	return database.ConvertColumnValue(csvValue, f.column)
+}
+
+// newer reports whether record is to be imported: whether its value is larger than the stored
+// watermark. Without a stored watermark every record is; with one, records without a value are not.
+func (f *watermarkFilter) newer(record []string) (bool, error) {
+	if f.last == nil {
+		return true, nil
+	}
+	if f.index >= len(record) || record[f.index] == "" {
+		return false, nil
+	}
+	value, err := f.convert(record[f.index])
+	if err != nil {
+		return false, fmt.Errorf("invalid watermark column %s: %w", f.column.ColumnName, err)
+	}
+	return compareWatermarks(value, f.last) > 0, nil
+}
+
+// imported records that record was imported.
+func (f *watermarkFilter) imported(record []string) {
+	if f.index >= len(record) || record[f.index] == "" {
+		return
+	}
+	value, err := f.convert(record[f.index])
+	if err != nil || value == nil {
+		return
+	}
+	if f.max == nil || compareWatermarks(value, f.max) > 0 {
+		f.max, f.maxCSV = value, record[f.index]
+	}
+}
+
+// compareWatermarks compares two converted values of the same column.
+func compareWatermarks(a, b interface{}) int {
+	switch a := a.(type) {
+	case int64:
+		if b, ok := b.(int64); ok {
+			return cmp.Compare(a, b)
+		}
+	case float64:
+		if b, ok := b.(float64); ok {
+			return cmp.Compare(a, b)
+		}
+	case time.Time:
+		if b, ok := b.(time.Time); ok {
+			return a.Compare(b)
+		}
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+// advanceWatermark stores the largest value imported by f once its table has been imported. A
+// watermark is not advanced over a table imported in part or with rejected rows, since its CSV
+// rows need not be in order.
+func (i *Importer) advanceWatermark(f *watermarkFilter) error {
+	if f.max == nil {
+		return nil
+	}
+	i.Watermarks.Tables[f.table] = &TableWatermark{Column: f.column.ColumnName, Value: f.maxCSV, UpdatedAt: time.Now().UTC()}
+	if err := i.Watermarks.save(); err != nil {
+		return err
+	}
+	log.Printf("Watermark of table %s advanced to %s.\n", f.table, f.maxCSV)
+	return nil
+}
@@ -0,0 +1,164 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Watermarks(t *testing.T) {
+	orders := database.DBInfo{
+		TableName: "orders",
+		Columns: []database.ColumnInfo{
+			{ColumnName: "id", DataType: database.IntegerType},
+			{ColumnName: "updated_at", DataType: database.TimestampType, IsNullable: true},
+			{ColumnName: "paid", DataType: database.BooleanType},
+		},
+	}
+	columnMap := map[string]int{"id": 0, "updated_at": 1, "paid": 2}
+
+	t.Run("ウォーターマークがない場合は全ての行が取り込まれ、最大値が保存されること", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "watermarks.json")
+		w, err := LoadWatermarks(path)
+		require.NoError(t, err)
+		i := &Importer{Watermarks: w, WatermarkColumn: "updated_at"}
+
+		f, err := i.newWatermarkFilter(orders, columnMap)
+		require.NoError(t, err)
+		require.NotNil(t, f)
+		for _, record := range [][]string{{"1", "2024-03-01 10:00:00", "t"}, {"2", "2024-03-02 09:00:00", "t"}, {"3", "", "f"}} {
+			newer, err := f.newer(record)
+			require.NoError(t, err)
+			assert.True(t, newer)
+			f.imported(record)
+		}
+		require.NoError(t, i.advanceWatermark(f))
+
+		loaded, err := LoadWatermarks(path)
+		require.NoError(t, err)
+		require.Contains(t, loaded.Tables, "orders")
+		assert.Equal(t, "updated_at", loaded.Tables["orders"].Column)
+		assert.Equal(t, "2024-03-02 09:00:00", loaded.Tables["orders"].Value)
+	})
+
+	t.Run("ウォーターマークより新しい行だけが取り込まれること", func(t *testing.T) {
+		w := &Watermarks{path: filepath.Join(t.TempDir(), "watermarks.json"), Tables: map[string]*TableWatermark{
+			"orders": {Column: "updated_at", Value: "2024-03-02 09:00:00"},
+		}}
+		i := &Importer{Watermarks: w, WatermarkColumn: "updated_at"}
+		f, err := i.newWatermarkFilter(orders, columnMap)
+		require.NoError(t, err)
+
+		tests := []struct {
+			value string
+			want  bool
+		}{
+			{"2024-03-01 10:00:00", false},
+			{"2024-03-02 09:00:00", false},
+			{"2024-03-02 09:00:01", true},
+			{"", false},
+		}
+		for _, tt := range tests {
+			newer, err := f.newer([]string{"1", tt.value, "t"})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, newer, tt.value)
+		}
+		_, err = f.newer([]string{"1", "yesterday", "t"})
+		assert.Error(t, err)
+	})
+
+	t.Run("数値は文字列ではなく数値として比較されること", func(t *testing.T) {
+		w := &Watermarks{Tables: map[string]*TableWatermark{"orders": {Column: "id", Value: "9"}}}
+		i := &Importer{Watermarks: w, Tables: map[string]TableOptions{"orders": {WatermarkColumn: "id"}}}
+		f, err := i.newWatermarkFilter(orders, columnMap)
+		require.NoError(t, err)
+		newer, err := f.newer([]string{"10", "", "t"})
+		require.NoError(t, err)
+		assert.True(t, newer)
+	})
+
+	t.Run("別のカラムで保存されたウォーターマークは使われないこと", func(t *testing.T) {
+		w := &Watermarks{Tables: map[string]*TableWatermark{"orders": {Column: "id", Value: "9"}}}
+		i := &Importer{Watermarks: w, WatermarkColumn: "updated_at"}
+		f, err := i.newWatermarkFilter(orders, columnMap)
+		require.NoError(t, err)
+		newer, err := f.newer([]string{"1", "2000-01-01 00:00:00", "t"})
+		require.NoError(t, err)
+		assert.True(t, newer)
+	})
+
+	t.Run("取り込んだ行がない場合はウォーターマークを変更しないこと", func(t *testing.T) {
+		w := &Watermarks{path: filepath.Join(t.TempDir(), "watermarks.json"), Tables: map[string]*TableWatermark{
+			"orders": {Column: "updated_at", Value: "2024-03-02 09:00:00"},
+		}}
+		i := &Importer{Watermarks: w, WatermarkColumn: "updated_at"}
+		f, err := i.newWatermarkFilter(orders, columnMap)
+		require.NoError(t, err)
+		require.NoError(t, i.advanceWatermark(f))
+		assert.Equal(t, "2024-03-02 09:00:00", w.Tables["orders"].Value)
+		assert.NoFileExists(t, w.path)
+	})
+
+	t.Run("共通のカラムがないテーブルは全件取り込まれること", func(t *testing.T) {
+		i := &Importer{Watermarks: &Watermarks{}, WatermarkColumn: "modified"}
+		f, err := i.newWatermarkFilter(orders, columnMap)
+		require.NoError(t, err)
+		assert.Nil(t, f)
+	})
+
+	t.Run("設定の誤りはエラーになること", func(t *testing.T) {
+		tests := []struct {
+			name      string
+			column    string
+			columnMap map[string]int
+		}{
+			{"テーブルにないカラム", "modified", columnMap},
+			{"順序のない型", "paid", columnMap},
+			{"CSVにないカラム", "updated_at", map[string]int{"id": 0}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				i := &Importer{Watermarks: &Watermarks{}, Tables: map[string]TableOptions{"orders": {WatermarkColumn: tt.column}}}
+				_, err := i.newWatermarkFilter(orders, tt.columnMap)
+				assert.Error(t, err)
+			})
+		}
+	})
+
+	t.Run("拒否された行がある場合はウォーターマークを進めず次の実行で取り込むこと", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "watermarks.json")
+		dir := writeCSVFiles(t, map[string]string{"organizations.csv": "id,name\n1,Acme\n2,bad\n3,Initech\n"})
+		client := newFakeClient(fakeSchema)
+		client.insertErr = func(tableName string, row map[string]string) error {
+			if row["name"] == "bad" {
+				return errors.New("check constraint violated")
+			}
+			return nil
+		}
+		w, err := LoadWatermarks(path)
+		require.NoError(t, err)
+		i := &Importer{DBSchema: fakeSchema, DBClient: client, Watermarks: w, WatermarkColumn: "id"}
+
+		result, err := i.ImportCSVFiles(context.Background(), dir, true)
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Failed)
+		loaded, err := LoadWatermarks(path)
+		require.NoError(t, err)
+		assert.NotContains(t, loaded.Tables, "organizations")
+
+		client.insertErr = nil
+		i.Watermarks = loaded
+		_, err = i.ImportCSVFiles(context.Background(), dir, true)
+		require.NoError(t, err)
+		assert.True(t, client.find("organizations", []string{"id"}, []string{"2"}))
+		loaded, err = LoadWatermarks(path)
+		require.NoError(t, err)
+		require.Contains(t, loaded.Tables, "organizations")
+		assert.Equal(t, "3", loaded.Tables["organizations"].Value)
+	})
+}
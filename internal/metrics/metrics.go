@@ -0,0 +1,133 @@
+// Package metrics collects import statistics and exposes them in the Prometheus text format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/k-wa-wa/db-auto-importer/internal/importer"
+)
+
+// Registry accumulates the statistics of every import it observes. It implements
+// importer.Observer and serves the metrics over HTTP as an http.Handler.
+type Registry struct {
+	mu             sync.Mutex
+	rowsImported   map[string]float64
+	rowErrors      map[string]float64
+	parentsCreated map[string]float64
+	durations      map[string]float64
+	rowsProcessed  map[string]float64
+	imports        map[string]float64
+	lastImport     time.Time
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		rowsImported:   make(map[string]float64),
+		rowErrors:      make(map[string]float64),
+		parentsCreated: make(map[string]float64),
+		durations:      make(map[string]float64),
+		rowsProcessed:  make(map[string]float64),
+		imports:        make(map[string]float64),
+	}
+}
+
+var _ importer.Observer = (*Registry)(nil)
+
+func (r *Registry) FileStarted(table, filePath string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rowsProcessed[table] = 0
+}
+
+func (r *Registry) RowsProcessed(table string, done, total int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rowsProcessed[table] = float64(done)
+}
+
+func (r *Registry) RowFailed(err *database.RowInsertError) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rowErrors[err.TableName]++
+}
+
+func (r *Registry) FileFinished(summary importer.TableSummary, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rowsImported[summary.Table] += float64(summary.RowsInserted + summary.RowsUpdated)
+	r.durations[summary.Table] += summary.Elapsed.Seconds()
+}
+
+// ImportFinished counts the run and the parent records it created; those are only known once
+// the run is over.
+func (r *Registry) ImportFinished(summaries []importer.TableSummary, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, summary := range summaries {
+		if summary.ParentsCreated > 0 {
+			r.parentsCreated[summary.Table] += float64(summary.ParentsCreated)
+		}
+	}
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	r.imports[result]++
+	r.lastImport = time.Now()
+}
+
+// metric is one metric family of the exposition.
+type metric struct {
+	name, kind, help string
+	label            string
+	values           map[string]float64
+}
+
+// WriteTo writes the metrics to w in the Prometheus text exposition format.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	metrics := []metric{
+		{"db_auto_importer_rows_imported_total", "counter", "Rows inserted from CSV files.", "table", maps.Clone(r.rowsImported)},
+		{"db_auto_importer_row_errors_total", "counter", "CSV rows that could not be inserted.", "table", maps.Clone(r.rowErrors)},
+		{"db_auto_importer_parents_created_total", "counter", "Parent records created automatically.", "table", maps.Clone(r.parentsCreated)},
+		{"db_auto_importer_import_duration_seconds_total", "counter", "Time spent importing the CSV files of each table.", "table", maps.Clone(r.durations)},
+		{"db_auto_importer_rows_processed", "gauge", "Rows processed of the table's latest CSV file.", "table", maps.Clone(r.rowsProcessed)},
+		{"db_auto_importer_imports_total", "counter", "Finished import runs by result.", "result", maps.Clone(r.imports)},
+	}
+	lastImport := r.lastImport
+	r.mu.Unlock()
+
+	var b strings.Builder
+	for _, m := range metrics {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, m.kind)
+		for _, labelValue := range slices.Sorted(maps.Keys(m.values)) {
+			fmt.Fprintf(&b, "%s{%s=\"%s\"} %g\n", m.name, m.label, escapeLabel(labelValue), m.values[labelValue])
+		}
+	}
+	if !lastImport.IsZero() {
+		name := "db_auto_importer_last_import_timestamp_seconds"
+		fmt.Fprintf(&b, "# HELP %s Time the latest import run finished.\n# TYPE %s gauge\n%s %d\n", name, name, name, lastImport.Unix())
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// ServeHTTP writes the metrics for a Prometheus scrape.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.WriteTo(w)
+}
+
+// escapeLabel escapes a label value as the exposition format requires.
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
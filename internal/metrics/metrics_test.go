@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/k-wa-wa/db-auto-importer/internal/importer"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Registry(t *testing.T) {
+	t.Run("インポートの統計がテーブルごとに累積されること", func(t *testing.T) {
+		r := NewRegistry()
+		for run := 0; run < 2; run++ {
+			r.FileStarted("users", "users.csv")
+			r.RowsProcessed("users", 3, 3)
+			r.RowFailed(&database.RowInsertError{TableName: "users"})
+			summary := importer.TableSummary{Table: "users", RowsRead: 3, RowsInserted: 2, RowsRejected: 1, Elapsed: 1500 * time.Millisecond}
+			r.FileFinished(summary, nil)
+			r.ImportFinished([]importer.TableSummary{summary, {Table: "organizations", ParentsCreated: 2}}, nil)
+		}
+		r.ImportFinished(nil, errors.New("failed"))
+
+		var b strings.Builder
+		_, err := r.WriteTo(&b)
+		assert.NoError(t, err)
+		out := b.String()
+		assert.Contains(t, out, "# TYPE db_auto_importer_rows_imported_total counter\n")
+		assert.Contains(t, out, `db_auto_importer_rows_imported_total{table="users"} 4`+"\n")
+		assert.Contains(t, out, `db_auto_importer_row_errors_total{table="users"} 2`+"\n")
+		assert.Contains(t, out, `db_auto_importer_parents_created_total{table="organizations"} 4`+"\n")
+		assert.NotContains(t, out, `db_auto_importer_parents_created_total{table="users"}`)
+		assert.Contains(t, out, `db_auto_importer_import_duration_seconds_total{table="users"} 3`+"\n")
+		assert.Contains(t, out, `db_auto_importer_rows_processed{table="users"} 3`+"\n")
+		assert.Contains(t, out, `db_auto_importer_imports_total{result="failure"} 1`+"\n")
+		assert.Contains(t, out, `db_auto_importer_imports_total{result="success"} 2`+"\n")
+		assert.Contains(t, out, "db_auto_importer_last_import_timestamp_seconds ")
+	})
+
+	t.Run("インポート前は最終実行時刻を出力しないこと", func(t *testing.T) {
+		var b strings.Builder
+		_, err := NewRegistry().WriteTo(&b)
+		assert.NoError(t, err)
+		assert.NotContains(t, b.String(), "last_import_timestamp")
+	})
+
+	t.Run("ラベル値がエスケープされること", func(t *testing.T) {
+		r := NewRegistry()
+		r.RowFailed(&database.RowInsertError{TableName: "odd\"name\\"})
+		var b strings.Builder
+		r.WriteTo(&b)
+		assert.Contains(t, b.String(), `db_auto_importer_row_errors_total{table="odd\"name\\"} 1`)
+	})
+
+	t.Run("HTTPでテキスト形式の指標を返すこと", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		NewRegistry().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+		assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", rec.Header().Get("Content-Type"))
+		assert.Contains(t, rec.Body.String(), "# HELP db_auto_importer_rows_imported_total")
+	})
+}
@@ -0,0 +1,114 @@
+// Package notify posts the summary of an import run to a webhook, such as a Slack incoming
+// webhook, so that a failed scheduled import does not go unnoticed.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Format is the payload posted to a webhook.
+type Format string
+
+const (
+	// FormatJSON posts the Run as a JSON object.
+	FormatJSON Format = "json"
+	// FormatSlack posts a message in the payload of Slack incoming webhooks, {"text": "..."},
+	// which Mattermost, Rocket.Chat and others accept too.
+	FormatSlack Format = "slack"
+)
+
+// ParseFormat parses "json" or "slack". An empty string chooses the format from the URL; see Webhook.
+func ParseFormat(s string) (Format, error) {
+	switch format := Format(s); format {
+	case "", FormatJSON, FormatSlack:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown notification format %q (expected json or slack)", s)
+	}
+}
+
+// Run summarizes a finished import run.
+type Run struct {
+	// Status is "success" or "failure".
+	Status          string  `json:"status"`
+	Version         string  `json:"version"`
+	Tables          int     `json:"tables"`
+	RowsInserted    int     `json:"rows_inserted"`
+	RowsUpdated     int     `json:"rows_updated"`
+	RowsRejected    int     `json:"rows_rejected"`
+	ParentsCreated  int     `json:"parents_created"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	Error           string  `json:"error,omitempty"`
+}
+
+// Text formats the run as a one-line chat message.
+func (r Run) Text() string {
+	duration := time.Duration(r.DurationSeconds * float64(time.Second)).Round(time.Second)
+	counts := fmt.Sprintf("%d table(s), %d row(s) inserted, %d updated, %d rejected, %d parent record(s) created", r.Tables, r.RowsInserted, r.RowsUpdated, r.RowsRejected, r.ParentsCreated)
+	if r.Status != "success" {
+		return fmt.Sprintf(":x: db-auto-importer %s: import failed after %s (%s): %s", r.Version, duration, counts, r.Error)
+	}
+	return fmt.Sprintf(":white_check_mark: db-auto-importer %s: import finished in %s (%s)", r.Version, duration, counts)
+}
+
+// Webhook posts runs to a URL. Without a Format, runs are posted as Slack messages to
+// hooks.slack.com and as JSON elsewhere.
+type Webhook struct {
+	URL    string
+	Format Format
+	// Client sends the requests; http.DefaultClient if nil.
+	Client *http.Client
+}
+
+// Post sends run to the webhook. A response status other than 2xx is an error.
+func (w *Webhook) Post(ctx context.Context, run Run) error {
+	var payload interface{} = run
+	if w.format() == FormatSlack {
+		payload = map[string]string{"text": run.Text()}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create notification request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// The URL of a webhook usually carries its secret, so it is left out of the error
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("failed to post notification: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("failed to post notification: webhook responded %s", resp.Status)
+	}
+	return nil
+}
+
+func (w *Webhook) format() Format {
+	if w.Format != "" {
+		return w.Format
+	}
+	if u, err := url.Parse(w.URL); err == nil && strings.EqualFold(u.Hostname(), "hooks.slack.com") {
+		return FormatSlack
+	}
+	return FormatJSON
+}
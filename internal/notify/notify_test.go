@@ -0,0 +1,55 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Webhook(t *testing.T) {
+	run := Run{Status: "failure", Version: "v1.0.0", Tables: 2, RowsInserted: 10, RowsRejected: 1, DurationSeconds: 3.2, Error: "boom"}
+
+	receive := func(t *testing.T, status int) (*httptest.Server, *map[string]interface{}) {
+		var got map[string]interface{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			body, _ := io.ReadAll(r.Body)
+			assert.NoError(t, json.Unmarshal(body, &got))
+			w.WriteHeader(status)
+		}))
+		t.Cleanup(server.Close)
+		return server, &got
+	}
+
+	t.Run("JSON形式で実行結果を送信すること", func(t *testing.T) {
+		server, got := receive(t, http.StatusOK)
+		err := (&Webhook{URL: server.URL}).Post(context.Background(), run)
+		assert.NoError(t, err)
+		assert.Equal(t, "failure", (*got)["status"])
+		assert.Equal(t, float64(10), (*got)["rows_inserted"])
+		assert.Equal(t, "boom", (*got)["error"])
+	})
+
+	t.Run("Slack形式ではテキストを送信すること", func(t *testing.T) {
+		server, got := receive(t, http.StatusOK)
+		err := (&Webhook{URL: server.URL, Format: FormatSlack}).Post(context.Background(), run)
+		assert.NoError(t, err)
+		assert.Equal(t, ":x: db-auto-importer v1.0.0: import failed after 3s (2 table(s), 10 row(s) inserted, 0 updated, 1 rejected, 0 parent record(s) created): boom", (*got)["text"])
+	})
+
+	t.Run("2xx以外の応答はエラーになること", func(t *testing.T) {
+		server, _ := receive(t, http.StatusNotFound)
+		err := (&Webhook{URL: server.URL}).Post(context.Background(), run)
+		assert.EqualError(t, err, "failed to post notification: webhook responded 404 Not Found")
+	})
+
+	t.Run("SlackのURLではSlack形式を選ぶこと", func(t *testing.T) {
+		assert.Equal(t, FormatSlack, (&Webhook{URL: "https://hooks.slack.com/services/T0/B0/x"}).format())
+		assert.Equal(t, FormatJSON, (&Webhook{URL: "https://example.com/hook"}).format())
+	})
+}
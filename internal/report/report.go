@@ -0,0 +1,188 @@
+// Package report writes a self-contained HTML report of an import run, to share its outcome
+// with people who do not read logs.
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/importer"
+)
+
+// MaxSamples is the number of rejected rows and created parent records listed per table.
+const MaxSamples = 20
+
+// Run is what the report shows.
+type Run struct {
+	Version string
+	Started time.Time
+	Result  *importer.ImportResult
+	// Err is the error the import ended with, if any.
+	Err error
+	// Parents holds the parent records created automatically, by table; see CollectParents.
+	Parents map[string][]importer.ParentCreated
+}
+
+// CollectParents receives events until events is closed and returns the parent records created,
+// by table, keeping the first MaxSamples of each table. Other events are dropped.
+func CollectParents(events <-chan importer.Event) map[string][]importer.ParentCreated {
+	parents := make(map[string][]importer.ParentCreated)
+	for event := range events {
+		if parent, ok := event.(importer.ParentCreated); ok && len(parents[parent.Table]) < MaxSamples {
+			parents[parent.Table] = append(parents[parent.Table], parent)
+		}
+	}
+	return parents
+}
+
+type tableView struct {
+	importer.TableSummary
+	// Bar is the width of the table's bar in the timing chart, in percent of the slowest table.
+	Bar      float64
+	Rejected []*rowView
+	// MoreRejected is the number of rejected rows not listed.
+	MoreRejected int
+	Parents      []string
+	MoreParents  int
+}
+
+type rowView struct {
+	Line   int
+	Error  string
+	Record string
+}
+
+type reportView struct {
+	Run
+	Tables         []*tableView
+	Elapsed        time.Duration
+	RowsRead       int
+	RowsInserted   int
+	RowsUpdated    int
+	RowsRejected   int
+	ParentsCreated int
+}
+
+// WriteHTML writes the report of run to w as a single HTML page without external resources.
+func WriteHTML(w io.Writer, run Run) error {
+	result := run.Result
+	if result == nil {
+		result = &importer.ImportResult{}
+	}
+	view := reportView{
+		Run:            run,
+		Elapsed:        result.Elapsed.Round(time.Millisecond),
+		RowsInserted:   result.RowsInserted(),
+		RowsUpdated:    result.RowsUpdated(),
+		RowsRejected:   result.RowsRejected(),
+		ParentsCreated: result.ParentsCreated(),
+	}
+	var slowest time.Duration
+	byTable := make(map[string]*tableView)
+	for _, summary := range result.Tables {
+		table := &tableView{TableSummary: summary}
+		view.Tables = append(view.Tables, table)
+		byTable[summary.Table] = table
+		view.RowsRead += summary.RowsRead
+		slowest = max(slowest, summary.Elapsed)
+		for _, parent := range run.Parents[summary.Table] {
+			table.Parents = append(table.Parents, formatKey(parent.ColumnNames, parent.Key))
+		}
+		table.MoreParents = summary.ParentsCreated - len(table.Parents)
+	}
+	for _, table := range view.Tables {
+		if slowest > 0 {
+			table.Bar = 100 * table.Elapsed.Seconds() / slowest.Seconds()
+		}
+	}
+	for _, rowErr := range result.Rejected {
+		table := byTable[rowErr.TableName]
+		if table == nil {
+			continue
+		}
+		if len(table.Rejected) == MaxSamples {
+			table.MoreRejected++
+			continue
+		}
+		table.Rejected = append(table.Rejected, &rowView{Line: rowErr.Line, Error: rowErr.Err.Error(), Record: strings.Join(rowErr.Record, ", ")})
+	}
+	return reportTemplate.Execute(w, view)
+}
+
+// formatKey renders the key of a parent record as "column=value, ...".
+func formatKey(columnNames, values []string) string {
+	parts := make([]string, len(columnNames))
+	for idx, columnName := range columnNames {
+		value := ""
+		if idx < len(values) {
+			value = values[idx]
+		}
+		parts[idx] = fmt.Sprintf("%s=%s", columnName, value)
+	}
+	return strings.Join(parts, ", ")
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"seconds": func(d time.Duration) string { return fmt.Sprintf("%.2fs", d.Seconds()) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>db-auto-importer report{{if not .Started.IsZero}} {{.Started.Format "2006-01-02 15:04"}}{{end}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; vertical-align: top; }
+td.num { text-align: right; }
+.status { display: inline-block; padding: 0.2em 0.8em; border-radius: 0.3em; color: #fff; }
+.success { background: #2e7d32; }
+.failure { background: #c62828; }
+.chart { width: 20em; }
+.bar { background: #1565c0; height: 1em; }
+.more { color: #666; }
+</style>
+</head>
+<body>
+<h1>db-auto-importer report</h1>
+<p>{{if .Err}}<span class="status failure">Failed</span>{{else}}<span class="status success">Succeeded</span>{{end}}</p>
+{{with .Err}}<p>{{.}}</p>{{end}}
+<table>
+{{if not .Started.IsZero}}<tr><th>Started</th><td>{{.Started.Format "2006-01-02 15:04:05 MST"}}</td></tr>{{end}}
+<tr><th>Duration</th><td>{{seconds .Elapsed}}</td></tr>
+<tr><th>Tables</th><td>{{len .Tables}}</td></tr>
+<tr><th>Rows read</th><td>{{.RowsRead}}</td></tr>
+<tr><th>Rows inserted</th><td>{{.RowsInserted}}</td></tr>
+<tr><th>Rows updated</th><td>{{.RowsUpdated}}</td></tr>
+<tr><th>Rows rejected</th><td>{{.RowsRejected}}</td></tr>
+<tr><th>Parent records created</th><td>{{.ParentsCreated}}</td></tr>
+{{with .Version}}<tr><th>Version</th><td>{{.}}</td></tr>{{end}}
+</table>
+
+<h2>Tables</h2>
+<table>
+<tr><th>Table</th><th>File</th><th>Read</th><th>Inserted</th><th>Updated</th><th>Skipped</th><th>Rejected</th><th>Parents created</th><th>Time</th><th class="chart"></th></tr>
+{{range .Tables}}<tr><td>{{.Table}}</td><td>{{.File}}</td><td class="num">{{.RowsRead}}</td><td class="num">{{.RowsInserted}}</td><td class="num">{{.RowsUpdated}}</td><td class="num">{{.RowsSkipped}}</td><td class="num">{{.RowsRejected}}</td><td class="num">{{.ParentsCreated}}</td><td class="num">{{seconds .Elapsed}}</td><td class="chart"><div class="bar" style="width: {{printf "%.1f" .Bar}}%"></div></td></tr>
+{{end}}</table>
+
+{{range .Tables}}{{if .Rejected}}
+<h2>Rejected rows of {{.Table}}</h2>
+<table>
+<tr><th>Line</th><th>Error</th><th>Record</th></tr>
+{{range .Rejected}}<tr><td class="num">{{if .Line}}{{.Line}}{{end}}</td><td>{{.Error}}</td><td>{{.Record}}</td></tr>
+{{end}}</table>
+{{if .MoreRejected}}<p class="more">and {{.MoreRejected}} more</p>{{end}}
+{{end}}{{end}}
+{{range .Tables}}{{if .Parents}}
+<h2>Parent records created in {{.Table}}</h2>
+<table>
+<tr><th>Key</th></tr>
+{{range .Parents}}<tr><td>{{.}}</td></tr>
+{{end}}</table>
+{{if gt .MoreParents 0}}<p class="more">and {{.MoreParents}} more</p>{{end}}
+{{end}}{{end}}
+</body>
+</html>
+`))
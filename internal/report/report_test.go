@@ -0,0 +1,52 @@
+package report
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/k-wa-wa/db-auto-importer/internal/importer"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_WriteHTML(t *testing.T) {
+	result := &importer.ImportResult{
+		Tables: []importer.TableSummary{
+			{Table: "organizations", ParentsCreated: 1, Elapsed: time.Second},
+			{Table: "users", File: "users.csv", RowsRead: 3, RowsInserted: 2, RowsRejected: 1, Elapsed: 2 * time.Second},
+		},
+		Rejected: []*database.RowInsertError{
+			{TableName: "users", FilePath: "users.csv", Line: 3, Record: []string{"2", "<Bob>"}, Err: errors.New("duplicate key")},
+		},
+		Elapsed: 3 * time.Second,
+	}
+
+	t.Run("テーブルごとの統計と拒否された行、生成した親レコードを出力すること", func(t *testing.T) {
+		events := make(chan importer.Event, 2)
+		events <- importer.ParentCreated{Table: "organizations", ColumnNames: []string{"id"}, Key: []string{"10"}}
+		events <- importer.TableStarted{Table: "users"}
+		close(events)
+
+		var b strings.Builder
+		err := WriteHTML(&b, Run{Version: "v1.0.0", Result: result, Parents: CollectParents(events)})
+		assert.NoError(t, err)
+		out := b.String()
+		assert.Contains(t, out, "Succeeded")
+		assert.Contains(t, out, "<td>users</td><td>users.csv</td>")
+		assert.Contains(t, out, `<div class="bar" style="width: 100.0%">`)
+		assert.Contains(t, out, `<div class="bar" style="width: 50.0%">`)
+		assert.Contains(t, out, "<td>duplicate key</td><td>2, &lt;Bob&gt;</td>")
+		assert.Contains(t, out, "<td>id=10</td>")
+		assert.Contains(t, out, "v1.0.0")
+	})
+
+	t.Run("失敗した場合はエラーを出力すること", func(t *testing.T) {
+		var b strings.Builder
+		err := WriteHTML(&b, Run{Err: errors.New("connection refused")})
+		assert.NoError(t, err)
+		assert.Contains(t, b.String(), "Failed")
+		assert.Contains(t, b.String(), "connection refused")
+	})
+}
@@ -0,0 +1,251 @@
+// Package rpcserver implements the gRPC ImportService defined in proto/dbimport/v1/import.proto.
+package rpcserver
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/k-wa-wa/db-auto-importer/dbimport/importv1"
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/k-wa-wa/db-auto-importer/internal/importer"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// maxFinishedJobs bounds the finished jobs kept for GetJobStatus; the oldest are forgotten first.
+const maxFinishedJobs = 1000
+
+// Server serves imports through one Importer. Imports run one at a time, since the Importer
+// is not safe for concurrent use.
+type Server struct {
+	importv1.UnimplementedImportServiceServer
+
+	imp       *importer.Importer
+	hasHeader bool // Used when a request does not say whether its CSV data has a header row
+
+	importMu sync.Mutex // Held while an import runs
+
+	mu       sync.Mutex
+	jobs     map[string]*importv1.JobStatus
+	finished []string // IDs of finished jobs, oldest first
+}
+
+// New creates a Server importing through imp.
+func New(imp *importer.Importer, hasHeader bool) *Server {
+	return &Server{imp: imp, hasHeader: hasHeader, jobs: make(map[string]*importv1.JobStatus)}
+}
+
+// ImportTable receives the CSV data of one table into a temporary file and imports it.
+func (s *Server) ImportTable(stream importv1.ImportService_ImportTableServer) error {
+	ctx := stream.Context()
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	header := first.GetHeader()
+	if header == nil {
+		return status.Error(codes.InvalidArgument, "the first message must carry the header")
+	}
+	if _, ok := s.imp.DBSchema[header.GetTable()]; !ok {
+		return status.Errorf(codes.NotFound, "table %q not found in the database schema", header.GetTable())
+	}
+	hasHeader := s.hasHeader
+	if header.HasHeader != nil {
+		hasHeader = *header.HasHeader
+	}
+
+	job, err := s.newJob(header.GetJobId(), header.GetTable())
+	if err != nil {
+		return err
+	}
+
+	// The file is named after the table so that the importer maps it to the table
+	dir, err := os.MkdirTemp("", "db-auto-importer-")
+	if err != nil {
+		s.finish(job.JobId, nil, err)
+		return status.Errorf(codes.Internal, "failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	filePath := filepath.Join(dir, header.GetTable()+".csv")
+	if err := receiveCSV(stream, filePath); err != nil {
+		s.finish(job.JobId, nil, err)
+		return err
+	}
+
+	s.importMu.Lock()
+	defer s.importMu.Unlock()
+	s.update(job.JobId, func(job *importv1.JobStatus) { job.State = importv1.JobStatus_STATE_RUNNING })
+	// The importer's own observer, e.g. for metrics, keeps being notified during the job
+	observer := s.imp.Observer
+	s.imp.Observer = importer.MultiObserver(&jobObserver{s: s, jobID: job.JobId}, observer)
+	defer func() { s.imp.Observer = observer }()
+	result, importErr := s.imp.ImportFiles(ctx, []string{filePath}, hasHeader)
+
+	return stream.SendAndClose(&importv1.ImportTableResponse{Status: s.finish(job.JobId, result, importErr)})
+}
+
+// receiveCSV writes the chunks of the stream to filePath.
+func receiveCSV(stream importv1.ImportService_ImportTableServer, filePath string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to create temporary file: %v", err)
+	}
+	defer file.Close()
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if req.GetHeader() != nil {
+			return status.Error(codes.InvalidArgument, "only the first message may carry the header")
+		}
+		if _, err := file.Write(req.GetChunk()); err != nil {
+			return status.Errorf(codes.Internal, "failed to write temporary file: %v", err)
+		}
+	}
+	if err := file.Close(); err != nil {
+		return status.Errorf(codes.Internal, "failed to write temporary file: %v", err)
+	}
+	return nil
+}
+
+// GetSchema returns the tables of the schema, sorted by name.
+func (s *Server) GetSchema(ctx context.Context, req *importv1.GetSchemaRequest) (*importv1.GetSchemaResponse, error) {
+	names := make([]string, 0, len(s.imp.DBSchema))
+	for name := range s.imp.DBSchema {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	resp := &importv1.GetSchemaResponse{}
+	for _, name := range names {
+		resp.Tables = append(resp.Tables, tableProto(s.imp.DBSchema[name]))
+	}
+	return resp, nil
+}
+
+func tableProto(dbInfo database.DBInfo) *importv1.Table {
+	table := &importv1.Table{Name: dbInfo.TableName, PrimaryKey: dbInfo.PrimaryKeyColumns}
+	for _, col := range dbInfo.Columns {
+		table.Columns = append(table.Columns, &importv1.Column{
+			Name:          col.ColumnName,
+			DataType:      col.DataType.String(),
+			Nullable:      col.IsNullable,
+			AutoIncrement: col.IsAutoIncrement,
+			Generated:     col.IsGenerated,
+		})
+	}
+	for _, fk := range dbInfo.ForeignKeys {
+		table.ForeignKeys = append(table.ForeignKeys, &importv1.ForeignKey{
+			Name:          fk.ConstraintName,
+			Columns:       fk.ColumnNames,
+			ParentTable:   fk.ForeignTableName,
+			ParentColumns: fk.ForeignColumnNames,
+		})
+	}
+	return table
+}
+
+// GetJobStatus returns the status of a job started by ImportTable.
+func (s *Server) GetJobStatus(ctx context.Context, req *importv1.GetJobStatusRequest) (*importv1.JobStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[req.GetJobId()]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "job %q not found", req.GetJobId())
+	}
+	return cloneJob(job), nil
+}
+
+// newJob registers a queued job. An empty id is replaced by a random one.
+func (s *Server) newJob(id, table string) (*importv1.JobStatus, error) {
+	if id == "" {
+		b := make([]byte, 8)
+		if _, err := rand.Read(b); err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to generate job ID: %v", err)
+		}
+		id = hex.EncodeToString(b)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[id]; ok {
+		return nil, status.Errorf(codes.AlreadyExists, "job %q already exists", id)
+	}
+	job := &importv1.JobStatus{JobId: id, Table: table, State: importv1.JobStatus_STATE_QUEUED}
+	s.jobs[id] = job
+	return cloneJob(job), nil
+}
+
+// update modifies a job while holding the lock.
+func (s *Server) update(id string, fn func(job *importv1.JobStatus)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if job, ok := s.jobs[id]; ok {
+		fn(job)
+	}
+}
+
+// finish records the outcome of a job and returns its final status.
+func (s *Server) finish(id string, result *importer.ImportResult, err error) *importv1.JobStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job := s.jobs[id]
+	if result != nil {
+		for _, summary := range result.Tables {
+			if summary.Table == job.Table && summary.File != "" {
+				job.RowsRead = int64(summary.RowsRead)
+				job.RowsInserted = int64(summary.RowsInserted)
+				job.RowsSkipped = int64(summary.RowsSkipped)
+				job.RowsRejected = int64(summary.RowsRejected)
+			}
+		}
+		job.ParentsCreated = int64(result.ParentsCreated())
+	}
+	job.State = importv1.JobStatus_STATE_SUCCEEDED
+	if err != nil {
+		job.State = importv1.JobStatus_STATE_FAILED
+		job.Error = err.Error()
+	}
+
+	s.finished = append(s.finished, id)
+	if len(s.finished) > maxFinishedJobs {
+		delete(s.jobs, s.finished[0])
+		s.finished = s.finished[1:]
+	}
+	return cloneJob(job)
+}
+
+func cloneJob(job *importv1.JobStatus) *importv1.JobStatus {
+	return proto.Clone(job).(*importv1.JobStatus)
+}
+
+// jobObserver keeps the row counts of a running job up to date.
+type jobObserver struct {
+	s     *Server
+	jobID string
+}
+
+func (o *jobObserver) FileStarted(table, filePath string) {}
+
+func (o *jobObserver) RowsProcessed(table string, done, total int) {
+	o.s.update(o.jobID, func(job *importv1.JobStatus) { job.RowsRead = int64(done) })
+}
+
+func (o *jobObserver) RowFailed(err *database.RowInsertError) {
+	o.s.update(o.jobID, func(job *importv1.JobStatus) { job.RowsRejected++ })
+}
+
+func (o *jobObserver) FileFinished(summary importer.TableSummary, err error) {}
+
+func (o *jobObserver) ImportFinished(summaries []importer.TableSummary, err error) {}
@@ -0,0 +1,84 @@
+package rpcserver
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/k-wa-wa/db-auto-importer/dbimport/importv1"
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/k-wa-wa/db-auto-importer/internal/importer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTestServer() *Server {
+	schema := map[string]database.DBInfo{
+		"users": {TableName: "users", PrimaryKeyColumns: []string{"id"}, Columns: []database.ColumnInfo{
+			{ColumnName: "id", DataType: database.IntegerType, IsAutoIncrement: true},
+		}},
+		"orders": {TableName: "orders", PrimaryKeyColumns: []string{"id"}, Columns: []database.ColumnInfo{
+			{ColumnName: "id", DataType: database.IntegerType},
+			{ColumnName: "user_id", DataType: database.IntegerType, IsNullable: true},
+		}, ForeignKeys: []database.ForeignKeyInfo{
+			{ConstraintName: "fk_user", TableName: "orders", ColumnNames: []string{"user_id"}, ForeignTableName: "users", ForeignColumnNames: []string{"id"}},
+		}},
+	}
+	imp, _ := importer.NewImporter(schema, nil)
+	return New(imp, true)
+}
+
+func Test_GetSchema(t *testing.T) {
+	t.Run("テーブルが名前順に返されること", func(t *testing.T) {
+		resp, err := newTestServer().GetSchema(context.Background(), &importv1.GetSchemaRequest{})
+		require.NoError(t, err)
+		require.Len(t, resp.Tables, 2)
+		assert.Equal(t, "orders", resp.Tables[0].Name)
+		assert.Equal(t, "INTEGER", resp.Tables[0].Columns[1].DataType)
+		assert.Equal(t, "users", resp.Tables[0].ForeignKeys[0].ParentTable)
+		assert.True(t, resp.Tables[1].Columns[0].AutoIncrement)
+	})
+}
+
+func Test_GetJobStatus(t *testing.T) {
+	t.Run("終了したジョブの結果が返されること", func(t *testing.T) {
+		s := newTestServer()
+		job, err := s.newJob("job-1", "orders")
+		require.NoError(t, err)
+		assert.Equal(t, importv1.JobStatus_STATE_QUEUED, job.State)
+		s.finish("job-1", &importer.ImportResult{Tables: []importer.TableSummary{
+			{Table: "orders", File: "orders.csv", RowsRead: 3, RowsInserted: 2, RowsRejected: 1},
+			{Table: "users", ParentsCreated: 2},
+		}}, errors.New("1 record(s) could not be imported"))
+
+		got, err := s.GetJobStatus(context.Background(), &importv1.GetJobStatusRequest{JobId: "job-1"})
+		require.NoError(t, err)
+		assert.Equal(t, importv1.JobStatus_STATE_FAILED, got.State)
+		assert.Equal(t, int64(2), got.RowsInserted)
+		assert.Equal(t, int64(2), got.ParentsCreated)
+		assert.Equal(t, "1 record(s) could not be imported", got.Error)
+	})
+	t.Run("存在しないジョブはNotFoundになること", func(t *testing.T) {
+		_, err := newTestServer().GetJobStatus(context.Background(), &importv1.GetJobStatusRequest{JobId: "missing"})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+	t.Run("同じIDのジョブは作成できないこと", func(t *testing.T) {
+		s := newTestServer()
+		_, err := s.newJob("job-1", "orders")
+		require.NoError(t, err)
+		_, err = s.newJob("job-1", "orders")
+		assert.Equal(t, codes.AlreadyExists, status.Code(err))
+	})
+	t.Run("古いジョブから破棄されること", func(t *testing.T) {
+		s := newTestServer()
+		for n := 0; n <= maxFinishedJobs; n++ {
+			job, err := s.newJob("", "users")
+			require.NoError(t, err)
+			s.finish(job.JobId, nil, nil)
+		}
+		assert.Len(t, s.jobs, maxFinishedJobs)
+	})
+}
@@ -0,0 +1,130 @@
+// Package schedule parses cron-style schedules for the daemon command.
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed five-field cron expression: minute, hour, day of month, month and
+// day of week. Each field accepts "*", values, ranges ("1-5"), steps ("*/15", "0-30/10")
+// and comma-separated lists of these. Day of week runs from 0 (Sunday) to 6; 7 is also Sunday.
+type Schedule struct {
+	expr                          string
+	minute, hour, dom, month, dow uint64 // Bit sets of the allowed values
+	domAny, dowAny                bool   // The field was "*"
+}
+
+type field struct {
+	name     string
+	min, max int
+}
+
+var fields = []field{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// Parse parses a cron expression such as "0 3 * * 1-5".
+func Parse(expr string) (*Schedule, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("invalid schedule %q: expected 5 fields (minute hour day-of-month month day-of-week), got %d", expr, len(parts))
+	}
+	sets := make([]uint64, len(fields))
+	for idx, part := range parts {
+		set, err := parseField(part, fields[idx])
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %w", expr, err)
+		}
+		sets[idx] = set
+	}
+	s := &Schedule{
+		expr:   expr,
+		minute: sets[0], hour: sets[1], dom: sets[2], month: sets[3], dow: sets[4],
+		domAny: parts[2] == "*", dowAny: parts[4] == "*",
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1 // 7 is Sunday, like 0
+	}
+	return s, nil
+}
+
+func parseField(part string, f field) (uint64, error) {
+	var set uint64
+	for _, item := range strings.Split(part, ",") {
+		rangePart, step := item, 1
+		if before, after, ok := strings.Cut(item, "/"); ok {
+			n, err := strconv.Atoi(after)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q in %s field", after, f.name)
+			}
+			rangePart, step = before, n
+		}
+
+		low, high := f.min, f.max
+		if rangePart != "*" {
+			lowStr, highStr, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = strconv.Atoi(lowStr); err != nil {
+				return 0, fmt.Errorf("invalid value %q in %s field", lowStr, f.name)
+			}
+			high = low
+			if isRange {
+				if high, err = strconv.Atoi(highStr); err != nil {
+					return 0, fmt.Errorf("invalid value %q in %s field", highStr, f.name)
+				}
+			} else if step > 1 {
+				high = f.max // "5/15" means from 5 to the end in steps of 15
+			}
+		}
+		if low < f.min || high > f.max || low > high {
+			return 0, fmt.Errorf("%s field value %q is out of range %d-%d", f.name, rangePart, f.min, f.max)
+		}
+		for v := low; v <= high; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+func (s *Schedule) String() string { return s.expr }
+
+// Next returns the first time after t that matches the schedule, in t's location.
+// It returns the zero time if nothing matches within five years (e.g. "0 0 31 2 *").
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			// Step in wall-clock hours: Truncate rounds in absolute time, which misses the
+			// hour boundaries of zones with a half-hour offset.
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches applies the cron rule that, if both day fields are restricted, either may match.
+func (s *Schedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domAny || s.dowAny {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
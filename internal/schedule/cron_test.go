@@ -0,0 +1,51 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Schedule_Next(t *testing.T) {
+	base := time.Date(2024, 5, 15, 10, 7, 30, 0, time.UTC) // Wednesday
+	tests := []struct {
+		name string
+		expr string
+		want time.Time
+	}{
+		{"毎分は次の分になること", "* * * * *", time.Date(2024, 5, 15, 10, 8, 0, 0, time.UTC)},
+		{"15分ごとは次の15分単位になること", "*/15 * * * *", time.Date(2024, 5, 15, 10, 15, 0, 0, time.UTC)},
+		{"毎日3時は翌日の3時になること", "0 3 * * *", time.Date(2024, 5, 16, 3, 0, 0, 0, time.UTC)},
+		{"平日のみは土日を飛ばすこと", "0 9 * * 1-5", time.Date(2024, 5, 16, 9, 0, 0, 0, time.UTC)},
+		{"日曜は7でも指定できること", "0 0 * * 7", time.Date(2024, 5, 19, 0, 0, 0, 0, time.UTC)},
+		{"月と日の指定で翌年になること", "30 1 1 1 *", time.Date(2025, 1, 1, 1, 30, 0, 0, time.UTC)},
+		{"日と曜日の両方を指定した場合はどちらかに一致すること", "0 0 20 * 5", time.Date(2024, 5, 17, 0, 0, 0, 0, time.UTC)},
+		{"一致しない場合はゼロ値を返すこと", "0 0 31 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, s.Next(base))
+		})
+	}
+
+	t.Run("30分ずれたタイムゾーンでも指定の時刻になること", func(t *testing.T) {
+		kolkata := time.FixedZone("IST", 5*60*60+30*60)
+		s, err := Parse("0 12 * * *")
+		require.NoError(t, err)
+		next := s.Next(time.Date(2024, 5, 15, 10, 7, 30, 0, kolkata))
+		assert.True(t, time.Date(2024, 5, 15, 12, 0, 0, 0, kolkata).Equal(next), "got %v", next)
+	})
+}
+
+func Test_Parse(t *testing.T) {
+	for _, expr := range []string{"* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		t.Run(expr+"はエラーになること", func(t *testing.T) {
+			_, err := Parse(expr)
+			assert.Error(t, err)
+		})
+	}
+}
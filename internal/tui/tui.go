@@ -0,0 +1,474 @@
+// Package tui is the interactive terminal UI of an import. It shows the progress, rejected rows
+// and throughput of each table while the import runs, and lets the user pause, resume and abort
+// the imports of individual tables.
+package tui
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/k-wa-wa/db-auto-importer/internal/importer"
+	"golang.org/x/term"
+)
+
+// refreshInterval is the time between two redraws of the screen.
+const refreshInterval = 250 * time.Millisecond
+
+// maxLogLines is the number of log lines kept while the UI runs. They are shown below the
+// tables and written to the log output once the UI stops.
+const maxLogLines = 1000
+
+// tableStatus is the state of the import of a table, as shown in the UI.
+type tableStatus string
+
+const (
+	statusPending  tableStatus = "pending"
+	statusRunning  tableStatus = "running"
+	statusPaused   tableStatus = "paused"
+	statusAborting tableStatus = "aborting"
+	statusAborted  tableStatus = "aborted"
+	statusDone     tableStatus = "done"
+	statusFailed   tableStatus = "failed"
+)
+
+// tableState is what the UI knows about the import of a table.
+type tableState struct {
+	name     string
+	started  time.Time
+	finished time.Time
+	done     int // Rows processed, including those of a resumed run
+	total    int
+	rejected int
+	running  bool
+	ended    tableStatus // Set once the import of the table has ended
+	rate     float64     // Rows per second over the last sampling period
+	// sampleDone and sampleTime are the rows processed at the start of the sampling period
+	sampleDone int
+	sampleTime time.Time
+}
+
+// UI is the interactive terminal UI of an import. It is an importer.Observer and drives the
+// importer's TableControl from the keys the user presses.
+type UI struct {
+	in      *os.File
+	out     *os.File
+	control *importer.TableControl
+	cancel  context.CancelFunc // Cancels the whole import
+
+	now func() time.Time // Replaced in tests
+
+	mu        sync.Mutex
+	tables    []*tableState
+	byName    map[string]*tableState
+	selected  int
+	logs      []string
+	dropped   int // Log lines dropped from logs because of maxLogLines
+	partial   []byte
+	started   time.Time
+	quitting  bool
+	stopped   bool
+	logOutput io.Writer // The log output before the UI started
+	oldState  *term.State
+	done      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// New creates a UI reading keys from in and drawing on out, which must both be terminals.
+// Quitting cancels the import with cancel.
+func New(in, out *os.File, cancel context.CancelFunc) *UI {
+	return &UI{
+		in:      in,
+		out:     out,
+		control: importer.NewTableControl(),
+		cancel:  cancel,
+		now:     time.Now,
+		byName:  make(map[string]*tableState),
+	}
+}
+
+// Control returns the TableControl the UI pauses and aborts tables with.
+func (u *UI) Control() *importer.TableControl {
+	return u.control
+}
+
+// SetTables lists the tables to import, in import order, before their imports start. Tables
+// without a file are left out.
+func (u *UI) SetTables(tables []importer.PlannedTable) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	for _, t := range tables {
+		if t.File != "" {
+			u.table(t.Table)
+		}
+	}
+}
+
+// table returns the state of a table, adding it if needed. u.mu must be held.
+func (u *UI) table(name string) *tableState {
+	t, ok := u.byName[name]
+	if !ok {
+		t = &tableState{name: name}
+		u.byName[name] = t
+		u.tables = append(u.tables, t)
+	}
+	return t
+}
+
+// Start switches the terminal to raw mode and the alternate screen, captures the log output and
+// starts drawing. Stop must be called once the import is done.
+func (u *UI) Start() error {
+	oldState, err := term.MakeRaw(int(u.in.Fd()))
+	if err != nil {
+		return fmt.Errorf("failed to set up the terminal: %w", err)
+	}
+	u.mu.Lock()
+	u.oldState = oldState
+	u.started = u.now()
+	u.done = make(chan struct{})
+	u.logOutput = log.Writer()
+	u.mu.Unlock()
+	log.SetOutput(u)
+	fmt.Fprint(u.out, "\033[?1049h\033[?25l") // Alternate screen, hidden cursor
+
+	u.wg.Add(1)
+	go func() {
+		defer u.wg.Done()
+		ticker := time.NewTicker(refreshInterval)
+		defer ticker.Stop()
+		for {
+			u.draw()
+			select {
+			case <-ticker.C:
+			case <-u.done:
+				return
+			}
+		}
+	}()
+	go u.readKeys()
+	return nil
+}
+
+// Stop restores the terminal and the log output, then writes the log lines kept while the UI ran.
+func (u *UI) Stop() {
+	u.mu.Lock()
+	if u.stopped || u.done == nil {
+		u.mu.Unlock()
+		return
+	}
+	// No frame is drawn once stopped is set
+	u.stopped = true
+	close(u.done)
+	u.mu.Unlock()
+	u.wg.Wait()
+
+	fmt.Fprint(u.out, "\033[?25h\033[?1049l") // Visible cursor, main screen
+	term.Restore(int(u.in.Fd()), u.oldState)
+	log.SetOutput(u.logOutput)
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.dropped > 0 {
+		fmt.Fprintf(u.logOutput, "(%d earlier log lines written during the import are not shown)\n", u.dropped)
+	}
+	for _, line := range u.logs {
+		fmt.Fprintln(u.logOutput, line)
+	}
+	if len(u.partial) > 0 {
+		fmt.Fprintln(u.logOutput, string(u.partial))
+	}
+}
+
+// Write keeps the log lines written while the UI runs.
+func (u *UI) Write(p []byte) (int, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.partial = append(u.partial, p...)
+	for {
+		idx := bytes.IndexByte(u.partial, '\n')
+		if idx < 0 {
+			break
+		}
+		u.logs = append(u.logs, string(u.partial[:idx]))
+		u.partial = u.partial[idx+1:]
+	}
+	if extra := len(u.logs) - maxLogLines; extra > 0 {
+		u.dropped += extra
+		u.logs = append(u.logs[:0:0], u.logs[extra:]...)
+	}
+	return len(p), nil
+}
+
+// readKeys handles the keys pressed until the UI stops. The read that is pending when it stops
+// returns with the next key, which is then ignored.
+func (u *UI) readKeys() {
+	buf := make([]byte, 16)
+	for {
+		n, err := u.in.Read(buf)
+		u.mu.Lock()
+		stopped := u.stopped
+		u.mu.Unlock()
+		if err != nil || stopped {
+			return
+		}
+		for _, key := range parseKeys(buf[:n]) {
+			u.handleKey(key)
+		}
+		u.draw()
+	}
+}
+
+// Keys the UI responds to.
+const (
+	keyUp    = "up"
+	keyDown  = "down"
+	keyPause = "pause"
+	keyAbort = "abort"
+	keyQuit  = "quit"
+)
+
+// parseKeys translates the bytes read from the terminal into keys, ignoring the others.
+func parseKeys(data []byte) []string {
+	var keys []string
+	for len(data) > 0 {
+		switch {
+		case bytes.HasPrefix(data, []byte("\033[A")):
+			keys, data = append(keys, keyUp), data[3:]
+			continue
+		case bytes.HasPrefix(data, []byte("\033[B")):
+			keys, data = append(keys, keyDown), data[3:]
+			continue
+		}
+		switch data[0] {
+		case 'k':
+			keys = append(keys, keyUp)
+		case 'j':
+			keys = append(keys, keyDown)
+		case 'p', ' ':
+			keys = append(keys, keyPause)
+		case 'a':
+			keys = append(keys, keyAbort)
+		case 'q', 3: // 3 is Ctrl+C, which raw mode does not turn into a signal
+			keys = append(keys, keyQuit)
+		}
+		data = data[1:]
+	}
+	return keys
+}
+
+// handleKey acts on a key.
+func (u *UI) handleKey(key string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	var selected *tableState
+	if u.selected < len(u.tables) {
+		selected = u.tables[u.selected]
+	}
+	switch key {
+	case keyUp:
+		if u.selected > 0 {
+			u.selected--
+		}
+	case keyDown:
+		if u.selected < len(u.tables)-1 {
+			u.selected++
+		}
+	case keyPause:
+		if selected == nil || selected.ended != "" || u.control.Aborted(selected.name) {
+			return
+		}
+		if u.control.Paused(selected.name) {
+			u.control.Resume(selected.name)
+		} else {
+			u.control.Pause(selected.name)
+		}
+	case keyAbort:
+		if selected != nil && selected.ended == "" {
+			u.control.Abort(selected.name)
+		}
+	case keyQuit:
+		if !u.quitting {
+			u.quitting = true
+			u.cancel()
+		}
+	}
+}
+
+// FileStarted implements importer.Observer.
+func (u *UI) FileStarted(table, filePath string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	t := u.table(table)
+	t.running = true
+	t.started = u.now()
+	t.sampleTime = t.started
+}
+
+// RowsProcessed implements importer.Observer.
+func (u *UI) RowsProcessed(table string, done, total int) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	t := u.table(table)
+	if t.done == 0 {
+		// The rows of the run being resumed are not part of the throughput
+		t.sampleDone = done - 1
+	}
+	t.done, t.total = done, total
+}
+
+// RowFailed implements importer.Observer.
+func (u *UI) RowFailed(err *database.RowInsertError) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.table(err.TableName).rejected++
+}
+
+// FileFinished implements importer.Observer.
+func (u *UI) FileFinished(summary importer.TableSummary, err error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	t := u.table(summary.Table)
+	t.running = false
+	t.finished = u.now()
+	t.rate = 0
+	switch {
+	case err != nil:
+		t.ended = statusFailed
+	case summary.Aborted:
+		t.ended = statusAborted
+	default:
+		t.ended = statusDone
+	}
+}
+
+// ImportFinished implements importer.Observer.
+func (u *UI) ImportFinished(summaries []importer.TableSummary, err error) {}
+
+// draw redraws the screen.
+func (u *UI) draw() {
+	width, height, err := term.GetSize(int(u.out.Fd()))
+	if err != nil {
+		width, height = 80, 24
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.stopped {
+		return
+	}
+	io.WriteString(u.out, u.render(width, height))
+}
+
+// status returns the status of a table shown in the UI. u.mu must be held.
+func (u *UI) status(t *tableState) tableStatus {
+	switch {
+	case t.ended != "":
+		return t.ended
+	case u.control.Aborted(t.name):
+		return statusAborting
+	case u.control.Paused(t.name):
+		return statusPaused
+	case t.running:
+		return statusRunning
+	default:
+		return statusPending
+	}
+}
+
+// render returns the frame of a screen of the given size, sampling the throughput of the
+// running tables. u.mu must be held.
+func (u *UI) render(width, height int) string {
+	now := u.now()
+	var totalRows, totalRejected int
+	var totalRate float64
+	for _, t := range u.tables {
+		if t.running && now.Sub(t.sampleTime) >= time.Second {
+			t.rate = float64(t.done-t.sampleDone) / now.Sub(t.sampleTime).Seconds()
+			t.sampleDone, t.sampleTime = t.done, now
+		}
+		totalRows += t.done
+		totalRejected += t.rejected
+		totalRate += t.rate
+	}
+
+	var lines []string
+	header := fmt.Sprintf("db-auto-importer  elapsed %s  rows %d  rejected %d  %.0f rows/s", now.Sub(u.started).Round(time.Second), totalRows, totalRejected, totalRate)
+	if u.quitting {
+		header += "  (quitting...)"
+	}
+	lines = append(lines, header, "")
+	lines = append(lines, fmt.Sprintf("  %-24s %-9s %-21s %-14s %9s %10s %9s", "TABLE", "STATUS", "ROWS", "PROGRESS", "REJECTED", "ROWS/S", "ELAPSED"))
+
+	// Below the tables: a blank line, the help line, a blank line and at least 3 log lines
+	visible := max(height-len(lines)-6, 1)
+	first := 0
+	if u.selected >= visible {
+		first = u.selected - visible + 1
+	}
+	for idx := first; idx < len(u.tables) && idx < first+visible; idx++ {
+		t := u.tables[idx]
+		cursor := " "
+		if idx == u.selected {
+			cursor = ">"
+		}
+		rows, progress, elapsed := "", "", ""
+		if t.done > 0 || t.running || t.ended != "" {
+			rows = fmt.Sprintf("%d/%d", t.done, t.total)
+			progress = progressBar(t.done, t.total)
+		}
+		if !t.started.IsZero() {
+			end := now
+			if !t.finished.IsZero() {
+				end = t.finished
+			}
+			elapsed = end.Sub(t.started).Round(time.Second).String()
+		}
+		rate := ""
+		if t.running {
+			rate = fmt.Sprintf("%.0f", t.rate)
+		}
+		lines = append(lines, fmt.Sprintf("%s %-24s %-9s %-21s %-14s %9d %10s %9s", cursor, truncate(t.name, 24), u.status(t), rows, progress, t.rejected, rate, elapsed))
+	}
+	lines = append(lines, "", "[↑/↓] select  [p] pause/resume  [a] abort table  [q] quit import", "")
+
+	logLines := max(height-len(lines), 0)
+	start := max(len(u.logs)-logLines, 0)
+	lines = append(lines, u.logs[start:]...)
+
+	var b strings.Builder
+	b.WriteString("\033[H") // Home; each line clears what is left of the previous frame
+	for idx, line := range lines {
+		if idx > 0 {
+			b.WriteString("\r\n")
+		}
+		b.WriteString(truncate(line, width))
+		b.WriteString("\033[K")
+	}
+	b.WriteString("\033[J")
+	return b.String()
+}
+
+// progressBar draws done/total as a bar of 8 cells followed by the percentage.
+func progressBar(done, total int) string {
+	percent := 100
+	if total > 0 {
+		percent = min(done*100/total, 100)
+	}
+	filled := percent * 8 / 100
+	return fmt.Sprintf("%s%s %3d%%", strings.Repeat("#", filled), strings.Repeat(".", 8-filled), percent)
+}
+
+// truncate shortens s to width characters.
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	return string(runes[:max(width, 0)])
+}
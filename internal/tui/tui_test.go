@@ -0,0 +1,93 @@
+package tui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/k-wa-wa/db-auto-importer/internal/database"
+	"github.com/k-wa-wa/db-auto-importer/internal/importer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseKeys(t *testing.T) {
+	assert.Equal(t, []string{keyUp, keyDown, keyPause, keyAbort, keyQuit, keyDown, keyPause, keyQuit},
+		parseKeys([]byte("\033[A\033[Bpaqj x\x03")))
+}
+
+func Test_UI(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	cancelled := false
+	u := New(nil, nil, func() { cancelled = true })
+	u.now = func() time.Time { return now }
+	u.started = now
+	u.SetTables([]importer.PlannedTable{{Table: "users", File: "users.csv"}, {Table: "tags"}, {Table: "orders", File: "orders.csv"}})
+
+	// lines renders a frame and returns its lines without the escape sequences
+	lines := func() []string {
+		frame := u.render(120, 20)
+		for _, seq := range []string{"\033[H", "\033[K", "\033[J"} {
+			frame = strings.ReplaceAll(frame, seq, "")
+		}
+		return strings.Split(frame, "\r\n")
+	}
+
+	t.Run("ファイルのあるテーブルが待機中として表示されること", func(t *testing.T) {
+		frame := lines()
+		require.Len(t, frame, 8)
+		assert.Regexp(t, `^> users +pending`, frame[3])
+		assert.Regexp(t, `^  orders +pending`, frame[4])
+	})
+
+	t.Run("進捗、拒否された行数と速度が表示されること", func(t *testing.T) {
+		u.FileStarted("users", "users.csv")
+		for done := 1; done <= 50; done++ {
+			u.RowsProcessed("users", done, 200)
+		}
+		u.RowFailed(&database.RowInsertError{TableName: "users", Err: errors.New("duplicate key")})
+		now = now.Add(2 * time.Second)
+
+		frame := lines()
+		assert.Contains(t, frame[0], "rows 50  rejected 1  25 rows/s")
+		assert.Regexp(t, `^> users +running +50/200 +##\.\.\.\.\.\.  25% +1 +25 +2s`, frame[3])
+	})
+
+	t.Run("選択したテーブルを一時停止、再開、中止できること", func(t *testing.T) {
+		u.handleKey(keyPause)
+		assert.True(t, u.Control().Paused("users"))
+		assert.Regexp(t, `^> users +paused`, lines()[3])
+		u.handleKey(keyPause)
+		assert.False(t, u.Control().Paused("users"))
+
+		u.handleKey(keyDown)
+		u.handleKey(keyAbort)
+		assert.True(t, u.Control().Aborted("orders"))
+		assert.False(t, u.Control().Aborted("users"))
+		assert.Regexp(t, `^> orders +aborting`, lines()[4])
+	})
+
+	t.Run("終了したテーブルは結果が表示され操作できないこと", func(t *testing.T) {
+		u.FileFinished(importer.TableSummary{Table: "users"}, nil)
+		u.FileFinished(importer.TableSummary{Table: "orders", Aborted: true}, nil)
+		frame := lines()
+		assert.Regexp(t, `^  users +done`, frame[3])
+		assert.Regexp(t, `^> orders +aborted`, frame[4])
+
+		u.handleKey(keyUp)
+		u.handleKey(keyPause)
+		assert.False(t, u.Control().Paused("users"))
+	})
+
+	t.Run("ログの末尾が表示され、終了でインポートがキャンセルされること", func(t *testing.T) {
+		u.Write([]byte("first line\nsecond "))
+		u.Write([]byte("line\n"))
+		frame := lines()
+		assert.Equal(t, []string{"first line", "second line"}, frame[len(frame)-2:])
+
+		u.handleKey(keyQuit)
+		assert.True(t, cancelled)
+		assert.Contains(t, lines()[0], "(quitting...)")
+	})
+}
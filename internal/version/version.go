@@ -0,0 +1,41 @@
+// Package version holds the build information of the binary. The variables are set at
+// build time, e.g.
+//
+//	go build -ldflags "-X github.com/k-wa-wa/db-auto-importer/internal/version.Version=v1.2.0 \
+//	  -X github.com/k-wa-wa/db-auto-importer/internal/version.Commit=$(git rev-parse --short HEAD) \
+//	  -X github.com/k-wa-wa/db-auto-importer/internal/version.Date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+package version
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+var (
+	Version = "dev"
+	Commit  = ""
+	Date    = ""
+)
+
+// String returns the version, commit and build date in one line. Without ldflags, the
+// commit and date recorded by the Go toolchain are used when available.
+func String() string {
+	commit, date := Commit, Date
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range info.Settings {
+			switch {
+			case s.Key == "vcs.revision" && commit == "":
+				commit = s.Value
+			case s.Key == "vcs.time" && date == "":
+				date = s.Value
+			}
+		}
+	}
+	if commit == "" {
+		commit = "unknown"
+	}
+	if date == "" {
+		date = "unknown"
+	}
+	return fmt.Sprintf("%s (commit %s, built %s)", Version, commit, date)
+}